@@ -64,11 +64,18 @@ type anthropicMessagesRequest struct {
 	TopK          int32              `json:"top_k,omitempty"`
 	Stream        bool               `json:"stream,omitempty"`
 	StopSequences []string           `json:"stop_sequences,omitempty"`
+	Thinking      *anthropicThinking `json:"thinking,omitempty"`
 
 	// These are not accepted from the client an instead are only used to talk
 	// to the upstream LLM APIs.
 	Metadata *anthropicMessagesRequestMetadata `json:"metadata,omitempty"`
-	System   string                            `json:"system,omitempty"`
+	System   []anthropicMessageContent         `json:"system,omitempty"`
+}
+
+// anthropicThinking enables extended thinking, it is forwarded as-is.
+type anthropicThinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
 }
 
 type anthropicMessage struct {
@@ -79,6 +86,13 @@ type anthropicMessage struct {
 type anthropicMessageContent struct {
 	Type string `json:"type"` // "text" or "image" (not yet supported)
 	Text string `json:"text"`
+
+	// CacheControl, if set, marks the end of a cacheable prompt prefix.
+	CacheControl *anthropicCacheControl `json:"cache_control,omitempty"`
+}
+
+type anthropicCacheControl struct {
+	Type string `json:"type"` // only "ephemeral" is supported
 }
 
 type anthropicMessagesRequestMetadata struct {
@@ -146,6 +160,10 @@ type anthropicMessagesStreamingResponseTextBucket struct {
 type anthropicMessagesResponseUsage struct {
 	InputTokens  int `json:"input_tokens"`
 	OutputTokens int `json:"output_tokens"`
+
+	// Only set if prompt caching was used.
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
 }
 
 type AnthropicMessagesHandlerMethods struct {
@@ -195,9 +213,9 @@ func (a *AnthropicMessagesHandlerMethods) transformBody(body *anthropicMessagesR
 	body.Model = strings.TrimPrefix(body.Model, "anthropic/")
 
 	// Convert the eventual first message from `system` to a top-level system prompt
-	body.System = "" // prevent the upstream API from setting this
+	body.System = nil // prevent the upstream API from setting this
 	if len(body.Messages) > 0 && body.Messages[0].Role == "system" {
-		body.System = body.Messages[0].Content[0].Text
+		body.System = body.Messages[0].Content
 		body.Messages = body.Messages[1:]
 	}
 }
@@ -213,6 +231,10 @@ func (a *AnthropicMessagesHandlerMethods) transformRequest(downstreamRequest, up
 	upstreamRequest.Header.Set("Content-Type", "application/json")
 	upstreamRequest.Header.Set("X-API-Key", a.config.AccessToken)
 	upstreamRequest.Header.Set("anthropic-version", "2023-06-01")
+	// Prompt caching is the only beta feature we allow clients to opt into.
+	if downstreamRequest.Header.Get("anthropic-beta") == anthropic.PromptCachingBeta {
+		upstreamRequest.Header.Set("anthropic-beta", anthropic.PromptCachingBeta)
+	}
 }
 
 func (a *AnthropicMessagesHandlerMethods) parseResponseAndUsage(logger log.Logger, body anthropicMessagesRequest, r io.Reader, isStreamRequest bool) (promptUsage, completionUsage usageStats) {
@@ -228,7 +250,9 @@ func (a *AnthropicMessagesHandlerMethods) parseResponseAndUsage(logger log.Logge
 	if err == nil {
 		promptUsage.tokenizerTokens = len(promptUsageTokens)
 	}
-	promptUsage.characters += len(body.System)
+	for _, c := range body.System {
+		promptUsage.characters += len(c.Text)
+	}
 
 	// Try to parse the request we saw, if it was non-streaming, we can simply parse
 	// it as JSON.
@@ -251,6 +275,8 @@ func (a *AnthropicMessagesHandlerMethods) parseResponseAndUsage(logger log.Logge
 		// Extract prompt usage data from the response
 		completionUsage.tokens = res.Usage.OutputTokens
 		promptUsage.tokens = res.Usage.InputTokens
+		promptUsage.cacheCreationTokens = res.Usage.CacheCreationInputTokens
+		promptUsage.cacheReadTokens = res.Usage.CacheReadInputTokens
 
 		return promptUsage, completionUsage
 	}
@@ -276,6 +302,8 @@ func (a *AnthropicMessagesHandlerMethods) parseResponseAndUsage(logger log.Logge
 		case "message_start":
 			if event.Message != nil && event.Message.Usage != nil {
 				promptUsage.tokens = event.Message.Usage.InputTokens
+				promptUsage.cacheCreationTokens = event.Message.Usage.CacheCreationInputTokens
+				promptUsage.cacheReadTokens = event.Message.Usage.CacheReadInputTokens
 			}
 		case "content_block_delta":
 			if event.Delta != nil {
@@ -33,6 +33,12 @@ type googleGenerationConfig struct {
 	StopSequences   []string `json:"stopSequences,omitempty"`   // request.StopSequences
 	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"` // request.MaxTokensToSample
 	CandidateCount  int      `json:"candidateCount,omitempty"`  // request.CandidateCount
+
+	ThinkingConfig *googleThinkingConfig `json:"thinkingConfig,omitempty"`
+}
+
+type googleThinkingConfig struct {
+	ThinkingBudget int `json:"thinkingBudget"`
 }
 
 type googleResponse struct {
@@ -0,0 +1,182 @@
+package completions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sourcegraph/log"
+
+	"github.com/sourcegraph/sourcegraph/cmd/cody-gateway/shared/config"
+	"github.com/sourcegraph/sourcegraph/internal/completions/client/openai"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"github.com/sourcegraph/sourcegraph/cmd/cody-gateway/internal/events"
+	"github.com/sourcegraph/sourcegraph/cmd/cody-gateway/internal/limiter"
+	"github.com/sourcegraph/sourcegraph/cmd/cody-gateway/internal/notify"
+	"github.com/sourcegraph/sourcegraph/internal/codygateway"
+	"github.com/sourcegraph/sourcegraph/internal/conf/conftypes"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+)
+
+func NewMistralHandler(baseLogger log.Logger, eventLogger events.Logger, rs limiter.RedisStore, rateLimitNotifier notify.RateLimitNotifier, httpClient httpcli.Doer, config config.MistralConfig, promptRecorder PromptRecorder, upstreamConfig UpstreamHandlerConfig) http.Handler {
+	return makeUpstreamHandler[mistralRequest](
+		baseLogger,
+		eventLogger,
+		rs,
+		rateLimitNotifier,
+		httpClient,
+		string(conftypes.CompletionsProviderNameMistral),
+		config.AllowedModels,
+		&MistralHandlerMethods{config: config},
+		promptRecorder,
+		upstreamConfig,
+	)
+}
+
+// mistralRequest captures fields from https://docs.mistral.ai/api/#tag/chat and
+// https://docs.mistral.ai/api/#tag/fim. Chat requests set Messages, code
+// completions (fill-in-the-middle) requests set Prompt and Suffix.
+type mistralRequest struct {
+	Model       string    `json:"model"`
+	Messages    []message `json:"messages,omitempty"`
+	Prompt      string    `json:"prompt,omitempty"`
+	Suffix      string    `json:"suffix,omitempty"`
+	Temperature float32   `json:"temperature,omitempty"`
+	TopP        float32   `json:"top_p,omitempty"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Stop        []string  `json:"stop,omitempty"`
+	Stream      bool      `json:"stream,omitempty"`
+}
+
+func (r mistralRequest) ShouldStream() bool {
+	return r.Stream
+}
+
+func (r mistralRequest) GetModel() string {
+	return r.Model
+}
+
+func (r mistralRequest) BuildPrompt() string {
+	if r.Prompt != "" || r.Suffix != "" {
+		return r.Prompt + "\n" + r.Suffix
+	}
+	var sb strings.Builder
+	for _, m := range r.Messages {
+		sb.WriteString(m.Content + "\n")
+	}
+	return sb.String()
+}
+
+type mistralResponse struct {
+	Choices []struct {
+		Message      message           `json:"message"`
+		Delta        openaiChoiceDelta `json:"delta"`
+		FinishReason string            `json:"finish_reason"`
+	} `json:"choices"`
+	Usage openaiUsage `json:"usage"`
+}
+
+type MistralHandlerMethods struct {
+	config config.MistralConfig
+}
+
+func (*MistralHandlerMethods) getAPIURL(feature codygateway.Feature, _ mistralRequest) string {
+	if feature == codygateway.FeatureCodeCompletions {
+		return "https://api.mistral.ai/v1/fim/completions"
+	}
+	return "https://api.mistral.ai/v1/chat/completions"
+}
+
+func (*MistralHandlerMethods) validateRequest(_ context.Context, _ log.Logger, feature codygateway.Feature, _ mistralRequest) error {
+	if feature == codygateway.FeatureEmbeddings {
+		return errors.Newf("feature %q is currently not supported for Mistral", feature)
+	}
+	return nil
+}
+
+func (m *MistralHandlerMethods) shouldFlagRequest(_ context.Context, _ log.Logger, req mistralRequest) (*flaggingResult, error) {
+	return isFlaggedRequest(
+		nil, // tokenizer, meaning token counts aren't considered when for flagging consideration.
+		flaggingRequest{
+			ModelName:       req.Model,
+			FlattenedPrompt: req.BuildPrompt(),
+			MaxTokens:       req.MaxTokens,
+		},
+		makeFlaggingConfig(m.config.FlaggingConfig))
+}
+
+func (*MistralHandlerMethods) transformBody(_ *mistralRequest, _ string) {}
+
+func (*MistralHandlerMethods) getRequestMetadata(body mistralRequest) (model string, additionalMetadata map[string]any) {
+	return body.Model, map[string]any{"stream": body.Stream}
+}
+
+func (m *MistralHandlerMethods) transformRequest(_, upstreamRequest *http.Request) {
+	upstreamRequest.Header.Set("Content-Type", "application/json")
+	upstreamRequest.Header.Set("Authorization", "Bearer "+m.config.AccessToken)
+}
+
+func (*MistralHandlerMethods) parseResponseAndUsage(logger log.Logger, reqBody mistralRequest, r io.Reader, isStreamRequest bool) (promptUsage, completionUsage usageStats) {
+	// First, extract prompt usage details from the request.
+	promptUsage.characters = len(reqBody.BuildPrompt())
+	promptUsage.tokenizerTokens = -1
+	completionUsage.tokenizerTokens = -1
+
+	// Try to parse the request we saw, if it was non-streaming, we can simply parse
+	// it as JSON.
+	if !isStreamRequest {
+		var res mistralResponse
+		if err := json.NewDecoder(r).Decode(&res); err != nil {
+			logger.Error("failed to parse Mistral response as JSON", log.Error(err))
+			return promptUsage, completionUsage
+		}
+		promptUsage.tokens = res.Usage.PromptTokens
+		completionUsage.tokens = res.Usage.CompletionTokens
+		if len(res.Choices) > 0 {
+			completionUsage.characters = len(res.Choices[0].Message.Content)
+		}
+		return promptUsage, completionUsage
+	}
+
+	// Otherwise, we have to parse the event stream. Mistral reports usage
+	// in the last event.
+	promptUsage.tokens = -1
+	completionUsage.tokens = -1
+
+	dec := openai.NewDecoder(r)
+	for dec.Scan() {
+		data := dec.Data()
+
+		// Gracefully skip over any data that isn't JSON-like.
+		if !bytes.HasPrefix(data, []byte("{")) {
+			continue
+		}
+
+		var event mistralResponse
+		if err := json.Unmarshal(data, &event); err != nil {
+			logger.Error("failed to decode event payload", log.Error(err), log.String("body", string(data)))
+			continue
+		}
+		if len(event.Choices) > 0 {
+			completionUsage.characters += len(event.Choices[0].Delta.Content)
+		}
+		if event.Usage.PromptTokens > 0 {
+			promptUsage.tokens = event.Usage.PromptTokens
+		}
+		if event.Usage.CompletionTokens > 0 {
+			completionUsage.tokens = event.Usage.CompletionTokens
+		}
+	}
+	if err := dec.Err(); err != nil {
+		logger.Error("failed to decode Mistral streaming response", log.Error(err))
+	}
+	if completionUsage.tokens == -1 || promptUsage.tokens == -1 {
+		logger.Warn("did not extract token counts from Mistral streaming response", log.Int("prompt-tokens", promptUsage.tokens), log.Int("completion-tokens", completionUsage.tokens))
+	}
+
+	return promptUsage, completionUsage
+}
@@ -0,0 +1,53 @@
+package completions
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/log/logtest"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sourcegraph/sourcegraph/internal/codygateway"
+)
+
+func TestMistralGetAPIURL(t *testing.T) {
+	handler := &MistralHandlerMethods{}
+	assert.Equal(t, "https://api.mistral.ai/v1/chat/completions", handler.getAPIURL(codygateway.FeatureChatCompletions, mistralRequest{}))
+	assert.Equal(t, "https://api.mistral.ai/v1/fim/completions", handler.getAPIURL(codygateway.FeatureCodeCompletions, mistralRequest{}))
+}
+
+func TestMistralRequestGetTokenCount(t *testing.T) {
+	logger := logtest.Scoped(t)
+
+	t.Run("streaming", func(t *testing.T) {
+		req := mistralRequest{Prompt: "func f() {", Suffix: "}", Stream: true}
+		r := strings.NewReader(mistralStreamingResponse)
+		handler := &MistralHandlerMethods{}
+		promptUsage, completionUsage := handler.parseResponseAndUsage(logger, req, r, true)
+
+		assert.Equal(t, 11, promptUsage.tokens)
+		assert.Equal(t, 4, completionUsage.tokens)
+		assert.Equal(t, len("return 1"), completionUsage.characters)
+	})
+
+	t.Run("non-streaming", func(t *testing.T) {
+		req := mistralRequest{Messages: []message{{Role: "user", Content: "Hello"}}}
+		r := strings.NewReader(mistralNonStreamingResponse)
+		handler := &MistralHandlerMethods{}
+		promptUsage, completionUsage := handler.parseResponseAndUsage(logger, req, r, false)
+
+		assert.Equal(t, 5, promptUsage.tokens)
+		assert.Equal(t, 9, completionUsage.tokens)
+		assert.Equal(t, len("Hello! How can I help you?"), completionUsage.characters)
+	})
+}
+
+var mistralStreamingResponse = `data: {"id":"1","object":"chat.completion.chunk","model":"codestral-latest","choices":[{"index":0,"delta":{"role":"assistant","content":"return"},"finish_reason":null}]}
+
+data: {"id":"1","object":"chat.completion.chunk","model":"codestral-latest","choices":[{"index":0,"delta":{"content":" 1"},"finish_reason":"stop"}],"usage":{"prompt_tokens":11,"total_tokens":15,"completion_tokens":4}}
+
+data: [DONE]
+
+`
+
+var mistralNonStreamingResponse = `{"id":"1","object":"chat.completion","model":"mistral-large-latest","choices":[{"index":0,"message":{"role":"assistant","content":"Hello! How can I help you?"},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"total_tokens":14,"completion_tokens":9}}`
@@ -41,6 +41,11 @@ type usageStats struct {
 	tokens int
 	// tokenizerTokens is the number of tokens computed by the tokenizer.
 	tokenizerTokens int
+	// cacheCreationTokens and cacheReadTokens are the number of input tokens
+	// written to and read from the upstream's prompt cache. They are only set
+	// for prompts of providers that support prompt caching.
+	cacheCreationTokens int
+	cacheReadTokens     int
 }
 
 // Hop-by-Hop headers that should not be copied when proxying upstream requests
@@ -348,12 +353,14 @@ func makeUpstreamHandler[ReqT UpstreamRequest](
 				requestMetadata["full_prompt"] = body.BuildPrompt()
 			}
 			usageData := map[string]any{
-				"prompt_character_count":           promptUsage.characters,
-				"prompt_token_count":               promptUsage.tokens,
-				"prompt_tokenizer_token_count":     promptUsage.tokenizerTokens,
-				"completion_character_count":       completionUsage.characters,
-				"completion_token_count":           completionUsage.tokens,
-				"completion_tokenizer_token_count": completionUsage.tokenizerTokens,
+				"prompt_character_count":            promptUsage.characters,
+				"prompt_token_count":                promptUsage.tokens,
+				"prompt_tokenizer_token_count":      promptUsage.tokenizerTokens,
+				"prompt_cache_creation_token_count": promptUsage.cacheCreationTokens,
+				"prompt_cache_read_token_count":     promptUsage.cacheReadTokens,
+				"completion_character_count":        completionUsage.characters,
+				"completion_token_count":            completionUsage.tokens,
+				"completion_tokenizer_token_count":  completionUsage.tokenizerTokens,
 			}
 			for k, v := range usageData {
 				// Drop usage fields that are invalid/unimplemented. All
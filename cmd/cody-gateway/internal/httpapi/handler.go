@@ -37,6 +37,7 @@ type Config struct {
 	OpenAI                      config.OpenAIConfig
 	Fireworks                   config.FireworksConfig
 	Google                      config.GoogleConfig
+	Mistral                     config.MistralConfig
 	EmbeddingsAllowedModels     []string
 	AutoFlushStreamingResponses bool
 	EnableAttributionSearch     bool
@@ -52,6 +53,7 @@ var (
 	attributesOpenAIEmbeddings     = newMetricAttributes("openai", "embeddings")
 	attributesFireworksCompletions = newMetricAttributes("fireworks", "completions")
 	attributesGoogleCompletions    = newMetricAttributes("google", "completions")
+	attributesMistralCompletions   = newMetricAttributes("mistral", "completions")
 )
 
 func NewHandler(
@@ -208,6 +210,15 @@ func NewHandler(
 			googleHandler)
 	}
 
+	if config.Mistral.AccessToken != "" {
+		mistralHandler := completions.NewMistralHandler(logger, eventLogger, rs, config.RateLimitNotifier, httpClient, config.Mistral, flaggedPromptRecorder, upstreamConfig)
+		registerStandardEndpoint(
+			"v1.completions.mistral",
+			"/completions/mistral",
+			attributesMistralCompletions,
+			mistralHandler)
+	}
+
 	// Register a route where actors can retrieve their current rate limit state.
 	limitsHandler := featurelimiter.ListLimitsHandler(logger, rs)
 	registerSimpleGETEndpoint("v1.limits", "/limits", limitsHandler)
@@ -14,6 +14,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/completions/client/anthropic"
 	"github.com/sourcegraph/sourcegraph/internal/completions/client/fireworks"
 	"github.com/sourcegraph/sourcegraph/internal/completions/client/google"
+	"github.com/sourcegraph/sourcegraph/internal/completions/client/mistral"
 	"github.com/sourcegraph/sourcegraph/internal/env"
 	"github.com/sourcegraph/sourcegraph/internal/trace/policy"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
@@ -88,6 +89,8 @@ type Config struct {
 	Environment string
 
 	Google GoogleConfig
+
+	Mistral MistralConfig
 }
 
 type OpenTelemetryConfig struct {
@@ -130,6 +133,12 @@ type GoogleConfig struct {
 	FlaggingConfig FlaggingConfig
 }
 
+type MistralConfig struct {
+	AccessToken    string
+	AllowedModels  []string
+	FlaggingConfig FlaggingConfig
+}
+
 // FlaggingConfig defines common parameters for filtering and flagging requests,
 // in an LLM-provider agnostic manner.
 type FlaggingConfig struct {
@@ -331,6 +340,24 @@ func (c *Config) Load() {
 	// HACK: Same as the comment on OpenAI or Fireworks, re: only using one env var prefix.
 	c.loadFlaggingConfig(&c.Google.FlaggingConfig, "CODY_GATEWAY_ANTHROPIC")
 
+	// Configurations for Mistral models.
+	c.Mistral.AccessToken = c.GetOptional("CODY_GATEWAY_MISTRAL_ACCESS_TOKEN", "The Mistral access token to be used.")
+	c.Mistral.AllowedModels = splitMaybe(c.Get("CODY_GATEWAY_MISTRAL_ALLOWED_MODELS",
+		strings.Join([]string{
+			mistral.Codestral,
+			mistral.MistralLarge,
+			mistral.MistralSmall,
+			mistral.Mixtral8x7b,
+			mistral.Mixtral8x22b,
+		}, ","),
+		"Mistral models that can be used."),
+	)
+	if c.Mistral.AccessToken != "" && len(c.Mistral.AllowedModels) == 0 {
+		c.AddError(errors.New("must provide allowed models for Mistral"))
+	}
+	// HACK: Same as the comment on OpenAI or Fireworks, re: only using one env var prefix.
+	c.loadFlaggingConfig(&c.Mistral.FlaggingConfig, "CODY_GATEWAY_ANTHROPIC")
+
 	defaultEmbeddingModels := strings.Join([]string{
 		string(embeddings.ModelNameOpenAIAda),
 		string(embeddings.ModelNameSourcegraphSTMultiQA),
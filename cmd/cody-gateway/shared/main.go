@@ -229,6 +229,7 @@ func Main(ctx context.Context, obctx *observation.Context, ready service.ReadyFu
 			OpenAI:                      cfg.OpenAI,
 			Fireworks:                   cfg.Fireworks,
 			Google:                      cfg.Google,
+			Mistral:                     cfg.Mistral,
 			EmbeddingsAllowedModels:     cfg.AllowedEmbeddingsModels,
 			AutoFlushStreamingResponses: cfg.AutoFlushStreamingResponses,
 			IdentifiersToLogFor:         cfg.IdentifiersToLogFor,
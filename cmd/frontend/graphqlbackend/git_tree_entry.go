@@ -34,6 +34,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/types"
 	"github.com/sourcegraph/sourcegraph/lib/codeintel/languages"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
+	"github.com/sourcegraph/sourcegraph/lib/pointers"
 )
 
 // GitTreeEntryResolver resolves an entry in a Git tree in a repository. The entry can be any Git
@@ -504,6 +505,7 @@ func (r *GitTreeEntryResolver) CodeGraphData(ctx context.Context, args *resolver
 		Args:   args,
 		Repo:   repo,
 		Commit: api.CommitID(r.Commit().OID()),
+		Rev:    pointers.Deref(r.Commit().InputRev(), ""),
 		Path:   core.NewRepoRelPathUnchecked(r.Path()),
 	})
 }
@@ -142,6 +142,15 @@ func (r *repositoryMirrorInfoResolver) LastError(ctx context.Context) (*string,
 	return strptr(info.LastError), nil
 }
 
+func (r *repositoryMirrorInfoResolver) LastErrorAt(ctx context.Context) (*gqlutil.DateTime, error) {
+	info, err := r.computeGitserverRepo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return gqlutil.FromTime(info.LastErrorAt), nil
+}
+
 func (r *repositoryMirrorInfoResolver) LastSyncOutput(ctx context.Context) (*string, error) {
 	output, ok, err := r.db.GitserverRepos().GetLastSyncOutput(ctx, r.repository.name)
 	if err != nil {
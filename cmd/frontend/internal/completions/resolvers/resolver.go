@@ -23,13 +23,15 @@ var _ graphqlbackend.CompletionsResolver = &completionsResolver{}
 // completionsResolver provides chat completions
 type completionsResolver struct {
 	rl     completions.RateLimiter
+	budget completions.TokenBudget
 	db     database.DB
 	logger log.Logger
 }
 
 func NewCompletionsResolver(db database.DB, logger log.Logger) graphqlbackend.CompletionsResolver {
 	rl := completions.NewRateLimiter(db, redispool.Store, types.CompletionsFeatureChat)
-	return &completionsResolver{rl: rl, db: db, logger: logger}
+	budget := completions.NewTokenBudget(db, redispool.Store)
+	return &completionsResolver{rl: rl, budget: budget, db: db, logger: logger}
 }
 
 func (c *completionsResolver) Completions(ctx context.Context, args graphqlbackend.CompletionsArgs) (_ string, err error) {
@@ -69,10 +71,14 @@ func (c *completionsResolver) Completions(ctx context.Context, args graphqlbacke
 		return "", errors.Wrap(err, "GetCompletionStreamClient")
 	}
 
-	// Check rate limit.
+	// Check rate limit. The error is returned as is, so that clients can read
+	// when the limit resets from its extensions.
 	if err := c.rl.TryAcquire(ctx); err != nil {
 		return "", err
 	}
+	if err := c.budget.Check(ctx); err != nil {
+		return "", err
+	}
 
 	params := convertParams(args)
 	request := types.CompletionRequest{
@@ -82,8 +88,20 @@ func (c *completionsResolver) Completions(ctx context.Context, args graphqlbacke
 		// GraphQL API is considered a legacy API.
 		Version: types.CompletionsVersionLegacy,
 	}
-	resp, err := client.Complete(ctx, c.logger, request)
+	return complete(ctx, c.logger, completions.WithTokenBudget(client, c.budget), request)
+}
+
+// complete sends the request to the provider. The provider request is bound to
+// ctx, which is cancelled once the GraphQL client disconnects, so that we stop
+// generating tokens nobody is going to read.
+func complete(ctx context.Context, logger log.Logger, client types.CompletionsClient, request types.CompletionRequest) (string, error) {
+	resp, err := client.Complete(ctx, logger, request)
 	if err != nil {
+		// If the client went away, report that rather than the error the
+		// provider request failed with as a consequence.
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
 		return "", errors.Wrap(err, "client.Complete")
 	}
 	return resp.Completion, nil
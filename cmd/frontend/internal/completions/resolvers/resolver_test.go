@@ -0,0 +1,71 @@
+package resolvers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/log/logtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/completions/client/openai"
+	"github.com/sourcegraph/sourcegraph/internal/completions/tokenusage"
+	"github.com/sourcegraph/sourcegraph/internal/completions/types"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+)
+
+func TestCompleteClientDisconnect(t *testing.T) {
+	requestStarted := make(chan struct{})
+	upstreamCancelled := make(chan struct{})
+	testDone := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The server only notices the client going away once the request body
+		// has been read.
+		_, _ = io.Copy(io.Discard, r.Body)
+		close(requestStarted)
+		// Don't respond, like a provider that is still generating tokens.
+		select {
+		case <-r.Context().Done():
+			close(upstreamCancelled)
+		case <-testDone:
+		}
+	}))
+	t.Cleanup(upstream.Close)
+	t.Cleanup(func() { close(testDone) })
+
+	client := openai.NewClient(httpcli.DoerFunc(http.DefaultClient.Do), upstream.URL, "token", *tokenusage.NewManager())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := complete(ctx, logtest.Scoped(t), client, types.CompletionRequest{
+			Feature: types.CompletionsFeatureChat,
+			Parameters: types.CompletionRequestParameters{
+				Messages: []types.Message{{Speaker: types.HUMAN_MESSAGE_SPEAKER, Text: "hello"}},
+			},
+			Version: types.CompletionsVersionLegacy,
+		})
+		errs <- err
+	}()
+
+	// Simulate the GraphQL client disconnecting while the provider is busy.
+	<-requestStarted
+	cancel()
+
+	select {
+	case <-upstreamCancelled:
+	case <-time.After(10 * time.Second):
+		t.Fatal("upstream request was not cancelled")
+	}
+	select {
+	case err := <-errs:
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(10 * time.Second):
+		t.Fatal("complete did not return")
+	}
+}
@@ -0,0 +1,232 @@
+package completions
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/sourcegraph/log"
+
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/completions/tokenizer"
+	"github.com/sourcegraph/sourcegraph/internal/completions/types"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/redispool"
+	itypes "github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// TokenBudget enforces the monthly LLM token budget of the organization the
+// calling user is charged to.
+//
+// A user that is a member of multiple organizations is charged to the one with
+// the lowest ID, so that every token is only counted once. Users that are not a
+// member of any organization are not limited.
+type TokenBudget interface {
+	// Check returns a TokenBudgetExceededError if the organization of the
+	// calling user has used up its budget for the current month.
+	Check(ctx context.Context) error
+	// Record adds the given number of tokens to the usage of the organization
+	// of the calling user.
+	Record(ctx context.Context, tokens int) error
+}
+
+type TokenBudgetExceededError struct {
+	Org     string
+	Budget  int
+	Used    int
+	ResetAt time.Time
+}
+
+func (e TokenBudgetExceededError) Error() string {
+	return fmt.Sprintf("the organization %q exceeded its monthly token budget of %d tokens (used: %d). The budget resets at %s", e.Org, e.Budget, e.Used, e.ResetAt.Format(time.RFC3339))
+}
+
+// Extensions exposes the budget state to GraphQL clients.
+func (e TokenBudgetExceededError) Extensions() map[string]any {
+	return map[string]any{
+		"code":    "ErrTokenBudgetExceeded",
+		"budget":  e.Budget,
+		"resetAt": e.ResetAt.Format(time.RFC3339),
+	}
+}
+
+func NewTokenBudget(db database.DB, rstore redispool.KeyValue) TokenBudget {
+	return &orgTokenBudget{db: db, rstore: rstore, now: time.Now}
+}
+
+type orgTokenBudget struct {
+	db     database.DB
+	rstore redispool.KeyValue
+	now    func() time.Time
+}
+
+func (b *orgTokenBudget) Check(ctx context.Context) error {
+	budget := getConfiguredOrgTokenBudget()
+	if budget <= 0 {
+		// Token budgets disabled.
+		return nil
+	}
+
+	org, err := b.chargedOrg(ctx)
+	if err != nil || org == nil {
+		return err
+	}
+
+	now := b.now()
+	used, err := b.rstore.WithContext(ctx).Get(orgTokensKey(org.ID, now)).Int()
+	if err != nil && err != redis.ErrNil {
+		return errors.Wrap(err, "failed to read token budget counter")
+	}
+	if used >= budget {
+		return TokenBudgetExceededError{
+			Org:     org.Name,
+			Budget:  budget,
+			Used:    used,
+			ResetAt: startOfNextMonth(now),
+		}
+	}
+	return nil
+}
+
+func (b *orgTokenBudget) Record(ctx context.Context, tokens int) error {
+	if tokens <= 0 || getConfiguredOrgTokenBudget() <= 0 {
+		return nil
+	}
+
+	org, err := b.chargedOrg(ctx)
+	if err != nil || org == nil {
+		return err
+	}
+
+	now := b.now()
+	rstore := b.rstore.WithContext(ctx)
+	key := orgTokensKey(org.ID, now)
+	used, err := rstore.IncrByInt64(key, int64(tokens))
+	if err != nil {
+		return errors.Wrap(err, "failed to increment token budget counter")
+	}
+	// The counter is keyed by month, so it never needs to be reset. We only
+	// make sure it is cleaned up some time after the month ended.
+	if used == int64(tokens) {
+		ttl := startOfNextMonth(now).Add(24 * time.Hour).Sub(now)
+		if err := rstore.Expire(key, int(ttl/time.Second)); err != nil {
+			return errors.Wrap(err, "failed to set expiry for token budget counter")
+		}
+	}
+	return nil
+}
+
+// chargedOrg returns the organization the calling user is charged to, see
+// TokenBudget. It returns nil if the actor is not limited.
+func (b *orgTokenBudget) chargedOrg(ctx context.Context) (*itypes.Org, error) {
+	a := actor.FromContext(ctx)
+	if !a.IsAuthenticated() || a.IsInternal() {
+		return nil, nil
+	}
+
+	orgs, err := b.db.Orgs().GetByUserID(ctx, a.UID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list organizations of user")
+	}
+	if len(orgs) == 0 {
+		return nil, nil
+	}
+	return slices.MinFunc(orgs, func(a, b *itypes.Org) int {
+		return cmp.Compare(a.ID, b.ID)
+	}), nil
+}
+
+func orgTokensKey(orgID int32, now time.Time) string {
+	return fmt.Sprintf("completions_token_budget:org:%d:tokens:%s", orgID, now.UTC().Format("2006-01"))
+}
+
+func startOfNextMonth(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func getConfiguredOrgTokenBudget() int {
+	cfg := conf.GetCompletionsConfig(conf.Get().SiteConfig())
+	if cfg == nil {
+		return 0
+	}
+	return cfg.PerOrgMonthlyTokenBudget
+}
+
+// WithTokenBudget wraps the given client so that the tokens used by every
+// request are recorded against the token budget. This includes requests that
+// fail after the provider started generating a response, eg. because the
+// client went away during a stream.
+//
+// We don't use the counts of tokenusage.Manager here: it aggregates usage per
+// model across all users, so it can't be attributed to an organization.
+func WithTokenBudget(cc types.CompletionsClient, budget TokenBudget) types.CompletionsClient {
+	return &budgetedClient{inner: cc, budget: budget}
+}
+
+// recordTimeout is the time we give recording usage. Recording happens after
+// the request was served, so it uses a context that is not canceled with the
+// request context.
+const recordTimeout = 10 * time.Second
+
+type budgetedClient struct {
+	inner  types.CompletionsClient
+	budget TokenBudget
+}
+
+func (c *budgetedClient) Stream(ctx context.Context, logger log.Logger, request types.CompletionRequest, send types.SendCompletionEvent) error {
+	// Providers report usage on some events only, so we remember the last
+	// reported value separately.
+	var last *types.CompletionResponse
+	var usage *types.TokenUsage
+	err := c.inner.Stream(ctx, logger, request, func(event types.CompletionResponse) error {
+		last = &event
+		if event.Usage != nil {
+			usage = event.Usage
+		}
+		return send(event)
+	})
+	// If the stream failed before we received anything, the provider didn't
+	// generate a response we could count.
+	if last != nil {
+		last.Usage = usage
+		c.record(ctx, logger, request, last)
+	}
+	return err
+}
+
+func (c *budgetedClient) Complete(ctx context.Context, logger log.Logger, request types.CompletionRequest) (*types.CompletionResponse, error) {
+	resp, err := c.inner.Complete(ctx, logger, request)
+	if resp != nil {
+		c.record(ctx, logger, request, resp)
+	}
+	return resp, err
+}
+
+func (c *budgetedClient) record(ctx context.Context, logger log.Logger, request types.CompletionRequest, resp *types.CompletionResponse) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+	defer cancel()
+
+	if err := c.budget.Record(ctx, usedTokens(request, resp)); err != nil {
+		// The response was already served, so we can only log the error here.
+		logger.Warn("failed to record token usage", log.Error(err))
+	}
+}
+
+// usedTokens returns the number of tokens used by the request, as reported by
+// the provider. If the provider didn't report usage, it is estimated.
+func usedTokens(request types.CompletionRequest, resp *types.CompletionResponse) int {
+	if resp.Usage != nil {
+		return resp.Usage.InputTokens + resp.Usage.OutputTokens
+	}
+	tokens := tokenizer.EstimateTokens(resp.Completion)
+	for _, m := range request.Parameters.Messages {
+		tokens += tokenizer.EstimateTokens(m.Text)
+	}
+	return tokens
+}
@@ -0,0 +1,181 @@
+package completions
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/sourcegraph/log"
+	"github.com/sourcegraph/log/logtest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/completions/types"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbmocks"
+	"github.com/sourcegraph/sourcegraph/internal/redispool"
+	itypes "github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+	"github.com/sourcegraph/sourcegraph/lib/pointers"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestTokenBudget(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		CodyEnabled: pointers.Ptr(true),
+		Completions: &schema.Completions{
+			Provider:                 "openai",
+			AccessToken:              "secret",
+			PerOrgMonthlyTokenBudget: 100,
+		},
+	}})
+	t.Cleanup(func() { conf.Mock(nil) })
+
+	orgs := dbmocks.NewMockOrgStore()
+	orgs.GetByUserIDFunc.SetDefaultHook(func(_ context.Context, userID int32) ([]*itypes.Org, error) {
+		switch userID {
+		case 1:
+			return []*itypes.Org{{ID: 7, Name: "acme"}}, nil
+		case 2:
+			// Members of multiple organizations are charged to the one with
+			// the lowest ID.
+			return []*itypes.Org{{ID: 9, Name: "other"}, {ID: 7, Name: "acme"}}, nil
+		}
+		return nil, nil
+	})
+	db := dbmocks.NewMockDB()
+	db.OrgsFunc.SetDefaultReturn(orgs)
+
+	// Back the mock by a map, so that recorded usage can be read again.
+	counters := map[string]int64{}
+	kv := redispool.NewMockKeyValue()
+	kv.WithContextFunc.SetDefaultReturn(kv)
+	kv.GetFunc.SetDefaultHook(func(key string) redispool.Value {
+		v, ok := counters[key]
+		if !ok {
+			return redispool.NewValue(nil, redis.ErrNil)
+		}
+		return redispool.NewValue(v, nil)
+	})
+	kv.IncrByInt64Func.SetDefaultHook(func(key string, value int64) (int64, error) {
+		counters[key] += value
+		return counters[key], nil
+	})
+
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	budget := &orgTokenBudget{db: db, rstore: kv, now: func() time.Time { return now }}
+
+	ctx := actor.WithActor(context.Background(), actor.FromUser(1))
+	require.NoError(t, budget.Check(ctx))
+
+	require.NoError(t, budget.Record(ctx, 60))
+	require.NoError(t, budget.Check(ctx))
+	require.NoError(t, budget.Record(ctx, 60))
+
+	// The organization is over budget now.
+	err := budget.Check(ctx)
+	var budgetErr TokenBudgetExceededError
+	require.ErrorAs(t, err, &budgetErr)
+	require.Equal(t, TokenBudgetExceededError{
+		Org:     "acme",
+		Budget:  100,
+		Used:    120,
+		ResetAt: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+	}, budgetErr)
+
+	// The expiry is only set when the counter is created.
+	require.Len(t, kv.ExpireFunc.History(), 1)
+
+	// Internal actors are never limited.
+	require.NoError(t, budget.Check(actor.WithInternalActor(ctx)))
+
+	// Members of multiple organizations are charged to a single one.
+	otherCtx := actor.WithActor(context.Background(), actor.FromUser(2))
+	require.ErrorAs(t, budget.Check(otherCtx), &budgetErr)
+	require.Equal(t, "acme", budgetErr.Org)
+	require.NoError(t, budget.Record(otherCtx, 10))
+	require.Equal(t, map[string]int64{"completions_token_budget:org:7:tokens:2024-03": 130}, counters)
+
+	// Users without an organization are not limited.
+	noOrgCtx := actor.WithActor(context.Background(), actor.FromUser(3))
+	require.NoError(t, budget.Record(noOrgCtx, 1000))
+	require.NoError(t, budget.Check(noOrgCtx))
+	require.Len(t, counters, 1)
+
+	// Once the next month starts, the budget is available again.
+	now = budgetErr.ResetAt
+	require.NoError(t, budget.Check(ctx))
+}
+
+type recordingBudget struct{ recorded []int }
+
+func (b *recordingBudget) Check(context.Context) error { return nil }
+
+func (b *recordingBudget) Record(ctx context.Context, tokens int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.recorded = append(b.recorded, tokens)
+	return nil
+}
+
+type streamingClient struct {
+	events []types.CompletionResponse
+	err    error
+}
+
+func (c *streamingClient) Stream(_ context.Context, _ log.Logger, _ types.CompletionRequest, send types.SendCompletionEvent) error {
+	for _, e := range c.events {
+		if err := send(e); err != nil {
+			return err
+		}
+	}
+	return c.err
+}
+
+func (c *streamingClient) Complete(context.Context, log.Logger, types.CompletionRequest) (*types.CompletionResponse, error) {
+	return nil, c.err
+}
+
+func TestBudgetedClient_RecordsUsageOfFailedStreams(t *testing.T) {
+	streamErr := errors.New("client went away")
+	usage := &types.TokenUsage{InputTokens: 10, OutputTokens: 5}
+
+	t.Run("reported usage", func(t *testing.T) {
+		budget := &recordingBudget{}
+		cc := WithTokenBudget(&streamingClient{
+			events: []types.CompletionResponse{{Completion: "a", Usage: usage}, {Completion: "ab"}},
+			err:    streamErr,
+		}, budget)
+
+		// Canceling the request doesn't prevent recording.
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := cc.Stream(ctx, logtest.Scoped(t), types.CompletionRequest{}, func(types.CompletionResponse) error { return nil })
+		require.ErrorIs(t, err, streamErr)
+		require.Equal(t, []int{15}, budget.recorded)
+	})
+
+	t.Run("estimated usage", func(t *testing.T) {
+		budget := &recordingBudget{}
+		cc := WithTokenBudget(&streamingClient{
+			events: []types.CompletionResponse{{Completion: "some partial completion"}},
+			err:    streamErr,
+		}, budget)
+
+		err := cc.Stream(context.Background(), logtest.Scoped(t), types.CompletionRequest{}, func(types.CompletionResponse) error { return nil })
+		require.ErrorIs(t, err, streamErr)
+		require.Len(t, budget.recorded, 1)
+		require.Positive(t, budget.recorded[0])
+	})
+
+	t.Run("no events", func(t *testing.T) {
+		budget := &recordingBudget{}
+		cc := WithTokenBudget(&streamingClient{err: streamErr}, budget)
+
+		err := cc.Stream(context.Background(), logtest.Scoped(t), types.CompletionRequest{}, func(types.CompletionResponse) error { return nil })
+		require.ErrorIs(t, err, streamErr)
+		require.Empty(t, budget.recorded)
+	})
+}
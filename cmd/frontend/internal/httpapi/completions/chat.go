@@ -24,6 +24,7 @@ func chatAttributionTest(context.Context, string) (bool, error) {
 func NewChatCompletionsStreamHandler(logger log.Logger, db database.DB) http.Handler {
 	logger = logger.Scoped("chat")
 	rl := NewRateLimiter(db, redispool.Store, types.CompletionsFeatureChat)
+	budget := NewTokenBudget(db, redispool.Store)
 
 	return newCompletionsHandler(
 		logger,
@@ -34,6 +35,7 @@ func NewChatCompletionsStreamHandler(logger log.Logger, db database.DB) http.Han
 		chatAttributionTest,
 		types.CompletionsFeatureChat,
 		rl,
+		budget,
 		"chat",
 		getChatModelFn(db))
 }
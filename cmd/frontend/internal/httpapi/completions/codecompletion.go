@@ -16,6 +16,7 @@ import (
 func NewCodeCompletionsHandler(logger log.Logger, db database.DB, test guardrails.AttributionTest) http.Handler {
 	logger = logger.Scoped("code")
 	rl := NewRateLimiter(db, redispool.Store, types.CompletionsFeatureCode)
+	budget := NewTokenBudget(db, redispool.Store)
 	return newCompletionsHandler(
 		logger,
 		db,
@@ -25,6 +26,7 @@ func NewCodeCompletionsHandler(logger log.Logger, db database.DB, test guardrail
 		test,
 		types.CompletionsFeatureCode,
 		rl,
+		budget,
 		"code",
 		getCodeCompletionModelFn())
 }
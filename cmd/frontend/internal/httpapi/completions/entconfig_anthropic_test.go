@@ -76,9 +76,10 @@ func testAPIProviderAnthropic(t *testing.T, infra *apiProviderTestInfra) {
 		}
 
 		wantCompletionsResponse := types.CompletionResponse{
-			Completion: "you should totally rewrite it in Rust!",
-			StopReason: "max_tokens",
-			Logprobs:   nil,
+			Completion:           "you should totally rewrite it in Rust!",
+			StopReason:           "max_tokens",
+			NormalizedStopReason: types.StopReasonMaxTokens,
+			Logprobs:             nil,
 		}
 
 		return completionsRequestTestData{
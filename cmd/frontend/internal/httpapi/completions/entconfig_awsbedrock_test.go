@@ -80,9 +80,10 @@ func testAPIProviderAWSBedrock(t *testing.T, infra *apiProviderTestInfra) {
 		}
 
 		wantCompletionsResponse := types.CompletionResponse{
-			Completion: "A rewrite in Rust is the only option.",
-			StopReason: "max_tokens",
-			Logprobs:   nil,
+			Completion:           "A rewrite in Rust is the only option.",
+			StopReason:           "max_tokens",
+			NormalizedStopReason: types.StopReasonMaxTokens,
+			Logprobs:             nil,
 		}
 
 		return completionsRequestTestData{
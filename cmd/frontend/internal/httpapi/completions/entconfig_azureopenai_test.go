@@ -90,9 +90,10 @@ func testAPIProviderAzureOpenAI(t *testing.T, infra *apiProviderTestInfra) {
 		}
 
 		wantCompletionsResponse := types.CompletionResponse{
-			Completion: "A rewrite in Rust is the only option.",
-			StopReason: "stop",
-			Logprobs:   nil,
+			Completion:           "A rewrite in Rust is the only option.",
+			StopReason:           "stop",
+			NormalizedStopReason: types.StopReasonEndTurn,
+			Logprobs:             nil,
 		}
 
 		return completionsRequestTestData{
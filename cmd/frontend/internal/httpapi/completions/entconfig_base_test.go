@@ -39,6 +39,16 @@ func (*mockRateLimiter) TryAcquire(ctx context.Context) error {
 	return nil
 }
 
+type mockTokenBudget struct{}
+
+func (*mockTokenBudget) Check(ctx context.Context) error {
+	return nil
+}
+
+func (*mockTokenBudget) Record(ctx context.Context, tokens int) error {
+	return nil
+}
+
 // Mock of the httpcli.Doer interface.
 type mockDoer struct {
 	do func(*http.Request) (*http.Response, error)
@@ -259,6 +269,7 @@ func TestAPIProviders(t *testing.T) {
 		nil, // guardrails.AttributionTest
 		types.CompletionsFeatureChat,
 		&mockRateLimiter{},
+		&mockTokenBudget{},
 		"trace-family",
 		mockGetModelFn.ToFunc())
 	codeCompletionHandler := newCompletionsHandler(
@@ -270,6 +281,7 @@ func TestAPIProviders(t *testing.T) {
 		nil, // guardrails.AttributionTest
 		types.CompletionsFeatureCode,
 		&mockRateLimiter{},
+		&mockTokenBudget{},
 		"trace-family",
 		mockGetModelFn.ToFunc())
 
@@ -3,13 +3,16 @@ package completions
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/sourcegraph/log"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/cody"
 	"github.com/sourcegraph/sourcegraph/internal/dotcom"
 	"github.com/sourcegraph/sourcegraph/internal/modelconfig"
+	"github.com/sourcegraph/sourcegraph/internal/modelconfig/embedded"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 
 	"github.com/sourcegraph/sourcegraph/internal/completions/client/anthropic"
@@ -85,6 +88,22 @@ func (lmr legacyModelRef) ToModelRef() modelconfigSDK.ModelRef {
 	return modelconfigSDK.ModelRef(converted)
 }
 
+// parseRequestedModel returns the requested model as a ModelRef and as a
+// legacyModelRef, to look it up in either format. If the requested model
+// contains "::" it is strictly treated as a ModelRef, and must be well-formed.
+// The returned legacyModelRef is empty then, so that e.g. "a/b::c::d" doesn't
+// ambiguously match the model "b::c::d" of provider "a".
+func parseRequestedModel(requestedModel types.TaintedModelRef) (modelconfigSDK.ModelRef, legacyModelRef, error) {
+	if strings.Contains(string(requestedModel), "::") {
+		mref := modelconfigSDK.ModelRef(requestedModel)
+		if err := modelconfig.ValidateModelRef(mref); err != nil {
+			return "", "", errors.Wrapf(err, "invalid model reference %q", requestedModel)
+		}
+		return mref, "", nil
+	}
+	return modelconfigSDK.ModelRef(requestedModel), legacyModelRef(requestedModel), nil
+}
+
 // getModelFn is the thunk used to return the LLM model we should use for processing
 // the supplied completion request. Depending on the incomming request, site config,
 // feature used, etc. it could be any number of things.
@@ -107,17 +126,13 @@ func getCodeCompletionModelFn() getModelFn {
 		// Default to model to use to the site's configuration if unspecified.
 		initialRequestedModel := requestParams.RequestedModel
 		if requestParams.RequestedModel == "" {
+			if !cfg.FeatureModels.CodeCompletionAllowed(cfg.DefaultModels.CodeCompletion) {
+				return "", errors.Errorf(
+					"default model %q is not allowed for code completion", cfg.DefaultModels.CodeCompletion)
+			}
 			requestParams.RequestedModel = types.TaintedModelRef(cfg.DefaultModels.CodeCompletion)
 		}
 
-		// We want to support newer clients sending fully-qualified ModelRefs, as well as older
-		// clients using the legacy format. So we check if the incomming model reference is in either
-		// format.
-		var (
-			mref       modelconfigSDK.ModelRef = modelconfigSDK.ModelRef(requestParams.RequestedModel)
-			legacyMRef legacyModelRef          = legacyModelRef(requestParams.RequestedModel)
-		)
-
 		// BUG: When we have the ability within the site config to rely on Sourcegraph
 		// supplied models, we can remove this step and rely on the data embedded in
 		// the binary. (This requires us updating the site configuration for Sourcegraph.com,
@@ -127,38 +142,90 @@ func getCodeCompletionModelFn() getModelFn {
 		// BUG: A side effect of this, until we make that change, Cody Pro users _cannot_ specify models
 		// using the newer MRef syntax. As this check only looks for older "provider/model" names.
 		if dotcom.SourcegraphDotComMode() {
+			legacyMRef := legacyModelRef(requestParams.RequestedModel)
 			if isAllowedCodyProCompletionModel(legacyMRef) {
 				return legacyMRef.ToModelRef(), nil
 			}
 			return "", errors.Errorf("unsupported Cody Pro completion model %q", legacyMRef)
 		}
 
+		// If a fast code completion is requested, we use the designated "fast" model,
+		// falling back to the regular code completion model if there is none.
+		if requestParams.Fast {
+			fastModel := cfg.DefaultModels.FastCodeCompletion
+			if fastModel == "" {
+				fastModel = cfg.DefaultModels.CodeCompletion
+			}
+			if !cfg.FeatureModels.CodeCompletionAllowed(fastModel) {
+				return "", errors.Errorf(
+					"model %q is not allowed for code completion", fastModel)
+			}
+			return fastModel, nil
+		}
+
+		// We want to support newer clients sending fully-qualified ModelRefs, as well as older
+		// clients using the legacy format. So we check if the incomming model reference is in either
+		// format.
+		mref, legacyMRef, err := parseRequestedModel(requestParams.RequestedModel)
+		if err != nil {
+			return "", err
+		}
+
 		// Now, for Cody Enterprise, if the caller requested a specific model we simply look
-		// it up in the site config. By definition, if it is found then the model is allowed.
+		// it up in the site config. If it is found then the model is allowed, unless the
+		// site config restricts which models can be used for code completion.
 		for _, supportedModel := range cfg.Models {
-			// Requested model was in the newer format.
-			if supportedModel.ModelRef == mref {
-				return mref, nil
-			}
-			// If the request is using the older format, then we are relying on the
-			// assumption that the user-supplied legacyMRef's provider and model names
-			// match the ProviderID and ModelID. (Likely, but may not be the case.)
+			// The requested model may be in the newer format. If the request is using the
+			// older format, then we are relying on the assumption that the user-supplied
+			// legacyMRef's provider and model names match the ProviderID and ModelID.
+			// (Likely, but may not be the case.)
 			//
 			// e.g. in order to support "fireworks/star-coder", we require that the
 			// Sourcegraph instance has ap rovider named "fireworks" and a model with ID
 			// "star-coder".
-			if legacyMRef.EqualToIgnoringAPIVersion(supportedModel.ModelRef) {
-				return supportedModel.ModelRef, nil
+			if supportedModel.ModelRef != mref && !legacyMRef.EqualToIgnoringAPIVersion(supportedModel.ModelRef) {
+				continue
 			}
+			if !cfg.FeatureModels.CodeCompletionAllowed(supportedModel.ModelRef) {
+				return "", errors.Errorf(
+					"model %q is not allowed for code completion", supportedModel.ModelRef)
+			}
+			return supportedModel.ModelRef, nil
 		}
 
-		err := errors.Errorf(
+		return "", errors.Errorf(
 			"unsupported code completion model %q (default %q)",
 			initialRequestedModel, cfg.DefaultModels.CodeCompletion)
-		return "", err
 	}
 }
 
+// ErrModelNotAllowed is returned when a Cody Free or Cody Pro user requests a
+// model that isn't available on their subscription tier.
+type ErrModelNotAllowed struct {
+	// Model is the model reference as requested by the client.
+	Model types.TaintedModelRef
+	// IsProUser is whether the user is on the Cody Pro tier.
+	IsProUser bool
+}
+
+func (e ErrModelNotAllowed) Error() string {
+	tier := "Cody Free"
+	if e.IsProUser {
+		tier = "Cody Pro"
+	}
+	return fmt.Sprintf("the requested model %q is not available on the %s tier", e.Model, tier)
+}
+
+// getCodyProChatModel returns the chat model to use for a Cody Free or Cody Pro
+// user, or ErrModelNotAllowed if the requested model isn't available to them.
+func getCodyProChatModel(requestedModel types.TaintedModelRef, isProUser bool) (modelconfigSDK.ModelRef, error) {
+	legacyMRef := legacyModelRef(requestedModel)
+	if isAllowedCodyProChatModel(legacyMRef, isProUser) {
+		return legacyMRef.ToModelRef(), nil
+	}
+	return "", ErrModelNotAllowed{Model: requestedModel, IsProUser: isProUser}
+}
+
 func getChatModelFn(db database.DB) getModelFn {
 	return func(
 		ctx context.Context, requestParams types.CodyCompletionRequestParameters, cfg *modelconfigSDK.ModelConfiguration) (
@@ -181,19 +248,16 @@ func getChatModelFn(db database.DB) getModelFn {
 			}
 
 			// Note that Cody Pro users MUST specify the model to use on all requests.
-			legacyMRef := legacyModelRef(requestParams.RequestedModel)
-			if isAllowedCodyProChatModel(legacyMRef, subscription.ApplyProRateLimits) {
-				return legacyMRef.ToModelRef(), nil
-			}
-			errModelNotAllowed := errors.Errorf(
-				"the requested model is not available (%q, onProTier=%v)",
-				requestParams.RequestedModel, subscription.ApplyProRateLimits)
-			return "", errModelNotAllowed
+			return getCodyProChatModel(requestParams.RequestedModel, subscription.ApplyProRateLimits)
 		}
 
 		// If FastChat is specified, we just use whatever the designated "fast" model is.
 		// Otherwise, we try to find whatever model matches based on the default.
 		if requestParams.Fast {
+			if !cfg.FeatureModels.ChatAllowed(cfg.DefaultModels.FastChat) {
+				return "", errors.Errorf(
+					"model %q is not allowed for chat", cfg.DefaultModels.FastChat)
+			}
 			return cfg.DefaultModels.FastChat, nil
 		}
 
@@ -202,48 +266,91 @@ func getChatModelFn(db database.DB) getModelFn {
 		// format.
 		initialRequestedModel := requestParams.RequestedModel
 		if requestParams.RequestedModel == "" {
+			if !cfg.FeatureModels.ChatAllowed(cfg.DefaultModels.Chat) {
+				return "", errors.Errorf(
+					"default model %q is not allowed for chat", cfg.DefaultModels.Chat)
+			}
 			requestParams.RequestedModel = types.TaintedModelRef(cfg.DefaultModels.Chat)
 		}
-		var (
-			mref       modelconfigSDK.ModelRef = modelconfigSDK.ModelRef(requestParams.RequestedModel)
-			legacyMRef legacyModelRef          = legacyModelRef(requestParams.RequestedModel)
-		)
+		mref, legacyMRef, err := parseRequestedModel(requestParams.RequestedModel)
+		if err != nil {
+			return "", err
+		}
 		// Now, for Cody Enterprise, if the caller requested a specific model we simply look
-		// it up in the site config. By definition, if it is found then the model is allowed.
+		// it up in the site config. If it is found then the model is allowed, unless the
+		// site config restricts which models can be used for chat.
 		for _, supportedModel := range cfg.Models {
-			// Requested model was in the newer format.
-			if supportedModel.ModelRef == mref {
-				return mref, nil
-			}
-			// If the request is using the older format, then we are relying on the
-			// assumption that the user-supplied legacyMRef's provider and model names
-			// match the ProviderID and ModelID. (Likely, but may not be the case.)
+			// The requested model may be in the newer format. If the request is using the
+			// older format, then we are relying on the assumption that the user-supplied
+			// legacyMRef's provider and model names match the ProviderID and ModelID.
+			// (Likely, but may not be the case.)
 			//
 			// e.g. in order to support "fireworks/star-coder", we require that the
 			// Sourcegraph instance has ap rovider named "fireworks" and a model with ID
 			// "star-coder".
-			if legacyMRef.EqualToIgnoringAPIVersion(supportedModel.ModelRef) {
-				return supportedModel.ModelRef, nil
+			if supportedModel.ModelRef != mref && !legacyMRef.EqualToIgnoringAPIVersion(supportedModel.ModelRef) {
+				continue
+			}
+			if !cfg.FeatureModels.ChatAllowed(supportedModel.ModelRef) {
+				return "", errors.Errorf(
+					"model %q is not allowed for chat", supportedModel.ModelRef)
 			}
+			return supportedModel.ModelRef, nil
 		}
 
-		err := errors.Errorf(
+		return "", errors.Errorf(
 			"unsupported code completion model %q (default %q)",
 			initialRequestedModel, cfg.DefaultModels.Chat)
-		return "", err
 	}
 }
 
+// codyProModelConfig returns the model configuration embedded in the binary,
+// which describes the models available to Cody Free and Cody Pro users.
+var codyProModelConfig = sync.OnceValues(embedded.GetCodyGatewayModelConfig)
+
+// isAllowedByModelConfig returns whether the embedded model configuration makes
+// the model available to Cody Free or Cody Pro users for the given capability.
+// Cody Pro users have access to models of the free and pro tiers, Cody Free
+// users only to those of the free tier.
+func isAllowedByModelConfig(model legacyModelRef, capability modelconfigSDK.ModelCapability, isProUser bool) bool {
+	cfg, err := codyProModelConfig()
+	if err != nil || cfg == nil {
+		return false
+	}
+
+	provider, name := model.Parse()
+	for _, m := range cfg.Models {
+		// Legacy model references use either the model ID or the name the
+		// provider knows the model by.
+		if provider != string(m.ModelRef.ProviderID()) {
+			continue
+		}
+		if name != string(m.ModelRef.ModelID()) && name != m.ModelName {
+			continue
+		}
+		if !slices.Contains(m.Capabilities, capability) {
+			continue
+		}
+		switch m.Tier {
+		case modelconfigSDK.ModelTierFree:
+			return true
+		case modelconfigSDK.ModelTierPro:
+			return isProUser
+		}
+	}
+	return false
+}
+
 // Returns whether or not Cody Pro users have access to the given model.
 // See the comment on `isAllowedCodyProModelChatModel` why this function
 // is required as we transition to using server-side LLM model configuration.
-//
-// BUG: This is temporary, and will be replaced when we support the site configuration
-// allowing a Sourcegraph instance to fall back to "Sourcegraph supplied" LLM models.
-//
-// For now, because that isn't possible, all a Sourcegraph instance can use to determine
-// which models are supported are the 3x that are put into the site's "completions config".
 func isAllowedCodyProCompletionModel(model legacyModelRef) bool {
+	if isAllowedByModelConfig(model, modelconfigSDK.ModelCapabilityAutocomplete, true) {
+		return true
+	}
+
+	// Fall back to the legacy allow-list for models that are missing from the
+	// model configuration, e.g. all Fireworks models, or if it is unavailable.
 	switch model {
 	case "fireworks/starcoder",
 		"fireworks/starcoder-16b",
@@ -289,12 +396,16 @@ func isAllowedCodyProCompletionModel(model legacyModelRef) bool {
 
 // Returns whether or not the supplied model is available to Cody Pro users.
 //
-// BUG: This is temporary, and will be replaced when we support the site configuration
-// allowing a Sourcegraph instance to fall back to "Sourcegraph supplied" LLM models.
-//
-// For now, because that isn't possible, all a Sourcegraph instance can use to determine
-// which models are supported are the 3x that are put into the site's "completions config".
+// Models are allowed based on their tier in the model configuration embedded in
+// the binary, so that adding a model there is enough to make it available.
 func isAllowedCodyProChatModel(model legacyModelRef, isProUser bool) bool {
+	if isAllowedByModelConfig(model, modelconfigSDK.ModelCapabilityChat, isProUser) {
+		return true
+	}
+
+	// Fall back to the legacy allow-lists for models that are missing from the
+	// model configuration, or if it is unavailable.
+	//
 	// When updating these two lists, make sure you also update `allowedModels` in codygateway_dotcom_user.go.
 	if isProUser {
 		switch model {
@@ -381,19 +492,34 @@ func resolveRequestedModel(
 	// So unfortunately we have to syntesize the Provider and Model objects dynamically. (And rely on the
 	// Cody Gateway completion provider to not get fancy and look for any client-side configuration data.)
 	if dotcom.SourcegraphDotComMode() {
+		serverSideConfig, err := dotcomServerSideConfig(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
 		fauxProvider := modelconfigSDK.Provider{
-			ID: mref.ProviderID(),
-			// If the instance is configured to be in dotcom mode, we assume the "completions.provider"
-			// is "sourcegraph", and therefore the ServerSideConfig will be set correctly.
-			// See `frontend/internal/modelconfig/siteconfig_completions_test.go`.
-			ServerSideConfig: cfg.Providers[0].ServerSideConfig,
+			ID:               mref.ProviderID(),
+			ServerSideConfig: serverSideConfig,
 		}
 		fauxModel := modelconfigSDK.Model{
 			ModelRef:  mref,
 			ModelName: string(mref.ModelID()),
-			// Leave everything invalid, even ContextWindow.
-			// Which will for the time being be set within the
-			// completion provider.
+			// Leave everything else invalid. The completion provider
+			// sets the actual limits for the time being.
+		}
+		// Take the capabilities and output limit from the known model, if
+		// any, so that we can validate the request against them.
+		known := findModelByID(cfg.Models, mref)
+		if known == nil {
+			if proCfg, err := codyProModelConfig(); err == nil && proCfg != nil {
+				known = findModelByID(proCfg.Models, mref)
+			}
+		}
+		if known != nil {
+			fauxModel.Capabilities = known.Capabilities
+			fauxModel.ContextWindow.MaxOutputTokens = known.ContextWindow.MaxOutputTokens
+		}
+		if err := validateThinkingBudget(request, &fauxModel); err != nil {
+			return nil, nil, err
 		}
 		return &fauxProvider, &fauxModel, nil
 	}
@@ -430,5 +556,96 @@ func resolveRequestedModel(
 		return nil, nil, errors.Errorf("unable to find model %q", mref)
 	}
 
+	if err := validateThinkingBudget(request, gotModel); err != nil {
+		return nil, nil, err
+	}
+
 	return gotProvider, gotModel, nil
 }
+
+// dotcomServerSideConfig returns the server-side config to use for all providers
+// on dotcom. If the instance is configured to be in dotcom mode, we assume the
+// "completions.provider" is "sourcegraph", and therefore the ServerSideConfig of
+// every provider is set to use the Sourcegraph API provider.
+// See `frontend/internal/modelconfig/siteconfig_completions_test.go`.
+func dotcomServerSideConfig(cfg *modelconfigSDK.ModelConfiguration) (*modelconfigSDK.ServerSideProviderConfig, error) {
+	if len(cfg.Providers) == 0 {
+		return nil, errors.New("invalid model configuration: no providers configured")
+	}
+	provider := cfg.Providers[0]
+	if provider.ServerSideConfig == nil || provider.ServerSideConfig.SourcegraphProvider == nil {
+		return nil, errors.Errorf(
+			"invalid model configuration: provider %q is not configured to use the Sourcegraph provider, "+
+				`is "completions.provider" set to "sourcegraph"?`, provider.ID)
+	}
+	return provider.ServerSideConfig, nil
+}
+
+// findModelByID returns the model with the same provider and model ID as mref,
+// ignoring the API version. Like legacy model references, the model ID of mref
+// may also be the name the provider knows the model by.
+func findModelByID(models []modelconfigSDK.Model, mref modelconfigSDK.ModelRef) *modelconfigSDK.Model {
+	for i := range models {
+		m := &models[i]
+		if m.ModelRef.ProviderID() != mref.ProviderID() {
+			continue
+		}
+		if m.ModelRef.ModelID() == mref.ModelID() || m.ModelName == string(mref.ModelID()) {
+			return m
+		}
+	}
+	return nil
+}
+
+// validateThinkingBudget checks that the request's ThinkingBudget, if any, is
+// something the resolved model can honor. Returned errors are user-facing.
+func validateThinkingBudget(request types.CodyCompletionRequestParameters, model *modelconfigSDK.Model) error {
+	switch {
+	case request.ThinkingBudget < 0:
+		return errors.Errorf("invalid thinking budget %d: must not be negative", request.ThinkingBudget)
+	case request.ThinkingBudget == 0:
+		return nil
+	}
+
+	if !slices.Contains(model.Capabilities, modelconfigSDK.ModelCapabilityReasoning) {
+		return errors.Errorf("model %q does not support reasoning, but a thinking budget was requested", model.ModelRef)
+	}
+	if maxOutput := model.ContextWindow.MaxOutputTokens; maxOutput > 0 && request.ThinkingBudget >= maxOutput {
+		return errors.Errorf(
+			"thinking budget %d must be less than the model's max output tokens (%d)",
+			request.ThinkingBudget, maxOutput)
+	}
+	return nil
+}
+
+// specifiedSamplingParameters records which sampling parameters were present in
+// the request body. Unlike types.CodyCompletionRequestParameters, it tells an
+// omitted parameter apart from one explicitly set to its zero value.
+type specifiedSamplingParameters struct {
+	Temperature   *float32 `json:"temperature"`
+	TopK          *int     `json:"topK"`
+	TopP          *float32 `json:"topP"`
+	StopSequences []string `json:"stopSequences"`
+}
+
+// applyModelDefaultParameters fills in any request parameters the client did not
+// specify with the defaults configured for the resolved model.
+func applyModelDefaultParameters(request *types.CodyCompletionRequestParameters, specified specifiedSamplingParameters, model *modelconfigSDK.Model) {
+	defaults := model.DefaultParameters
+	if defaults == nil {
+		return
+	}
+
+	if specified.Temperature == nil {
+		request.Temperature = defaults.Temperature
+	}
+	if specified.TopK == nil {
+		request.TopK = defaults.TopK
+	}
+	if specified.TopP == nil {
+		request.TopP = defaults.TopP
+	}
+	if specified.StopSequences == nil && len(defaults.StopSequences) > 0 {
+		request.StopSequences = slices.Clone(defaults.StopSequences)
+	}
+}
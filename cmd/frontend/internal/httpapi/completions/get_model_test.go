@@ -2,14 +2,18 @@ package completions
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 
+	"github.com/sourcegraph/log/logtest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/sourcegraph/sourcegraph/internal/completions/types"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbmocks"
+	"github.com/sourcegraph/sourcegraph/internal/dotcom"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
 
 	modelconfigSDK "github.com/sourcegraph/sourcegraph/internal/modelconfig/types"
 )
@@ -248,6 +252,77 @@ func TestCodyProModelAllowlists(t *testing.T) {
 	})
 }
 
+func TestCodyProModelConfig(t *testing.T) {
+	mockModelConfig := func(t *testing.T, cfg *modelconfigSDK.ModelConfiguration, err error) {
+		orig := codyProModelConfig
+		codyProModelConfig = func() (*modelconfigSDK.ModelConfiguration, error) { return cfg, err }
+		t.Cleanup(func() { codyProModelConfig = orig })
+	}
+
+	t.Run("EmbeddedConfig", func(t *testing.T) {
+		// Models in the embedded config are allowed by their ID or their name.
+		assert.True(t, isAllowedCodyProChatModel("anthropic/claude-3-opus", true))
+		assert.True(t, isAllowedCodyProChatModel("anthropic/claude-3-opus-20240229", true))
+		assert.True(t, isAllowedCodyProChatModel("mistral/mixtral-8x7b-instruct", true))
+		assert.False(t, isAllowedCodyProChatModel("mistral/mixtral-8x7b-instruct", false))
+	})
+
+	t.Run("NewModels", func(t *testing.T) {
+		mockModelConfig(t, &modelconfigSDK.ModelConfiguration{
+			Models: []modelconfigSDK.Model{
+				{
+					ModelRef:     "openai::2024-02-01::gpt-5",
+					ModelName:    "gpt-5-2025-01-01",
+					Capabilities: []modelconfigSDK.ModelCapability{modelconfigSDK.ModelCapabilityChat},
+					Tier:         modelconfigSDK.ModelTierPro,
+				},
+				{
+					ModelRef:     "anthropic::2023-06-01::claude-4-sonnet",
+					ModelName:    "claude-4-sonnet",
+					Capabilities: []modelconfigSDK.ModelCapability{modelconfigSDK.ModelCapabilityChat, modelconfigSDK.ModelCapabilityAutocomplete},
+					Tier:         modelconfigSDK.ModelTierFree,
+				},
+				{
+					ModelRef:     "openai::2024-02-01::gpt-5-enterprise",
+					ModelName:    "gpt-5-enterprise",
+					Capabilities: []modelconfigSDK.ModelCapability{modelconfigSDK.ModelCapabilityChat},
+					Tier:         modelconfigSDK.ModelTierEnterprise,
+				},
+			},
+		}, nil)
+
+		// Pro tier models are only available to Cody Pro users.
+		assert.True(t, isAllowedCodyProChatModel("openai/gpt-5", true))
+		assert.True(t, isAllowedCodyProChatModel("openai/gpt-5-2025-01-01", true))
+		assert.False(t, isAllowedCodyProChatModel("openai/gpt-5", false))
+		assert.False(t, isAllowedCodyProCompletionModel("openai/gpt-5"))
+
+		// Free tier models are available to everyone.
+		assert.True(t, isAllowedCodyProChatModel("anthropic/claude-4-sonnet", true))
+		assert.True(t, isAllowedCodyProChatModel("anthropic/claude-4-sonnet", false))
+		assert.True(t, isAllowedCodyProCompletionModel("anthropic/claude-4-sonnet"))
+
+		// Enterprise tier models are not available on dotcom.
+		assert.False(t, isAllowedCodyProChatModel("openai/gpt-5-enterprise", true))
+
+		// The provider must match.
+		assert.False(t, isAllowedCodyProChatModel("google/gpt-5", true))
+
+		// Models that are missing from the config fall back to the legacy lists.
+		assert.True(t, isAllowedCodyProCompletionModel("fireworks/starcoder2-7b"))
+		assert.True(t, isAllowedCodyProChatModel("openai/gpt-4o", true))
+	})
+
+	t.Run("ConfigUnavailable", func(t *testing.T) {
+		mockModelConfig(t, nil, errors.New("boom"))
+
+		assert.True(t, isAllowedCodyProChatModel("openai/gpt-4o", true))
+		assert.False(t, isAllowedCodyProChatModel("openai/gpt-4o", false))
+		assert.True(t, isAllowedCodyProCompletionModel("fireworks/starcoder2-7b"))
+		assert.False(t, isAllowedCodyProChatModel("mistral/mixtral-8x7b-instruct", true))
+	})
+}
+
 func TestGetCodeCompletionsModelFn(t *testing.T) {
 	ctx := context.Background()
 	getModelFn := getCodeCompletionModelFn()
@@ -316,6 +391,92 @@ func TestGetCodeCompletionsModelFn(t *testing.T) {
 			assert.EqualValues(t, "code-model-in-config", model)
 		})
 	})
+
+	t.Run("Fast", func(t *testing.T) {
+		modelConfig := modelconfigSDK.ModelConfiguration{
+			Models: []modelconfigSDK.Model{
+				{ModelRef: "code-model-in-config"},
+				{ModelRef: "fast-code-model-in-config"},
+			},
+			DefaultModels: modelconfigSDK.DefaultModels{
+				CodeCompletion:     "code-model-in-config",
+				FastCodeCompletion: "fast-code-model-in-config",
+			},
+		}
+		reqParams := types.CodyCompletionRequestParameters{
+			CompletionRequestParameters: types.CompletionRequestParameters{
+				RequestedModel: "code-model-in-config",
+			},
+			Fast: true,
+		}
+
+		t.Run("FastCodeCompletion", func(t *testing.T) {
+			model, err := getModelFn(ctx, reqParams, &modelConfig)
+			require.NoError(t, err)
+			// We use the FastCodeCompletion model, regardless of what the user requested.
+			assert.EqualValues(t, "fast-code-model-in-config", model)
+		})
+
+		t.Run("FallbackToCodeCompletion", func(t *testing.T) {
+			noFastModel := modelConfig
+			noFastModel.DefaultModels.FastCodeCompletion = ""
+			model, err := getModelFn(ctx, reqParams, &noFastModel)
+			require.NoError(t, err)
+			assert.EqualValues(t, "code-model-in-config", model)
+		})
+	})
+}
+
+func TestParseRequestedModel(t *testing.T) {
+	ctx := context.Background()
+	modelConfig := modelconfigSDK.ModelConfiguration{
+		Models: []modelconfigSDK.Model{
+			{ModelRef: "anthropic::2023-06-01::claude-3-sonnet"},
+		},
+	}
+	reqParams := func(model string) types.CodyCompletionRequestParameters {
+		return types.CodyCompletionRequestParameters{
+			CompletionRequestParameters: types.CompletionRequestParameters{
+				RequestedModel: types.TaintedModelRef(model),
+			},
+		}
+	}
+
+	t.Run("Legacy", func(t *testing.T) {
+		mref, legacyMRef, err := parseRequestedModel("anthropic/claude-3-sonnet")
+		require.NoError(t, err)
+		assert.EqualValues(t, "anthropic/claude-3-sonnet", mref)
+		assert.EqualValues(t, "anthropic/claude-3-sonnet", legacyMRef)
+	})
+
+	t.Run("ModelRef", func(t *testing.T) {
+		mref, legacyMRef, err := parseRequestedModel("anthropic::2023-06-01::claude-3-sonnet")
+		require.NoError(t, err)
+		assert.EqualValues(t, "anthropic::2023-06-01::claude-3-sonnet", mref)
+		assert.Empty(t, legacyMRef)
+
+		model, err := getChatModelFn(dbmocks.NewMockDB())(ctx, reqParams("anthropic::2023-06-01::claude-3-sonnet"), &modelConfig)
+		require.NoError(t, err)
+		assert.EqualValues(t, "anthropic::2023-06-01::claude-3-sonnet", model)
+	})
+
+	// A model containing both "::" and "/" must not be parsed as a legacy
+	// "provider/model" reference.
+	t.Run("Ambiguous", func(t *testing.T) {
+		_, _, err := parseRequestedModel("a/b::c::d")
+		require.ErrorContains(t, err, `invalid model reference "a/b::c::d": invalid ProviderID`)
+
+		_, err = getChatModelFn(dbmocks.NewMockDB())(ctx, reqParams("anthropic/claude-3-sonnet::x::y"), &modelConfig)
+		require.ErrorContains(t, err, `invalid model reference "anthropic/claude-3-sonnet::x::y"`)
+
+		_, err = getCodeCompletionModelFn()(ctx, reqParams("a/b::c::d"), &modelConfig)
+		require.ErrorContains(t, err, `invalid model reference "a/b::c::d"`)
+	})
+
+	t.Run("MalformedModelRef", func(t *testing.T) {
+		_, _, err := parseRequestedModel("anthropic::claude-3-sonnet")
+		require.ErrorContains(t, err, "modelRef syntax error")
+	})
 }
 
 func TestGetChatModelFn(t *testing.T) {
@@ -390,4 +551,347 @@ func TestGetChatModelFn(t *testing.T) {
 	// TODO(PRIME-283): As part of enabling model selection for Cody Enterprise users,
 	// add more tests for the Cody Pro path as well. Where we only allow certain models
 	// based on the calling user's subscription status, etc.
+
+	t.Run("CodyPro", func(t *testing.T) {
+		t.Run("Allowed", func(t *testing.T) {
+			model, err := getCodyProChatModel("anthropic/claude-3-opus", true)
+			require.NoError(t, err)
+			assert.EqualValues(t, "anthropic::unknown::claude-3-opus", model)
+		})
+
+		t.Run("NotAllowedOnFreeTier", func(t *testing.T) {
+			_, err := getCodyProChatModel("anthropic/claude-3-opus", false)
+
+			var notAllowedErr ErrModelNotAllowed
+			require.True(t, errors.As(err, &notAllowedErr))
+			assert.Equal(t, ErrModelNotAllowed{Model: "anthropic/claude-3-opus", IsProUser: false}, notAllowedErr)
+			assert.EqualError(t, err, `the requested model "anthropic/claude-3-opus" is not available on the Cody Free tier`)
+		})
+	})
+}
+
+func TestGetModelFn_FeatureModels(t *testing.T) {
+	ctx := context.Background()
+	mockDB := dbmocks.NewMockDB()
+
+	modelConfig := modelconfigSDK.ModelConfiguration{
+		Models: []modelconfigSDK.Model{
+			{ModelRef: "anthropic::2023-06-01::claude-3-sonnet"},
+			{ModelRef: "fireworks::v1::starcoder"},
+		},
+		DefaultModels: modelconfigSDK.DefaultModels{
+			Chat:           "anthropic::2023-06-01::claude-3-sonnet",
+			CodeCompletion: "fireworks::v1::starcoder",
+		},
+		FeatureModels: &modelconfigSDK.FeatureModels{
+			Chat: []modelconfigSDK.ModelRef{
+				"anthropic::2023-06-01::claude-3-sonnet",
+			},
+			CodeCompletion: []modelconfigSDK.ModelRef{
+				"fireworks::v1::starcoder",
+			},
+		},
+	}
+	reqParams := func(model string) types.CodyCompletionRequestParameters {
+		return types.CodyCompletionRequestParameters{
+			CompletionRequestParameters: types.CompletionRequestParameters{
+				RequestedModel: types.TaintedModelRef(model),
+			},
+		}
+	}
+
+	t.Run("AllowedForChat", func(t *testing.T) {
+		model, err := getChatModelFn(mockDB)(ctx, reqParams("anthropic::2023-06-01::claude-3-sonnet"), &modelConfig)
+		require.NoError(t, err)
+		assert.EqualValues(t, "anthropic::2023-06-01::claude-3-sonnet", model)
+	})
+
+	t.Run("DeniedForCodeCompletion", func(t *testing.T) {
+		_, err := getCodeCompletionModelFn()(ctx, reqParams("anthropic::2023-06-01::claude-3-sonnet"), &modelConfig)
+		require.ErrorContains(t, err, `model "anthropic::2023-06-01::claude-3-sonnet" is not allowed for code completion`)
+
+		// Legacy model references are restricted the same way.
+		_, err = getCodeCompletionModelFn()(ctx, reqParams("anthropic/claude-3-sonnet"), &modelConfig)
+		require.ErrorContains(t, err, "is not allowed for code completion")
+	})
+
+	t.Run("DeniedForChat", func(t *testing.T) {
+		_, err := getChatModelFn(mockDB)(ctx, reqParams("fireworks::v1::starcoder"), &modelConfig)
+		require.ErrorContains(t, err, `model "fireworks::v1::starcoder" is not allowed for chat`)
+	})
+
+	t.Run("DefaultModelDenied", func(t *testing.T) {
+		restricted := modelConfig
+		restricted.FeatureModels = &modelconfigSDK.FeatureModels{
+			Chat:           []modelconfigSDK.ModelRef{"fireworks::v1::starcoder"},
+			CodeCompletion: []modelconfigSDK.ModelRef{"anthropic::2023-06-01::claude-3-sonnet"},
+		}
+		_, err := getChatModelFn(mockDB)(ctx, reqParams(""), &restricted)
+		require.ErrorContains(t, err, `default model "anthropic::2023-06-01::claude-3-sonnet" is not allowed for chat`)
+
+		_, err = getCodeCompletionModelFn()(ctx, reqParams(""), &restricted)
+		require.ErrorContains(t, err, `default model "fireworks::v1::starcoder" is not allowed for code completion`)
+	})
+
+	t.Run("NoRestrictions", func(t *testing.T) {
+		unrestricted := modelConfig
+		unrestricted.FeatureModels = nil
+		model, err := getCodeCompletionModelFn()(ctx, reqParams("anthropic::2023-06-01::claude-3-sonnet"), &unrestricted)
+		require.NoError(t, err)
+		assert.EqualValues(t, "anthropic::2023-06-01::claude-3-sonnet", model)
+	})
+}
+
+func TestResolveRequestedModel_ThinkingBudget(t *testing.T) {
+	ctx := context.Background()
+	logger := logtest.Scoped(t)
+
+	const (
+		reasoningMRef    modelconfigSDK.ModelRef = "anthropic::2023-06-01::reasoning-model"
+		nonReasoningMRef modelconfigSDK.ModelRef = "anthropic::2023-06-01::plain-model"
+	)
+	modelConfig := modelconfigSDK.ModelConfiguration{
+		Providers: []modelconfigSDK.Provider{
+			{ID: "anthropic"},
+		},
+		Models: []modelconfigSDK.Model{
+			{
+				ModelRef: reasoningMRef,
+				Capabilities: []modelconfigSDK.ModelCapability{
+					modelconfigSDK.ModelCapabilityChat,
+					modelconfigSDK.ModelCapabilityReasoning,
+				},
+				ContextWindow: modelconfigSDK.ContextWindow{MaxOutputTokens: 8000},
+			},
+			{
+				ModelRef: nonReasoningMRef,
+				Capabilities: []modelconfigSDK.ModelCapability{
+					modelconfigSDK.ModelCapabilityChat,
+				},
+				ContextWindow: modelconfigSDK.ContextWindow{MaxOutputTokens: 8000},
+			},
+		},
+	}
+
+	resolve := func(mref modelconfigSDK.ModelRef, budget int) (*modelconfigSDK.Model, error) {
+		var mockFn mockGetModelFn
+		mockFn.PushResult(mref, nil)
+		request := types.CodyCompletionRequestParameters{
+			CompletionRequestParameters: types.CompletionRequestParameters{
+				RequestedModel: types.TaintedModelRef(mref),
+				ThinkingBudget: budget,
+			},
+		}
+		_, model, err := resolveRequestedModel(ctx, logger, &modelConfig, request, mockFn.ToFunc())
+		return model, err
+	}
+
+	t.Run("ReasoningModel", func(t *testing.T) {
+		model, err := resolve(reasoningMRef, 2000)
+		require.NoError(t, err)
+		assert.Equal(t, reasoningMRef, model.ModelRef)
+	})
+
+	t.Run("ReasoningModelBudgetTooLarge", func(t *testing.T) {
+		_, err := resolve(reasoningMRef, 8000)
+		require.ErrorContains(t, err, "must be less than the model's max output tokens")
+	})
+
+	t.Run("NonReasoningModel", func(t *testing.T) {
+		_, err := resolve(nonReasoningMRef, 2000)
+		require.ErrorContains(t, err, `model "anthropic::2023-06-01::plain-model" does not support reasoning`)
+	})
+
+	t.Run("NonReasoningModelNoBudget", func(t *testing.T) {
+		model, err := resolve(nonReasoningMRef, 0)
+		require.NoError(t, err)
+		assert.Equal(t, nonReasoningMRef, model.ModelRef)
+	})
+
+	t.Run("NegativeBudget", func(t *testing.T) {
+		_, err := resolve(reasoningMRef, -1)
+		require.ErrorContains(t, err, "must not be negative")
+	})
+}
+
+func TestResolveRequestedModel_Dotcom(t *testing.T) {
+	dotcom.MockSourcegraphDotComMode(t, true)
+
+	ctx := context.Background()
+	logger := logtest.Scoped(t)
+
+	const mref modelconfigSDK.ModelRef = "anthropic::unknown::claude-3-sonnet-20240229"
+	resolve := func(providers []modelconfigSDK.Provider) (*modelconfigSDK.Provider, *modelconfigSDK.Model, error) {
+		var mockFn mockGetModelFn
+		mockFn.PushResult(mref, nil)
+		request := types.CodyCompletionRequestParameters{
+			CompletionRequestParameters: types.CompletionRequestParameters{
+				RequestedModel: types.TaintedModelRef(mref),
+			},
+		}
+		modelConfig := modelconfigSDK.ModelConfiguration{Providers: providers}
+		return resolveRequestedModel(ctx, logger, &modelConfig, request, mockFn.ToFunc())
+	}
+
+	t.Run("SourcegraphProvider", func(t *testing.T) {
+		sgConfig := &modelconfigSDK.ServerSideProviderConfig{
+			SourcegraphProvider: &modelconfigSDK.SourcegraphProviderConfig{
+				AccessToken: "secret",
+				Endpoint:    "https://cody-gateway.sourcegraph.com",
+			},
+		}
+		provider, model, err := resolve([]modelconfigSDK.Provider{
+			{ID: "fireworks", ServerSideConfig: sgConfig},
+		})
+		require.NoError(t, err)
+		assert.EqualValues(t, "anthropic", provider.ID)
+		assert.Equal(t, sgConfig, provider.ServerSideConfig)
+		assert.Equal(t, mref, model.ModelRef)
+		assert.Equal(t, "claude-3-sonnet-20240229", model.ModelName)
+	})
+
+	t.Run("NoProviders", func(t *testing.T) {
+		_, _, err := resolve(nil)
+		require.ErrorContains(t, err, "invalid model configuration: no providers configured")
+	})
+
+	t.Run("NotSourcegraphProvider", func(t *testing.T) {
+		_, _, err := resolve([]modelconfigSDK.Provider{
+			{
+				ID: "anthropic",
+				ServerSideConfig: &modelconfigSDK.ServerSideProviderConfig{
+					GenericProvider: &modelconfigSDK.GenericProviderConfig{
+						ServiceName: modelconfigSDK.GenericServiceProviderAnthropic,
+					},
+				},
+			},
+		})
+		require.ErrorContains(t, err, `provider "anthropic" is not configured to use the Sourcegraph provider`)
+	})
+
+	t.Run("NoServerSideConfig", func(t *testing.T) {
+		_, _, err := resolve([]modelconfigSDK.Provider{{ID: "anthropic"}})
+		require.ErrorContains(t, err, `provider "anthropic" is not configured to use the Sourcegraph provider`)
+	})
+}
+
+func TestResolveRequestedModel_DotcomThinkingBudget(t *testing.T) {
+	dotcom.MockSourcegraphDotComMode(t, true)
+
+	ctx := context.Background()
+	logger := logtest.Scoped(t)
+
+	const mref modelconfigSDK.ModelRef = "anthropic::unknown::reasoning-model"
+	modelConfig := modelconfigSDK.ModelConfiguration{
+		Providers: []modelconfigSDK.Provider{{
+			ID: "anthropic",
+			ServerSideConfig: &modelconfigSDK.ServerSideProviderConfig{
+				SourcegraphProvider: &modelconfigSDK.SourcegraphProviderConfig{
+					AccessToken: "secret",
+					Endpoint:    "https://cody-gateway.sourcegraph.com",
+				},
+			},
+		}},
+		Models: []modelconfigSDK.Model{{
+			ModelRef: "anthropic::2023-06-01::reasoning-model",
+			Capabilities: []modelconfigSDK.ModelCapability{
+				modelconfigSDK.ModelCapabilityChat,
+				modelconfigSDK.ModelCapabilityReasoning,
+			},
+			ContextWindow: modelconfigSDK.ContextWindow{MaxOutputTokens: 8000},
+		}},
+	}
+
+	resolve := func(budget int) (*modelconfigSDK.Model, error) {
+		var mockFn mockGetModelFn
+		mockFn.PushResult(mref, nil)
+		request := types.CodyCompletionRequestParameters{
+			CompletionRequestParameters: types.CompletionRequestParameters{
+				RequestedModel: types.TaintedModelRef(mref),
+				ThinkingBudget: budget,
+			},
+		}
+		_, model, err := resolveRequestedModel(ctx, logger, &modelConfig, request, mockFn.ToFunc())
+		return model, err
+	}
+
+	model, err := resolve(2000)
+	require.NoError(t, err)
+	assert.Equal(t, mref, model.ModelRef)
+	assert.Contains(t, model.Capabilities, modelconfigSDK.ModelCapabilityReasoning)
+	assert.Equal(t, 8000, model.ContextWindow.MaxOutputTokens)
+
+	_, err = resolve(8000)
+	require.ErrorContains(t, err, "must be less than the model's max output tokens")
+}
+
+func TestApplyModelDefaultParameters(t *testing.T) {
+	model := modelconfigSDK.Model{
+		ModelRef: "anthropic::2023-06-01::claude-3-sonnet",
+		DefaultParameters: &modelconfigSDK.ModelDefaultParameters{
+			Temperature:   0.2,
+			TopK:          40,
+			TopP:          0.95,
+			StopSequences: []string{"</code>"},
+		},
+	}
+
+	t.Run("RequestOmitsParameters", func(t *testing.T) {
+		var request types.CodyCompletionRequestParameters
+		applyModelDefaultParameters(&request, specifiedSamplingParameters{}, &model)
+
+		assert.Equal(t, float32(0.2), request.Temperature)
+		assert.Equal(t, 40, request.TopK)
+		assert.Equal(t, float32(0.95), request.TopP)
+		assert.Equal(t, []string{"</code>"}, request.StopSequences)
+	})
+
+	t.Run("RequestSpecifiesParameters", func(t *testing.T) {
+		request := types.CodyCompletionRequestParameters{
+			CompletionRequestParameters: types.CompletionRequestParameters{
+				Temperature:   0.7,
+				TopK:          10,
+				TopP:          0.5,
+				StopSequences: []string{"\n\n"},
+			},
+		}
+		specified := parseSpecifiedParameters(t, `{"temperature": 0.7, "topK": 10, "topP": 0.5, "stopSequences": ["\n\n"]}`)
+		applyModelDefaultParameters(&request, specified, &model)
+
+		assert.Equal(t, float32(0.7), request.Temperature)
+		assert.Equal(t, 10, request.TopK)
+		assert.Equal(t, float32(0.5), request.TopP)
+		assert.Equal(t, []string{"\n\n"}, request.StopSequences)
+	})
+
+	t.Run("RequestSpecifiesZeroParameters", func(t *testing.T) {
+		var request types.CodyCompletionRequestParameters
+		specified := parseSpecifiedParameters(t, `{"temperature": 0, "topK": 0, "topP": 0, "stopSequences": []}`)
+		applyModelDefaultParameters(&request, specified, &model)
+
+		assert.Zero(t, request.Temperature)
+		assert.Zero(t, request.TopK)
+		assert.Zero(t, request.TopP)
+		assert.Empty(t, request.StopSequences)
+	})
+
+	t.Run("NoModelDefaults", func(t *testing.T) {
+		request := types.CodyCompletionRequestParameters{
+			CompletionRequestParameters: types.CompletionRequestParameters{
+				Temperature: 0.7,
+			},
+		}
+		applyModelDefaultParameters(&request, specifiedSamplingParameters{}, &modelconfigSDK.Model{})
+
+		assert.Equal(t, float32(0.7), request.Temperature)
+		assert.Zero(t, request.TopK)
+		assert.Empty(t, request.StopSequences)
+	})
+}
+
+func parseSpecifiedParameters(t *testing.T, body string) specifiedSamplingParameters {
+	t.Helper()
+	var specified specifiedSamplingParameters
+	require.NoError(t, json.Unmarshal([]byte(body), &specified))
+	return specified
 }
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"sync"
@@ -42,6 +43,15 @@ import (
 // being cancelled as DeadlineExceeded.
 const maxRequestDuration = 8 * time.Minute
 
+// maxRequestBodyBytes is the maximum size of a completions request body. It
+// leaves room for JSON escaping and the other request parameters on top of the
+// default limit of the prompt size, see client.DefaultRequestLimits.
+const maxRequestBodyBytes = 32 * 1024 * 1024
+
+// errRequestBodyTooLarge is returned by readRequestBody if the body exceeds
+// the limit.
+var errRequestBodyTooLarge = errors.New("request body too large")
+
 var timeToFirstEventMetrics = metrics.NewREDMetrics(
 	prometheus.DefaultRegisterer,
 	"completions_stream_first_event",
@@ -58,6 +68,7 @@ func newCompletionsHandler(
 	grAttributionTest guardrails.AttributionTest,
 	feature types.CompletionsFeature,
 	rl RateLimiter,
+	budget TokenBudget,
 	traceFamily string,
 	getModel getModelFn,
 ) http.Handler {
@@ -102,15 +113,46 @@ func newCompletionsHandler(
 			}
 		}
 
+		// Reject bodies that announce their size up front as too large before
+		// reading them, readRequestBody catches the others.
+		if r.ContentLength > maxRequestBodyBytes {
+			http.Error(w, fmt.Sprintf("%s, the limit is %d bytes", errRequestBodyTooLarge, maxRequestBodyBytes), http.StatusRequestEntityTooLarge)
+			return
+		}
+
 		// We don't perform any sort of validation. So we would silently accept a totally bogus
 		// JSON payload. And just have a zero-value CompletionRequestParameters, e.g. no prompt.
+		body, err := readRequestBody(r.Body, maxRequestBodyBytes)
+		if err != nil {
+			if errors.Is(err, errRequestBodyTooLarge) {
+				http.Error(w, fmt.Sprintf("%s, the limit is %d bytes", err, maxRequestBodyBytes), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "could not read request body", http.StatusBadRequest)
+			return
+		}
 		var requestParams types.CodyCompletionRequestParameters
-		if err := json.NewDecoder(r.Body).Decode(&requestParams); err != nil {
+		if err := json.Unmarshal(body, &requestParams); err != nil {
+			logger.Warn("malformed CodyCompletionRequestParameters", log.Error(err))
+			http.Error(w, "could not decode request body", http.StatusBadRequest)
+			return
+		}
+		// Track which sampling parameters the client set explicitly, so that an
+		// explicit zero value is not replaced by the model's defaults.
+		var specifiedParams specifiedSamplingParameters
+		if err := json.Unmarshal(body, &specifiedParams); err != nil {
 			logger.Warn("malformed CodyCompletionRequestParameters", log.Error(err))
 			http.Error(w, "could not decode request body", http.StatusBadRequest)
 			return
 		}
 
+		// Reject oversized requests before doing any more work. The completions
+		// client enforces this as well, but we can return a proper status here.
+		if err := client.DefaultRequestLimits().Check(requestParams.CompletionRequestParameters); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+
 		// Load the current LLM model configuration for the Sourcegraph instance.
 		modelConfigSvc := modelconfig.Get()
 		currentModelConfig, err := modelConfigSvc.Get()
@@ -127,9 +169,15 @@ func newCompletionsHandler(
 			// NOTE: We return the raw error to the user assuming that it contains relevant
 			// user-facing diagnostic information, and doesn't leak any internal details.
 			logger.Info("error resolving model", log.Error(err))
+			var notAllowedErr ErrModelNotAllowed
+			if errors.As(err, &notAllowedErr) {
+				http.Error(w, notAllowedErr.Error(), http.StatusForbidden)
+				return
+			}
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+		applyModelDefaultParameters(&requestParams, specifiedParams, modelConfig)
 
 		ctx, done := Trace(ctx, traceFamily, modelConfig.ModelName, requestParams.MaxTokensToSample).
 			WithErrorP(&err).
@@ -211,6 +259,20 @@ func newCompletionsHandler(
 			}
 		}
 
+		// Check the token budget of the user's organizations.
+		if err := budget.Check(ctx); err != nil {
+			var budgetErr TokenBudgetExceededError
+			if errors.As(err, &budgetErr) {
+				w.Header().Set("retry-after", budgetErr.ResetAt.Format(time.RFC1123))
+				http.Error(w, budgetErr.Error(), http.StatusTooManyRequests)
+				return
+			}
+			l.Warn("Token budget error", log.Error(err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		completionClient = WithTokenBudget(completionClient, budget)
+
 		// Finally serve the request.
 		fullCompletionRequest := types.CompletionRequest{
 			Feature:         feature,
@@ -273,7 +335,7 @@ func getCallersCodyProAccessToken(
 func respondRateLimited(w http.ResponseWriter, err RateLimitExceededError, isDotcom, isProUser bool) {
 	// Rate limit exceeded, write well known headers and return correct status code.
 	w.Header().Set("x-ratelimit-limit", strconv.Itoa(err.Limit))
-	w.Header().Set("x-ratelimit-remaining", strconv.Itoa(max(err.Limit-err.Used, 0)))
+	w.Header().Set("x-ratelimit-remaining", strconv.Itoa(err.Remaining()))
 	w.Header().Set("retry-after", err.RetryAfter.Format(time.RFC1123))
 	if isDotcom {
 		if isProUser {
@@ -513,6 +575,19 @@ func serveSyncResponse(
 	_, _ = w.Write(completionBytes)
 }
 
+// readRequestBody reads all of body, failing with errRequestBodyTooLarge if
+// it is longer than limit bytes.
+func readRequestBody(body io.Reader, limit int64) ([]byte, error) {
+	b, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > limit {
+		return nil, errRequestBodyTooLarge
+	}
+	return b, nil
+}
+
 type codyIgnoreCompatibilityError struct {
 	reason     string
 	statusCode int
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -402,3 +403,12 @@ func TestCheckClientCodyIgnoreCompatibility(t *testing.T) {
 		})
 	}
 }
+
+func TestReadRequestBody(t *testing.T) {
+	body, err := readRequestBody(strings.NewReader("1234"), 4)
+	require.NoError(t, err)
+	require.Equal(t, "1234", string(body))
+
+	_, err = readRequestBody(strings.NewReader("12345"), 4)
+	require.ErrorIs(t, err, errRequestBodyTooLarge)
+}
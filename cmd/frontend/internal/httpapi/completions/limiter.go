@@ -32,6 +32,22 @@ func (e RateLimitExceededError) Error() string {
 	return fmt.Sprintf("you exceeded the rate limit for %s, only %d requests are allowed per day at the moment to ensure the service stays functional. Current usage: %d. Retry after %s", e.Scope, e.Limit, e.Used, e.RetryAfter.Truncate(time.Second))
 }
 
+// Remaining returns the number of requests left until the limit resets.
+func (e RateLimitExceededError) Remaining() int {
+	return max(e.Limit-e.Used, 0)
+}
+
+// Extensions exposes the rate limit state to GraphQL clients, so that they can
+// back off until the limit resets.
+func (e RateLimitExceededError) Extensions() map[string]any {
+	return map[string]any{
+		"code":       "ErrRateLimitExceeded",
+		"limit":      e.Limit,
+		"remaining":  e.Remaining(),
+		"retryAfter": e.RetryAfter.Format(time.RFC3339),
+	}
+}
+
 func NewRateLimiter(db database.DB, rstore redispool.KeyValue, scope types.CompletionsFeature) RateLimiter {
 	return &rateLimiter{db: db, rstore: rstore, scope: scope}
 }
@@ -0,0 +1,47 @@
+package completions
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/completions/types"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbmocks"
+	"github.com/sourcegraph/sourcegraph/internal/redispool"
+	"github.com/sourcegraph/sourcegraph/lib/pointers"
+)
+
+func TestRateLimiter_TryAcquireExceeded(t *testing.T) {
+	users := dbmocks.NewMockUserStore()
+	users.GetChatCompletionsQuotaFunc.SetDefaultReturn(pointers.Ptr(10), nil)
+	db := dbmocks.NewMockDB()
+	db.UsersFunc.SetDefaultReturn(users)
+
+	kv := redispool.NewMockKeyValue()
+	kv.WithContextFunc.SetDefaultReturn(kv)
+	// The counter keeps increasing past the limit, see TryAcquire.
+	kv.GetFunc.SetDefaultReturn(redispool.NewValue(int64(12), nil))
+	kv.TTLFunc.SetDefaultReturn(int(time.Hour/time.Second), nil)
+
+	ctx := actor.WithActor(context.Background(), actor.FromUser(1))
+	err := NewRateLimiter(db, kv, types.CompletionsFeatureChat).TryAcquire(ctx)
+
+	var rateLimitErr RateLimitExceededError
+	require.ErrorAs(t, err, &rateLimitErr)
+	require.Equal(t, 10, rateLimitErr.Limit)
+	require.Equal(t, 0, rateLimitErr.Remaining())
+	require.WithinDuration(t, time.Now().Add(time.Hour), rateLimitErr.RetryAfter, time.Minute)
+
+	require.Equal(t, map[string]any{
+		"code":       "ErrRateLimitExceeded",
+		"limit":      10,
+		"remaining":  0,
+		"retryAfter": rateLimitErr.RetryAfter.Format(time.RFC3339),
+	}, rateLimitErr.Extensions())
+
+	// No quota must be consumed when the limit is exceeded.
+	require.Empty(t, kv.IncrFunc.History())
+}
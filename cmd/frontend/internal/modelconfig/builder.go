@@ -251,6 +251,7 @@ func applySiteConfig(baseConfig *types.ModelConfiguration, siteConfig *types.Sit
 		mergedConfig.DefaultModels.Chat = siteConfig.DefaultModels.Chat
 		mergedConfig.DefaultModels.CodeCompletion = siteConfig.DefaultModels.CodeCompletion
 		mergedConfig.DefaultModels.FastChat = siteConfig.DefaultModels.FastChat
+		mergedConfig.DefaultModels.FastCodeCompletion = siteConfig.DefaultModels.FastCodeCompletion
 	} else {
 		// getModelWithRequirements returns the the first model available with the specific capability and a matching
 		// category. Returns nil if no such model is found.
@@ -310,6 +311,9 @@ func applySiteConfig(baseConfig *types.ModelConfiguration, siteConfig *types.Sit
 		}
 	}
 
+	// Per-feature model restrictions only come from the site config.
+	mergedConfig.FeatureModels = siteConfig.FeatureModels
+
 	// Validate the resulting configuration.
 	if err := modelconfig.ValidateModelConfig(mergedConfig); err != nil {
 		return nil, errors.Wrap(err, "result of application was invalid configuration")
@@ -36,6 +36,7 @@ func convertModelConfiguration(v *schema.SiteModelConfiguration) *types.SiteMode
 		ProviderOverrides:      convertProviderOverrides(v.ProviderOverrides),
 		ModelOverrides:         convertModelOverrides(v),
 		DefaultModels:          convertDefaultModels(v.DefaultModels),
+		FeatureModels:          convertFeatureModels(v.FeatureModels),
 	}
 }
 
@@ -79,6 +80,8 @@ func convertModelOverrides(modelConfig *schema.SiteModelConfiguration) []types.M
 			ContextWindow:    convertContextWindow(v.ContextWindow),
 			ClientSideConfig: convertClientSideModelConfig(v.ClientSideConfig),
 			ServerSideConfig: convertServerSideModelConfig(v.ServerSideConfig),
+
+			DefaultParameters: convertModelDefaultParameters(v.DefaultParameters),
 		})
 	}
 	for _, modelRef := range modelConfig.ModelOverridesRecommendedSettings {
@@ -94,9 +97,27 @@ func convertDefaultModels(v *schema.DefaultModels) *types.DefaultModels {
 		return nil
 	}
 	return &types.DefaultModels{
-		Chat:           types.ModelRef(v.Chat),
-		FastChat:       types.ModelRef(v.FastChat),
-		CodeCompletion: types.ModelRef(v.CodeCompletion),
+		Chat:               types.ModelRef(v.Chat),
+		FastChat:           types.ModelRef(v.FastChat),
+		CodeCompletion:     types.ModelRef(v.CodeCompletion),
+		FastCodeCompletion: types.ModelRef(v.FastCodeCompletion),
+	}
+}
+
+func convertFeatureModels(v *schema.FeatureModels) *types.FeatureModels {
+	if v == nil {
+		return nil
+	}
+	toModelRefs := func(refs []string) []types.ModelRef {
+		var converted []types.ModelRef
+		for _, ref := range refs {
+			converted = append(converted, types.ModelRef(ref))
+		}
+		return converted
+	}
+	return &types.FeatureModels{
+		Chat:           toModelRefs(v.Chat),
+		CodeCompletion: toModelRefs(v.CodeCompletion),
 	}
 }
 
@@ -174,12 +195,12 @@ func convertServerSideProviderConfig(cfg *schema.ServerSideProviderConfig) *type
 			},
 		}
 	} else if v := cfg.Openaicompatible; v != nil {
-		// TODO(slimsag): self-hosted-models: map this to OpenAICompatibleProviderConfig in the future
 		return &types.ServerSideProviderConfig{
-			GenericProvider: &types.GenericProviderConfig{
-				ServiceName: types.GenericServiceProviderOpenAI,
-				AccessToken: v.AccessToken,
-				Endpoint:    v.Endpoint,
+			OpenAICompatible: &types.OpenAICompatibleProviderConfig{
+				AccessToken:         v.AccessToken,
+				Endpoint:            v.Endpoint,
+				ChatCompletionsPath: v.ChatCompletionsPath,
+				CompletionsPath:     v.CompletionsPath,
 			},
 		}
 	} else if v := cfg.Sourcegraph; v != nil {
@@ -218,6 +239,18 @@ func convertServerSideModelConfig(cfg *schema.ServerSideModelConfig) *types.Serv
 	}
 }
 
+func convertModelDefaultParameters(v *schema.ModelDefaultParameters) *types.ModelDefaultParameters {
+	if v == nil {
+		return nil
+	}
+	return &types.ModelDefaultParameters{
+		Temperature:   float32(v.Temperature),
+		TopK:          v.TopK,
+		TopP:          float32(v.TopP),
+		StopSequences: v.StopSequences,
+	}
+}
+
 func convertDefaultModelConfig(v *schema.DefaultModelConfig) *types.DefaultModelConfig {
 	if v == nil {
 		return nil
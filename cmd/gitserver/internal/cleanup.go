@@ -11,10 +11,12 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sourcegraph/conc/pool"
 	"github.com/sourcegraph/log"
 
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/common"
@@ -29,6 +31,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/env"
 	"github.com/sourcegraph/sourcegraph/internal/errcode"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/connection"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/goroutine"
 	"github.com/sourcegraph/sourcegraph/internal/hostname"
 	"github.com/sourcegraph/sourcegraph/internal/lazyregexp"
@@ -40,6 +43,9 @@ import (
 type JanitorConfig struct {
 	JanitorInterval time.Duration
 	ShardID         string
+	// GitBinary is the git executable that maintains repos. If empty, "git"
+	// is used.
+	GitBinary string
 
 	DisableDeleteReposOnWrongShard bool
 }
@@ -52,7 +58,17 @@ func NewJanitor(ctx context.Context, cfg JanitorConfig, db database.DB, fs gitse
 
 			gitserverAddrs := connection.NewGitserverAddresses(conf.Get())
 			// TODO: Should this return an error?
-			cleanupRepos(ctx, logger, db, fs, gitBackendSource, rcf, cfg.ShardID, gitserverAddrs, cfg.DisableDeleteReposOnWrongShard)
+			gitBinary := cfg.GitBinary
+			if gitBinary == "" {
+				gitBinary = gitcli.DefaultGitBinary
+			}
+			cleanupRepos(ctx, logger, db, fs, gitBackendSource, rcf, gitBinary, cfg.ShardID, gitserverAddrs, cfg.DisableDeleteReposOnWrongShard)
+
+			// Keep the orphaned and missing repos metrics up to date, so that
+			// drift is visible without anyone asking for a report.
+			if _, err := checkRepoConsistency(ctx, db.GitserverRepos(), fs, cfg.ShardID, gitserverAddrs); err != nil {
+				logger.Error("repo consistency check failed", log.Error(err))
+			}
 
 			return nil
 		}),
@@ -143,9 +159,62 @@ var sgmLogExpire = env.MustGetDuration("SRC_GIT_LOG_FILE_EXPIRY", 24*time.Hour,
 // sure that changes here are reflected in sgmLogHeader, too.
 var sgmRetries, _ = strconv.Atoi(env.Get("SRC_SGM_RETRIES", "3", "the maximum number of times we retry sg maintenance before triggering a reclone."))
 
+// The number of repos the janitor cleans up concurrently. Cleanups for a single
+// repo always run sequentially, but independent repos are processed in parallel
+// so that a single slow repo doesn't block the rest of the run.
+var janitorConcurrency, _ = strconv.Atoi(env.Get("SRC_REPOS_JANITOR_CONCURRENCY", "1", "the number of repos the janitor cleans up concurrently"))
+
+// The number of resource intensive jobs (git gc, sg maintenance, git prune) the
+// janitor runs at the same time, regardless of SRC_REPOS_JANITOR_CONCURRENCY.
+var janitorGCConcurrency, _ = strconv.Atoi(env.Get("SRC_REPOS_JANITOR_GC_CONCURRENCY", "1", "the maximum number of concurrent git gc, sg maintenance and git prune jobs run by the janitor"))
+
+// The git gc settings used by the janitor. Empty values keep git's defaults,
+// which expire reachable reflog entries after 90 days and unreachable ones after
+// 30 days. Shorter expiries and aggressive gc save disk at the cost of CPU. The
+// reflog expiries apply to both git gc and sg maintenance, aggressive gc only
+// to git gc.
+var janitorGCOptions = gitGCOptions{
+	ReflogExpire:            env.Get("SRC_REPOS_JANITOR_GC_REFLOG_EXPIRE", "", "how long reflog entries are kept by git gc and sg maintenance run by the janitor (gc.reflogExpire), e.g. \"30.days\" or \"never\". Defaults to git's default"),
+	ReflogExpireUnreachable: env.Get("SRC_REPOS_JANITOR_GC_REFLOG_EXPIRE_UNREACHABLE", "", "how long reflog entries that are not reachable from the current tip are kept by git gc and sg maintenance run by the janitor (gc.reflogExpireUnreachable). Defaults to git's default"),
+	Aggressive:              env.MustGetBool("SRC_REPOS_JANITOR_GC_AGGRESSIVE", false, "run git gc with --aggressive during janitor runs, which optimizes repos more thoroughly but takes much more CPU. Has no effect if SRC_ENABLE_SG_MAINTENANCE is set, as sg maintenance repacks with fixed flags"),
+}
+
 // Controls if gitserver cleanup tries to remove repos from disk which are not defined in the DB. Defaults to false.
 var removeNonExistingRepos, _ = strconv.ParseBool(env.Get("SRC_REMOVE_NON_EXISTING_REPOS", "false", "controls if gitserver cleanup tries to remove repos from disk which are not defined in the DB"))
 
+// How long a repo that is no longer defined in the DB is kept on disk before
+// SRC_REMOVE_NON_EXISTING_REPOS removes it. If the repo is added back within
+// this period, for example after an accidental code host config change, its
+// data is still there and no re-clone is required.
+var removeNonExistingReposGracePeriod = env.MustGetDuration("SRC_REMOVE_NON_EXISTING_REPOS_GRACE_PERIOD", 24*time.Hour, "the time a repo that is not defined in the DB is kept on disk before it is removed. Set to 0 to remove such repos immediately")
+
+// Repos gitserver never deletes from disk, neither because they are no longer
+// defined in the DB nor because they were not found on the code host. Copies
+// cloned on the wrong shard are still removed, as the repo is kept on its
+// shard. Corrupt repos and repos that fail garbage collection are still
+// re-cloned, since that restores them rather than dropping them.
+var janitorPinnedRepos = parsePinnedRepos(env.Get("SRC_REPOS_JANITOR_PINNED_REPOS", "", "comma separated list of repo names that are never removed from disk, except for copies on the wrong shard, e.g. \"github.com/sourcegraph/sourcegraph\""))
+
+// parsePinnedRepos parses a comma separated list of repo names into a set of
+// normalized repo names.
+func parsePinnedRepos(value string) map[api.RepoName]struct{} {
+	pinned := make(map[api.RepoName]struct{})
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		pinned[protocol.NormalizeRepo(api.RepoName(name))] = struct{}{}
+	}
+	return pinned
+}
+
+// isPinnedRepo returns true if the repo must not be removed from disk.
+func isPinnedRepo(repoName api.RepoName) bool {
+	_, ok := janitorPinnedRepos[protocol.NormalizeRepo(repoName)]
+	return ok
+}
+
 var (
 	reposRemoved = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "src_gitserver_repos_removed",
@@ -181,6 +250,14 @@ var (
 		Name: "src_gitserver_non_existing_repos_removed",
 		Help: "number of non existing repos removed during cleanup",
 	})
+	nonExistingReposPendingDeletion = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "src_gitserver_non_existing_repos_pending_deletion",
+		Help: "number of non existing repos that are kept on disk until their deletion grace period has passed",
+	})
+	pinnedReposKept = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "src_gitserver_janitor_pinned_repos_kept",
+		Help: "number of times gitserver kept a pinned repo on disk that it would otherwise have removed",
+	}, []string{"reason"})
 )
 
 // cleanupRepos walks the repos directory and performs maintenance tasks:
@@ -203,6 +280,7 @@ func cleanupRepos(
 	fs gitserverfs.FS,
 	gitBackendSource git.GitBackendSource,
 	rcf *wrexec.RecordingCommandFactory,
+	gitBinary string,
 	shardID string,
 	gitServerAddrs connection.GitserverAddresses,
 	disableDeleteReposOnWrongShard bool,
@@ -219,17 +297,15 @@ func cleanupRepos(
 		janitorTimer.Observe(time.Since(janitorStart).Seconds())
 	}()
 
-	knownGitServerShard := false
-	for _, addr := range gitServerAddrs.Addresses {
-		if hostnameMatch(shardID, addr) {
-			knownGitServerShard = true
-			break
-		}
-	}
+	knownGitServerShard := IsKnownShard(shardID, gitServerAddrs.Addresses)
 	if !knownGitServerShard {
 		logger.Warn("current shard is not included in the list of known gitserver shards, will not delete repos", log.String("current-hostname", shardID), log.Strings("all-shards", gitServerAddrs.Addresses))
 	}
 
+	// Cleanups for different repos run concurrently, mu guards the state shared
+	// between them.
+	var mu sync.Mutex
+
 	repoToSize := make(map[api.RepoName]int64)
 	var wrongShardRepoCount int64
 	defer func() {
@@ -254,7 +330,9 @@ func cleanupRepos(
 			return false, nil
 		}
 
+		mu.Lock()
 		wrongShardRepoCount++
+		mu.Unlock()
 
 		// If we're on a shard not currently known, basically every repo would
 		// be considered on the wrong shard. This is probably a configuration
@@ -279,7 +357,9 @@ func cleanupRepos(
 			return true, err
 		}
 
+		mu.Lock()
 		wrongShardReposDeleted++
+		mu.Unlock()
 
 		// Note: We just deleted the repo. So we're done with any further janitor tasks!
 		return true, nil
@@ -300,13 +380,15 @@ func cleanupRepos(
 		if err != nil {
 			return false, errors.Wrap(err, "calculating repo size")
 		}
+		mu.Lock()
 		repoToSize[repoName] = size
+		mu.Unlock()
 
 		return false, setLastSizeCalculation(dir, time.Now())
 	}
 
 	maybeRemoveCorrupt := func(backend git.GitBackend, repoName api.RepoName, dir common.GitDir) (done bool, _ error) {
-		corrupt, shouldLog, reason, err := checkRepoDirCorrupt(rcf, repoName, dir)
+		corrupt, shouldLog, reason, err := checkRepoDirCorrupt(rcf, gitBinary, repoName, dir)
 		if !corrupt || err != nil {
 			return false, err
 		}
@@ -334,15 +416,28 @@ func cleanupRepos(
 		return true, nil
 	}
 
+	var pendingDeletionCount int64
+	defer func() {
+		// We want to set the gauge only at the end when we know the total
+		if removeNonExistingRepos {
+			nonExistingReposPendingDeletion.Set(float64(pendingDeletionCount))
+		}
+	}()
+
 	maybeRemoveNonExisting := func(backend git.GitBackend, repoName api.RepoName, dir common.GitDir) (bool, error) {
 		if !removeNonExistingRepos {
 			return false, nil
 		}
 
 		_, err := db.GitserverRepos().GetByName(ctx, repoName)
-		// Repo still exists, nothing to do.
+		// Repo still exists, make sure it is no longer scheduled for deletion in
+		// case it was added back during the grace period.
 		if err == nil {
-			return false, nil
+			restored, err := clearPendingDeletion(dir)
+			if restored {
+				logger.Info("repo exists again, cancelled pending deletion", log.String("repo", string(repoName)))
+			}
+			return false, err
 		}
 
 		// Failed to talk to DB, skip this repo.
@@ -351,7 +446,42 @@ func cleanupRepos(
 			return false, nil
 		}
 
-		// The repo does not exist in the DB (or is soft-deleted), continue deleting it.
+		if isPinnedRepo(repoName) {
+			logger.Info("keeping pinned repo that does not exist in the DB anymore", log.String("repo", string(repoName)))
+			pinnedReposKept.WithLabelValues("non_existing").Inc()
+			// The repo might have been scheduled for deletion before it was pinned.
+			_, err := clearPendingDeletion(dir)
+			return false, err
+		}
+
+		// The repo does not exist in the DB (or is soft-deleted). Keep it around
+		// until the grace period has passed, so that it can quickly be restored.
+		if removeNonExistingReposGracePeriod > 0 {
+			markedAt, err := getPendingDeletion(dir)
+			if err != nil {
+				return true, err
+			}
+			if markedAt.IsZero() {
+				markedAt = time.Now()
+				if err := setPendingDeletion(dir, markedAt); err != nil {
+					return true, err
+				}
+				logger.Info(
+					"repo does not exist in the DB anymore, scheduled for deletion",
+					log.String("repo", string(repoName)),
+					log.Time("deleteAfter", markedAt.Add(removeNonExistingReposGracePeriod)),
+				)
+			}
+			if time.Since(markedAt) < removeNonExistingReposGracePeriod {
+				mu.Lock()
+				pendingDeletionCount++
+				mu.Unlock()
+				// No need to spend further CPU cycles on maintaining this repo.
+				return true, nil
+			}
+		}
+
+		// The grace period has passed, continue deleting it.
 		// TODO: For soft-deleted, it might be nice to attempt to update the clone status,
 		// but that can only work when we can map a repo on disk back to a repo in DB
 		// when the name has been modified to have the DELETED- prefix.
@@ -502,15 +632,30 @@ func cleanupRepos(
 	}
 
 	performGC := func(backend git.GitBackend, repoName api.RepoName, dir common.GitDir) (done bool, err error) {
-		return false, gitGC(logger, rcf, repoName, dir)
+		return false, gitGC(logger, rcf, gitBinary, repoName, dir)
 	}
 
 	performSGMaintenance := func(backend git.GitBackend, repoName api.RepoName, dir common.GitDir) (done bool, err error) {
-		return false, sgMaintenance(logger, dir)
+		return false, sgMaintenance(logger, gitBinary, dir)
 	}
 
 	performGitPrune := func(backend git.GitBackend, repoName api.RepoName, dir common.GitDir) (done bool, err error) {
-		return false, pruneIfNeeded(rcf, repoName, dir, looseObjectsLimit)
+		return false, pruneIfNeeded(rcf, gitBinary, repoName, dir, looseObjectsLimit)
+	}
+
+	// gcSem bounds the number of resource intensive jobs running at the same
+	// time across all repos.
+	gcSem := make(chan struct{}, max(janitorGCConcurrency, 1))
+	rateLimited := func(do func(git.GitBackend, api.RepoName, common.GitDir) (bool, error)) func(git.GitBackend, api.RepoName, common.GitDir) (bool, error) {
+		return func(backend git.GitBackend, repoName api.RepoName, dir common.GitDir) (bool, error) {
+			select {
+			case gcSem <- struct{}{}:
+			case <-ctx.Done():
+				return false, ctx.Err()
+			}
+			defer func() { <-gcSem }()
+			return do(backend, repoName, dir)
+		}
 	}
 
 	type cleanupFn struct {
@@ -544,15 +689,15 @@ func cleanupRepos(
 		// removing unreachable objects which may have been created from prior
 		// invocations of git add, packing refs, pruning reflog, rerere metadata or stale
 		// working trees. May also update ancillary indexes such as the commit-graph.
-		cleanups = append(cleanups, cleanupFn{"garbage collect", performGC})
+		cleanups = append(cleanups, cleanupFn{"garbage collect", rateLimited(performGC)})
 	}
 
 	if gitGCMode == gitGCModeMaintenance {
 		// Run tasks to optimize Git repository data, speeding up other Git commands and
 		// reducing storage requirements for the repository. Note: "garbage collect" and
 		// "sg maintenance" must not be enabled at the same time.
-		cleanups = append(cleanups, cleanupFn{"sg maintenance", performSGMaintenance})
-		cleanups = append(cleanups, cleanupFn{"git prune", performGitPrune})
+		cleanups = append(cleanups, cleanupFn{"sg maintenance", rateLimited(performSGMaintenance)})
+		cleanups = append(cleanups, cleanupFn{"git prune", rateLimited(performGitPrune)})
 	}
 
 	if !conf.Get().DisableAutoGitUpdates {
@@ -573,40 +718,52 @@ func cleanupRepos(
 
 	reposCleaned := 0
 
+	p := pool.New().WithMaxGoroutines(max(janitorConcurrency, 1))
 	err := fs.ForEachRepo(func(repo api.RepoName, gitDir common.GitDir) (done bool) {
-		backend := gitBackendSource(gitDir, repo)
-		for _, cfn := range cleanups {
-			// Check if context has been canceled, if so skip the rest of the repos.
-			select {
-			case <-ctx.Done():
-				logger.Warn("aborting janitor run", log.Error(ctx.Err()))
-				return true
-			default:
-			}
+		// Check if context has been canceled, if so skip the rest of the repos.
+		select {
+		case <-ctx.Done():
+			logger.Warn("aborting janitor run", log.Error(ctx.Err()))
+			return true
+		default:
+		}
 
-			start := time.Now()
-			done, err := cfn.Do(backend, repo, gitDir)
-			if err != nil {
-				logger.Error("error running cleanup command",
-					log.String("name", cfn.Name),
-					log.String("repo", string(gitDir)),
-					log.Error(err))
-			}
-			jobTimer.WithLabelValues(strconv.FormatBool(err == nil), cfn.Name).Observe(time.Since(start).Seconds())
-			if done {
-				break
+		p.Go(func() {
+			backend := gitBackendSource(gitDir, repo)
+			for _, cfn := range cleanups {
+				// Check if context has been canceled, if so skip the remaining cleanups.
+				if ctx.Err() != nil {
+					return
+				}
+
+				start := time.Now()
+				done, err := cfn.Do(backend, repo, gitDir)
+				if err != nil {
+					logger.Error("error running cleanup command",
+						log.String("name", cfn.Name),
+						log.String("repo", string(gitDir)),
+						log.Error(err))
+				}
+				jobTimer.WithLabelValues(strconv.FormatBool(err == nil), cfn.Name).Observe(time.Since(start).Seconds())
+				if done {
+					break
+				}
 			}
-		}
 
-		reposCleaned++
+			mu.Lock()
+			reposCleaned++
+			cleaned := reposCleaned
+			mu.Unlock()
 
-		// Every 1000 repos, log a progress message.
-		if reposCleaned%1000 == 0 {
-			logger.Info("Janitor progress", log.Int("repos_cleaned", reposCleaned))
-		}
+			// Every 1000 repos, log a progress message.
+			if cleaned%1000 == 0 {
+				logger.Info("Janitor progress", log.Int("repos_cleaned", cleaned))
+			}
+		})
 
 		return false
 	})
+	p.Wait()
 	if err != nil {
 		logger.Error("error iterating over repositories", log.Error(err))
 	}
@@ -621,7 +778,7 @@ func cleanupRepos(
 	logger.Info("Janitor run finished", log.String("duration", time.Since(start).String()))
 }
 
-func checkRepoDirCorrupt(rcf *wrexec.RecordingCommandFactory, repoName api.RepoName, dir common.GitDir) (corrupt, shouldLog bool, description string, err error) {
+func checkRepoDirCorrupt(rcf *wrexec.RecordingCommandFactory, gitBinary string, repoName api.RepoName, dir common.GitDir) (corrupt, shouldLog bool, description string, err error) {
 	// We treat repositories missing HEAD to be corrupt. Both our cloning
 	// and fetching ensure there is a HEAD file.
 	if _, err := os.Stat(dir.Path("HEAD")); os.IsNotExist(err) {
@@ -636,7 +793,7 @@ func checkRepoDirCorrupt(rcf *wrexec.RecordingCommandFactory, repoName api.RepoN
 	// repos as corrupt. Since we often fetch with ensureRevision, this
 	// leads to most commands failing against the repository. It is safer
 	// to remove now than try a safe reclone.
-	if gitIsNonBareBestEffort(rcf, repoName, dir) {
+	if gitIsNonBareBestEffort(rcf, gitBinary, repoName, dir) {
 		return true, true, "non-bare", nil
 	}
 
@@ -677,8 +834,8 @@ func checkRepoFlaggedForCorruption(gitDir common.GitDir) (bool, error) {
 // Note: it is not always possible to check if a repository is bare since a
 // lock file may prevent the check from succeeding. We only want bare
 // repositories and want to avoid transient false positives.
-func gitIsNonBareBestEffort(rcf *wrexec.RecordingCommandFactory, repoName api.RepoName, dir common.GitDir) bool {
-	cmd := exec.Command("git", "-C", dir.Path(), "rev-parse", "--is-bare-repository")
+func gitIsNonBareBestEffort(rcf *wrexec.RecordingCommandFactory, gitBinary string, repoName api.RepoName, dir common.GitDir) bool {
+	cmd := exec.Command(gitBinary, "-C", dir.Path(), "rev-parse", "--is-bare-repository")
 	dir.Set(cmd)
 	wrappedCmd := rcf.WrapWithRepoName(context.Background(), log.NoOp(), repoName, cmd)
 	b, _ := wrappedCmd.Output()
@@ -686,11 +843,66 @@ func gitIsNonBareBestEffort(rcf *wrexec.RecordingCommandFactory, repoName api.Re
 	return bytes.Equal(b, []byte("false"))
 }
 
+// gitGCOptions are the operator configurable settings of the git gc and sg
+// maintenance runs of the janitor.
+type gitGCOptions struct {
+	// ReflogExpire is passed as gc.reflogExpire if set.
+	ReflogExpire string
+	// ReflogExpireUnreachable is passed as gc.reflogExpireUnreachable if set.
+	ReflogExpireUnreachable string
+	// Aggressive runs git gc with --aggressive. It is ignored by sg
+	// maintenance.
+	Aggressive bool
+}
+
+// config returns the git config overrides of the options as key/value pairs.
+func (o gitGCOptions) config() [][2]string {
+	var config [][2]string
+	if o.ReflogExpire != "" {
+		config = append(config, [2]string{"gc.reflogExpire", o.ReflogExpire})
+	}
+	if o.ReflogExpireUnreachable != "" {
+		config = append(config, [2]string{"gc.reflogExpireUnreachable", o.ReflogExpireUnreachable})
+	}
+	return config
+}
+
+// env returns the environment variables that apply the config overrides of the
+// options to every git command run by sg maintenance, see GIT_CONFIG_COUNT in
+// git(1). It returns nil if there are no overrides.
+func (o gitGCOptions) env() []string {
+	config := o.config()
+	if len(config) == 0 {
+		return nil
+	}
+	env := []string{"GIT_CONFIG_COUNT=" + strconv.Itoa(len(config))}
+	for i, kv := range config {
+		env = append(env,
+			fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", i, kv[0]),
+			fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i, kv[1]),
+		)
+	}
+	return env
+}
+
+// args returns the arguments to git for running git gc with the given options.
+func (o gitGCOptions) args() []string {
+	args := []string{"-c", "gc.auto=1", "-c", "gc.autoDetach=false"}
+	for _, kv := range o.config() {
+		args = append(args, "-c", kv[0]+"="+kv[1])
+	}
+	args = append(args, "gc", "--auto")
+	if o.Aggressive {
+		args = append(args, "--aggressive")
+	}
+	return args
+}
+
 // gitGC will invoke `git-gc` to clean up any garbage in the repo. It will
 // operate synchronously and be aggressive with its internal heuristics when
 // deciding to act (meaning it will act now at lower thresholds).
-func gitGC(logger log.Logger, rcf *wrexec.RecordingCommandFactory, repoName api.RepoName, dir common.GitDir) error {
-	cmd := exec.Command("git", "-c", "gc.auto=1", "-c", "gc.autoDetach=false", "gc", "--auto")
+func gitGC(logger log.Logger, rcf *wrexec.RecordingCommandFactory, gitBinary string, repoName api.RepoName, dir common.GitDir) error {
+	cmd := exec.Command(gitBinary, janitorGCOptions.args()...)
 	dir.Set(cmd)
 	wrappedCmd := rcf.WrapWithRepoName(context.Background(), log.NoOp(), repoName, cmd)
 	err := wrappedCmd.Run()
@@ -764,7 +976,7 @@ func bestEffortParseFailed(b []byte) int {
 // concurrently with git gc. sgMaintenance will check the state of the repository
 // to avoid running the cleanup tasks if possible. If a sgmLog file is present in
 // dir, sgMaintenance will not run unless the file is old.
-func sgMaintenance(logger log.Logger, dir common.GitDir) (err error) {
+func sgMaintenance(logger log.Logger, gitBinary string, dir common.GitDir) (err error) {
 	// Don't run if sgmLog file is younger than sgmLogExpire hours. There is no need
 	// to report an error, because the error has already been logged in a previous
 	// run.
@@ -786,6 +998,8 @@ func sgMaintenance(logger log.Logger, dir common.GitDir) (err error) {
 
 	cmd := exec.Command("sh")
 	dir.Set(cmd)
+	cmd.Env = append(os.Environ(), "SG_GIT_BINARY="+gitBinary)
+	cmd.Env = append(cmd.Env, janitorGCOptions.env()...)
 
 	cmd.Stdin = strings.NewReader(sgMaintenanceScript)
 
@@ -850,7 +1064,7 @@ func lockRepoForGC(dir common.GitDir) (error, func() error) {
 
 // We run git-prune only if there are enough loose objects. This approach is
 // adapted from https://gitlab.com/gitlab-org/gitaly.
-func pruneIfNeeded(rcf *wrexec.RecordingCommandFactory, repo api.RepoName, dir common.GitDir, limit int) (err error) {
+func pruneIfNeeded(rcf *wrexec.RecordingCommandFactory, gitBinary string, repo api.RepoName, dir common.GitDir, limit int) (err error) {
 	needed, err := tooManyLooseObjects(dir, limit)
 	defer func() {
 		pruneStatus.WithLabelValues(strconv.FormatBool(err == nil), strconv.FormatBool(!needed)).Inc()
@@ -867,7 +1081,7 @@ func pruneIfNeeded(rcf *wrexec.RecordingCommandFactory, repo api.RepoName, dir c
 	// unreachable, loose objects count towards the threshold that triggers a
 	// repack. In the worst case, IE all loose objects are unreachable, we would
 	// continuously trigger repacks until the loose objects expire.
-	cmd := exec.Command("git", "prune", "--expire", "now")
+	cmd := exec.Command(gitBinary, "prune", "--expire", "now")
 	dir.Set(cmd)
 	wrappedCmd := rcf.WrapWithRepoName(context.Background(), log.NoOp(), repo, cmd)
 	err = wrappedCmd.Run()
@@ -1019,6 +1233,10 @@ func mockRemoveNonExistingReposConfig(value bool) {
 	removeNonExistingRepos = value
 }
 
+func mockJanitorPinnedRepos(value string) {
+	janitorPinnedRepos = parsePinnedRepos(value)
+}
+
 const (
 	// We recalculate the repository size every day at most in the janitor.
 	// There's no need to recalculate it more often than that, since fetches
@@ -1103,3 +1321,42 @@ func resetGCFailCounter(dir common.GitDir) error {
 	}
 	return nil
 }
+
+const pendingDeletionFilepath = ".sourcegraph-pending-deletion"
+
+// getPendingDeletion returns the time the janitor first noticed that the repo
+// no longer exists in the DB, or the zero time if it isn't pending deletion.
+func getPendingDeletion(dir common.GitDir) (time.Time, error) {
+	fd, err := os.Stat(dir.Path(pendingDeletionFilepath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	// We use modtime to track when the repo was scheduled for deletion.
+	return fd.ModTime(), nil
+}
+
+func setPendingDeletion(dir common.GitDir, when time.Time) error {
+	path := dir.Path(pendingDeletionFilepath)
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_ = f.Close()
+	return os.Chtimes(path, time.Time{}, when)
+}
+
+// clearPendingDeletion removes the pending deletion marker of the repo. It
+// returns true if the repo was pending deletion.
+func clearPendingDeletion(dir common.GitDir) (bool, error) {
+	err := os.Remove(dir.Path(pendingDeletionFilepath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
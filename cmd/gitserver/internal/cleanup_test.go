@@ -8,6 +8,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -83,6 +84,7 @@ UPDATE gitserver_repos SET repo_size_bytes = 5 where repo_id = 3;
 			return b
 		},
 		wrexec.NewNoOpRecordingCommandFactory(),
+		"git",
 		"test-gitserver",
 		connection.GitserverAddresses{Addresses: []string{"test-gitserver"}},
 		false,
@@ -149,6 +151,7 @@ func TestCleanupInactive(t *testing.T) {
 			return b
 		},
 		wrexec.NewNoOpRecordingCommandFactory(),
+		"git",
 		"test-gitserver",
 		connection.GitserverAddresses{Addresses: []string{"test-gitserver"}},
 		false,
@@ -162,6 +165,82 @@ func TestCleanupInactive(t *testing.T) {
 	}
 }
 
+func TestCleanupConcurrency(t *testing.T) {
+	const concurrency = 3
+
+	oldConcurrency := janitorConcurrency
+	janitorConcurrency = concurrency
+	t.Cleanup(func() { janitorConcurrency = oldConcurrency })
+
+	root := t.TempDir()
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		p := path.Join(root, name, ".git")
+		cmd := exec.Command("git", "--bare", "init", p)
+		if err := cmd.Run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fs := gitserverfs.New(observation.TestContextTB(t), root)
+	require.NoError(t, fs.Initialize())
+
+	// Every repo's cleanup blocks in the "auto gc config" job until the
+	// configured number of jobs are in flight, so the run only finishes quickly
+	// if the janitor actually processes repos concurrently.
+	var (
+		mu          sync.Mutex
+		inFlight    int
+		maxInFlight int
+	)
+	limitReached := make(chan struct{})
+	blockUntilLimit := func() {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		if maxInFlight == concurrency {
+			select {
+			case <-limitReached:
+			default:
+				close(limitReached)
+			}
+		}
+		mu.Unlock()
+
+		select {
+		case <-limitReached:
+		case <-time.After(10 * time.Second):
+		}
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}
+
+	cleanupRepos(
+		context.Background(),
+		logtest.Scoped(t),
+		newMockedGitserverDB(),
+		fs,
+		func(dir common.GitDir, repoName api.RepoName) git.GitBackend {
+			b := git.NewMockGitBackend()
+			b.ConfigFunc.SetDefaultHook(func() git.GitConfigBackend {
+				blockUntilLimit()
+				return git.NewMockGitConfigBackend()
+			})
+			return b
+		},
+		wrexec.NewNoOpRecordingCommandFactory(),
+		"git",
+		"test-gitserver",
+		connection.GitserverAddresses{Addresses: []string{"test-gitserver"}},
+		false,
+	)
+
+	require.Equal(t, concurrency, maxInFlight)
+}
+
 func TestCleanupWrongShard(t *testing.T) {
 	t.Run("wrongShardName", func(t *testing.T) {
 		root := t.TempDir()
@@ -193,6 +272,7 @@ func TestCleanupWrongShard(t *testing.T) {
 				return b
 			},
 			wrexec.NewNoOpRecordingCommandFactory(),
+			"git",
 			"does-not-exist",
 			connection.GitserverAddresses{Addresses: []string{"gitserver-0", "gitserver-1"}},
 			false,
@@ -235,6 +315,7 @@ func TestCleanupWrongShard(t *testing.T) {
 				return b
 			},
 			wrexec.NewNoOpRecordingCommandFactory(),
+			"git",
 			"gitserver-0",
 			connection.GitserverAddresses{Addresses: []string{"gitserver-0.cluster.local:3178", "gitserver-1.cluster.local:3178"}},
 			false,
@@ -247,6 +328,44 @@ func TestCleanupWrongShard(t *testing.T) {
 			t.Error("expected repoD assigned to different shard to be removed")
 		}
 	})
+	t.Run("pinned", func(t *testing.T) {
+		mockJanitorPinnedRepos("testrepo-D")
+		defer mockJanitorPinnedRepos("")
+		root := t.TempDir()
+		// should be allocated to shard gitserver-1
+		testRepoD := "testrepo-D"
+
+		repoD := path.Join(root, testRepoD, ".git")
+		cmdD := exec.Command("git", "--bare", "init", repoD)
+		if err := cmdD.Run(); err != nil {
+			t.Fatal(err)
+		}
+
+		fs := gitserverfs.New(observation.TestContextTB(t), root)
+		require.NoError(t, fs.Initialize())
+
+		cleanupRepos(
+			context.Background(),
+			logtest.Scoped(t),
+			newMockedGitserverDB(),
+			fs,
+			func(dir common.GitDir, repoName api.RepoName) git.GitBackend {
+				b := git.NewMockGitBackend()
+				b.ConfigFunc.SetDefaultReturn(git.NewMockGitConfigBackend())
+				return b
+			},
+			wrexec.NewNoOpRecordingCommandFactory(),
+			"git",
+			"gitserver-0",
+			connection.GitserverAddresses{Addresses: []string{"gitserver-0", "gitserver-1"}},
+			false,
+		)
+
+		// Pinned repos are kept on their shard only.
+		if _, err := os.Stat(repoD); err == nil {
+			t.Error("expected pinned repoD assigned to different shard to be removed")
+		}
+	})
 	t.Run("cleanupDisabled", func(t *testing.T) {
 		root := t.TempDir()
 		// should be allocated to shard gitserver-1
@@ -277,6 +396,7 @@ func TestCleanupWrongShard(t *testing.T) {
 				return b
 			},
 			wrexec.NewNoOpRecordingCommandFactory(),
+			"git",
 			"gitserver-0",
 			connection.GitserverAddresses{Addresses: []string{"gitserver-0", "gitserver-1"}},
 			true,
@@ -354,6 +474,7 @@ func TestGitGCAuto(t *testing.T) {
 			return b
 		},
 		wrexec.NewNoOpRecordingCommandFactory(),
+		"git",
 		"test-gitserver",
 		connection.GitserverAddresses{Addresses: []string{"test-gitserver"}},
 		false,
@@ -419,7 +540,7 @@ func TestCleanupBroken(t *testing.T) {
 		require.NoError(t, f.Close())
 	}
 	{
-		cli := gitcli.NewBackend(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), common.GitDir(repoPerforceGCOld), "perforce")
+		cli := gitcli.NewBackend(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), gitcli.DefaultGitBinary, common.GitDir(repoPerforceGCOld), "perforce")
 		if err := git.SetRepositoryType(ctx, cli.Config(), "perforce"); err != nil {
 			t.Fatal(err)
 		}
@@ -443,9 +564,10 @@ func TestCleanupBroken(t *testing.T) {
 		newMockedGitserverDB(),
 		fs,
 		func(dir common.GitDir, repoName api.RepoName) git.GitBackend {
-			return gitcli.NewBackend(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), dir, repoName)
+			return gitcli.NewBackend(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), gitcli.DefaultGitBinary, dir, repoName)
 		},
 		wrexec.NewNoOpRecordingCommandFactory(),
+		"git",
 		"test-gitserver",
 		connection.GitserverAddresses{Addresses: []string{"test-gitserver"}},
 		false,
@@ -454,6 +576,14 @@ func TestCleanupBroken(t *testing.T) {
 	require.Equal(t, []api.RepoName{"repo-corrupt", "repo-gc-old", "repo-non-bare"}, calledRemove)
 }
 
+// mockRemoveNonExistingReposGracePeriod sets the grace period for repos that
+// are not defined in the DB until the end of the test.
+func mockRemoveNonExistingReposGracePeriod(t *testing.T, value time.Duration) {
+	previous := removeNonExistingReposGracePeriod
+	removeNonExistingReposGracePeriod = value
+	t.Cleanup(func() { removeNonExistingReposGracePeriod = previous })
+}
+
 func TestCleanup_RemoveNonExistentRepos(t *testing.T) {
 	initRepos := func(root string) (repoExists string, repoNotExists string) {
 		repoExists = path.Join(root, "repo-exists", ".git")
@@ -502,6 +632,7 @@ func TestCleanup_RemoveNonExistentRepos(t *testing.T) {
 				return b
 			},
 			wrexec.NewNoOpRecordingCommandFactory(),
+			"git",
 			"test-gitserver",
 			connection.GitserverAddresses{Addresses: []string{"test-gitserver"}},
 			false,
@@ -519,6 +650,7 @@ func TestCleanup_RemoveNonExistentRepos(t *testing.T) {
 	t.Run("Should delete the repo dir that is not defined in DB", func(t *testing.T) {
 		mockRemoveNonExistingReposConfig(true)
 		defer mockRemoveNonExistingReposConfig(false)
+		mockRemoveNonExistingReposGracePeriod(t, 0)
 		root := t.TempDir()
 		repoExists, repoNotExists := initRepos(root)
 
@@ -536,6 +668,7 @@ func TestCleanup_RemoveNonExistentRepos(t *testing.T) {
 				return b
 			},
 			wrexec.NewNoOpRecordingCommandFactory(),
+			"git",
 			"test-gitserver",
 			connection.GitserverAddresses{Addresses: []string{"test-gitserver"}},
 			false,
@@ -548,6 +681,130 @@ func TestCleanup_RemoveNonExistentRepos(t *testing.T) {
 			t.Fatal("repo existing in DB does not exist on disk anymore")
 		}
 	})
+
+	t.Run("Should keep the repo dir that is not defined in DB during the grace period", func(t *testing.T) {
+		mockRemoveNonExistingReposConfig(true)
+		defer mockRemoveNonExistingReposConfig(false)
+		mockRemoveNonExistingReposGracePeriod(t, time.Hour)
+		root := t.TempDir()
+		repoExists, repoNotExists := initRepos(root)
+
+		fs := gitserverfs.New(observation.TestContextTB(t), root)
+		require.NoError(t, fs.Initialize())
+
+		cleanup := func() {
+			cleanupRepos(
+				context.Background(),
+				logtest.Scoped(t),
+				mockDB,
+				fs,
+				func(dir common.GitDir, repoName api.RepoName) git.GitBackend {
+					b := git.NewMockGitBackend()
+					b.ConfigFunc.SetDefaultReturn(git.NewMockGitConfigBackend())
+					return b
+				},
+				wrexec.NewNoOpRecordingCommandFactory(),
+				"git",
+				"test-gitserver",
+				connection.GitserverAddresses{Addresses: []string{"test-gitserver"}},
+				false,
+			)
+		}
+
+		// The first run only schedules the repo for deletion.
+		cleanup()
+		_, err := os.Stat(repoNotExists)
+		require.NoError(t, err, "repo not existing in DB was removed during the grace period")
+		markedAt, err := getPendingDeletion(common.GitDir(repoNotExists))
+		require.NoError(t, err)
+		require.False(t, markedAt.IsZero(), "repo not existing in DB was not scheduled for deletion")
+
+		// Subsequent runs within the grace period keep it.
+		cleanup()
+		_, err = os.Stat(repoNotExists)
+		require.NoError(t, err, "repo not existing in DB was removed during the grace period")
+
+		// Once the grace period has elapsed, the repo is removed.
+		require.NoError(t, setPendingDeletion(common.GitDir(repoNotExists), time.Now().Add(-2*time.Hour)))
+		cleanup()
+		if _, err := os.Stat(repoNotExists); err == nil {
+			t.Fatal("repo not existing in DB was not removed after the grace period")
+		}
+		if _, err := os.Stat(repoExists); err != nil {
+			t.Fatal("repo existing in DB does not exist on disk anymore")
+		}
+	})
+
+	t.Run("Should keep a pinned repo dir that is not defined in DB", func(t *testing.T) {
+		mockRemoveNonExistingReposConfig(true)
+		defer mockRemoveNonExistingReposConfig(false)
+		mockRemoveNonExistingReposGracePeriod(t, 0)
+		mockJanitorPinnedRepos("foo, repo-not-exists")
+		defer mockJanitorPinnedRepos("")
+		root := t.TempDir()
+		repoExists, repoNotExists := initRepos(root)
+		require.NoError(t, setPendingDeletion(common.GitDir(repoNotExists), time.Now().Add(-time.Hour)))
+
+		fs := gitserverfs.New(observation.TestContextTB(t), root)
+		require.NoError(t, fs.Initialize())
+
+		cleanupRepos(
+			context.Background(),
+			logtest.Scoped(t),
+			mockDB,
+			fs,
+			func(dir common.GitDir, repoName api.RepoName) git.GitBackend {
+				b := git.NewMockGitBackend()
+				b.ConfigFunc.SetDefaultReturn(git.NewMockGitConfigBackend())
+				return b
+			},
+			wrexec.NewNoOpRecordingCommandFactory(),
+			"git",
+			"test-gitserver",
+			connection.GitserverAddresses{Addresses: []string{"test-gitserver"}},
+			false,
+		)
+
+		_, err := os.Stat(repoNotExists)
+		require.NoError(t, err, "pinned repo not existing in DB was removed")
+		markedAt, err := getPendingDeletion(common.GitDir(repoNotExists))
+		require.NoError(t, err)
+		require.True(t, markedAt.IsZero(), "pinned repo is still scheduled for deletion")
+		_, err = os.Stat(repoExists)
+		require.NoError(t, err, "repo existing in DB does not exist on disk anymore")
+	})
+
+	t.Run("Should cancel the deletion of a repo that is defined in DB again", func(t *testing.T) {
+		mockRemoveNonExistingReposConfig(true)
+		defer mockRemoveNonExistingReposConfig(false)
+		root := t.TempDir()
+		repoExists, _ := initRepos(root)
+		require.NoError(t, setPendingDeletion(common.GitDir(repoExists), time.Now().Add(-time.Minute)))
+
+		fs := gitserverfs.New(observation.TestContextTB(t), root)
+		require.NoError(t, fs.Initialize())
+
+		cleanupRepos(
+			context.Background(),
+			logtest.Scoped(t),
+			mockDB,
+			fs,
+			func(dir common.GitDir, repoName api.RepoName) git.GitBackend {
+				b := git.NewMockGitBackend()
+				b.ConfigFunc.SetDefaultReturn(git.NewMockGitConfigBackend())
+				return b
+			},
+			wrexec.NewNoOpRecordingCommandFactory(),
+			"git",
+			"test-gitserver",
+			connection.GitserverAddresses{Addresses: []string{"test-gitserver"}},
+			false,
+		)
+
+		markedAt, err := getPendingDeletion(common.GitDir(repoExists))
+		require.NoError(t, err)
+		require.True(t, markedAt.IsZero(), "repo existing in DB is still scheduled for deletion")
+	})
 }
 
 // TestCleanupOldLocks checks whether cleanupRepos removes stale lock files. It
@@ -693,6 +950,7 @@ func TestCleanupOldLocks(t *testing.T) {
 			return b
 		},
 		wrexec.NewNoOpRecordingCommandFactory(),
+		"git",
 		"test-gitserver",
 		connection.GitserverAddresses{Addresses: []string{"gitserver-0"}},
 		false,
@@ -990,7 +1248,7 @@ func TestPruneIfNeeded(t *testing.T) {
 	}
 
 	limit := -1 // always run prune
-	if err := pruneIfNeeded(wrexec.NewNoOpRecordingCommandFactory(), "reponame", gitDir, limit); err != nil {
+	if err := pruneIfNeeded(wrexec.NewNoOpRecordingCommandFactory(), "git", "reponame", gitDir, limit); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -1022,7 +1280,7 @@ func TestSGMLogFile(t *testing.T) {
 	}
 
 	// failed run => log file
-	if err := sgMaintenance(logger, dir); err == nil {
+	if err := sgMaintenance(logger, "git", dir); err == nil {
 		t.Fatal("sgMaintenance should have returned an error")
 	}
 	mustHaveLogFile(t)
@@ -1035,7 +1293,7 @@ func TestSGMLogFile(t *testing.T) {
 	_ = os.Remove(fakeRef)
 
 	// fresh sgmLog file => skip execution
-	if err := sgMaintenance(logger, dir); err != nil {
+	if err := sgMaintenance(logger, "git", dir); err != nil {
 		t.Fatalf("unexpected error %s", err)
 	}
 	mustHaveLogFile(t)
@@ -1045,7 +1303,7 @@ func TestSGMLogFile(t *testing.T) {
 	if err := os.Chtimes(dir.Path(sgmLog), old, old); err != nil {
 		t.Fatal(err)
 	}
-	if err := sgMaintenance(logger, dir); err != nil {
+	if err := sgMaintenance(logger, "git", dir); err != nil {
 		t.Fatalf("unexpected error %s", err)
 	}
 	if _, err := os.Stat(dir.Path(sgmLog)); err == nil {
@@ -1053,6 +1311,30 @@ func TestSGMLogFile(t *testing.T) {
 	}
 }
 
+func TestSGMaintenance_GitBinary(t *testing.T) {
+	logger := logtest.Scoped(t)
+	dir := common.GitDir(t.TempDir())
+	cmd := exec.Command("git", "--bare", "init")
+	dir.Set(cmd)
+	require.NoError(t, cmd.Run())
+
+	// The configured git binary logs its subcommands before running git.
+	gitPath, err := exec.LookPath("git")
+	require.NoError(t, err)
+	binDir := t.TempDir()
+	logPath := filepath.Join(binDir, "calls.log")
+	script := "#!/bin/sh\necho \"$@\" >> " + logPath + "\nexec " + gitPath + " \"$@\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "my-git"), []byte(script), 0o755))
+
+	require.NoError(t, sgMaintenance(logger, filepath.Join(binDir, "my-git"), dir))
+
+	calls, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	for _, subcommand := range []string{"pack-refs", "reflog expire", "repack", "commit-graph write"} {
+		require.Contains(t, string(calls), subcommand)
+	}
+}
+
 func TestBestEffortReadFailed(t *testing.T) {
 	tc := []struct {
 		content     []byte
@@ -1129,6 +1411,45 @@ error message
 	}
 }
 
+func TestGitGCOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts gitGCOptions
+		want []string
+	}{
+		{
+			name: "defaults",
+			want: []string{"-c", "gc.auto=1", "-c", "gc.autoDetach=false", "gc", "--auto"},
+		},
+		{
+			name: "reflog expiry",
+			opts: gitGCOptions{ReflogExpire: "30.days", ReflogExpireUnreachable: "now"},
+			want: []string{"-c", "gc.auto=1", "-c", "gc.autoDetach=false", "-c", "gc.reflogExpire=30.days", "-c", "gc.reflogExpireUnreachable=now", "gc", "--auto"},
+		},
+		{
+			name: "aggressive",
+			opts: gitGCOptions{Aggressive: true},
+			want: []string{"-c", "gc.auto=1", "-c", "gc.autoDetach=false", "gc", "--auto", "--aggressive"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, tc.opts.args())
+		})
+	}
+
+	t.Run("env", func(t *testing.T) {
+		require.Nil(t, gitGCOptions{Aggressive: true}.env())
+		require.Equal(t, []string{
+			"GIT_CONFIG_COUNT=2",
+			"GIT_CONFIG_KEY_0=gc.reflogExpire",
+			"GIT_CONFIG_VALUE_0=30.days",
+			"GIT_CONFIG_KEY_1=gc.reflogExpireUnreachable",
+			"GIT_CONFIG_VALUE_1=now",
+		}, gitGCOptions{ReflogExpire: "30.days", ReflogExpireUnreachable: "now"}.env())
+	})
+}
+
 // We test whether the lock set by sg maintenance is respected by git gc.
 func TestGitGCRespectsLock(t *testing.T) {
 	dir := common.GitDir(t.TempDir())
@@ -1186,7 +1507,7 @@ func TestSGMaintenanceRespectsLock(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = sgMaintenance(logger, dir)
+	err = sgMaintenance(logger, "git", dir)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1211,7 +1532,7 @@ func TestSGMaintenanceRemovesLock(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err := sgMaintenance(logger, dir)
+	err := sgMaintenance(logger, "git", dir)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"syscall"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// cloneFailureReason is a coarse classification of why a clone failed. It is
+// used as a metric label, so that operators can tell e.g. a code host outage
+// from a disk problem, and to decide whether a clone is worth retrying.
+type cloneFailureReason string
+
+const (
+	// cloneFailureAuth means we aren't allowed to access the repo.
+	cloneFailureAuth cloneFailureReason = "auth"
+	// cloneFailureNotFound means the repo doesn't exist on the code host.
+	cloneFailureNotFound cloneFailureReason = "not_found"
+	// cloneFailureNetwork means talking to the code host failed temporarily.
+	cloneFailureNetwork cloneFailureReason = "network"
+	// cloneFailureDiskFull means gitserver ran out of disk space.
+	cloneFailureDiskFull cloneFailureReason = "disk_full"
+	// cloneFailureTimeout means the clone didn't finish within its deadline.
+	cloneFailureTimeout cloneFailureReason = "timeout"
+	// cloneFailureCanceled means the clone was canceled, e.g. on shutdown.
+	cloneFailureCanceled cloneFailureReason = "canceled"
+	// cloneFailureTooLarge means the repo exceeded the maximum repo size.
+	cloneFailureTooLarge cloneFailureReason = "too_large"
+	// cloneFailureOther is used for all errors we can't classify.
+	cloneFailureOther cloneFailureReason = "other"
+)
+
+// cloneFailurePatterns are substrings of git's output that indicate why a
+// clone failed, in the order they are checked. Auth and not found errors take
+// precedence over network errors, as git may report e.g. a hung up remote after
+// failing to authenticate.
+var cloneFailurePatterns = []struct {
+	reason   cloneFailureReason
+	patterns []string
+}{
+	{
+		reason: cloneFailureDiskFull,
+		patterns: []string{
+			"no space left on device",
+			"disk quota exceeded",
+		},
+	},
+	{
+		reason: cloneFailureAuth,
+		patterns: []string{
+			"authentication failed",
+			"could not read username",
+			"could not read password",
+			"terminal prompts disabled",
+			"permission denied",
+			"returned error: 401",
+			"returned error: 403",
+		},
+	},
+	{
+		reason: cloneFailureNotFound,
+		patterns: []string{
+			"repository not found",
+			"does not exist",
+			"returned error: 404",
+		},
+	},
+	{
+		reason: cloneFailureNetwork,
+		patterns: []string{
+			"could not resolve host",
+			"temporary failure in name resolution",
+			"connection timed out",
+			"operation timed out",
+			"connection reset",
+			"connection refused",
+			"tls handshake timeout",
+			"the remote end hung up unexpectedly",
+			"early eof",
+			"unexpected disconnect",
+			"rpc failed",
+			"returned error: 429",
+			"returned error: 500",
+			"returned error: 502",
+			"returned error: 503",
+			"returned error: 504",
+		},
+	},
+}
+
+// classifyCloneError returns why the clone that returned err failed.
+func classifyCloneError(err error) cloneFailureReason {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return cloneFailureTimeout
+	case errors.Is(err, context.Canceled):
+		return cloneFailureCanceled
+	case errors.Is(err, syscall.ENOSPC), errors.Is(err, syscall.EDQUOT):
+		return cloneFailureDiskFull
+	}
+	var notAllowed *ErrCloneNotAllowed
+	if errors.As(err, &notAllowed) {
+		return cloneFailureOther
+	}
+	var tooLarge *ErrRepoTooLarge
+	if errors.As(err, &tooLarge) {
+		return cloneFailureTooLarge
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, c := range cloneFailurePatterns {
+		for _, p := range c.patterns {
+			if strings.Contains(msg, p) {
+				return c.reason
+			}
+		}
+	}
+	return cloneFailureOther
+}
+
+var cloneFailuresCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "src_gitserver_clone_failures",
+	Help: "number of failed clones by classification of the failure",
+}, []string{"reason"})
@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+func TestClassifyCloneError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want cloneFailureReason
+	}{
+		{
+			name: "auth",
+			err:  errors.New("clone failed. Output: remote: Invalid username or password.\nfatal: Authentication failed for 'https://github.com/foo/bar/'"),
+			want: cloneFailureAuth,
+		},
+		{
+			name: "auth via HTTP status",
+			err:  errors.New("clone failed. Output: fatal: unable to access 'https://example.com/foo/bar/': The requested URL returned error: 403"),
+			want: cloneFailureAuth,
+		},
+		{
+			name: "not found",
+			err:  errors.New("clone failed. Output: remote: Repository not found.\nfatal: repository 'https://github.com/foo/bar/' not found"),
+			want: cloneFailureNotFound,
+		},
+		{
+			name: "network",
+			err:  errors.New("clone failed. Output: fatal: unable to access 'https://github.com/foo/bar/': Could not resolve host: github.com"),
+			want: cloneFailureNetwork,
+		},
+		{
+			name: "code host unavailable",
+			err:  errors.New("clone failed. Output: error: RPC failed; HTTP 503 curl 22 The requested URL returned error: 503"),
+			want: cloneFailureNetwork,
+		},
+		{
+			name: "disk full",
+			err:  errors.New("clone failed. Output: fatal: write error: No space left on device\nfatal: the remote end hung up unexpectedly"),
+			want: cloneFailureDiskFull,
+		},
+		{
+			name: "disk full syscall error",
+			err:  errors.Wrap(&fs.PathError{Op: "write", Path: "/data/repos/foo", Err: syscall.ENOSPC}, "failed to create tmp dir"),
+			want: cloneFailureDiskFull,
+		},
+		{
+			name: "timeout",
+			err:  errors.Wrapf(context.DeadlineExceeded, "failed to clone repo within deadline of %s", "1h0m0s"),
+			want: cloneFailureTimeout,
+		},
+		{
+			name: "canceled",
+			err:  errors.Wrap(context.Canceled, "clone failed"),
+			want: cloneFailureCanceled,
+		},
+		{
+			name: "clone not allowed",
+			err:  &ErrCloneNotAllowed{Repo: "github.com/foo/bar", Reason: "repo does not match allowlist"},
+			want: cloneFailureOther,
+		},
+		{
+			name: "unknown",
+			err:  &os.PathError{Op: "cloneRepo", Path: "/data/repos/foo", Err: os.ErrExist},
+			want: cloneFailureOther,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := classifyCloneError(tc.err); got != tc.want {
+				t.Errorf("classifyCloneError() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
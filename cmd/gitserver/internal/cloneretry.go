@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sourcegraph/log"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// CloneRetryPolicy configures how clones that failed with a transient error,
+// such as a network hiccup, are retried. Without retries, such repos are only
+// cloned again on the next scheduled update.
+type CloneRetryPolicy struct {
+	// MaxAttempts is the maximum number of clone attempts, including the
+	// first one. Values below 2 disable retries.
+	MaxAttempts int
+	// Backoff is the delay before the first retry. It doubles with every
+	// further retry.
+	Backoff time.Duration
+}
+
+// retryDelay returns the delay before the next attempt, given the number of
+// attempts that failed so far.
+func (p CloneRetryPolicy) retryDelay(failedAttempts int) time.Duration {
+	return p.Backoff << (failedAttempts - 1)
+}
+
+// isTransientCloneError returns true if err, as returned from cloneRepo, is
+// likely to go away when retrying the clone later. Errors we can't classify
+// are considered permanent.
+func isTransientCloneError(err error) bool {
+	return err != nil && classifyCloneError(err) == cloneFailureNetwork
+}
+
+// maybeRetryClone schedules another attempt to clone repo in the background,
+// if the failed attempt number attempt failed with a transient error and the
+// retry policy allows for more attempts. The retry goes through
+// repoUpdateOrClone again after the backoff, so it queues up behind the clones
+// that were requested in the meantime.
+func (s *Server) maybeRetryClone(repo api.RepoName, attempt int, cloneErr error) {
+	if attempt >= s.cloneRetryPolicy.MaxAttempts || !isTransientCloneError(cloneErr) {
+		return
+	}
+
+	delay := s.cloneRetryPolicy.retryDelay(attempt)
+	logger := s.logger.Scoped("cloneRetry").With(
+		log.String("repo", string(repo)),
+		log.Int("attempt", attempt+1),
+		log.Duration("delay", delay),
+	)
+	logger.Info("scheduling clone retry after transient error", log.Error(cloneErr))
+	cloneRetriesCounter.Inc()
+
+	s.setCloneRetryQueued(repo, true)
+	ctx, cancel := s.serverContext()
+	go func() {
+		defer cancel()
+		defer s.setCloneRetryQueued(repo, false)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+
+		// The repo might have been cloned by a request in the meantime.
+		if cloned, err := s.fs.RepoCloned(repo); err != nil || cloned {
+			return
+		}
+
+		if err := s.repoUpdateOrClone(ctx, repo, attempt+1); err != nil && !errors.Is(err, ErrFetchInProgress) {
+			logger.Warn("clone retry failed", log.Error(err))
+		}
+	}()
+}
+
+// CloneQueued returns true if a clone of repo failed and is scheduled to be
+// retried by maybeRetryClone.
+func (s *Server) CloneQueued(repo api.RepoName) bool {
+	s.queuedCloneRetriesMu.Lock()
+	defer s.queuedCloneRetriesMu.Unlock()
+	_, ok := s.queuedCloneRetries[repo]
+	return ok
+}
+
+func (s *Server) setCloneRetryQueued(repo api.RepoName, queued bool) {
+	s.queuedCloneRetriesMu.Lock()
+	defer s.queuedCloneRetriesMu.Unlock()
+	if !queued {
+		delete(s.queuedCloneRetries, repo)
+		return
+	}
+	if s.queuedCloneRetries == nil {
+		s.queuedCloneRetries = make(map[api.RepoName]struct{})
+	}
+	s.queuedCloneRetries[repo] = struct{}{}
+}
+
+var cloneRetriesCounter = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "src_gitserver_clone_retries",
+	Help: "number of clones retried after failing with a transient error",
+})
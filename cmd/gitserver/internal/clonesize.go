@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/gitserverfs"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+// ErrRepoTooLarge is returned when a clone is aborted because the repo took up
+// more disk space than the configured maximum repo size.
+type ErrRepoTooLarge struct {
+	Repo  api.RepoName
+	Size  int64
+	Limit int64
+}
+
+func (e *ErrRepoTooLarge) Error() string {
+	return fmt.Sprintf(
+		"repo %s is too large: clone aborted after it took up %s on disk, the maximum repo size is %s",
+		e.Repo, humanize.Bytes(uint64(e.Size)), humanize.Bytes(uint64(e.Limit)),
+	)
+}
+
+// cloneSizeCheckInterval is how often the size of a clone in progress is
+// compared to the maximum repo size.
+var cloneSizeCheckInterval = 5 * time.Second
+
+// checkCloneSize returns an *ErrRepoTooLarge if dir, the temporary directory
+// repo is cloned into, is larger than limit. A non-positive limit disables the
+// check.
+func checkCloneSize(fs gitserverfs.FS, repo api.RepoName, dir string, limit int64) error {
+	if limit <= 0 {
+		return nil
+	}
+	size, err := fs.DirSize(dir)
+	if err != nil {
+		// The clone is writing to dir, so we only check on a best effort basis.
+		return nil
+	}
+	if size > limit {
+		return &ErrRepoTooLarge{Repo: repo, Size: size, Limit: limit}
+	}
+	return nil
+}
+
+// watchCloneSize periodically checks the size of dir while repo is being
+// cloned into it, and calls cancel with an *ErrRepoTooLarge as the cause once
+// it exceeds limit. This keeps a single huge repo from filling up the disk
+// before the clone finishes. Watching stops when ctx is done or the returned
+// function is called, which waits for the watcher to exit.
+func watchCloneSize(ctx context.Context, cancel context.CancelCauseFunc, fs gitserverfs.FS, repo api.RepoName, dir string, limit int64) (stop func()) {
+	if limit <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+
+		ticker := time.NewTicker(cloneSizeCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := checkCloneSize(fs, repo, dir, limit); err != nil {
+					cancel(err)
+					return
+				}
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-exited
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sync"
 )
 
 // GitDir is an absolute path to a GIT_DIR.
@@ -25,6 +26,9 @@ func (dir GitDir) Path(elem ...string) string {
 // Note: GitDir is always a valid GIT_DIR, so we additionally set the
 // environment variable GIT_DIR. This is to avoid git doing discovery in case
 // of a bad repo, leading to hard to diagnose error messages.
+//
+// If dir is marked with NoLazyFetchFile, git is told to never lazily fetch
+// missing objects. The marker is only looked up once per dir.
 func (dir GitDir) Set(cmd *exec.Cmd) {
 	cmd.Dir = string(dir)
 	if cmd.Env == nil {
@@ -32,6 +36,47 @@ func (dir GitDir) Set(cmd *exec.Cmd) {
 		cmd.Env = os.Environ()
 	}
 	cmd.Env = append(cmd.Env, "GIT_DIR="+string(dir))
+	if dir.noLazyFetch() {
+		cmd.Env = append(cmd.Env, "GIT_NO_LAZY_FETCH=1")
+	}
+}
+
+// NoLazyFetchFile is the name of a file in a GitDir that marks that the
+// contents of some paths must never be fetched from the remote. Reading a
+// missing blob of such a repo fails instead of lazily fetching it, missing
+// blobs need to be fetched explicitly.
+const NoLazyFetchFile = "sg_no_lazy_fetch"
+
+// noLazyFetchDirs caches for every GitDir whether it is marked with
+// NoLazyFetchFile, so we don't stat the marker for every git command.
+var noLazyFetchDirs sync.Map // GitDir -> bool
+
+func (dir GitDir) noLazyFetch() bool {
+	if v, ok := noLazyFetchDirs.Load(dir); ok {
+		return v.(bool)
+	}
+	_, err := os.Stat(dir.Path(NoLazyFetchFile))
+	v, _ := noLazyFetchDirs.LoadOrStore(dir, err == nil)
+	return v.(bool)
+}
+
+// SetNoLazyFetch marks or unmarks dir with NoLazyFetchFile.
+func (dir GitDir) SetNoLazyFetch(noLazyFetch bool) error {
+	var err error
+	if noLazyFetch {
+		err = os.WriteFile(dir.Path(NoLazyFetchFile), nil, 0o600)
+	} else if err = os.Remove(dir.Path(NoLazyFetchFile)); os.IsNotExist(err) {
+		err = nil
+	}
+	noLazyFetchDirs.Delete(dir)
+	return err
+}
+
+// ForgetNoLazyFetch drops what we know about the NoLazyFetchFile marker of
+// dir. It needs to be called when the repo at dir is replaced, eg. by moving a
+// new clone into its place.
+func ForgetNoLazyFetch(dir GitDir) {
+	noLazyFetchDirs.Delete(dir)
 }
 
 // ErrRepoCorrupted is an error indicating that the repository is potentially
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"os"
 	"os/exec"
 	"testing"
 )
@@ -41,3 +42,53 @@ func TestGitDirSet(t *testing.T) {
 		t.Error("Expected GIT_DIR env to be set")
 	}
 }
+
+func TestGitDirSet_NoLazyFetch(t *testing.T) {
+	dir := GitDir(t.TempDir())
+
+	hasNoLazyFetchEnv := func() bool {
+		cmd := exec.Command("git", "log")
+		dir.Set(cmd)
+		for _, env := range cmd.Env {
+			if env == "GIT_NO_LAZY_FETCH=1" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if hasNoLazyFetchEnv() {
+		t.Error("Expected GIT_NO_LAZY_FETCH env to not be set")
+	}
+
+	if err := dir.SetNoLazyFetch(true); err != nil {
+		t.Fatal(err)
+	}
+
+	if !hasNoLazyFetchEnv() {
+		t.Error("Expected GIT_NO_LAZY_FETCH env to be set")
+	}
+
+	// The marker is only looked up once, changes on disk are picked up after
+	// forgetting about dir.
+	if err := os.Remove(dir.Path(NoLazyFetchFile)); err != nil {
+		t.Fatal(err)
+	}
+	if !hasNoLazyFetchEnv() {
+		t.Error("Expected GIT_NO_LAZY_FETCH env to still be set")
+	}
+	ForgetNoLazyFetch(dir)
+	if hasNoLazyFetchEnv() {
+		t.Error("Expected GIT_NO_LAZY_FETCH env to not be set")
+	}
+
+	if err := dir.SetNoLazyFetch(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := dir.SetNoLazyFetch(false); err != nil {
+		t.Fatal(err)
+	}
+	if hasNoLazyFetchEnv() {
+		t.Error("Expected GIT_NO_LAZY_FETCH env to not be set")
+	}
+}
@@ -122,22 +122,38 @@ func getTlsExternalDoNotInvoke() *tlsConfig {
 	}
 }
 
-func ConfigureRemoteGitCommand(cmd *exec.Cmd, remoteURL *vcs.URL) {
+// ConfigureRemoteGitCommand configures cmd to talk to remoteURL. cmd must run
+// either git, the configured git executable gitBinary or p4-fusion.
+func ConfigureRemoteGitCommand(cmd *exec.Cmd, gitBinary string, remoteURL *vcs.URL) {
 	// Inherit process environment. This allows admins to configure
 	// variables like http_proxy/etc.
 	if cmd.Env == nil {
 		cmd.Env = os.Environ()
 	}
-	configureRemoteGitCommand(cmd, remoteURL, tlsExternal())
+	configureRemoteGitCommand(cmd, gitBinary, remoteURL, tlsExternal(), false)
 }
 
-func configureRemoteGitCommand(cmd *exec.Cmd, remoteURL *vcs.URL, tlsConf *tlsConfig) {
+// ConfigureNamedRemoteGitCommand is like ConfigureRemoteGitCommand, for git
+// commands that talk to a remote configured in the repo by name instead of
+// to remoteURL directly. The configured URL is expected to have no
+// credentials, they are passed to git via the credential helper instead.
+func ConfigureNamedRemoteGitCommand(cmd *exec.Cmd, gitBinary string, remoteURL *vcs.URL) {
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	configureRemoteGitCommand(cmd, gitBinary, remoteURL, tlsExternal(), true)
+}
+
+func configureRemoteGitCommand(cmd *exec.Cmd, gitBinary string, remoteURL *vcs.URL, tlsConf *tlsConfig, namedRemote bool) {
 	// We split here in case the first command is an absolute path to the executable
 	// which allows us to safely match lower down
 	_, executable := path.Split(cmd.Args[0])
+	// The git executable can be configured with SRC_GITSERVER_GIT_BINARY, so it
+	// can have another name than "git".
+	isGit := executable == "git" || (gitBinary != "" && cmd.Args[0] == gitBinary)
 	// As a special case we also support the experimental p4-fusion client which is
 	// not run as a subcommand of git.
-	if executable != "git" && executable != "p4-fusion" {
+	if !isGit && executable != "p4-fusion" {
 		panic(fmt.Sprintf("Only git or p4-fusion commands are supported, got %q", executable))
 	}
 
@@ -168,11 +184,13 @@ func configureRemoteGitCommand(cmd *exec.Cmd, remoteURL *vcs.URL, tlsConf *tlsCo
 		"-c", "credential.helper=",
 	}
 
-	// If we have creds in the URL, pass it in via the credHelper.
+	// If we have creds in the URL, pass it in via the credHelper. For named
+	// remotes, this also includes a username without a password, since it
+	// is usually a token.
 	password, ok := remoteURL.User.Password()
-	if ok && executable == "git" && !remoteURL.IsSSH() {
+	if (ok || (namedRemote && remoteURL.User != nil)) && isGit && !remoteURL.IsSSH() {
 		// If the remote URL is one of the args, remove the user section from it.
-		hasCreds := false
+		hasCreds := namedRemote
 		for i, arg := range cmd.Args {
 			if arg == remoteURL.String() {
 				ru := *remoteURL
@@ -196,7 +214,7 @@ func configureRemoteGitCommand(cmd *exec.Cmd, remoteURL *vcs.URL, tlsConf *tlsCo
 		extraArgs = append(extraArgs, "-c", "protocol.version=2")
 	}
 
-	if executable == "p4-fusion" {
+	if !isGit {
 		extraArgs = removeUnsupportedP4Args(extraArgs)
 	}
 
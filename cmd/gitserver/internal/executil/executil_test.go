@@ -79,6 +79,7 @@ func TestConfigureRemoteGitCommand(t *testing.T) {
 	require.NoError(t, err)
 	tests := []struct {
 		input        *exec.Cmd
+		gitBinary    string
 		tlsConfig    *tlsConfig
 		expectedEnv  []string
 		expectedArgs []string
@@ -88,6 +89,13 @@ func TestConfigureRemoteGitCommand(t *testing.T) {
 			expectedEnv:  append(expectedEnv, "GIT_SG_USERNAME=foo", "GIT_SG_PASSWORD=bar"),
 			expectedArgs: []string{"git", "-c", "credential.helper=", "-c", "credential.helper=!f() { echo \"username=$GIT_SG_USERNAME\npassword=$GIT_SG_PASSWORD\"; }; f", "-c", "protocol.version=2", "fetch", "https://example.com/foo.git"},
 		},
+		{
+			// A git executable configured with SRC_GITSERVER_GIT_BINARY
+			input:        exec.Command("/opt/git/bin/git-2.45", "fetch", remoteURL.String()),
+			gitBinary:    "/opt/git/bin/git-2.45",
+			expectedEnv:  append(expectedEnv, "GIT_SG_USERNAME=foo", "GIT_SG_PASSWORD=bar"),
+			expectedArgs: []string{"/opt/git/bin/git-2.45", "-c", "credential.helper=", "-c", "credential.helper=!f() { echo \"username=$GIT_SG_USERNAME\npassword=$GIT_SG_PASSWORD\"; }; f", "-c", "protocol.version=2", "fetch", "https://example.com/foo.git"},
+		},
 		{
 			input:       exec.Command("git", "ls-remote", remoteURL.String()),
 			expectedEnv: append(expectedEnv, "GIT_SG_USERNAME=foo", "GIT_SG_PASSWORD=bar"),
@@ -138,7 +146,7 @@ func TestConfigureRemoteGitCommand(t *testing.T) {
 			if config == nil {
 				config = &tlsConfig{}
 			}
-			configureRemoteGitCommand(test.input, remoteURL, config)
+			configureRemoteGitCommand(test.input, test.gitBinary, remoteURL, config, false)
 			assert.Equal(t, test.expectedEnv, test.input.Env)
 			assert.Equal(t, test.expectedArgs, test.input.Args)
 		})
@@ -156,11 +164,24 @@ func TestConfigureRemoteP4FusionCommandWithoutCArgs(t *testing.T) {
 
 	remoteURL, err := vcs.ParseURL("https://example.com/foo.git")
 	require.NoError(t, err)
-	configureRemoteGitCommand(input, remoteURL, &tlsConfig{})
+	configureRemoteGitCommand(input, "", remoteURL, &tlsConfig{}, false)
 	assert.Equal(t, expectedEnv, input.Env)
 	assert.Equal(t, expectedArgs, input.Args)
 }
 
+func TestConfigureRemoteGitCommand_UnknownExecutable(t *testing.T) {
+	remoteURL, err := vcs.ParseURL("https://example.com/foo.git")
+	require.NoError(t, err)
+
+	assert.Panics(t, func() {
+		configureRemoteGitCommand(exec.Command("curl", remoteURL.String()), "", remoteURL, &tlsConfig{}, false)
+	})
+	assert.Panics(t, func() {
+		// Only the configured git executable is run as git.
+		configureRemoteGitCommand(exec.Command("/opt/git/bin/git-2.45", "fetch"), "/opt/git/bin/git-2.46", remoteURL, &tlsConfig{}, false)
+	})
+}
+
 func TestRemoveUnsupportedP4Args(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -237,7 +258,7 @@ func TestConfigureRemoteGitCommand_tls(t *testing.T) {
 	require.NoError(t, err)
 	for _, tc := range cases {
 		cmd := exec.Command("git", "clone")
-		configureRemoteGitCommand(cmd, remoteURL, tc.conf)
+		configureRemoteGitCommand(cmd, "", remoteURL, tc.conf, false)
 		want := append(baseEnv, tc.want...)
 		assert.Equal(t, want, cmd.Env)
 	}
@@ -55,10 +55,10 @@ func SetGitAttributes(dir common.GitDir) error {
 //
 //	warning: refname 'HEAD' is ambiguous.
 //
-// Instead we just remove this ref.
-func RemoveBadRefs(ctx context.Context, dir common.GitDir) (errs error) {
+// Instead we just remove this ref, with the git executable gitBinary.
+func RemoveBadRefs(ctx context.Context, gitBinary string, dir common.GitDir) (errs error) {
 	args := append([]string{"branch", "-D"}, badRefs()...)
-	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd := exec.CommandContext(ctx, gitBinary, args...)
 	dir.Set(cmd)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
@@ -70,7 +70,7 @@ func RemoveBadRefs(ctx context.Context, dir common.GitDir) (errs error) {
 	}
 
 	args = append([]string{"tag", "-d"}, badRefs()...)
-	cmd = exec.CommandContext(ctx, "git", args...)
+	cmd = exec.CommandContext(ctx, gitBinary, args...)
 	dir.Set(cmd)
 	out, err = cmd.CombinedOutput()
 	if err != nil {
@@ -101,9 +101,10 @@ var badRefs = sync.OnceValue(func() []string {
 	return refs
 })
 
-// MakeBareRepo initializes a new bare repo at the given dir.
-func MakeBareRepo(ctx context.Context, dir string) error {
-	cmd := exec.CommandContext(ctx, "git", "init", "--bare", ".")
+// MakeBareRepo initializes a new bare repo at the given dir with the git
+// executable gitBinary.
+func MakeBareRepo(ctx context.Context, gitBinary, dir string) error {
+	cmd := exec.CommandContext(ctx, gitBinary, "init", "--bare", ".")
 	cmd.Dir = dir
 	out, err := cmd.CombinedOutput()
 	if err != nil {
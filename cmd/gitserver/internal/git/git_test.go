@@ -17,7 +17,7 @@ func TestMakeBareRepo(t *testing.T) {
 	dir := t.TempDir()
 	ctx := context.Background()
 
-	require.NoError(t, MakeBareRepo(ctx, dir))
+	require.NoError(t, MakeBareRepo(ctx, "git", dir))
 
 	// Now verify we created a valid repo.
 	c := exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
@@ -45,7 +45,7 @@ func TestRemoveBadRefs(t *testing.T) {
 			t.Logf("WARNING: git tag %s failed to produce ambiguous output: %s", name, dontWant)
 		}
 
-		if err := RemoveBadRefs(context.Background(), gitDir); err != nil {
+		if err := RemoveBadRefs(context.Background(), "git", gitDir); err != nil {
 			t.Fatal(err)
 		}
 
@@ -62,7 +62,7 @@ func TestRemoveBadRefs(t *testing.T) {
 			t.Logf("WARNING: git ref %s failed to produce ambiguous output: %s", name, dontWant)
 		}
 
-		if err := RemoveBadRefs(context.Background(), gitDir); err != nil {
+		if err := RemoveBadRefs(context.Background(), "git", gitDir); err != nil {
 			t.Fatal(err)
 		}
 
@@ -1,13 +1,48 @@
 package gitcli
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"io"
+	"path"
+	"strconv"
+	"strings"
 
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/git"
+	"github.com/sourcegraph/sourcegraph/internal/env"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
+const (
+	// archiveSymlinkPolicyAllow includes all symlinks in archives as they are.
+	archiveSymlinkPolicyAllow = "allow"
+	// archiveSymlinkPolicyStrip leaves symlinks that point outside of the
+	// repository out of archives.
+	archiveSymlinkPolicyStrip = "strip"
+	// archiveSymlinkPolicyReject fails archives that would contain a symlink
+	// that points outside of the repository.
+	archiveSymlinkPolicyReject = "reject"
+)
+
+// archiveSymlinkPolicy controls how archives treat symlinks that point outside
+// of the repository. Consumers that extract archives to disk might otherwise
+// follow such a symlink out of the extracted tree.
+var archiveSymlinkPolicy = env.Get("SRC_GITSERVER_ARCHIVE_SYMLINK_POLICY", archiveSymlinkPolicyAllow, "How archives handle symlinks that point outside of the repository: allow keeps them, strip leaves them out of the archive, reject fails the archive.")
+
+// archiveBigFileThreshold is passed to git archive as core.bigFileThreshold.
+// Git reads files smaller than the threshold into memory as a whole before
+// writing them to the archive, larger files are streamed. The git default of
+// 512MiB lets a single archive of a repo with huge files use a lot of memory.
+var archiveBigFileThreshold = env.Get("SRC_GITSERVER_ARCHIVE_BIG_FILE_THRESHOLD", "32m", "Files larger than this are streamed into archives instead of being read into memory as a whole. Accepts the same units as git's core.bigFileThreshold.")
+
 func (g *gitCLIBackend) ArchiveReader(ctx context.Context, format git.ArchiveFormat, treeish string, paths []string) (io.ReadCloser, error) {
+	switch format {
+	case git.ArchiveFormatTar, git.ArchiveFormatZip:
+	default:
+		return nil, errors.Newf("unsupported archive format %q", format)
+	}
+
 	if err := checkSpecArgSafety(treeish); err != nil {
 		return nil, err
 	}
@@ -18,9 +53,33 @@ func (g *gitCLIBackend) ArchiveReader(ctx context.Context, format git.ArchiveFor
 		return nil, err
 	}
 
+	var excludes []string
+	switch archiveSymlinkPolicy {
+	case archiveSymlinkPolicyAllow:
+	case archiveSymlinkPolicyStrip, archiveSymlinkPolicyReject:
+		symlinks, err := g.outOfTreeSymlinks(ctx, treeish, paths)
+		if err != nil {
+			return nil, err
+		}
+		if len(symlinks) > 0 && archiveSymlinkPolicy == archiveSymlinkPolicyReject {
+			return nil, symlinks[0]
+		}
+		for _, s := range symlinks {
+			excludes = append(excludes, s.Path)
+		}
+	default:
+		return nil, errors.Newf("invalid archive symlink policy %q", archiveSymlinkPolicy)
+	}
+
 	archiveArgs := buildArchiveArgs(format, treeish, paths)
+	for _, p := range excludes {
+		archiveArgs = append(archiveArgs, pathspecExcludeLiteral(p))
+	}
 
-	return g.NewCommand(ctx, WithArguments(archiveArgs...))
+	return g.NewCommand(ctx,
+		WithArguments(archiveArgs...),
+		withConfigOverride("core.bigFileThreshold", archiveBigFileThreshold),
+	)
 }
 
 func buildArchiveArgs(format git.ArchiveFormat, treeish string, paths []string) []string {
@@ -38,8 +97,157 @@ func buildArchiveArgs(format git.ArchiveFormat, treeish string, paths []string)
 	return args
 }
 
+// lsTreeMaxPathsSize limits the combined size of the paths passed to a single
+// git ls-tree invocation, to stay well below the OS limit for the size of a
+// command line (ARG_MAX). ls-tree can't read paths from stdin.
+const lsTreeMaxPathsSize = 64 * 1024
+
+// outOfTreeSymlinks returns the symlinks below paths of treeish whose target
+// lies outside of the repository. If no paths are given, the whole tree is
+// considered.
+func (g *gitCLIBackend) outOfTreeSymlinks(ctx context.Context, treeish string, paths []string) ([]*git.OutOfTreeSymlinkError, error) {
+	var names, oids []string
+	seen := make(map[string]struct{})
+	for _, batch := range batchPaths(paths, lsTreeMaxPathsSize) {
+		r, err := g.NewCommand(ctx, WithArguments(append([]string{"ls-tree", "-r", "-z", treeish, "--"}, batch...)...))
+		if err != nil {
+			return nil, err
+		}
+		out, err := io.ReadAll(r)
+		if closeErr := r.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list tree")
+		}
+
+		for _, entry := range bytes.Split(out, []byte{0}) {
+			if len(entry) == 0 {
+				continue
+			}
+			// Format: <mode> SP <type> SP <object> TAB <file>
+			info, name, ok := bytes.Cut(entry, []byte{'\t'})
+			if !ok {
+				return nil, errors.Errorf("invalid `git ls-tree` output: %q", entry)
+			}
+			fields := bytes.Fields(info)
+			if len(fields) != 3 {
+				return nil, errors.Errorf("invalid `git ls-tree` output: %q", entry)
+			}
+			if string(fields[0]) != "120000" {
+				continue
+			}
+			// Overlapping paths in different batches list the same entry.
+			if _, ok := seen[string(name)]; ok {
+				continue
+			}
+			seen[string(name)] = struct{}{}
+			names = append(names, string(name))
+			oids = append(oids, string(fields[2]))
+		}
+	}
+	if len(oids) == 0 {
+		return nil, nil
+	}
+
+	targets, err := g.readBlobs(ctx, oids)
+	if err != nil {
+		return nil, err
+	}
+
+	var symlinks []*git.OutOfTreeSymlinkError
+	for i, name := range names {
+		if symlinkEscapesTree(name, targets[i]) {
+			symlinks = append(symlinks, &git.OutOfTreeSymlinkError{Path: name, Target: targets[i]})
+		}
+	}
+
+	return symlinks, nil
+}
+
+// batchPaths splits paths into batches whose combined size doesn't exceed
+// maxSize, unless a single path does. It always returns at least one batch, so
+// that no paths still means the whole tree.
+func batchPaths(paths []string, maxSize int) [][]string {
+	batches := [][]string{nil}
+	size := 0
+	for _, p := range paths {
+		last := len(batches) - 1
+		if size+len(p) > maxSize && len(batches[last]) > 0 {
+			batches = append(batches, nil)
+			last++
+			size = 0
+		}
+		batches[last] = append(batches[last], p)
+		size += len(p)
+	}
+	return batches
+}
+
+// readBlobs returns the contents of the blobs with the given object IDs. All
+// blobs are read by a single git cat-file --batch process.
+func (g *gitCLIBackend) readBlobs(ctx context.Context, oids []string) ([]string, error) {
+	r, err := g.NewCommand(ctx,
+		WithArguments("cat-file", "--batch"),
+		WithStdin(strings.NewReader(strings.Join(oids, "\n")+"\n")),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := readCatFileBatch(bufio.NewReader(r), oids)
+	if closeErr := r.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return contents, nil
+}
+
+// readCatFileBatch reads the contents of the given objects from the output of
+// git cat-file --batch.
+func readCatFileBatch(r *bufio.Reader, oids []string) ([]string, error) {
+	contents := make([]string, 0, len(oids))
+	for _, oid := range oids {
+		// Format: <oid> SP <type> SP <size> LF <contents> LF
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read blob %s", oid)
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			return nil, errors.Errorf("invalid `git cat-file --batch` output for %s: %q", oid, header)
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, errors.Errorf("invalid `git cat-file --batch` output for %s: %q", oid, header)
+		}
+		content := make([]byte, size+1)
+		if _, err := io.ReadFull(r, content); err != nil {
+			return nil, errors.Wrapf(err, "failed to read blob %s", oid)
+		}
+		contents = append(contents, string(content[:size]))
+	}
+	return contents, nil
+}
+
+// symlinkEscapesTree returns true if the symlink at name, a path relative to
+// the repository root, resolves to a location outside of the repository.
+func symlinkEscapesTree(name, target string) bool {
+	if path.IsAbs(target) {
+		return true
+	}
+	resolved := path.Join(path.Dir(name), target)
+	return resolved == ".." || strings.HasPrefix(resolved, "../")
+}
+
 // pathspecLiteral constructs a pathspec that matches a path without interpreting "*" or "?" as special
 // characters.
 //
 // See: https://git-scm.com/docs/gitglossary#Documentation/gitglossary.txt-literal
 func pathspecLiteral(s string) string { return ":(literal)" + s }
+
+// pathspecExcludeLiteral constructs a pathspec that excludes a path, without
+// interpreting "*" or "?" as special characters.
+func pathspecExcludeLiteral(s string) string { return ":(exclude,literal)" + s }
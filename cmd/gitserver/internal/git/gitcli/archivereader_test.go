@@ -3,9 +3,12 @@ package gitcli
 import (
 	"archive/tar"
 	"archive/zip"
+	"bufio"
 	"bytes"
 	"context"
+	"fmt"
 	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -182,3 +185,217 @@ func TestGitCLIBackend_ArchiveReader(t *testing.T) {
 		require.True(t, errors.Is(r.Close(), context.Canceled), "unexpected error: %v", err)
 	})
 }
+
+func TestGitCLIBackend_ArchiveReader_Formats(t *testing.T) {
+	ctx := context.Background()
+
+	// Make sure large files are streamed into the archive.
+	old := archiveBigFileThreshold
+	archiveBigFileThreshold = "1k"
+	t.Cleanup(func() { archiveBigFileThreshold = old })
+
+	backend := BackendWithRepoCommands(t,
+		"echo abcd > file1",
+		"mkdir dir1",
+		"seq 1 100000 > dir1/large",
+		"git add file1 dir1",
+		"git commit -m commit --author='Foo Author <foo@sourcegraph.com>'",
+	)
+
+	commitID, err := backend.RevParseHead(ctx)
+	require.NoError(t, err)
+
+	var large strings.Builder
+	for i := 1; i <= 100000; i++ {
+		fmt.Fprintf(&large, "%d\n", i)
+	}
+	want := map[string]string{
+		"file1":      "abcd\n",
+		"dir1/large": large.String(),
+	}
+
+	t.Run("tar", func(t *testing.T) {
+		r, err := backend.ArchiveReader(ctx, git.ArchiveFormatTar, string(commitID), nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { r.Close() })
+
+		got := map[string]string{}
+		tr := tar.NewReader(r)
+		for {
+			h, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			if h.Typeflag != tar.TypeReg {
+				continue
+			}
+			contents, err := io.ReadAll(tr)
+			require.NoError(t, err)
+			require.Equal(t, h.Size, int64(len(contents)))
+			got[h.Name] = string(contents)
+		}
+		require.Equal(t, want, got)
+		require.NoError(t, r.Close())
+	})
+
+	t.Run("zip", func(t *testing.T) {
+		r, err := backend.ArchiveReader(ctx, git.ArchiveFormatZip, string(commitID), nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { r.Close() })
+
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+
+		got := map[string]string{}
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			got[f.Name] = readFileContentsFromZip(t, zr, f.Name)
+		}
+		require.Equal(t, want, got)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := backend.ArchiveReader(ctx, "tar.gz", string(commitID), nil)
+		require.ErrorContains(t, err, `unsupported archive format "tar.gz"`)
+	})
+}
+
+func TestGitCLIBackend_ArchiveReader_SymlinkPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	backend := BackendWithRepoCommands(t,
+		"echo abcd > file1",
+		"ln -s file1 link",
+		"ln -s ../../etc/passwd escaping",
+		"mkdir dir1",
+		"ln -s ../file1 dir1/link",
+		"git add file1 link escaping dir1",
+		"git commit -m commit --author='Foo Author <foo@sourcegraph.com>'",
+	)
+
+	commitID, err := backend.RevParseHead(ctx)
+	require.NoError(t, err)
+
+	setPolicy := func(t *testing.T, policy string) {
+		old := archiveSymlinkPolicy
+		archiveSymlinkPolicy = policy
+		t.Cleanup(func() { archiveSymlinkPolicy = old })
+	}
+
+	archiveEntries := func(t *testing.T, paths []string) []string {
+		r, err := backend.ArchiveReader(ctx, git.ArchiveFormatTar, string(commitID), paths)
+		require.NoError(t, err)
+		t.Cleanup(func() { r.Close() })
+
+		var names []string
+		tr := tar.NewReader(r)
+		for {
+			h, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			// git archive writes the commit ID into a pax global header.
+			if h.Typeflag == tar.TypeXGlobalHeader {
+				continue
+			}
+			names = append(names, h.Name)
+		}
+		return names
+	}
+
+	t.Run("allow", func(t *testing.T) {
+		setPolicy(t, archiveSymlinkPolicyAllow)
+		require.ElementsMatch(t, []string{"dir1/", "dir1/link", "escaping", "file1", "link"}, archiveEntries(t, nil))
+	})
+
+	t.Run("strip", func(t *testing.T) {
+		setPolicy(t, archiveSymlinkPolicyStrip)
+		require.ElementsMatch(t, []string{"dir1/", "dir1/link", "file1", "link"}, archiveEntries(t, nil))
+		require.ElementsMatch(t, []string{"file1"}, archiveEntries(t, []string{"file1", "escaping"}))
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		setPolicy(t, archiveSymlinkPolicyReject)
+		_, err := backend.ArchiveReader(ctx, git.ArchiveFormatTar, string(commitID), nil)
+		var symlinkErr *git.OutOfTreeSymlinkError
+		require.ErrorAs(t, err, &symlinkErr)
+		require.Equal(t, "escaping", symlinkErr.Path)
+		require.Equal(t, "../../etc/passwd", symlinkErr.Target)
+
+		// Archives that don't contain the symlink are fine.
+		require.ElementsMatch(t, []string{"dir1/", "dir1/link"}, archiveEntries(t, []string{"dir1"}))
+	})
+
+	t.Run("invalid policy", func(t *testing.T) {
+		setPolicy(t, "follow")
+		_, err := backend.ArchiveReader(ctx, git.ArchiveFormatTar, string(commitID), nil)
+		require.Error(t, err)
+	})
+}
+
+func TestGitCLIBackend_OutOfTreeSymlinks_ManyPaths(t *testing.T) {
+	ctx := context.Background()
+
+	backend := BackendWithRepoCommands(t,
+		"echo abcd > file1",
+		"ln -s ../../etc/passwd escaping",
+		"git add file1 escaping",
+		"git commit -m commit --author='Foo Author <foo@sourcegraph.com>'",
+	)
+
+	commitID, err := backend.RevParseHead(ctx)
+	require.NoError(t, err)
+
+	// Together, the paths exceed what fits on a command line. The escaping
+	// symlink is listed in more than one batch.
+	paths := []string{"escaping"}
+	for i := range 100_000 {
+		paths = append(paths, fmt.Sprintf("dir/some/long/path/to/a/file/that/does/not/exist/%d", i))
+	}
+	paths = append(paths, "escaping")
+
+	symlinks, err := backend.(*gitCLIBackend).outOfTreeSymlinks(ctx, string(commitID), paths)
+	require.NoError(t, err)
+	require.Len(t, symlinks, 1)
+	require.Equal(t, "escaping", symlinks[0].Path)
+}
+
+func TestBatchPaths(t *testing.T) {
+	require.Equal(t, [][]string{nil}, batchPaths(nil, 4))
+	require.Equal(t, [][]string{{"a", "bb"}, {"cc"}, {"dddddd"}, {"e"}}, batchPaths([]string{"a", "bb", "cc", "dddddd", "e"}, 4))
+}
+
+func TestSymlinkEscapesTree(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		target string
+		want   bool
+	}{
+		{name: "link", target: "file", want: false},
+		{name: "dir/link", target: "../file", want: false},
+		{name: "dir/link", target: "./sub/../../file", want: false},
+		{name: "link", target: "../file", want: true},
+		{name: "link", target: "..", want: true},
+		{name: "dir/link", target: "../../file", want: true},
+		{name: "link", target: "/etc/passwd", want: true},
+	} {
+		require.Equal(t, tc.want, symlinkEscapesTree(tc.name, tc.target), "%s -> %s", tc.name, tc.target)
+	}
+}
+
+func TestReadCatFileBatch(t *testing.T) {
+	out := "aaaa blob 5\nfile1\nbbbb blob 9\n../file1\n\n"
+	contents, err := readCatFileBatch(bufio.NewReader(strings.NewReader(out)), []string{"aaaa", "bbbb"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"file1", "../file1\n"}, contents)
+
+	_, err = readCatFileBatch(bufio.NewReader(strings.NewReader("cccc missing\n")), []string{"cccc"})
+	require.Error(t, err)
+}
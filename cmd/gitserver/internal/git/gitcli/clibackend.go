@@ -1,6 +1,10 @@
 package gitcli
 
 import (
+	"context"
+	"maps"
+	"time"
+
 	"github.com/sourcegraph/log"
 
 	"github.com/hashicorp/golang-lru/v2"
@@ -8,23 +12,60 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/common"
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/git"
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
 	"github.com/sourcegraph/sourcegraph/internal/wrexec"
 )
 
-func NewBackend(logger log.Logger, rcf *wrexec.RecordingCommandFactory, dir common.GitDir, repoName api.RepoName) git.GitBackend {
-	return &gitCLIBackend{
+// DefaultGitBinary is the git executable used when no other one is configured.
+// It is looked up in PATH.
+const DefaultGitBinary = "git"
+
+// NewBackend returns a git.GitBackend for the repo at dir that is implemented
+// by running the git executable gitBinary. If gitBinary is empty,
+// DefaultGitBinary is used.
+func NewBackend(logger log.Logger, rcf *wrexec.RecordingCommandFactory, gitBinary string, dir common.GitDir, repoName api.RepoName, opts ...BackendOptionFunc) git.GitBackend {
+	if gitBinary == "" {
+		gitBinary = DefaultGitBinary
+	}
+	g := &gitCLIBackend{
 		logger:         logger,
 		rcf:            rcf,
+		gitBinary:      gitBinary,
 		dir:            dir,
 		repoName:       repoName,
 		revAtTimeCache: globalRevAtTimeCache,
+		timeouts:       maps.Clone(gitCommandTimeouts),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// BackendOptionFunc configures a backend created by NewBackend.
+type BackendOptionFunc func(*gitCLIBackend)
+
+// WithLazyFetchRemoteURL makes git commands pass the credentials of the URL
+// returned by remoteURL to git. This is needed for partial clones, where git
+// lazily fetches missing objects from a promisor remote that is configured
+// without credentials.
+func WithLazyFetchRemoteURL(remoteURL func(context.Context) (*vcs.URL, error)) BackendOptionFunc {
+	return func(g *gitCLIBackend) {
+		g.lazyFetchRemoteURL = remoteURL
 	}
 }
 
 type gitCLIBackend struct {
 	logger         log.Logger
 	rcf            *wrexec.RecordingCommandFactory
+	gitBinary      string
 	dir            common.GitDir
 	repoName       api.RepoName
 	revAtTimeCache *lru.Cache[revAtTimeCacheKey, api.CommitID]
+	// timeouts overrides the timeouts of git subcommands used when the
+	// context of a command has no deadline. See commandTimeout.
+	timeouts map[string]time.Duration
+	// lazyFetchRemoteURL, if set, returns the URL whose credentials git uses
+	// to lazily fetch missing objects. See WithLazyFetchRemoteURL.
+	lazyFetchRemoteURL func(context.Context) (*vcs.URL, error)
 }
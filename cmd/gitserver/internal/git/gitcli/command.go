@@ -7,6 +7,8 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"runtime"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -23,6 +25,7 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/common"
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/executil"
 	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/honey"
@@ -48,12 +51,26 @@ var (
 	}, []string{"cmd"})
 
 	memoryObservationEnabled = env.MustGetBool("GITSERVER_MEMORY_OBSERVATION_ENABLED", false, "enable memory observation for gitserver commands")
+
+	// highMemoryUsageThreshold is the max RSS above which a git command is
+	// counted and logged as using a lot of memory.
+	highMemoryUsageThreshold = bytesize.Bytes(env.MustGetBytes("SRC_GITSERVER_HIGH_MEMORY_USAGE_THRESHOLD", "500MiB", "Git commands whose maximum resident set size exceeds this are counted and logged as high memory usage. 0 disables the reporting."))
 )
 
 type commandOpts struct {
 	arguments []string
 
+	// configOverrides are passed to git as `-c <key>=<value>` before the
+	// subcommand.
+	configOverrides []string
+
 	stdin io.Reader
+
+	progressCallback func(line string)
+
+	// maxOutputBytes is the maximum number of bytes read from stdout before
+	// the command is killed. Zero means no limit.
+	maxOutputBytes int64
 }
 
 func optsFromFuncs(optFns ...CommandOptionFunc) commandOpts {
@@ -74,14 +91,82 @@ func WithArguments(args ...string) CommandOptionFunc {
 }
 
 // WithStdin specifies the reader to use for the command's stdin input.
+//
+// If the git process exits or the command's context is canceled before all of
+// stdin was read, and stdin has a CloseWithError method like *io.PipeReader,
+// it is closed with the error so that writers on the other end don't block
+// forever. The command never closes stdin otherwise, it remains owned by the
+// caller.
 func WithStdin(stdin io.Reader) CommandOptionFunc {
 	return func(o *commandOpts) {
 		o.stdin = stdin
 	}
 }
 
+// WithProgressCallback registers a callback that is invoked with every line git
+// writes to stderr, as it arrives. Git separates updates of its progress output
+// with carriage returns, each of those is passed to the callback as a separate
+// line. Stderr is still captured for error reporting.
+func WithProgressCallback(callback func(line string)) CommandOptionFunc {
+	return func(o *commandOpts) {
+		o.progressCallback = callback
+	}
+}
+
+// WithMaxOutputBytes limits the output of the command to n bytes. Once the
+// command writes more than that to stdout, it is killed and reading from it
+// returns an *OutputLimitExceededError.
+func WithMaxOutputBytes(n int64) CommandOptionFunc {
+	return func(o *commandOpts) {
+		o.maxOutputBytes = n
+	}
+}
+
+// withConfigOverride overrides the git config key with value for the command.
+// It is unexported as it isn't subject to the allowlist of IsAllowedGitCmd.
+func withConfigOverride(key, value string) CommandOptionFunc {
+	return func(o *commandOpts) {
+		o.configOverrides = append(o.configOverrides, key+"="+value)
+	}
+}
+
 const gitCommandDefaultTimeout = time.Minute
 
+// gitCommandTimeouts overrides gitCommandDefaultTimeout for git subcommands.
+var gitCommandTimeouts = mustParseGitCommandTimeouts(env.Get("SRC_GITSERVER_GIT_COMMAND_TIMEOUTS", "", "Comma-separated list of subcommand=duration pairs, e.g. \"archive=30m,rev-parse=10s\", overriding the timeouts of git commands run without a deadline. By default, git commands time out after 1 minute."))
+
+// mustParseGitCommandTimeouts parses a comma-separated list of
+// subcommand=duration pairs. It panics if s is invalid, like the env.MustGet
+// functions.
+func mustParseGitCommandTimeouts(s string) map[string]time.Duration {
+	timeouts := map[string]time.Duration{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		subCmd, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			panic(fmt.Sprintf("invalid SRC_GITSERVER_GIT_COMMAND_TIMEOUTS entry %q: expected subcommand=duration", pair))
+		}
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			panic(fmt.Sprintf("invalid SRC_GITSERVER_GIT_COMMAND_TIMEOUTS entry %q: expected a positive duration", pair))
+		}
+		timeouts[strings.TrimSpace(subCmd)] = timeout
+	}
+	return timeouts
+}
+
+// commandTimeout returns the timeout for the git subcommand subCmd, which is
+// used if the context of the command has no deadline.
+func (g *gitCLIBackend) commandTimeout(subCmd string) time.Duration {
+	if timeout, ok := g.timeouts[subCmd]; ok {
+		return timeout
+	}
+	return gitCommandDefaultTimeout
+}
+
 func (g *gitCLIBackend) NewCommand(ctx context.Context, optFns ...CommandOptionFunc) (_ io.ReadCloser, err error) {
 	opts := optsFromFuncs(optFns...)
 
@@ -111,19 +196,35 @@ func (g *gitCLIBackend) NewCommand(ctx context.Context, optFns ...CommandOptionF
 
 	subCmd := opts.arguments[0]
 
-	// If no deadline is set, use the default git command timeout.
+	// If no deadline is set, use the timeout for the subcommand.
 	cancel := func() {}
 	if _, ok := ctx.Deadline(); !ok {
-		ctx, cancel = context.WithTimeout(ctx, gitCommandDefaultTimeout)
+		ctx, cancel = context.WithTimeout(ctx, g.commandTimeout(subCmd))
 	}
 
-	cmd := exec.CommandContext(ctx, "git", opts.arguments...)
+	args := make([]string, 0, 2*len(opts.configOverrides)+len(opts.arguments))
+	for _, c := range opts.configOverrides {
+		args = append(args, "-c", c)
+	}
+	args = append(args, opts.arguments...)
+
+	cmd := exec.CommandContext(ctx, g.gitBinary, args...)
 	cmd.Cancel = func() error {
 		// Send SIGKILL to the process group instead of just the process
 		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
 	}
 	g.dir.Set(cmd)
 
+	if g.lazyFetchRemoteURL != nil {
+		// Not all commands read blobs, so we don't fail the command if we
+		// can't get the credentials. Lazy fetches fail then instead.
+		if remoteURL, err := g.lazyFetchRemoteURL(ctx); err != nil {
+			logger.Warn("failed to get remote URL for lazy fetches", log.Error(err))
+		} else {
+			executil.ConfigureNamedRemoteGitCommand(cmd, g.gitBinary, remoteURL)
+		}
+	}
+
 	if cmd.SysProcAttr == nil {
 		cmd.SysProcAttr = &syscall.SysProcAttr{}
 	}
@@ -143,6 +244,11 @@ func (g *gitCLIBackend) NewCommand(ctx context.Context, optFns ...CommandOptionF
 	cmd.SysProcAttr.Setpgid = true
 
 	stderr, stderrBuf := stderrBuffer()
+	var progress *progressWriter
+	if opts.progressCallback != nil {
+		progress = &progressWriter{callback: opts.progressCallback}
+		stderr = io.MultiWriter(stderr, progress)
+	}
 	cmd.Stderr = stderr
 
 	wrappedCmd := g.rcf.WrapWithRepoName(ctx, logger, g.repoName, cmd)
@@ -153,8 +259,14 @@ func (g *gitCLIBackend) NewCommand(ctx context.Context, optFns ...CommandOptionF
 		return nil, errors.Wrap(err, "failed to create stdout pipe")
 	}
 
+	var stdin *stdinPump
 	if opts.stdin != nil {
-		cmd.Stdin = opts.stdin
+		w, err := wrappedCmd.StdinPipe()
+		if err != nil {
+			cancel()
+			return nil, errors.Wrap(err, "failed to create stdin pipe")
+		}
+		stdin = newStdinPump(opts.stdin, w)
 	}
 
 	cmdStart := time.Now()
@@ -164,6 +276,10 @@ func (g *gitCLIBackend) NewCommand(ctx context.Context, optFns ...CommandOptionF
 		return nil, errors.Wrap(err, "failed to start git process")
 	}
 
+	if stdin != nil {
+		stdin.start(ctx)
+	}
+
 	observer := memcmd.NewNoOpObserver()
 
 	if memoryObservationEnabled {
@@ -189,13 +305,16 @@ func (g *gitCLIBackend) NewCommand(ctx context.Context, optFns ...CommandOptionF
 		ctxCancel:      cancel,
 		cmdStart:       cmdStart,
 		stdout:         stdout,
+		stdin:          stdin,
 		cmd:            wrappedCmd,
 		stderr:         stderrBuf,
+		progress:       progress,
 		repoName:       g.repoName,
 		logger:         logger,
 		gitDir:         g.dir,
 		tr:             tr,
 		memoryObserver: observer,
+		maxOutputBytes: opts.maxOutputBytes,
 	}
 
 	return cr, nil
@@ -218,6 +337,18 @@ func newCommandFailedError(ctx context.Context, err error, cmd wrexec.Cmder, std
 	}
 }
 
+// OutputLimitExceededError is returned when reading from a command that wrote
+// more output than allowed by WithMaxOutputBytes. The output read up to that
+// point is truncated at the limit.
+type OutputLimitExceededError struct {
+	Limit int64
+	args  []string
+}
+
+func (e *OutputLimitExceededError) Error() string {
+	return fmt.Sprintf("git command %v output truncated: exceeded the limit of %d bytes", e.args, e.Limit)
+}
+
 type commandFailedError struct {
 	Stderr     []byte
 	ExitStatus int
@@ -235,12 +366,14 @@ func (e *commandFailedError) Error() string {
 
 type cmdReader struct {
 	stdout         io.Reader
+	stdin          *stdinPump
 	ctx            context.Context
 	ctxCancel      context.CancelFunc
 	subCmd         string
 	cmdStart       time.Time
 	cmd            wrexec.Cmder
 	stderr         *bytes.Buffer
+	progress       *progressWriter
 	logger         log.Logger
 	gitDir         common.GitDir
 	repoName       api.RepoName
@@ -249,13 +382,32 @@ type cmdReader struct {
 	err            error
 	waitOnce       sync.Once
 	memoryObserver memcmd.Observer
+	maxOutputBytes int64
+	outputBytes    int64
+	truncated      bool
 }
 
 func (rc *cmdReader) Read(p []byte) (n int, err error) {
 	rc.mu.Lock()
 	defer rc.mu.Unlock()
 
+	if rc.truncated {
+		return 0, rc.waitCmd()
+	}
+
 	n, err = rc.stdout.Read(p)
+	if rc.maxOutputBytes > 0 {
+		if rc.outputBytes+int64(n) > rc.maxOutputBytes {
+			// Only pass on output up to the limit, and stop the process so
+			// that it doesn't keep producing output nobody reads.
+			n = int(rc.maxOutputBytes - rc.outputBytes)
+			rc.outputBytes = rc.maxOutputBytes
+			rc.truncated = true
+			_ = rc.cmd.Unwrap().Cancel()
+			return n, rc.waitCmd()
+		}
+		rc.outputBytes += int64(n)
+	}
 	// If the command has finished, we close the stdout pipe and wait on the command
 	// to free any leftover resources. If it errored, this will return the command
 	// error from Read.
@@ -281,8 +433,19 @@ func (rc *cmdReader) waitCmd() error {
 	rc.waitOnce.Do(func() {
 		rc.err = rc.cmd.Wait()
 		rc.memoryObserver.Stop()
-
-		if rc.err != nil {
+		// All of stderr has been written once Wait returns, so pass on a final
+		// line that wasn't terminated.
+		rc.progress.flush()
+		// The process won't read any more input, so don't let the caller
+		// block on writing it.
+		rc.stdin.stop(errStdinNotConsumed)
+
+		if rc.truncated {
+			rc.err = &OutputLimitExceededError{
+				Limit: rc.maxOutputBytes,
+				args:  rc.cmd.Unwrap().Args,
+			}
+		} else if rc.err != nil {
 			if checkMaybeCorruptRepo(rc.logger, rc.gitDir, rc.repoName, rc.stderr.String()) {
 				rc.err = common.ErrRepoCorrupted{Reason: rc.stderr.String()}
 			} else {
@@ -298,8 +461,6 @@ func (rc *cmdReader) waitCmd() error {
 	return rc.err
 }
 
-const highMemoryUsageThreshold = 500 * bytesize.MiB
-
 func (rc *cmdReader) trace() {
 	duration := time.Since(rc.cmdStart)
 
@@ -323,12 +484,14 @@ func (rc *cmdReader) trace() {
 
 	isSlow := duration > shortGitCommandSlow(rc.cmd.Unwrap().Args)
 
-	isHighMem := memUsage > highMemoryUsageThreshold
-
-	if isHighMem {
-		highMemoryCounter.WithLabelValues(rc.subCmd).Inc()
+	// Without memory observation, fall back to the max RSS the kernel reports
+	// for the finished process.
+	if memUsage == 0 {
+		memUsage = rssToByteSize(runtime.GOOS, sysUsage.Maxrss)
 	}
 
+	isHighMem := observeHighMemoryUsage(rc.subCmd, memUsage, highMemoryUsageThreshold)
+
 	if honey.Enabled() || isSlow || isHighMem {
 		act := actor.FromContext(rc.ctx)
 		ev := honey.NewEvent("gitserver-exec")
@@ -380,6 +543,110 @@ func (rc *cmdReader) trace() {
 	rc.tr.SetAttributes(attribute.Int64("cmd_ru_oublock", sysUsage.Oublock))
 }
 
+// rssToByteSize converts the ru_maxrss value of a syscall.Rusage to bytes.
+// Linux reports it in KiB, while darwin reports it in bytes.
+func rssToByteSize(goos string, maxrss int64) bytesize.Bytes {
+	if goos == "darwin" {
+		return bytesize.Bytes(maxrss)
+	}
+	return bytesize.Bytes(maxrss) * bytesize.KiB
+}
+
+// observeHighMemoryUsage reports whether memUsage exceeds threshold, and if so
+// increments highMemoryCounter for subCmd. A threshold of 0 disables it.
+func observeHighMemoryUsage(subCmd string, memUsage, threshold bytesize.Bytes) bool {
+	if threshold == 0 || memUsage <= threshold {
+		return false
+	}
+	highMemoryCounter.WithLabelValues(subCmd).Inc()
+	return true
+}
+
+// errStdinNotConsumed is returned to writers of a command's stdin when the git
+// process exited before reading all of it.
+var errStdinNotConsumed = errors.New("git process exited before consuming all of stdin")
+
+// stdinPump copies the caller provided stdin to the git process.
+//
+// We don't let exec.Cmd copy stdin for us: if the process exits early or the
+// command's context is canceled, it stops reading from the caller's reader, so
+// a caller writing into e.g. an io.Pipe would block forever. The pump instead
+// closes a reader with a CloseWithError method with an error in that case, so
+// that pending and future writes fail. Other readers are left alone.
+type stdinPump struct {
+	r        io.Reader
+	w        io.WriteCloser
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newStdinPump(r io.Reader, w io.WriteCloser) *stdinPump {
+	return &stdinPump{
+		r:    r,
+		w:    w,
+		done: make(chan struct{}),
+	}
+}
+
+// start starts copying stdin to the process in the background. Copying stops
+// once ctx is done.
+func (p *stdinPump) start(ctx context.Context) {
+	go func() {
+		defer close(p.done)
+
+		if _, err := io.Copy(p.w, &ctxReader{ctx: ctx, r: p.r}); err != nil {
+			if ctx.Err() == nil {
+				err = errors.Wrap(err, "failed to write to git stdin")
+			}
+			p.stop(err)
+			return
+		}
+
+		// All of stdin was copied, signal EOF to the process. The reader
+		// belongs to the caller, so we leave it alone.
+		p.stopOnce.Do(func() {
+			_ = p.w.Close()
+		})
+	}()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			p.stop(ctx.Err())
+		case <-p.done:
+		}
+	}()
+}
+
+// stop stops copying stdin to the process and closes the reader with err, if
+// it supports that. It is a no-op if all of stdin has already been copied.
+func (p *stdinPump) stop(err error) {
+	if p == nil {
+		return
+	}
+
+	p.stopOnce.Do(func() {
+		_ = p.w.Close()
+
+		if r, ok := p.r.(interface{ CloseWithError(error) error }); ok {
+			_ = r.CloseWithError(err)
+		}
+	})
+}
+
+// ctxReader is an io.Reader that fails once ctx is done.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
 const maxStderrCapture = 1024
 
 // stderrBuffer sets up a limited buffer to capture stderr for error reporting.
@@ -389,6 +656,52 @@ func stderrBuffer() (io.Writer, *bytes.Buffer) {
 	return stderr, stderrBuf
 }
 
+// maxProgressLineLength is the maximum length of a line passed to a progress
+// callback. Longer lines are split, so that we don't buffer indefinitely if git
+// never writes a line separator.
+const maxProgressLineLength = 4096
+
+// progressWriter is an io.Writer that splits git's stderr output into lines
+// separated by '\r' or '\n' and passes each non-empty line to callback.
+type progressWriter struct {
+	callback func(line string)
+	buf      []byte
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexAny(w.buf, "\r\n")
+		if i < 0 {
+			if len(w.buf) >= maxProgressLineLength {
+				w.emit(w.buf)
+				w.buf = w.buf[:0]
+			}
+			return len(p), nil
+		}
+		w.emit(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// flush passes any remaining unterminated line to the callback. It is safe to
+// call on a nil progressWriter.
+func (w *progressWriter) flush() {
+	if w == nil {
+		return
+	}
+	w.emit(w.buf)
+	w.buf = nil
+}
+
+func (w *progressWriter) emit(line []byte) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return
+	}
+	w.callback(string(line))
+}
+
 // limitWriter is a io.Writer that writes to an W but discards after N bytes.
 type limitWriter struct {
 	W io.Writer // underling writer
@@ -465,12 +778,20 @@ var (
 	// See https://github.com/sourcegraph/sourcegraph/issues/37872 for more
 	// context.
 	commitGraphCorruptionRegex = lazyregexp.NewPOSIX(`^fatal: commit-graph requires overflow generation data but has none`)
+
+	// missingOrBrokenObjectRegex matches stderr lines from git which indicate
+	// that objects referenced by the repository itself are empty, unreadable or
+	// missing. "bad object" is only matched for HEAD and refs, as git reports
+	// the same for unknown commit SHAs requested by users.
+	missingOrBrokenObjectRegex = lazyregexp.NewPOSIX(`^(error: object file [^ ]+ is empty$|fatal: (loose|packed) object [0-9a-f]{40,64} \(stored in [^)]+\) is corrupt|fatal: bad object (HEAD|refs/[^ ]+)$|fatal: did not receive expected object [0-9a-f]{40,64}$|error: inflate: data stream error)`)
 )
 
 // stdErrIndicatesCorruption returns true if the provided stderr output from a git command indicates
 // that there might be repository corruption.
 func stdErrIndicatesCorruption(stderr string) bool {
-	return objectOrPackFileCorruptionRegex.MatchString(stderr) || commitGraphCorruptionRegex.MatchString(stderr)
+	return objectOrPackFileCorruptionRegex.MatchString(stderr) ||
+		commitGraphCorruptionRegex.MatchString(stderr) ||
+		missingOrBrokenObjectRegex.MatchString(stderr)
 }
 
 // shortGitCommandSlow returns the threshold for regarding an git command as
@@ -2,14 +2,21 @@ package gitcli
 
 import (
 	"context"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sourcegraph/log/logtest"
 	"github.com/stretchr/testify/require"
 
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/common"
+	"github.com/sourcegraph/sourcegraph/internal/bytesize"
+	"github.com/sourcegraph/sourcegraph/internal/wrexec"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
@@ -93,3 +100,261 @@ func TestIsContextErr(t *testing.T) {
 		})
 	}
 }
+
+func TestNewCommand_StdinProcessExited(t *testing.T) {
+	ctx := context.Background()
+
+	backend := BackendWithRepoCommands(t,
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com git commit --allow-empty -m foo --author='a <a@a.com>'",
+	)
+
+	// rev-parse never reads its stdin, so the process exits while we are
+	// still writing.
+	pr, pw := io.Pipe()
+	r, err := backend.(*gitCLIBackend).NewCommand(ctx, WithArguments("rev-parse", "HEAD"), WithStdin(pr))
+	require.NoError(t, err)
+
+	writeErr := make(chan error, 1)
+	go func() {
+		chunk := make([]byte, 64*1024)
+		for {
+			if _, err := pw.Write(chunk); err != nil {
+				writeErr <- err
+				return
+			}
+		}
+	}()
+
+	_, err = io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	select {
+	case err := <-writeErr:
+		require.Error(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("writing to stdin blocked after the git process exited")
+	}
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestNewCommand_StdinNotClosed(t *testing.T) {
+	ctx := context.Background()
+
+	backend := BackendWithRepoCommands(t,
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com git commit --allow-empty -m foo --author='a <a@a.com>'",
+	)
+
+	// The stdin of the caller is never closed, whether the process consumes
+	// all of it or not.
+	for name, args := range map[string][]string{
+		"consumed":     {"cat-file", "--batch"},
+		"not consumed": {"rev-parse", "HEAD"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			stdin := &closeRecorder{Reader: strings.NewReader(strings.Repeat("HEAD\n", 64*1024))}
+			r, err := backend.(*gitCLIBackend).NewCommand(ctx, WithArguments(args...), WithStdin(stdin))
+			require.NoError(t, err)
+
+			_, err = io.ReadAll(r)
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+			require.False(t, stdin.closed)
+		})
+	}
+}
+
+func TestNewCommand_GitBinary(t *testing.T) {
+	ctx := context.Background()
+
+	// A stand-in for git that prints the arguments it was called with.
+	gitBinary := filepath.Join(t.TempDir(), "my-git")
+	require.NoError(t, os.WriteFile(gitBinary, []byte("#!/bin/sh\necho my-git \"$@\"\n"), 0o755))
+
+	backend := NewBackend(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), gitBinary, common.GitDir(t.TempDir()), "repo")
+
+	r, err := backend.(*gitCLIBackend).NewCommand(ctx, WithArguments("rev-parse", "HEAD"))
+	require.NoError(t, err)
+
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, "my-git rev-parse HEAD\n", string(out))
+}
+
+func TestProgressWriter(t *testing.T) {
+	var lines []string
+	w := &progressWriter{callback: func(line string) {
+		lines = append(lines, line)
+	}}
+
+	// Synthetic output of git fetch --progress, written in arbitrary chunks.
+	for _, chunk := range []string{
+		"remote: Enumerating objects: 5, done.\n",
+		"Receiving objects:  20% (1/5)\rReceiving obj",
+		"ects:  60% (3/5)\r",
+		"Receiving objects: 100% (5/5), done.\n\n",
+		"Resolving deltas: 100% (2/2)",
+	} {
+		n, err := w.Write([]byte(chunk))
+		require.NoError(t, err)
+		require.Equal(t, len(chunk), n)
+	}
+	require.Equal(t, []string{
+		"remote: Enumerating objects: 5, done.",
+		"Receiving objects:  20% (1/5)",
+		"Receiving objects:  60% (3/5)",
+		"Receiving objects: 100% (5/5), done.",
+	}, lines)
+
+	// The last line isn't terminated, so it's only passed on when flushing.
+	w.flush()
+	require.Equal(t, "Resolving deltas: 100% (2/2)", lines[len(lines)-1])
+	require.Len(t, lines, 5)
+}
+
+func TestNewCommand_ProgressCallback(t *testing.T) {
+	ctx := context.Background()
+
+	// A stand-in for git that writes progress output to stderr.
+	gitBinary := filepath.Join(t.TempDir(), "my-git")
+	require.NoError(t, os.WriteFile(gitBinary, []byte("#!/bin/sh\nprintf 'Counting objects:  50%% (1/2)\\rCounting objects: 100%% (2/2), done.\\n' >&2\necho out\n"), 0o755))
+
+	backend := NewBackend(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), gitBinary, common.GitDir(t.TempDir()), "repo")
+
+	var lines []string
+	r, err := backend.(*gitCLIBackend).NewCommand(ctx, WithArguments("rev-parse", "HEAD"), WithProgressCallback(func(line string) {
+		lines = append(lines, line)
+	}))
+	require.NoError(t, err)
+
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, "out\n", string(out))
+	require.Equal(t, []string{
+		"Counting objects:  50% (1/2)",
+		"Counting objects: 100% (2/2), done.",
+	}, lines)
+}
+
+func TestNewCommand_MaxOutputBytes(t *testing.T) {
+	ctx := context.Background()
+
+	// A stand-in for git that writes output until it is killed.
+	gitBinary := filepath.Join(t.TempDir(), "my-git")
+	require.NoError(t, os.WriteFile(gitBinary, []byte("#!/bin/sh\nwhile true; do echo 0123456789; done\n"), 0o755))
+
+	backend := NewBackend(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), gitBinary, common.GitDir(t.TempDir()), "repo")
+
+	t.Run("exceeded", func(t *testing.T) {
+		r, err := backend.(*gitCLIBackend).NewCommand(ctx, WithArguments("log"), WithMaxOutputBytes(100))
+		require.NoError(t, err)
+
+		out, err := io.ReadAll(r)
+		var limitErr *OutputLimitExceededError
+		require.True(t, errors.As(err, &limitErr), "unexpected error: %v", err)
+		require.Equal(t, int64(100), limitErr.Limit)
+		require.False(t, errors.HasType[*commandFailedError](err))
+		require.Len(t, out, 100)
+
+		// Subsequent reads and closing return the same error.
+		_, err = r.Read(make([]byte, 1))
+		require.True(t, errors.As(err, &limitErr))
+		require.True(t, errors.As(r.Close(), &limitErr))
+	})
+
+	t.Run("within limit", func(t *testing.T) {
+		echoBackend := NewBackend(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), "echo", common.GitDir(t.TempDir()), "repo")
+
+		r, err := echoBackend.(*gitCLIBackend).NewCommand(ctx, WithArguments("log"), WithMaxOutputBytes(4))
+		require.NoError(t, err)
+
+		out, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		require.Equal(t, "log\n", string(out))
+	})
+}
+
+func TestGitCLIBackend_CommandTimeout(t *testing.T) {
+	backend := NewBackend(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), "", common.GitDir(t.TempDir()), "repo").(*gitCLIBackend)
+
+	for _, subCmd := range []string{"archive", "fetch", "log", "rev-parse"} {
+		require.Equal(t, time.Minute, backend.commandTimeout(subCmd), subCmd)
+	}
+
+	// Overrides are copied per backend.
+	backend.timeouts["rev-parse"] = 10 * time.Second
+	require.Equal(t, 10*time.Second, backend.commandTimeout("rev-parse"))
+	other := NewBackend(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), "", common.GitDir(t.TempDir()), "repo").(*gitCLIBackend)
+	require.Equal(t, time.Minute, other.commandTimeout("rev-parse"))
+}
+
+func TestMustParseGitCommandTimeouts(t *testing.T) {
+	require.Empty(t, mustParseGitCommandTimeouts(""))
+	require.Equal(t, map[string]time.Duration{
+		"archive":   30 * time.Minute,
+		"rev-parse": 10 * time.Second,
+	}, mustParseGitCommandTimeouts("archive=30m, rev-parse=10s"))
+
+	for _, invalid := range []string{"archive", "archive=soon", "archive=-1m"} {
+		require.Panics(t, func() { mustParseGitCommandTimeouts(invalid) }, invalid)
+	}
+}
+
+func TestNewCommand_SubcommandTimeout(t *testing.T) {
+	// A stand-in for git that never finishes on its own.
+	gitBinary := filepath.Join(t.TempDir(), "my-git")
+	require.NoError(t, os.WriteFile(gitBinary, []byte("#!/bin/sh\nsleep 60\n"), 0o755))
+
+	backend := NewBackend(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), gitBinary, common.GitDir(t.TempDir()), "repo").(*gitCLIBackend)
+	backend.timeouts = map[string]time.Duration{"log": 100 * time.Millisecond}
+
+	start := time.Now()
+	r, err := backend.NewCommand(context.Background(), WithArguments("log"))
+	require.NoError(t, err)
+	_, err = io.ReadAll(r)
+	require.Error(t, err)
+	require.Less(t, time.Since(start), 30*time.Second)
+}
+
+func TestObserveHighMemoryUsage(t *testing.T) {
+	threshold := 500 * bytesize.MiB
+
+	for _, tc := range []struct {
+		goos   string
+		maxrss int64
+	}{
+		// Linux reports ru_maxrss in KiB.
+		{goos: "linux", maxrss: 600 * 1024},
+		// darwin reports ru_maxrss in bytes.
+		{goos: "darwin", maxrss: 600 * 1024 * 1024},
+	} {
+		t.Run(tc.goos, func(t *testing.T) {
+			subCmd := "test-" + tc.goos
+			usage := syscall.Rusage{Maxrss: tc.maxrss}
+
+			memUsage := rssToByteSize(tc.goos, usage.Maxrss)
+			require.Equal(t, 600*bytesize.MiB, memUsage)
+
+			before := testutil.ToFloat64(highMemoryCounter.WithLabelValues(subCmd))
+			require.True(t, observeHighMemoryUsage(subCmd, memUsage, threshold))
+			require.Equal(t, before+1, testutil.ToFloat64(highMemoryCounter.WithLabelValues(subCmd)))
+
+			// Below the threshold or with reporting disabled, nothing is counted.
+			require.False(t, observeHighMemoryUsage(subCmd, memUsage/2, threshold))
+			require.False(t, observeHighMemoryUsage(subCmd, memUsage, 0))
+			require.Equal(t, before+1, testutil.ToFloat64(highMemoryCounter.WithLabelValues(subCmd)))
+		})
+	}
+}
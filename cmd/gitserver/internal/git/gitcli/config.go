@@ -14,7 +14,12 @@ func (g *gitCLIBackend) Config() git.GitConfigBackend {
 }
 
 func (g *gitCLIBackend) Get(ctx context.Context, key string) (string, error) {
-	r, err := g.NewCommand(ctx, WithArguments("config", "--get", key))
+	getArgs, err := separatedValueFlag("--get", key)
+	if err != nil {
+		return "", err
+	}
+
+	r, err := g.NewCommand(ctx, WithArguments(append([]string{"config"}, getArgs...)...))
 	if err != nil {
 		return "", err
 	}
@@ -52,7 +57,12 @@ func (g *gitCLIBackend) Set(ctx context.Context, key, value string) error {
 }
 
 func (g *gitCLIBackend) Unset(ctx context.Context, key string) error {
-	r, err := g.NewCommand(ctx, WithArguments("config", "--unset-all", key))
+	unsetArgs, err := separatedValueFlag("--unset-all", key)
+	if err != nil {
+		return err
+	}
+
+	r, err := g.NewCommand(ctx, WithArguments(append([]string{"config"}, unsetArgs...)...))
 	if err != nil {
 		return err
 	}
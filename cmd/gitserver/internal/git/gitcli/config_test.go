@@ -31,7 +31,7 @@ func TestConfigGetSetUnset(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	git := NewBackend(logtest.Scoped(t), rcf, dir, "repo")
+	git := NewBackend(logtest.Scoped(t), rcf, DefaultGitBinary, dir, "repo")
 
 	ctx := context.Background()
 
@@ -85,4 +85,12 @@ func TestConfigGetSetUnset(t *testing.T) {
 	t.Run("with subsection", func(t *testing.T) {
 		testGetSetUnset("sourcegraph.test.section")
 	})
+
+	t.Run("flag injection", func(t *testing.T) {
+		for _, key := range []string{"--list", "-l", "--file=/etc/passwd"} {
+			_, err := git.Config().Get(ctx, key)
+			require.ErrorContains(t, err, "begins with '-'")
+			require.ErrorContains(t, git.Config().Unset(ctx, key), "begins with '-'")
+		}
+	})
 }
@@ -30,12 +30,15 @@ var (
 		"merge-base":   {"--"},
 		"show-ref":     {"--heads"},
 		"shortlog":     {"--summary", "--numbered", "--email", "--no-merges", "--after", "--before"},
-		"cat-file":     {"-p", "-t"},
+		"cat-file":     {"-p", "-t", "--batch"},
 		"lfs":          {},
 
 		// Commands used by GitConfigStore:
 		"config": {"--get", "--unset-all"},
 
+		// Used by GarbageCollect.
+		"gc": {"--quiet", "--force"},
+
 		// Commands used by Batch Changes when publishing changesets.
 		"init":       {},
 		"reset":      {"-q"},
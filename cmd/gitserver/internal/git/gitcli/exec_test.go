@@ -87,12 +87,25 @@ error: Could not read d24d09b8bc5d1ea2c3aa24455f4578db6aa3afda`,
 		"\n\nerror: Could not read d24d09b8bc5d1ea2c3aa24455f4578db6aa3afda",
 		"fatal: commit-graph requires overflow generation data but has none\n",
 		"\rResolving deltas: 100% (21750/21750), completed with 565 local objects.\nfatal: commit-graph requires overflow generation data but has none\nerror: https://github.com/sgtest/megarepo did not send all necessary objects\n\n\": exit status 1",
+		"error: object file objects/d2/4d09b8bc5d1ea2c3aa24455f4578db6aa3afda is empty\nerror: object file objects/d2/4d09b8bc5d1ea2c3aa24455f4578db6aa3afda is empty\nfatal: loose object d24d09b8bc5d1ea2c3aa24455f4578db6aa3afda (stored in objects/d2/4d09b8bc5d1ea2c3aa24455f4578db6aa3afda) is corrupt\n",
+		"fatal: packed object 45043b3ff0440f4d7937f8c68f8fb2881759edef (stored in objects/pack/pack-a.pack) is corrupt\n",
+		"fatal: bad object HEAD\n",
+		"error: refs/heads/main does not point to a valid object!\nfatal: bad object refs/heads/main\n",
+		"remote: Enumerating objects: 5, done.\nfatal: did not receive expected object 156639577dd2ea91cdd53b25352648387d985743\nfatal: index-pack failed\n",
+		"error: inflate: data stream error (incorrect header check)\nerror: unable to unpack d24d09b8bc5d1ea2c3aa24455f4578db6aa3afda header\n",
 	}
 	good := []string{
 		"",
 		"error: short SHA1 1325 is ambiguous",
 		"error: object 156639577dd2ea91cdd53b25352648387d985743 is a blob, not a commit",
 		"error: object 45043b3ff0440f4d7937f8c68f8fb2881759edef is a tree, not a commit",
+		// Users asking for commits that don't exist in the repo.
+		"fatal: bad object 156639577dd2ea91cdd53b25352648387d985743\n",
+		"fatal: bad object HEAD~1000",
+		// Transient network errors.
+		"error: RPC failed; curl 18 transfer closed with outstanding read data remaining\nfatal: early EOF\nfatal: fetch-pack: invalid index-pack output\n",
+		"fatal: the remote end hung up unexpectedly\n",
+		"warning: object file objects/d2/4d09b8bc5d1ea2c3aa24455f4578db6aa3afda is empty",
 	}
 	for _, stderr := range bad {
 		if !stdErrIndicatesCorruption(stderr) {
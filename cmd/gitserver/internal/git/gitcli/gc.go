@@ -0,0 +1,44 @@
+package gitcli
+
+import (
+	"context"
+	"io"
+	"strconv"
+
+	"github.com/sourcegraph/log"
+
+	"github.com/sourcegraph/sourcegraph/internal/env"
+)
+
+var (
+	gcMaxThreads   = env.MustGetInt("SRC_GITSERVER_GC_MAX_THREADS", 1, "The maximum number of threads git gc uses for delta compression when triggered on demand.")
+	gcWindowMemory = env.Get("SRC_GITSERVER_GC_WINDOW_MEMORY", "100m", "The maximum memory per thread git gc uses for delta compression when triggered on demand.")
+)
+
+func (g *gitCLIBackend) GarbageCollect(ctx context.Context) error {
+	r, err := g.NewCommand(ctx,
+		// The caller holds the gc lock, which would make git gc refuse to run
+		// otherwise.
+		WithArguments("gc", "--quiet", "--force"),
+		// Limit the resources used for repacking, which is by far the most
+		// expensive part of gc. This mirrors what sg maintenance does.
+		withConfigOverride("pack.threads", strconv.Itoa(gcMaxThreads)),
+		withConfigOverride("pack.windowMemory", gcWindowMemory),
+		// Make sure git doesn't detach, so we return once gc completed.
+		withConfigOverride("gc.autoDetach", "false"),
+		// With --quiet, git gc only writes warnings to stderr, e.g. about too
+		// many unreachable loose objects. Stderr is only reported if gc fails,
+		// so log them as they arrive.
+		WithProgressCallback(func(line string) {
+			g.logger.Warn("git gc reported a warning", log.String("repo", string(g.repoName)), log.String("line", line))
+		}),
+	)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	// Drain reader so process can exit.
+	_, err = io.Copy(io.Discard, r)
+	return err
+}
@@ -0,0 +1,63 @@
+package gitcli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sourcegraph/log/logtest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/common"
+	"github.com/sourcegraph/sourcegraph/internal/wrexec"
+)
+
+func TestGitCLIBackend_GarbageCollect(t *testing.T) {
+	ctx := context.Background()
+
+	dir := RepoWithCommands(t,
+		"echo line1 > f",
+		"git add f",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com git commit -m foo --author='a <a@a.com>'",
+		"echo line2 >> f",
+		"git add f",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com git commit -m bar --author='a <a@a.com>'",
+	)
+	backend := NewBackend(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), DefaultGitBinary, dir, "repo")
+
+	packs, err := filepath.Glob(dir.Path("objects", "pack", "*.pack"))
+	require.NoError(t, err)
+	require.Empty(t, packs)
+
+	require.NoError(t, backend.GarbageCollect(ctx))
+
+	// All loose objects are packed.
+	packs, err = filepath.Glob(dir.Path("objects", "pack", "*.pack"))
+	require.NoError(t, err)
+	require.Len(t, packs, 1)
+	entries, err := os.ReadDir(dir.Path("objects"))
+	require.NoError(t, err)
+	for _, e := range entries {
+		require.Contains(t, []string{"info", "pack"}, e.Name())
+	}
+}
+
+func TestGitCLIBackend_GarbageCollect_LogsWarnings(t *testing.T) {
+	ctx := context.Background()
+
+	// A stand-in for git that writes a warning to stderr.
+	gitBinary := filepath.Join(t.TempDir(), "my-git")
+	require.NoError(t, os.WriteFile(gitBinary, []byte("#!/bin/sh\necho 'warning: There are too many unreachable loose objects.' >&2\n"), 0o755))
+
+	logger, exportLogs := logtest.Captured(t)
+	backend := NewBackend(logger, wrexec.NewNoOpRecordingCommandFactory(), gitBinary, common.GitDir(t.TempDir()), "repo")
+
+	require.NoError(t, backend.GarbageCollect(ctx))
+
+	logs := exportLogs().Filter(func(l logtest.CapturedLog) bool {
+		return l.Message == "git gc reported a warning"
+	})
+	require.Len(t, logs, 1)
+	require.Equal(t, "warning: There are too many unreachable loose objects.", logs[0].Fields["line"])
+}
@@ -549,7 +549,7 @@ func TestGitCLIBackend_RefHash(t *testing.T) {
 		"git add f2",
 		`GIT_COMMITTER_DATE="2015-01-01 00:00 Z" git commit -m foo --author='Foo Author <foo@sourcegraph.com>'`,
 	)
-	backend := NewBackend(logtest.Scoped(t), rcf, dir, api.RepoName(t.Name()))
+	backend := NewBackend(logtest.Scoped(t), rcf, DefaultGitBinary, dir, api.RepoName(t.Name()))
 
 	first, err := backend.RefHash(ctx)
 	require.NoError(t, err)
@@ -570,7 +570,7 @@ func TestGitCLIBackend_RefHash(t *testing.T) {
 		"git add f2",
 		`GIT_COMMITTER_DATE="2015-01-01 00:00 Z" git commit -m foo --author='Foo Author <foo@sourcegraph.com>'`,
 	)
-	backend = NewBackend(logtest.Scoped(t), rcf, dir, api.RepoName(t.Name()))
+	backend = NewBackend(logtest.Scoped(t), rcf, DefaultGitBinary, dir, api.RepoName(t.Name()))
 
 	third, err := backend.RefHash(ctx)
 	require.NoError(t, err)
@@ -14,3 +14,14 @@ func checkSpecArgSafety(spec string) error {
 	}
 	return nil
 }
+
+// separatedValueFlag returns flag and value as two separate arguments, for
+// flags that don't accept the "--flag=value" form. Like with revision specs,
+// a value beginning with a "-" is rejected, as git would interpret it as
+// another flag.
+func separatedValueFlag(flag, value string) ([]string, error) {
+	if strings.HasPrefix(value, "-") {
+		return nil, errors.Errorf("invalid value %q for git flag %s (begins with '-')", value, flag)
+	}
+	return []string{flag, value}, nil
+}
@@ -20,7 +20,7 @@ func BackendWithRepoCommands(t *testing.T, cmds ...string) git.GitBackend {
 
 	dir := RepoWithCommands(t, cmds...)
 
-	return NewBackend(logtest.Scoped(t), rcf, dir, api.RepoName(t.Name()))
+	return NewBackend(logtest.Scoped(t), rcf, DefaultGitBinary, dir, api.RepoName(t.Name()))
 }
 
 func RepoWithCommands(t *testing.T, cmds ...string) common.GitDir {
@@ -45,3 +45,19 @@ func RepoWithCommands(t *testing.T, cmds ...string) common.GitDir {
 
 	return dir
 }
+
+func TestSeparatedValueFlag(t *testing.T) {
+	args, err := separatedValueFlag("--points-at", "HEAD")
+	require.NoError(t, err)
+	require.Equal(t, []string{"--points-at", "HEAD"}, args)
+
+	// The value is passed verbatim, even if it contains a "=" or whitespace.
+	args, err = separatedValueFlag("--get", "a=b c")
+	require.NoError(t, err)
+	require.Equal(t, []string{"--get", "a=b c"}, args)
+
+	for _, value := range []string{"-", "--output=/tmp/pwned", "-c core.pager=sh", "--upload-pack=touch /tmp/pwned"} {
+		_, err := separatedValueFlag("--points-at", value)
+		require.Error(t, err, value)
+	}
+}
@@ -2,6 +2,7 @@ package git
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"io/fs"
 	"time"
@@ -58,8 +59,13 @@ type GitBackend interface {
 	// ArchiveReader returns a reader for an archive in the given format.
 	// Treeish is the tree or commit to archive, and paths is the list of
 	// paths to include in the archive. If empty, all paths are included.
+	// The archive is streamed as it is produced, it is never held in memory
+	// as a whole.
 	//
 	// If the commit does not exist, a RevisionNotFoundError is returned.
+	// Depending on the configured symlink policy, symlinks pointing outside of
+	// the repository are left out of the archive, or an OutOfTreeSymlinkError
+	// is returned.
 	ArchiveReader(ctx context.Context, format ArchiveFormat, treeish string, paths []string) (io.ReadCloser, error)
 	// ResolveRevision resolves the given revspec to a commit ID.
 	// I.e., HEAD, deadbeefdeadbeefdeadbeefdeadbeef, or refs/heads/main.
@@ -162,6 +168,14 @@ type GitBackend interface {
 	// This value can be used to determine if a repository changed since the last
 	// time the hash has been computed.
 	RefHash(ctx context.Context) ([]byte, error)
+
+	// GarbageCollect runs git gc on the repository to clean up unnecessary
+	// files and optimize the repository. The threads and memory used for delta
+	// compression are limited, so that gc doesn't starve other commands.
+	//
+	// git gc is forced to run even if the gc lock of the repository (gc.pid)
+	// is held, so the caller must hold it.
+	GarbageCollect(ctx context.Context) error
 }
 
 // CommitLogOrder is the order of the commits returned by CommitLog.
@@ -296,6 +310,18 @@ const (
 	ArchiveFormatTar ArchiveFormat = "tar"
 )
 
+// OutOfTreeSymlinkError is returned from ArchiveReader if the archive would
+// contain a symlink that points outside of the repository, and such archives
+// are rejected.
+type OutOfTreeSymlinkError struct {
+	Path   string
+	Target string
+}
+
+func (e *OutOfTreeSymlinkError) Error() string {
+	return fmt.Sprintf("symlink %q points outside of the repository to %q", e.Path, e.Target)
+}
+
 // ListRefsOpts are additional options passed to ListRefs.
 type ListRefsOpts struct {
 	// If true, only heads are returned. Can be combined with HeadsOnly.
@@ -573,6 +573,9 @@ type MockGitBackend struct {
 	// FirstEverCommitFunc is an instance of a mock function object
 	// controlling the behavior of the method FirstEverCommit.
 	FirstEverCommitFunc *GitBackendFirstEverCommitFunc
+	// GarbageCollectFunc is an instance of a mock function object
+	// controlling the behavior of the method GarbageCollect.
+	GarbageCollectFunc *GitBackendGarbageCollectFunc
 	// GetCommitFunc is an instance of a mock function object controlling
 	// the behavior of the method GetCommit.
 	GetCommitFunc *GitBackendGetCommitFunc
@@ -661,6 +664,11 @@ func NewMockGitBackend() *MockGitBackend {
 				return
 			},
 		},
+		GarbageCollectFunc: &GitBackendGarbageCollectFunc{
+			defaultHook: func(context.Context) (r0 error) {
+				return
+			},
+		},
 		GetCommitFunc: &GitBackendGetCommitFunc{
 			defaultHook: func(context.Context, api.CommitID, bool) (r0 *GitCommitWithFiles, r1 error) {
 				return
@@ -778,6 +786,11 @@ func NewStrictMockGitBackend() *MockGitBackend {
 				panic("unexpected invocation of MockGitBackend.FirstEverCommit")
 			},
 		},
+		GarbageCollectFunc: &GitBackendGarbageCollectFunc{
+			defaultHook: func(context.Context) error {
+				panic("unexpected invocation of MockGitBackend.GarbageCollect")
+			},
+		},
 		GetCommitFunc: &GitBackendGetCommitFunc{
 			defaultHook: func(context.Context, api.CommitID, bool) (*GitCommitWithFiles, error) {
 				panic("unexpected invocation of MockGitBackend.GetCommit")
@@ -879,6 +892,9 @@ func NewMockGitBackendFrom(i GitBackend) *MockGitBackend {
 		FirstEverCommitFunc: &GitBackendFirstEverCommitFunc{
 			defaultHook: i.FirstEverCommit,
 		},
+		GarbageCollectFunc: &GitBackendGarbageCollectFunc{
+			defaultHook: i.GarbageCollect,
+		},
 		GetCommitFunc: &GitBackendGetCommitFunc{
 			defaultHook: i.GetCommit,
 		},
@@ -1793,6 +1809,108 @@ func (c GitBackendFirstEverCommitFuncCall) Results() []interface{} {
 	return []interface{}{c.Result0, c.Result1}
 }
 
+// GitBackendGarbageCollectFunc describes the behavior when the
+// GarbageCollect method of the parent MockGitBackend instance is invoked.
+type GitBackendGarbageCollectFunc struct {
+	defaultHook func(context.Context) error
+	hooks       []func(context.Context) error
+	history     []GitBackendGarbageCollectFuncCall
+	mutex       sync.Mutex
+}
+
+// GarbageCollect delegates to the next hook function in the queue and
+// stores the parameter and result values of this invocation.
+func (m *MockGitBackend) GarbageCollect(v0 context.Context) error {
+	r0 := m.GarbageCollectFunc.nextHook()(v0)
+	m.GarbageCollectFunc.appendCall(GitBackendGarbageCollectFuncCall{v0, r0})
+	return r0
+}
+
+// SetDefaultHook sets function that is called when the GarbageCollect
+// method of the parent MockGitBackend instance is invoked and the hook
+// queue is empty.
+func (f *GitBackendGarbageCollectFunc) SetDefaultHook(hook func(context.Context) error) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// GarbageCollect method of the parent MockGitBackend instance invokes the
+// hook at the front of the queue and discards it. After the queue is empty,
+// the default hook function is invoked for any future action.
+func (f *GitBackendGarbageCollectFunc) PushHook(hook func(context.Context) error) {
+	f.mutex.Lock()
+	f.hooks = append(f.hooks, hook)
+	f.mutex.Unlock()
+}
+
+// SetDefaultReturn calls SetDefaultHook with a function that returns the
+// given values.
+func (f *GitBackendGarbageCollectFunc) SetDefaultReturn(r0 error) {
+	f.SetDefaultHook(func(context.Context) error {
+		return r0
+	})
+}
+
+// PushReturn calls PushHook with a function that returns the given values.
+func (f *GitBackendGarbageCollectFunc) PushReturn(r0 error) {
+	f.PushHook(func(context.Context) error {
+		return r0
+	})
+}
+
+func (f *GitBackendGarbageCollectFunc) nextHook() func(context.Context) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+func (f *GitBackendGarbageCollectFunc) appendCall(r0 GitBackendGarbageCollectFuncCall) {
+	f.mutex.Lock()
+	f.history = append(f.history, r0)
+	f.mutex.Unlock()
+}
+
+// History returns a sequence of GitBackendGarbageCollectFuncCall objects
+// describing the invocations of this function.
+func (f *GitBackendGarbageCollectFunc) History() []GitBackendGarbageCollectFuncCall {
+	f.mutex.Lock()
+	history := make([]GitBackendGarbageCollectFuncCall, len(f.history))
+	copy(history, f.history)
+	f.mutex.Unlock()
+
+	return history
+}
+
+// GitBackendGarbageCollectFuncCall is an object that describes an
+// invocation of method GarbageCollect on an instance of MockGitBackend.
+type GitBackendGarbageCollectFuncCall struct {
+	// Arg0 is the value of the 1st argument passed to this method
+	// invocation.
+	Arg0 context.Context
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 error
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c GitBackendGarbageCollectFuncCall) Args() []interface{} {
+	return []interface{}{c.Arg0}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c GitBackendGarbageCollectFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0}
+}
+
 // GitBackendGetCommitFunc describes the behavior when the GetCommit method
 // of the parent MockGitBackend instance is invoked.
 type GitBackendGetCommitFunc struct {
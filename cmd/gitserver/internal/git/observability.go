@@ -456,6 +456,16 @@ func (b *observableBackend) RefHash(ctx context.Context) (_ []byte, err error) {
 	return b.backend.RefHash(ctx)
 }
 
+func (b *observableBackend) GarbageCollect(ctx context.Context) (err error) {
+	ctx, _, endObservation := b.operations.garbageCollect.With(ctx, &err, observation.Args{})
+	defer endObservation(1, observation.Args{})
+
+	concurrentOps.WithLabelValues("GarbageCollect").Inc()
+	defer concurrentOps.WithLabelValues("GarbageCollect").Dec()
+
+	return b.backend.GarbageCollect(ctx)
+}
+
 func (b *observableBackend) CommitLog(ctx context.Context, opt CommitLogOpts) (_ CommitLogIterator, err error) {
 	ctx, errCollector, endObservation := b.operations.commitLog.WithErrors(ctx, &err, observation.Args{
 		Attrs: []attribute.KeyValue{
@@ -536,6 +546,7 @@ type operations struct {
 	latestCommitTimestamp *observation.Operation
 	refHash               *observation.Operation
 	commitLog             *observation.Operation
+	garbageCollect        *observation.Operation
 }
 
 func newOperations(observationCtx *observation.Context) *operations {
@@ -588,6 +599,7 @@ func newOperations(observationCtx *observation.Context) *operations {
 		latestCommitTimestamp: op("latest-commit-timestamp"),
 		refHash:               op("ref-hash"),
 		commitLog:             op("commit-log"),
+		garbageCollect:        op("garbage-collect"),
 	}
 }
 
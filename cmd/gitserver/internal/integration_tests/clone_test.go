@@ -54,10 +54,11 @@ func TestClone(t *testing.T) {
 	}
 
 	s := server.NewServer(&server.ServerOpts{
-		Logger: logger,
-		FS:     fs,
+		Logger:         logger,
+		ObservationCtx: observation.TestContextTB(t),
+		FS:             fs,
 		GitBackendSource: func(dir common.GitDir, repoName api.RepoName) git.GitBackend {
-			return gitcli.NewBackend(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), dir, repoName)
+			return gitcli.NewBackend(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), gitcli.DefaultGitBinary, dir, repoName)
 		},
 		GetRemoteURLFunc: getRemoteURLFunc,
 		GetVCSSyncer: func(ctx context.Context, name api.RepoName) (vcssyncer.VCSSyncer, error) {
@@ -78,7 +79,7 @@ func TestClone(t *testing.T) {
 			}
 
 			require.Equal(t, repo, name)
-			return vcssyncer.NewGitRepoSyncer(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), getRemoteURLSource), nil
+			return vcssyncer.NewGitRepoSyncer(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), "", getRemoteURLSource), nil
 		},
 		DB:                      db,
 		RecordingCommandFactory: wrexec.NewNoOpRecordingCommandFactory(),
@@ -156,10 +157,11 @@ func TestClone_Fail(t *testing.T) {
 	}
 
 	s := server.NewServer(&server.ServerOpts{
-		Logger: logger,
-		FS:     fs,
+		Logger:         logger,
+		ObservationCtx: observation.TestContextTB(t),
+		FS:             fs,
 		GitBackendSource: func(dir common.GitDir, repoName api.RepoName) git.GitBackend {
-			return gitcli.NewBackend(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), dir, repoName)
+			return gitcli.NewBackend(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), gitcli.DefaultGitBinary, dir, repoName)
 		},
 		GetRemoteURLFunc: getRemoteURLFunc,
 		GetVCSSyncer: func(ctx context.Context, name api.RepoName) (vcssyncer.VCSSyncer, error) {
@@ -179,7 +181,7 @@ func TestClone_Fail(t *testing.T) {
 					return u, nil
 				}), nil
 			}
-			return vcssyncer.NewGitRepoSyncer(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), getRemoteURLSource), nil
+			return vcssyncer.NewGitRepoSyncer(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), "", getRemoteURLSource), nil
 		},
 		DB:                      db,
 		RecordingCommandFactory: wrexec.NewNoOpRecordingCommandFactory(),
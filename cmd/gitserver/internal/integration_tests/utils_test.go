@@ -88,10 +88,11 @@ func InitGitserver() {
 	}
 
 	s := server.NewServer(&server.ServerOpts{
-		Logger: sglog.Scoped("server"),
-		FS:     fs,
+		Logger:         sglog.Scoped("server"),
+		ObservationCtx: observation.TestContextTB(&t),
+		FS:             fs,
 		GitBackendSource: func(dir common.GitDir, repoName api.RepoName) git.GitBackend {
-			return gitcli.NewBackend(logtest.Scoped(&t), wrexec.NewNoOpRecordingCommandFactory(), dir, repoName)
+			return gitcli.NewBackend(logtest.Scoped(&t), wrexec.NewNoOpRecordingCommandFactory(), gitcli.DefaultGitBinary, dir, repoName)
 		},
 		GetRemoteURLFunc: getRemoteURLFunc,
 		GetVCSSyncer: func(ctx context.Context, name api.RepoName) (vcssyncer.VCSSyncer, error) {
@@ -111,7 +112,7 @@ func InitGitserver() {
 				}), nil
 			}
 
-			return vcssyncer.NewGitRepoSyncer(logger, wrexec.NewNoOpRecordingCommandFactory(), getRemoteURLSource), nil
+			return vcssyncer.NewGitRepoSyncer(logger, wrexec.NewNoOpRecordingCommandFactory(), "", getRemoteURLSource), nil
 		},
 		DB:                      db,
 		RecordingCommandFactory: wrexec.NewNoOpRecordingCommandFactory(),
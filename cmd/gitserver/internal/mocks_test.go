@@ -666,12 +666,18 @@ func (c RepositoryLockerTryAcquireFuncCall) Results() []interface{} {
 // package github.com/sourcegraph/sourcegraph/cmd/gitserver/internal) used
 // for unit testing.
 type MockService struct {
+	// CloneQueuedFunc is an instance of a mock function object controlling the
+	// behavior of the method CloneQueued.
+	CloneQueuedFunc *ServiceCloneQueuedFunc
 	// CreateCommitFromPatchFunc is an instance of a mock function object
 	// controlling the behavior of the method CreateCommitFromPatch.
 	CreateCommitFromPatchFunc *ServiceCreateCommitFromPatchFunc
 	// EnsureRevisionFunc is an instance of a mock function object
 	// controlling the behavior of the method EnsureRevision.
 	EnsureRevisionFunc *ServiceEnsureRevisionFunc
+	// FetchMissingBlobsFunc is an instance of a mock function object
+	// controlling the behavior of the method FetchMissingBlobs.
+	FetchMissingBlobsFunc *ServiceFetchMissingBlobsFunc
 	// FetchRepositoryFunc is an instance of a mock function object
 	// controlling the behavior of the method FetchRepository.
 	FetchRepositoryFunc *ServiceFetchRepositoryFunc
@@ -681,12 +687,20 @@ type MockService struct {
 	// LogIfCorruptFunc is an instance of a mock function object controlling
 	// the behavior of the method LogIfCorrupt.
 	LogIfCorruptFunc *ServiceLogIfCorruptFunc
+	// IsRepoCloneableWithExternalServiceConfigFunc is an instance of a mock function object controlling the
+	// behavior of the method IsRepoCloneableWithExternalServiceConfig.
+	IsRepoCloneableWithExternalServiceConfigFunc *ServiceIsRepoCloneableWithExternalServiceConfigFunc
 }
 
 // NewMockService creates a new mock of the service interface. All methods
 // return zero values for all results, unless overwritten.
 func NewMockService() *MockService {
 	return &MockService{
+		CloneQueuedFunc: &ServiceCloneQueuedFunc{
+			defaultHook: func(api.RepoName) (r0 bool) {
+				return
+			},
+		},
 		CreateCommitFromPatchFunc: &ServiceCreateCommitFromPatchFunc{
 			defaultHook: func(context.Context, protocol.CreateCommitFromPatchRequest, io.Reader) (r0 protocol.CreateCommitFromPatchResponse) {
 				return
@@ -697,6 +711,11 @@ func NewMockService() *MockService {
 				return
 			},
 		},
+		FetchMissingBlobsFunc: &ServiceFetchMissingBlobsFunc{
+			defaultHook: func(context.Context, api.RepoName, string, []string) (r0 error) {
+				return
+			},
+		},
 		FetchRepositoryFunc: &ServiceFetchRepositoryFunc{
 			defaultHook: func(context.Context, api.RepoName) (r0 time.Time, r1 time.Time, r2 error) {
 				return
@@ -712,6 +731,11 @@ func NewMockService() *MockService {
 				return
 			},
 		},
+		IsRepoCloneableWithExternalServiceConfigFunc: &ServiceIsRepoCloneableWithExternalServiceConfigFunc{
+			defaultHook: func(context.Context, api.RepoName, string, string) (r0 protocol.IsRepoCloneableResponse, r1 error) {
+				return
+			},
+		},
 	}
 }
 
@@ -719,6 +743,11 @@ func NewMockService() *MockService {
 // methods panic on invocation, unless overwritten.
 func NewStrictMockService() *MockService {
 	return &MockService{
+		CloneQueuedFunc: &ServiceCloneQueuedFunc{
+			defaultHook: func(api.RepoName) bool {
+				panic("unexpected invocation of MockService.CloneQueued")
+			},
+		},
 		CreateCommitFromPatchFunc: &ServiceCreateCommitFromPatchFunc{
 			defaultHook: func(context.Context, protocol.CreateCommitFromPatchRequest, io.Reader) protocol.CreateCommitFromPatchResponse {
 				panic("unexpected invocation of MockService.CreateCommitFromPatch")
@@ -729,6 +758,11 @@ func NewStrictMockService() *MockService {
 				panic("unexpected invocation of MockService.EnsureRevision")
 			},
 		},
+		FetchMissingBlobsFunc: &ServiceFetchMissingBlobsFunc{
+			defaultHook: func(context.Context, api.RepoName, string, []string) error {
+				panic("unexpected invocation of MockService.FetchMissingBlobs")
+			},
+		},
 		FetchRepositoryFunc: &ServiceFetchRepositoryFunc{
 			defaultHook: func(context.Context, api.RepoName) (time.Time, time.Time, error) {
 				panic("unexpected invocation of MockService.FetchRepository")
@@ -744,6 +778,11 @@ func NewStrictMockService() *MockService {
 				panic("unexpected invocation of MockService.LogIfCorrupt")
 			},
 		},
+		IsRepoCloneableWithExternalServiceConfigFunc: &ServiceIsRepoCloneableWithExternalServiceConfigFunc{
+			defaultHook: func(context.Context, api.RepoName, string, string) (protocol.IsRepoCloneableResponse, error) {
+				panic("unexpected invocation of MockService.IsRepoCloneableWithExternalServiceConfig")
+			},
+		},
 	}
 }
 
@@ -751,10 +790,13 @@ func NewStrictMockService() *MockService {
 // github.com/sourcegraph/sourcegraph/cmd/gitserver/internal). It is
 // redefined here as it is unexported in the source package.
 type surrogateMockService interface {
+	CloneQueued(api.RepoName) bool
 	CreateCommitFromPatch(context.Context, protocol.CreateCommitFromPatchRequest, io.Reader) protocol.CreateCommitFromPatchResponse
 	EnsureRevision(context.Context, api.RepoName, string) bool
+	FetchMissingBlobs(context.Context, api.RepoName, string, []string) error
 	FetchRepository(context.Context, api.RepoName) (time.Time, time.Time, error)
 	IsRepoCloneable(context.Context, api.RepoName) (protocol.IsRepoCloneableResponse, error)
+	IsRepoCloneableWithExternalServiceConfig(context.Context, api.RepoName, string, string) (protocol.IsRepoCloneableResponse, error)
 	LogIfCorrupt(context.Context, api.RepoName, error)
 }
 
@@ -762,12 +804,18 @@ type surrogateMockService interface {
 // methods delegate to the given implementation, unless overwritten.
 func NewMockServiceFrom(i surrogateMockService) *MockService {
 	return &MockService{
+		CloneQueuedFunc: &ServiceCloneQueuedFunc{
+			defaultHook: i.CloneQueued,
+		},
 		CreateCommitFromPatchFunc: &ServiceCreateCommitFromPatchFunc{
 			defaultHook: i.CreateCommitFromPatch,
 		},
 		EnsureRevisionFunc: &ServiceEnsureRevisionFunc{
 			defaultHook: i.EnsureRevision,
 		},
+		FetchMissingBlobsFunc: &ServiceFetchMissingBlobsFunc{
+			defaultHook: i.FetchMissingBlobs,
+		},
 		FetchRepositoryFunc: &ServiceFetchRepositoryFunc{
 			defaultHook: i.FetchRepository,
 		},
@@ -777,9 +825,113 @@ func NewMockServiceFrom(i surrogateMockService) *MockService {
 		LogIfCorruptFunc: &ServiceLogIfCorruptFunc{
 			defaultHook: i.LogIfCorrupt,
 		},
+		IsRepoCloneableWithExternalServiceConfigFunc: &ServiceIsRepoCloneableWithExternalServiceConfigFunc{
+			defaultHook: i.IsRepoCloneableWithExternalServiceConfig,
+		},
 	}
 }
 
+// ServiceCloneQueuedFunc describes the behavior when the CloneQueued method of the
+// parent MockService instance is invoked.
+type ServiceCloneQueuedFunc struct {
+	defaultHook func(api.RepoName) bool
+	hooks       []func(api.RepoName) bool
+	history     []ServiceCloneQueuedFuncCall
+	mutex       sync.Mutex
+}
+
+// CloneQueued delegates to the next hook function in the queue and stores the
+// parameter and result values of this invocation.
+func (m *MockService) CloneQueued(v0 api.RepoName) bool {
+	r0 := m.CloneQueuedFunc.nextHook()(v0)
+	m.CloneQueuedFunc.appendCall(ServiceCloneQueuedFuncCall{v0, r0})
+	return r0
+}
+
+// SetDefaultHook sets function that is called when the CloneQueued method of
+// the parent MockService instance is invoked and the hook queue is empty.
+func (f *ServiceCloneQueuedFunc) SetDefaultHook(hook func(api.RepoName) bool) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// CloneQueued method of the parent MockService instance invokes the hook at the
+// front of the queue and discards it. After the queue is empty, the default
+// hook function is invoked for any future action.
+func (f *ServiceCloneQueuedFunc) PushHook(hook func(api.RepoName) bool) {
+	f.mutex.Lock()
+	f.hooks = append(f.hooks, hook)
+	f.mutex.Unlock()
+}
+
+// SetDefaultReturn calls SetDefaultHook with a function that returns the
+// given values.
+func (f *ServiceCloneQueuedFunc) SetDefaultReturn(r0 bool) {
+	f.SetDefaultHook(func(api.RepoName) bool {
+		return r0
+	})
+}
+
+// PushReturn calls PushHook with a function that returns the given values.
+func (f *ServiceCloneQueuedFunc) PushReturn(r0 bool) {
+	f.PushHook(func(api.RepoName) bool {
+		return r0
+	})
+}
+
+func (f *ServiceCloneQueuedFunc) nextHook() func(api.RepoName) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+func (f *ServiceCloneQueuedFunc) appendCall(r0 ServiceCloneQueuedFuncCall) {
+	f.mutex.Lock()
+	f.history = append(f.history, r0)
+	f.mutex.Unlock()
+}
+
+// History returns a sequence of ServiceCloneQueuedFuncCall objects describing the invocations of
+// this function.
+func (f *ServiceCloneQueuedFunc) History() []ServiceCloneQueuedFuncCall {
+	f.mutex.Lock()
+	history := make([]ServiceCloneQueuedFuncCall, len(f.history))
+	copy(history, f.history)
+	f.mutex.Unlock()
+
+	return history
+}
+
+// ServiceCloneQueuedFuncCall is an object that describes an invocation of method CloneQueued on an
+// instance of MockService.
+type ServiceCloneQueuedFuncCall struct {
+	// Arg0 is the value of the 1st argument passed to this method
+	// invocation.
+	Arg0 api.RepoName
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 bool
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c ServiceCloneQueuedFuncCall) Args() []interface{} {
+	return []interface{}{c.Arg0}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c ServiceCloneQueuedFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0}
+}
+
 // ServiceCreateCommitFromPatchFunc describes the behavior when the
 // CreateCommitFromPatch method of the parent MockService instance is
 // invoked.
@@ -997,6 +1149,117 @@ func (c ServiceEnsureRevisionFuncCall) Results() []interface{} {
 	return []interface{}{c.Result0}
 }
 
+// ServiceFetchMissingBlobsFunc describes the behavior when the
+// FetchMissingBlobs method of the parent MockService instance is invoked.
+type ServiceFetchMissingBlobsFunc struct {
+	defaultHook func(context.Context, api.RepoName, string, []string) error
+	hooks       []func(context.Context, api.RepoName, string, []string) error
+	history     []ServiceFetchMissingBlobsFuncCall
+	mutex       sync.Mutex
+}
+
+// FetchMissingBlobs delegates to the next hook function in the queue and
+// stores the parameter and result values of this invocation.
+func (m *MockService) FetchMissingBlobs(v0 context.Context, v1 api.RepoName, v2 string, v3 []string) error {
+	r0 := m.FetchMissingBlobsFunc.nextHook()(v0, v1, v2, v3)
+	m.FetchMissingBlobsFunc.appendCall(ServiceFetchMissingBlobsFuncCall{v0, v1, v2, v3, r0})
+	return r0
+}
+
+// SetDefaultHook sets function that is called when the FetchMissingBlobs
+// method of the parent MockService instance is invoked and the hook queue
+// is empty.
+func (f *ServiceFetchMissingBlobsFunc) SetDefaultHook(hook func(context.Context, api.RepoName, string, []string) error) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// FetchMissingBlobs method of the parent MockService instance invokes the
+// hook at the front of the queue and discards it. After the queue is empty,
+// the default hook function is invoked for any future action.
+func (f *ServiceFetchMissingBlobsFunc) PushHook(hook func(context.Context, api.RepoName, string, []string) error) {
+	f.mutex.Lock()
+	f.hooks = append(f.hooks, hook)
+	f.mutex.Unlock()
+}
+
+// SetDefaultReturn calls SetDefaultHook with a function that returns the
+// given values.
+func (f *ServiceFetchMissingBlobsFunc) SetDefaultReturn(r0 error) {
+	f.SetDefaultHook(func(context.Context, api.RepoName, string, []string) error {
+		return r0
+	})
+}
+
+// PushReturn calls PushHook with a function that returns the given values.
+func (f *ServiceFetchMissingBlobsFunc) PushReturn(r0 error) {
+	f.PushHook(func(context.Context, api.RepoName, string, []string) error {
+		return r0
+	})
+}
+
+func (f *ServiceFetchMissingBlobsFunc) nextHook() func(context.Context, api.RepoName, string, []string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+func (f *ServiceFetchMissingBlobsFunc) appendCall(r0 ServiceFetchMissingBlobsFuncCall) {
+	f.mutex.Lock()
+	f.history = append(f.history, r0)
+	f.mutex.Unlock()
+}
+
+// History returns a sequence of ServiceFetchMissingBlobsFuncCall objects
+// describing the invocations of this function.
+func (f *ServiceFetchMissingBlobsFunc) History() []ServiceFetchMissingBlobsFuncCall {
+	f.mutex.Lock()
+	history := make([]ServiceFetchMissingBlobsFuncCall, len(f.history))
+	copy(history, f.history)
+	f.mutex.Unlock()
+
+	return history
+}
+
+// ServiceFetchMissingBlobsFuncCall is an object that describes an
+// invocation of method FetchMissingBlobs on an instance of MockService.
+type ServiceFetchMissingBlobsFuncCall struct {
+	// Arg0 is the value of the 1st argument passed to this method
+	// invocation.
+	Arg0 context.Context
+	// Arg1 is the value of the 2nd argument passed to this method
+	// invocation.
+	Arg1 api.RepoName
+	// Arg2 is the value of the 3rd argument passed to this method
+	// invocation.
+	Arg2 string
+	// Arg3 is the value of the 4th argument passed to this method
+	// invocation.
+	Arg3 []string
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 error
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c ServiceFetchMissingBlobsFuncCall) Args() []interface{} {
+	return []interface{}{c.Arg0, c.Arg1, c.Arg2, c.Arg3}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c ServiceFetchMissingBlobsFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0}
+}
+
 // ServiceFetchRepositoryFunc describes the behavior when the
 // FetchRepository method of the parent MockService instance is invoked.
 type ServiceFetchRepositoryFunc struct {
@@ -1320,3 +1583,116 @@ func (c ServiceLogIfCorruptFuncCall) Args() []interface{} {
 func (c ServiceLogIfCorruptFuncCall) Results() []interface{} {
 	return []interface{}{}
 }
+
+// ServiceIsRepoCloneableWithExternalServiceConfigFunc describes the behavior when the IsRepoCloneableWithExternalServiceConfig method of the
+// parent MockService instance is invoked.
+type ServiceIsRepoCloneableWithExternalServiceConfigFunc struct {
+	defaultHook func(context.Context, api.RepoName, string, string) (protocol.IsRepoCloneableResponse, error)
+	hooks       []func(context.Context, api.RepoName, string, string) (protocol.IsRepoCloneableResponse, error)
+	history     []ServiceIsRepoCloneableWithExternalServiceConfigFuncCall
+	mutex       sync.Mutex
+}
+
+// IsRepoCloneableWithExternalServiceConfig delegates to the next hook function in the queue and stores the
+// parameter and result values of this invocation.
+func (m *MockService) IsRepoCloneableWithExternalServiceConfig(v0 context.Context, v1 api.RepoName, v2 string, v3 string) (protocol.IsRepoCloneableResponse, error) {
+	r0, r1 := m.IsRepoCloneableWithExternalServiceConfigFunc.nextHook()(v0, v1, v2, v3)
+	m.IsRepoCloneableWithExternalServiceConfigFunc.appendCall(ServiceIsRepoCloneableWithExternalServiceConfigFuncCall{v0, v1, v2, v3, r0, r1})
+	return r0, r1
+}
+
+// SetDefaultHook sets function that is called when the IsRepoCloneableWithExternalServiceConfig method of
+// the parent MockService instance is invoked and the hook queue is empty.
+func (f *ServiceIsRepoCloneableWithExternalServiceConfigFunc) SetDefaultHook(hook func(context.Context, api.RepoName, string, string) (protocol.IsRepoCloneableResponse, error)) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// IsRepoCloneableWithExternalServiceConfig method of the parent MockService instance invokes the hook at the
+// front of the queue and discards it. After the queue is empty, the default
+// hook function is invoked for any future action.
+func (f *ServiceIsRepoCloneableWithExternalServiceConfigFunc) PushHook(hook func(context.Context, api.RepoName, string, string) (protocol.IsRepoCloneableResponse, error)) {
+	f.mutex.Lock()
+	f.hooks = append(f.hooks, hook)
+	f.mutex.Unlock()
+}
+
+// SetDefaultReturn calls SetDefaultHook with a function that returns the
+// given values.
+func (f *ServiceIsRepoCloneableWithExternalServiceConfigFunc) SetDefaultReturn(r0 protocol.IsRepoCloneableResponse, r1 error) {
+	f.SetDefaultHook(func(context.Context, api.RepoName, string, string) (protocol.IsRepoCloneableResponse, error) {
+		return r0, r1
+	})
+}
+
+// PushReturn calls PushHook with a function that returns the given values.
+func (f *ServiceIsRepoCloneableWithExternalServiceConfigFunc) PushReturn(r0 protocol.IsRepoCloneableResponse, r1 error) {
+	f.PushHook(func(context.Context, api.RepoName, string, string) (protocol.IsRepoCloneableResponse, error) {
+		return r0, r1
+	})
+}
+
+func (f *ServiceIsRepoCloneableWithExternalServiceConfigFunc) nextHook() func(context.Context, api.RepoName, string, string) (protocol.IsRepoCloneableResponse, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+func (f *ServiceIsRepoCloneableWithExternalServiceConfigFunc) appendCall(r0 ServiceIsRepoCloneableWithExternalServiceConfigFuncCall) {
+	f.mutex.Lock()
+	f.history = append(f.history, r0)
+	f.mutex.Unlock()
+}
+
+// History returns a sequence of ServiceIsRepoCloneableWithExternalServiceConfigFuncCall objects describing the invocations of
+// this function.
+func (f *ServiceIsRepoCloneableWithExternalServiceConfigFunc) History() []ServiceIsRepoCloneableWithExternalServiceConfigFuncCall {
+	f.mutex.Lock()
+	history := make([]ServiceIsRepoCloneableWithExternalServiceConfigFuncCall, len(f.history))
+	copy(history, f.history)
+	f.mutex.Unlock()
+
+	return history
+}
+
+// ServiceIsRepoCloneableWithExternalServiceConfigFuncCall is an object that describes an invocation of method IsRepoCloneableWithExternalServiceConfig on an
+// instance of MockService.
+type ServiceIsRepoCloneableWithExternalServiceConfigFuncCall struct {
+	// Arg0 is the value of the 1st argument passed to this method
+	// invocation.
+	Arg0 context.Context
+	// Arg1 is the value of the 2nd argument passed to this method
+	// invocation.
+	Arg1 api.RepoName
+	// Arg2 is the value of the 3rd argument passed to this method
+	// invocation.
+	Arg2 string
+	// Arg3 is the value of the 4th argument passed to this method
+	// invocation.
+	Arg3 string
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 protocol.IsRepoCloneableResponse
+	// Result1 is the value of the 2nd result returned from this method
+	// invocation.
+	Result1 error
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c ServiceIsRepoCloneableWithExternalServiceConfigFuncCall) Args() []interface{} {
+	return []interface{}{c.Arg0, c.Arg1, c.Arg2, c.Arg3}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c ServiceIsRepoCloneableWithExternalServiceConfigFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0, c.Result1}
+}
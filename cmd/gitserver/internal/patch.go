@@ -106,7 +106,7 @@ func (s *Server) CreateCommitFromPatch(ctx context.Context, req protocol.CreateC
 		if isRemote {
 			// Configure the command to be able to talk to a remote since one of our
 			// commands could be git push
-			executil.ConfigureRemoteGitCommand(cmd, remoteURL)
+			executil.ConfigureRemoteGitCommand(cmd, s.gitBinary, remoteURL)
 		}
 
 		out, err := s.recordingCommandFactory.Wrap(ctx, s.logger, cmd).CombinedOutput()
@@ -132,7 +132,7 @@ func (s *Server) CreateCommitFromPatch(ctx context.Context, req protocol.CreateC
 
 	altObjectsEnv := "GIT_ALTERNATE_OBJECT_DIRECTORIES=" + repoObjectsDir
 
-	cmd := exec.CommandContext(ctx, "git", "init")
+	cmd := exec.CommandContext(ctx, s.gitBinary, "init")
 	cmd.Dir = tmpRepoDir
 	cmd.Env = append(os.Environ(), tmpGitPathEnv)
 
@@ -140,7 +140,7 @@ func (s *Server) CreateCommitFromPatch(ctx context.Context, req protocol.CreateC
 		return resp
 	}
 
-	cmd = exec.CommandContext(ctx, "git", "reset", "-q", string(req.BaseCommit))
+	cmd = exec.CommandContext(ctx, s.gitBinary, "reset", "-q", string(req.BaseCommit))
 	cmd.Dir = tmpRepoDir
 	cmd.Env = append(os.Environ(), tmpGitPathEnv, altObjectsEnv)
 
@@ -156,7 +156,7 @@ func (s *Server) CreateCommitFromPatch(ctx context.Context, req protocol.CreateC
 		applyArgs = append(applyArgs, "-p0")
 	}
 
-	cmd = exec.CommandContext(ctx, "git", applyArgs...)
+	cmd = exec.CommandContext(ctx, s.gitBinary, applyArgs...)
 	cmd.Dir = tmpRepoDir
 	cmd.Env = append(os.Environ(), tmpGitPathEnv, altObjectsEnv)
 	cmd.Stdin = patchReader
@@ -189,7 +189,7 @@ func (s *Server) CreateCommitFromPatch(ctx context.Context, req protocol.CreateC
 
 	// Commit messages can be arbitrary strings, so using `-m` runs into problems.
 	// Instead, feed the commit messages to stdin.
-	cmd = exec.CommandContext(ctx, "git", "commit", "-F", "-")
+	cmd = exec.CommandContext(ctx, s.gitBinary, "commit", "-F", "-")
 	// NOTE: join messages with a blank line in between ("\n\n")
 	// because the previous behavior was to use multiple -m arguments,
 	// which concatenate with a blank line in between.
@@ -213,7 +213,7 @@ func (s *Server) CreateCommitFromPatch(ctx context.Context, req protocol.CreateC
 		return resp
 	}
 
-	cmd = exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
+	cmd = exec.CommandContext(ctx, s.gitBinary, "rev-parse", "HEAD")
 	cmd.Dir = tmpRepoDir
 	cmd.Env = append(os.Environ(), tmpGitPathEnv, altObjectsEnv)
 
@@ -265,7 +265,7 @@ func (s *Server) CreateCommitFromPatch(ctx context.Context, req protocol.CreateC
 
 			resp.ChangelistId = cid
 		} else {
-			cmd = exec.CommandContext(ctx, "git", "push", "--force", remoteURL.String(), fmt.Sprintf("%s:%s", cmtHash, ref))
+			cmd = exec.CommandContext(ctx, s.gitBinary, "push", "--force", remoteURL.String(), fmt.Sprintf("%s:%s", cmtHash, ref))
 			repoGitDir.Set(cmd)
 
 			// If the protocol is SSH and a private key was given, we want to
@@ -302,7 +302,7 @@ func (s *Server) CreateCommitFromPatch(ctx context.Context, req protocol.CreateC
 	resp.Rev = "refs/" + strings.TrimPrefix(ref, "refs/")
 
 	if req.PushRef == nil {
-		cmd = exec.CommandContext(ctx, "git", "update-ref", "--", ref, cmtHash)
+		cmd = exec.CommandContext(ctx, s.gitBinary, "update-ref", "--", ref, cmtHash)
 		repoGitDir.Set(cmd)
 
 		if out, err = run(cmd, "creating ref", false); err != nil {
@@ -366,6 +366,7 @@ func (s *Server) shelveChangelist(ctx context.Context, req protocol.CreateCommit
 
 	gitCmd := gitCommand{
 		ctx:        ctx,
+		gitBinary:  s.gitBinary,
 		workingDir: tmpClientDir,
 		env:        commonEnv,
 	}
@@ -521,12 +522,13 @@ func (s *Server) shelveChangelist(ctx context.Context, req protocol.CreateCommit
 
 type gitCommand struct {
 	ctx        context.Context
+	gitBinary  string
 	workingDir string
 	env        []string
 }
 
 func (g gitCommand) commandContext(args ...string) *exec.Cmd {
-	cmd := exec.CommandContext(g.ctx, "git", args...)
+	cmd := exec.CommandContext(g.ctx, g.gitBinary, args...)
 	cmd.Dir = g.workingDir
 	cmd.Env = g.env
 	return cmd
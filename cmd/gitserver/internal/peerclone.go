@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+
+	"github.com/sourcegraph/log"
+
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/vcssyncer"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+)
+
+// peerCloneURL returns the URL under which the gitserver peer that last had
+// the repo cloned serves it, if the repo should be cloned from that peer
+// instead of from the code host. Every gitserver exposes its repos as a git
+// remote under /git/, see NewHTTPHandler.
+func peerCloneURL(repo api.RepoName, gr *types.GitserverRepo, hostname string) (*vcs.URL, bool) {
+	if gr == nil || gr.CloneStatus != types.CloneStatusCloned {
+		return nil, false
+	}
+	// The repo was never cloned anywhere else, or we are the peer ourselves and
+	// lost the repo on disk.
+	if gr.ShardID == "" || gr.ShardID == hostname {
+		return nil, false
+	}
+
+	u := url.URL{
+		Scheme: "http",
+		Host:   gr.ShardID,
+		Path:   path.Join("/git", string(repo)),
+	}
+	peer, err := vcs.ParseURL(u.String())
+	if err != nil {
+		return nil, false
+	}
+	return peer, true
+}
+
+// maybeCloneFromPeer clones the repo into tmpPath from the gitserver peer
+// that last had it cloned, if cloning from peers is enabled and the repo's
+// syncer supports it. It returns false if the repo wasn't cloned from a peer
+// and should be cloned from the code host instead. tmpPath is left empty in
+// that case. If it returns true, the caller must still fetch from the code
+// host, as the peer might lag behind it.
+//
+// peer must be looked up before this instance marks the repo as cloning, as
+// that overwrites the shard the repo was last cloned on.
+func (s *Server) maybeCloneFromPeer(ctx context.Context, logger log.Logger, repo api.RepoName, syncer vcssyncer.VCSSyncer, peer *vcs.URL, tmpPath string, progressWriter io.Writer) bool {
+	// Other VCSs keep additional state next to the git repo that a peer can't
+	// serve to us, and some clone options make a clone from a peer differ from
+	// one from the code host.
+	peerCloner, ok := syncer.(vcssyncer.PeerCloner)
+	if peer == nil || !ok || !peerCloner.CanCloneFromPeer() {
+		return false
+	}
+
+	logger = logger.With(log.String("peer", peer.Host))
+	logger.Info("cloning repo from gitserver peer")
+	fmt.Fprintf(progressWriter, "Cloning from gitserver peer %s\n", peer.Host)
+
+	if err := peerCloner.CloneFromPeer(ctx, repo, peer, tmpPath, progressWriter); err != nil {
+		// The peer might not have the repo anymore, or might be gone altogether.
+		// Clean up whatever we got so far and fall back to the code host.
+		logger.Warn("failed to clone repo from gitserver peer, falling back to code host", log.Error(err))
+		fmt.Fprintf(progressWriter, "Failed to clone from gitserver peer, falling back to code host\n")
+		if err := os.RemoveAll(tmpPath); err != nil {
+			logger.Error("failed to clean up partial clone from gitserver peer", log.Error(err))
+		}
+		return false
+	}
+
+	return true
+}
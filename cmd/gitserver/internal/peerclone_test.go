@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func TestPeerCloneURL(t *testing.T) {
+	const hostname = "gitserver-1:3178"
+
+	tests := []struct {
+		name    string
+		gr      *types.GitserverRepo
+		wantURL string
+	}{
+		{
+			name: "repo unknown",
+			gr:   nil,
+		},
+		{
+			name: "never cloned",
+			gr:   &types.GitserverRepo{CloneStatus: types.CloneStatusNotCloned},
+		},
+		{
+			name: "peer still cloning",
+			gr:   &types.GitserverRepo{ShardID: "gitserver-0:3178", CloneStatus: types.CloneStatusCloning},
+		},
+		{
+			name: "cloned on this instance",
+			gr:   &types.GitserverRepo{ShardID: hostname, CloneStatus: types.CloneStatusCloned},
+		},
+		{
+			name: "cloned without shard",
+			gr:   &types.GitserverRepo{CloneStatus: types.CloneStatusCloned},
+		},
+		{
+			name:    "cloned on peer",
+			gr:      &types.GitserverRepo{ShardID: "gitserver-0:3178", CloneStatus: types.CloneStatusCloned},
+			wantURL: "http://gitserver-0:3178/git/github.com/sourcegraph/sourcegraph",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			u, ok := peerCloneURL("github.com/sourcegraph/sourcegraph", tc.gr, hostname)
+			if tc.wantURL == "" {
+				assert.False(t, ok)
+				assert.Nil(t, u)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tc.wantURL, u.String())
+		})
+	}
+}
@@ -23,6 +23,7 @@ func postRepoFetchActions(
 	logger log.Logger,
 	fs gitserverfs.FS,
 	db database.DB,
+	gitBinary string,
 	backend git.GitBackend,
 	shardID string,
 	repo api.RepoName,
@@ -32,7 +33,7 @@ func postRepoFetchActions(
 	// Note: We use a multi error in this function to try to make as many of the
 	// post repo fetch actions succeed.
 
-	if err := git.RemoveBadRefs(ctx, dir); err != nil {
+	if err := git.RemoveBadRefs(ctx, gitBinary, dir); err != nil {
 		errs = errors.Append(errs, errors.Wrapf(err, "failed to remove bad refs for repo %q", repo))
 	}
 
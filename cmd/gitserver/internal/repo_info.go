@@ -4,6 +4,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/gitserverfs"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/types"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
@@ -27,3 +28,24 @@ func repoCloneProgress(fs gitserverfs.FS, locker RepositoryLocker, repo api.Repo
 	}
 	return &resp, nil
 }
+
+// repoCloneStatuses returns the clone status of each of repos on disk. Repos
+// that are neither cloned nor being cloned are reported as not cloned.
+func repoCloneStatuses(fs gitserverfs.FS, locker RepositoryLocker, repos []api.RepoName) (map[api.RepoName]types.CloneStatus, error) {
+	statuses := make(map[api.RepoName]types.CloneStatus, len(repos))
+	for _, repo := range repos {
+		progress, err := repoCloneProgress(fs, locker, repo)
+		if err != nil {
+			return nil, errors.Wrapf(err, "repo %q", repo)
+		}
+		switch {
+		case progress.Cloned:
+			statuses[repo] = types.CloneStatusCloned
+		case progress.CloneInProgress:
+			statuses[repo] = types.CloneStatusCloning
+		default:
+			statuses[repo] = types.CloneStatusNotCloned
+		}
+	}
+	return statuses, nil
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"context"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/common"
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/gitserverfs"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/connection"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+)
+
+var (
+	orphanedReposTotal = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "src_gitserver_repos_orphaned",
+		Help: "The number of repos on disk that are not in the repo store, as of the last consistency check",
+	})
+	missingReposTotal = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "src_gitserver_repos_missing",
+		Help: "The number of repos in the repo store that belong to this shard but are not on disk, as of the last consistency check",
+	})
+)
+
+// repoConsistencyReport describes how the repos on disk drifted from the repo
+// store.
+type repoConsistencyReport struct {
+	// Orphaned are the repos on disk that are not in the repo store, or that
+	// have been deleted from it.
+	Orphaned []api.RepoName
+	// Missing are the repos in the repo store that belong to this shard, but
+	// are not on disk. These should be cloned.
+	Missing []api.RepoName
+}
+
+// checkRepoConsistency compares the repos on disk with the repos in the repo
+// store and updates the orphaned and missing repos metrics. It only reports
+// drift, it doesn't try to fix it: orphaned repos are removed by the janitor if
+// SRC_REMOVE_NON_EXISTING_REPOS is set, and missing repos are cloned by the
+// repo-updater scheduler. The janitor runs it on every run, admins can run it
+// on demand with the CheckRepoConsistency RPC.
+func checkRepoConsistency(
+	ctx context.Context,
+	store database.GitserverRepoStore,
+	fs gitserverfs.FS,
+	shardID string,
+	gitServerAddrs connection.GitserverAddresses,
+) (*repoConsistencyReport, error) {
+	onDisk := make(map[api.RepoName]struct{})
+	if err := fs.ForEachRepo(func(name api.RepoName, _ common.GitDir) bool {
+		onDisk[name] = struct{}{}
+		return false
+	}); err != nil {
+		return nil, err
+	}
+
+	var report repoConsistencyReport
+	inStore := make(map[api.RepoName]struct{})
+	options := database.IterateRepoGitserverStatusOptions{
+		BatchSize: 500,
+	}
+	for {
+		repos, nextRepo, err := store.IterateRepoGitserverStatus(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		for _, repo := range repos {
+			name := protocol.NormalizeRepo(repo.Name)
+			inStore[name] = struct{}{}
+
+			if _, ok := onDisk[name]; ok {
+				continue
+			}
+			if hostnameMatch(shardID, gitServerAddrs.AddrForRepo(ctx, repo.Name)) {
+				report.Missing = append(report.Missing, repo.Name)
+			}
+		}
+
+		if nextRepo == 0 {
+			break
+		}
+		options.NextCursor = nextRepo
+	}
+
+	for name := range onDisk {
+		if _, ok := inStore[name]; !ok {
+			report.Orphaned = append(report.Orphaned, name)
+		}
+	}
+
+	sort.Slice(report.Orphaned, func(i, j int) bool { return report.Orphaned[i] < report.Orphaned[j] })
+	sort.Slice(report.Missing, func(i, j int) bool { return report.Missing[i] < report.Missing[j] })
+
+	orphanedReposTotal.Set(float64(len(report.Orphaned)))
+	missingReposTotal.Set(float64(len(report.Missing)))
+
+	return &report, nil
+}
@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/gitserverfs"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbmocks"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/connection"
+	"github.com/sourcegraph/sourcegraph/internal/observation"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func TestCheckRepoConsistency(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"github.com/foo/cloned", "github.com/foo/upper", "github.com/foo/orphan"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, name, ".git"), os.ModePerm))
+	}
+	fs := gitserverfs.New(observation.TestContextTB(t), root)
+	require.NoError(t, fs.Initialize())
+
+	// The store is paginated, make sure we look at every page.
+	pages := [][]types.RepoGitserverStatus{
+		{
+			{ID: 1, Name: "github.com/foo/cloned"},
+			{ID: 2, Name: "github.com/Foo/Upper"},
+		},
+		{
+			{ID: 3, Name: "github.com/foo/missing"},
+			{ID: 4, Name: "github.com/foo/other-shard"},
+		},
+	}
+	store := dbmocks.NewMockGitserverRepoStore()
+	store.IterateRepoGitserverStatusFunc.SetDefaultHook(func(_ context.Context, opts database.IterateRepoGitserverStatusOptions) ([]types.RepoGitserverStatus, int, error) {
+		if opts.NextCursor == 0 {
+			return pages[0], 2, nil
+		}
+		return pages[1], 0, nil
+	})
+
+	addrs := connection.GitserverAddresses{
+		Addresses: []string{"test-gitserver", "other-gitserver"},
+		PinnedServers: map[string]string{
+			"github.com/foo/missing":     "test-gitserver",
+			"github.com/foo/other-shard": "other-gitserver",
+		},
+	}
+
+	report, err := checkRepoConsistency(context.Background(), store, fs, "test-gitserver", addrs)
+	require.NoError(t, err)
+	require.Equal(t, &repoConsistencyReport{
+		Orphaned: []api.RepoName{"github.com/foo/orphan"},
+		Missing:  []api.RepoName{"github.com/foo/missing"},
+	}, report)
+}
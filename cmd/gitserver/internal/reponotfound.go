@@ -0,0 +1,225 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/log"
+
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/common"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/metrics"
+	"github.com/sourcegraph/sourcegraph/internal/observation"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// RepoNotFoundAction is what gitserver does with its copy of a repo once
+// fetching it fails because the repo no longer exists on the code host.
+//
+// Gitserver never deletes repos from the database: a fetch can also fail with
+// not found because of missing permissions, for example after a token expired.
+// Deleting repos is left to syncing the code host connections.
+type RepoNotFoundAction string
+
+const (
+	// RepoNotFoundRetain keeps the local copy as it was before the repo
+	// disappeared, so it can still be searched and browsed. This is the default.
+	RepoNotFoundRetain RepoNotFoundAction = "retain"
+	// RepoNotFoundRemoveAfterGrace removes the local copy once fetching the repo
+	// kept failing with not found for the grace period, and the code host
+	// confirmed once more that the repo doesn't exist. The repo is then not
+	// cloned again for another grace period, so that gitserver doesn't keep
+	// trying to clone a repo that is gone.
+	RepoNotFoundRemoveAfterGrace RepoNotFoundAction = "remove-after-grace"
+)
+
+// ParseRepoNotFoundAction returns the RepoNotFoundAction named s.
+func ParseRepoNotFoundAction(s string) (RepoNotFoundAction, error) {
+	switch a := RepoNotFoundAction(s); a {
+	case RepoNotFoundRetain, RepoNotFoundRemoveAfterGrace:
+		return a, nil
+	}
+	return "", errors.Newf("unknown action %q, must be one of %q or %q", s, RepoNotFoundRetain, RepoNotFoundRemoveAfterGrace)
+}
+
+// RepoNotFoundPolicy configures what happens to repos that disappear from the
+// code host. The zero value retains them.
+type RepoNotFoundPolicy struct {
+	Action RepoNotFoundAction
+	// GracePeriod is how long fetches of a repo have to fail with not found
+	// before it is removed, if Action is RepoNotFoundRemoveAfterGrace.
+	GracePeriod time.Duration
+}
+
+// handleRepoNotFound applies the RepoNotFoundPolicy to repo, after fetching it
+// failed with fetchErr. Only errors that confirm the repo doesn't exist on the
+// code host count towards the grace period. Authentication errors restart it,
+// as we can't tell whether the repo exists. The caller must hold the repo lock.
+func (s *Server) handleRepoNotFound(ctx context.Context, logger log.Logger, repo api.RepoName, dir common.GitDir, fetchErr error) error {
+	if !isConfirmedNotFound(fetchErr) {
+		if classifyCloneError(fetchErr) == cloneFailureAuth {
+			if found, err := clearNotFound(dir); err != nil {
+				return err
+			} else if found {
+				logger.Info("fetching repo failed to authenticate, restarting the grace period for its removal")
+			}
+		}
+		return nil
+	}
+
+	action := s.repoNotFoundPolicy.Action
+	if action == "" {
+		action = RepoNotFoundRetain
+	}
+	logger = logger.With(log.String("action", string(action)))
+
+	switch action {
+	case RepoNotFoundRemoveAfterGrace:
+		since, err := getNotFoundSince(dir)
+		if err != nil {
+			return err
+		}
+		if since.IsZero() {
+			since = time.Now()
+			if err := setNotFoundSince(dir, since); err != nil {
+				return err
+			}
+			logger.Warn("repo not found on code host, scheduled local copy for removal",
+				log.Time("removeAfter", since.Add(s.repoNotFoundPolicy.GracePeriod)))
+		}
+		if time.Since(since) < s.repoNotFoundPolicy.GracePeriod {
+			return nil
+		}
+		if isPinnedRepo(repo) {
+			logger.Info("keeping pinned repo that was not found on the code host for longer than the grace period",
+				log.Time("notFoundSince", since))
+			pinnedReposKept.WithLabelValues("not_found").Inc()
+			return nil
+		}
+
+		// Ask the code host once more before removing the repo. The remote URL
+		// is looked up again, so this might use the credentials of another code
+		// host connection that has access to the repo.
+		syncer, err := s.getVCSSyncer(ctx, repo)
+		if err != nil {
+			return errors.Wrap(err, "get VCS syncer")
+		}
+		if err := syncer.IsCloneable(ctx, repo); !isConfirmedNotFound(err) {
+			logger.Info("code host didn't confirm that repo doesn't exist, keeping local copy", log.Error(err))
+			return nil
+		}
+
+		if err := s.fs.RemoveRepo(repo); err != nil {
+			return errors.Wrap(err, "removing repo")
+		}
+		// We don't reset the clone status, which would make the scheduler
+		// clone the repo again right away. Instead, we refuse to clone it for
+		// another grace period.
+		s.rememberRemovedNotFound(repo)
+		logger.Warn("repo not found on code host for longer than the grace period, removed local copy",
+			log.Time("notFoundSince", since))
+
+	default:
+		logger.Warn("repo not found on code host, retaining local copy")
+	}
+
+	s.repoNotFoundCounter.WithLabelValues(string(action)).Inc()
+	return nil
+}
+
+// isConfirmedNotFound returns true if err is the code host's answer that the
+// repo doesn't exist. Errors that mention failed authentication never are, and
+// neither are errors git reports for local reasons, e.g. a ref that "does not
+// exist".
+func isConfirmedNotFound(err error) bool {
+	if err == nil || classifyCloneError(err) != cloneFailureNotFound {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "repository not found") || strings.Contains(msg, "returned error: 404")
+}
+
+// rememberRemovedNotFound keeps repo from being cloned again for the grace
+// period, after it was removed because it doesn't exist on the code host.
+func (s *Server) rememberRemovedNotFound(repo api.RepoName) {
+	s.removedNotFoundMu.Lock()
+	defer s.removedNotFoundMu.Unlock()
+	s.removedNotFound[repo] = time.Now().Add(s.repoNotFoundPolicy.GracePeriod)
+}
+
+// rememberCloneNotFound keeps repo from being cloned again for the grace
+// period if cloning it failed because it doesn't exist on the code host. This
+// keeps repos removed by RepoNotFoundRemoveAfterGrace out of the clone queue
+// after gitserver restarted.
+func (s *Server) rememberCloneNotFound(repo api.RepoName, cloneErr error) {
+	if s.repoNotFoundPolicy.Action == RepoNotFoundRemoveAfterGrace && isConfirmedNotFound(cloneErr) {
+		s.rememberRemovedNotFound(repo)
+	}
+}
+
+// checkNotRemovedAsNotFound returns an *ErrCloneNotAllowed if repo must not be
+// cloned, because it didn't exist on the code host less than a grace period
+// ago.
+func (s *Server) checkNotRemovedAsNotFound(repo api.RepoName) error {
+	s.removedNotFoundMu.Lock()
+	defer s.removedNotFoundMu.Unlock()
+	until, ok := s.removedNotFound[repo]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(until) {
+		delete(s.removedNotFound, repo)
+		return nil
+	}
+	return &ErrCloneNotAllowed{Repo: repo, Reason: fmt.Sprintf("repo was not found on the code host, not retrying before %s", until.Format(time.RFC3339))}
+}
+
+const notFoundFilepath = ".sourcegraph-not-found"
+
+// getNotFoundSince returns the time fetching the repo first failed because it
+// doesn't exist on the code host, or the zero time if it exists.
+func getNotFoundSince(dir common.GitDir) (time.Time, error) {
+	fd, err := os.Stat(dir.Path(notFoundFilepath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	// We use modtime to track when the repo was first not found.
+	return fd.ModTime(), nil
+}
+
+func setNotFoundSince(dir common.GitDir, when time.Time) error {
+	path := dir.Path(notFoundFilepath)
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_ = f.Close()
+	return os.Chtimes(path, time.Time{}, when)
+}
+
+// clearNotFound removes the not found marker of the repo after it was fetched
+// successfully. It returns true if the repo was marked as not found.
+func clearNotFound(dir common.GitDir) (bool, error) {
+	err := os.Remove(dir.Path(notFoundFilepath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func newRepoNotFoundCounter(observationCtx *observation.Context) *prometheus.CounterVec {
+	return metrics.MustRegisterIgnoreDuplicate(observationCtx.Registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "src_gitserver_repo_not_found",
+		Help: "number of fetches that failed because the repo no longer exists on the code host, by the configured action",
+	}, []string{"action"}))
+}
@@ -10,9 +10,12 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/common"
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/git"
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/gitserverfs"
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/connection"
 	proto "github.com/sourcegraph/sourcegraph/internal/gitserver/v1"
 	"github.com/sourcegraph/sourcegraph/internal/types"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
@@ -29,6 +32,9 @@ func NewRepositoryServiceServer(server *Server, config *GRPCRepositoryServiceCon
 		hostname: server.hostname,
 		svc:      server,
 		fs:       server.fs,
+		locker:   server.locker,
+
+		gitBackendSource: server.gitBackendSource,
 	}
 
 	if config.ExhaustiveRequestLoggingEnabled {
@@ -49,6 +55,9 @@ type repositoryServiceServer struct {
 	hostname string
 	fs       gitserverfs.FS
 	svc      service
+	locker   RepositoryLocker
+
+	gitBackendSource git.GitBackendSource
 
 	proto.UnimplementedGitserverRepositoryServiceServer
 }
@@ -107,6 +116,83 @@ func (s *repositoryServiceServer) FetchRepository(ctx context.Context, req *prot
 	}, nil
 }
 
+func (s *repositoryServiceServer) GarbageCollect(ctx context.Context, req *proto.GarbageCollectRequest) (*proto.GarbageCollectResponse, error) {
+	if req.GetRepoName() == "" {
+		return nil, status.New(codes.InvalidArgument, "repo_name must be specified").Err()
+	}
+
+	repoName := api.RepoName(req.GetRepoName())
+
+	cloned, err := s.fs.RepoCloned(repoName)
+	if err != nil {
+		return nil, status.New(codes.Internal, "failed to determine clone status").Err()
+	}
+
+	if !cloned {
+		return nil, newRepoNotFoundError(repoName, false, "")
+	}
+
+	// Don't run concurrently with clones and fetches of the repo.
+	lock, ok := s.locker.TryAcquire(repoName, "running git gc")
+	if !ok {
+		return nil, status.Errorf(codes.Aborted, "repository %s is busy", repoName)
+	}
+	defer lock.Release()
+
+	dir := s.fs.RepoDir(repoName)
+
+	// Don't run concurrently with the maintenance of the janitor, which takes
+	// the same lock.
+	err, unlock := lockRepoForGC(dir)
+	if err != nil {
+		return nil, status.Errorf(codes.Aborted, "failed to lock repository %s for gc: %s", repoName, err)
+	}
+	defer func() { _ = unlock() }()
+
+	sizeBefore, err := s.fs.DirSize(dir.Path())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to determine repo size: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, conf.GitLongCommandTimeout())
+	defer cancel()
+
+	if err := s.gitBackendSource(dir, repoName).GarbageCollect(ctx); err != nil {
+		s.logger.Error("git gc failed", log.String("repo", string(repoName)), log.Error(err))
+		return nil, status.Errorf(codes.Internal, "git gc failed: %s", err)
+	}
+
+	sizeAfter, err := s.fs.DirSize(dir.Path())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to determine repo size: %s", err)
+	}
+
+	return &proto.GarbageCollectResponse{
+		SizeBefore: sizeBefore,
+		SizeAfter:  sizeAfter,
+	}, nil
+}
+
+func (s *repositoryServiceServer) CheckRepoConsistency(ctx context.Context, req *proto.CheckRepoConsistencyRequest) (*proto.CheckRepoConsistencyResponse, error) {
+	gitServerAddrs := connection.NewGitserverAddresses(conf.Get())
+	report, err := checkRepoConsistency(ctx, s.db.GitserverRepos(), s.fs, s.hostname, gitServerAddrs)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "repo consistency check failed: %s", err)
+	}
+
+	resp := &proto.CheckRepoConsistencyResponse{
+		Orphaned: make([]string, 0, len(report.Orphaned)),
+		Missing:  make([]string, 0, len(report.Missing)),
+	}
+	for _, repo := range report.Orphaned {
+		resp.Orphaned = append(resp.Orphaned, string(repo))
+	}
+	for _, repo := range report.Missing {
+		resp.Missing = append(resp.Missing, string(repo))
+	}
+	return resp, nil
+}
+
 func (s *repositoryServiceServer) ListRepositories(ctx context.Context, req *proto.ListRepositoriesRequest) (*proto.ListRepositoriesResponse, error) {
 	if req.GetPageSize() == 0 {
 		return nil, status.New(codes.InvalidArgument, "page_size must be > 0").Err()
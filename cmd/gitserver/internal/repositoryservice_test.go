@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"strconv"
 	"testing"
 	"time"
@@ -15,12 +16,16 @@ import (
 	"google.golang.org/grpc/codes"
 
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/common"
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/git"
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/gitserverfs"
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/conf/conftypes"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbmocks"
 	proto "github.com/sourcegraph/sourcegraph/internal/gitserver/v1"
 	internalgrpc "github.com/sourcegraph/sourcegraph/internal/grpc"
 	"github.com/sourcegraph/sourcegraph/internal/grpc/defaults"
+	"github.com/sourcegraph/sourcegraph/internal/types"
 )
 
 func TestRepositoryServiceServer_DeleteRepository(t *testing.T) {
@@ -99,6 +104,129 @@ func TestRepositoryServiceServer_FetchRepository(t *testing.T) {
 	})
 }
 
+func TestRepositoryServiceServer_GarbageCollect(t *testing.T) {
+	ctx := context.Background()
+
+	newServer := func(t *testing.T, cloned bool) (*repositoryServiceServer, common.GitDir, *git.MockGitBackend) {
+		dir := common.GitDir(t.TempDir())
+		fs := gitserverfs.NewMockFS()
+		fs.RepoClonedFunc.SetDefaultReturn(cloned, nil)
+		fs.RepoDirFunc.SetDefaultReturn(dir)
+		fs.DirSizeFunc.PushReturn(2048, nil)
+		fs.DirSizeFunc.PushReturn(1024, nil)
+
+		b := git.NewMockGitBackend()
+		rs := &repositoryServiceServer{
+			fs:     fs,
+			locker: NewRepositoryLocker(),
+			logger: logtest.NoOp(t),
+			gitBackendSource: func(d common.GitDir, repoName api.RepoName) git.GitBackend {
+				require.Equal(t, dir, d)
+				require.Equal(t, api.RepoName("therepo"), repoName)
+				return b
+			},
+		}
+		return rs, dir, b
+	}
+
+	t.Run("argument validation", func(t *testing.T) {
+		rs := &repositoryServiceServer{}
+		_, err := rs.GarbageCollect(ctx, &proto.GarbageCollectRequest{RepoName: ""})
+		require.ErrorContains(t, err, "repo_name must be specified")
+		assertGRPCStatusCode(t, err, codes.InvalidArgument)
+	})
+
+	t.Run("checks for uncloned repo", func(t *testing.T) {
+		rs, _, b := newServer(t, false)
+		_, err := rs.GarbageCollect(ctx, &proto.GarbageCollectRequest{RepoName: "therepo"})
+		assertGRPCStatusCode(t, err, codes.NotFound)
+		assertHasGRPCErrorDetailOfType(t, err, &proto.RepoNotFoundPayload{})
+		mockassert.NotCalled(t, b.GarbageCollectFunc)
+	})
+
+	t.Run("repo is locked", func(t *testing.T) {
+		rs, _, b := newServer(t, true)
+		lock, ok := rs.locker.TryAcquire("therepo", "fetching")
+		require.True(t, ok)
+		defer lock.Release()
+
+		_, err := rs.GarbageCollect(ctx, &proto.GarbageCollectRequest{RepoName: "therepo"})
+		assertGRPCStatusCode(t, err, codes.Aborted)
+		mockassert.NotCalled(t, b.GarbageCollectFunc)
+	})
+
+	t.Run("gc lock is held", func(t *testing.T) {
+		rs, dir, b := newServer(t, true)
+		require.NoError(t, os.WriteFile(dir.Path(gcLockFile), []byte("1234 otherhost"), 0o644))
+
+		_, err := rs.GarbageCollect(ctx, &proto.GarbageCollectRequest{RepoName: "therepo"})
+		assertGRPCStatusCode(t, err, codes.Aborted)
+		require.ErrorContains(t, err, "process 1234 on machine otherhost is already running a gc operation")
+		mockassert.NotCalled(t, b.GarbageCollectFunc)
+		// The repo lock is released again.
+		_, locked := rs.locker.Status("therepo")
+		require.False(t, locked)
+	})
+
+	t.Run("e2e", func(t *testing.T) {
+		rs, dir, b := newServer(t, true)
+		b.GarbageCollectFunc.SetDefaultHook(func(context.Context) error {
+			// Both locks are held while gc runs.
+			require.FileExists(t, dir.Path(gcLockFile))
+			status, locked := rs.locker.Status("therepo")
+			require.True(t, locked)
+			require.Equal(t, "running git gc", status)
+			return nil
+		})
+
+		cli := spawnRepositoryServer(t, rs)
+		res, err := cli.GarbageCollect(ctx, &proto.GarbageCollectRequest{RepoName: "therepo"})
+		require.NoError(t, err)
+		require.Equal(t, int64(2048), res.GetSizeBefore())
+		require.Equal(t, int64(1024), res.GetSizeAfter())
+		mockassert.CalledOnce(t, b.GarbageCollectFunc)
+		require.NoFileExists(t, dir.Path(gcLockFile))
+	})
+}
+
+func TestRepositoryServiceServer_CheckRepoConsistency(t *testing.T) {
+	conf.Mock(&conf.Unified{
+		ServiceConnectionConfig: conftypes.ServiceConnections{
+			GitServers: []string{"test-gitserver"},
+		},
+	})
+	t.Cleanup(func() { conf.Mock(nil) })
+
+	fs := gitserverfs.NewMockFS()
+	fs.ForEachRepoFunc.SetDefaultHook(func(cb func(api.RepoName, common.GitDir) bool) error {
+		for _, repo := range []string{"github.com/foo/cloned", "github.com/foo/orphan"} {
+			if cb(api.RepoName(repo), common.GitDir("/data/repos/"+repo)) {
+				break
+			}
+		}
+		return nil
+	})
+	gsStore := dbmocks.NewMockGitserverRepoStore()
+	gsStore.IterateRepoGitserverStatusFunc.SetDefaultReturn([]types.RepoGitserverStatus{
+		{ID: 1, Name: "github.com/foo/cloned"},
+		{ID: 2, Name: "github.com/foo/missing"},
+	}, 0, nil)
+	db := dbmocks.NewMockDB()
+	db.GitserverReposFunc.SetDefaultReturn(gsStore)
+
+	rs := &repositoryServiceServer{
+		db:       db,
+		fs:       fs,
+		hostname: "test-gitserver",
+	}
+
+	cli := spawnRepositoryServer(t, rs)
+	resp, err := cli.CheckRepoConsistency(context.Background(), &proto.CheckRepoConsistencyRequest{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"github.com/foo/orphan"}, resp.GetOrphaned())
+	require.Equal(t, []string{"github.com/foo/missing"}, resp.GetMissing())
+}
+
 func TestRepositoryServiceServer_ListRepositories(t *testing.T) {
 	ctx := context.Background()
 
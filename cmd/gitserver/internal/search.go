@@ -39,7 +39,7 @@ var (
 	})
 )
 
-func searchWithObservability(ctx context.Context, logger log.Logger, repoDir common.GitDir, tr trace.Trace, args *protocol.SearchRequest, onMatch func(*protocol.CommitMatch) error) (limitHit bool, err error) {
+func searchWithObservability(ctx context.Context, logger log.Logger, gitBinary string, repoDir common.GitDir, tr trace.Trace, args *protocol.SearchRequest, onMatch func(*protocol.CommitMatch) error) (limitHit bool, err error) {
 	searchStart := time.Now()
 
 	searchRunning.Inc()
@@ -96,12 +96,12 @@ func searchWithObservability(ctx context.Context, logger log.Logger, repoDir com
 		return onMatch(cm)
 	}
 
-	return doSearch(ctx, logger, repoDir, args, onMatchWithLatency)
+	return doSearch(ctx, logger, gitBinary, repoDir, args, onMatchWithLatency)
 }
 
 // doSearch handles the core logic of the search. It is passed a matchesBuf so it doesn't need to
 // concern itself with event types, and all instrumentation is handled in the calling function.
-func doSearch(ctx context.Context, logger log.Logger, repoDir common.GitDir, args *protocol.SearchRequest, onMatch func(*protocol.CommitMatch) error) (limitHit bool, err error) {
+func doSearch(ctx context.Context, logger log.Logger, gitBinary string, repoDir common.GitDir, args *protocol.SearchRequest, onMatch func(*protocol.CommitMatch) error) (limitHit bool, err error) {
 	if args.Limit == 0 {
 		args.Limit = math.MaxInt32
 	}
@@ -138,6 +138,7 @@ func doSearch(ctx context.Context, logger log.Logger, repoDir common.GitDir, arg
 
 	searcher := &search.CommitSearcher{
 		Logger:               logger,
+		GitBinary:            gitBinary,
 		RepoName:             args.Repo,
 		RepoDir:              string(repoDir),
 		Revisions:            args.Revisions,
@@ -14,7 +14,8 @@ import (
 // DiffFetcher is a handle to the stdin and stdout of a git diff-tree subprocess
 // started with StartDiffFetcher
 type DiffFetcher struct {
-	dir string
+	gitBinary string
+	dir       string
 
 	startOnce sync.Once
 	stdin     io.Writer
@@ -24,11 +25,12 @@ type DiffFetcher struct {
 	cmd       *exec.Cmd
 }
 
-// NewDiffFetcher starts a git diff-tree subprocess that waits, listening on stdin
-// for comimt hashes to generate patches for.
-func NewDiffFetcher(dir string) (*DiffFetcher, error) {
+// NewDiffFetcher starts a git diff-tree subprocess of the git executable
+// gitBinary that waits, listening on stdin for comimt hashes to generate
+// patches for.
+func NewDiffFetcher(gitBinary, dir string) (*DiffFetcher, error) {
 
-	return &DiffFetcher{dir: dir}, nil
+	return &DiffFetcher{gitBinary: gitBinary, dir: dir}, nil
 }
 
 func (d *DiffFetcher) Stop() {
@@ -42,7 +44,7 @@ func (d *DiffFetcher) start() (err error) {
 	d.startOnce.Do(func() {
 		ctx := context.Background()
 		ctx, d.cancel = context.WithCancel(ctx)
-		d.cmd = exec.CommandContext(ctx, "git",
+		d.cmd = exec.CommandContext(ctx, d.gitBinary,
 			"diff-tree",
 			"--stdin",          // Read commit hashes from stdin
 			"--no-prefix",      // Do not prefix file names with a/ and b/
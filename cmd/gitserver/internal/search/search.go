@@ -89,6 +89,9 @@ type CommitSearcher struct {
 	IncludeDiff          bool
 	IncludeModifiedFiles bool
 	RepoName             api.RepoName
+
+	// GitBinary is the git executable to run. If empty, "git" is used.
+	GitBinary string
 }
 
 // Search runs a search for commits matching the given predicate across the revisions passed in as revisionArgs.
@@ -143,6 +146,13 @@ func (cs *CommitSearcher) gitArgs() []string {
 	return args
 }
 
+func (cs *CommitSearcher) gitBinary() string {
+	if cs.GitBinary == "" {
+		return "git"
+	}
+	return cs.GitBinary
+}
+
 func revsToGitArgs(revs []string) []string {
 	revArgs := make([]string, 0, len(revs))
 	for _, rev := range revs {
@@ -156,7 +166,7 @@ func revsToGitArgs(revs []string) []string {
 }
 
 func (cs *CommitSearcher) feedBatches(ctx context.Context, jobs chan job, resultChans chan chan *protocol.CommitMatch) (err error) {
-	cmd := exec.CommandContext(ctx, "git", cs.gitArgs()...)
+	cmd := exec.CommandContext(ctx, cs.gitBinary(), cs.gitArgs()...)
 	cmd.Dir = cs.RepoDir
 	stdoutReader, err := cmd.StdoutPipe()
 	if err != nil {
@@ -231,7 +241,7 @@ func getSubRepoFilterFunc(ctx context.Context, checker authz.SubRepoPermissionCh
 
 func (cs *CommitSearcher) runJobs(ctx context.Context, jobs chan job) error {
 	// Create a new diff fetcher subprocess for each worker
-	diffFetcher, err := NewDiffFetcher(cs.RepoDir)
+	diffFetcher, err := NewDiffFetcher(cs.gitBinary(), cs.RepoDir)
 	if err != nil {
 		return err
 	}
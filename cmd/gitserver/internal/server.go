@@ -7,8 +7,10 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -22,6 +24,7 @@ import (
 
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/common"
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/git"
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/git/gitcli"
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/gitserverfs"
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/vcssyncer"
 	"github.com/sourcegraph/sourcegraph/internal/actor"
@@ -32,6 +35,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/fileutil"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/limiter"
+	"github.com/sourcegraph/sourcegraph/internal/observation"
 	"github.com/sourcegraph/sourcegraph/internal/ratelimit"
 	"github.com/sourcegraph/sourcegraph/internal/types"
 	"github.com/sourcegraph/sourcegraph/internal/vcs"
@@ -51,6 +55,9 @@ type ServerOpts struct {
 	// Logger should be used for all logging and logger creation.
 	Logger log.Logger
 
+	// ObservationCtx is used to register the metrics of the server.
+	ObservationCtx *observation.Context
+
 	// FS is the file system to use for the gitserver. It allows to find repos by
 	// name on disk and map a dir on disk back to a repo name.
 	FS gitserverfs.FS
@@ -72,6 +79,12 @@ type ServerOpts struct {
 	// usually set to return a GitRepoSyncer.
 	GetVCSSyncer func(context.Context, api.RepoName) (vcssyncer.VCSSyncer, error)
 
+	// ValidateExternalServiceConfig checks whether repo is cloneable with an
+	// external service config of the given kind that is not necessarily saved
+	// yet. In production this is vcssyncer.ValidateExternalServiceConfig with
+	// the remote URL of repo derived from config.
+	ValidateExternalServiceConfig func(ctx context.Context, repo api.RepoName, kind, config string) vcssyncer.ExternalServiceValidation
+
 	// Hostname is how we identify this instance of gitserver. Generally it is the
 	// actual hostname but can also be overridden by the HOSTNAME environment variable.
 	Hostname string
@@ -90,6 +103,40 @@ type ServerOpts struct {
 	// The factory creates recordable commands with a set predicate, which is used to determine whether a
 	// particular command should be recorded or not.
 	RecordingCommandFactory *wrexec.RecordingCommandFactory
+
+	// GitBinary is the git executable to run, e.g. when creating commits from
+	// patches. If empty, "git" is used.
+	GitBinary string
+
+	// CloneAllowPattern, if set, restricts cloning to repos whose name matches
+	// the pattern.
+	CloneAllowPattern *regexp.Regexp
+
+	// CloneDenyPattern, if set, prevents cloning repos whose name matches the
+	// pattern. It takes precedence over CloneAllowPattern.
+	CloneDenyPattern *regexp.Regexp
+
+	// CloneFromPeers makes the server clone repos from the gitserver peer that
+	// last had them cloned, instead of from the code host, if possible.
+	CloneFromPeers bool
+
+	// CloneRetryPolicy configures how clones that failed with a transient
+	// error are retried. The zero value disables retries.
+	CloneRetryPolicy CloneRetryPolicy
+
+	// MaxRepoSize is the maximum number of bytes a repo may take up on disk
+	// while it is cloned. Clones of larger repos are aborted. Zero disables
+	// the limit.
+	MaxRepoSize int64
+
+	// RepoNotFoundPolicy configures what happens to repos once fetching them
+	// fails because they no longer exist on the code host.
+	RepoNotFoundPolicy RepoNotFoundPolicy
+
+	// DesiredPercentFree is the percentage of disk space we want to keep free.
+	// The number of concurrent clones is reduced as the free disk space
+	// approaches it. Zero disables adapting the clone concurrency.
+	DesiredPercentFree int
 }
 
 func NewServer(opt *ServerOpts) *Server {
@@ -106,27 +153,52 @@ func NewServer(opt *ServerOpts) *Server {
 	maxConcurrentClones := conf.GitMaxConcurrentClones()
 	cloneLimiter := limiter.NewMutable(maxConcurrentClones)
 
+	s := &Server{
+		logger:                        opt.Logger,
+		gitBackendSource:              opt.GitBackendSource,
+		getRemoteURLFunc:              opt.GetRemoteURLFunc,
+		getVCSSyncer:                  opt.GetVCSSyncer,
+		validateExternalServiceConfig: opt.ValidateExternalServiceConfig,
+		hostname:                      opt.Hostname,
+		db:                            opt.DB,
+		locker:                        opt.Locker,
+		rpsLimiter:                    opt.RPSLimiter,
+		recordingCommandFactory:       opt.RecordingCommandFactory,
+		gitBinary:                     opt.GitBinary,
+		fs:                            opt.FS,
+		cloneAllowPattern:             opt.CloneAllowPattern,
+		cloneDenyPattern:              opt.CloneDenyPattern,
+		cloneFromPeers:                opt.CloneFromPeers,
+		cloneRetryPolicy:              opt.CloneRetryPolicy,
+		maxRepoSize:                   opt.MaxRepoSize,
+		repoNotFoundPolicy:            opt.RepoNotFoundPolicy,
+		repoNotFoundCounter:           newRepoNotFoundCounter(opt.ObservationCtx),
+		removedNotFound:               make(map[api.RepoName]time.Time),
+		desiredPercentFree:            opt.DesiredPercentFree,
+
+		cloneLimiter:        cloneLimiter,
+		maxConcurrentClones: maxConcurrentClones,
+		diskPercentFree:     100,
+		ctx:                 ctx,
+		cancel:              cancel,
+	}
+
+	if s.gitBinary == "" {
+		s.gitBinary = gitcli.DefaultGitBinary
+	}
+
 	conf.Watch(func() {
-		limit := conf.GitMaxConcurrentClones()
-		cloneLimiter.SetLimit(limit)
+		s.cloneLimitMu.Lock()
+		s.maxConcurrentClones = conf.GitMaxConcurrentClones()
+		s.cloneLimitMu.Unlock()
+		s.updateCloneLimit()
 	})
 
-	return &Server{
-		logger:                  opt.Logger,
-		gitBackendSource:        opt.GitBackendSource,
-		getRemoteURLFunc:        opt.GetRemoteURLFunc,
-		getVCSSyncer:            opt.GetVCSSyncer,
-		hostname:                opt.Hostname,
-		db:                      opt.DB,
-		locker:                  opt.Locker,
-		rpsLimiter:              opt.RPSLimiter,
-		recordingCommandFactory: opt.RecordingCommandFactory,
-		fs:                      opt.FS,
-
-		cloneLimiter: cloneLimiter,
-		ctx:          ctx,
-		cancel:       cancel,
+	if s.desiredPercentFree > 0 {
+		go s.adaptCloneLimitToDiskPressure()
 	}
+
+	return s
 }
 
 // Server is a gitserver server.
@@ -155,6 +227,10 @@ type Server struct {
 	// usually set to return a GitRepoSyncer.
 	getVCSSyncer func(context.Context, api.RepoName) (vcssyncer.VCSSyncer, error)
 
+	// validateExternalServiceConfig checks whether a repo is cloneable with an
+	// external service config that is not necessarily saved yet.
+	validateExternalServiceConfig func(ctx context.Context, repo api.RepoName, kind, config string) vcssyncer.ExternalServiceValidation
+
 	// hostname is how we identify this instance of gitserver. Generally it is the
 	// actual hostname but can also be overridden by the HOSTNAME environment variable.
 	hostname string
@@ -178,6 +254,16 @@ type Server struct {
 	// clones.
 	cloneLimiter *limiter.MutableLimiter
 
+	// desiredPercentFree is the percentage of disk space we want to keep free.
+	// Zero disables adapting the clone concurrency to disk pressure.
+	desiredPercentFree int
+
+	cloneLimitMu sync.Mutex // protects the fields below
+	// maxConcurrentClones is the configured maximum number of concurrent clones.
+	maxConcurrentClones int
+	// diskPercentFree is the percentage of free disk space as of the last check.
+	diskPercentFree float64
+
 	// rpsLimiter limits the remote code host git operations done per second
 	// per gitserver instance
 	rpsLimiter *ratelimit.InstrumentedLimiter
@@ -186,6 +272,48 @@ type Server struct {
 	// The factory creates recordable commands with a set predicate, which is used to determine whether a
 	// particular command should be recorded or not.
 	recordingCommandFactory *wrexec.RecordingCommandFactory
+
+	// gitBinary is the git executable to run.
+	gitBinary string
+
+	// cloneAllowPattern, if set, restricts cloning to repos whose name matches
+	// the pattern.
+	cloneAllowPattern *regexp.Regexp
+
+	// cloneDenyPattern, if set, prevents cloning repos whose name matches the
+	// pattern.
+	cloneDenyPattern *regexp.Regexp
+
+	// cloneFromPeers makes the server clone repos from the gitserver peer that
+	// last had them cloned, instead of from the code host, if possible.
+	cloneFromPeers bool
+
+	// cloneRetryPolicy configures how clones that failed with a transient
+	// error are retried.
+	cloneRetryPolicy CloneRetryPolicy
+
+	queuedCloneRetriesMu sync.Mutex // protects queuedCloneRetries
+	// queuedCloneRetries holds the repos whose clone is scheduled to be
+	// retried, see maybeRetryClone.
+	queuedCloneRetries map[api.RepoName]struct{}
+
+	// maxRepoSize is the maximum number of bytes a repo may take up on disk
+	// while it is cloned. Zero disables the limit.
+	maxRepoSize int64
+
+	// repoNotFoundPolicy configures what happens to repos once fetching them
+	// fails because they no longer exist on the code host.
+	repoNotFoundPolicy RepoNotFoundPolicy
+
+	// repoNotFoundCounter counts the fetches that failed because the repo no
+	// longer exists on the code host, by the action taken.
+	repoNotFoundCounter *prometheus.CounterVec
+
+	// removedNotFound maps repos that were removed or failed to clone because
+	// they don't exist on the code host to the time until which they are not
+	// cloned again. See RepoNotFoundRemoveAfterGrace.
+	removedNotFoundMu sync.Mutex
+	removedNotFound   map[api.RepoName]time.Time
 }
 
 // Stop cancels the running background jobs and returns when done.
@@ -228,6 +356,80 @@ func (s *Server) serverContext() (context.Context, context.CancelFunc) {
 	}
 }
 
+// diskPressureCheckInterval is how often the clone concurrency is adapted to the
+// free disk space.
+const diskPressureCheckInterval = 30 * time.Second
+
+// adaptCloneLimitToDiskPressure periodically checks the free disk space and
+// adapts the clone concurrency to it, until the server is stopped.
+func (s *Server) adaptCloneLimitToDiskPressure() {
+	ctx, cancel := s.serverContext()
+	defer cancel()
+
+	ticker := time.NewTicker(diskPressureCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.checkDiskPressure()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkDiskPressure records the current free disk space and updates the clone
+// concurrency accordingly.
+func (s *Server) checkDiskPressure() {
+	usage, err := s.fs.DiskUsage()
+	if err != nil {
+		s.logger.Warn("failed to get disk usage, not adapting the clone concurrency", log.Error(err))
+		return
+	}
+
+	s.cloneLimitMu.Lock()
+	s.diskPercentFree = 100 - float64(usage.PercentUsed())
+	s.cloneLimitMu.Unlock()
+
+	s.updateCloneLimit()
+}
+
+// updateCloneLimit sets the limit of concurrent clones based on the configured
+// maximum and the last known free disk space.
+func (s *Server) updateCloneLimit() {
+	s.cloneLimitMu.Lock()
+	defer s.cloneLimitMu.Unlock()
+
+	limit := adaptiveCloneLimit(s.maxConcurrentClones, s.diskPercentFree, s.desiredPercentFree)
+	s.cloneLimiter.SetLimit(limit)
+	cloneLimitGauge.Set(float64(limit))
+}
+
+// adaptiveCloneLimit returns the number of concurrent clones to allow, given the
+// configured maximum and the percentage of free disk space. Once less than
+// twice desiredPercentFree is free, the limit is reduced linearly, down to a
+// single clone once at most desiredPercentFree is free. We keep allowing a
+// single clone, as repo updates are subject to the same limit.
+func adaptiveCloneLimit(maxConcurrentClones int, percentFree float64, desiredPercentFree int) int {
+	if desiredPercentFree <= 0 || maxConcurrentClones <= 1 {
+		return maxConcurrentClones
+	}
+
+	desired := float64(desiredPercentFree)
+	headroom := percentFree - desired
+	if headroom >= desired {
+		return maxConcurrentClones
+	}
+	if headroom <= 0 {
+		return 1
+	}
+
+	limit := int(math.Ceil(float64(maxConcurrentClones) * headroom / desired))
+	return max(1, min(limit, maxConcurrentClones))
+}
+
 func (s *Server) getRemoteURL(ctx context.Context, name api.RepoName) (*vcs.URL, error) {
 	remoteURL, err := s.getRemoteURLFunc(ctx, name)
 	if err != nil {
@@ -265,13 +467,36 @@ func (s *Server) IsRepoCloneable(ctx context.Context, repo api.RepoName) (protoc
 	return resp, nil
 }
 
+// IsRepoCloneableWithExternalServiceConfig is like IsRepoCloneable, but checks
+// whether repo is cloneable with the given external service config instead of
+// the configs of the external services repo belongs to. It allows admins to
+// catch bad code host URLs or credentials before saving a config.
+func (s *Server) IsRepoCloneableWithExternalServiceConfig(ctx context.Context, repo api.RepoName, kind, config string) (protocol.IsRepoCloneableResponse, error) {
+	// See IsRepoCloneable for why we use an internal actor. The config is
+	// provided by the caller, so its credentials are used to contact the code
+	// host.
+	ctx = actor.WithInternalActor(ctx)
+
+	cloned, err := s.fs.RepoCloned(repo)
+	if err != nil {
+		return protocol.IsRepoCloneableResponse{}, errors.Wrap(err, "determine if repo is cloned")
+	}
+
+	v := s.validateExternalServiceConfig(ctx, repo, kind, config)
+	return protocol.IsRepoCloneableResponse{
+		Cloneable: v.Valid,
+		Cloned:    cloned,
+		Reason:    v.Error,
+	}, nil
+}
+
 // FetchRepository triggers an update for the given repo.
 // If the repo is not cloned, a blocking clone will be triggered instead.
 // This function will not return until the update is complete.
 // Canceling the context will not cancel the update, but it will let the caller
 // escape the function early.
 func (s *Server) FetchRepository(ctx context.Context, repoName api.RepoName) (lastFetched, lastChanged time.Time, err error) {
-	err = s.repoUpdateOrClone(ctx, repoName)
+	err = s.repoUpdateOrClone(ctx, repoName, 1)
 	if err != nil {
 		return lastFetched, lastChanged, err
 	}
@@ -291,7 +516,10 @@ func (s *Server) FetchRepository(ctx context.Context, repoName api.RepoName) (la
 	return lastFetched, lastChanged, nil
 }
 
-func (s *Server) repoUpdateOrClone(ctx context.Context, repoName api.RepoName) error {
+// repoUpdateOrClone updates the given repo, or clones it if it doesn't exist
+// yet. attempt is the number of the clone attempt, starting at 1, and is used
+// to retry clones that failed with a transient error.
+func (s *Server) repoUpdateOrClone(ctx context.Context, repoName api.RepoName, attempt int) error {
 	logger := s.logger.Scoped("repoUpdateOrClone")
 
 	lock, ok := s.locker.TryAcquire(repoName, "starting fetch")
@@ -314,6 +542,7 @@ func (s *Server) repoUpdateOrClone(ctx context.Context, repoName api.RepoName) e
 	// We spawn a background job to do the update. This is to keep going when the
 	// caller has already cancelled the context, or when the connection was interrupted.
 	go func() {
+		var cloneErr error
 		errCh <- func() (err error) {
 			defer lock.Release()
 			defer cancelCloneLimiter()
@@ -343,9 +572,18 @@ func (s *Server) repoUpdateOrClone(ctx context.Context, repoName api.RepoName) e
 			}
 
 			if !cloned {
+				if err := s.checkCloneAllowed(repoName); err != nil {
+					logger.Warn("refusing to clone repo", log.String("repo", string(repoName)), log.Error(err))
+					return err
+				}
+				if err := s.checkNotRemovedAsNotFound(repoName); err != nil {
+					return err
+				}
 				if err := s.cloneRepo(ctx, repoName, lock); err != nil {
+					s.rememberCloneNotFound(repoName, err)
 					repoCloneFailedCounter.Inc()
 					logger.Error("error cloning repo", log.String("repo", string(repoName)), log.Error(err))
+					cloneErr = err
 					return errors.Wrapf(err, "failed to clone %s", repoName)
 				}
 				repoClonedCounter.Inc()
@@ -362,6 +600,12 @@ func (s *Server) repoUpdateOrClone(ctx context.Context, repoName api.RepoName) e
 
 			return nil
 		}()
+
+		// Retry only after releasing the lock above, so that the retry can
+		// acquire it.
+		if cloneErr != nil {
+			s.maybeRetryClone(repoName, attempt, cloneErr)
+		}
 	}()
 
 	select {
@@ -382,8 +626,51 @@ func (s *Server) LogIfCorrupt(ctx context.Context, repo api.RepoName, err error)
 	}
 }
 
+// FetchMissingBlobs fetches the blobs below paths of treeish that are missing
+// locally because the repository is a partial clone. It is a no-op for
+// repositories that contain all their objects.
+func (s *Server) FetchMissingBlobs(ctx context.Context, repo api.RepoName, treeish string, paths []string) error {
+	dir := s.fs.RepoDir(repo)
+	if !vcssyncer.IsPartialClone(dir) {
+		return nil
+	}
+
+	syncer, err := s.getVCSSyncer(ctx, repo)
+	if err != nil {
+		return errors.Wrap(err, "get VCS syncer")
+	}
+	bf, ok := syncer.(vcssyncer.BlobFetcher)
+	if !ok {
+		return nil
+	}
+	return bf.FetchMissingBlobs(ctx, repo, dir, treeish, paths)
+}
+
 var ErrFetchInProgress = errors.New("fetch for this repo already in progress")
 
+// ErrCloneNotAllowed is returned when a repo is not cloned because its name is
+// rejected by the configured clone allow/deny patterns.
+type ErrCloneNotAllowed struct {
+	Repo   api.RepoName
+	Reason string
+}
+
+func (e *ErrCloneNotAllowed) Error() string {
+	return fmt.Sprintf("cloning %s is not allowed: %s", e.Repo, e.Reason)
+}
+
+// checkCloneAllowed returns an *ErrCloneNotAllowed if the configured clone
+// allow/deny patterns reject the given repo. The deny pattern takes precedence.
+func (s *Server) checkCloneAllowed(repo api.RepoName) error {
+	if s.cloneDenyPattern != nil && s.cloneDenyPattern.MatchString(string(repo)) {
+		return &ErrCloneNotAllowed{Repo: repo, Reason: fmt.Sprintf("name matches deny pattern %q", s.cloneDenyPattern)}
+	}
+	if s.cloneAllowPattern != nil && !s.cloneAllowPattern.MatchString(string(repo)) {
+		return &ErrCloneNotAllowed{Repo: repo, Reason: fmt.Sprintf("name does not match allow pattern %q", s.cloneAllowPattern)}
+	}
+	return nil
+}
+
 // cloneRepo performs a clone operation for the given repository.
 func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, lock RepositoryLock) (err error) {
 	if isAlwaysCloningTest(repo) {
@@ -402,6 +689,7 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, lock Reposito
 	defer func() {
 		if err != nil {
 			repoCloneFailedCounter.Inc()
+			cloneFailuresCounter.WithLabelValues(string(classifyCloneError(err))).Inc()
 		}
 	}()
 	if err := s.rpsLimiter.Wait(ctx); err != nil {
@@ -429,6 +717,16 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, lock Reposito
 	}
 	defer os.RemoveAll(tmpDir)
 	tmpPath := filepath.Join(tmpDir, ".git")
+	defer common.ForgetNoLazyFetch(common.GitDir(tmpPath))
+
+	// Look up the peer that last had the repo cloned before we claim the repo
+	// for ourselves below.
+	var peer *vcs.URL
+	if s.cloneFromPeers {
+		if gr, err := s.db.GitserverRepos().GetByName(ctx, repo); err == nil {
+			peer, _ = peerCloneURL(repo, gr, s.hostname)
+		}
+	}
 
 	if err := s.db.GitserverRepos().SetCloneStatus(ctx, repo, types.CloneStatusCloning, s.hostname); err != nil {
 		s.logger.Error("Setting clone status in DB", log.Error(err))
@@ -462,8 +760,19 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, lock Reposito
 	cloneTimeout := conf.GitLongCommandTimeout()
 	cloneCtx, cancel := context.WithTimeout(ctx, cloneTimeout)
 	defer cancel()
-
-	cloneErr := syncer.Clone(cloneCtx, repo, dir, tmpPath, progressWriter)
+	cloneCtx, cancelTooLarge := context.WithCancelCause(cloneCtx)
+	defer cancelTooLarge(nil)
+	stopWatchingSize := watchCloneSize(cloneCtx, cancelTooLarge, s.fs, repo, tmpDir, s.maxRepoSize)
+
+	var cloneErr error
+	if s.maybeCloneFromPeer(cloneCtx, logger, repo, syncer, peer, tmpPath, progressWriter) {
+		// The peer might lag behind the code host, so bring the clone up to
+		// date like a regular fetch would.
+		cloneErr = syncer.Fetch(cloneCtx, repo, common.GitDir(tmpPath), progressWriter)
+	} else {
+		cloneErr = syncer.Clone(cloneCtx, repo, dir, tmpPath, progressWriter)
+	}
+	stopWatchingSize()
 	progressWriter.Close()
 
 	if err := eg.Wait(); err != nil {
@@ -475,9 +784,21 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, lock Reposito
 		s.logger.Error("Setting last output in DB", log.Error(err))
 	}
 
+	// The clone might have finished before the size was checked. The temporary
+	// directory is removed when we return.
+	tooLargeErr := context.Cause(cloneCtx)
+	if cloneErr == nil {
+		tooLargeErr = checkCloneSize(s.fs, repo, tmpDir, s.maxRepoSize)
+	}
+	var errTooLarge *ErrRepoTooLarge
+	if errors.As(tooLargeErr, &errTooLarge) {
+		logger.Warn("aborted clone of repo exceeding the maximum repo size", log.Int64("size", errTooLarge.Size), log.Int64("limit", errTooLarge.Limit))
+		return errTooLarge
+	}
+
 	if cloneErr != nil {
 		if errors.Is(cloneCtx.Err(), context.DeadlineExceeded) {
-			return errors.Newf("failed to clone repo within deadline of %s", cloneTimeout)
+			return errors.Wrapf(cloneCtx.Err(), "failed to clone repo within deadline of %s", cloneTimeout)
 		}
 		// TODO: Should we really return the entire output here in an error?
 		// It could be a super big error string.
@@ -489,7 +810,7 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, lock Reposito
 	// and we want this to succeed rather than be super fast.
 	ctx, cancel = context.WithTimeout(ctx, conf.GitLongCommandTimeout())
 	defer cancel()
-	if err := postRepoFetchActions(ctx, logger, s.fs, s.db, s.gitBackendSource(common.GitDir(tmpPath), repo), s.hostname, repo, common.GitDir(tmpPath), syncer); err != nil {
+	if err := postRepoFetchActions(ctx, logger, s.fs, s.db, s.gitBinary, s.gitBackendSource(common.GitDir(tmpPath), repo), s.hostname, repo, common.GitDir(tmpPath), syncer); err != nil {
 		return err
 	}
 
@@ -499,6 +820,7 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, lock Reposito
 	if err := fileutil.RenameAndSync(tmpPath, dstPath); err != nil {
 		return err
 	}
+	common.ForgetNoLazyFetch(dir)
 
 	return nil
 }
@@ -635,6 +957,10 @@ var (
 		Name: "src_gitserver_clone_queue",
 		Help: "number of repos waiting to be cloned.",
 	})
+	cloneLimitGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "src_gitserver_clone_limit",
+		Help: "number of concurrent clones currently allowed, reduced under disk pressure.",
+	})
 	repoClonedCounter = promauto.NewCounter(prometheus.CounterOpts{
 		Name: "src_gitserver_repo_cloned",
 		Help: "number of successful git clones run",
@@ -703,7 +1029,17 @@ func (s *Server) doRepoUpdate(ctx context.Context, repo api.RepoName, lock Repos
 			}
 			// TODO: Should we really return the entire output here in an error?
 			// It could be a super big error string.
-			return errors.Wrapf(err, "failed to fetch repo %q with output %q", repo, output.String())
+			err := errors.Wrapf(fetchErr, "failed to fetch repo %q with output %q", repo, output.String())
+			if err := s.handleRepoNotFound(ctx, logger, repo, dir, err); err != nil {
+				logger.Error("failed to handle repo not found on code host", log.Error(err))
+			}
+			return err
+		}
+
+		if found, err := clearNotFound(dir); err != nil {
+			logger.Warn("failed to clear not found marker", log.Error(err))
+		} else if found {
+			logger.Info("repo exists on code host again, cancelled scheduled removal")
 		}
 
 		// Set a separate timeout for post repo fetch actions, otherwise git commands
@@ -711,7 +1047,7 @@ func (s *Server) doRepoUpdate(ctx context.Context, repo api.RepoName, lock Repos
 		// and we want this to succeed rather than be super fast.
 		ctx, cancel := context.WithTimeout(ctx, conf.GitLongCommandTimeout())
 		defer cancel()
-		return postRepoFetchActions(ctx, logger, s.fs, s.db, s.gitBackendSource(dir, repo), s.hostname, repo, dir, syncer)
+		return postRepoFetchActions(ctx, logger, s.fs, s.db, s.gitBinary, s.gitBackendSource(dir, repo), s.hostname, repo, dir, syncer)
 	}(ctx)
 
 	if errors.Is(err, context.DeadlineExceeded) {
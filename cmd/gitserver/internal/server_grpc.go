@@ -26,6 +26,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/grpc/chunk"
 	"github.com/sourcegraph/sourcegraph/internal/grpc/streamio"
 	"github.com/sourcegraph/sourcegraph/internal/trace"
+	"github.com/sourcegraph/sourcegraph/internal/types"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
@@ -33,8 +34,11 @@ type service interface {
 	CreateCommitFromPatch(ctx context.Context, req protocol.CreateCommitFromPatchRequest, patchReader io.Reader) protocol.CreateCommitFromPatchResponse
 	LogIfCorrupt(context.Context, api.RepoName, error)
 	IsRepoCloneable(ctx context.Context, repo api.RepoName) (protocol.IsRepoCloneableResponse, error)
+	IsRepoCloneableWithExternalServiceConfig(ctx context.Context, repo api.RepoName, kind, config string) (protocol.IsRepoCloneableResponse, error)
 	FetchRepository(ctx context.Context, repo api.RepoName) (lastFetched, lastChanged time.Time, err error)
 	EnsureRevision(ctx context.Context, repo api.RepoName, rev string) (didUpdate bool)
+	FetchMissingBlobs(ctx context.Context, repo api.RepoName, treeish string, paths []string) error
+	CloneQueued(repo api.RepoName) bool
 }
 
 type GRPCServerConfig struct {
@@ -50,6 +54,7 @@ func NewGRPCServer(server *Server, config *GRPCServerConfig) proto.GitserverServ
 		gitBackendSource: server.gitBackendSource,
 		svc:              server,
 		fs:               server.fs,
+		gitBinary:        server.gitBinary,
 	}
 
 	if config.ExhaustiveRequestLoggingEnabled {
@@ -72,6 +77,7 @@ type grpcServer struct {
 	gitBackendSource git.GitBackendSource
 	fs               gitserverfs.FS
 	svc              service
+	gitBinary        string
 
 	proto.UnimplementedGitserverServiceServer
 }
@@ -182,6 +188,13 @@ func (gs *grpcServer) Archive(req *proto.ArchiveRequest, ss proto.GitserverServi
 	ctx, cancel := context.WithTimeout(ctx, conf.GitLongCommandTimeout())
 	defer cancel()
 
+	// For partial clones, make sure all the file contents we need are present
+	// before we start streaming the archive. If this fails, we still try to
+	// create the archive, git will report any blob that is still missing.
+	if err := gs.svc.FetchMissingBlobs(ctx, repoName, req.GetTreeish(), byteSlicesToStrings(req.GetPaths())); err != nil {
+		gs.logger.Warn("failed to fetch missing blobs", log.String("repo", string(repoName)), log.Error(err))
+	}
+
 	backend := gs.gitBackendSource(repoDir, repoName)
 
 	r, err := backend.ArchiveReader(ctx, format, req.GetTreeish(), byteSlicesToStrings(req.GetPaths()))
@@ -198,6 +211,11 @@ func (gs *grpcServer) Archive(req *proto.ArchiveRequest, ss proto.GitserverServi
 			return s.Err()
 		}
 
+		var symlinkErr *git.OutOfTreeSymlinkError
+		if errors.As(err, &symlinkErr) {
+			return status.Error(codes.FailedPrecondition, symlinkErr.Error())
+		}
+
 		gs.svc.LogIfCorrupt(ctx, repoName, err)
 		return err
 	}
@@ -306,7 +324,7 @@ func (gs *grpcServer) Search(req *proto.SearchRequest, ss proto.GitserverService
 	tr, ctx := trace.New(ss.Context(), "search")
 	defer tr.End()
 
-	limitHit, err := searchWithObservability(ctx, gs.logger, gs.fs.RepoDir(args.Repo), tr, args, onMatch)
+	limitHit, err := searchWithObservability(ctx, gs.logger, gs.gitBinary, gs.fs.RepoDir(args.Repo), tr, args, onMatch)
 	if err != nil {
 		return err
 	}
@@ -333,6 +351,34 @@ func (gs *grpcServer) RepoCloneProgress(_ context.Context, req *proto.RepoCloneP
 	return progress.ToProto(), nil
 }
 
+func (gs *grpcServer) ReposExist(_ context.Context, req *proto.ReposExistRequest) (*proto.ReposExistResponse, error) {
+	repos := make([]api.RepoName, 0, len(req.GetRepoNames()))
+	for _, name := range req.GetRepoNames() {
+		if name == "" {
+			return nil, status.New(codes.InvalidArgument, "repo names must not be empty").Err()
+		}
+		repos = append(repos, api.RepoName(name))
+	}
+
+	statuses, err := repoCloneStatuses(gs.fs, gs.locker, repos)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &proto.ReposExistResponse{
+		Statuses: make(map[string]proto.CloneStatus, len(statuses)),
+	}
+	for repo, cloneStatus := range statuses {
+		// A repo whose clone failed and is queued to be retried isn't locked
+		// while it waits for the retry, but is still on its way to be cloned.
+		if cloneStatus == types.CloneStatusNotCloned && gs.svc.CloneQueued(repo) {
+			cloneStatus = types.CloneStatusCloning
+		}
+		resp.Statuses[string(repo)] = protocol.CloneStatusToProto(cloneStatus)
+	}
+	return resp, nil
+}
+
 func (gs *grpcServer) IsRepoCloneable(ctx context.Context, req *proto.IsRepoCloneableRequest) (*proto.IsRepoCloneableResponse, error) {
 	repo := api.RepoName(req.GetRepo())
 
@@ -340,7 +386,16 @@ func (gs *grpcServer) IsRepoCloneable(ctx context.Context, req *proto.IsRepoClon
 		return nil, status.Error(codes.InvalidArgument, "no Repo given")
 	}
 
-	resp, err := gs.svc.IsRepoCloneable(ctx, repo)
+	var resp protocol.IsRepoCloneableResponse
+	var err error
+	if extsvc := req.GetExternalService(); extsvc != nil {
+		if extsvc.GetKind() == "" {
+			return nil, status.Error(codes.InvalidArgument, "no ExternalService.Kind given")
+		}
+		resp, err = gs.svc.IsRepoCloneableWithExternalServiceConfig(ctx, repo, extsvc.GetKind(), extsvc.GetConfig())
+	} else {
+		resp, err = gs.svc.IsRepoCloneable(ctx, repo)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -906,6 +961,11 @@ func (gs *grpcServer) ReadFile(req *proto.ReadFileRequest, ss proto.GitserverSer
 		return err
 	}
 
+	// For partial clones, the file contents might not have been fetched yet.
+	if err := gs.svc.FetchMissingBlobs(ctx, repoName, req.GetCommit(), []string{string(req.GetPath())}); err != nil {
+		gs.logger.Warn("failed to fetch missing blobs", log.String("repo", string(repoName)), log.Error(err))
+	}
+
 	backend := gs.gitBackendSource(repoDir, repoName)
 
 	r, err := backend.ReadFile(ctx, api.CommitID(req.GetCommit()), string(req.GetPath()))
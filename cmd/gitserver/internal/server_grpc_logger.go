@@ -198,6 +198,8 @@ func (l *loggingGRPCServer) IsRepoCloneable(ctx context.Context, request *proto.
 func isRepoCloneableRequestToLogFields(req *proto.IsRepoCloneableRequest) []log.Field {
 	return []log.Field{
 		log.String("repo", req.GetRepo()),
+		// The config is omitted since it contains credentials.
+		log.String("externalServiceKind", req.GetExternalService().GetKind()),
 	}
 }
 func (l *loggingGRPCServer) ListGitolite(ctx context.Context, request *proto.ListGitoliteRequest) (response *proto.ListGitoliteResponse, err error) {
@@ -406,6 +408,34 @@ func repoCloneProgressRequest(req *proto.RepoCloneProgressRequest) []log.Field {
 	}
 }
 
+func (l *loggingGRPCServer) ReposExist(ctx context.Context, request *proto.ReposExistRequest) (response *proto.ReposExistResponse, err error) {
+	start := time.Now()
+
+	defer func() {
+		elapsed := time.Since(start)
+
+		doLog(
+			l.logger,
+			proto.GitserverService_ReposExist_FullMethodName,
+			status.Code(err),
+			trace.Context(ctx).TraceID,
+			elapsed,
+
+			reposExistRequestToLogFields(request)...,
+		)
+
+	}()
+
+	return l.base.ReposExist(ctx, request)
+}
+
+func reposExistRequestToLogFields(req *proto.ReposExistRequest) []log.Field {
+	// Requests can contain many repos, so only log how many were requested.
+	return []log.Field{
+		log.Int("repoNamesCount", len(req.GetRepoNames())),
+	}
+}
+
 func (l *loggingGRPCServer) IsPerforcePathCloneable(ctx context.Context, request *proto.IsPerforcePathCloneableRequest) (response *proto.IsPerforcePathCloneableResponse, err error) {
 	start := time.Now()
 
@@ -1155,6 +1185,52 @@ func fetchRepositoryRequestToLogFields(req *proto.FetchRepositoryRequest) []log.
 	}
 }
 
+func (l *loggingRepositoryServiceServer) GarbageCollect(ctx context.Context, request *proto.GarbageCollectRequest) (resp *proto.GarbageCollectResponse, err error) {
+	start := time.Now()
+
+	defer func() {
+		elapsed := time.Since(start)
+
+		doLog(
+			l.logger,
+
+			proto.GitserverRepositoryService_GarbageCollect_FullMethodName,
+			status.Code(err),
+			trace.Context(ctx).TraceID,
+			elapsed,
+
+			garbageCollectRequestToLogFields(request)...,
+		)
+	}()
+
+	return l.base.GarbageCollect(ctx, request)
+}
+
+func garbageCollectRequestToLogFields(req *proto.GarbageCollectRequest) []log.Field {
+	return []log.Field{
+		log.String("repoName", req.GetRepoName()),
+	}
+}
+
+func (l *loggingRepositoryServiceServer) CheckRepoConsistency(ctx context.Context, request *proto.CheckRepoConsistencyRequest) (resp *proto.CheckRepoConsistencyResponse, err error) {
+	start := time.Now()
+
+	defer func() {
+		elapsed := time.Since(start)
+
+		doLog(
+			l.logger,
+
+			proto.GitserverRepositoryService_CheckRepoConsistency_FullMethodName,
+			status.Code(err),
+			trace.Context(ctx).TraceID,
+			elapsed,
+		)
+	}()
+
+	return l.base.CheckRepoConsistency(ctx, request)
+}
+
 func (l *loggingRepositoryServiceServer) ListRepositories(ctx context.Context, request *proto.ListRepositoriesRequest) (resp *proto.ListRepositoriesResponse, err error) {
 	start := time.Now()
 
@@ -32,6 +32,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/fileutil"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/gitdomain"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	proto "github.com/sourcegraph/sourcegraph/internal/gitserver/v1"
 	v1 "github.com/sourcegraph/sourcegraph/internal/gitserver/v1"
 	internalgrpc "github.com/sourcegraph/sourcegraph/internal/grpc"
@@ -1570,6 +1571,101 @@ func TestGRPCServer_CommitLog(t *testing.T) {
 	})
 }
 
+func TestGRPCServer_IsRepoCloneable(t *testing.T) {
+	ctx := context.Background()
+	t.Run("argument validation", func(t *testing.T) {
+		gs := &grpcServer{}
+		_, err := gs.IsRepoCloneable(ctx, &v1.IsRepoCloneableRequest{Repo: ""})
+		require.ErrorContains(t, err, "no Repo given")
+		assertGRPCStatusCode(t, err, codes.InvalidArgument)
+		_, err = gs.IsRepoCloneable(ctx, &v1.IsRepoCloneableRequest{Repo: "therepo", ExternalService: &v1.ExternalServiceConfig{}})
+		require.ErrorContains(t, err, "no ExternalService.Kind given")
+		assertGRPCStatusCode(t, err, codes.InvalidArgument)
+	})
+	t.Run("e2e", func(t *testing.T) {
+		svc := NewMockService()
+		svc.IsRepoCloneableFunc.SetDefaultReturn(protocol.IsRepoCloneableResponse{Cloneable: true, Cloned: true}, nil)
+		svc.IsRepoCloneableWithExternalServiceConfigFunc.SetDefaultReturn(protocol.IsRepoCloneableResponse{Cloned: true, Reason: "bad credentials"}, nil)
+		gs := &grpcServer{svc: svc}
+
+		cli := spawnServer(t, gs)
+		res, err := cli.IsRepoCloneable(ctx, &v1.IsRepoCloneableRequest{Repo: "therepo"})
+		require.NoError(t, err)
+		if diff := cmp.Diff(&proto.IsRepoCloneableResponse{
+			Cloneable: true,
+			Cloned:    true,
+		}, res, protocmp.Transform()); diff != "" {
+			t.Fatalf("unexpected response (-want +got):\n%s", diff)
+		}
+		mockrequire.CalledOnceWith(t, svc.IsRepoCloneableFunc, mockassert.Values(mockassert.Skip, api.RepoName("therepo")))
+
+		// With an external service config, the repo is checked against that
+		// config instead.
+		res, err = cli.IsRepoCloneable(ctx, &v1.IsRepoCloneableRequest{
+			Repo: "therepo",
+			ExternalService: &v1.ExternalServiceConfig{
+				Kind:   "GITHUB",
+				Config: `{"url": "https://github.com", "token": "bad"}`,
+			},
+		})
+		require.NoError(t, err)
+		if diff := cmp.Diff(&proto.IsRepoCloneableResponse{
+			Cloned: true,
+			Reason: "bad credentials",
+		}, res, protocmp.Transform()); diff != "" {
+			t.Fatalf("unexpected response (-want +got):\n%s", diff)
+		}
+		mockrequire.CalledOnceWith(t, svc.IsRepoCloneableWithExternalServiceConfigFunc, mockassert.Values(mockassert.Skip, api.RepoName("therepo"), "GITHUB", `{"url": "https://github.com", "token": "bad"}`))
+		mockrequire.CalledOnce(t, svc.IsRepoCloneableFunc)
+	})
+}
+
+func TestGRPCServer_ReposExist(t *testing.T) {
+	ctx := context.Background()
+	t.Run("argument validation", func(t *testing.T) {
+		gs := &grpcServer{}
+		_, err := gs.ReposExist(ctx, &v1.ReposExistRequest{RepoNames: []string{"therepo", ""}})
+		require.ErrorContains(t, err, "repo names must not be empty")
+		assertGRPCStatusCode(t, err, codes.InvalidArgument)
+	})
+	t.Run("e2e", func(t *testing.T) {
+		fs := gitserverfs.NewMockFS()
+		fs.RepoClonedFunc.SetDefaultHook(func(repo api.RepoName) (bool, error) {
+			return repo == "cloned", nil
+		})
+		locker := NewMockRepositoryLocker()
+		locker.StatusFunc.SetDefaultHook(func(repo api.RepoName) (string, bool) {
+			if repo == "cloning" {
+				return "cloning", true
+			}
+			return "", false
+		})
+		// Checking for existence must not enqueue any repos for cloning, so
+		// we only expect svc to be asked about queued clone retries.
+		svc := NewStrictMockService()
+		svc.CloneQueuedFunc.SetDefaultHook(func(repo api.RepoName) bool {
+			return repo == "retrying"
+		})
+		gs := &grpcServer{svc: svc, fs: fs, locker: locker}
+
+		cli := spawnServer(t, gs)
+		res, err := cli.ReposExist(ctx, &v1.ReposExistRequest{
+			RepoNames: []string{"cloned", "cloning", "retrying", "absent"},
+		})
+		require.NoError(t, err)
+		if diff := cmp.Diff(&proto.ReposExistResponse{
+			Statuses: map[string]proto.CloneStatus{
+				"cloned":   proto.CloneStatus_CLONE_STATUS_CLONED,
+				"cloning":  proto.CloneStatus_CLONE_STATUS_CLONING,
+				"retrying": proto.CloneStatus_CLONE_STATUS_CLONING,
+				"absent":   proto.CloneStatus_CLONE_STATUS_NOT_CLONED,
+			},
+		}, res, protocmp.Transform()); diff != "" {
+			t.Fatalf("unexpected response (-want +got):\n%s", diff)
+		}
+	})
+}
+
 func assertGRPCStatusCode(t *testing.T, err error, want codes.Code) {
 	t.Helper()
 	s, ok := status.FromError(err)
@@ -7,7 +7,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/sourcegraph/sourcegraph/internal/actor"
 
@@ -25,9 +29,11 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/gitserverfs"
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/vcssyncer"
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbmocks"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
+	"github.com/sourcegraph/sourcegraph/internal/diskusage"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/connection"
 	"github.com/sourcegraph/sourcegraph/internal/limiter"
 	"github.com/sourcegraph/sourcegraph/internal/observation"
@@ -81,10 +87,11 @@ func makeTestServer(ctx context.Context, t *testing.T, repoDir, remote string, d
 	fs := gitserverfs.New(obctx, repoDir)
 	require.NoError(t, fs.Initialize())
 	s := NewServer(&ServerOpts{
-		Logger: logger,
-		FS:     fs,
+		Logger:         logger,
+		ObservationCtx: obctx,
+		FS:             fs,
 		GitBackendSource: func(dir common.GitDir, repoName api.RepoName) git.GitBackend {
-			return gitcli.NewBackend(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), dir, repoName)
+			return gitcli.NewBackend(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), gitcli.DefaultGitBinary, dir, repoName)
 		},
 		GetRemoteURLFunc: getRemoteURLFunc,
 		GetVCSSyncer: func(ctx context.Context, name api.RepoName) (vcssyncer.VCSSyncer, error) {
@@ -105,7 +112,7 @@ func makeTestServer(ctx context.Context, t *testing.T, repoDir, remote string, d
 			}
 
 			return vcssyncer.NewGitRepoSyncer(logtest.Scoped(t), wrexec.
-				NewNoOpRecordingCommandFactory(), getRemoteURLSource), nil
+				NewNoOpRecordingCommandFactory(), "", getRemoteURLSource), nil
 		},
 		DB:                      db,
 		Locker:                  NewRepositoryLocker(),
@@ -257,6 +264,7 @@ func TestCloneRepoRecordsFailures(t *testing.T) {
 
 var ignoreVolatileGitserverRepoFields = cmpopts.IgnoreFields(
 	types.GitserverRepo{},
+	"LastErrorAt",
 	"LastFetched",
 	"LastChanged",
 	"RepoSizeBytes",
@@ -305,7 +313,7 @@ func TestHandleRepoUpdate(t *testing.T) {
 		return fakeURL, nil
 	}
 	s.getVCSSyncer = func(ctx context.Context, name api.RepoName) (vcssyncer.VCSSyncer, error) {
-		return vcssyncer.NewGitRepoSyncer(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), func(ctx context.Context, name api.RepoName) (vcssyncer.RemoteURLSource, error) {
+		return vcssyncer.NewGitRepoSyncer(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), "", func(ctx context.Context, name api.RepoName) (vcssyncer.RemoteURLSource, error) {
 			return vcssyncer.RemoteURLSourceFunc(func(ctx context.Context) (*vcs.URL, error) {
 				u, err := vcs.ParseURL(fakeURL)
 				if err != nil {
@@ -335,7 +343,7 @@ func TestHandleRepoUpdate(t *testing.T) {
 	}
 
 	// We don't care exactly what the error is here
-	cmpIgnored := cmpopts.IgnoreFields(types.GitserverRepo{}, "LastFetched", "LastChanged", "RepoSizeBytes", "UpdatedAt", "LastError", "CorruptionLogs")
+	cmpIgnored := cmpopts.IgnoreFields(types.GitserverRepo{}, "LastFetched", "LastChanged", "RepoSizeBytes", "UpdatedAt", "LastError", "LastErrorAt", "CorruptionLogs")
 	// But we do care that it exists
 	if fromDB.LastError == "" {
 		t.Errorf("Expected an error when trying to clone from an invalid URL")
@@ -397,6 +405,10 @@ func TestHandleRepoUpdate(t *testing.T) {
 	if diff := cmp.Diff(want, fromDB, ignoreVolatileGitserverRepoFields); diff != "" {
 		t.Fatal(diff)
 	}
+	// And we expect to know when it happened
+	if fromDB.LastErrorAt.IsZero() {
+		t.Fatal("Expected the time of the failed fetch to be recorded")
+	}
 
 	// Now we'll call again and with an update that succeeds
 	doBackgroundRepoUpdateMock = nil
@@ -423,6 +435,10 @@ func TestHandleRepoUpdate(t *testing.T) {
 	if diff := cmp.Diff(want, fromDB, ignoreVolatileGitserverRepoFields); diff != "" {
 		t.Fatal(diff)
 	}
+	// And the error to be cleared
+	if !fromDB.LastErrorAt.IsZero() {
+		t.Fatal("Expected the time of the failed fetch to be cleared after a successful fetch")
+	}
 }
 
 func TestCloneRepo_EnsureValidity(t *testing.T) {
@@ -536,6 +552,327 @@ func TestCloneRepo_EnsureValidity(t *testing.T) {
 	})
 }
 
+func TestFetchRepository_CloneAllowDenyPatterns(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	remote := t.TempDir()
+	_ = makeSingleCommitRepo(func(name string, arg ...string) string {
+		t.Helper()
+		return runCmd(t, remote, name, arg...)
+	})
+
+	gsStore := dbmocks.NewMockGitserverRepoStore()
+	db := dbmocks.NewMockDB()
+	db.GitserverReposFunc.SetDefaultReturn(gsStore)
+	db.FeatureFlagsFunc.SetDefaultReturn(dbmocks.NewMockFeatureFlagStore())
+
+	s := makeTestServer(ctx, t, t.TempDir(), remote, db)
+	s.cloneAllowPattern = regexp.MustCompile(`^example\.com/`)
+	s.cloneDenyPattern = regexp.MustCompile(`/huge-monorepo$`)
+
+	t.Run("denied repo is rejected", func(t *testing.T) {
+		repoName := api.RepoName("example.com/foo/huge-monorepo")
+		_, _, err := s.FetchRepository(ctx, repoName)
+		var notAllowed *ErrCloneNotAllowed
+		require.ErrorAs(t, err, &notAllowed)
+		require.Equal(t, repoName, notAllowed.Repo)
+
+		cloned, err := s.fs.RepoCloned(repoName)
+		require.NoError(t, err)
+		require.False(t, cloned)
+
+		// The rejection is recorded as the last error of the repo.
+		calls := gsStore.SetLastErrorFunc.History()
+		require.NotEmpty(t, calls)
+		last := calls[len(calls)-1]
+		require.Equal(t, repoName, last.Arg1)
+		require.Contains(t, last.Arg2, "matches deny pattern")
+	})
+
+	t.Run("repo not matching the allow pattern is rejected", func(t *testing.T) {
+		_, _, err := s.FetchRepository(ctx, "github.com/foo/bar")
+		var notAllowed *ErrCloneNotAllowed
+		require.ErrorAs(t, err, &notAllowed)
+	})
+
+	t.Run("allowed repo is cloned", func(t *testing.T) {
+		repoName := api.RepoName("example.com/foo/bar")
+		_, _, err := s.FetchRepository(ctx, repoName)
+		require.NoError(t, err)
+
+		cloned, err := s.fs.RepoCloned(repoName)
+		require.NoError(t, err)
+		require.True(t, cloned)
+	})
+}
+
+func TestFetchRepository_CloneRetry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := makeTestServer(ctx, t, t.TempDir(), "", nil)
+	s.cloneRetryPolicy = CloneRetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+
+	failingSyncer := func(output string) *vcssyncer.MockVCSSyncer {
+		m := vcssyncer.NewMockVCSSyncer()
+		m.CloneFunc.SetDefaultHook(func(_ context.Context, _ api.RepoName, _ common.GitDir, _ string, w io.Writer) error {
+			_, err := fmt.Fprint(w, output)
+			require.NoError(t, err)
+			return &exec.ExitError{ProcessState: &os.ProcessState{}}
+		})
+		return m
+	}
+
+	t.Run("transient failure is retried", func(t *testing.T) {
+		m := failingSyncer("fatal: unable to access 'https://example.com/foo/bar/': Could not resolve host: example.com")
+		s.getVCSSyncer = func(context.Context, api.RepoName) (vcssyncer.VCSSyncer, error) { return m, nil }
+
+		_, _, err := s.FetchRepository(ctx, "example.com/foo/transient")
+		require.Error(t, err)
+
+		require.Eventually(t, func() bool {
+			return len(m.CloneFunc.History()) == 3
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// No more attempts than configured.
+		time.Sleep(50 * time.Millisecond)
+		require.Len(t, m.CloneFunc.History(), 3)
+	})
+
+	t.Run("permanent failure is not retried", func(t *testing.T) {
+		m := failingSyncer("remote: Repository not found.\nfatal: repository 'https://example.com/foo/bar/' not found")
+		s.getVCSSyncer = func(context.Context, api.RepoName) (vcssyncer.VCSSyncer, error) { return m, nil }
+
+		_, _, err := s.FetchRepository(ctx, "example.com/foo/permanent")
+		require.Error(t, err)
+
+		time.Sleep(50 * time.Millisecond)
+		require.Len(t, m.CloneFunc.History(), 1)
+		require.False(t, s.CloneQueued("example.com/foo/permanent"))
+	})
+
+	t.Run("retry is queued until it runs", func(t *testing.T) {
+		m := failingSyncer("fatal: unable to access 'https://example.com/foo/bar/': Could not resolve host: example.com")
+		s.getVCSSyncer = func(context.Context, api.RepoName) (vcssyncer.VCSSyncer, error) { return m, nil }
+		s.cloneRetryPolicy = CloneRetryPolicy{MaxAttempts: 2, Backoff: time.Hour}
+
+		_, _, err := s.FetchRepository(ctx, "example.com/foo/queued")
+		require.Error(t, err)
+
+		require.Eventually(t, func() bool {
+			return s.CloneQueued("example.com/foo/queued")
+		}, 5*time.Second, 10*time.Millisecond)
+		require.False(t, s.CloneQueued("example.com/foo/transient"))
+	})
+}
+
+func TestFetchRepository_MaxRepoSize(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	orig := cloneSizeCheckInterval
+	cloneSizeCheckInterval = 10 * time.Millisecond
+	t.Cleanup(func() { cloneSizeCheckInterval = orig })
+
+	gsStore := dbmocks.NewMockGitserverRepoStore()
+	db := dbmocks.NewMockDB()
+	db.GitserverReposFunc.SetDefaultReturn(gsStore)
+	db.FeatureFlagsFunc.SetDefaultReturn(dbmocks.NewMockFeatureFlagStore())
+
+	reposDir := t.TempDir()
+	s := makeTestServer(ctx, t, reposDir, "", db)
+	s.maxRepoSize = 1024
+
+	// hugeSyncer writes more than maxRepoSize into the clone. If block is set,
+	// the clone only finishes once it is canceled, like a clone that keeps
+	// writing to disk.
+	hugeSyncer := func(block bool) *vcssyncer.MockVCSSyncer {
+		m := vcssyncer.NewMockVCSSyncer()
+		m.CloneFunc.SetDefaultHook(func(ctx context.Context, _ api.RepoName, _ common.GitDir, tmpPath string, _ io.Writer) error {
+			if err := os.MkdirAll(tmpPath, os.ModePerm); err != nil {
+				return err
+			}
+			if err := os.WriteFile(filepath.Join(tmpPath, "pack"), make([]byte, 4096), 0o644); err != nil {
+				return err
+			}
+			if !block {
+				return nil
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		return m
+	}
+
+	for _, tc := range []struct {
+		name  string
+		block bool
+	}{
+		{name: "clone in progress is aborted", block: true},
+		{name: "finished clone is discarded", block: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			repoName := api.RepoName("example.com/foo/" + strings.ReplaceAll(tc.name, " ", "-"))
+			m := hugeSyncer(tc.block)
+			s.getVCSSyncer = func(context.Context, api.RepoName) (vcssyncer.VCSSyncer, error) { return m, nil }
+
+			_, _, err := s.FetchRepository(ctx, repoName)
+			var tooLarge *ErrRepoTooLarge
+			require.ErrorAs(t, err, &tooLarge)
+			require.Equal(t, repoName, tooLarge.Repo)
+			require.Equal(t, int64(1024), tooLarge.Limit)
+			require.Equal(t, cloneFailureTooLarge, classifyCloneError(err))
+
+			// The clone is cleaned up.
+			cloned, err := s.fs.RepoCloned(repoName)
+			require.NoError(t, err)
+			require.False(t, cloned)
+			tmpDirs, err := os.ReadDir(filepath.Join(reposDir, ".tmp"))
+			require.NoError(t, err)
+			require.Empty(t, tmpDirs)
+
+			// The repo is marked as too large.
+			calls := gsStore.SetLastErrorFunc.History()
+			require.NotEmpty(t, calls)
+			last := calls[len(calls)-1]
+			require.Equal(t, repoName, last.Arg1)
+			require.Contains(t, last.Arg2, "is too large")
+		})
+	}
+}
+
+func TestFetchRepository_RepoNotFound(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gsStore := dbmocks.NewMockGitserverRepoStore()
+	repoStore := dbmocks.NewMockRepoStore()
+	db := dbmocks.NewMockDB()
+	db.GitserverReposFunc.SetDefaultReturn(gsStore)
+	db.ReposFunc.SetDefaultReturn(repoStore)
+	db.FeatureFlagsFunc.SetDefaultReturn(dbmocks.NewMockFeatureFlagStore())
+
+	s := makeTestServer(ctx, t, t.TempDir(), "", db)
+
+	notFound := vcssyncer.NewMockVCSSyncer()
+	notFound.FetchFunc.SetDefaultReturn(errors.New("remote: Repository not found.\nfatal: repository 'https://example.com/foo/bar/' not found"))
+	notFound.IsCloneableFunc.SetDefaultReturn(errors.New("remote: Repository not found.\nfatal: repository 'https://example.com/foo/bar/' not found"))
+	s.getVCSSyncer = func(context.Context, api.RepoName) (vcssyncer.VCSSyncer, error) { return notFound, nil }
+
+	setup := func(t *testing.T, policy RepoNotFoundPolicy) (api.RepoName, common.GitDir) {
+		t.Helper()
+		s.repoNotFoundPolicy = policy
+		repoName := api.RepoName("example.com/foo/" + strings.ReplaceAll(t.Name(), "/", "-"))
+		dir := s.fs.RepoDir(repoName)
+		out, err := exec.Command("git", "init", "--bare", dir.Path()).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return repoName, dir
+	}
+	cloned := func(t *testing.T, repoName api.RepoName) bool {
+		t.Helper()
+		cloned, err := s.fs.RepoCloned(repoName)
+		require.NoError(t, err)
+		return cloned
+	}
+
+	t.Run("retain", func(t *testing.T) {
+		repoName, dir := setup(t, RepoNotFoundPolicy{Action: RepoNotFoundRetain})
+
+		_, _, err := s.FetchRepository(ctx, repoName)
+		require.Error(t, err)
+		require.Equal(t, cloneFailureNotFound, classifyCloneError(err))
+
+		require.True(t, cloned(t, repoName))
+		since, err := getNotFoundSince(dir)
+		require.NoError(t, err)
+		require.True(t, since.IsZero())
+		require.Empty(t, repoStore.DeleteFunc.History())
+	})
+
+	t.Run("remove-after-grace", func(t *testing.T) {
+		repoName, dir := setup(t, RepoNotFoundPolicy{Action: RepoNotFoundRemoveAfterGrace, GracePeriod: time.Hour})
+
+		// The first fetch that fails schedules the removal.
+		_, _, _ = s.FetchRepository(ctx, repoName)
+		require.True(t, cloned(t, repoName))
+		since, err := getNotFoundSince(dir)
+		require.NoError(t, err)
+		require.False(t, since.IsZero())
+
+		// Once the grace period passed, the repo is removed.
+		require.NoError(t, setNotFoundSince(dir, time.Now().Add(-2*time.Hour)))
+		_, _, _ = s.FetchRepository(ctx, repoName)
+		require.False(t, cloned(t, repoName))
+
+		// The clone status is left alone and the repo isn't cloned again.
+		for _, call := range gsStore.SetCloneStatusFunc.History() {
+			require.NotEqual(t, repoName, call.Arg1)
+		}
+		_, _, err = s.FetchRepository(ctx, repoName)
+		var notAllowed *ErrCloneNotAllowed
+		require.ErrorAs(t, err, &notAllowed)
+		require.False(t, cloned(t, repoName))
+	})
+
+	t.Run("not confirmed by the code host", func(t *testing.T) {
+		repoName, dir := setup(t, RepoNotFoundPolicy{Action: RepoNotFoundRemoveAfterGrace, GracePeriod: time.Hour})
+		require.NoError(t, setNotFoundSince(dir, time.Now().Add(-2*time.Hour)))
+		notFound.IsCloneableFunc.SetDefaultReturn(errors.New("remote: Invalid username or password.\nfatal: Authentication failed"))
+		t.Cleanup(func() {
+			notFound.IsCloneableFunc.SetDefaultReturn(errors.New("remote: Repository not found.\nfatal: repository 'https://example.com/foo/bar/' not found"))
+		})
+
+		_, _, _ = s.FetchRepository(ctx, repoName)
+
+		require.True(t, cloned(t, repoName))
+	})
+
+	t.Run("authentication failure", func(t *testing.T) {
+		repoName, dir := setup(t, RepoNotFoundPolicy{Action: RepoNotFoundRemoveAfterGrace, GracePeriod: time.Hour})
+		require.NoError(t, setNotFoundSince(dir, time.Now().Add(-2*time.Hour)))
+		notFound.FetchFunc.PushReturn(errors.New("remote: Invalid username or password.\nfatal: Authentication failed"))
+
+		// A failure to authenticate doesn't count, and restarts the grace
+		// period.
+		_, _, _ = s.FetchRepository(ctx, repoName)
+
+		require.True(t, cloned(t, repoName))
+		since, err := getNotFoundSince(dir)
+		require.NoError(t, err)
+		require.True(t, since.IsZero())
+	})
+
+	t.Run("pinned", func(t *testing.T) {
+		repoName, dir := setup(t, RepoNotFoundPolicy{Action: RepoNotFoundRemoveAfterGrace, GracePeriod: time.Hour})
+		mockJanitorPinnedRepos(string(repoName))
+		t.Cleanup(func() { mockJanitorPinnedRepos("") })
+		require.NoError(t, setNotFoundSince(dir, time.Now().Add(-2*time.Hour)))
+
+		_, _, _ = s.FetchRepository(ctx, repoName)
+
+		require.True(t, cloned(t, repoName))
+	})
+
+	t.Run("found again", func(t *testing.T) {
+		repoName, dir := setup(t, RepoNotFoundPolicy{Action: RepoNotFoundRemoveAfterGrace, GracePeriod: time.Hour})
+		require.NoError(t, setNotFoundSince(dir, time.Now()))
+
+		found := vcssyncer.NewMockVCSSyncer()
+		s.getVCSSyncer = func(context.Context, api.RepoName) (vcssyncer.VCSSyncer, error) { return found, nil }
+		t.Cleanup(func() {
+			s.getVCSSyncer = func(context.Context, api.RepoName) (vcssyncer.VCSSyncer, error) { return notFound, nil }
+		})
+
+		_, _, _ = s.FetchRepository(ctx, repoName)
+
+		require.True(t, cloned(t, repoName))
+		since, err := getNotFoundSince(dir)
+		require.NoError(t, err)
+		require.True(t, since.IsZero())
+	})
+}
+
 func TestHostnameMatch(t *testing.T) {
 	testCases := []struct {
 		hostname    string
@@ -594,6 +931,123 @@ func TestHostnameMatch(t *testing.T) {
 	}
 }
 
+func TestIsKnownShard(t *testing.T) {
+	addrs := []string{"gitserver-0.gitserver:3178", "gitserver-1.gitserver:3178"}
+
+	testCases := []struct {
+		shardID string
+		want    bool
+	}{
+		{shardID: "gitserver-0", want: true},
+		{shardID: "gitserver-1.gitserver", want: true},
+		{shardID: "gitserver-1.gitserver.default.svc.cluster.local", want: false},
+		{shardID: "gitserver-10", want: false},
+		{shardID: "", want: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.shardID, func(t *testing.T) {
+			if have := IsKnownShard(tc.shardID, addrs); have != tc.want {
+				t.Fatalf("Want %v, got %v", tc.want, have)
+			}
+		})
+	}
+
+	if IsKnownShard("gitserver-0", nil) {
+		t.Fatal("Want no match without addresses")
+	}
+}
+
+func TestAdaptiveCloneLimit(t *testing.T) {
+	testCases := []struct {
+		name        string
+		percentFree float64
+		want        int
+	}{
+		{name: "plenty of space", percentFree: 50, want: 8},
+		{name: "at twice the desired free space", percentFree: 20, want: 8},
+		{name: "approaching the desired free space", percentFree: 15, want: 4},
+		{name: "close to the desired free space", percentFree: 11, want: 1},
+		{name: "at the desired free space", percentFree: 10, want: 1},
+		{name: "below the desired free space", percentFree: 2, want: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if have := adaptiveCloneLimit(8, tc.percentFree, 10); have != tc.want {
+				t.Fatalf("Want %d, got %d", tc.want, have)
+			}
+		})
+	}
+
+	if have := adaptiveCloneLimit(8, 2, 0); have != 8 {
+		t.Fatalf("Want the configured limit when disabled, got %d", have)
+	}
+}
+
+type fakeDiskUsage struct {
+	percentUsed float32
+}
+
+func (d fakeDiskUsage) Free() uint64         { return 0 }
+func (d fakeDiskUsage) Size() uint64         { return 0 }
+func (d fakeDiskUsage) PercentUsed() float32 { return d.percentUsed }
+func (d fakeDiskUsage) Available() uint64    { return 0 }
+
+func TestServer_CloneLimitDiskPressure(t *testing.T) {
+	var percentUsed atomic.Value
+	percentUsed.Store(float32(50))
+	fs := gitserverfs.NewMockFS()
+	fs.DiskUsageFunc.SetDefaultHook(func() (diskusage.DiskUsage, error) {
+		return fakeDiskUsage{percentUsed: percentUsed.Load().(float32)}, nil
+	})
+
+	s := NewServer(&ServerOpts{
+		Logger:             logtest.Scoped(t),
+		ObservationCtx:     observation.TestContextTB(t),
+		FS:                 fs,
+		DesiredPercentFree: 10,
+	})
+	t.Cleanup(s.Stop)
+
+	maxConcurrentClones := conf.GitMaxConcurrentClones()
+	s.checkDiskPressure()
+	if limit, _ := s.cloneLimiter.GetLimit(); limit != maxConcurrentClones {
+		t.Fatalf("Want the configured limit %d with plenty of free space, got %d", maxConcurrentClones, limit)
+	}
+
+	// Free disk space drops to the desired percentage.
+	percentUsed.Store(float32(95))
+	s.checkDiskPressure()
+	if limit, _ := s.cloneLimiter.GetLimit(); limit != 1 {
+		t.Fatalf("Want a single clone under disk pressure, got %d", limit)
+	}
+
+	// Once space frees up again, the limit is restored.
+	percentUsed.Store(float32(50))
+	s.checkDiskPressure()
+	if limit, _ := s.cloneLimiter.GetLimit(); limit != maxConcurrentClones {
+		t.Fatalf("Want the configured limit %d to be restored, got %d", maxConcurrentClones, limit)
+	}
+}
+
+func TestSyncRepoState_NoGitserverAddresses(t *testing.T) {
+	// Before the config arrived, there are no addresses to spread the rate limit
+	// across, so we bail out before touching the database.
+	err := syncRepoState(context.Background(), logtest.Scoped(t), nil, nil, "test", nil, connection.GitserverAddresses{}, 10, 10, true)
+	require.ErrorIs(t, err, errNoGitserverAddresses)
+}
+
+func TestSecondsSinceLastRepoStateSync(t *testing.T) {
+	t.Cleanup(func() { lastSuccessfulRepoStateSync.Store(0) })
+
+	now := time.Now()
+	require.Equal(t, float64(0), secondsSinceLastRepoStateSync(now))
+
+	lastSuccessfulRepoStateSync.Store(now.Add(-time.Minute).Unix())
+	require.InDelta(t, 60, secondsSinceLastRepoStateSync(now), 1)
+}
+
 func TestSyncRepoState(t *testing.T) {
 	logger := logtest.Scoped(t)
 	ctx, cancel := context.WithCancel(context.Background())
@@ -874,7 +1328,8 @@ func TestServer_IsRepoCloneable_InternalActor(t *testing.T) {
 	require.NoError(t, fs.Initialize())
 
 	s := NewServer(&ServerOpts{
-		Logger: logtest.Scoped(t),
+		Logger:         logtest.Scoped(t),
+		ObservationCtx: observation.TestContextTB(t),
 		GitBackendSource: func(dir common.GitDir, repoName api.RepoName) git.GitBackend {
 			return git.NewMockGitBackend()
 		},
@@ -77,6 +77,17 @@ func mapToLoggerField(m map[string]any) []log.Field {
 	return LogFields
 }
 
+// IsKnownShard returns true if shardID matches one of the gitserver addresses
+// in addrs, ie. if any repos are assigned to the gitserver instance.
+func IsKnownShard(shardID string, addrs []string) bool {
+	for _, addr := range addrs {
+		if hostnameMatch(shardID, addr) {
+			return true
+		}
+	}
+	return false
+}
+
 // hostnameMatch checks whether the hostname matches the given address.
 // If we don't find an exact match, we look at the initial prefix.
 func hostnameMatch(shardID, addr string) bool {
@@ -37,16 +37,19 @@
 
 set -xe
 
+# The git executable to run, see SRC_GITSERVER_GIT_BINARY.
+GIT="${SG_GIT_BINARY:-git}"
+
 # Usually run by git gc. Pack heads and tags for efficient repository access.
 # --all Pack branch tips as well. Useful for a repository with many branches of
 # historical interest.
-git pack-refs --all --prune
+"$GIT" pack-refs --all --prune
 
 # Usually run by git gc. The "expire" subcommand prunes older reflog entries.
 # Entries older than expire time, or entries older than expire-unreachable time
 # and not reachable from the current tip, are removed from the reflog.
 # --all Process the reflogs of all references
-git reflog expire --all
+"$GIT" reflog expire --all
 
 # Usually run by git gc. Here with the additional option --window-memory
 # and --write-bitmap-index. We previously set the option --geometric=2, however
@@ -54,7 +57,7 @@ git reflog expire --all
 # instances. Restricting the memory consumption by setting pack.windowMemory,
 # pack.deltaCacheSize and pack.threads in addition to --geometric=2 seemed to
 # have no effect.
-git repack -d -l -A --write-bitmap-index --window-memory 100m --unpack-unreachable=now
+"$GIT" repack -d -l -A --write-bitmap-index --window-memory 100m --unpack-unreachable=now
 
 # With the --changed-paths option, compute and write information about the
 # paths changed between a commit and its first parent. This operation can take
@@ -62,4 +65,4 @@ git repack -d -l -A --write-bitmap-index --window-memory 100m --unpack-unreachab
 # getting history of a directory or a file with git log -- <path>. If this
 # option is given, future commit-graph writes will automatically assume that
 # this option was intended
-git commit-graph write --reachable --changed-paths
+"$GIT" commit-graph write --reachable --changed-paths
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -32,8 +33,32 @@ var (
 		Name: "src_repo_sync_state_upsert_counter",
 		Help: "Incremented each time we upsert repo state in the database",
 	}, []string{"success"})
+
+	// lastSuccessfulRepoStateSync is the unix time of the last successful run
+	// of the repo state syncer, or of its start if it never succeeded.
+	lastSuccessfulRepoStateSync atomic.Int64
+	repoStateLastSuccessAge     = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "src_repo_sync_state_last_success_age_seconds",
+		Help: "Seconds since the repo state syncer last completed successfully",
+	}, func() float64 {
+		return secondsSinceLastRepoStateSync(time.Now())
+	})
 )
 
+// errNoGitserverAddresses is returned by syncRepoState while the list of
+// gitserver addresses isn't known yet, e.g. before the config arrived.
+var errNoGitserverAddresses = errors.New("found zero gitserver instances")
+
+// secondsSinceLastRepoStateSync returns the age of lastSuccessfulRepoStateSync
+// at now, or 0 if the repo state syncer isn't running.
+func secondsSinceLastRepoStateSync(now time.Time) float64 {
+	last := lastSuccessfulRepoStateSync.Load()
+	if last == 0 {
+		return 0
+	}
+	return now.Sub(time.Unix(last, 0)).Seconds()
+}
+
 // NewRepoStateSyncer returns a periodic goroutine that syncs state on disk to the
 // database for all repos. We perform a full sync if the known gitserver addresses
 // has changed since the last run. Otherwise, we only sync repos that have not yet
@@ -52,6 +77,7 @@ func NewRepoStateSyncer(
 	var previousAddrs string
 	var previousPinned string
 	fullSync := true
+	lastSuccessfulRepoStateSync.Store(time.Now().Unix())
 
 	return goroutine.NewPeriodicGoroutine(
 		actor.WithInternalActor(ctx),
@@ -86,6 +112,7 @@ func NewRepoStateSyncer(
 
 			// Last full sync was a success, so next time we can be more optimistic.
 			fullSync = false
+			lastSuccessfulRepoStateSync.Store(time.Now().Unix())
 
 			return nil
 		}),
@@ -109,6 +136,11 @@ func syncRepoState(
 ) error {
 	logger.Debug("starting syncRepoState", log.Bool("fullSync", fullSync))
 	addrs := gitServerAddrs.Addresses
+	if len(addrs) == 0 {
+		// The syncer runs periodically, so we simply try again on the next
+		// invocation instead of spreading the rate limit across no instances.
+		return errNoGitserverAddresses
+	}
 
 	// When fullSync is true we'll scan all repos in the database and ensure we set
 	// their clone state and assign any that belong to this shard with the correct
@@ -26,15 +26,36 @@ type gitRepoSyncer struct {
 	logger                  log.Logger
 	recordingCommandFactory *wrexec.RecordingCommandFactory
 	getRemoteURLSource      func(ctx context.Context, name api.RepoName) (RemoteURLSource, error)
+	// partialClone, if true, makes the syncer fetch blobless partial clones.
+	// Missing blobs can then be fetched on demand using FetchMissingBlobs.
+	partialClone bool
+	// sparseExcludePaths are paths whose file contents are never fetched. If
+	// set, the syncer fetches blobless partial clones and then only fetches
+	// the blobs of HEAD outside of these paths.
+	sparseExcludePaths []string
+	// fetchRefspecs, if set, replaces defaultFetchRefspecs. It takes
+	// precedence over SRC_GITSERVER_REFSPECS.
+	fetchRefspecs []string
+	// fetchLFS, if true, makes the syncer fetch the Git LFS objects of HEAD
+	// after cloning and fetching.
+	fetchLFS bool
+	// gitBinary is the git executable to run.
+	gitBinary string
 }
 
+var _ BlobFetcher = &gitRepoSyncer{}
+
+// NewGitRepoSyncer returns a syncer for git repos that runs the git executable
+// gitBinary. If gitBinary is empty, "git" is used.
 func NewGitRepoSyncer(
 	logger log.Logger,
 	r *wrexec.RecordingCommandFactory,
+	gitBinary string,
 	getRemoteURLSource func(ctx context.Context, name api.RepoName) (RemoteURLSource, error)) *gitRepoSyncer {
 	return &gitRepoSyncer{
 		logger:                  logger.Scoped("GitRepoSyncer"),
 		recordingCommandFactory: r,
+		gitBinary:               gitExecutable(gitBinary),
 		getRemoteURLSource:      getRemoteURLSource}
 }
 
@@ -42,6 +63,20 @@ func (s *gitRepoSyncer) Type() string {
 	return "git"
 }
 
+// gitCommand returns a command that runs the configured git executable with
+// args.
+func (s *gitRepoSyncer) gitCommand(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, s.gitBinary, args...)
+}
+
+// gitExecutable returns gitBinary, or "git" if it is empty.
+func gitExecutable(gitBinary string) string {
+	if gitBinary == "" {
+		return "git"
+	}
+	return gitBinary
+}
+
 // IsCloneable checks to see if the Git remote URL is cloneable.
 func (s *gitRepoSyncer) IsCloneable(ctx context.Context, repoName api.RepoName) (err error) {
 	if isAlwaysCloningTest(repoName) {
@@ -63,10 +98,10 @@ func (s *gitRepoSyncer) IsCloneable(ctx context.Context, repoName api.RepoName)
 	defer cancel()
 
 	r := urlredactor.New(remoteURL)
-	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd := s.gitCommand(ctx, args...)
 
 	// Configure the command to be able to talk to a remote.
-	executil.ConfigureRemoteGitCommand(cmd, remoteURL)
+	executil.ConfigureRemoteGitCommand(cmd, s.gitBinary, remoteURL)
 
 	out, err := s.recordingCommandFactory.WrapWithRepoName(ctx, log.NoOp(), repoName, cmd).WithRedactorFunc(r.Redact).CombinedOutput()
 	if err != nil {
@@ -108,7 +143,7 @@ func (s *gitRepoSyncer) Clone(ctx context.Context, repo api.RepoName, _ common.G
 	{
 		tryWrite(s.logger, progressWriter, "Creating bare repo\n")
 
-		if err := git.MakeBareRepo(ctx, string(dir)); err != nil {
+		if err := git.MakeBareRepo(ctx, s.gitBinary, string(dir)); err != nil {
 			return err
 		}
 
@@ -149,6 +184,12 @@ func (s *gitRepoSyncer) Clone(ctx context.Context, repo api.RepoName, _ common.G
 		tryWrite(s.logger, progressWriter, "Finished setting local HEAD to remote HEAD\n")
 	}
 
+	if err := s.fetchIncludedBlobs(ctx, repo, dir, progressWriter); err != nil {
+		return err
+	}
+
+	s.fetchLFSObjects(ctx, repo, dir, source, progressWriter)
+
 	return nil
 }
 
@@ -188,6 +229,12 @@ func (s *gitRepoSyncer) Fetch(ctx context.Context, repoName api.RepoName, dir co
 		tryWrite(s.logger, progressWriter, "Finished setting local HEAD to remote HEAD\n")
 	}
 
+	if err := s.fetchIncludedBlobs(ctx, repoName, dir, progressWriter); err != nil {
+		return err
+	}
+
+	s.fetchLFSObjects(ctx, repoName, dir, source, progressWriter)
+
 	return nil
 }
 
@@ -200,26 +247,25 @@ func (s *gitRepoSyncer) runFetchCommand(ctx context.Context, repoName api.RepoNa
 	var cmd *exec.Cmd
 
 	configRemoteOpts := true
+	namedRemote := false
 	if customCmd := customFetchCmd(ctx, remoteURL); customCmd != nil {
 		cmd = customCmd
 		configRemoteOpts = false
-	} else if useRefspecOverrides() {
-		cmd = refspecOverridesFetchCmd(ctx, remoteURL)
 	} else {
-		cmd = exec.CommandContext(ctx, "git", "fetch",
-			"--progress", "--prune", remoteURL.String(),
-			// Normal git refs
-			"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*",
-			// GitHub pull requests
-			"+refs/pull/*:refs/pull/*",
-			// GitLab merge requests
-			"+refs/merge-requests/*:refs/merge-requests/*",
-			// Bitbucket pull requests
-			"+refs/pull-requests/*:refs/pull-requests/*",
-			// Gerrit changesets
-			"+refs/changes/*:refs/changes/*",
-			// Possibly deprecated refs for sourcegraph zap experiment?
-			"+refs/sourcegraph/*:refs/sourcegraph/*")
+		remote := remoteURL.String()
+		if s.filterBlobs() {
+			if err := s.configurePromisorRemote(ctx, dir, remoteURL); err != nil {
+				return -1, err
+			}
+			remote = promisorRemote
+			namedRemote = true
+		}
+		if useRefspecOverrides() && len(s.fetchRefspecs) == 0 {
+			cmd = s.gitCommand(ctx, refspecOverridesFetchArgs(remote, s.fetchFlags()...)...)
+		} else {
+			args := append(append([]string{"fetch"}, s.fetchFlags()...), remote)
+			cmd = s.gitCommand(ctx, append(args, s.refspecs()...)...)
+		}
 	}
 
 	if cmd.Env == nil {
@@ -232,9 +278,11 @@ func (s *gitRepoSyncer) runFetchCommand(ctx context.Context, repoName api.RepoNa
 	// Set the working directory for the command.
 	dir.Set(cmd)
 
-	if configRemoteOpts {
+	if namedRemote {
+		executil.ConfigureNamedRemoteGitCommand(cmd, s.gitBinary, remoteURL)
+	} else if configRemoteOpts {
 		// Configure the command to be able to talk to a remote.
-		executil.ConfigureRemoteGitCommand(cmd, remoteURL)
+		executil.ConfigureRemoteGitCommand(cmd, s.gitBinary, remoteURL)
 	}
 
 	redactor := urlredactor.New(remoteURL)
@@ -247,6 +295,48 @@ func (s *gitRepoSyncer) runFetchCommand(ctx context.Context, repoName api.RepoNa
 	return executil.RunCommandWriteOutput(ctx, wrCmd, progressWriter, redactor.Redact)
 }
 
+// defaultFetchRefspecs are the refspecs fetched unless the code host
+// connection configures fetchRefspecs.
+var defaultFetchRefspecs = []string{
+	// Normal git refs
+	"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*",
+	// GitHub pull requests
+	"+refs/pull/*:refs/pull/*",
+	// GitLab merge requests
+	"+refs/merge-requests/*:refs/merge-requests/*",
+	// Bitbucket pull requests
+	"+refs/pull-requests/*:refs/pull-requests/*",
+	// Gerrit changesets
+	"+refs/changes/*:refs/changes/*",
+	// Possibly deprecated refs for sourcegraph zap experiment?
+	"+refs/sourcegraph/*:refs/sourcegraph/*",
+}
+
+// refspecs returns the refspecs to fetch.
+func (s *gitRepoSyncer) refspecs() []string {
+	if len(s.fetchRefspecs) > 0 {
+		return s.fetchRefspecs
+	}
+	return defaultFetchRefspecs
+}
+
+// fetchFlags returns the flags passed to git fetch before the remote URL.
+func (s *gitRepoSyncer) fetchFlags() []string {
+	flags := []string{"--progress", "--prune"}
+	if s.filterBlobs() {
+		// When fetching with a filter, git records the remote as a promisor
+		// remote and marks the received packfiles accordingly.
+		flags = append(flags, "--filter="+partialCloneFilter)
+	}
+	return flags
+}
+
+// filterBlobs returns true if blobs are left out of fetches, see
+// partialCloneFilter.
+func (s *gitRepoSyncer) filterBlobs() bool {
+	return s.partialClone || len(s.sparseExcludePaths) > 0
+}
+
 var headBranchPattern = lazyregexp.New(`HEAD branch: (.+?)\n`)
 
 // setHEAD configures git repo defaults (such as what HEAD is) which are
@@ -269,12 +359,12 @@ func (s *gitRepoSyncer) setHEAD(ctx context.Context, repoName api.RepoName, dir
 		}
 
 		// try to fetch HEAD from origin
-		cmd := exec.CommandContext(ctx, "git", "remote", "show", remoteURL.String())
+		cmd := s.gitCommand(ctx, "remote", "show", remoteURL.String())
 		dir.Set(cmd)
 		r := urlredactor.New(remoteURL)
 
 		// Configure the command to be able to talk to a remote.
-		executil.ConfigureRemoteGitCommand(cmd, remoteURL)
+		executil.ConfigureRemoteGitCommand(cmd, s.gitBinary, remoteURL)
 
 		output, err := s.recordingCommandFactory.WrapWithRepoName(ctx, s.logger, repoName, cmd).WithRedactorFunc(r.Redact).CombinedOutput()
 		if err != nil {
@@ -301,11 +391,11 @@ func (s *gitRepoSyncer) setHEAD(ctx context.Context, repoName api.RepoName, dir
 
 	// check if branch pointed to by HEAD exists
 	{
-		cmd := exec.CommandContext(ctx, "git", "rev-parse", headBranch, "--")
+		cmd := s.gitCommand(ctx, "rev-parse", headBranch, "--")
 		dir.Set(cmd)
 		if err := cmd.Run(); err != nil {
 			// branch does not exist, pick first branch
-			cmd := exec.CommandContext(ctx, "git", "branch")
+			cmd := s.gitCommand(ctx, "branch")
 			dir.Set(cmd)
 			output, err := cmd.Output()
 			if err != nil {
@@ -322,7 +412,7 @@ func (s *gitRepoSyncer) setHEAD(ctx context.Context, repoName api.RepoName, dir
 
 	// set HEAD
 	{
-		cmd := exec.CommandContext(ctx, "git", "symbolic-ref", "HEAD", "refs/heads/"+headBranch)
+		cmd := s.gitCommand(ctx, "symbolic-ref", "HEAD", "refs/heads/"+headBranch)
 		dir.Set(cmd)
 		output, err := cmd.CombinedOutput()
 		if err != nil {
@@ -0,0 +1,147 @@
+package vcssyncer
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/log/logtest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/common"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+	"github.com/sourcegraph/sourcegraph/internal/wrexec"
+)
+
+func TestGitRepoSyncer_FetchRefspecs(t *testing.T) {
+	ctx := context.Background()
+
+	remoteDir := t.TempDir()
+	runGit := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@a.com",
+			"GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@a.com",
+		)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	runGit(remoteDir, "init")
+	require.NoError(t, os.WriteFile(filepath.Join(remoteDir, "a.txt"), []byte("a"), 0o644))
+	runGit(remoteDir, "add", ".")
+	runGit(remoteDir, "commit", "-m", "initial")
+	runGit(remoteDir, "tag", "v1.0.0")
+	runGit(remoteDir, "update-ref", "refs/pull/1/head", "HEAD")
+
+	remoteURL, err := vcs.ParseURL("file://" + filepath.ToSlash(remoteDir))
+	require.NoError(t, err)
+
+	clone := func(t *testing.T, fetchRefspecs []string) []string {
+		s := NewGitRepoSyncer(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), "", func(ctx context.Context, name api.RepoName) (RemoteURLSource, error) {
+			return RemoteURLSourceFunc(func(ctx context.Context) (*vcs.URL, error) {
+				return remoteURL, nil
+			}), nil
+		})
+		s.fetchRefspecs = fetchRefspecs
+
+		tmpDir := filepath.Join(t.TempDir(), ".git")
+		require.NoError(t, s.Clone(ctx, "example.com/refspecs", "", tmpDir, io.Discard))
+
+		cmd := exec.Command("git", "for-each-ref", "--format=%(refname)")
+		common.GitDir(tmpDir).Set(cmd)
+		out, err := cmd.Output()
+		require.NoError(t, err)
+		return strings.Fields(string(out))
+	}
+
+	t.Run("default refspecs", func(t *testing.T) {
+		refs := clone(t, nil)
+		require.Contains(t, refs, "refs/pull/1/head")
+		require.Contains(t, refs, "refs/tags/v1.0.0")
+	})
+
+	t.Run("configured refspecs", func(t *testing.T) {
+		refs := clone(t, []string{"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*"})
+		require.NotContains(t, refs, "refs/pull/1/head")
+		require.Contains(t, refs, "refs/tags/v1.0.0")
+	})
+}
+
+func TestGitRepoSyncer_FetchLFSObjectsFailureIsNotFatal(t *testing.T) {
+	ctx := context.Background()
+
+	remoteDir := t.TempDir()
+	runGit := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@a.com",
+			"GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@a.com",
+		)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	runGit(remoteDir, "init")
+	require.NoError(t, os.WriteFile(filepath.Join(remoteDir, "a.txt"), []byte("a"), 0o644))
+	runGit(remoteDir, "add", ".")
+	runGit(remoteDir, "commit", "-m", "initial")
+
+	// Make git lfs always fail, like it does when the LFS server is down.
+	binDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "git-lfs"), []byte("#!/bin/sh\nexit 1\n"), 0o755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	remoteURL, err := vcs.ParseURL("file://" + filepath.ToSlash(remoteDir))
+	require.NoError(t, err)
+
+	s := NewGitRepoSyncer(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), "", func(ctx context.Context, name api.RepoName) (RemoteURLSource, error) {
+		return RemoteURLSourceFunc(func(ctx context.Context) (*vcs.URL, error) {
+			return remoteURL, nil
+		}), nil
+	})
+	s.fetchLFS = true
+
+	tmpDir := filepath.Join(t.TempDir(), ".git")
+	require.NoError(t, s.Clone(ctx, "example.com/lfs", "", tmpDir, io.Discard))
+	require.NoError(t, s.Fetch(ctx, "example.com/lfs", common.GitDir(tmpDir), io.Discard))
+}
+
+func TestGitRepoSyncer_GitBinary(t *testing.T) {
+	ctx := context.Background()
+
+	remoteDir, _ := makePartialCloneRemote(t)
+	remoteURL, err := vcs.ParseURL("file://" + filepath.ToSlash(remoteDir))
+	require.NoError(t, err)
+
+	// The configured git binary logs its subcommands before running git.
+	gitPath, err := exec.LookPath("git")
+	require.NoError(t, err)
+	binDir := t.TempDir()
+	logPath := filepath.Join(binDir, "calls.log")
+	script := "#!/bin/sh\necho \"$@\" >> " + logPath + "\nexec " + gitPath + " \"$@\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "my-git"), []byte(script), 0o755))
+
+	s := NewGitRepoSyncer(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), filepath.Join(binDir, "my-git"), func(ctx context.Context, name api.RepoName) (RemoteURLSource, error) {
+		return RemoteURLSourceFunc(func(ctx context.Context) (*vcs.URL, error) {
+			return remoteURL, nil
+		}), nil
+	})
+
+	tmpDir := filepath.Join(t.TempDir(), ".git")
+	require.NoError(t, s.Clone(ctx, "example.com/git-binary", "", tmpDir, io.Discard))
+
+	calls, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	for _, subcommand := range []string{"init --bare", "fetch", "symbolic-ref HEAD"} {
+		require.Contains(t, string(calls), subcommand)
+	}
+}
@@ -28,6 +28,7 @@ func NewGoModulesSyncer(
 	svc *dependencies.Service,
 	client *gomodproxy.Client,
 	fs gitserverfs.FS,
+	gitBinary string,
 	getRemoteURLSource func(ctx context.Context, name api.RepoName) (RemoteURLSource, error),
 ) VCSSyncer {
 	placeholder, err := reposource.ParseGoVersionedPackage("sourcegraph.com/placeholder@v0.0.0")
@@ -45,6 +46,7 @@ func NewGoModulesSyncer(
 		source:             &goModulesSyncer{client: client, fs: fs},
 		fs:                 fs,
 		getRemoteURLSource: getRemoteURLSource,
+		gitBinary:          gitBinary,
 	}
 }
 
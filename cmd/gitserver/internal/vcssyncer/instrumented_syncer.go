@@ -12,6 +12,8 @@ import (
 
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/common"
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
 // fetchBuckets are the buckets used for the fetch and clone duration histograms.
@@ -112,6 +114,31 @@ func (i *instrumentedSyncer) Fetch(ctx context.Context, repoName api.RepoName, d
 	return i.base.Fetch(ctx, repoName, dir, progressWriter)
 }
 
+// FetchMissingBlobs implements BlobFetcher if the wrapped syncer does, and is a
+// no-op otherwise.
+func (i *instrumentedSyncer) FetchMissingBlobs(ctx context.Context, repoName api.RepoName, dir common.GitDir, treeish string, paths []string) error {
+	if bf, ok := i.base.(BlobFetcher); ok {
+		return bf.FetchMissingBlobs(ctx, repoName, dir, treeish, paths)
+	}
+	return nil
+}
+
+// CanCloneFromPeer implements PeerCloner. It returns false if the wrapped
+// syncer doesn't implement PeerCloner.
+func (i *instrumentedSyncer) CanCloneFromPeer() bool {
+	pc, ok := i.base.(PeerCloner)
+	return ok && pc.CanCloneFromPeer()
+}
+
+// CloneFromPeer implements PeerCloner if the wrapped syncer does.
+func (i *instrumentedSyncer) CloneFromPeer(ctx context.Context, repo api.RepoName, peer *vcs.URL, tmpPath string, progressWriter io.Writer) error {
+	pc, ok := i.base.(PeerCloner)
+	if !ok {
+		return errors.Newf("%s syncer can't clone from peers", i.base.Type())
+	}
+	return pc.CloneFromPeer(ctx, repo, peer, tmpPath, progressWriter)
+}
+
 func (i *instrumentedSyncer) shouldObserve() bool {
 	// check to see if the base is another instance of instrumented syncer
 	// if so, we should skip the observation to avoid double counting
@@ -120,4 +147,8 @@ func (i *instrumentedSyncer) shouldObserve() bool {
 	return !ok
 }
 
-var _ VCSSyncer = &instrumentedSyncer{}
+var (
+	_ VCSSyncer   = &instrumentedSyncer{}
+	_ BlobFetcher = &instrumentedSyncer{}
+	_ PeerCloner  = &instrumentedSyncer{}
+)
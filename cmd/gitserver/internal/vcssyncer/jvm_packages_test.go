@@ -221,7 +221,7 @@ func TestJVMCloneCommand(t *testing.T) {
 	cacheDir := filepath.Join(dir, "cache")
 	fs := gitserverfs.New(observation.TestContextTB(t), dir)
 	require.NoError(t, fs.Initialize())
-	s := NewJVMPackagesSyncer(&schema.JVMPackagesConnection{Maven: schema.Maven{Dependencies: []string{}}}, depsSvc, testGetRemoteURLSource, cacheDir, fs).(*vcsPackagesSyncer)
+	s := NewJVMPackagesSyncer(&schema.JVMPackagesConnection{Maven: schema.Maven{Dependencies: []string{}}}, depsSvc, testGetRemoteURLSource, cacheDir, fs, "git").(*vcsPackagesSyncer)
 	bareGitDirectory := path.Join(dir, "git")
 
 	s.runCloneCommand(t, bareGitDirectory, []string{exampleVersionedPackage})
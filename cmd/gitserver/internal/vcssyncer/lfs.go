@@ -0,0 +1,60 @@
+package vcssyncer
+
+import (
+	"context"
+	"io"
+
+	"github.com/sourcegraph/log"
+
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/common"
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/executil"
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/urlredactor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// fetchLFSObjects fetches the Git LFS objects referenced by HEAD into the LFS
+// storage of the repository, if the syncer is configured to do so. Objects
+// referenced only by other revisions are not fetched. Reads of the repository
+// still return the pointer files.
+//
+// LFS objects are optional, so failing to fetch them, eg. because git-lfs is
+// not installed or the LFS server is unavailable, doesn't fail the clone or
+// fetch. The error is logged instead.
+func (s *gitRepoSyncer) fetchLFSObjects(ctx context.Context, repoName api.RepoName, dir common.GitDir, source RemoteURLSource, progressWriter io.Writer) {
+	if !s.fetchLFS {
+		return
+	}
+
+	tryWrite(s.logger, progressWriter, "Fetching Git LFS objects\n")
+
+	if err := s.runLFSFetch(ctx, repoName, dir, source); err != nil {
+		s.logger.Warn("failed to fetch Git LFS objects", log.String("repo", string(repoName)), log.Error(err))
+		tryWrite(s.logger, progressWriter, "Failed to fetch Git LFS objects\n")
+		return
+	}
+
+	tryWrite(s.logger, progressWriter, "Fetched Git LFS objects\n")
+}
+
+func (s *gitRepoSyncer) runLFSFetch(ctx context.Context, repoName api.RepoName, dir common.GitDir, source RemoteURLSource) error {
+	remoteURL, err := source.RemoteURL(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get remote URL")
+	}
+
+	// We don't configure a remote in the repos we mirror, so we pass the URL.
+	cmd := s.gitCommand(ctx, "lfs", "fetch", remoteURL.String(), "HEAD")
+	dir.Set(cmd)
+	executil.ConfigureRemoteGitCommand(cmd, s.gitBinary, remoteURL)
+
+	redactor := urlredactor.New(remoteURL)
+	out, err := s.recordingCommandFactory.WrapWithRepoName(ctx, s.logger, repoName, cmd).WithRedactorFunc(redactor.Redact).CombinedOutput()
+	if err != nil {
+		if ctxerr := ctx.Err(); ctxerr != nil {
+			err = ctxerr
+		}
+		return errors.Wrapf(err, "git lfs fetch: %s", redactor.Redact(string(out)))
+	}
+	return nil
+}
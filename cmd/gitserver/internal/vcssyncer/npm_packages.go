@@ -28,6 +28,7 @@ func NewNpmPackagesSyncer(
 	svc *dependencies.Service,
 	client npm.Client,
 	fs gitserverfs.FS,
+	gitBinary string,
 	getRemoteURLSource func(ctx context.Context, name api.RepoName) (RemoteURLSource, error),
 ) VCSSyncer {
 	placeholder, err := reposource.ParseNpmVersionedPackage("@sourcegraph/placeholder@1.0.0")
@@ -45,6 +46,7 @@ func NewNpmPackagesSyncer(
 		fs:                 fs,
 		source:             &npmPackagesSyncer{client: client},
 		getRemoteURLSource: getRemoteURLSource,
+		gitBinary:          gitBinary,
 	}
 }
 
@@ -129,6 +129,7 @@ func TestNpmCloneCommand(t *testing.T) {
 		depsSvc,
 		&client,
 		fs,
+		"git",
 		testGetRemoteURLSource,
 	).(*vcsPackagesSyncer)
 
@@ -0,0 +1,209 @@
+package vcssyncer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/internal/env"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+)
+
+// packageDownloadPolicy controls how hard the package syncers may hit a
+// package registry.
+type packageDownloadPolicy struct {
+	// Concurrency is the maximum number of concurrent requests to a registry.
+	// A value <= 0 means no limit.
+	Concurrency int
+	// MaxRetries is the number of times a request that was rate limited by the
+	// registry is retried.
+	MaxRetries int
+	// Backoff is how long to wait before retrying a rate limited request, if
+	// the registry didn't send a Retry-After header. It doubles with every
+	// attempt.
+	Backoff time.Duration
+	// MaxRetryAfter is the longest Retry-After we're willing to wait for. If a
+	// registry asks us to wait longer, the rate limited response is returned.
+	MaxRetryAfter time.Duration
+}
+
+var (
+	packageDownloadMaxRetries    = env.MustGetInt("SRC_GITSERVER_PACKAGES_DOWNLOAD_MAX_RETRIES", 3, "The number of times a request to a package registry is retried after the registry responded with 429 Too Many Requests.")
+	packageDownloadBackoff       = env.MustGetDuration("SRC_GITSERVER_PACKAGES_DOWNLOAD_BACKOFF", time.Second, "How long to wait before retrying a request to a package registry that responded with 429 Too Many Requests without a Retry-After header. Doubles with every retry.")
+	packageDownloadMaxRetryAfter = env.MustGetDuration("SRC_GITSERVER_PACKAGES_DOWNLOAD_MAX_RETRY_AFTER", time.Minute, "The longest Retry-After of a package registry we wait for before retrying a request.")
+
+	packageDownloadConcurrency = map[string]int{
+		extsvc.TypeNpmPackages:    env.MustGetInt("SRC_GITSERVER_NPM_PACKAGES_DOWNLOAD_CONCURRENCY", 8, "The maximum number of concurrent requests to an npm registry. 0 means no limit."),
+		extsvc.TypeGoModules:      env.MustGetInt("SRC_GITSERVER_GO_MODULES_DOWNLOAD_CONCURRENCY", 8, "The maximum number of concurrent requests to a Go module proxy. 0 means no limit."),
+		extsvc.TypePythonPackages: env.MustGetInt("SRC_GITSERVER_PYTHON_PACKAGES_DOWNLOAD_CONCURRENCY", 8, "The maximum number of concurrent requests to a Python package index. 0 means no limit."),
+		extsvc.TypeRustPackages:   env.MustGetInt("SRC_GITSERVER_RUST_PACKAGES_DOWNLOAD_CONCURRENCY", 8, "The maximum number of concurrent requests to a crates registry. 0 means no limit."),
+		extsvc.TypeRubyPackages:   env.MustGetInt("SRC_GITSERVER_RUBY_PACKAGES_DOWNLOAD_CONCURRENCY", 8, "The maximum number of concurrent requests to a RubyGems repository. 0 means no limit."),
+	}
+)
+
+// packageDownloadPolicyFor returns the download policy for registries of the
+// given service type.
+func packageDownloadPolicyFor(serviceType string) packageDownloadPolicy {
+	return packageDownloadPolicy{
+		Concurrency:   packageDownloadConcurrency[serviceType],
+		MaxRetries:    packageDownloadMaxRetries,
+		Backoff:       packageDownloadBackoff,
+		MaxRetryAfter: packageDownloadMaxRetryAfter,
+	}
+}
+
+// packageRegistrySemaphores holds the semaphores limiting concurrent requests
+// per registry. Syncers are constructed for every clone and fetch, so the
+// limit needs to be shared between them.
+var packageRegistrySemaphores = struct {
+	sync.Mutex
+	m map[string]chan struct{}
+}{m: map[string]chan struct{}{}}
+
+// packageRegistrySemaphore returns the semaphore of the registry identified by
+// urn. The size of the semaphore is determined on first use.
+func packageRegistrySemaphore(urn string, size int) chan struct{} {
+	packageRegistrySemaphores.Lock()
+	defer packageRegistrySemaphores.Unlock()
+
+	sem, ok := packageRegistrySemaphores.m[urn]
+	if !ok {
+		sem = make(chan struct{}, size)
+		packageRegistrySemaphores.m[urn] = sem
+	}
+	return sem
+}
+
+// packageDownloadMiddleware returns a middleware that applies policy to all
+// requests to the registry identified by urn.
+func packageDownloadMiddleware(urn string, policy packageDownloadPolicy) httpcli.Middleware {
+	var sem chan struct{}
+	if policy.Concurrency > 0 {
+		sem = packageRegistrySemaphore(urn, policy.Concurrency)
+	}
+	return func(next httpcli.Doer) httpcli.Doer {
+		return &packageDownloadDoer{
+			next:   next,
+			sem:    sem,
+			policy: policy,
+			sleep:  sleepWithContext,
+		}
+	}
+}
+
+type packageDownloadDoer struct {
+	next   httpcli.Doer
+	sem    chan struct{}
+	policy packageDownloadPolicy
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+func (d *packageDownloadDoer) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		resp, err := d.do(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt >= d.policy.MaxRetries {
+			return resp, err
+		}
+		// We can only resend a request if we can rewind its body.
+		if req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+
+		wait, ok := d.backoff(resp, attempt)
+		if !ok {
+			return resp, nil
+		}
+		if resp.Body != nil {
+			resp.Body.Close()
+		}
+
+		if err := d.sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// do sends req once the registry has capacity for it. The slot is held until
+// the response body is closed, as that is when the download completed.
+func (d *packageDownloadDoer) do(req *http.Request) (*http.Response, error) {
+	if d.sem == nil {
+		return d.next.Do(req)
+	}
+
+	select {
+	case d.sem <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	release := sync.OnceFunc(func() { <-d.sem })
+
+	resp, err := d.next.Do(req)
+	if err != nil || resp.Body == nil {
+		release()
+		return resp, err
+	}
+	resp.Body = &releaseOnCloseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releaseOnCloseBody releases a registry slot once the response body is
+// closed.
+type releaseOnCloseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseOnCloseBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
+
+// backoff returns how long to wait before retrying a request that was rate
+// limited with resp. It returns false if we shouldn't retry, because the
+// registry asked us to wait longer than MaxRetryAfter.
+func (d *packageDownloadDoer) backoff(resp *http.Response, attempt int) (time.Duration, bool) {
+	if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+		return retryAfter, retryAfter <= d.policy.MaxRetryAfter
+	}
+	return d.policy.Backoff << attempt, true
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a
+// number of seconds or an HTTP date.
+func parseRetryAfter(header string) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,119 @@
+package vcssyncer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+)
+
+func TestPackageDownloadMiddleware(t *testing.T) {
+	policy := packageDownloadPolicy{
+		Concurrency:   1,
+		MaxRetries:    2,
+		Backoff:       time.Second,
+		MaxRetryAfter: time.Minute,
+	}
+
+	// newDoer returns a Doer that responds with the given responses in order,
+	// and records the backoffs it slept for.
+	newDoer := func(t *testing.T, responses ...*http.Response) (httpcli.Doer, *[]time.Duration, *int) {
+		var (
+			slept    []time.Duration
+			requests int
+		)
+		upstream := httpcli.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			require.Less(t, requests, len(responses), "unexpected request")
+			resp := responses[requests]
+			requests++
+			return resp, nil
+		})
+		doer := packageDownloadMiddleware(t.Name(), policy)(upstream)
+		doer.(*packageDownloadDoer).sleep = func(_ context.Context, d time.Duration) error {
+			slept = append(slept, d)
+			return nil
+		}
+		return doer, &slept, &requests
+	}
+
+	response := func(status int, retryAfter string) *http.Response {
+		header := make(http.Header)
+		if retryAfter != "" {
+			header.Set("Retry-After", retryAfter)
+		}
+		return &http.Response{
+			StatusCode: status,
+			Header:     header,
+			Body:       io.NopCloser(strings.NewReader(http.StatusText(status))),
+		}
+	}
+
+	do := func(t *testing.T, doer httpcli.Doer) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, "https://registry.example.com/pkg", nil)
+		require.NoError(t, err)
+		resp, err := doer.Do(req)
+		require.NoError(t, err)
+		// Closing the body must release the registry slot for the next request.
+		t.Cleanup(func() { require.NoError(t, resp.Body.Close()) })
+		return resp
+	}
+
+	t.Run("respects Retry-After", func(t *testing.T) {
+		doer, slept, requests := newDoer(t,
+			response(http.StatusTooManyRequests, "7"),
+			response(http.StatusOK, ""),
+		)
+		resp := do(t, doer)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 2, *requests)
+		require.Equal(t, []time.Duration{7 * time.Second}, *slept)
+	})
+
+	t.Run("backs off exponentially without Retry-After", func(t *testing.T) {
+		doer, slept, requests := newDoer(t,
+			response(http.StatusTooManyRequests, ""),
+			response(http.StatusTooManyRequests, ""),
+			response(http.StatusTooManyRequests, ""),
+		)
+		resp := do(t, doer)
+		require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		require.Equal(t, 3, *requests)
+		require.Equal(t, []time.Duration{time.Second, 2 * time.Second}, *slept)
+	})
+
+	t.Run("gives up if Retry-After is too long", func(t *testing.T) {
+		doer, slept, requests := newDoer(t,
+			response(http.StatusTooManyRequests, "3600"),
+		)
+		resp := do(t, doer)
+		require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		require.Equal(t, 1, *requests)
+		require.Empty(t, *slept)
+	})
+
+	t.Run("limits concurrency", func(t *testing.T) {
+		doer, _, _ := newDoer(t,
+			response(http.StatusOK, ""),
+			response(http.StatusOK, ""),
+		)
+		req, err := http.NewRequest(http.MethodGet, "https://registry.example.com/pkg", nil)
+		require.NoError(t, err)
+		resp, err := doer.Do(req)
+		require.NoError(t, err)
+
+		// The registry is at capacity until the first response body is closed.
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = doer.Do(req.WithContext(ctx))
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		require.NoError(t, resp.Body.Close())
+		do(t, doer)
+	})
+}
@@ -39,6 +39,9 @@ type vcsPackagesSyncer struct {
 	svc                dependenciesService
 	fs                 gitserverfs.FS
 	getRemoteURLSource func(ctx context.Context, name api.RepoName) (RemoteURLSource, error)
+	// gitBinary is the git executable that builds the synthetic git repos of
+	// packages. If empty, "git" is used.
+	gitBinary string
 }
 
 var _ VCSSyncer = &vcsPackagesSyncer{}
@@ -78,6 +81,12 @@ func (s *vcsPackagesSyncer) Type() string {
 	return s.typ
 }
 
+// gitCommand returns a command that runs the configured git executable with
+// args.
+func (s *vcsPackagesSyncer) gitCommand(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, gitExecutable(s.gitBinary), args...)
+}
+
 // Clone writes a package and all requested versions of it into a synthetic git
 // repo at tmpPath by creating one head per version.
 // It reports redacted progress logs via the progressWriter.
@@ -89,7 +98,7 @@ func (s *vcsPackagesSyncer) Clone(ctx context.Context, repo api.RepoName, _ comm
 
 	// Next, initialize a bare repo in that tmp path.
 	tryWrite(s.logger, progressWriter, "Creating bare repo\n")
-	if err := git.MakeBareRepo(ctx, tmpPath); err != nil {
+	if err := git.MakeBareRepo(ctx, gitExecutable(s.gitBinary), tmpPath); err != nil {
 		return err
 	}
 	tryWrite(s.logger, progressWriter, "Created bare repo at %s\n", tmpPath)
@@ -154,7 +163,7 @@ func (s *vcsPackagesSyncer) fetchVersions(ctx context.Context, name reposource.P
 
 	// Create set of existing tags. We want to skip the download of a package if the
 	// tag already exists.
-	out, err := runCommandInDirectory(ctx, exec.CommandContext(ctx, "git", "tag"), string(dir), s.placeholder)
+	out, err := runCommandInDirectory(ctx, s.gitCommand(ctx, "tag"), string(dir), s.placeholder)
 	if err != nil {
 		return err
 	}
@@ -183,7 +192,7 @@ func (s *vcsPackagesSyncer) fetchVersions(ctx context.Context, name reposource.P
 	// Set the latest version as the default branch, if there was a successful download.
 	if len(cloned) > 0 {
 		latest := cloned[0]
-		cmd := exec.CommandContext(ctx, "git", "branch", "--force", "latest", latest.GitTagFromVersion())
+		cmd := s.gitCommand(ctx, "branch", "--force", "latest", latest.GitTagFromVersion())
 		if _, err := runCommandInDirectory(ctx, cmd, string(dir), latest); err != nil {
 			return errors.Append(errs, err)
 		}
@@ -211,7 +220,7 @@ func (s *vcsPackagesSyncer) fetchVersions(ctx context.Context, name reposource.P
 
 	for tag := range tags {
 		if _, isDependencyTag := dependencyTags[tag]; !isDependencyTag {
-			cmd := exec.CommandContext(ctx, "git", "tag", "-d", tag)
+			cmd := s.gitCommand(ctx, "tag", "-d", tag)
 			if _, err := runCommandInDirectory(ctx, cmd, string(dir), s.placeholder); err != nil {
 				s.logger.Error("failed to delete git tag",
 					log.Error(err),
@@ -223,7 +232,7 @@ func (s *vcsPackagesSyncer) fetchVersions(ctx context.Context, name reposource.P
 	}
 
 	if len(cloneable) == 0 {
-		cmd := exec.CommandContext(ctx, "git", "branch", "--force", "-D", "latest")
+		cmd := s.gitCommand(ctx, "branch", "--force", "-D", "latest")
 		// Best-effort branch deletion since we don't know if this branch has been created yet.
 		_, _ = runCommandInDirectory(ctx, cmd, string(dir), s.placeholder)
 	}
@@ -255,36 +264,36 @@ func (s *vcsPackagesSyncer) gitPushDependencyTag(ctx context.Context, bareGitDir
 		return err
 	}
 
-	cmd := exec.CommandContext(ctx, "git", "init")
+	cmd := s.gitCommand(ctx, "init")
 	if _, err := runCommandInDirectory(ctx, cmd, workDir, dep); err != nil {
 		return err
 	}
 
-	cmd = exec.CommandContext(ctx, "git", "add", ".")
+	cmd = s.gitCommand(ctx, "add", ".")
 	if _, err := runCommandInDirectory(ctx, cmd, workDir, dep); err != nil {
 		return err
 	}
 
 	// Use --no-verify for security reasons. See https://github.com/sourcegraph/sourcegraph/pull/23399
-	cmd = exec.CommandContext(ctx, "git", "commit", "--no-verify",
+	cmd = s.gitCommand(ctx, "commit", "--no-verify",
 		"-m", dep.VersionedPackageSyntax(), "--date", stableGitCommitDate)
 	if _, err := runCommandInDirectory(ctx, cmd, workDir, dep); err != nil {
 		return err
 	}
 
-	cmd = exec.CommandContext(ctx, "git", "tag",
+	cmd = s.gitCommand(ctx, "tag",
 		"-m", dep.VersionedPackageSyntax(), dep.GitTagFromVersion())
 	if _, err := runCommandInDirectory(ctx, cmd, workDir, dep); err != nil {
 		return err
 	}
 
-	cmd = exec.CommandContext(ctx, "git", "remote", "add", "origin", bareGitDirectory)
+	cmd = s.gitCommand(ctx, "remote", "add", "origin", bareGitDirectory)
 	if _, err := runCommandInDirectory(ctx, cmd, workDir, dep); err != nil {
 		return err
 	}
 
 	// Use --no-verify for security reasons. See https://github.com/sourcegraph/sourcegraph/pull/23399
-	cmd = exec.CommandContext(ctx, "git", "push", "--no-verify", "--force", "origin", "--tags")
+	cmd = s.gitCommand(ctx, "push", "--no-verify", "--force", "origin", "--tags")
 	if _, err := runCommandInDirectory(ctx, cmd, workDir, dep); err != nil {
 		return err
 	}
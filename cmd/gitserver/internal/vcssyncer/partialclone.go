@@ -0,0 +1,187 @@
+package vcssyncer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/sourcegraph/log"
+
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/common"
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/executil"
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/urlredactor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// partialCloneFilter is the object filter used for partial clones. We only
+// leave out blobs, so that commits and trees are always available locally and
+// only reading file contents requires talking to the remote.
+const partialCloneFilter = "blob:none"
+
+// promisorRemote is the name of the remote that filtered fetches talk to.
+// Git records the remote of a filtered fetch as a promisor remote in the repo
+// config. If we fetched from the remote URL directly, that would write the
+// URL including its credentials to disk, so we configure this remote with a
+// URL without credentials instead.
+const promisorRemote = "sourcegraph-promisor"
+
+// configurePromisorRemote configures promisorRemote in the repo in dir to
+// fetch from remoteURL, without its credentials. Promisor remotes named by
+// their URL, which were created by fetching from the URL directly, are
+// removed.
+func (s *gitRepoSyncer) configurePromisorRemote(ctx context.Context, dir common.GitDir, remoteURL *vcs.URL) error {
+	runConfig := func(args ...string) ([]byte, error) {
+		cmd := s.gitCommand(ctx, append([]string{"config"}, args...)...)
+		dir.Set(cmd)
+		return cmd.Output()
+	}
+
+	// Exits with 1 if there are no promisor remotes.
+	out, _ := runConfig("--get-regexp", `^remote\..*\.promisor$`)
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		key, _, _ := strings.Cut(sc.Text(), " ")
+		name := strings.TrimSuffix(strings.TrimPrefix(key, "remote."), ".promisor")
+		if name == promisorRemote {
+			continue
+		}
+		if _, err := runConfig("--remove-section", "remote."+name); err != nil {
+			return errors.Wrap(err, "failed to remove promisor remote")
+		}
+	}
+
+	for _, kv := range [][2]string{
+		{"url", credentialFreeURL(remoteURL)},
+		{"promisor", "true"},
+		{"partialclonefilter", partialCloneFilter},
+	} {
+		if _, err := runConfig("remote."+promisorRemote+"."+kv[0], kv[1]); err != nil {
+			return errors.Wrapf(err, "failed to configure promisor remote %s", kv[0])
+		}
+	}
+	return nil
+}
+
+// credentialFreeURL returns remoteURL without credentials. The user of SSH
+// URLs is kept, since it isn't a secret.
+func credentialFreeURL(remoteURL *vcs.URL) string {
+	u := *remoteURL
+	if !u.IsSSH() {
+		u.User = nil
+	}
+	return u.String()
+}
+
+// BlobFetcher is implemented by VCSSyncers that may leave blobs out of the
+// local repository, and can fetch them from the remote on demand.
+type BlobFetcher interface {
+	// FetchMissingBlobs fetches all blobs below the given paths of treeish
+	// that are not present in the local repository. If no paths are given,
+	// the whole tree is considered. Blobs of paths that are excluded from
+	// the repo by configuration are never fetched.
+	FetchMissingBlobs(ctx context.Context, repoName api.RepoName, dir common.GitDir, treeish string, paths []string) error
+}
+
+// IsPartialClone returns true if the repository in dir contains objects that
+// were fetched from a promisor remote, ie. blobs might be missing locally.
+func IsPartialClone(dir common.GitDir) bool {
+	// Git marks every packfile received from a promisor remote with a
+	// .promisor file. This is cheaper than asking git for its config.
+	matches, _ := filepath.Glob(dir.Path("objects", "pack", "*.promisor"))
+	return len(matches) > 0
+}
+
+// FetchMissingBlobs implements BlobFetcher. Blobs below sparseExcludePaths are
+// never fetched.
+func (s *gitRepoSyncer) FetchMissingBlobs(ctx context.Context, repoName api.RepoName, dir common.GitDir, treeish string, paths []string) error {
+	missing, err := s.missingBlobs(ctx, dir, treeish, sparsePathspecs(paths, s.sparseExcludePaths))
+	if err != nil {
+		return err
+	}
+	return s.fetchBlobs(ctx, repoName, dir, missing)
+}
+
+// fetchBlobs fetches the blobs with the given IDs from the remote of repoName.
+func (s *gitRepoSyncer) fetchBlobs(ctx context.Context, repoName api.RepoName, dir common.GitDir, missing []string) error {
+	if len(missing) == 0 {
+		return nil
+	}
+
+	source, err := s.getRemoteURLSource(ctx, repoName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get remote URL source for %s", repoName)
+	}
+	remoteURL, err := source.RemoteURL(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get remote URL")
+	}
+
+	s.logger.Debug("fetching missing blobs",
+		log.String("repo", string(repoName)),
+		log.Int("count", len(missing)))
+
+	if err := s.configurePromisorRemote(ctx, dir, remoteURL); err != nil {
+		return err
+	}
+
+	// This mirrors what git itself does when lazily fetching objects from a
+	// promisor remote: we don't need to negotiate, as we already know exactly
+	// which objects we want.
+	cmd := s.gitCommand(ctx,
+		"-c", "fetch.negotiationAlgorithm=noop",
+		"fetch", "--no-tags", "--no-write-fetch-head", "--recurse-submodules=no",
+		"--filter="+partialCloneFilter, "--stdin", promisorRemote)
+	cmd.Stdin = strings.NewReader(strings.Join(missing, "\n") + "\n")
+	dir.Set(cmd)
+	executil.ConfigureNamedRemoteGitCommand(cmd, s.gitBinary, remoteURL)
+
+	redactor := urlredactor.New(remoteURL)
+	out, err := s.recordingCommandFactory.WrapWithRepoName(ctx, s.logger, repoName, cmd).WithRedactorFunc(redactor.Redact).CombinedOutput()
+	if err != nil {
+		if ctxerr := ctx.Err(); ctxerr != nil {
+			err = ctxerr
+		}
+		return errors.Wrapf(err, "failed to fetch %d missing blobs: %s", len(missing), redactor.Redact(string(out)))
+	}
+
+	return nil
+}
+
+// missingBlobs returns the IDs of the objects below paths of treeish that are
+// not present in the local repository. It never causes git to lazily fetch
+// objects.
+func (s *gitRepoSyncer) missingBlobs(ctx context.Context, dir common.GitDir, treeish string, paths []string) ([]string, error) {
+	if strings.HasPrefix(treeish, "-") {
+		return nil, errors.Errorf("invalid treeish %q", treeish)
+	}
+	return s.missingObjects(ctx, dir, []string{"--no-walk", treeish}, paths)
+}
+
+// missingObjects returns the IDs of the objects below paths reachable from the
+// given rev-list revision arguments that are not present in the local
+// repository. It never causes git to lazily fetch objects.
+func (s *gitRepoSyncer) missingObjects(ctx context.Context, dir common.GitDir, revs []string, paths []string) ([]string, error) {
+	args := append([]string{"rev-list", "--objects", "--missing=print"}, revs...)
+	args = append(append(args, "--"), paths...)
+	cmd := s.gitCommand(ctx, args...)
+	dir.Set(cmd)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list missing objects: %s", stderr.String())
+	}
+
+	var missing []string
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		if oid, ok := strings.CutPrefix(sc.Text(), "?"); ok {
+			missing = append(missing, oid)
+		}
+	}
+	return missing, sc.Err()
+}
@@ -0,0 +1,212 @@
+package vcssyncer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sourcegraph/log/logtest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/common"
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/git"
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/git/gitcli"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+	"github.com/sourcegraph/sourcegraph/internal/wrexec"
+)
+
+// makePartialCloneRemote creates a remote that allows serving partial clones
+// and returns its directory and the commit it contains.
+func makePartialCloneRemote(t *testing.T) (remoteDir, commit string) {
+	t.Helper()
+	remoteDir = t.TempDir()
+	runGit := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@a.com",
+			"GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@a.com",
+		)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	runGit(remoteDir, "init")
+	runGit(remoteDir, "config", "uploadpack.allowFilter", "true")
+	runGit(remoteDir, "config", "uploadpack.allowAnySHA1InWant", "true")
+	require.NoError(t, os.WriteFile(filepath.Join(remoteDir, "a.txt"), []byte("a"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(remoteDir, "b.txt"), []byte("b"), 0o644))
+	runGit(remoteDir, "add", ".")
+	runGit(remoteDir, "commit", "-m", "initial")
+	return remoteDir, runGit(remoteDir, "rev-parse", "HEAD")
+}
+
+func TestGitRepoSyncer_PartialClone(t *testing.T) {
+	ctx := context.Background()
+
+	remoteDir, commit := makePartialCloneRemote(t)
+	remoteURL, err := vcs.ParseURL("file://" + filepath.ToSlash(remoteDir))
+	require.NoError(t, err)
+
+	s := NewGitRepoSyncer(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), "", func(ctx context.Context, name api.RepoName) (RemoteURLSource, error) {
+		return RemoteURLSourceFunc(func(ctx context.Context) (*vcs.URL, error) {
+			return remoteURL, nil
+		}), nil
+	})
+	s.partialClone = true
+
+	repoName := api.RepoName("example.com/partial")
+	tmpDir := filepath.Join(t.TempDir(), ".git")
+	require.NoError(t, s.Clone(ctx, repoName, "", tmpDir, io.Discard))
+	dir := common.GitDir(tmpDir)
+
+	require.True(t, IsPartialClone(dir))
+
+	missing, err := s.missingBlobs(ctx, dir, commit, nil)
+	require.NoError(t, err)
+	require.Len(t, missing, 2, "expected all blobs to be left out of the clone")
+
+	require.NoError(t, s.FetchMissingBlobs(ctx, repoName, dir, commit, []string{"a.txt"}))
+
+	missing, err = s.missingBlobs(ctx, dir, commit, []string{"a.txt"})
+	require.NoError(t, err)
+	require.Empty(t, missing)
+
+	missing, err = s.missingBlobs(ctx, dir, commit, []string{"b.txt"})
+	require.NoError(t, err)
+	require.Len(t, missing, 1, "expected blobs of other paths to still be missing")
+
+	// Fetching again is a no-op.
+	require.NoError(t, s.FetchMissingBlobs(ctx, repoName, dir, commit, []string{"a.txt"}))
+
+	_, err = s.missingBlobs(ctx, dir, "--output=foo", nil)
+	require.Error(t, err)
+}
+
+// servePrivateRemote serves the repo in remoteDir over HTTP, requiring basic
+// auth. It returns the URL of the server and a function returning the
+// Authorization headers it received.
+func servePrivateRemote(t *testing.T, remoteDir string) (url string, gotAuth func() []string) {
+	t.Helper()
+	gitPath, err := exec.LookPath("git")
+	require.NoError(t, err)
+	var auth []string
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, ok := r.BasicAuth(); !ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		auth = append(auth, r.Header.Get("Authorization"))
+		mu.Unlock()
+		(&cgi.Handler{
+			Path: gitPath,
+			Args: []string{"http-backend"},
+			Env:  []string{"GIT_PROJECT_ROOT=" + remoteDir, "GIT_HTTP_EXPORT_ALL=1"},
+		}).ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), auth...)
+	}
+}
+
+func TestGitRepoSyncer_PartialClone_NoCredentialsInConfig(t *testing.T) {
+	ctx := context.Background()
+
+	remoteDir, commit := makePartialCloneRemote(t)
+
+	// Serve the remote over HTTP, so that credentials in the remote URL are
+	// actually used.
+	srvURL, gotAuth := servePrivateRemote(t, remoteDir)
+
+	for _, userinfo := range []string{"secrettoken", "user:secretpassword"} {
+		t.Run(userinfo, func(t *testing.T) {
+			remoteURL, err := vcs.ParseURL(strings.Replace(srvURL, "http://", "http://"+userinfo+"@", 1) + "/.git")
+			require.NoError(t, err)
+
+			s := NewGitRepoSyncer(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), "", func(ctx context.Context, name api.RepoName) (RemoteURLSource, error) {
+				return RemoteURLSourceFunc(func(ctx context.Context) (*vcs.URL, error) {
+					return remoteURL, nil
+				}), nil
+			})
+			s.partialClone = true
+
+			repoName := api.RepoName("example.com/partial")
+			tmpDir := filepath.Join(t.TempDir(), ".git")
+			require.NoError(t, s.Clone(ctx, repoName, "", tmpDir, io.Discard))
+			dir := common.GitDir(tmpDir)
+			require.NoError(t, s.Fetch(ctx, repoName, dir, io.Discard))
+			require.NoError(t, s.FetchMissingBlobs(ctx, repoName, dir, commit, []string{"a.txt"}))
+
+			missing, err := s.missingBlobs(ctx, dir, commit, []string{"a.txt"})
+			require.NoError(t, err)
+			require.Empty(t, missing)
+
+			config, err := os.ReadFile(filepath.Join(tmpDir, "config"))
+			require.NoError(t, err)
+			require.NotContains(t, string(config), "secret")
+			require.NotContains(t, string(config), "@127.0.0.1")
+			require.Contains(t, string(config), `[remote "`+promisorRemote+`"]`)
+		})
+	}
+
+	require.NotEmpty(t, gotAuth(), "expected credentials to be sent to the remote")
+}
+
+func TestGitRepoSyncer_PartialClone_PrivateBlame(t *testing.T) {
+	ctx := context.Background()
+
+	remoteDir, commit := makePartialCloneRemote(t)
+	srvURL, _ := servePrivateRemote(t, remoteDir)
+	remoteURL, err := vcs.ParseURL(strings.Replace(srvURL, "http://", "http://secrettoken@", 1) + "/.git")
+	require.NoError(t, err)
+
+	s := NewGitRepoSyncer(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), "", func(ctx context.Context, name api.RepoName) (RemoteURLSource, error) {
+		return RemoteURLSourceFunc(func(ctx context.Context) (*vcs.URL, error) {
+			return remoteURL, nil
+		}), nil
+	})
+	s.partialClone = true
+
+	repoName := api.RepoName("example.com/partial")
+	tmpDir := filepath.Join(t.TempDir(), ".git")
+	require.NoError(t, s.Clone(ctx, repoName, "", tmpDir, io.Discard))
+	dir := common.GitDir(tmpDir)
+
+	blame := func(opts ...gitcli.BackendOptionFunc) error {
+		backend := gitcli.NewBackend(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), "", dir, repoName, opts...)
+		r, err := backend.Blame(ctx, api.CommitID(commit), "a.txt", git.BlameOptions{})
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		hunk, err := r.Read()
+		if err != nil {
+			return err
+		}
+		require.Equal(t, api.CommitID(commit), hunk.CommitID)
+		return nil
+	}
+
+	// Without credentials, git can't lazily fetch the blob.
+	require.Error(t, blame())
+
+	require.NoError(t, blame(gitcli.WithLazyFetchRemoteURL(func(context.Context) (*vcs.URL, error) {
+		return remoteURL, nil
+	})))
+}
@@ -0,0 +1,42 @@
+package vcssyncer
+
+import (
+	"context"
+	"io"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+)
+
+// PeerCloner is implemented by VCSSyncers that can clone a repo from a
+// gitserver peer that serves it, instead of from the code host.
+type PeerCloner interface {
+	// CanCloneFromPeer returns false if a clone from a peer would differ from
+	// a clone from the code host in a way that the subsequent Fetch doesn't
+	// fix, e.g. because of options that only apply when cloning.
+	CanCloneFromPeer() bool
+	// CloneFromPeer clones repo into tmpPath from peer like Clone does from
+	// the code host. The peer might lag behind the code host, so the clone
+	// must be followed by a Fetch from the code host.
+	CloneFromPeer(ctx context.Context, repo api.RepoName, peer *vcs.URL, tmpPath string, progressWriter io.Writer) error
+}
+
+var _ PeerCloner = &gitRepoSyncer{}
+
+// CanCloneFromPeer implements PeerCloner. Clones from peers are plain full
+// clones, so repos that are partially cloned, sparse, limited to some
+// refspecs or fetch Git LFS objects are always cloned from the code host.
+func (s *gitRepoSyncer) CanCloneFromPeer() bool {
+	return !s.filterBlobs() && len(s.fetchRefspecs) == 0 && !s.fetchLFS
+}
+
+// CloneFromPeer implements PeerCloner.
+func (s *gitRepoSyncer) CloneFromPeer(ctx context.Context, repo api.RepoName, peer *vcs.URL, tmpPath string, progressWriter io.Writer) error {
+	peerSyncer := *s
+	peerSyncer.getRemoteURLSource = func(context.Context, api.RepoName) (RemoteURLSource, error) {
+		return RemoteURLSourceFunc(func(context.Context) (*vcs.URL, error) {
+			return peer, nil
+		}), nil
+	}
+	return peerSyncer.Clone(ctx, repo, "", tmpPath, progressWriter)
+}
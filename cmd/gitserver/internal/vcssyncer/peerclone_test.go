@@ -0,0 +1,89 @@
+package vcssyncer
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/log/logtest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/common"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+	"github.com/sourcegraph/sourcegraph/internal/wrexec"
+)
+
+func TestGitRepoSyncer_CloneFromPeer(t *testing.T) {
+	ctx := context.Background()
+
+	runGit := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@a.com",
+			"GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@a.com",
+		)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+
+	remoteDir := t.TempDir()
+	runGit(remoteDir, "init")
+	require.NoError(t, os.WriteFile(filepath.Join(remoteDir, "a.txt"), []byte("a"), 0o644))
+	runGit(remoteDir, "add", ".")
+	runGit(remoteDir, "commit", "-m", "initial")
+
+	// The peer has the repo cloned, but lags behind the code host.
+	peerDir := filepath.Join(t.TempDir(), "peer.git")
+	runGit(remoteDir, "clone", "--bare", remoteDir, peerDir)
+
+	require.NoError(t, os.WriteFile(filepath.Join(remoteDir, "a.txt"), []byte("b"), 0o644))
+	runGit(remoteDir, "commit", "-am", "second")
+	head := runGit(remoteDir, "rev-parse", "HEAD")
+
+	remoteURL, err := vcs.ParseURL("file://" + filepath.ToSlash(remoteDir))
+	require.NoError(t, err)
+	peerURL, err := vcs.ParseURL("file://" + filepath.ToSlash(peerDir))
+	require.NoError(t, err)
+
+	newSyncer := func(t *testing.T) *gitRepoSyncer {
+		return NewGitRepoSyncer(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), "", func(ctx context.Context, name api.RepoName) (RemoteURLSource, error) {
+			return RemoteURLSourceFunc(func(ctx context.Context) (*vcs.URL, error) {
+				return remoteURL, nil
+			}), nil
+		})
+	}
+
+	t.Run("fetch from code host after clone from peer", func(t *testing.T) {
+		s := newSyncer(t)
+		require.True(t, s.CanCloneFromPeer())
+
+		tmpDir := filepath.Join(t.TempDir(), ".git")
+		require.NoError(t, s.CloneFromPeer(ctx, "example.com/peer", peerURL, tmpDir, io.Discard))
+		require.NoError(t, s.Fetch(ctx, "example.com/peer", common.GitDir(tmpDir), io.Discard))
+
+		require.Equal(t, head, runGit(tmpDir, "rev-parse", "HEAD"))
+	})
+
+	t.Run("clone options", func(t *testing.T) {
+		for name, configure := range map[string]func(s *gitRepoSyncer){
+			"partial clone":  func(s *gitRepoSyncer) { s.partialClone = true },
+			"sparse":         func(s *gitRepoSyncer) { s.sparseExcludePaths = []string{"vendor/"} },
+			"fetch refspecs": func(s *gitRepoSyncer) { s.fetchRefspecs = []string{"+refs/heads/*:refs/heads/*"} },
+			"git lfs":        func(s *gitRepoSyncer) { s.fetchLFS = true },
+		} {
+			t.Run(name, func(t *testing.T) {
+				s := newSyncer(t)
+				configure(s)
+				require.False(t, s.CanCloneFromPeer())
+			})
+		}
+	})
+}
@@ -28,6 +28,9 @@ type perforceDepotSyncer struct {
 	logger                  log.Logger
 	recordingCommandFactory *wrexec.RecordingCommandFactory
 	fs                      gitserverfs.FS
+	// gitBinary is the git executable that converts depots with git p4 and
+	// verifies the converted repos.
+	gitBinary string
 
 	// maxChanges indicates to only import at most n changes when possible.
 	maxChanges int
@@ -43,11 +46,14 @@ type perforceDepotSyncer struct {
 	getRemoteURLSource func(ctx context.Context, name api.RepoName) (RemoteURLSource, error)
 }
 
-func NewPerforceDepotSyncer(logger log.Logger, r *wrexec.RecordingCommandFactory, fs gitserverfs.FS, connection *schema.PerforceConnection, getRemoteURLSource func(ctx context.Context, name api.RepoName) (RemoteURLSource, error)) VCSSyncer {
+// NewPerforceDepotSyncer returns a syncer for Perforce depots that runs the git
+// executable gitBinary. If gitBinary is empty, "git" is used.
+func NewPerforceDepotSyncer(logger log.Logger, r *wrexec.RecordingCommandFactory, gitBinary string, fs gitserverfs.FS, connection *schema.PerforceConnection, getRemoteURLSource func(ctx context.Context, name api.RepoName) (RemoteURLSource, error)) VCSSyncer {
 	return &perforceDepotSyncer{
 		logger:                  logger.Scoped("PerforceDepotSyncer"),
 		recordingCommandFactory: r,
 		fs:                      fs,
+		gitBinary:               gitExecutable(gitBinary),
 		maxChanges:              int(connection.MaxChanges),
 		p4Client:                connection.P4Client,
 		fusionConfig:            configureFusionClient(connection),
@@ -129,7 +135,7 @@ func (s *perforceDepotSyncer) Clone(ctx context.Context, repo api.RepoName, _ co
 		// Example: git p4 clone --bare --max-changes 1000 //Sourcegraph/@all /tmp/clone-584194180/.git
 		args := append([]string{"p4", "clone", "--bare"}, s.p4CommandOptions()...)
 		args = append(args, depot+"@all", tmpPath)
-		cmd = exec.CommandContext(ctx, "git", args...)
+		cmd = exec.CommandContext(ctx, s.gitBinary, args...)
 	}
 	cmd.Env, err = s.p4CommandEnv(tmpPath, p4port, p4user, p4passwd)
 	if err != nil {
@@ -148,7 +154,7 @@ func (s *perforceDepotSyncer) Clone(ctx context.Context, repo api.RepoName, _ co
 
 	// Verify that p4-fusion generated a valid git repository.
 	tryWrite(s.logger, progressWriter, "Verifying integrity of converted repository\n")
-	fsck := exec.CommandContext(ctx, "git", "fsck", "--progress")
+	fsck := exec.CommandContext(ctx, s.gitBinary, "fsck", "--progress")
 	fsck.Dir = tmpPath
 	exitCode, err = executil.RunCommandWriteOutput(
 		ctx,
@@ -168,7 +174,7 @@ func (s *perforceDepotSyncer) Clone(ctx context.Context, repo api.RepoName, _ co
 	// --local passes --local to git-pack-objects. Not needed today but doesn't cost a penny and should we ever start deduping objects, this will keep objects from the alternative stores unpacked
 	// --window-memory to constrain the memory usage of delta-compression, success is more important than disk space efficiency
 	// --cruft --cruft-expiration=2.weeks.ago move unused objects into a cruft pack to have some evidence of something going wrong, also don't expire them just yet
-	repack := exec.CommandContext(ctx, "git", "repack", "-d", "--local", "--cruft", "--cruft-expiration=2.weeks.ago", "--write-bitmap-index", "--window-memory=100m")
+	repack := exec.CommandContext(ctx, s.gitBinary, "repack", "-d", "--local", "--cruft", "--cruft-expiration=2.weeks.ago", "--write-bitmap-index", "--window-memory=100m")
 	repack.Dir = tmpPath
 	exitCode, err = executil.RunCommandWriteOutput(
 		ctx,
@@ -242,7 +248,7 @@ func (s *perforceDepotSyncer) Fetch(ctx context.Context, repoName api.RepoName,
 	} else {
 		// Example: git p4 sync --max-changes 1000
 		args := append([]string{"p4", "sync"}, s.p4CommandOptions()...)
-		cmd = wrexec.CommandContext(ctx, nil, "git", args...)
+		cmd = wrexec.CommandContext(ctx, nil, s.gitBinary, args...)
 	}
 	cmd.Env, err = s.p4CommandEnv(string(dir), p4port, p4user, p4passwd)
 	if err != nil {
@@ -266,7 +272,7 @@ func (s *perforceDepotSyncer) Fetch(ctx context.Context, repoName api.RepoName,
 		}
 
 		// Force update "master" to "refs/remotes/p4/master" where changes are synced into
-		cmd = wrexec.CommandContext(ctx, nil, "git", "branch", "-f", "master", "refs/remotes/p4/master")
+		cmd = wrexec.CommandContext(ctx, nil, s.gitBinary, "branch", "-f", "master", "refs/remotes/p4/master")
 		cmd.Cmd.Env = append(os.Environ(),
 			"P4PORT="+p4port,
 			"P4USER="+p4user,
@@ -26,6 +26,7 @@ func NewPythonPackagesSyncer(
 	svc *dependencies.Service,
 	client *pypi.Client,
 	fs gitserverfs.FS,
+	gitBinary string,
 	getRemoteURLSource func(ctx context.Context, name api.RepoName) (RemoteURLSource, error),
 ) VCSSyncer {
 	return &vcsPackagesSyncer{
@@ -37,6 +38,7 @@ func NewPythonPackagesSyncer(
 		configDeps:         connection.Dependencies,
 		source:             &pythonPackagesSyncer{client: client, fs: fs},
 		getRemoteURLSource: getRemoteURLSource,
+		gitBinary:          gitBinary,
 		fs:                 fs,
 	}
 }
@@ -1,12 +1,9 @@
 package vcssyncer
 
 import (
-	"context"
-	"os/exec"
 	"strings"
 
 	"github.com/sourcegraph/sourcegraph/internal/env"
-	"github.com/sourcegraph/sourcegraph/internal/vcs"
 )
 
 // HACK(keegancsmith) workaround to experiment with cloning less in a large
@@ -21,6 +18,7 @@ func useRefspecOverrides() bool {
 
 // HACK(keegancsmith) workaround to experiment with cloning less in a large
 // monorepo. https://github.com/sourcegraph/customer/issues/19
-func refspecOverridesFetchCmd(ctx context.Context, remoteURL *vcs.URL) *exec.Cmd {
-	return exec.CommandContext(ctx, "git", append([]string{"fetch", "--progress", "--prune", remoteURL.String()}, refspecOverrides...)...)
+func refspecOverridesFetchArgs(remote string, flags ...string) []string {
+	args := append(append([]string{"fetch"}, flags...), remote)
+	return append(args, refspecOverrides...)
 }
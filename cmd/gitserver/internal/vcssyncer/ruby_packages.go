@@ -26,6 +26,7 @@ func NewRubyPackagesSyncer(
 	svc *dependencies.Service,
 	client *rubygems.Client,
 	fs gitserverfs.FS,
+	gitBinary string,
 	getRemoteURLSource func(ctx context.Context, name api.RepoName) (RemoteURLSource, error),
 ) VCSSyncer {
 	return &vcsPackagesSyncer{
@@ -38,6 +39,7 @@ func NewRubyPackagesSyncer(
 		source:             &rubyDependencySource{client: client},
 		fs:                 fs,
 		getRemoteURLSource: getRemoteURLSource,
+		gitBinary:          gitBinary,
 	}
 }
 
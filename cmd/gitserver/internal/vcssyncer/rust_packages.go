@@ -23,6 +23,7 @@ func NewRustPackagesSyncer(
 	svc *dependencies.Service,
 	client *crates.Client,
 	fs gitserverfs.FS,
+	gitBinary string,
 	getRemoteURLSource func(ctx context.Context, name api.RepoName) (RemoteURLSource, error),
 ) VCSSyncer {
 	return &vcsPackagesSyncer{
@@ -35,6 +36,7 @@ func NewRustPackagesSyncer(
 		source:             &rustDependencySource{client: client},
 		fs:                 fs,
 		getRemoteURLSource: getRemoteURLSource,
+		gitBinary:          gitBinary,
 	}
 }
 
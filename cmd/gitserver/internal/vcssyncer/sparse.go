@@ -0,0 +1,60 @@
+package vcssyncer
+
+import (
+	"context"
+	"io"
+
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/common"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// sparsePathspecs returns the pathspecs selecting everything below paths but
+// the given excluded paths. If paths is empty, everything but the excluded
+// paths is selected.
+func sparsePathspecs(paths, excludePaths []string) []string {
+	if len(excludePaths) == 0 {
+		return paths
+	}
+	pathspecs := make([]string, 0, len(paths)+len(excludePaths)+1)
+	if len(paths) == 0 {
+		pathspecs = append(pathspecs, ":(top)")
+	}
+	pathspecs = append(pathspecs, paths...)
+	for _, p := range excludePaths {
+		pathspecs = append(pathspecs, ":(top,exclude)"+p)
+	}
+	return pathspecs
+}
+
+// fetchIncludedBlobs fetches the blobs of all refs that are not below any of
+// the sparseExcludePaths. We mirror repositories as bare repositories, so there
+// is no working copy to apply a sparse-checkout to. Instead, we fetch a
+// blobless partial clone and only materialize the blobs of the paths we want.
+//
+// We fetch the blobs of the whole history, not just of HEAD, so that commands
+// like blame, diff and log -p work on included paths without having to fetch
+// blobs first. The repo is marked with common.NoLazyFetchFile, so that git
+// never fetches the contents of excluded paths by itself.
+func (s *gitRepoSyncer) fetchIncludedBlobs(ctx context.Context, repoName api.RepoName, dir common.GitDir, progressWriter io.Writer) error {
+	if len(s.sparseExcludePaths) == 0 {
+		return errors.Wrap(dir.SetNoLazyFetch(false), "failed to remove no lazy fetch marker")
+	}
+
+	if err := dir.SetNoLazyFetch(true); err != nil {
+		return errors.Wrap(err, "failed to write no lazy fetch marker")
+	}
+
+	tryWrite(s.logger, progressWriter, "Fetching file contents outside of excluded paths\n")
+
+	missing, err := s.missingObjects(ctx, dir, []string{"--all"}, sparsePathspecs(nil, s.sparseExcludePaths))
+	if err != nil {
+		return errors.Wrap(err, "failed to list file contents outside of excluded paths")
+	}
+	if err := s.fetchBlobs(ctx, repoName, dir, missing); err != nil {
+		return errors.Wrap(err, "failed to fetch file contents outside of excluded paths")
+	}
+
+	tryWrite(s.logger, progressWriter, "Fetched file contents outside of excluded paths\n")
+	return nil
+}
@@ -0,0 +1,142 @@
+package vcssyncer
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/log/logtest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/common"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+	"github.com/sourcegraph/sourcegraph/internal/wrexec"
+)
+
+func TestGitRepoSyncer_SparseExcludePaths(t *testing.T) {
+	ctx := context.Background()
+
+	remoteDir := t.TempDir()
+	runGit := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@a.com",
+			"GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@a.com",
+		)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	writeFile := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(remoteDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	runGit(remoteDir, "init")
+	runGit(remoteDir, "config", "uploadpack.allowFilter", "true")
+	runGit(remoteDir, "config", "uploadpack.allowAnySHA1InWant", "true")
+	writeFile("README.md", "readme")
+	writeFile("src/main.go", "package main")
+	writeFile("vendor/lib/lib.go", "package lib")
+	writeFile("assets/generated/big.bin", "generated")
+	runGit(remoteDir, "add", ".")
+	runGit(remoteDir, "commit", "-m", "initial")
+	commit := runGit(remoteDir, "rev-parse", "HEAD")
+
+	remoteURL, err := vcs.ParseURL("file://" + filepath.ToSlash(remoteDir))
+	require.NoError(t, err)
+
+	s := NewGitRepoSyncer(logtest.Scoped(t), wrexec.NewNoOpRecordingCommandFactory(), "", func(ctx context.Context, name api.RepoName) (RemoteURLSource, error) {
+		return RemoteURLSourceFunc(func(ctx context.Context) (*vcs.URL, error) {
+			return remoteURL, nil
+		}), nil
+	})
+	s.sparseExcludePaths = []string{"vendor", "assets/generated"}
+
+	repoName := api.RepoName("example.com/sparse")
+	tmpDir := filepath.Join(t.TempDir(), ".git")
+	require.NoError(t, s.Clone(ctx, repoName, "", tmpDir, io.Discard))
+	dir := common.GitDir(tmpDir)
+
+	require.True(t, IsPartialClone(dir))
+
+	// The contents of excluded paths are not on disk.
+	missing, err := s.missingBlobs(ctx, dir, commit, []string{"vendor", "assets/generated"})
+	require.NoError(t, err)
+	require.Len(t, missing, 2)
+
+	// Everything else is, so reading it doesn't need the remote.
+	missing, err = s.missingBlobs(ctx, dir, commit, []string{"README.md", "src"})
+	require.NoError(t, err)
+	require.Empty(t, missing)
+
+	cmd := exec.Command("git", "cat-file", "-p", commit+":src/main.go")
+	dir.Set(cmd)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.Equal(t, "package main", string(out))
+
+	// New files outside of excluded paths are fetched on the next fetch.
+	writeFile("src/main.go", "package main\n\nfunc main() {}")
+	writeFile("src/util.go", "package main")
+	writeFile("vendor/lib/util.go", "package lib // util")
+	runGit(remoteDir, "add", ".")
+	runGit(remoteDir, "commit", "-m", "second")
+	commit = runGit(remoteDir, "rev-parse", "HEAD")
+
+	require.NoError(t, s.Fetch(ctx, repoName, dir, io.Discard))
+
+	missing, err = s.missingBlobs(ctx, dir, commit, []string{"src"})
+	require.NoError(t, err)
+	require.Empty(t, missing)
+
+	missing, err = s.missingBlobs(ctx, dir, commit, []string{"vendor"})
+	require.NoError(t, err)
+	require.Len(t, missing, 2)
+
+	// The history of included paths is available too, so blame and diffs
+	// work without fetching blobs first.
+	cmd = exec.Command("git", "blame", "--porcelain", commit, "--", "src/main.go")
+	dir.Set(cmd)
+	out, err = cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.Contains(t, string(out), "summary second")
+	require.Contains(t, string(out), "previous ")
+
+	cmd = exec.Command("git", "log", "-p", commit, "--", "src")
+	dir.Set(cmd)
+	out, err = cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.Contains(t, string(out), "+func main() {}")
+
+	// Fetching the missing blobs of the whole tree, eg. for an archive,
+	// leaves out excluded paths.
+	require.NoError(t, s.FetchMissingBlobs(ctx, repoName, dir, commit, nil))
+	require.NoError(t, s.FetchMissingBlobs(ctx, repoName, dir, commit, []string{"vendor"}))
+	missing, err = s.missingBlobs(ctx, dir, commit, []string{"vendor"})
+	require.NoError(t, err)
+	require.Len(t, missing, 2)
+
+	// Reading excluded paths fails instead of lazily fetching them.
+	cmd = exec.Command("git", "cat-file", "-p", commit+":vendor/lib/lib.go")
+	dir.Set(cmd)
+	out, err = cmd.CombinedOutput()
+	require.Error(t, err, string(out))
+	missing, err = s.missingBlobs(ctx, dir, commit, []string{"vendor"})
+	require.NoError(t, err)
+	require.Len(t, missing, 2)
+}
+
+func TestSparsePathspecs(t *testing.T) {
+	require.Equal(t, []string{":(top)", ":(top,exclude)vendor", ":(top,exclude)assets/generated"}, sparsePathspecs(nil, []string{"vendor", "assets/generated"}))
+	require.Equal(t, []string{"src", ":(top,exclude)vendor"}, sparsePathspecs([]string{"src"}, []string{"vendor"}))
+	require.Equal(t, []string{"src"}, sparsePathspecs([]string{"src"}, nil))
+}
@@ -3,6 +3,7 @@ package vcssyncer
 import (
 	"context"
 	"io"
+	"strings"
 
 	jsoniter "github.com/json-iterator/go"
 
@@ -77,6 +78,12 @@ type NewVCSSyncerOpts struct {
 	Logger                  log.Logger
 	FS                      gitserverfs.FS
 	GetRemoteURLSource      func(ctx context.Context, repo api.RepoName) (RemoteURLSource, error)
+	// HTTPClientFactory is used to talk to package hosts. If nil,
+	// httpcli.ExternalClientFactory is used.
+	HTTPClientFactory *httpcli.Factory
+	// GitBinary is the git executable run by all syncers. If empty, "git" is
+	// used.
+	GitBinary string
 }
 
 func NewVCSSyncer(ctx context.Context, opts *NewVCSSyncerOpts) (VCSSyncer, error) {
@@ -110,78 +117,7 @@ func NewVCSSyncer(ctx context.Context, opts *NewVCSSyncerOpts) (VCSSyncer, error
 		return "", errors.Errorf("unexpected empty Sources map in %v", r)
 	}
 
-	out, err := func() (VCSSyncer, error) {
-		switch r.ExternalRepo.ServiceType {
-		case extsvc.TypePerforce:
-			var c schema.PerforceConnection
-			if _, err := extractOptions(&c); err != nil {
-				return nil, err
-			}
-
-			return NewPerforceDepotSyncer(opts.Logger, opts.RecordingCommandFactory, opts.FS, &c, opts.GetRemoteURLSource), nil
-		case extsvc.TypeJVMPackages:
-			var c schema.JVMPackagesConnection
-			if _, err := extractOptions(&c); err != nil {
-				return nil, err
-			}
-			return NewJVMPackagesSyncer(&c, opts.DepsSvc, opts.GetRemoteURLSource, opts.CoursierCacheDir, opts.FS), nil
-		case extsvc.TypeNpmPackages:
-			var c schema.NpmPackagesConnection
-			urn, err := extractOptions(&c)
-			if err != nil {
-				return nil, err
-			}
-			cli, err := npm.NewHTTPClient(urn, c.Registry, c.Credentials, httpcli.ExternalClientFactory)
-			if err != nil {
-				return nil, err
-			}
-			return NewNpmPackagesSyncer(c, opts.DepsSvc, cli, opts.FS, opts.GetRemoteURLSource), nil
-		case extsvc.TypeGoModules:
-			var c schema.GoModulesConnection
-			urn, err := extractOptions(&c)
-			if err != nil {
-				return nil, err
-			}
-			cli := gomodproxy.NewClient(urn, c.Urls, httpcli.ExternalClientFactory)
-			return NewGoModulesSyncer(&c, opts.DepsSvc, cli, opts.FS, opts.GetRemoteURLSource), nil
-		case extsvc.TypePythonPackages:
-			var c schema.PythonPackagesConnection
-			urn, err := extractOptions(&c)
-			if err != nil {
-				return nil, err
-			}
-			cli, err := pypi.NewClient(urn, c.Urls, httpcli.ExternalClientFactory)
-			if err != nil {
-				return nil, err
-			}
-			return NewPythonPackagesSyncer(&c, opts.DepsSvc, cli, opts.FS, opts.GetRemoteURLSource), nil
-		case extsvc.TypeRustPackages:
-			var c schema.RustPackagesConnection
-			urn, err := extractOptions(&c)
-			if err != nil {
-				return nil, err
-			}
-			cli, err := crates.NewClient(urn, httpcli.ExternalClientFactory)
-			if err != nil {
-				return nil, err
-			}
-			return NewRustPackagesSyncer(&c, opts.DepsSvc, cli, opts.FS, opts.GetRemoteURLSource), nil
-		case extsvc.TypeRubyPackages:
-			var c schema.RubyPackagesConnection
-			urn, err := extractOptions(&c)
-			if err != nil {
-				return nil, err
-			}
-			cli, err := rubygems.NewClient(urn, c.Repository, httpcli.ExternalClientFactory)
-			if err != nil {
-				return nil, err
-			}
-			return NewRubyPackagesSyncer(&c, opts.DepsSvc, cli, opts.FS, opts.GetRemoteURLSource), nil
-		}
-
-		return NewGitRepoSyncer(opts.Logger, opts.RecordingCommandFactory, opts.GetRemoteURLSource), nil
-	}()
-
+	out, err := newVCSSyncer(opts, r.ExternalRepo.ServiceType, len(r.Sources) > 0, extractOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -189,6 +125,118 @@ func NewVCSSyncer(ctx context.Context, opts *NewVCSSyncerOpts) (VCSSyncer, error
 	return newInstrumentedSyncer(out), nil
 }
 
+// newVCSSyncer constructs the VCSSyncer for repos of the given service type.
+// extractOptions unmarshals the code host connection config of the repo into
+// its argument and returns the URN of the external service. hasConnection is
+// false if the repo doesn't belong to any external service.
+func newVCSSyncer(opts *NewVCSSyncerOpts, serviceType string, hasConnection bool, extractOptions func(connection any) (string, error)) (VCSSyncer, error) {
+	httpFactory := opts.HTTPClientFactory
+	if httpFactory == nil {
+		httpFactory = httpcli.ExternalClientFactory
+	}
+	// packageHostFactory returns the factory for clients talking to the
+	// package registry identified by urn, which applies the download policy
+	// of the registry.
+	packageHostFactory := func(urn string) *httpcli.Factory {
+		return httpFactory.WithMiddleware(packageDownloadMiddleware(urn, packageDownloadPolicyFor(serviceType)))
+	}
+
+	switch serviceType {
+	case extsvc.TypePerforce:
+		var c schema.PerforceConnection
+		if _, err := extractOptions(&c); err != nil {
+			return nil, err
+		}
+
+		return NewPerforceDepotSyncer(opts.Logger, opts.RecordingCommandFactory, opts.GitBinary, opts.FS, &c, opts.GetRemoteURLSource), nil
+	case extsvc.TypeJVMPackages:
+		var c schema.JVMPackagesConnection
+		if _, err := extractOptions(&c); err != nil {
+			return nil, err
+		}
+		return NewJVMPackagesSyncer(&c, opts.DepsSvc, opts.GetRemoteURLSource, opts.CoursierCacheDir, opts.FS, opts.GitBinary), nil
+	case extsvc.TypeNpmPackages:
+		var c schema.NpmPackagesConnection
+		urn, err := extractOptions(&c)
+		if err != nil {
+			return nil, err
+		}
+		cli, err := npm.NewHTTPClient(urn, c.Registry, c.Credentials, packageHostFactory(urn))
+		if err != nil {
+			return nil, err
+		}
+		return NewNpmPackagesSyncer(c, opts.DepsSvc, cli, opts.FS, opts.GitBinary, opts.GetRemoteURLSource), nil
+	case extsvc.TypeGoModules:
+		var c schema.GoModulesConnection
+		urn, err := extractOptions(&c)
+		if err != nil {
+			return nil, err
+		}
+		cli := gomodproxy.NewClient(urn, c.Urls, packageHostFactory(urn))
+		return NewGoModulesSyncer(&c, opts.DepsSvc, cli, opts.FS, opts.GitBinary, opts.GetRemoteURLSource), nil
+	case extsvc.TypePythonPackages:
+		var c schema.PythonPackagesConnection
+		urn, err := extractOptions(&c)
+		if err != nil {
+			return nil, err
+		}
+		cli, err := pypi.NewClient(urn, c.Urls, packageHostFactory(urn))
+		if err != nil {
+			return nil, err
+		}
+		return NewPythonPackagesSyncer(&c, opts.DepsSvc, cli, opts.FS, opts.GitBinary, opts.GetRemoteURLSource), nil
+	case extsvc.TypeRustPackages:
+		var c schema.RustPackagesConnection
+		urn, err := extractOptions(&c)
+		if err != nil {
+			return nil, err
+		}
+		cli, err := crates.NewClient(urn, packageHostFactory(urn))
+		if err != nil {
+			return nil, err
+		}
+		return NewRustPackagesSyncer(&c, opts.DepsSvc, cli, opts.FS, opts.GitBinary, opts.GetRemoteURLSource), nil
+	case extsvc.TypeRubyPackages:
+		var c schema.RubyPackagesConnection
+		urn, err := extractOptions(&c)
+		if err != nil {
+			return nil, err
+		}
+		cli, err := rubygems.NewClient(urn, c.Repository, packageHostFactory(urn))
+		if err != nil {
+			return nil, err
+		}
+		return NewRubyPackagesSyncer(&c, opts.DepsSvc, cli, opts.FS, opts.GitBinary, opts.GetRemoteURLSource), nil
+	}
+
+	syncer := NewGitRepoSyncer(opts.Logger, opts.RecordingCommandFactory, opts.GitBinary, opts.GetRemoteURLSource)
+	if hasConnection {
+		// All code host connections that support these options share the
+		// same option names, so we don't need to know the concrete type.
+		var c struct {
+			PartialClone       bool     `json:"partialClone"`
+			SparseExcludePaths []string `json:"sparseExcludePaths"`
+			FetchRefspecs      []string `json:"fetchRefspecs"`
+			FetchLFSObjects    bool     `json:"fetchLFSObjects"`
+		}
+		if _, err := extractOptions(&c); err != nil {
+			return nil, err
+		}
+		syncer.partialClone = c.PartialClone
+		syncer.sparseExcludePaths = c.SparseExcludePaths
+		for _, refspec := range c.FetchRefspecs {
+			// Refspecs are passed to git fetch as arguments, make sure they
+			// can't be interpreted as flags.
+			if strings.HasPrefix(refspec, "-") {
+				return nil, errors.Errorf("invalid fetch refspec %q", refspec)
+			}
+		}
+		syncer.fetchRefspecs = c.FetchRefspecs
+		syncer.fetchLFS = c.FetchLFSObjects
+	}
+	return syncer, nil
+}
+
 type notFoundError struct{ error }
 
 func (e notFoundError) NotFound() bool { return true }
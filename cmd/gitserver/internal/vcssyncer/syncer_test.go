@@ -2,6 +2,7 @@ package vcssyncer
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
@@ -72,3 +73,26 @@ func TestGetVCSSyncer(t *testing.T) {
 
 	require.Equal(t, "perforce", s.Type())
 }
+
+func TestNewVCSSyncer_GitOptions(t *testing.T) {
+	newGitSyncer := func(t *testing.T, config string) *gitRepoSyncer {
+		t.Helper()
+		s, err := newVCSSyncer(&NewVCSSyncerOpts{Logger: logtest.Scoped(t)}, extsvc.TypeOther, true, func(connection any) (string, error) {
+			return "extsvc:other:1", json.Unmarshal([]byte(config), connection)
+		})
+		require.NoError(t, err)
+		require.IsType(t, &gitRepoSyncer{}, s)
+		return s.(*gitRepoSyncer)
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		s := newGitSyncer(t, `{"url": "https://git.example.com"}`)
+		require.False(t, s.fetchLFS)
+		require.False(t, s.partialClone)
+	})
+
+	t.Run("fetch LFS objects", func(t *testing.T) {
+		s := newGitSyncer(t, `{"url": "https://git.example.com", "fetchLFSObjects": true}`)
+		require.True(t, s.fetchLFS)
+	})
+}
@@ -0,0 +1,128 @@
+package vcssyncer
+
+import (
+	"context"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// connectivityCheckTimeout bounds how long ValidateExternalServiceConfig waits
+// for the code host to respond, so that unreachable hosts fail fast instead of
+// going through all HTTP retries.
+const connectivityCheckTimeout = 30 * time.Second
+
+// errConnectivityCheckUnsupported is returned by connectivity checks of
+// syncers that have no cheap way of talking to their code host without a
+// concrete repository.
+var errConnectivityCheckUnsupported = errors.New("connectivity check not supported")
+
+// connectivityChecker is implemented by VCSSyncers and package sources that
+// can check that their code host is reachable with the configured
+// credentials.
+type connectivityChecker interface {
+	checkConnectivity(ctx context.Context) error
+}
+
+// ExternalServiceValidation is the result of ValidateExternalServiceConfig.
+type ExternalServiceValidation struct {
+	// Valid is true if a syncer could be constructed from the config and, if
+	// supported, the code host was reachable.
+	Valid bool `json:"valid"`
+	// SyncerType is the type of the syncer constructed from the config.
+	SyncerType string `json:"syncerType,omitempty"`
+	// ConnectivityChecked is true if the code host was contacted. Not all
+	// syncers support this, in which case only the config is validated.
+	ConnectivityChecked bool `json:"connectivityChecked"`
+	// Error describes why the config is invalid.
+	Error string `json:"error,omitempty"`
+}
+
+// ValidateExternalServiceConfig checks that a VCSSyncer can be constructed
+// from the given external service config the same way NewVCSSyncer does for
+// repos of such an external service, and that the code host is reachable.
+// Nothing is persisted, so this can be used to validate a config before it is
+// saved.
+//
+// If repo is set, reachability is checked by asking the syncer whether repo is
+// cloneable, e.g. with git ls-remote for git code hosts. opts.GetRemoteURLSource
+// must then return the remote URL of repo as derived from config. Otherwise,
+// only package hosts are contacted, as git code hosts can't be contacted
+// without a concrete repository.
+//
+// opts.Repo, opts.RepoStore and opts.ExternalServiceStore are not used.
+func ValidateExternalServiceConfig(ctx context.Context, opts *NewVCSSyncerOpts, kind, config string, repo api.RepoName) ExternalServiceValidation {
+	variant, err := extsvc.VariantValueOf(kind)
+	if err != nil {
+		return ExternalServiceValidation{Error: err.Error()}
+	}
+
+	extractOptions := func(connection any) (string, error) {
+		normalized, err := jsonc.Parse(config)
+		if err != nil {
+			return "", errors.Wrap(err, "normalize JSON")
+		}
+		if err = jsoniter.Unmarshal(normalized, connection); err != nil {
+			return "", errors.Wrap(err, "unmarshal JSON")
+		}
+		// The external service doesn't exist yet, so it doesn't have an ID.
+		return extsvc.URN(variant.AsKind(), 0), nil
+	}
+
+	syncer, err := newVCSSyncer(opts, variant.AsType(), true, extractOptions)
+	if err != nil {
+		return ExternalServiceValidation{Error: errors.Wrap(err, "invalid config").Error()}
+	}
+	res := ExternalServiceValidation{SyncerType: syncer.Type()}
+
+	ctx, cancel := context.WithTimeout(ctx, connectivityCheckTimeout)
+	defer cancel()
+
+	if repo != "" {
+		res.ConnectivityChecked = true
+		if err := syncer.IsCloneable(ctx, repo); err != nil {
+			res.Error = errors.Wrapf(err, "repository %s not cloneable", repo).Error()
+			return res
+		}
+		res.Valid = true
+		return res
+	}
+
+	checker, ok := syncer.(connectivityChecker)
+	if !ok {
+		res.Valid = true
+		return res
+	}
+
+	err = checker.checkConnectivity(ctx)
+	if errors.Is(err, errConnectivityCheckUnsupported) {
+		res.Valid = true
+		return res
+	}
+	res.ConnectivityChecked = true
+	if err != nil {
+		res.Error = errors.Wrap(err, "code host not reachable").Error()
+		return res
+	}
+	res.Valid = true
+	return res
+}
+
+func (s *vcsPackagesSyncer) checkConnectivity(ctx context.Context) error {
+	if c, ok := s.source.(connectivityChecker); ok {
+		return c.checkConnectivity(ctx)
+	}
+	return errConnectivityCheckUnsupported
+}
+
+func (s *npmPackagesSyncer) checkConnectivity(ctx context.Context) error {
+	if p, ok := s.client.(interface{ Ping(context.Context) error }); ok {
+		return p.Ping(ctx)
+	}
+	return errConnectivityCheckUnsupported
+}
@@ -0,0 +1,125 @@
+package vcssyncer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/sourcegraph/log/logtest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/ratelimit"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+	"github.com/sourcegraph/sourcegraph/internal/wrexec"
+)
+
+func TestValidateExternalServiceConfig_Npm(t *testing.T) {
+	ratelimit.SetupForTest(t)
+
+	const credentials = "top secret access token"
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+credentials {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error": "incorrect credentials"}`))
+			return
+		}
+		if r.URL.Path != "/-/ping" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(registry.Close)
+
+	opts := &NewVCSSyncerOpts{
+		Logger:            logtest.Scoped(t),
+		HTTPClientFactory: httpcli.TestExternalClientFactory,
+	}
+	validate := func(config string) ExternalServiceValidation {
+		return ValidateExternalServiceConfig(context.Background(), opts, extsvc.KindNpmPackages, config, "")
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		res := validate(fmt.Sprintf(`{"registry": %q, "credentials": %q}`, registry.URL, credentials))
+		require.Equal(t, ExternalServiceValidation{
+			Valid:               true,
+			SyncerType:          "npm_packages",
+			ConnectivityChecked: true,
+		}, res)
+	})
+
+	t.Run("wrong credentials", func(t *testing.T) {
+		res := validate(fmt.Sprintf(`{"registry": %q, "credentials": "wrong"}`, registry.URL))
+		require.False(t, res.Valid)
+		require.True(t, res.ConnectivityChecked)
+		require.Contains(t, res.Error, "401")
+	})
+
+	t.Run("malformed config", func(t *testing.T) {
+		res := validate(`{"registry": 42}`)
+		require.False(t, res.Valid)
+		require.False(t, res.ConnectivityChecked)
+		require.Contains(t, res.Error, "invalid config")
+	})
+
+	t.Run("unknown kind", func(t *testing.T) {
+		res := ValidateExternalServiceConfig(context.Background(), opts, "NOTACODEHOST", `{}`, "")
+		require.False(t, res.Valid)
+		require.NotEmpty(t, res.Error)
+	})
+}
+
+func TestValidateExternalServiceConfig_Git(t *testing.T) {
+	remoteDir := t.TempDir()
+	cmd := exec.Command("git", "init", "--bare", remoteDir)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	// remoteURL is the URL derived from the config for a repo.
+	var remoteURL string
+	opts := &NewVCSSyncerOpts{
+		Logger:                  logtest.Scoped(t),
+		RecordingCommandFactory: wrexec.NewNoOpRecordingCommandFactory(),
+		GetRemoteURLSource: func(ctx context.Context, repo api.RepoName) (RemoteURLSource, error) {
+			require.Equal(t, api.RepoName("example.com/foo/bar"), repo)
+			return RemoteURLSourceFunc(func(ctx context.Context) (*vcs.URL, error) {
+				return vcs.ParseURL(remoteURL)
+			}), nil
+		},
+	}
+	validate := func(repo api.RepoName) ExternalServiceValidation {
+		return ValidateExternalServiceConfig(context.Background(), opts, extsvc.KindOther, `{"url": "https://example.com"}`, repo)
+	}
+
+	t.Run("reachable", func(t *testing.T) {
+		remoteURL = "file://" + filepath.ToSlash(remoteDir)
+		require.Equal(t, ExternalServiceValidation{
+			Valid:               true,
+			SyncerType:          "git",
+			ConnectivityChecked: true,
+		}, validate("example.com/foo/bar"))
+	})
+
+	t.Run("not reachable", func(t *testing.T) {
+		remoteURL = "file://" + filepath.ToSlash(filepath.Join(remoteDir, "missing"))
+		res := validate("example.com/foo/bar")
+		require.False(t, res.Valid)
+		require.True(t, res.ConnectivityChecked)
+		require.Contains(t, res.Error, "repository example.com/foo/bar not cloneable")
+	})
+
+	t.Run("without repo", func(t *testing.T) {
+		// Git code hosts can't be contacted without a repository.
+		require.Equal(t, ExternalServiceValidation{
+			Valid:      true,
+			SyncerType: "git",
+		}, validate(""))
+	})
+}
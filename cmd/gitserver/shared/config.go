@@ -2,9 +2,14 @@ package shared
 
 import (
 	"net"
+	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
 
+	server "github.com/sourcegraph/sourcegraph/cmd/gitserver/internal"
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/git/gitcli"
 	"github.com/sourcegraph/sourcegraph/internal/env"
 	"github.com/sourcegraph/sourcegraph/internal/hostname"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
@@ -39,6 +44,44 @@ type Config struct {
 	JanitorDisableDeleteReposOnWrongShard bool
 
 	ExhaustiveRequestLoggingEnabled bool
+
+	// CloneAllowPattern, if set, restricts cloning to repos whose name matches
+	// the pattern.
+	CloneAllowPattern *regexp.Regexp
+	// CloneDenyPattern, if set, prevents cloning repos whose name matches the
+	// pattern. It takes precedence over CloneAllowPattern.
+	CloneDenyPattern *regexp.Regexp
+
+	// CloneFromPeers makes gitserver clone repos from the gitserver instance
+	// that last had them cloned, instead of from the code host. This is useful
+	// when adding or replacing gitserver instances.
+	CloneFromPeers bool
+
+	// CloneRetryMaxAttempts and CloneRetryBackoff configure how often and when
+	// clones that failed with a transient error are retried.
+	CloneRetryMaxAttempts int
+	CloneRetryBackoff     time.Duration
+
+	// MaxRepoSize is the maximum number of bytes a repo may take up on disk
+	// while it is cloned. Clones of larger repos are aborted. Zero disables the
+	// limit.
+	MaxRepoSize int64
+
+	// RepoNotFoundAction and RepoNotFoundGracePeriod configure what happens to
+	// repos once fetching them fails because they no longer exist on the code
+	// host.
+	RepoNotFoundAction      server.RepoNotFoundAction
+	RepoNotFoundGracePeriod time.Duration
+
+	// DesiredPercentFree is the percentage of disk space gitserver tries to
+	// keep free. Concurrent clones are reduced as free disk space approaches
+	// it. Zero, the default, disables this.
+	DesiredPercentFree int
+
+	// GitBinary is the path of the git executable used to run git commands
+	// against repos. It is used by the git CLI backend, all syncers, the
+	// janitor, search and patch commits.
+	GitBinary string
 }
 
 func (c *Config) Load() {
@@ -57,7 +100,13 @@ func (c *Config) Load() {
 	// happening in environments were we run gitserver on localhost.
 	// Otherwise we assume we can reach gitserver via its hostname / its
 	// hostname is a prefix of the reachable address (see hostnameMatch).
-	c.ExternalAddress = c.Get("GITSERVER_EXTERNAL_ADDR", hostname.Get(), "The name of this gitserver as it would appear in SRC_GIT_SERVERS.")
+	// In k8s, SRC_GIT_SERVERS may instead list the DNS names pods get from
+	// the gitserver service, which we can derive from the service domain.
+	c.ExternalAddress = c.GetOptional("GITSERVER_EXTERNAL_ADDR", "The name of this gitserver as it would appear in SRC_GIT_SERVERS. Defaults to the hostname.")
+	serviceDomain := c.GetOptional("GITSERVER_SERVICE_DOMAIN", "The DNS domain of the service gitserver pods are reachable by, e.g. \"gitserver.default.svc.cluster.local\". If set and GITSERVER_EXTERNAL_ADDR is not, the external address is <hostname>.<domain>.")
+	if c.ExternalAddress == "" {
+		c.ExternalAddress = externalAddress(hostname.Get(), serviceDomain)
+	}
 
 	c.ListenAddress = c.GetOptional("GITSERVER_ADDR", "The address under which the gitserver API listens. Can include a port.")
 	// Fall back to a reasonable default.
@@ -78,4 +127,65 @@ func (c *Config) Load() {
 	c.JanitorDisableDeleteReposOnWrongShard = c.GetBool("SRC_REPOS_JANITOR_DISABLE_DELETE_REPOS_ON_WRONG_SHARD", "false", "Disable deleting repos on wrong shard")
 
 	c.ExhaustiveRequestLoggingEnabled = c.GetBool("SRC_GITSERVER_EXHAUSTIVE_LOGGING_ENABLED", "false", "Enable exhaustive request logging in gitserver")
+
+	c.CloneAllowPattern = c.getRegexp("SRC_GITSERVER_CLONE_ALLOW_PATTERN", "If set, only repos with a name matching this regular expression will be cloned.")
+	c.CloneDenyPattern = c.getRegexp("SRC_GITSERVER_CLONE_DENY_PATTERN", "If set, repos with a name matching this regular expression will not be cloned.")
+
+	c.CloneFromPeers = c.GetBool("SRC_GITSERVER_CLONE_FROM_PEERS", "false", "Clone repos from the gitserver instance that last had them cloned instead of from the code host, falling back to the code host if that fails. Speeds up adding or replacing gitserver instances.")
+
+	c.CloneRetryMaxAttempts = c.GetInt("SRC_GITSERVER_CLONE_RETRY_MAX_ATTEMPTS", "3", "The maximum number of attempts to clone a repo when cloning fails with a transient error, such as a network error. Set to 1 to disable retries.")
+	c.CloneRetryBackoff = c.GetInterval("SRC_GITSERVER_CLONE_RETRY_BACKOFF", "1m", "The delay before retrying a clone that failed with a transient error. Doubles with every further retry.")
+
+	c.MaxRepoSize = int64(c.GetInt("SRC_GITSERVER_MAX_REPO_SIZE_BYTES", "0", "The maximum size in bytes a repo may take up on disk while it is cloned. Clones of larger repos are aborted, so a single huge repo can't fill up the disk. Set to 0 to disable the limit."))
+
+	repoNotFoundAction, err := server.ParseRepoNotFoundAction(c.Get("SRC_GITSERVER_REPO_NOT_FOUND_ACTION", string(server.RepoNotFoundRetain), "What to do with a repo once fetching it fails because it no longer exists on the code host: \"retain\" keeps the local copy and \"remove-after-grace\" removes the local copy once the repo was not found for SRC_GITSERVER_REPO_NOT_FOUND_GRACE_PERIOD, and doesn't clone it again for another grace period."))
+	if err != nil {
+		c.AddError(errors.Wrap(err, "invalid SRC_GITSERVER_REPO_NOT_FOUND_ACTION"))
+	}
+	c.RepoNotFoundAction = repoNotFoundAction
+	c.RepoNotFoundGracePeriod = c.GetInterval("SRC_GITSERVER_REPO_NOT_FOUND_GRACE_PERIOD", "168h", "How long a repo has to be missing from the code host before its local copy is removed, if SRC_GITSERVER_REPO_NOT_FOUND_ACTION is \"remove-after-grace\".")
+
+	c.DesiredPercentFree = c.GetPercent("SRC_REPOS_DESIRED_PERCENT_FREE", "0", "Target percentage of free space on disk. If set, the number of concurrent clones is reduced as free disk space approaches it. 0 disables this.")
+
+	c.GitBinary = c.getExecutable("SRC_GITSERVER_GIT_BINARY", gitcli.DefaultGitBinary, "The git executable to use for all git commands run by gitserver, either a path or a name that is looked up in PATH. Useful for running against a specific git version.")
+}
+
+// externalAddress returns the address of a gitserver with the given hostname
+// that is reachable by the service with the given DNS domain, if any.
+func externalAddress(hostname, serviceDomain string) string {
+	serviceDomain = strings.Trim(serviceDomain, ".")
+	if serviceDomain == "" {
+		return hostname
+	}
+	return hostname + "." + serviceDomain
+}
+
+// getExecutable reads the name or path of an executable from the environment
+// and makes sure it exists and is executable, so that a misconfiguration is
+// caught on startup rather than on the first command.
+func (c *Config) getExecutable(name, defaultValue, description string) string {
+	raw := c.Get(name, defaultValue, description)
+	if raw == "" {
+		return ""
+	}
+	if _, err := exec.LookPath(raw); err != nil {
+		c.AddError(errors.Wrapf(err, "invalid executable for %s", name))
+		return ""
+	}
+	return raw
+}
+
+// getRegexp reads an optional regular expression from the environment. An
+// empty value results in a nil pattern.
+func (c *Config) getRegexp(name, description string) *regexp.Regexp {
+	raw := c.GetOptional(name, description)
+	if raw == "" {
+		return nil
+	}
+	re, err := regexp.Compile(raw)
+	if err != nil {
+		c.AddError(errors.Wrapf(err, "invalid regular expression for %s", name))
+		return nil
+	}
+	return re
 }
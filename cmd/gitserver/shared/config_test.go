@@ -1,8 +1,12 @@
 package shared
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/sourcegraph/sourcegraph/internal/hostname"
 )
 
 func TestConfigDefaults(t *testing.T) {
@@ -36,6 +40,87 @@ func TestConfigDefaults(t *testing.T) {
 	if have, want := config.JanitorDisableDeleteReposOnWrongShard, false; have != want {
 		t.Errorf("invalid value for JanitorDisableDeleteReposOnWrongShard: have=%t want=%t", have, want)
 	}
+	if have, want := config.GitBinary, "git"; have != want {
+		t.Errorf("invalid value for GitBinary: have=%s want=%s", have, want)
+	}
+}
+
+func TestConfigGitBinary(t *testing.T) {
+	t.Run("executable", func(t *testing.T) {
+		gitBinary := filepath.Join(t.TempDir(), "git")
+		if err := os.WriteFile(gitBinary, []byte("#!/bin/sh\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		config := Config{}
+		config.SetMockGetter(mapGetter(map[string]string{"SRC_GITSERVER_GIT_BINARY": gitBinary}))
+		config.Load()
+
+		if err := config.Validate(); err != nil {
+			t.Fatalf("unexpected validation error: %s", err)
+		}
+		if have, want := config.GitBinary, gitBinary; have != want {
+			t.Errorf("invalid value for GitBinary: have=%s want=%s", have, want)
+		}
+	})
+
+	t.Run("not executable", func(t *testing.T) {
+		gitBinary := filepath.Join(t.TempDir(), "git")
+		if err := os.WriteFile(gitBinary, []byte("#!/bin/sh\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		config := Config{}
+		config.SetMockGetter(mapGetter(map[string]string{"SRC_GITSERVER_GIT_BINARY": gitBinary}))
+		config.Load()
+
+		if err := config.Validate(); err == nil {
+			t.Fatal("expected validation error for non-executable git binary")
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		config := Config{}
+		config.SetMockGetter(mapGetter(map[string]string{"SRC_GITSERVER_GIT_BINARY": filepath.Join(t.TempDir(), "git")}))
+		config.Load()
+
+		if err := config.Validate(); err == nil {
+			t.Fatal("expected validation error for missing git binary")
+		}
+	})
+}
+
+func TestConfigExternalAddress(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{
+			name: "explicit",
+			env:  map[string]string{"GITSERVER_EXTERNAL_ADDR": "127.0.0.1:3178", "GITSERVER_SERVICE_DOMAIN": "gitserver.default.svc.cluster.local"},
+			want: "127.0.0.1:3178",
+		},
+		{
+			name: "service domain",
+			env:  map[string]string{"GITSERVER_SERVICE_DOMAIN": ".gitserver.default.svc.cluster.local."},
+			want: hostname.Get() + ".gitserver.default.svc.cluster.local",
+		},
+		{
+			name: "hostname",
+			want: hostname.Get(),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := Config{}
+			config.SetMockGetter(mapGetter(tc.env))
+			config.Load()
+
+			if have, want := config.ExternalAddress, tc.want; have != want {
+				t.Errorf("invalid value for ExternalAddress: have=%s want=%s", have, want)
+			}
+		})
+	}
 }
 
 func mapGetter(env map[string]string) func(name, defaultValue, description string) string {
@@ -20,6 +20,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/trace"
 	"github.com/sourcegraph/sourcegraph/internal/vcs"
 
+	"github.com/gobwas/glob"
 	"github.com/sourcegraph/log"
 	"google.golang.org/grpc"
 
@@ -43,6 +44,7 @@ import (
 	connections "github.com/sourcegraph/sourcegraph/internal/database/connections/live"
 	"github.com/sourcegraph/sourcegraph/internal/encryption/keyring"
 	"github.com/sourcegraph/sourcegraph/internal/env"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	proto "github.com/sourcegraph/sourcegraph/internal/gitserver/v1"
 	"github.com/sourcegraph/sourcegraph/internal/goroutine"
 	"github.com/sourcegraph/sourcegraph/internal/goroutine/recorder"
@@ -66,6 +68,18 @@ func Main(ctx context.Context, observationCtx *observation.Context, ready servic
 		return errors.Wrap(err, "failed to validate configuration")
 	}
 
+	// If the external address doesn't match any of SRC_GIT_SERVERS, no repos
+	// are assigned to this instance and no requests will be routed to it.
+	go conf.Watch(func() {
+		if gitServers := conf.Get().ServiceConnections().GitServers; !server.IsKnownShard(config.ExternalAddress, gitServers) {
+			logger.Warn(
+				"the external address of this gitserver does not match any of SRC_GIT_SERVERS, it will not serve any repos. Set GITSERVER_EXTERNAL_ADDR or GITSERVER_SERVICE_DOMAIN to fix this",
+				log.String("externalAddress", config.ExternalAddress),
+				log.Strings("gitServers", gitServers),
+			)
+		}
+	})
+
 	// Prepare the file system.
 	fs := gitserverfs.New(observationCtx, config.ReposDir)
 	if err := fs.Initialize(); err != nil {
@@ -92,7 +106,19 @@ func Main(ctx context.Context, observationCtx *observation.Context, ready servic
 	locker := server.NewRepositoryLocker()
 	hostname := config.ExternalAddress
 	backendSource := func(dir common.GitDir, repoName api.RepoName) git.GitBackend {
-		return git.NewObservableBackend(gitcli.NewBackend(logger, recordingCommandFactory, dir, repoName))
+		var opts []gitcli.BackendOptionFunc
+		if vcssyncer.IsPartialClone(dir) {
+			// The promisor remote of partial clones has no credentials, so
+			// git needs them passed in to lazily fetch missing blobs.
+			opts = append(opts, gitcli.WithLazyFetchRemoteURL(func(ctx context.Context) (*vcs.URL, error) {
+				remoteURL, err := getRemoteURLFunc(ctx, db, repoName)
+				if err != nil {
+					return nil, err
+				}
+				return vcs.ParseURL(remoteURL)
+			}))
+		}
+		return git.NewObservableBackend(gitcli.NewBackend(logger, recordingCommandFactory, config.GitBinary, dir, repoName, opts...))
 	}
 	gitserver := makeServer(
 		observationCtx,
@@ -100,8 +126,7 @@ func Main(ctx context.Context, observationCtx *observation.Context, ready servic
 		db,
 		recordingCommandFactory,
 		backendSource,
-		hostname,
-		config.CoursierCacheDir,
+		config,
 		locker,
 		func(ctx context.Context, repo api.RepoName) (string, error) {
 			return getRemoteURLFunc(ctx, db, repo)
@@ -117,7 +142,7 @@ func Main(ctx context.Context, observationCtx *observation.Context, ready servic
 			recordingCommandFactory.Disable()
 			return
 		}
-		recordingCommandFactory.Update(recordCommandsOnRepos(recordingConf.Repos, recordingConf.IgnoredGitCommands), recordingConf.Size)
+		recordingCommandFactory.Update(recordCommandsOnRepos(logger, recordingConf.Repos, recordingConf.IgnoredGitCommands), recordingConf.Size)
 	})
 
 	internal.RegisterEchoMetric(logger.Scoped("echoMetricReporter"))
@@ -143,6 +168,7 @@ func Main(ctx context.Context, observationCtx *observation.Context, ready servic
 			server.JanitorConfig{
 				ShardID:                        hostname,
 				JanitorInterval:                config.JanitorInterval,
+				GitBinary:                      config.GitBinary,
 				DisableDeleteReposOnWrongShard: config.JanitorDisableDeleteReposOnWrongShard,
 			},
 			db,
@@ -198,13 +224,13 @@ func makeServer(
 	db database.DB,
 	recordingCommandFactory *wrexec.RecordingCommandFactory,
 	backendSource func(dir common.GitDir, repoName api.RepoName) git.GitBackend,
-	hostname string,
-	coursierCacheDir string,
+	config *Config,
 	locker internal.RepositoryLocker,
 	getRemoteURLFunc func(ctx context.Context, repo api.RepoName) (string, error),
 ) *internal.Server {
 	return server.NewServer(&server.ServerOpts{
 		Logger:           observationCtx.Logger,
+		ObservationCtx:   observationCtx,
 		GitBackendSource: backendSource,
 		GetRemoteURLFunc: getRemoteURLFunc,
 		GetVCSSyncer: func(ctx context.Context, repo api.RepoName) (vcssyncer.VCSSyncer, error) {
@@ -213,40 +239,54 @@ func makeServer(
 				RepoStore:               db.Repos(),
 				DepsSvc:                 dependencies.NewService(observationCtx, db),
 				Repo:                    repo,
-				CoursierCacheDir:        coursierCacheDir,
+				CoursierCacheDir:        config.CoursierCacheDir,
 				RecordingCommandFactory: recordingCommandFactory,
 				Logger:                  observationCtx.Logger,
 				FS:                      fs,
+				GitBinary:               config.GitBinary,
 				GetRemoteURLSource: func(ctx context.Context, repo api.RepoName) (vcssyncer.RemoteURLSource, error) {
-					return vcssyncer.RemoteURLSourceFunc(func(ctx context.Context) (*vcs.URL, error) {
-						rawURL, err := getRemoteURLFunc(ctx, repo)
-						if err != nil {
-							return nil, errors.Wrapf(err, "getting remote URL for %q", repo)
-
-						}
-
-						u, err := vcs.ParseURL(rawURL)
-						if err != nil {
-							// TODO@ggilmore: Note that we can't redact the URL here because we can't
-							// parse it to know where the sensitive information is.
-							return nil, errors.Wrapf(err, "parsing remote URL %q", rawURL)
-						}
-
-						return u, nil
-
-					}), nil
+					return remoteURLSource(repo, getRemoteURLFunc), nil
 				},
 			})
 		},
+		ValidateExternalServiceConfig: func(ctx context.Context, repo api.RepoName, kind, extsvcConfig string) vcssyncer.ExternalServiceValidation {
+			return vcssyncer.ValidateExternalServiceConfig(ctx, &vcssyncer.NewVCSSyncerOpts{
+				DepsSvc:                 dependencies.NewService(observationCtx, db),
+				CoursierCacheDir:        config.CoursierCacheDir,
+				RecordingCommandFactory: recordingCommandFactory,
+				Logger:                  observationCtx.Logger,
+				FS:                      fs,
+				GitBinary:               config.GitBinary,
+				GetRemoteURLSource: func(ctx context.Context, repo api.RepoName) (vcssyncer.RemoteURLSource, error) {
+					return remoteURLSource(repo, func(ctx context.Context, repo api.RepoName) (string, error) {
+						return getRemoteURLForConfig(ctx, db, kind, extsvcConfig, repo)
+					}), nil
+				},
+			}, kind, extsvcConfig, repo)
+		},
 		FS:                      fs,
-		Hostname:                hostname,
+		Hostname:                config.ExternalAddress,
 		DB:                      db,
 		RecordingCommandFactory: recordingCommandFactory,
+		GitBinary:               config.GitBinary,
 		Locker:                  locker,
 		RPSLimiter: ratelimit.NewInstrumentedLimiter(
 			ratelimit.GitRPSLimiterBucketName,
 			ratelimit.NewGlobalRateLimiter(observationCtx.Logger, ratelimit.GitRPSLimiterBucketName),
 		),
+		CloneAllowPattern: config.CloneAllowPattern,
+		CloneDenyPattern:  config.CloneDenyPattern,
+		CloneFromPeers:    config.CloneFromPeers,
+		CloneRetryPolicy: server.CloneRetryPolicy{
+			MaxAttempts: config.CloneRetryMaxAttempts,
+			Backoff:     config.CloneRetryBackoff,
+		},
+		MaxRepoSize: config.MaxRepoSize,
+		RepoNotFoundPolicy: server.RepoNotFoundPolicy{
+			Action:      config.RepoNotFoundAction,
+			GracePeriod: config.RepoNotFoundGracePeriod,
+		},
+		DesiredPercentFree: config.DesiredPercentFree,
 	})
 }
 
@@ -332,6 +372,98 @@ func getRemoteURLFunc(
 	return "", errors.Errorf("no sources for %q", repo)
 }
 
+// getRemoteURLForConfig returns the remote URL for the given repo as derived
+// from an external service config that is not necessarily saved yet.
+func getRemoteURLForConfig(
+	ctx context.Context,
+	db database.DB,
+	kind, config string,
+	repo api.RepoName,
+) (string, error) {
+	r, err := db.Repos().GetByName(ctx, repo)
+	if err != nil {
+		return "", err
+	}
+
+	return cloneurl.ForEncryptableConfig(ctx, db, kind, extsvc.NewUnencryptedConfig(config), r)
+}
+
+// remoteURLSource returns a vcssyncer.RemoteURLSource that parses the remote
+// URL returned by getRemoteURL for repo.
+func remoteURLSource(repo api.RepoName, getRemoteURL func(context.Context, api.RepoName) (string, error)) vcssyncer.RemoteURLSource {
+	return vcssyncer.RemoteURLSourceFunc(func(ctx context.Context) (*vcs.URL, error) {
+		rawURL, err := getRemoteURL(ctx, repo)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting remote URL for %q", repo)
+
+		}
+
+		u, err := vcs.ParseURL(rawURL)
+		if err != nil {
+			// TODO@ggilmore: Note that we can't redact the URL here because we can't
+			// parse it to know where the sensitive information is.
+			return nil, errors.Wrapf(err, "parsing remote URL %q", rawURL)
+		}
+
+		return u, nil
+	})
+}
+
+// repoDirMatcher returns a function that reports whether the git directory of
+// a command belongs to one of repos. Repos are matched against the end of the
+// directory on path component boundaries, so "sourcegraph/sourcegraph" doesn't
+// match "sourcegraph/sourcegraph-x". Repos containing glob characters are
+// matched as github.com/gobwas/glob patterns, where "*" doesn't cross "/" but
+// "**" does. A single "*" element matches all repositories. Invalid patterns
+// are logged and matched as plain repo names.
+func repoDirMatcher(logger log.Logger, repos []string) func(dir string) bool {
+	if len(repos) == 1 && repos[0] == "*" {
+		return func(string) bool { return true }
+	}
+
+	var exact []string
+	var globs []glob.Glob
+	for _, repo := range repos {
+		if strings.ContainsAny(repo, "*?[{") {
+			g, err := glob.Compile(repo, '/')
+			if err == nil {
+				globs = append(globs, g)
+				continue
+			}
+			logger.Warn("invalid repo pattern in gitRecorder.repos, matching it as a plain repo name", log.String("pattern", repo), log.Error(err))
+		}
+		exact = append(exact, repo)
+	}
+
+	return func(dir string) bool {
+		name, ok := strings.CutSuffix(dir, "/.git")
+		if !ok {
+			return false
+		}
+		for _, repo := range exact {
+			if name == repo || strings.HasSuffix(name, "/"+repo) {
+				return true
+			}
+		}
+		if len(globs) == 0 {
+			return false
+		}
+		// We don't know where the repo name starts in dir, so we try the
+		// pattern against every suffix starting on a path component.
+		for i := 0; i < len(name); i++ {
+			if i > 0 && name[i-1] != '/' {
+				continue
+			}
+			for _, g := range globs {
+				if g.Match(name[i:]) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
 var defaultIgnoredGitCommands = []string{
 	"show",
 	"rev-parse",
@@ -342,7 +474,7 @@ var defaultIgnoredGitCommands = []string{
 
 // recordCommandsOnRepos returns a ShouldRecordFunc which determines whether the given command should be recorded
 // for a particular repository.
-func recordCommandsOnRepos(repos []string, ignoredGitCommands []string) wrexec.ShouldRecordFunc {
+func recordCommandsOnRepos(logger log.Logger, repos []string, ignoredGitCommands []string) wrexec.ShouldRecordFunc {
 	// empty repos, means we should never record since there is nothing to match on
 	if len(repos) == 0 {
 		return func(ctx context.Context, c *exec.Cmd) bool {
@@ -357,32 +489,16 @@ func recordCommandsOnRepos(repos []string, ignoredGitCommands []string) wrexec.S
 	// we won't record any git commands with these commands since they are considered to be not destructive
 	ignoredGitCommandsMap := collections.NewSet(ignoredGitCommands...)
 
+	repoMatch := repoDirMatcher(logger, repos)
+
 	return func(_ context.Context, cmd *exec.Cmd) bool {
 		base := filepath.Base(cmd.Path)
 		if base != "git" {
 			return false
 		}
 
-		repoMatch := false
-		// If repos contains a single "*" element, it means to record commands
-		// for all repositories.
-		if len(repos) == 1 && repos[0] == "*" {
-			repoMatch = true
-		} else {
-			for _, repo := range repos {
-				// We need to check the suffix, because we can have some common parts in
-				// different repo names. E.g. "sourcegraph/sourcegraph" and
-				// "sourcegraph/sourcegraph-code-ownership" will both be allowed even if only the
-				// first name is included in the config.
-				if strings.HasSuffix(cmd.Dir, repo+"/.git") {
-					repoMatch = true
-					break
-				}
-			}
-		}
-
 		// If the repo doesn't match, no use in checking if it is a command we should record.
-		if !repoMatch {
+		if !repoMatch(cmd.Dir) {
 			return false
 		}
 		// we have to scan the Args, since it isn't guaranteed that the Arg at index 1 is the git command:
@@ -0,0 +1,122 @@
+package shared
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"github.com/sourcegraph/log/logtest"
+)
+
+func TestRecordCommandsOnRepos(t *testing.T) {
+	gitCmd := func(repo string, args ...string) *exec.Cmd {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = "/data/repos/" + repo + "/.git"
+		return cmd
+	}
+
+	tests := []struct {
+		name  string
+		repos []string
+		cmd   *exec.Cmd
+		want  bool
+	}{
+		{
+			name:  "no repos",
+			repos: nil,
+			cmd:   gitCmd("github.com/sourcegraph/sourcegraph", "fetch"),
+			want:  false,
+		},
+		{
+			name:  "all repos",
+			repos: []string{"*"},
+			cmd:   gitCmd("github.com/sourcegraph/sourcegraph", "fetch"),
+			want:  true,
+		},
+		{
+			name:  "exact",
+			repos: []string{"github.com/sourcegraph/sourcegraph"},
+			cmd:   gitCmd("github.com/sourcegraph/sourcegraph", "fetch"),
+			want:  true,
+		},
+		{
+			name:  "exact suffix",
+			repos: []string{"sourcegraph/sourcegraph"},
+			cmd:   gitCmd("github.com/sourcegraph/sourcegraph", "fetch"),
+			want:  true,
+		},
+		{
+			name:  "common prefix is not a match",
+			repos: []string{"sourcegraph/sourcegraph"},
+			cmd:   gitCmd("github.com/sourcegraph/sourcegraph-x", "fetch"),
+			want:  false,
+		},
+		{
+			name:  "suffix within path component is not a match",
+			repos: []string{"sourcegraph/sourcegraph"},
+			cmd:   gitCmd("github.com/not-sourcegraph/sourcegraph", "fetch"),
+			want:  false,
+		},
+		{
+			name:  "glob",
+			repos: []string{"github.com/sourcegraph/*"},
+			cmd:   gitCmd("github.com/sourcegraph/sourcegraph-x", "fetch"),
+			want:  true,
+		},
+		{
+			name:  "glob doesn't match other orgs",
+			repos: []string{"github.com/sourcegraph/*"},
+			cmd:   gitCmd("github.com/other/sourcegraph", "fetch"),
+			want:  false,
+		},
+		{
+			name:  "glob doesn't cross path components",
+			repos: []string{"gitlab.com/sourcegraph/*"},
+			cmd:   gitCmd("gitlab.com/sourcegraph/group/repo", "fetch"),
+			want:  false,
+		},
+		{
+			name:  "double star crosses path components",
+			repos: []string{"gitlab.com/sourcegraph/**"},
+			cmd:   gitCmd("gitlab.com/sourcegraph/group/repo", "fetch"),
+			want:  true,
+		},
+		{
+			name:  "prefix",
+			repos: []string{"github.com/sourcegraph/sourcegraph*"},
+			cmd:   gitCmd("github.com/sourcegraph/sourcegraph-x", "fetch"),
+			want:  true,
+		},
+		{
+			name:  "prefix doesn't match other names",
+			repos: []string{"github.com/sourcegraph/sourcegraph*"},
+			cmd:   gitCmd("github.com/sourcegraph/zoekt", "fetch"),
+			want:  false,
+		},
+		{
+			name:  "ignored command",
+			repos: []string{"github.com/sourcegraph/*"},
+			cmd:   gitCmd("github.com/sourcegraph/sourcegraph", "rev-parse", "HEAD"),
+			want:  false,
+		},
+		{
+			name:  "not git",
+			repos: []string{"*"},
+			cmd: func() *exec.Cmd {
+				cmd := exec.Command("p4", "sync")
+				cmd.Dir = "/data/repos/github.com/sourcegraph/sourcegraph/.git"
+				return cmd
+			}(),
+			want: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			shouldRecord := recordCommandsOnRepos(logtest.Scoped(t), tc.repos, nil)
+			if got := shouldRecord(context.Background(), tc.cmd); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -33,9 +33,9 @@ func TestAPIServer(ctx context.Context, observationCtx *observation.Context, db
 	}
 
 	backendSource := func(dir common.GitDir, repoName api.RepoName) git.GitBackend {
-		return git.NewObservableBackend(gitcli.NewBackend(logger, wrexec.NewNoOpRecordingCommandFactory(), dir, repoName))
+		return git.NewObservableBackend(gitcli.NewBackend(logger, wrexec.NewNoOpRecordingCommandFactory(), config.GitBinary, dir, repoName))
 	}
-	gitserver := makeServer(observationCtx, fs, db, wrexec.NewNoOpRecordingCommandFactory(), backendSource, config.ExternalAddress, config.CoursierCacheDir, server.NewRepositoryLocker(), getRemoteURLFunc)
+	gitserver := makeServer(observationCtx, fs, db, wrexec.NewNoOpRecordingCommandFactory(), backendSource, config, server.NewRepositoryLocker(), getRemoteURLFunc)
 	httpServer := makeHTTPServer(logger, fs, makeGRPCServer(logger, gitserver, config), config.ListenAddress)
 
 	return &testServerRoutine{start: httpServer.Start, stop: func() {
@@ -27,6 +27,12 @@ type schedule struct {
 	randGenerator interface {
 		Int63n(n int64) int64
 	}
+
+	// lastAccessed is when each repo was last accessed, tracked since
+	// accessTrackingStart.
+	lastAccessed        map[api.RepoID]time.Time
+	accessTrackingStart time.Time
+	accessScheduling    accessSchedulingConfig
 }
 
 // upsert inserts or updates a repo in the schedule.
@@ -153,6 +159,26 @@ func (s *schedule) updateInterval(repo configuredRepo, interval time.Duration) {
 	s.mu.Unlock()
 }
 
+// recordAccess records that repo was accessed just now.
+func (s *schedule) recordAccess(repo configuredRepo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lastAccessed == nil {
+		s.lastAccessed = make(map[api.RepoID]time.Time)
+	}
+	s.lastAccessed[repo.ID] = timeNow()
+}
+
+// adjustIntervalForAccess adjusts the update interval of repo based on when it
+// was last accessed.
+func (s *schedule) adjustIntervalForAccess(repo configuredRepo, interval time.Duration) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.accessScheduling.adjustInterval(interval, s.lastAccessed[repo.ID], s.accessTrackingStart, timeNow())
+}
+
 // getCurrentInterval gets the current interval for the supplied repo and a bool
 // indicating whether it was found.
 func (s *schedule) getCurrentInterval(repo configuredRepo) (time.Duration, bool) {
@@ -175,6 +201,8 @@ func (s *schedule) remove(repo configuredRepo) (removed bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	delete(s.lastAccessed, repo.ID)
+
 	update := s.index[repo.ID]
 	if update == nil {
 		return false
@@ -210,6 +238,7 @@ func (s *schedule) reset() {
 
 	s.heap = s.heap[:0]
 	s.index = map[api.RepoID]*scheduledRepoUpdate{}
+	s.lastAccessed = map[api.RepoID]time.Time{}
 	s.wakeup = make(chan struct{}, notifyChanBuffer)
 	if s.timer != nil {
 		s.timer.Stop()
@@ -18,6 +18,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/env"
 	"github.com/sourcegraph/sourcegraph/internal/limiter"
 	"github.com/sourcegraph/sourcegraph/internal/ratelimit"
 	"github.com/sourcegraph/sourcegraph/internal/repoupdater/protocol"
@@ -42,6 +43,11 @@ const (
 // then the next update will be scheduled 6 hours from then.
 // This heuristic is simple to compute and has nice backoff properties.
 //
+// The interval is then adjusted based on when the repo was last accessed, so
+// that freshness is focused on repos people actually look at: repos accessed
+// recently are updated at least twice as often, repos that haven't been
+// accessed in a long time half as often. See accessSchedulingConfig.
+//
 // If an error occurs when attempting to fetch a repo we perform exponential
 // backoff by doubling the current interval. This ensures that problematic repos
 // don't stay in the front of the schedule clogging up the queue.
@@ -84,10 +90,13 @@ func NewUpdateScheduler(logger log.Logger, db database.DB, gitserverClient gitse
 			notifyEnqueue: make(chan struct{}, notifyChanBuffer),
 		},
 		schedule: &schedule{
-			index:         make(map[api.RepoID]*scheduledRepoUpdate),
-			wakeup:        make(chan struct{}, notifyChanBuffer),
-			randGenerator: rand.New(rand.NewSource(time.Now().UnixNano())),
-			logger:        updateSchedLogger.Scoped("Schedule"),
+			index:               make(map[api.RepoID]*scheduledRepoUpdate),
+			wakeup:              make(chan struct{}, notifyChanBuffer),
+			randGenerator:       rand.New(rand.NewSource(time.Now().UnixNano())),
+			logger:              updateSchedLogger.Scoped("Schedule"),
+			lastAccessed:        make(map[api.RepoID]time.Time),
+			accessTrackingStart: time.Now(),
+			accessScheduling:    defaultAccessSchedulingConfig,
 		},
 		logger: updateSchedLogger,
 	}
@@ -301,13 +310,63 @@ func (s *UpdateScheduler) runUpdateLoop(ctx context.Context) {
 					// This is the heuristic that is described in the UpdateScheduler documentation.
 					// Update that documentation if you update this logic.
 					interval := lastFetched.Sub(lastChanged) / 2
-					s.schedule.updateInterval(repo, interval)
+					s.schedule.updateInterval(repo, s.schedule.adjustIntervalForAccess(repo, interval))
 				}
 			}()
 		}
 	}
 }
 
+var defaultAccessSchedulingConfig = accessSchedulingConfig{
+	RecentWindow:      env.MustGetDuration("SRC_REPO_UPDATE_RECENT_ACCESS_WINDOW", 24*time.Hour, "Repos accessed within this window are updated more frequently. Set to 0 to disable."),
+	RecentMaxInterval: env.MustGetDuration("SRC_REPO_UPDATE_RECENT_ACCESS_MAX_INTERVAL", time.Hour, "The maximum interval between updates of repos accessed within SRC_REPO_UPDATE_RECENT_ACCESS_WINDOW."),
+	ColdWindow:        env.MustGetDuration("SRC_REPO_UPDATE_COLD_ACCESS_WINDOW", 7*24*time.Hour, "Repos not accessed within this window are updated less frequently. Set to 0 to disable."),
+	ColdMinInterval:   env.MustGetDuration("SRC_REPO_UPDATE_COLD_ACCESS_MIN_INTERVAL", 4*time.Hour, "The minimum interval between updates of repos not accessed within SRC_REPO_UPDATE_COLD_ACCESS_WINDOW."),
+}
+
+// accessSchedulingConfig configures how the update interval of a repo is
+// adjusted based on when it was last accessed. The adjusted interval is still
+// capped to [minDelay, maxDelay].
+type accessSchedulingConfig struct {
+	// RecentWindow is how long a repo is considered recently accessed after
+	// an access. The interval of recently accessed repos is halved and capped
+	// to RecentMaxInterval.
+	RecentWindow      time.Duration
+	RecentMaxInterval time.Duration
+
+	// ColdWindow is how long a repo has to go without accesses to be
+	// considered cold. The interval of cold repos is doubled and at least
+	// ColdMinInterval.
+	ColdWindow      time.Duration
+	ColdMinInterval time.Duration
+}
+
+// adjustInterval adjusts the update interval of a repo that was last accessed
+// at lastAccessed, which is zero if no access was recorded. Accesses are only
+// tracked since trackingStart, so a repo only counts as cold once the cold
+// window has passed since then.
+func (c accessSchedulingConfig) adjustInterval(interval time.Duration, lastAccessed, trackingStart, now time.Time) time.Duration {
+	if c.RecentWindow > 0 && !lastAccessed.IsZero() && now.Sub(lastAccessed) <= c.RecentWindow {
+		interval /= 2
+		if c.RecentMaxInterval > 0 && interval > c.RecentMaxInterval {
+			interval = c.RecentMaxInterval
+		}
+		return interval
+	}
+
+	lastSeen := lastAccessed
+	if lastSeen.Before(trackingStart) {
+		lastSeen = trackingStart
+	}
+	if c.ColdWindow > 0 && now.Sub(lastSeen) > c.ColdWindow {
+		interval *= 2
+		if interval < c.ColdMinInterval {
+			interval = c.ColdMinInterval
+		}
+	}
+	return interval
+}
+
 func getCustomInterval(logger log.Logger, c *conf.Unified, repoName string) time.Duration {
 	if c == nil {
 		return 0
@@ -455,6 +514,9 @@ func (s *UpdateScheduler) UpdateOnce(id api.RepoID, name api.RepoName) {
 		Name: name,
 	}
 	schedManualFetch.Inc()
+	// Manual updates are requested whenever a repo is visited, so we use them
+	// to track how recently a repo was accessed.
+	s.schedule.recordAccess(repo)
 	s.updateQueue.enqueue(repo, priorityHigh)
 }
 
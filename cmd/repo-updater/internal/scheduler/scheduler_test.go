@@ -1669,3 +1669,132 @@ func abs(x time.Duration) time.Duration {
 	}
 	return x
 }
+
+func TestAccessSchedulingConfig_adjustInterval(t *testing.T) {
+	c := accessSchedulingConfig{
+		RecentWindow:      24 * time.Hour,
+		RecentMaxInterval: time.Hour,
+		ColdWindow:        7 * 24 * time.Hour,
+		ColdMinInterval:   4 * time.Hour,
+	}
+	now := defaultTime
+	trackingStart := now.Add(-30 * 24 * time.Hour)
+
+	cases := []struct {
+		name          string
+		config        accessSchedulingConfig
+		interval      time.Duration
+		lastAccessed  time.Time
+		trackingStart time.Time
+		expected      time.Duration
+	}{
+		{
+			name:          "accessed recently",
+			config:        c,
+			interval:      time.Hour,
+			lastAccessed:  now.Add(-time.Hour),
+			trackingStart: trackingStart,
+			expected:      30 * time.Minute,
+		},
+		{
+			name:          "accessed recently, capped",
+			config:        c,
+			interval:      8 * time.Hour,
+			lastAccessed:  now.Add(-time.Hour),
+			trackingStart: trackingStart,
+			expected:      time.Hour,
+		},
+		{
+			name:          "accessed a few days ago",
+			config:        c,
+			interval:      2 * time.Hour,
+			lastAccessed:  now.Add(-3 * 24 * time.Hour),
+			trackingStart: trackingStart,
+			expected:      2 * time.Hour,
+		},
+		{
+			name:          "not accessed in a long time",
+			config:        c,
+			interval:      3 * time.Hour,
+			lastAccessed:  now.Add(-10 * 24 * time.Hour),
+			trackingStart: trackingStart,
+			expected:      6 * time.Hour,
+		},
+		{
+			name:          "not accessed in a long time, raised to minimum",
+			config:        c,
+			interval:      time.Minute,
+			lastAccessed:  now.Add(-10 * 24 * time.Hour),
+			trackingStart: trackingStart,
+			expected:      4 * time.Hour,
+		},
+		{
+			name:          "never accessed",
+			config:        c,
+			interval:      time.Hour,
+			trackingStart: trackingStart,
+			expected:      4 * time.Hour,
+		},
+		{
+			name:          "never accessed since tracking started recently",
+			config:        c,
+			interval:      time.Hour,
+			trackingStart: now.Add(-time.Hour),
+			expected:      time.Hour,
+		},
+		{
+			name:          "disabled",
+			config:        accessSchedulingConfig{},
+			interval:      time.Hour,
+			lastAccessed:  now.Add(-time.Minute),
+			trackingStart: trackingStart,
+			expected:      time.Hour,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if have := tc.config.adjustInterval(tc.interval, tc.lastAccessed, tc.trackingStart, now); have != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, have)
+			}
+		})
+	}
+}
+
+func TestSchedule_accessRecency(t *testing.T) {
+	hot := configuredRepo{ID: 1, Name: "hot"}
+	cold := configuredRepo{ID: 2, Name: "cold"}
+
+	_, stop := startRecording()
+	defer stop()
+
+	s := NewUpdateScheduler(logtest.Scoped(t), dbmocks.NewMockDB(), gitserver.NewMockRepositoryServiceClient())
+	s.schedule.randGenerator = &mockRandomGenerator{}
+	s.schedule.accessTrackingStart = defaultTime.Add(-30 * 24 * time.Hour)
+	s.schedule.accessScheduling = accessSchedulingConfig{
+		RecentWindow:      24 * time.Hour,
+		RecentMaxInterval: time.Hour,
+		ColdWindow:        7 * 24 * time.Hour,
+		ColdMinInterval:   4 * time.Hour,
+	}
+
+	s.schedule.upsert(hot)
+	s.schedule.upsert(cold)
+	s.schedule.recordAccess(hot)
+
+	// Both repos last changed 6 hours before they were fetched.
+	for _, repo := range []configuredRepo{hot, cold} {
+		s.schedule.updateInterval(repo, s.schedule.adjustIntervalForAccess(repo, 3*time.Hour))
+	}
+
+	verifySchedule(t, s, []*scheduledRepoUpdate{
+		{Repo: hot, Interval: time.Hour, Due: defaultTime.Add(time.Hour)},
+		{Repo: cold, Interval: 6 * time.Hour, Due: defaultTime.Add(6 * time.Hour)},
+	})
+
+	// Removing a repo forgets when it was accessed.
+	s.schedule.remove(hot)
+	if _, ok := s.schedule.lastAccessed[hot.ID]; ok {
+		t.Fatal("expected access of removed repo to be forgotten")
+	}
+}
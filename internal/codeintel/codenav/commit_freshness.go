@@ -0,0 +1,80 @@
+package codenav
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// TargetCommitFreshness tracks whether the target commit that upload data is
+// mapped to (e.g. via a GitTreeTranslator) is still what the requested revision
+// resolves to. Long-lived callers that hold on to mapped results should check
+// Stale and rebuild them once the revision moved, e.g. after HEAD advanced.
+//
+// The revision is resolved at most once per ttl, and a zero ttl resolves it on
+// every check. Once stale, it stays stale.
+type TargetCommitFreshness struct {
+	client gitserver.Client
+	repo   api.RepoName
+	rev    string
+	commit api.CommitID
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	stale     bool
+}
+
+// NewTargetCommitFreshness returns a TargetCommitFreshness for commit, which
+// rev resolved to in repo at the time of calling.
+func NewTargetCommitFreshness(client gitserver.Client, repo api.RepoName, rev string, commit api.CommitID, ttl time.Duration) *TargetCommitFreshness {
+	return &TargetCommitFreshness{
+		client:    client,
+		repo:      repo,
+		rev:       rev,
+		commit:    commit,
+		ttl:       ttl,
+		now:       time.Now,
+		checkedAt: time.Now(),
+	}
+}
+
+// Commit returns the target commit being tracked.
+func (f *TargetCommitFreshness) Commit() api.CommitID {
+	return f.commit
+}
+
+// Stale returns true if rev no longer resolves to the target commit.
+func (f *TargetCommitFreshness) Stale(ctx context.Context) (bool, error) {
+	f.mu.Lock()
+	stale, checkedAt := f.stale, f.checkedAt
+	f.mu.Unlock()
+
+	if stale {
+		return true, nil
+	}
+	now := f.now()
+	if f.ttl > 0 && now.Sub(checkedAt) < f.ttl {
+		return false, nil
+	}
+
+	// We don't hold the lock while talking to gitserver, concurrent checks
+	// may resolve the revision more than once.
+	commit, err := f.client.ResolveRevision(ctx, f.repo, f.rev, gitserver.ResolveRevisionOptions{})
+	if err != nil {
+		return false, errors.Wrap(err, "gitserver.ResolveRevision")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if now.After(f.checkedAt) {
+		f.checkedAt = now
+	}
+	f.stale = f.stale || commit != f.commit
+	return f.stale, nil
+}
@@ -0,0 +1,54 @@
+package codenav
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+)
+
+func TestTargetCommitFreshness(t *testing.T) {
+	ctx := context.Background()
+
+	head := api.CommitID("deadbeef01")
+	client := gitserver.NewMockClient()
+	client.ResolveRevisionFunc.SetDefaultHook(func(_ context.Context, repo api.RepoName, rev string, _ gitserver.ResolveRevisionOptions) (api.CommitID, error) {
+		require.Equal(t, api.RepoName("github.com/sourcegraph/sourcegraph"), repo)
+		require.Equal(t, "HEAD", rev)
+		return head, nil
+	})
+
+	now := time.Now()
+	freshness := NewTargetCommitFreshness(client, "github.com/sourcegraph/sourcegraph", "HEAD", head, time.Minute)
+	freshness.now = func() time.Time { return now }
+	freshness.checkedAt = now
+
+	stale, err := freshness.Stale(ctx)
+	require.NoError(t, err)
+	require.False(t, stale)
+	require.Empty(t, client.ResolveRevisionFunc.History(), "expected no resolution within the TTL")
+
+	// Advance the target commit. This isn't noticed until the TTL expired.
+	head = "deadbeef02"
+	stale, err = freshness.Stale(ctx)
+	require.NoError(t, err)
+	require.False(t, stale)
+
+	now = now.Add(time.Minute)
+	stale, err = freshness.Stale(ctx)
+	require.NoError(t, err)
+	require.True(t, stale)
+	require.Len(t, client.ResolveRevisionFunc.History(), 1)
+
+	// The index stays stale even if the revision moves back.
+	head = "deadbeef01"
+	now = now.Add(time.Minute)
+	stale, err = freshness.Stale(ctx)
+	require.NoError(t, err)
+	require.True(t, stale)
+	require.Len(t, client.ResolveRevisionFunc.History(), 1)
+}
@@ -0,0 +1,114 @@
+package lsifstore
+
+import (
+	"math"
+	"sync"
+
+	"github.com/hashicorp/golang-lru/v2/simplelru"
+	"github.com/sourcegraph/scip/bindings/go/scip"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sourcegraph/sourcegraph/internal/env"
+)
+
+// scipDocumentCacheSizeBytes bounds the memory used by the SCIP document cache.
+//
+// The budget is measured in decompressed payload bytes, as that is the only size
+// known without walking the unmarshalled document. The heap actually retained is
+// larger: every occurrence, symbol and range of an unmarshalled scip.Document is
+// a separate allocation, so operators should budget a small multiple of this
+// value per frontend. In exchange, repeated requests for hot documents skip
+// transferring the compressed payload from the database as well as decompressing
+// and unmarshalling it. The cache is disabled by default.
+var scipDocumentCacheSizeBytes = env.MustGetBytes("CODEINTEL_SCIP_DOCUMENT_CACHE_SIZE_BYTES", "0", "The maximum decompressed size of SCIP documents cached in memory by the code navigation service, e.g. 64MiB. Set to 0 to disable the cache.")
+
+type scipDocumentCacheKey struct {
+	uploadID int
+	path     string
+}
+
+type scipDocumentCacheEntry struct {
+	documentID int
+	document   *scip.Document
+	size       int64
+}
+
+// scipDocumentCache is a size-bounded LRU of decompressed SCIP documents keyed
+// by (upload ID, path). Each entry remembers the ID of the document row it was
+// decoded from, so that callers can check whether an entry is still current
+// without transferring or decompressing the payload again.
+type scipDocumentCache struct {
+	mu       sync.Mutex
+	lru      *simplelru.LRU[scipDocumentCacheKey, scipDocumentCacheEntry]
+	size     int64
+	maxBytes int64
+}
+
+// newSCIPDocumentCache returns a cache holding at most maxBytes of decompressed
+// documents, or nil if maxBytes is zero. A nil cache is valid and never hits.
+func newSCIPDocumentCache(maxBytes int64) *scipDocumentCache {
+	if maxBytes <= 0 {
+		return nil
+	}
+
+	c := &scipDocumentCache{maxBytes: maxBytes}
+	// The LRU is bounded by the total size of its entries, not their count.
+	c.lru, _ = simplelru.NewLRU(math.MaxInt, func(_ scipDocumentCacheKey, entry scipDocumentCacheEntry) {
+		c.size -= entry.size
+	})
+	return c
+}
+
+// get returns the cached entry for the given key. The returned document is a copy
+// and may be freely modified by the caller.
+func (c *scipDocumentCache) get(uploadID int, path string) (documentID int, document *scip.Document, ok bool) {
+	if c == nil {
+		return 0, nil, false
+	}
+
+	c.mu.Lock()
+	entry, ok := c.lru.Get(scipDocumentCacheKey{uploadID: uploadID, path: path})
+	c.mu.Unlock()
+	if !ok {
+		return 0, nil, false
+	}
+
+	return entry.documentID, proto.Clone(entry.document).(*scip.Document), true
+}
+
+// add caches a copy of the given document, whose decompressed payload is size
+// bytes long. Documents larger than the whole cache are not stored.
+func (c *scipDocumentCache) add(uploadID int, path string, documentID int, document *scip.Document, size int64) {
+	if c == nil || size > c.maxBytes {
+		return
+	}
+
+	entry := scipDocumentCacheEntry{
+		documentID: documentID,
+		document:   proto.Clone(document).(*scip.Document),
+		size:       size,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := scipDocumentCacheKey{uploadID: uploadID, path: path}
+	c.lru.Remove(key)
+	c.lru.Add(key, entry)
+	c.size += size
+	for c.size > c.maxBytes {
+		c.lru.RemoveOldest()
+	}
+}
+
+// remove drops the entry for the given key, e.g. because the upload it belongs
+// to has been deleted.
+func (c *scipDocumentCache) remove(uploadID int, path string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	c.lru.Remove(scipDocumentCacheKey{uploadID: uploadID, path: path})
+	c.mu.Unlock()
+}
@@ -0,0 +1,122 @@
+package lsifstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/sourcegraph/scip/bindings/go/scip"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sourcegraph/sourcegraph/internal/codeintel/core"
+)
+
+func TestSCIPDocumentCache(t *testing.T) {
+	ctx := context.Background()
+	s := populateTestStore(t).(*store)
+	s.documentCache = newSCIPDocumentCache(1 << 30)
+
+	const path = "template/src/lsif/api.ts"
+	uploadRelPath := core.NewUploadRelPathUnchecked(path)
+
+	first, err := s.SCIPDocument(ctx, testSCIPUploadID, uploadRelPath)
+	require.NoError(t, err)
+	require.NotNil(t, first)
+
+	// Corrupt the stored payload. If the second fetch decompressed it again
+	// instead of hitting the cache, it would fail.
+	require.NoError(t, s.db.Exec(ctx, sqlf.Sprintf(`
+		UPDATE codeintel_scip_documents
+		SET raw_scip_payload = 'not a compressed payload'::bytea
+		WHERE id = (
+			SELECT document_id
+			FROM codeintel_scip_document_lookup
+			WHERE upload_id = %s AND document_path = %s
+		)
+	`, testSCIPUploadID, path)))
+
+	second, err := s.SCIPDocument(ctx, testSCIPUploadID, uploadRelPath)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(first, second), "cached document differs from the original")
+	require.NotSame(t, first, second, "cache must hand out copies")
+
+	// Once the upload is deleted, the cached entry must not be served anymore.
+	require.NoError(t, s.db.Exec(ctx, sqlf.Sprintf(
+		`DELETE FROM codeintel_scip_document_lookup WHERE upload_id = %s`, testSCIPUploadID)))
+
+	third, err := s.SCIPDocument(ctx, testSCIPUploadID, uploadRelPath)
+	require.NoError(t, err)
+	require.Nil(t, third)
+	_, _, ok := s.documentCache.get(testSCIPUploadID, path)
+	require.False(t, ok, "expected cache entry to be invalidated")
+}
+
+func TestSCIPDocumentCacheSizeBound(t *testing.T) {
+	c := newSCIPDocumentCache(10)
+
+	c.add(1, "a", 1, &scip.Document{RelativePath: "a"}, 4)
+	c.add(1, "b", 2, &scip.Document{RelativePath: "b"}, 4)
+	// Exceeds the budget, so the least recently used entry "a" is evicted.
+	c.add(1, "c", 3, &scip.Document{RelativePath: "c"}, 4)
+
+	_, _, ok := c.get(1, "a")
+	require.False(t, ok)
+	for _, path := range []string{"b", "c"} {
+		_, doc, ok := c.get(1, path)
+		require.True(t, ok)
+		require.Equal(t, path, doc.RelativePath)
+	}
+
+	// Documents larger than the whole cache are never stored.
+	c.add(2, "huge", 4, &scip.Document{}, 11)
+	_, _, ok = c.get(2, "huge")
+	require.False(t, ok)
+
+	// A nil cache is disabled and never hits.
+	require.Nil(t, newSCIPDocumentCache(0))
+	var disabled *scipDocumentCache
+	disabled.add(1, "a", 1, &scip.Document{}, 1)
+	_, _, ok = disabled.get(1, "a")
+	require.False(t, ok)
+}
+
+func TestSCIPDocumentsCache(t *testing.T) {
+	ctx := context.Background()
+	s := populateTestStore(t).(*store)
+	s.documentCache = newSCIPDocumentCache(1 << 30)
+
+	const path = "template/src/lsif/api.ts"
+	uploadRelPath := core.NewUploadRelPathUnchecked(path)
+
+	first, err := s.SCIPDocument(ctx, testSCIPUploadID, uploadRelPath)
+	require.NoError(t, err)
+	require.NotNil(t, first)
+
+	// Corrupt the stored payload like in TestSCIPDocumentCache. The batched
+	// fetch must serve the document cached by SCIPDocument.
+	require.NoError(t, s.db.Exec(ctx, sqlf.Sprintf(`
+		UPDATE codeintel_scip_documents
+		SET raw_scip_payload = 'not a compressed payload'::bytea
+		WHERE id = (
+			SELECT document_id
+			FROM codeintel_scip_document_lookup
+			WHERE upload_id = %s AND document_path = %s
+		)
+	`, testSCIPUploadID, path)))
+
+	documents, err := s.SCIPDocuments(ctx, testSCIPUploadID, []core.UploadRelPath{uploadRelPath, core.NewUploadRelPathUnchecked("missing.ts")})
+	require.NoError(t, err)
+	require.Len(t, documents, 1)
+	require.True(t, proto.Equal(first, documents[uploadRelPath]), "cached document differs from the original")
+
+	// Once the upload is deleted, the cached entry must not be served anymore.
+	require.NoError(t, s.db.Exec(ctx, sqlf.Sprintf(
+		`DELETE FROM codeintel_scip_document_lookup WHERE upload_id = %s`, testSCIPUploadID)))
+
+	documents, err = s.SCIPDocuments(ctx, testSCIPUploadID, []core.UploadRelPath{uploadRelPath})
+	require.NoError(t, err)
+	require.Empty(t, documents)
+	_, _, ok := s.documentCache.get(testSCIPUploadID, path)
+	require.False(t, ok, "expected cache entry to be invalidated")
+}
@@ -644,3 +644,28 @@ func TestGetBulkMonikerLocations(t *testing.T) {
 		t.Errorf("unexpected locations (-want +got):\n%s", diff)
 	}
 }
+
+func TestGetBulkMonikerLocations_ExactMatch(t *testing.T) {
+	// Both symbols share a prefix with asArray(), which has references in
+	// the test upload, but neither of them is a symbol of the upload.
+	monikers := []precise.MonikerData{
+		{
+			Scheme:     "scip-typescript",
+			Identifier: "scip-typescript npm template 0.0.0-DEVELOPMENT src/util/`helpers.ts`/asArr",
+		},
+		{
+			Scheme:     "scip-typescript",
+			Identifier: "scip-typescript npm template 0.0.0-DEVELOPMENT src/util/`helpers.ts`/asArray().x",
+		},
+	}
+
+	store := populateTestStore(t)
+
+	locations, totalCount, err := store.GetBulkMonikerLocations(context.Background(), "references", []int{testSCIPUploadID}, monikers, 100, 0)
+	if err != nil {
+		t.Fatalf("unexpected error querying bulk moniker locations: %s", err)
+	}
+	if totalCount != 0 || len(locations) != 0 {
+		t.Errorf("unexpected locations for symbols sharing a prefix: totalCount=%d locations=%v", totalCount, locations)
+	}
+}
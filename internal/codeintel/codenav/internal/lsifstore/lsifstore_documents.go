@@ -5,6 +5,7 @@ import (
 	"context"
 
 	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
 	"github.com/sourcegraph/scip/bindings/go/scip"
 	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/protobuf/proto"
@@ -23,29 +24,56 @@ func (s *store) SCIPDocument(ctx context.Context, uploadID int, path core.Upload
 	}})
 	defer endObservation(1, observation.Args{})
 
-	scanner := basestore.NewFirstScanner(func(dbs dbutil.Scanner) (*scip.Document, error) {
+	// If we have a cached copy of the document, we only ask for the payload when
+	// the cached copy was decoded from a different document row. Upload IDs are
+	// never reused, but the lookup row disappears once the upload is deleted, so
+	// the query also tells us when to drop the cached entry.
+	cachedDocumentID, cachedDocument, cached := s.documentCache.get(uploadID, path.RawValue())
+	if !cached {
+		cachedDocumentID = -1
+	}
+
+	type fetchedDocument struct {
+		documentID int
+		document   *scip.Document
+	}
+	scanner := basestore.NewFirstScanner(func(dbs dbutil.Scanner) (fetchedDocument, error) {
+		var documentID int
 		var compressedSCIPPayload []byte
-		if err := dbs.Scan(&compressedSCIPPayload); err != nil {
-			return nil, err
+		if err := dbs.Scan(&documentID, &compressedSCIPPayload); err != nil {
+			return fetchedDocument{}, err
+		}
+		if cached && documentID == cachedDocumentID {
+			return fetchedDocument{documentID: documentID, document: cachedDocument}, nil
 		}
 
 		scipPayload, err := shared.Decompressor.Decompress(bytes.NewReader(compressedSCIPPayload))
 		if err != nil {
-			return nil, err
+			return fetchedDocument{}, err
 		}
 
 		var document scip.Document
 		if err := proto.Unmarshal(scipPayload, &document); err != nil {
-			return nil, err
+			return fetchedDocument{}, err
 		}
-		return &document, nil
+		s.documentCache.add(uploadID, path.RawValue(), documentID, &document, int64(len(scipPayload)))
+		return fetchedDocument{documentID: documentID, document: &document}, nil
 	})
-	doc, _, err := scanner(s.db.Query(ctx, sqlf.Sprintf(fetchSCIPDocumentQuery, uploadID, path.RawValue())))
-	return doc, err
+	fetched, ok, err := scanner(s.db.Query(ctx, sqlf.Sprintf(fetchSCIPDocumentQuery, cachedDocumentID, uploadID, path.RawValue())))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.documentCache.remove(uploadID, path.RawValue())
+		return nil, nil
+	}
+	return fetched.document, nil
 }
 
 const fetchSCIPDocumentQuery = `
-SELECT sd.raw_scip_payload
+SELECT
+	sd.id,
+	CASE WHEN sd.id = %s THEN NULL ELSE sd.raw_scip_payload END
 FROM codeintel_scip_document_lookup sid
 JOIN codeintel_scip_documents sd ON sd.id = sid.document_id
 WHERE
@@ -53,6 +81,91 @@ WHERE
 	sid.document_path = %s
 `
 
+// SCIPDocuments is like SCIPDocument, but fetches the documents at all of
+// paths of the upload with a single query. Paths without a document are
+// omitted from the returned map.
+func (s *store) SCIPDocuments(ctx context.Context, uploadID int, paths []core.UploadRelPath) (_ map[core.UploadRelPath]*scip.Document, err error) {
+	ctx, _, endObservation := s.operations.scipDocuments.With(ctx, &err, observation.Args{Attrs: []attribute.KeyValue{
+		attribute.Int("numPaths", len(paths)),
+		attribute.Int("uploadID", uploadID),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	// Like in SCIPDocument, we only ask for the payloads of documents that
+	// aren't cached already or were decoded from a different document row.
+	type cachedDocument struct {
+		documentID int
+		document   *scip.Document
+	}
+	rawPaths := make([]string, 0, len(paths))
+	cachedDocumentIDs := make([]int32, 0, len(paths))
+	cachedDocuments := make(map[string]cachedDocument, len(paths))
+	for _, path := range paths {
+		cachedDocumentID, document, cached := s.documentCache.get(uploadID, path.RawValue())
+		if cached {
+			cachedDocuments[path.RawValue()] = cachedDocument{documentID: cachedDocumentID, document: document}
+		} else {
+			cachedDocumentID = -1
+		}
+		rawPaths = append(rawPaths, path.RawValue())
+		cachedDocumentIDs = append(cachedDocumentIDs, int32(cachedDocumentID))
+	}
+
+	documents := make(map[core.UploadRelPath]*scip.Document, len(paths))
+	scanner := basestore.NewCallbackScanner(func(dbs dbutil.Scanner) (bool, error) {
+		var path string
+		var documentID int
+		var compressedSCIPPayload []byte
+		if err := dbs.Scan(&path, &documentID, &compressedSCIPPayload); err != nil {
+			return false, err
+		}
+		if cached, ok := cachedDocuments[path]; ok && cached.documentID == documentID {
+			documents[core.NewUploadRelPathUnchecked(path)] = cached.document
+			return true, nil
+		}
+
+		scipPayload, err := shared.Decompressor.Decompress(bytes.NewReader(compressedSCIPPayload))
+		if err != nil {
+			return false, err
+		}
+
+		var document scip.Document
+		if err := proto.Unmarshal(scipPayload, &document); err != nil {
+			return false, err
+		}
+		s.documentCache.add(uploadID, path, documentID, &document, int64(len(scipPayload)))
+		documents[core.NewUploadRelPathUnchecked(path)] = &document
+		return true, nil
+	})
+	if err := scanner(s.db.Query(ctx, sqlf.Sprintf(fetchSCIPDocumentsQuery, pq.Array(rawPaths), pq.Array(cachedDocumentIDs), uploadID))); err != nil {
+		return nil, err
+	}
+
+	// Drop the cached entries of documents that are gone, see SCIPDocument.
+	for path := range cachedDocuments {
+		if _, ok := documents[core.NewUploadRelPathUnchecked(path)]; !ok {
+			s.documentCache.remove(uploadID, path)
+		}
+	}
+	return documents, nil
+}
+
+const fetchSCIPDocumentsQuery = `
+SELECT
+	sid.document_path,
+	sd.id,
+	CASE WHEN sd.id = p.cached_document_id THEN NULL ELSE sd.raw_scip_payload END
+FROM unnest(%s::text[], %s::integer[]) AS p(document_path, cached_document_id)
+JOIN codeintel_scip_document_lookup sid ON sid.document_path = p.document_path
+JOIN codeintel_scip_documents sd ON sd.id = sid.document_id
+WHERE
+	sid.upload_id = %s
+`
+
 func (s *store) FindDocumentIDs(ctx context.Context, uploadIDToLookupPath map[int]core.UploadRelPath) (uploadIDToDocumentID map[int]int, err error) {
 	ctx, _, endObservation := s.operations.findDocumentIDs.With(ctx, &err, observation.Args{Attrs: []attribute.KeyValue{
 		attribute.Int("numUploadIDs", len(uploadIDToLookupPath)),
@@ -25,6 +25,7 @@ type LsifStore interface {
 	GetStencil(ctx context.Context, bundleID int, path core.UploadRelPath) ([]shared.Range, error)
 	GetRanges(ctx context.Context, bundleID int, path core.UploadRelPath, startLine, endLine int) ([]shared.CodeIntelligenceRange, error)
 	SCIPDocument(ctx context.Context, uploadID int, path core.UploadRelPath) (_ *scip.Document, err error)
+	SCIPDocuments(ctx context.Context, uploadID int, paths []core.UploadRelPath) (_ map[core.UploadRelPath]*scip.Document, err error)
 
 	// Fetch symbol names by position
 	GetMonikersByPosition(ctx context.Context, uploadID int, path core.UploadRelPath, line, character int) ([][]precise.MonikerData, error)
@@ -53,13 +54,15 @@ type LocationKey struct {
 }
 
 type store struct {
-	db         *basestore.Store
-	operations *operations
+	db            *basestore.Store
+	operations    *operations
+	documentCache *scipDocumentCache
 }
 
 func New(observationCtx *observation.Context, db codeintelshared.CodeIntelDB) LsifStore {
 	return &store{
-		db:         basestore.NewWithHandle(db.Handle()),
-		operations: newOperations(observationCtx),
+		db:            basestore.NewWithHandle(db.Handle()),
+		operations:    newOperations(observationCtx),
+		documentCache: newSCIPDocumentCache(int64(scipDocumentCacheSizeBytes)),
 	}
 }
@@ -3,8 +3,10 @@ package codenav
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sourcegraph/log"
 
 	"github.com/sourcegraph/sourcegraph/internal/metrics"
@@ -25,6 +27,15 @@ type operations struct {
 	visibleUploadsForPath             *observation.Operation
 	syntacticUsages                   *observation.Operation
 	searchBasedUsages                 *observation.Operation
+	getSymbolDefinitions              *observation.Operation
+	getSymbolDefinitionUploads        *observation.Operation
+
+	// occurrenceTranslations counts the occurrences whose ranges were mapped
+	// from the commit of an upload to the requested commit. Dropped
+	// occurrences are ones whose surrounding lines changed in between, so a
+	// high share of them means code navigation results on changed files are
+	// degraded by stale indexes.
+	occurrenceTranslations *prometheus.CounterVec
 }
 
 var m = new(metrics.SingletonREDMetrics)
@@ -47,6 +58,13 @@ func newOperations(observationCtx *observation.Context) *operations {
 		})
 	}
 
+	occurrenceTranslations := metrics.MustRegisterIgnoreDuplicate(observationCtx.Registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "src",
+		Subsystem: "codeintel_codenav",
+		Name:      "occurrence_translations_total",
+		Help:      "Total number of occurrences mapped between commits, by whether the mapping is the identity and whether they were translated or dropped.",
+	}, []string{"identity", "result"}))
+
 	return &operations{
 		getReferences:                     op("getReferences"),
 		getImplementations:                op("getImplementations"),
@@ -61,9 +79,21 @@ func newOperations(observationCtx *observation.Context) *operations {
 		visibleUploadsForPath:             op("VisibleUploadsForPath"),
 		syntacticUsages:                   op("SyntacticUsages"),
 		searchBasedUsages:                 op("SearchBasedUsages"),
+		getSymbolDefinitions:              op("GetSymbolDefinitions"),
+		getSymbolDefinitionUploads:        op("GetSymbolDefinitionUploads"),
+
+		occurrenceTranslations: occurrenceTranslations,
 	}
 }
 
+// observeOccurrenceTranslations records the outcome of mapping the occurrences
+// of a single document.
+func (o *operations) observeOccurrenceTranslations(identity bool, translated, dropped int) {
+	identityLabel := strconv.FormatBool(identity)
+	o.occurrenceTranslations.WithLabelValues(identityLabel, "translated").Add(float64(translated))
+	o.occurrenceTranslations.WithLabelValues(identityLabel, "dropped").Add(float64(dropped))
+}
+
 var serviceObserverThreshold = time.Second
 
 func observeResolver(ctx context.Context, err *error, operation *observation.Operation, threshold time.Duration, observationArgs observation.Args) (context.Context, observation.TraceLogger, func()) {
@@ -1,6 +1,8 @@
 package codenav
 
 import (
+	"slices"
+
 	"github.com/sourcegraph/scip/bindings/go/scip"
 	"github.com/sourcegraph/sourcegraph/internal/collections"
 )
@@ -16,3 +18,52 @@ func findOccurrencesWithEqualRange[Occurrence IOccurrence](occurrences []Occurre
 	})
 	return occurrences[interval.Start:interval.End]
 }
+
+// findOccurrencesContainingRange returns all occurrences whose range contains
+// the search range. Unlike findOccurrencesWithEqualRange, this also matches
+// occurrences for a search range (e.g. a cursor position) which lies strictly
+// inside of an occurrence's range.
+//
+// The occurrences must be sorted by range, which is the case for canonicalized
+// SCIP documents.
+func findOccurrencesContainingRange[Occurrence IOccurrence](occurrences []Occurrence, search scip.Range) []Occurrence {
+	// Occurrences are sorted by their start position, so only occurrences
+	// up to the last one starting at or before the search range can contain it.
+	end, _ := slices.BinarySearchFunc(occurrences, search.Start, func(occ Occurrence, p scip.Position) int {
+		if scip.NewRangeUnchecked(occ.GetRange()).Start.Compare(p) <= 0 {
+			return -1
+		}
+		return 1
+	})
+
+	results := []Occurrence{}
+	for _, occ := range occurrences[:end] {
+		if scip.NewRangeUnchecked(occ.GetRange()).End.Compare(search.End) >= 0 {
+			results = append(results, occ)
+		}
+	}
+	return results
+}
+
+// findOccurrencesAtRange returns the occurrences whose range is equal to the
+// search range. If there are none, e.g. because the search range is a cursor
+// position inside of a symbol, it returns the occurrences containing it.
+func findOccurrencesAtRange[Occurrence IOccurrence](occurrences []Occurrence, search scip.Range) []Occurrence {
+	if matches := findOccurrencesWithEqualRange(occurrences, search); len(matches) > 0 {
+		return matches
+	}
+	return findOccurrencesContainingRange(occurrences, search)
+}
+
+// symbolDocumentation returns the documentation of the symbols described in
+// the document, keyed by symbol name. Symbols without documentation are
+// omitted.
+func symbolDocumentation(document *scip.Document) map[string][]string {
+	documentation := map[string][]string{}
+	for _, info := range document.Symbols {
+		if len(info.Documentation) != 0 {
+			documentation[info.Symbol] = info.Documentation
+		}
+	}
+	return documentation
+}
@@ -89,3 +89,113 @@ func Test_findOccurrencesWithEqualRange(t *testing.T) {
 		})
 	}
 }
+
+func Test_findOccurrencesContainingRange(t *testing.T) {
+	tests := []testCase[testOccurrence]{
+		{
+			name: "empty",
+			args: args[testOccurrence]{
+				occurrences: []testOccurrence{},
+				search:      scip.NewRangeUnchecked([]int32{1, 1, 4}),
+			},
+			want: []testOccurrence{},
+		},
+		{
+			name: "exact match",
+			args: args[testOccurrence]{
+				occurrences: []testOccurrence{
+					{Ints: []int32{1, 0, 3}},
+					{Ints: []int32{1, 3, 5}},
+					{Ints: []int32{1, 5, 6}},
+				},
+				search: scip.NewRangeUnchecked([]int32{1, 3, 5}),
+			},
+			want: []testOccurrence{
+				{Ints: []int32{1, 3, 5}},
+			},
+		},
+		{
+			name: "point inside range",
+			args: args[testOccurrence]{
+				occurrences: []testOccurrence{
+					{Ints: []int32{1, 0, 3}},
+					{Ints: []int32{1, 3, 8}},
+					{Ints: []int32{1, 9, 12}},
+				},
+				search: scip.NewRangeUnchecked([]int32{1, 5, 5}),
+			},
+			want: []testOccurrence{
+				{Ints: []int32{1, 3, 8}},
+			},
+		},
+		{
+			name: "nested ranges",
+			args: args[testOccurrence]{
+				occurrences: []testOccurrence{
+					{Ints: []int32{0, 0, 5, 1}},
+					{Ints: []int32{1, 3, 8}},
+					{Ints: []int32{1, 4, 6}},
+					{Ints: []int32{2, 0, 4}},
+				},
+				search: scip.NewRangeUnchecked([]int32{1, 5, 6}),
+			},
+			want: []testOccurrence{
+				{Ints: []int32{0, 0, 5, 1}},
+				{Ints: []int32{1, 3, 8}},
+				{Ints: []int32{1, 4, 6}},
+			},
+		},
+		{
+			name: "partial overlap",
+			args: args[testOccurrence]{
+				occurrences: []testOccurrence{
+					{Ints: []int32{1, 0, 3}},
+					{Ints: []int32{1, 3, 5}},
+				},
+				search: scip.NewRangeUnchecked([]int32{1, 4, 7}),
+			},
+			want: []testOccurrence{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findOccurrencesContainingRange(tt.args.occurrences, tt.args.search)
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("unexpected ranges (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_findOccurrences_pointInsideRange(t *testing.T) {
+	occurrences := []testOccurrence{
+		{Ints: []int32{1, 3, 8}},
+	}
+	cursor := scip.NewRangeUnchecked([]int32{1, 5, 5})
+
+	if got := findOccurrencesWithEqualRange(occurrences, cursor); len(got) != 0 {
+		t.Errorf("expected no exact matches for a point inside a range, got %v", got)
+	}
+	if got := findOccurrencesContainingRange(occurrences, cursor); len(got) != 1 {
+		t.Errorf("expected a containment match for a point inside a range, got %v", got)
+	}
+}
+
+func Test_findOccurrencesAtRange(t *testing.T) {
+	occurrences := []testOccurrence{
+		{Ints: []int32{1, 3, 8}},
+		{Ints: []int32{1, 4, 6}},
+	}
+
+	// Exact matches take precedence over occurrences containing the range.
+	got := findOccurrencesAtRange(occurrences, scip.NewRangeUnchecked([]int32{1, 4, 6}))
+	if diff := cmp.Diff([]testOccurrence{{Ints: []int32{1, 4, 6}}}, got); diff != "" {
+		t.Errorf("unexpected ranges (-want +got):\n%s", diff)
+	}
+
+	// A cursor inside of a symbol matches the occurrences containing it.
+	got = findOccurrencesAtRange(occurrences, scip.NewRangeUnchecked([]int32{1, 7, 7}))
+	if diff := cmp.Diff([]testOccurrence{{Ints: []int32{1, 3, 8}}}, got); diff != "" {
+		t.Errorf("unexpected ranges (-want +got):\n%s", diff)
+	}
+}
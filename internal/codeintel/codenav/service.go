@@ -25,6 +25,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/observation"
 	searcher "github.com/sourcegraph/sourcegraph/internal/search/client"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
 	"github.com/sourcegraph/sourcegraph/internal/types"
 	"github.com/sourcegraph/sourcegraph/lib/codeintel/languages"
 	"github.com/sourcegraph/sourcegraph/lib/codeintel/precise"
@@ -998,31 +999,259 @@ func (s *Service) SCIPDocument(ctx context.Context, gitTreeTranslator GitTreeTra
 	if err != nil {
 		return nil, err
 	}
+	return s.mapDocument(ctx, gitTreeTranslator, upload, path, rawDocument)
+}
+
+// SCIPDocuments is like SCIPDocument, but fetches the documents at all of paths
+// with a single query and maps them with the same gitTreeTranslator. Paths
+// without a document in the upload are omitted from the returned map.
+func (s *Service) SCIPDocuments(ctx context.Context, gitTreeTranslator GitTreeTranslator, upload core.UploadLike, paths []core.RepoRelPath) (map[core.RepoRelPath]*scip.Document, error) {
+	uploadRelPaths := make([]core.UploadRelPath, 0, len(paths))
+	for _, path := range paths {
+		uploadRelPaths = append(uploadRelPaths, core.NewUploadRelPath(upload, path))
+	}
+	rawDocuments, err := s.lsifstore.SCIPDocuments(ctx, upload.GetID(), uploadRelPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	documents := make(map[core.RepoRelPath]*scip.Document, len(rawDocuments))
+	for i, path := range paths {
+		rawDocument, ok := rawDocuments[uploadRelPaths[i]]
+		if !ok {
+			continue
+		}
+		document, err := s.mapDocument(ctx, gitTreeTranslator, upload, path, rawDocument)
+		if err != nil {
+			return nil, err
+		}
+		documents[path] = document
+	}
+	return documents, nil
+}
+
+// mapDocument maps the occurrences of rawDocument, the document at path in
+// upload, to the source commit of gitTreeTranslator.
+func (s *Service) mapDocument(ctx context.Context, gitTreeTranslator GitTreeTranslator, upload core.UploadLike, path core.RepoRelPath, rawDocument *scip.Document) (*scip.Document, error) {
 	// The caller shouldn't need to care whether the document was uploaded
 	// for a different root or not.
 	rawDocument.RelativePath = path.RawValue()
 	if gitTreeTranslator.GetSourceCommit() == upload.GetCommit() {
+		s.operations.observeOccurrenceTranslations(true, len(rawDocument.Occurrences), 0)
 		return rawDocument, nil
 	}
 	translated := make([]*scip.Occurrence, 0, len(rawDocument.Occurrences))
-	for _, occ := range rawDocument.Occurrences {
-		sourceRange := scip.NewRangeUnchecked(occ.Range)
-		sourceSharedRange := shared.TranslateRange(sourceRange)
-		// TODO: This will be ~quadratic in document size; see TODO(id: add-bulk-translation-api)
-		targetSharedRange, success, err := gitTreeTranslator.GetTargetCommitRangeFromSourceRange(
-			ctx, string(upload.GetCommit()), path.RawValue(), sourceSharedRange, true,
+	if err := s.mapOccurrences(ctx, gitTreeTranslator, upload, path, rawDocument.Occurrences, func(occ *scip.Occurrence) error {
+		translated = append(translated, occ)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	rawDocument.Occurrences = translated
+	return rawDocument, nil
+}
+
+// StreamOccurrences is like SCIPDocument, but passes the occurrences of the
+// document to yield one at a time instead of returning them all at once.
+// Iteration stops at the first error returned by yield, which is returned.
+//
+// The document is still loaded and decoded as a whole via SCIPDocument, as the
+// payload is stored as a single compressed protobuf message. Only the mapped
+// occurrences aren't collected, so callers that don't retain them avoid a
+// second copy of the occurrences of huge (e.g. generated) documents.
+func (s *Service) StreamOccurrences(ctx context.Context, gitTreeTranslator GitTreeTranslator, upload core.UploadLike, path core.RepoRelPath, yield func(*scip.Occurrence) error) error {
+	rawDocument, err := s.lsifstore.SCIPDocument(ctx, upload.GetID(), core.NewUploadRelPath(upload, path))
+	if err != nil {
+		return err
+	}
+	if rawDocument == nil {
+		// The upload has no document at path, so there is nothing to yield.
+		return nil
+	}
+	return s.mapOccurrences(ctx, gitTreeTranslator, upload, path, rawDocument.Occurrences, yield)
+}
+
+// GetOccurrencesByRole returns the occurrences of the document at path that
+// have any of the given symbol roles, e.g. scip.SymbolRole_WriteAccess to find
+// all writes to variables. Ranges are mapped like in SCIPDocument.
+func (s *Service) GetOccurrencesByRole(ctx context.Context, gitTreeTranslator GitTreeTranslator, upload core.UploadLike, path core.RepoRelPath, roles scip.SymbolRole) ([]*scip.Occurrence, error) {
+	var occurrences []*scip.Occurrence
+	if err := s.StreamOccurrences(ctx, gitTreeTranslator, upload, path, func(occ *scip.Occurrence) error {
+		if roles.Matches(occ) {
+			occurrences = append(occurrences, occ)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return occurrences, nil
+}
+
+// GetOccurrencesAtRange returns the occurrences of the document at path whose
+// range, mapped like in SCIPDocument, is equal to rng.
+func (s *Service) GetOccurrencesAtRange(ctx context.Context, gitTreeTranslator GitTreeTranslator, upload core.UploadLike, path core.RepoRelPath, rng scip.Range) ([]*scip.Occurrence, error) {
+	rawDocument, err := s.lsifstore.SCIPDocument(ctx, upload.GetID(), core.NewUploadRelPath(upload, path))
+	if err != nil {
+		return nil, err
+	}
+	if rawDocument == nil {
+		return nil, nil
+	}
+
+	// Large documents have thousands of occurrences, so instead of mapping all
+	// of them we map the search range to the upload's commit, and only map the
+	// occurrences found there back. If the search range can't be mapped, e.g.
+	// because its lines changed in between, we can't tell which occurrences end
+	// up at it, and fall back to mapping the whole document.
+	targetRange, ok := rng, true
+	if gitTreeTranslator.GetSourceCommit() != upload.GetCommit() {
+		sharedRange, translated, err := gitTreeTranslator.GetTargetCommitRangeFromSourceRange(
+			ctx, string(upload.GetCommit()), path.RawValue(), shared.TranslateRange(rng), false,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "While translating ranges between commits")
 		}
-		if !success {
-			continue
+		targetRange, ok = sharedRange.ToSCIPRange(), translated
+	}
+	candidates := rawDocument.Occurrences
+	if ok {
+		candidates = findOccurrencesWithEqualRange(candidates, targetRange)
+	}
+
+	var occurrences []*scip.Occurrence
+	if err := s.mapOccurrences(ctx, gitTreeTranslator, upload, path, candidates, func(occ *scip.Occurrence) error {
+		if scip.NewRangeUnchecked(occ.Range).CompareStrict(rng) == 0 {
+			occurrences = append(occurrences, occ)
 		}
-		occ.Range = targetSharedRange.ToSCIPRange().SCIPRange()
-		translated = append(translated, occ)
+		return nil
+	}); err != nil {
+		return nil, err
 	}
-	rawDocument.Occurrences = translated
-	return rawDocument, nil
+	return occurrences, nil
+}
+
+// mapOccurrences maps the ranges of the given occurrences of the document at
+// path from the upload's commit to the source commit of gitTreeTranslator, and
+// passes them to yield one by one. Occurrences whose range can't be mapped are
+// skipped. Entries of occurrences are cleared once they were handled, so that
+// yielded occurrences can be garbage collected while iterating.
+func (s *Service) mapOccurrences(ctx context.Context, gitTreeTranslator GitTreeTranslator, upload core.UploadLike, path core.RepoRelPath, occurrences []*scip.Occurrence, yield func(*scip.Occurrence) error) error {
+	translate := gitTreeTranslator.GetSourceCommit() != upload.GetCommit()
+	translated, dropped := 0, 0
+	defer func() { s.operations.observeOccurrenceTranslations(!translate, translated, dropped) }()
+	for i, occ := range occurrences {
+		occurrences[i] = nil
+		if translate {
+			sourceRange := scip.NewRangeUnchecked(occ.Range)
+			sourceSharedRange := shared.TranslateRange(sourceRange)
+			// TODO: This will be ~quadratic in document size; see TODO(id: add-bulk-translation-api)
+			targetSharedRange, success, err := gitTreeTranslator.GetTargetCommitRangeFromSourceRange(
+				ctx, string(upload.GetCommit()), path.RawValue(), sourceSharedRange, true,
+			)
+			if err != nil {
+				return errors.Wrap(err, "While translating ranges between commits")
+			}
+			if !success {
+				dropped++
+				continue
+			}
+			occ.Range = targetSharedRange.ToSCIPRange().SCIPRange()
+		}
+		translated++
+		if err := yield(occ); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maxSymbolDefinitions bounds the number of definitions returned by
+// GetSymbolDefinitions. Symbols usually have a single definition, but some
+// languages allow for more, e.g. partial classes in C#.
+const maxSymbolDefinitions = 100
+
+// GetSymbolDefinitions returns the locations of all definitions of the given
+// SCIP symbol in the given upload, without the caller having to know which
+// documents contain them. Ranges are mapped from the upload's commit to the
+// source commit of gitTreeTranslator. Definitions that can't be mapped because
+// the surrounding lines changed in between are dropped.
+func (s *Service) GetSymbolDefinitions(ctx context.Context, gitTreeTranslator GitTreeTranslator, upload uploadsshared.CompletedUpload, symbol string) (_ []shared.UploadLocation, err error) {
+	ctx, _, endObservation := s.operations.getSymbolDefinitions.With(ctx, &err, observation.Args{Attrs: []attribute.KeyValue{
+		attribute.Int("uploadID", upload.ID),
+		attribute.String("symbol", symbol),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	locations, _, err := s.lsifstore.GetMinimalBulkMonikerLocations(
+		ctx,
+		"definitions",
+		[]int{upload.ID},
+		nil,
+		[]precise.MonikerData{{Identifier: symbol}},
+		maxSymbolDefinitions,
+		0,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	targetCommit := gitTreeTranslator.GetSourceCommit()
+	definitions := make([]shared.UploadLocation, 0, len(locations))
+	for _, location := range locations {
+		path := core.NewRepoRelPath(upload, location.Path)
+		targetRange := location.Range
+		if targetCommit != upload.GetCommit() {
+			var ok bool
+			targetRange, ok, err = gitTreeTranslator.GetTargetCommitRangeFromSourceRange(ctx, upload.Commit, path.RawValue(), location.Range, true)
+			if err != nil {
+				return nil, errors.Wrap(err, "While translating ranges between commits")
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		definitions = append(definitions, shared.UploadLocation{
+			Upload:       upload,
+			Path:         path,
+			TargetCommit: string(targetCommit),
+			TargetRange:  targetRange,
+		})
+	}
+
+	return definitions, nil
+}
+
+// GetSymbolDefinitionUploads returns the uploads that define the given SCIP
+// symbol, which may belong to other repositories than the one the symbol was
+// referenced in, e.g. for symbols defined in dependencies. Pass the returned
+// uploads to GetSymbolDefinitions, with a GitTreeTranslator for the upload's
+// repository, to get the locations of the definitions.
+//
+// Local symbols and symbols without package information can only be resolved
+// within the upload they occur in, so no uploads are returned for them.
+func (s *Service) GetSymbolDefinitionUploads(ctx context.Context, symbol string) (_ []uploadsshared.CompletedUpload, err error) {
+	ctx, _, endObservation := s.operations.getSymbolDefinitionUploads.With(ctx, &err, observation.Args{Attrs: []attribute.KeyValue{
+		attribute.String("symbol", symbol),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	if scip.IsLocalSymbol(symbol) {
+		return nil, nil
+	}
+	monikers, err := symbolsToMonikers([]string{symbol})
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing symbol")
+	}
+	if len(monikers) == 0 {
+		return nil, nil
+	}
+
+	uploads, err := s.uploadSvc.GetCompletedUploadsWithDefinitionsForMonikers(ctx, monikers)
+	if err != nil {
+		return nil, errors.Wrap(err, "uploadSvc.GetCompletedUploadsWithDefinitionsForMonikers")
+	}
+	return uploads, nil
 }
 
 type SyntacticUsagesErrorCode int
@@ -1131,7 +1360,7 @@ func (s *Service) getSyntacticSymbolsAtRange(
 	var parseFail *scip.Occurrence = nil
 
 	// FIXME(issue: GRAPH-674): Properly handle different text encodings here.
-	for _, occurrence := range findOccurrencesWithEqualRange(doc.Occurrences, targetRange) {
+	for _, occurrence := range findOccurrencesAtRange(doc.Occurrences, targetRange) {
 		parsedSymbol, err := scip.ParseSymbol(occurrence.Symbol)
 		if err != nil {
 			parseFail = occurrence
@@ -1197,6 +1426,7 @@ func (s *Service) findSyntacticMatchesForCandidateFile(
 
 	syntacticMatches := []SyntacticMatch{}
 	searchBasedMatches := []SearchBasedMatch{}
+	documentation := symbolDocumentation(document)
 	// TODO: We can optimize this further by continuously slicing the occurrences array
 	// as both these arrays are sorted
 	failedTranslationCount := 0
@@ -1215,17 +1445,19 @@ func (s *Service) findSyntacticMatchesForCandidateFile(
 				// Return results at the commit the match was found at, not at the commit
 				// where the syntactic upload was found.
 				syntacticMatches = append(syntacticMatches, SyntacticMatch{
-					Path:         filePath,
-					Range:        sourceCandidateRange,
-					Symbol:       occ.Symbol,
-					IsDefinition: scip.SymbolRole_Definition.Matches(occ),
+					Path:          filePath,
+					Range:         sourceCandidateRange,
+					Symbol:        occ.Symbol,
+					IsDefinition:  scip.SymbolRole_Definition.Matches(occ),
+					Documentation: documentation[occ.Symbol],
 				})
 			}
 		}
 		if !foundSyntacticMatch {
 			searchBasedMatches = append(searchBasedMatches, SearchBasedMatch{
-				Path:  filePath,
-				Range: sourceCandidateRange,
+				Path:       filePath,
+				Range:      sourceCandidateRange,
+				DataSource: candidateFile.dataSource,
 			})
 		}
 	}
@@ -1239,6 +1471,9 @@ type SearchBasedMatch struct {
 	Path         core.RepoRelPath
 	Range        scip.Range
 	IsDefinition bool
+	// DataSource is the search backend which found the match, see
+	// result.FileMatch.Source.
+	DataSource result.FileMatchSource
 }
 
 type SyntacticMatch struct {
@@ -1246,6 +1481,9 @@ type SyntacticMatch struct {
 	Range        scip.Range
 	IsDefinition bool
 	Symbol       string
+	// Documentation is the markdown documentation of Symbol from the
+	// syntactic upload, if any.
+	Documentation []string
 }
 
 type SyntacticUsagesResult struct {
@@ -1464,6 +1702,7 @@ func (s *Service) searchBasedUsagesInner(
 				Path:         pair.Key,
 				Range:        rg,
 				IsDefinition: candidateSymbols.Contains(pair.Key, rg),
+				DataSource:   pair.Value.dataSource,
 			})
 		}
 		results = append(results, matches)
@@ -2,8 +2,17 @@ package codenav
 
 import (
 	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/log"
 
 	"github.com/sourcegraph/sourcegraph/internal/codeintel/codenav/shared"
+	uploadsshared "github.com/sourcegraph/sourcegraph/internal/codeintel/uploads/shared"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/observation"
+	"github.com/sourcegraph/sourcegraph/internal/search/client"
+	"github.com/sourcegraph/sourcegraph/lib/codeintel/precise"
 )
 
 func mockedGitTreeTranslator() GitTreeTranslator {
@@ -17,3 +26,110 @@ func mockedGitTreeTranslator() GitTreeTranslator {
 
 	return mockPositionAdjuster
 }
+
+func TestGetSymbolDefinitions(t *testing.T) {
+	mockLsifStore := NewMockLsifStore()
+	svc := newService(observation.TestContextTB(t), defaultMockRepoStore(), mockLsifStore, NewMockUploadService(), gitserver.NewMockClient(), client.NewMockSearchClient(), log.NoOp())
+
+	const symbol = "scip-go gomod example.com/foo v1.0.0 `example.com/foo/bar`/Baz#"
+	upload := uploadsshared.CompletedUpload{ID: 42, Commit: "deadbeef", Root: "sub/"}
+
+	mockLsifStore.GetMinimalBulkMonikerLocationsFunc.SetDefaultHook(func(_ context.Context, tableName string, uploadIDs []int, _ map[int]string, monikers []precise.MonikerData, _, _ int) ([]shared.Location, int, error) {
+		if tableName != "definitions" || len(uploadIDs) != 1 || uploadIDs[0] != upload.ID || len(monikers) != 1 || monikers[0].Identifier != symbol {
+			return nil, 0, nil
+		}
+		return []shared.Location{
+			{UploadID: upload.ID, Path: uploadRelPath("bar/baz.go"), Range: testRange1},
+			// This definition is no longer present at the target commit.
+			{UploadID: upload.ID, Path: uploadRelPath("bar/removed.go"), Range: testRange2},
+		}, 2, nil
+	})
+
+	// The target commit has two lines inserted at the top of bar/baz.go.
+	translator := NewMockGitTreeTranslator()
+	translator.GetSourceCommitFunc.SetDefaultReturn("cafebabe")
+	translator.GetTargetCommitRangeFromSourceRangeFunc.SetDefaultHook(func(_ context.Context, commit string, path string, rx shared.Range, _ bool) (shared.Range, bool, error) {
+		if path != "sub/bar/baz.go" {
+			return shared.Range{}, false, nil
+		}
+		rx.Start.Line += 2
+		rx.End.Line += 2
+		return rx, true, nil
+	})
+
+	definitions, err := svc.GetSymbolDefinitions(context.Background(), translator, upload, symbol)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expectedRange := testRange1
+	expectedRange.Start.Line += 2
+	expectedRange.End.Line += 2
+	expectedDefinitions := []shared.UploadLocation{
+		{Upload: upload, Path: repoRelPath("sub/bar/baz.go"), TargetCommit: "cafebabe", TargetRange: expectedRange},
+	}
+	if diff := cmp.Diff(expectedDefinitions, definitions); diff != "" {
+		t.Errorf("unexpected definitions (-want +got):\n%s", diff)
+	}
+}
+
+func TestGetSymbolDefinitionUploads(t *testing.T) {
+	mockLsifStore := NewMockLsifStore()
+	mockUploadSvc := NewMockUploadService()
+	svc := newService(observation.TestContextTB(t), defaultMockRepoStore(), mockLsifStore, mockUploadSvc, gitserver.NewMockClient(), client.NewMockSearchClient(), log.NoOp())
+
+	// The symbol is referenced in an upload of repository 50, and defined in an
+	// upload of the dependency example.com/dep, which is repository 60.
+	const symbol = "scip-go gomod example.com/dep v1.2.0 `example.com/dep/lib`/Frob()."
+	dependency := uploadsshared.CompletedUpload{ID: 51, RepositoryID: 60, Commit: "cafebabe"}
+
+	mockUploadSvc.GetCompletedUploadsWithDefinitionsForMonikersFunc.SetDefaultHook(func(_ context.Context, monikers []precise.QualifiedMonikerData) ([]uploadsshared.CompletedUpload, error) {
+		if len(monikers) != 1 || monikers[0].Identifier != symbol || monikers[0].Name != "example.com/dep" || monikers[0].Version != "v1.2.0" {
+			return nil, nil
+		}
+		return []uploadsshared.CompletedUpload{dependency}, nil
+	})
+	mockLsifStore.GetMinimalBulkMonikerLocationsFunc.SetDefaultHook(func(_ context.Context, tableName string, uploadIDs []int, _ map[int]string, monikers []precise.MonikerData, _, _ int) ([]shared.Location, int, error) {
+		if tableName != "definitions" || len(uploadIDs) != 1 || uploadIDs[0] != dependency.ID || len(monikers) != 1 || monikers[0].Identifier != symbol {
+			return nil, 0, nil
+		}
+		return []shared.Location{{UploadID: dependency.ID, Path: uploadRelPath("lib/frob.go"), Range: testRange1}}, 1, nil
+	})
+
+	uploads, err := svc.GetSymbolDefinitionUploads(context.Background(), symbol)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff([]uploadsshared.CompletedUpload{dependency}, uploads); diff != "" {
+		t.Fatalf("unexpected uploads (-want +got):\n%s", diff)
+	}
+
+	// Definitions in the dependency are resolved at its own commit.
+	translator := NewMockGitTreeTranslator()
+	translator.GetSourceCommitFunc.SetDefaultReturn("cafebabe")
+	definitions, err := svc.GetSymbolDefinitions(context.Background(), translator, uploads[0], symbol)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expectedDefinitions := []shared.UploadLocation{
+		{Upload: dependency, Path: repoRelPath("lib/frob.go"), TargetCommit: "cafebabe", TargetRange: testRange1},
+	}
+	if diff := cmp.Diff(expectedDefinitions, definitions); diff != "" {
+		t.Errorf("unexpected definitions (-want +got):\n%s", diff)
+	}
+	if history := translator.GetTargetCommitRangeFromSourceRangeFunc.History(); len(history) != 0 {
+		t.Errorf("unexpected range translations: %v", history)
+	}
+
+	// Local symbols can't be defined in other uploads.
+	uploads, err = svc.GetSymbolDefinitionUploads(context.Background(), "local 3")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(uploads) != 0 {
+		t.Errorf("unexpected uploads for local symbol: %v", uploads)
+	}
+	if history := mockUploadSvc.GetCompletedUploadsWithDefinitionsForMonikersFunc.History(); len(history) != 1 {
+		t.Errorf("unexpected number of upload lookups: want=1 got=%d", len(history))
+	}
+}
@@ -0,0 +1,396 @@
+package codenav
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sourcegraph/log"
+	"github.com/sourcegraph/scip/bindings/go/scip"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/codeintel/codenav/shared"
+	"github.com/sourcegraph/sourcegraph/internal/codeintel/core"
+	uploadsshared "github.com/sourcegraph/sourcegraph/internal/codeintel/uploads/shared"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/observation"
+	"github.com/sourcegraph/sourcegraph/internal/search/client"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+func TestStreamOccurrences(t *testing.T) {
+	mockLsifStore := NewMockLsifStore()
+	svc := newService(observation.TestContextTB(t), defaultMockRepoStore(), mockLsifStore, NewMockUploadService(), gitserver.NewMockClient(), client.NewMockSearchClient(), log.NoOp())
+
+	// Return a fresh document on every call, as occurrences are mapped in place.
+	mockLsifStore.SCIPDocumentFunc.SetDefaultHook(func(_ context.Context, _ int, _ core.UploadRelPath) (*scip.Document, error) {
+		doc := &scip.Document{RelativePath: "foo.go"}
+		for line := int32(0); line < 1000; line++ {
+			doc.Occurrences = append(doc.Occurrences, &scip.Occurrence{
+				Range:  []int32{line, 0, 5},
+				Symbol: "local 1",
+			})
+		}
+		return doc, nil
+	})
+
+	// Lines 100-199 were removed in the target commit.
+	translator := NewMockGitTreeTranslator()
+	translator.GetSourceCommitFunc.SetDefaultReturn("cafebabe")
+	translator.GetTargetCommitRangeFromSourceRangeFunc.SetDefaultHook(func(_ context.Context, _, _ string, rx shared.Range, _ bool) (shared.Range, bool, error) {
+		switch {
+		case rx.Start.Line < 100:
+			return rx, true, nil
+		case rx.Start.Line < 200:
+			return shared.Range{}, false, nil
+		default:
+			rx.Start.Line -= 100
+			rx.End.Line -= 100
+			return rx, true, nil
+		}
+	})
+
+	for _, upload := range []uploadsshared.CompletedUpload{
+		{ID: 42, Commit: "deadbeef"},
+		{ID: 42, Commit: "cafebabe"},
+	} {
+		t.Run(upload.Commit, func(t *testing.T) {
+			doc, err := svc.SCIPDocument(context.Background(), translator, upload, repoRelPath("foo.go"))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var streamed []*scip.Occurrence
+			if err := svc.StreamOccurrences(context.Background(), translator, upload, repoRelPath("foo.go"), func(occ *scip.Occurrence) error {
+				streamed = append(streamed, occ)
+				return nil
+			}); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if diff := cmp.Diff(occurrenceRanges(doc.Occurrences), occurrenceRanges(streamed)); diff != "" {
+				t.Errorf("unexpected streamed occurrences (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("yield error", func(t *testing.T) {
+		errStop := errors.New("stop")
+		calls := 0
+		err := svc.StreamOccurrences(context.Background(), translator, uploadsshared.CompletedUpload{ID: 42, Commit: "deadbeef"}, repoRelPath("foo.go"), func(*scip.Occurrence) error {
+			calls++
+			return errStop
+		})
+		if !errors.Is(err, errStop) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("unexpected number of yielded occurrences after error: %d", calls)
+		}
+	})
+}
+
+func TestOccurrencesOfMissingDocument(t *testing.T) {
+	mockLsifStore := NewMockLsifStore()
+	svc := newService(observation.TestContextTB(t), defaultMockRepoStore(), mockLsifStore, NewMockUploadService(), gitserver.NewMockClient(), client.NewMockSearchClient(), log.NoOp())
+
+	// The upload has no document for the path.
+	mockLsifStore.SCIPDocumentFunc.SetDefaultReturn(nil, nil)
+	translator := NewMockGitTreeTranslator()
+	translator.GetSourceCommitFunc.SetDefaultReturn("deadbeef")
+	upload := uploadsshared.CompletedUpload{ID: 42, Commit: "deadbeef"}
+
+	err := svc.StreamOccurrences(context.Background(), translator, upload, repoRelPath("foo.go"), func(*scip.Occurrence) error {
+		t.Fatal("unexpected occurrence")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	occurrences, err := svc.GetOccurrencesByRole(context.Background(), translator, upload, repoRelPath("foo.go"), scip.SymbolRole_Definition)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(occurrences) != 0 {
+		t.Errorf("unexpected occurrences: %v", occurrences)
+	}
+
+	occurrences, err = svc.GetOccurrencesAtRange(context.Background(), translator, upload, repoRelPath("foo.go"), scip.NewRangeUnchecked([]int32{1, 0, 5}))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(occurrences) != 0 {
+		t.Errorf("unexpected occurrences: %v", occurrences)
+	}
+}
+
+func TestSCIPDocuments(t *testing.T) {
+	mockLsifStore := NewMockLsifStore()
+	svc := newService(observation.TestContextTB(t), defaultMockRepoStore(), mockLsifStore, NewMockUploadService(), gitserver.NewMockClient(), client.NewMockSearchClient(), log.NoOp())
+
+	// bar.go has no document in the upload.
+	mockLsifStore.SCIPDocumentsFunc.SetDefaultHook(func(_ context.Context, _ int, paths []core.UploadRelPath) (map[core.UploadRelPath]*scip.Document, error) {
+		documents := map[core.UploadRelPath]*scip.Document{}
+		for _, path := range paths {
+			if path.RawValue() == "bar.go" {
+				continue
+			}
+			documents[path] = &scip.Document{
+				RelativePath: path.RawValue(),
+				Occurrences: []*scip.Occurrence{
+					{Range: []int32{1, 0, 5}, Symbol: "local 1"},
+					{Range: []int32{2, 0, 5}, Symbol: "local 1"},
+				},
+			}
+		}
+		return documents, nil
+	})
+
+	// Line 1 was removed in the target commit.
+	translator := NewMockGitTreeTranslator()
+	translator.GetSourceCommitFunc.SetDefaultReturn("cafebabe")
+	translator.GetTargetCommitRangeFromSourceRangeFunc.SetDefaultHook(func(_ context.Context, _, _ string, rx shared.Range, _ bool) (shared.Range, bool, error) {
+		if rx.Start.Line == 1 {
+			return shared.Range{}, false, nil
+		}
+		return rx, true, nil
+	})
+
+	upload := uploadsshared.CompletedUpload{ID: 42, Commit: "deadbeef", Root: "sub/"}
+	documents, err := svc.SCIPDocuments(context.Background(), translator, upload, []core.RepoRelPath{
+		repoRelPath("sub/foo.go"),
+		repoRelPath("sub/bar.go"),
+		repoRelPath("sub/baz.go"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if calls := len(mockLsifStore.SCIPDocumentsFunc.History()); calls != 1 {
+		t.Errorf("unexpected number of store calls: %d", calls)
+	}
+
+	got := map[string][][]int32{}
+	for path, document := range documents {
+		if document.RelativePath != path.RawValue() {
+			t.Errorf("unexpected relative path for %s: %s", path.RawValue(), document.RelativePath)
+		}
+		got[path.RawValue()] = occurrenceRanges(document.Occurrences)
+	}
+	want := map[string][][]int32{
+		"sub/foo.go": {{2, 0, 5}},
+		"sub/baz.go": {{2, 0, 5}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected documents (-want +got):\n%s", diff)
+	}
+}
+
+func TestGetOccurrencesByRole(t *testing.T) {
+	mockLsifStore := NewMockLsifStore()
+	svc := newService(observation.TestContextTB(t), defaultMockRepoStore(), mockLsifStore, NewMockUploadService(), gitserver.NewMockClient(), client.NewMockSearchClient(), log.NoOp())
+
+	mockLsifStore.SCIPDocumentFunc.SetDefaultHook(func(_ context.Context, _ int, _ core.UploadRelPath) (*scip.Document, error) {
+		return &scip.Document{
+			RelativePath: "foo.go",
+			Occurrences: []*scip.Occurrence{
+				{Range: []int32{0, 0, 5}, Symbol: "local 1", SymbolRoles: int32(scip.SymbolRole_Definition)},
+				{Range: []int32{1, 0, 5}, Symbol: "local 1", SymbolRoles: int32(scip.SymbolRole_WriteAccess)},
+				{Range: []int32{2, 0, 5}, Symbol: "local 1", SymbolRoles: int32(scip.SymbolRole_ReadAccess)},
+				{Range: []int32{3, 0, 5}, Symbol: "local 1", SymbolRoles: int32(scip.SymbolRole_ReadAccess | scip.SymbolRole_WriteAccess)},
+				{Range: []int32{4, 0, 5}, Symbol: "local 2", SymbolRoles: int32(scip.SymbolRole_Import)},
+				{Range: []int32{5, 0, 5}, Symbol: "local 2"},
+			},
+		}, nil
+	})
+
+	translator := NewMockGitTreeTranslator()
+	translator.GetSourceCommitFunc.SetDefaultReturn("deadbeef")
+	upload := uploadsshared.CompletedUpload{ID: 42, Commit: "deadbeef"}
+
+	for _, tc := range []struct {
+		name  string
+		roles scip.SymbolRole
+		want  [][]int32
+	}{
+		{name: "definitions", roles: scip.SymbolRole_Definition, want: [][]int32{{0, 0, 5}}},
+		{name: "writes", roles: scip.SymbolRole_WriteAccess, want: [][]int32{{1, 0, 5}, {3, 0, 5}}},
+		{name: "imports", roles: scip.SymbolRole_Import, want: [][]int32{{4, 0, 5}}},
+		{name: "any of multiple roles", roles: scip.SymbolRole_Definition | scip.SymbolRole_Import, want: [][]int32{{0, 0, 5}, {4, 0, 5}}},
+		{name: "no matches", roles: scip.SymbolRole_Generated, want: [][]int32{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			occurrences, err := svc.GetOccurrencesByRole(context.Background(), translator, upload, repoRelPath("foo.go"), tc.roles)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(tc.want, occurrenceRanges(occurrences)); diff != "" {
+				t.Errorf("unexpected occurrences (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMapOccurrencesMetrics(t *testing.T) {
+	occurrences := func() []*scip.Occurrence {
+		var occurrences []*scip.Occurrence
+		for line := int32(0); line < 10; line++ {
+			occurrences = append(occurrences, &scip.Occurrence{Range: []int32{line, 0, 5}})
+		}
+		return occurrences
+	}
+
+	// Lines 2 and 3 were changed in the target commit.
+	translator := NewMockGitTreeTranslator()
+	translator.GetSourceCommitFunc.SetDefaultReturn("cafebabe")
+	translator.GetTargetCommitRangeFromSourceRangeFunc.SetDefaultHook(func(_ context.Context, _, _ string, rx shared.Range, _ bool) (shared.Range, bool, error) {
+		if rx.Start.Line == 2 || rx.Start.Line == 3 {
+			return shared.Range{}, false, nil
+		}
+		return rx, true, nil
+	})
+	yield := func(*scip.Occurrence) error { return nil }
+
+	svc := &Service{operations: newOperations(observation.TestContextTB(t))}
+	occurrenceTranslations := svc.operations.occurrenceTranslations
+
+	for _, tc := range []struct {
+		commit              api.CommitID
+		identity            string
+		translated, dropped float64
+	}{
+		{commit: "deadbeef", identity: "false", translated: 8, dropped: 2},
+		{commit: "cafebabe", identity: "true", translated: 10, dropped: 0},
+	} {
+		t.Run(string(tc.commit), func(t *testing.T) {
+			translatedBefore := testutil.ToFloat64(occurrenceTranslations.WithLabelValues(tc.identity, "translated"))
+			droppedBefore := testutil.ToFloat64(occurrenceTranslations.WithLabelValues(tc.identity, "dropped"))
+
+			upload := uploadsshared.CompletedUpload{ID: 42, Commit: string(tc.commit)}
+			if err := svc.mapOccurrences(context.Background(), translator, upload, repoRelPath("foo.go"), occurrences(), yield); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got := testutil.ToFloat64(occurrenceTranslations.WithLabelValues(tc.identity, "translated")) - translatedBefore; got != tc.translated {
+				t.Errorf("unexpected number of translated occurrences: want=%v got=%v", tc.translated, got)
+			}
+			if got := testutil.ToFloat64(occurrenceTranslations.WithLabelValues(tc.identity, "dropped")) - droppedBefore; got != tc.dropped {
+				t.Errorf("unexpected number of dropped occurrences: want=%v got=%v", tc.dropped, got)
+			}
+		})
+	}
+}
+
+func TestGetOccurrencesAtRange(t *testing.T) {
+	mockLsifStore := NewMockLsifStore()
+	svc := newService(observation.TestContextTB(t), defaultMockRepoStore(), mockLsifStore, NewMockUploadService(), gitserver.NewMockClient(), client.NewMockSearchClient(), log.NoOp())
+
+	// Return a fresh document on every call, as occurrences are mapped in place.
+	mockLsifStore.SCIPDocumentFunc.SetDefaultHook(func(_ context.Context, _ int, _ core.UploadRelPath) (*scip.Document, error) {
+		doc := &scip.Document{RelativePath: "foo.go"}
+		for line := int32(0); line < 1000; line++ {
+			doc.Occurrences = append(doc.Occurrences,
+				&scip.Occurrence{Range: []int32{line, 0, 5}, Symbol: "local 1"},
+				&scip.Occurrence{Range: []int32{line, 0, 5}, Symbol: "local 2"},
+				&scip.Occurrence{Range: []int32{line, 10, 15}, Symbol: "local 3"},
+			)
+		}
+		return doc, nil
+	})
+
+	// Lines 100-199 of the upload's commit were removed in the source commit.
+	// Line 50 of the source commit can't be mapped to the upload's commit, so
+	// that lookups there have to fall back to mapping the whole document.
+	translator := NewMockGitTreeTranslator()
+	translator.GetSourceCommitFunc.SetDefaultReturn("cafebabe")
+	translator.GetTargetCommitRangeFromSourceRangeFunc.SetDefaultHook(func(_ context.Context, _, _ string, rx shared.Range, reverse bool) (shared.Range, bool, error) {
+		if !reverse {
+			switch {
+			case rx.Start.Line == 50:
+				return shared.Range{}, false, nil
+			case rx.Start.Line >= 100:
+				rx.Start.Line += 100
+				rx.End.Line += 100
+			}
+			return rx, true, nil
+		}
+		switch {
+		case rx.Start.Line < 100:
+			return rx, true, nil
+		case rx.Start.Line < 200:
+			return shared.Range{}, false, nil
+		default:
+			rx.Start.Line -= 100
+			rx.End.Line -= 100
+			return rx, true, nil
+		}
+	})
+
+	upload := uploadsshared.CompletedUpload{ID: 42, Commit: "deadbeef"}
+	fullDocument, err := svc.SCIPDocument(context.Background(), translator, upload, repoRelPath("foo.go"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, tc := range []struct {
+		name        string
+		rng         scip.Range
+		wantMatches int
+		fallback    bool
+	}{
+		{"unchanged line", scip.NewRangeUnchecked([]int32{10, 0, 5}), 2, false},
+		{"shifted line", scip.NewRangeUnchecked([]int32{150, 10, 15}), 1, false},
+		{"no occurrence", scip.NewRangeUnchecked([]int32{150, 0, 4}), 0, false},
+		{"past the end", scip.NewRangeUnchecked([]int32{950, 0, 5}), 0, false},
+		{"unmappable range", scip.NewRangeUnchecked([]int32{50, 0, 5}), 2, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var want []*scip.Occurrence
+			for _, occ := range fullDocument.Occurrences {
+				if scip.NewRangeUnchecked(occ.Range).CompareStrict(tc.rng) == 0 {
+					want = append(want, occ)
+				}
+			}
+			if len(want) != tc.wantMatches {
+				t.Fatalf("unexpected number of matches in fully mapped document: %d", len(want))
+			}
+
+			calls := len(translator.GetTargetCommitRangeFromSourceRangeFunc.History())
+			got, err := svc.GetOccurrencesAtRange(context.Background(), translator, upload, repoRelPath("foo.go"), tc.rng)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(occurrenceSymbols(want), occurrenceSymbols(got)); diff != "" {
+				t.Errorf("unexpected occurrences (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(occurrenceRanges(want), occurrenceRanges(got)); diff != "" {
+				t.Errorf("unexpected occurrence ranges (-want +got):\n%s", diff)
+			}
+
+			// Only the search range and the matches should have been mapped.
+			if !tc.fallback {
+				if mapped := len(translator.GetTargetCommitRangeFromSourceRangeFunc.History()) - calls; mapped > 1+tc.wantMatches {
+					t.Errorf("unexpected number of mapped ranges: %d", mapped)
+				}
+			}
+		})
+	}
+}
+
+func occurrenceRanges(occurrences []*scip.Occurrence) [][]int32 {
+	ranges := make([][]int32, 0, len(occurrences))
+	for _, occ := range occurrences {
+		ranges = append(ranges, occ.Range)
+	}
+	return ranges
+}
+
+func occurrenceSymbols(occurrences []*scip.Occurrence) []string {
+	symbols := make([]string, 0, len(occurrences))
+	for _, occ := range occurrences {
+		symbols = append(symbols, occ.Symbol)
+	}
+	return symbols
+}
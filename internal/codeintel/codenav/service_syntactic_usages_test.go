@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/sourcegraph/sourcegraph/internal/codeintel/core"
+	uploadsshared "github.com/sourcegraph/sourcegraph/internal/codeintel/uploads/shared"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/observation"
 )
@@ -97,3 +98,38 @@ func TestSearchBasedUsages_ResultWithSymbol(t *testing.T) {
 	expectSearchRanges(t, usages, refRange, refRange2, defRange)
 	expectDefinitionRanges(t, usages, defRange)
 }
+
+func TestFindSyntacticMatches_Documentation(t *testing.T) {
+	documentedSymbol := "scip-syntax . . . Foo#"
+	undocumentedSymbol := "scip-syntax . . . Bar#"
+	mockLsifStore := NewMockLsifStore()
+	mockLsifStore.SCIPDocumentFunc.SetDefaultReturn(&scip.Document{
+		RelativePath: "path.java",
+		Occurrences: []*scip.Occurrence{
+			{Range: []int32{1, 1, 1}, Symbol: documentedSymbol},
+			{Range: []int32{2, 2, 2}, Symbol: undocumentedSymbol},
+		},
+		Symbols: []*scip.SymbolInformation{
+			{Symbol: documentedSymbol, Documentation: []string{"```java\nclass Foo\n```", "Does foo things."}},
+			{Symbol: undocumentedSymbol},
+		},
+	}, nil)
+
+	svc := newService(
+		observation.TestContextTB(t), defaultMockRepoStore(),
+		mockLsifStore, NewMockUploadService(), gitserver.NewMockClient(),
+		FakeSearchClient().Build(), log.NoOp(),
+	)
+
+	syntacticMatches, _, err := svc.findSyntacticMatchesForCandidateFile(
+		context.Background(), observation.TestTraceLogger(log.NoOp()), NewMockGitTreeTranslator(),
+		uploadsshared.CompletedUpload{ID: 42}, repoRelPath("path.java"),
+		candidateFile{matches: []scip.Range{scipRange(1), scipRange(2)}},
+	)
+	require.Nil(t, err)
+	require.Len(t, syntacticMatches, 2)
+	require.Equal(t, documentedSymbol, syntacticMatches[0].Symbol)
+	require.Equal(t, []string{"```java\nclass Foo\n```", "Does foo things."}, syntacticMatches[0].Documentation)
+	require.Equal(t, undocumentedSymbol, syntacticMatches[1].Symbol)
+	require.Nil(t, syntacticMatches[1].Documentation)
+}
@@ -25,8 +25,9 @@ import (
 )
 
 type candidateFile struct {
-	matches             []scip.Range // Guaranteed to be sorted
-	didSearchEntireFile bool         // Or did we hit the search count limit?
+	matches             []scip.Range           // Guaranteed to be sorted
+	didSearchEntireFile bool                   // Or did we hit the search count limit?
+	dataSource          result.FileMatchSource // The search backend which found the matches
 }
 
 type searchArgs struct {
@@ -89,6 +90,7 @@ func findCandidateOccurrencesViaSearch(
 		_, alreadyPresent := resultMap.Set(core.NewRepoRelPathUnchecked(path), candidateFile{
 			matches:             scip.SortRanges(matches),
 			didSearchEntireFile: !fileMatch.LimitHit,
+			dataSource:          fileMatch.Source,
 		})
 		if alreadyPresent {
 			duplicatedFilepaths.Add(path)
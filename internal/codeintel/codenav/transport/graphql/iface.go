@@ -29,6 +29,9 @@ type CodeNavService interface {
 	SCIPDocument(context.Context, codenav.GitTreeTranslator, core.UploadLike, core.RepoRelPath) (*scip.Document, error)
 	SyntacticUsages(context.Context, codenav.GitTreeTranslator, codenav.UsagesForSymbolArgs) (codenav.SyntacticUsagesResult, codenav.PreviousSyntacticSearch, *codenav.SyntacticUsagesError)
 	SearchBasedUsages(context.Context, codenav.GitTreeTranslator, codenav.UsagesForSymbolArgs, core.Option[codenav.PreviousSyntacticSearch]) ([]codenav.SearchBasedMatch, error)
+	GetSymbolDefinitions(context.Context, codenav.GitTreeTranslator, uploadsshared.CompletedUpload, string) ([]shared.UploadLocation, error)
+	GetSymbolDefinitionUploads(context.Context, string) ([]uploadsshared.CompletedUpload, error)
+	GetOccurrencesAtRange(context.Context, codenav.GitTreeTranslator, core.UploadLike, core.RepoRelPath, scip.Range) ([]*scip.Occurrence, error)
 }
 
 var _ CodeNavService = &codenav.Service{}
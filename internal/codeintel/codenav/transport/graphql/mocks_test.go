@@ -197,6 +197,9 @@ type MockCodeNavService struct {
 	// GetImplementationsFunc is an instance of a mock function object
 	// controlling the behavior of the method GetImplementations.
 	GetImplementationsFunc *CodeNavServiceGetImplementationsFunc
+	// GetOccurrencesAtRangeFunc is an instance of a mock function object controlling the
+	// behavior of the method GetOccurrencesAtRange.
+	GetOccurrencesAtRangeFunc *CodeNavServiceGetOccurrencesAtRangeFunc
 	// GetPrototypesFunc is an instance of a mock function object
 	// controlling the behavior of the method GetPrototypes.
 	GetPrototypesFunc *CodeNavServiceGetPrototypesFunc
@@ -209,6 +212,12 @@ type MockCodeNavService struct {
 	// GetStencilFunc is an instance of a mock function object controlling
 	// the behavior of the method GetStencil.
 	GetStencilFunc *CodeNavServiceGetStencilFunc
+	// GetSymbolDefinitionUploadsFunc is an instance of a mock function object controlling the
+	// behavior of the method GetSymbolDefinitionUploads.
+	GetSymbolDefinitionUploadsFunc *CodeNavServiceGetSymbolDefinitionUploadsFunc
+	// GetSymbolDefinitionsFunc is an instance of a mock function object controlling the
+	// behavior of the method GetSymbolDefinitions.
+	GetSymbolDefinitionsFunc *CodeNavServiceGetSymbolDefinitionsFunc
 	// SCIPDocumentFunc is an instance of a mock function object controlling
 	// the behavior of the method SCIPDocument.
 	SCIPDocumentFunc *CodeNavServiceSCIPDocumentFunc
@@ -255,6 +264,11 @@ func NewMockCodeNavService() *MockCodeNavService {
 				return
 			},
 		},
+		GetOccurrencesAtRangeFunc: &CodeNavServiceGetOccurrencesAtRangeFunc{
+			defaultHook: func(context.Context, codenav.GitTreeTranslator, core.UploadLike, core.RepoRelPath, scip.Range) (r0 []*scip.Occurrence, r1 error) {
+				return
+			},
+		},
 		GetPrototypesFunc: &CodeNavServiceGetPrototypesFunc{
 			defaultHook: func(context.Context, codenav.PositionalRequestArgs, codenav.RequestState, codenav.Cursor) (r0 []shared1.UploadLocation, r1 codenav.Cursor, r2 error) {
 				return
@@ -275,6 +289,16 @@ func NewMockCodeNavService() *MockCodeNavService {
 				return
 			},
 		},
+		GetSymbolDefinitionUploadsFunc: &CodeNavServiceGetSymbolDefinitionUploadsFunc{
+			defaultHook: func(context.Context, string) (r0 []shared.CompletedUpload, r1 error) {
+				return
+			},
+		},
+		GetSymbolDefinitionsFunc: &CodeNavServiceGetSymbolDefinitionsFunc{
+			defaultHook: func(context.Context, codenav.GitTreeTranslator, shared.CompletedUpload, string) (r0 []shared1.UploadLocation, r1 error) {
+				return
+			},
+		},
 		SCIPDocumentFunc: &CodeNavServiceSCIPDocumentFunc{
 			defaultHook: func(context.Context, codenav.GitTreeTranslator, core.UploadLike, core.RepoRelPath) (r0 *scip.Document, r1 error) {
 				return
@@ -332,6 +356,11 @@ func NewStrictMockCodeNavService() *MockCodeNavService {
 				panic("unexpected invocation of MockCodeNavService.GetImplementations")
 			},
 		},
+		GetOccurrencesAtRangeFunc: &CodeNavServiceGetOccurrencesAtRangeFunc{
+			defaultHook: func(context.Context, codenav.GitTreeTranslator, core.UploadLike, core.RepoRelPath, scip.Range) ([]*scip.Occurrence, error) {
+				panic("unexpected invocation of MockCodeNavService.GetOccurrencesAtRange")
+			},
+		},
 		GetPrototypesFunc: &CodeNavServiceGetPrototypesFunc{
 			defaultHook: func(context.Context, codenav.PositionalRequestArgs, codenav.RequestState, codenav.Cursor) ([]shared1.UploadLocation, codenav.Cursor, error) {
 				panic("unexpected invocation of MockCodeNavService.GetPrototypes")
@@ -352,6 +381,16 @@ func NewStrictMockCodeNavService() *MockCodeNavService {
 				panic("unexpected invocation of MockCodeNavService.GetStencil")
 			},
 		},
+		GetSymbolDefinitionUploadsFunc: &CodeNavServiceGetSymbolDefinitionUploadsFunc{
+			defaultHook: func(context.Context, string) ([]shared.CompletedUpload, error) {
+				panic("unexpected invocation of MockCodeNavService.GetSymbolDefinitionUploads")
+			},
+		},
+		GetSymbolDefinitionsFunc: &CodeNavServiceGetSymbolDefinitionsFunc{
+			defaultHook: func(context.Context, codenav.GitTreeTranslator, shared.CompletedUpload, string) ([]shared1.UploadLocation, error) {
+				panic("unexpected invocation of MockCodeNavService.GetSymbolDefinitions")
+			},
+		},
 		SCIPDocumentFunc: &CodeNavServiceSCIPDocumentFunc{
 			defaultHook: func(context.Context, codenav.GitTreeTranslator, core.UploadLike, core.RepoRelPath) (*scip.Document, error) {
 				panic("unexpected invocation of MockCodeNavService.SCIPDocument")
@@ -400,6 +439,9 @@ func NewMockCodeNavServiceFrom(i CodeNavService) *MockCodeNavService {
 		GetImplementationsFunc: &CodeNavServiceGetImplementationsFunc{
 			defaultHook: i.GetImplementations,
 		},
+		GetOccurrencesAtRangeFunc: &CodeNavServiceGetOccurrencesAtRangeFunc{
+			defaultHook: i.GetOccurrencesAtRange,
+		},
 		GetPrototypesFunc: &CodeNavServiceGetPrototypesFunc{
 			defaultHook: i.GetPrototypes,
 		},
@@ -412,6 +454,12 @@ func NewMockCodeNavServiceFrom(i CodeNavService) *MockCodeNavService {
 		GetStencilFunc: &CodeNavServiceGetStencilFunc{
 			defaultHook: i.GetStencil,
 		},
+		GetSymbolDefinitionUploadsFunc: &CodeNavServiceGetSymbolDefinitionUploadsFunc{
+			defaultHook: i.GetSymbolDefinitionUploads,
+		},
+		GetSymbolDefinitionsFunc: &CodeNavServiceGetSymbolDefinitionsFunc{
+			defaultHook: i.GetSymbolDefinitions,
+		},
 		SCIPDocumentFunc: &CodeNavServiceSCIPDocumentFunc{
 			defaultHook: i.SCIPDocument,
 		},
@@ -1012,6 +1060,122 @@ func (c CodeNavServiceGetImplementationsFuncCall) Results() []interface{} {
 	return []interface{}{c.Result0, c.Result1, c.Result2}
 }
 
+// CodeNavServiceGetOccurrencesAtRangeFunc describes the behavior when the GetOccurrencesAtRange method of the
+// parent MockCodeNavService instance is invoked.
+type CodeNavServiceGetOccurrencesAtRangeFunc struct {
+	defaultHook func(context.Context, codenav.GitTreeTranslator, core.UploadLike, core.RepoRelPath, scip.Range) ([]*scip.Occurrence, error)
+	hooks       []func(context.Context, codenav.GitTreeTranslator, core.UploadLike, core.RepoRelPath, scip.Range) ([]*scip.Occurrence, error)
+	history     []CodeNavServiceGetOccurrencesAtRangeFuncCall
+	mutex       sync.Mutex
+}
+
+// GetOccurrencesAtRange delegates to the next hook function in the queue and stores the
+// parameter and result values of this invocation.
+func (m *MockCodeNavService) GetOccurrencesAtRange(v0 context.Context, v1 codenav.GitTreeTranslator, v2 core.UploadLike, v3 core.RepoRelPath, v4 scip.Range) ([]*scip.Occurrence, error) {
+	r0, r1 := m.GetOccurrencesAtRangeFunc.nextHook()(v0, v1, v2, v3, v4)
+	m.GetOccurrencesAtRangeFunc.appendCall(CodeNavServiceGetOccurrencesAtRangeFuncCall{v0, v1, v2, v3, v4, r0, r1})
+	return r0, r1
+}
+
+// SetDefaultHook sets function that is called when the GetOccurrencesAtRange method of
+// the parent MockCodeNavService instance is invoked and the hook queue is empty.
+func (f *CodeNavServiceGetOccurrencesAtRangeFunc) SetDefaultHook(hook func(context.Context, codenav.GitTreeTranslator, core.UploadLike, core.RepoRelPath, scip.Range) ([]*scip.Occurrence, error)) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// GetOccurrencesAtRange method of the parent MockCodeNavService instance invokes the hook at the
+// front of the queue and discards it. After the queue is empty, the default
+// hook function is invoked for any future action.
+func (f *CodeNavServiceGetOccurrencesAtRangeFunc) PushHook(hook func(context.Context, codenav.GitTreeTranslator, core.UploadLike, core.RepoRelPath, scip.Range) ([]*scip.Occurrence, error)) {
+	f.mutex.Lock()
+	f.hooks = append(f.hooks, hook)
+	f.mutex.Unlock()
+}
+
+// SetDefaultReturn calls SetDefaultHook with a function that returns the
+// given values.
+func (f *CodeNavServiceGetOccurrencesAtRangeFunc) SetDefaultReturn(r0 []*scip.Occurrence, r1 error) {
+	f.SetDefaultHook(func(context.Context, codenav.GitTreeTranslator, core.UploadLike, core.RepoRelPath, scip.Range) ([]*scip.Occurrence, error) {
+		return r0, r1
+	})
+}
+
+// PushReturn calls PushHook with a function that returns the given values.
+func (f *CodeNavServiceGetOccurrencesAtRangeFunc) PushReturn(r0 []*scip.Occurrence, r1 error) {
+	f.PushHook(func(context.Context, codenav.GitTreeTranslator, core.UploadLike, core.RepoRelPath, scip.Range) ([]*scip.Occurrence, error) {
+		return r0, r1
+	})
+}
+
+func (f *CodeNavServiceGetOccurrencesAtRangeFunc) nextHook() func(context.Context, codenav.GitTreeTranslator, core.UploadLike, core.RepoRelPath, scip.Range) ([]*scip.Occurrence, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+func (f *CodeNavServiceGetOccurrencesAtRangeFunc) appendCall(r0 CodeNavServiceGetOccurrencesAtRangeFuncCall) {
+	f.mutex.Lock()
+	f.history = append(f.history, r0)
+	f.mutex.Unlock()
+}
+
+// History returns a sequence of CodeNavServiceGetOccurrencesAtRangeFuncCall objects describing the invocations of
+// this function.
+func (f *CodeNavServiceGetOccurrencesAtRangeFunc) History() []CodeNavServiceGetOccurrencesAtRangeFuncCall {
+	f.mutex.Lock()
+	history := make([]CodeNavServiceGetOccurrencesAtRangeFuncCall, len(f.history))
+	copy(history, f.history)
+	f.mutex.Unlock()
+
+	return history
+}
+
+// CodeNavServiceGetOccurrencesAtRangeFuncCall is an object that describes an invocation of method GetOccurrencesAtRange on an
+// instance of MockCodeNavService.
+type CodeNavServiceGetOccurrencesAtRangeFuncCall struct {
+	// Arg0 is the value of the 1st argument passed to this method
+	// invocation.
+	Arg0 context.Context
+	// Arg1 is the value of the 2nd argument passed to this method
+	// invocation.
+	Arg1 codenav.GitTreeTranslator
+	// Arg2 is the value of the 3rd argument passed to this method
+	// invocation.
+	Arg2 core.UploadLike
+	// Arg3 is the value of the 4th argument passed to this method
+	// invocation.
+	Arg3 core.RepoRelPath
+	// Arg4 is the value of the 5th argument passed to this method
+	// invocation.
+	Arg4 scip.Range
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 []*scip.Occurrence
+	// Result1 is the value of the 2nd result returned from this method
+	// invocation.
+	Result1 error
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c CodeNavServiceGetOccurrencesAtRangeFuncCall) Args() []interface{} {
+	return []interface{}{c.Arg0, c.Arg1, c.Arg2, c.Arg3, c.Arg4}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c CodeNavServiceGetOccurrencesAtRangeFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0, c.Result1}
+}
+
 // CodeNavServiceGetPrototypesFunc describes the behavior when the
 // GetPrototypes method of the parent MockCodeNavService instance is
 // invoked.
@@ -1476,6 +1640,226 @@ func (c CodeNavServiceGetStencilFuncCall) Results() []interface{} {
 	return []interface{}{c.Result0, c.Result1}
 }
 
+// CodeNavServiceGetSymbolDefinitionUploadsFunc describes the behavior when the GetSymbolDefinitionUploads method of the
+// parent MockCodeNavService instance is invoked.
+type CodeNavServiceGetSymbolDefinitionUploadsFunc struct {
+	defaultHook func(context.Context, string) ([]shared.CompletedUpload, error)
+	hooks       []func(context.Context, string) ([]shared.CompletedUpload, error)
+	history     []CodeNavServiceGetSymbolDefinitionUploadsFuncCall
+	mutex       sync.Mutex
+}
+
+// GetSymbolDefinitionUploads delegates to the next hook function in the queue and stores the
+// parameter and result values of this invocation.
+func (m *MockCodeNavService) GetSymbolDefinitionUploads(v0 context.Context, v1 string) ([]shared.CompletedUpload, error) {
+	r0, r1 := m.GetSymbolDefinitionUploadsFunc.nextHook()(v0, v1)
+	m.GetSymbolDefinitionUploadsFunc.appendCall(CodeNavServiceGetSymbolDefinitionUploadsFuncCall{v0, v1, r0, r1})
+	return r0, r1
+}
+
+// SetDefaultHook sets function that is called when the GetSymbolDefinitionUploads method of
+// the parent MockCodeNavService instance is invoked and the hook queue is empty.
+func (f *CodeNavServiceGetSymbolDefinitionUploadsFunc) SetDefaultHook(hook func(context.Context, string) ([]shared.CompletedUpload, error)) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// GetSymbolDefinitionUploads method of the parent MockCodeNavService instance invokes the hook at the
+// front of the queue and discards it. After the queue is empty, the default
+// hook function is invoked for any future action.
+func (f *CodeNavServiceGetSymbolDefinitionUploadsFunc) PushHook(hook func(context.Context, string) ([]shared.CompletedUpload, error)) {
+	f.mutex.Lock()
+	f.hooks = append(f.hooks, hook)
+	f.mutex.Unlock()
+}
+
+// SetDefaultReturn calls SetDefaultHook with a function that returns the
+// given values.
+func (f *CodeNavServiceGetSymbolDefinitionUploadsFunc) SetDefaultReturn(r0 []shared.CompletedUpload, r1 error) {
+	f.SetDefaultHook(func(context.Context, string) ([]shared.CompletedUpload, error) {
+		return r0, r1
+	})
+}
+
+// PushReturn calls PushHook with a function that returns the given values.
+func (f *CodeNavServiceGetSymbolDefinitionUploadsFunc) PushReturn(r0 []shared.CompletedUpload, r1 error) {
+	f.PushHook(func(context.Context, string) ([]shared.CompletedUpload, error) {
+		return r0, r1
+	})
+}
+
+func (f *CodeNavServiceGetSymbolDefinitionUploadsFunc) nextHook() func(context.Context, string) ([]shared.CompletedUpload, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+func (f *CodeNavServiceGetSymbolDefinitionUploadsFunc) appendCall(r0 CodeNavServiceGetSymbolDefinitionUploadsFuncCall) {
+	f.mutex.Lock()
+	f.history = append(f.history, r0)
+	f.mutex.Unlock()
+}
+
+// History returns a sequence of CodeNavServiceGetSymbolDefinitionUploadsFuncCall objects describing the invocations of
+// this function.
+func (f *CodeNavServiceGetSymbolDefinitionUploadsFunc) History() []CodeNavServiceGetSymbolDefinitionUploadsFuncCall {
+	f.mutex.Lock()
+	history := make([]CodeNavServiceGetSymbolDefinitionUploadsFuncCall, len(f.history))
+	copy(history, f.history)
+	f.mutex.Unlock()
+
+	return history
+}
+
+// CodeNavServiceGetSymbolDefinitionUploadsFuncCall is an object that describes an invocation of method GetSymbolDefinitionUploads on an
+// instance of MockCodeNavService.
+type CodeNavServiceGetSymbolDefinitionUploadsFuncCall struct {
+	// Arg0 is the value of the 1st argument passed to this method
+	// invocation.
+	Arg0 context.Context
+	// Arg1 is the value of the 2nd argument passed to this method
+	// invocation.
+	Arg1 string
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 []shared.CompletedUpload
+	// Result1 is the value of the 2nd result returned from this method
+	// invocation.
+	Result1 error
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c CodeNavServiceGetSymbolDefinitionUploadsFuncCall) Args() []interface{} {
+	return []interface{}{c.Arg0, c.Arg1}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c CodeNavServiceGetSymbolDefinitionUploadsFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0, c.Result1}
+}
+
+// CodeNavServiceGetSymbolDefinitionsFunc describes the behavior when the GetSymbolDefinitions method of the
+// parent MockCodeNavService instance is invoked.
+type CodeNavServiceGetSymbolDefinitionsFunc struct {
+	defaultHook func(context.Context, codenav.GitTreeTranslator, shared.CompletedUpload, string) ([]shared1.UploadLocation, error)
+	hooks       []func(context.Context, codenav.GitTreeTranslator, shared.CompletedUpload, string) ([]shared1.UploadLocation, error)
+	history     []CodeNavServiceGetSymbolDefinitionsFuncCall
+	mutex       sync.Mutex
+}
+
+// GetSymbolDefinitions delegates to the next hook function in the queue and stores the
+// parameter and result values of this invocation.
+func (m *MockCodeNavService) GetSymbolDefinitions(v0 context.Context, v1 codenav.GitTreeTranslator, v2 shared.CompletedUpload, v3 string) ([]shared1.UploadLocation, error) {
+	r0, r1 := m.GetSymbolDefinitionsFunc.nextHook()(v0, v1, v2, v3)
+	m.GetSymbolDefinitionsFunc.appendCall(CodeNavServiceGetSymbolDefinitionsFuncCall{v0, v1, v2, v3, r0, r1})
+	return r0, r1
+}
+
+// SetDefaultHook sets function that is called when the GetSymbolDefinitions method of
+// the parent MockCodeNavService instance is invoked and the hook queue is empty.
+func (f *CodeNavServiceGetSymbolDefinitionsFunc) SetDefaultHook(hook func(context.Context, codenav.GitTreeTranslator, shared.CompletedUpload, string) ([]shared1.UploadLocation, error)) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// GetSymbolDefinitions method of the parent MockCodeNavService instance invokes the hook at the
+// front of the queue and discards it. After the queue is empty, the default
+// hook function is invoked for any future action.
+func (f *CodeNavServiceGetSymbolDefinitionsFunc) PushHook(hook func(context.Context, codenav.GitTreeTranslator, shared.CompletedUpload, string) ([]shared1.UploadLocation, error)) {
+	f.mutex.Lock()
+	f.hooks = append(f.hooks, hook)
+	f.mutex.Unlock()
+}
+
+// SetDefaultReturn calls SetDefaultHook with a function that returns the
+// given values.
+func (f *CodeNavServiceGetSymbolDefinitionsFunc) SetDefaultReturn(r0 []shared1.UploadLocation, r1 error) {
+	f.SetDefaultHook(func(context.Context, codenav.GitTreeTranslator, shared.CompletedUpload, string) ([]shared1.UploadLocation, error) {
+		return r0, r1
+	})
+}
+
+// PushReturn calls PushHook with a function that returns the given values.
+func (f *CodeNavServiceGetSymbolDefinitionsFunc) PushReturn(r0 []shared1.UploadLocation, r1 error) {
+	f.PushHook(func(context.Context, codenav.GitTreeTranslator, shared.CompletedUpload, string) ([]shared1.UploadLocation, error) {
+		return r0, r1
+	})
+}
+
+func (f *CodeNavServiceGetSymbolDefinitionsFunc) nextHook() func(context.Context, codenav.GitTreeTranslator, shared.CompletedUpload, string) ([]shared1.UploadLocation, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+func (f *CodeNavServiceGetSymbolDefinitionsFunc) appendCall(r0 CodeNavServiceGetSymbolDefinitionsFuncCall) {
+	f.mutex.Lock()
+	f.history = append(f.history, r0)
+	f.mutex.Unlock()
+}
+
+// History returns a sequence of CodeNavServiceGetSymbolDefinitionsFuncCall objects describing the invocations of
+// this function.
+func (f *CodeNavServiceGetSymbolDefinitionsFunc) History() []CodeNavServiceGetSymbolDefinitionsFuncCall {
+	f.mutex.Lock()
+	history := make([]CodeNavServiceGetSymbolDefinitionsFuncCall, len(f.history))
+	copy(history, f.history)
+	f.mutex.Unlock()
+
+	return history
+}
+
+// CodeNavServiceGetSymbolDefinitionsFuncCall is an object that describes an invocation of method GetSymbolDefinitions on an
+// instance of MockCodeNavService.
+type CodeNavServiceGetSymbolDefinitionsFuncCall struct {
+	// Arg0 is the value of the 1st argument passed to this method
+	// invocation.
+	Arg0 context.Context
+	// Arg1 is the value of the 2nd argument passed to this method
+	// invocation.
+	Arg1 codenav.GitTreeTranslator
+	// Arg2 is the value of the 3rd argument passed to this method
+	// invocation.
+	Arg2 shared.CompletedUpload
+	// Arg3 is the value of the 4th argument passed to this method
+	// invocation.
+	Arg3 string
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 []shared1.UploadLocation
+	// Result1 is the value of the 2nd result returned from this method
+	// invocation.
+	Result1 error
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c CodeNavServiceGetSymbolDefinitionsFuncCall) Args() []interface{} {
+	return []interface{}{c.Arg0, c.Arg1, c.Arg2, c.Arg3}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c CodeNavServiceGetSymbolDefinitionsFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0, c.Result1}
+}
+
 // CodeNavServiceSCIPDocumentFunc describes the behavior when the
 // SCIPDocument method of the parent MockCodeNavService instance is invoked.
 type CodeNavServiceSCIPDocumentFunc struct {
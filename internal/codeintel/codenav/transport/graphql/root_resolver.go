@@ -3,7 +3,9 @@ package graphql
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/graph-gophers/graphql-go"
 	"github.com/graph-gophers/graphql-go/relay"
@@ -15,6 +17,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/authz"
 	"github.com/sourcegraph/sourcegraph/internal/codeintel/codenav"
+	codenavshared "github.com/sourcegraph/sourcegraph/internal/codeintel/codenav/shared"
 	"github.com/sourcegraph/sourcegraph/internal/codeintel/core"
 	resolverstubs "github.com/sourcegraph/sourcegraph/internal/codeintel/resolvers"
 	sharedresolvers "github.com/sourcegraph/sourcegraph/internal/codeintel/shared/resolvers"
@@ -31,6 +34,15 @@ import (
 
 var ErrNotEnabled = errors.New("experimentalFeatures.scipBasedAPIs is not enabled")
 
+// ErrStaleTargetCommit occurs when code graph data was mapped to a commit, but
+// the requested revision no longer resolves to that commit. The code graph data
+// needs to be requested again.
+var ErrStaleTargetCommit = errors.New("the requested revision moved to another commit")
+
+// codeGraphDataFreshnessTTL is how often code graph data checks whether the
+// requested revision still resolves to the commit it was mapped to.
+const codeGraphDataFreshnessTTL = time.Minute
+
 type rootResolver struct {
 	svc                            CodeNavService
 	autoindexingSvc                AutoIndexingService
@@ -127,6 +139,10 @@ func (r *rootResolver) CodeGraphData(ctx context.Context, opts *resolverstubs.Co
 	// TODO: The resolvers may be invoked in parallel. Is GitTreeTranslator
 	// concurrency-safe? It looks like
 	gitTreeTranslator := r.MakeGitTreeTranslator(opts.Repo, opts.Commit)
+	var freshness *codenav.TargetCommitFreshness
+	if opts.Rev != "" && opts.Rev != string(opts.Commit) {
+		freshness = codenav.NewTargetCommitFreshness(r.gitserverClient, opts.Repo.Name, opts.Rev, opts.Commit, codeGraphDataFreshnessTTL)
+	}
 	makeResolvers := func(prov resolverstubs.CodeGraphDataProvenance) ([]resolverstubs.CodeGraphDataResolver, error) {
 		indexer := ""
 		if prov == resolverstubs.ProvenanceSyntactic {
@@ -146,7 +162,7 @@ func (r *rootResolver) CodeGraphData(ctx context.Context, opts *resolverstubs.Co
 		filteredUploads := preferUploadsWithLongestRoots(uploads)
 
 		for _, upload := range filteredUploads {
-			resolvers = append(resolvers, newCodeGraphDataResolver(r.svc, gitTreeTranslator, upload, opts, prov, r.operations))
+			resolvers = append(resolvers, newCodeGraphDataResolver(r.svc, gitTreeTranslator, freshness, upload, opts, prov, r.operations))
 		}
 		return resolvers, nil
 	}
@@ -198,6 +214,7 @@ func (r *rootResolver) CodeGraphDataByID(ctx context.Context, rawID graphql.ID)
 		/*documentRetrievalError*/ nil,
 		r.svc,
 		r.MakeGitTreeTranslator(repo, id.Commit),
+		/*freshness*/ nil,
 		id.UploadData,
 		&opts,
 		id.CodeGraphDataProvenance,
@@ -254,11 +271,6 @@ func (r *rootResolver) UsagesForSymbol(ctx context.Context, unresolvedArgs *reso
 	linesGetter := newCachedLinesGetter(r.gitserverClient, 5*1024*1024 /* 5MB */)
 	usageResolvers := []resolverstubs.UsageResolver{}
 
-	if provsForSCIPData.Precise {
-		// Attempt to get up to remainingCount precise results.
-		remainingCount = remainingCount - numPreciseResults
-	}
-
 	usagesForSymbolArgs := codenav.UsagesForSymbolArgs{
 		Repo:        args.Repo,
 		Commit:      args.CommitID,
@@ -268,8 +280,24 @@ func (r *rootResolver) UsagesForSymbol(ctx context.Context, unresolvedArgs *reso
 
 	gitTreeTranslator := r.MakeGitTreeTranslator(&args.Repo, args.CommitID)
 
+	// preciseLocations are the locations of precise usages, which take
+	// precedence over syntactic usages at the same location.
+	preciseLocations := map[usageLocation]struct{}{}
+	if remainingCount > 0 && provsForSCIPData.Precise {
+		definitions, err := r.preciseDefinitions(ctx, args, gitTreeTranslator)
+		if err != nil {
+			return nil, err
+		}
+		for _, definition := range definitions {
+			usageResolvers = append(usageResolvers, NewPreciseDefinitionUsageResolver(definition.symbol, definition.location, linesGetter))
+			preciseLocations[usageLocation{definition.location.Upload.RepositoryID, definition.location.Path, definition.location.TargetRange.ToSCIPRange()}] = struct{}{}
+		}
+		numPreciseResults = len(definitions)
+		remainingCount = remainingCount - numPreciseResults
+	}
+
 	var previousSyntacticSearch core.Option[codenav.PreviousSyntacticSearch]
-	if remainingCount > 0 && provsForSCIPData.Syntactic {
+	syntacticUsages := func(ctx context.Context) ([]codenav.SyntacticMatch, error) {
 		syntacticResult, prevSearch, err := r.svc.SyntacticUsages(ctx, gitTreeTranslator, usagesForSymbolArgs)
 		if err != nil {
 			switch err.Code {
@@ -282,18 +310,36 @@ func (r *rootResolver) UsagesForSymbol(ctx context.Context, unresolvedArgs *reso
 				// None of these errors should cause the whole request to fail
 				// TODO: We might want to log some of them in the future
 			}
-		} else {
-			for _, result := range syntacticResult.Matches {
-				usageResolvers = append(usageResolvers, NewSyntacticUsageResolver(result, args.Repo, args.CommitID, linesGetter))
+			return nil, nil
+		}
+		previousSyntacticSearch = core.Some(prevSearch)
+		return syntacticResult.Matches, nil
+	}
+	searchBasedUsages := func(ctx context.Context) ([]codenav.SearchBasedMatch, error) {
+		return r.svc.SearchBasedUsages(ctx, gitTreeTranslator, usagesForSymbolArgs, previousSyntacticSearch)
+	}
+
+	searchedSyntactic := false
+	if remainingCount > 0 && provsForSCIPData.Syntactic {
+		searchedSyntactic = true
+		matches, err := syntacticUsages(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range matches {
+			if _, ok := preciseLocations[usageLocation{int(args.Repo.ID), result.Path, result.Range}]; ok {
+				continue
 			}
-			numSyntacticResults = len(syntacticResult.Matches)
-			remainingCount = remainingCount - numSyntacticResults
-			previousSyntacticSearch = core.Some(prevSearch)
+			usageResolvers = append(usageResolvers, NewSyntacticUsageResolver(result, args.Repo, args.CommitID, linesGetter))
+			numSyntacticResults++
 		}
+		remainingCount = remainingCount - numSyntacticResults
 	}
 
+	searchedSearchBased := false
 	if remainingCount > 0 && provsForSCIPData.SearchBased {
-		results, err := r.svc.SearchBasedUsages(ctx, gitTreeTranslator, usagesForSymbolArgs, previousSyntacticSearch)
+		searchedSearchBased = true
+		results, err := searchBasedUsages(ctx)
 		if err != nil {
 			// We only want to fail the request on an error here if we didn't get any precise or syntactic results before
 			if len(usageResolvers) == 0 {
@@ -306,6 +352,7 @@ func (r *rootResolver) UsagesForSymbol(ctx context.Context, unresolvedArgs *reso
 			for _, result := range results {
 				usageResolvers = append(usageResolvers, NewSearchBasedUsageResolver(result, args.Repo, args.CommitID, linesGetter))
 			}
+			numSearchBasedResults = len(results)
 		}
 	}
 
@@ -313,12 +360,138 @@ func (r *rootResolver) UsagesForSymbol(ctx context.Context, unresolvedArgs *reso
 		return &usageConnectionResolver{
 			nodes:    usageResolvers,
 			pageInfo: resolverstubs.NewSimplePageInfo(false),
+			// We stop searching once remainingCount usages were found. To
+			// count the usages across all pages, we run the searches we
+			// skipped for this page, without resolving their results.
+			totalCount: func(ctx context.Context) (int32, error) {
+				count := numSyntacticResults + numSearchBasedResults
+				if !searchedSyntactic && provsForSCIPData.Syntactic {
+					matches, err := syntacticUsages(ctx)
+					if err != nil {
+						return 0, err
+					}
+					for _, match := range matches {
+						if _, ok := preciseLocations[usageLocation{int(args.Repo.ID), match.Path, match.Range}]; !ok {
+							count++
+						}
+					}
+				}
+				if !searchedSearchBased && provsForSCIPData.SearchBased {
+					results, err := searchBasedUsages(ctx)
+					if err != nil {
+						return 0, err
+					}
+					count += len(results)
+				}
+				return int32(count), nil
+			},
 		}, nil
 	}
 
 	return nil, errors.New("Not implemented yet")
 }
 
+// usageLocation identifies the location of a usage.
+type usageLocation struct {
+	repositoryID int
+	path         core.RepoRelPath
+	range_       scip.Range
+}
+
+// preciseDefinition is the definition of a symbol found in a precise upload.
+type preciseDefinition struct {
+	symbol   string
+	location codenavshared.UploadLocation
+}
+
+// preciseDefinitions returns the definitions of the requested symbol in the
+// precise uploads of the requested file, mapped to the requested commit. If
+// no symbol was requested, the definitions of the symbols of the occurrences
+// at the requested range are returned instead. Definitions in other
+// repositories are returned at the commit of the upload defining them.
+func (r *rootResolver) preciseDefinitions(ctx context.Context, args resolverstubs.UsagesForSymbolResolvedArgs, gitTreeTranslator codenav.GitTreeTranslator) ([]preciseDefinition, error) {
+	uploads, err := r.svc.GetClosestCompletedUploadsForBlob(ctx, shared.UploadMatchingOptions{
+		RepositoryID:       args.Repo.ID,
+		Commit:             args.CommitID,
+		Path:               args.Path,
+		RootToPathMatching: shared.RootMustEnclosePath,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var definitions []preciseDefinition
+	var unresolvedSymbols []string
+	for _, upload := range uploads {
+		symbols, err := r.preciseSymbols(ctx, args, gitTreeTranslator, upload)
+		if err != nil {
+			return nil, err
+		}
+		for _, symbol := range symbols {
+			locations, err := r.svc.GetSymbolDefinitions(ctx, gitTreeTranslator, upload, symbol)
+			if err != nil {
+				return nil, err
+			}
+			if len(locations) == 0 && !slices.Contains(unresolvedSymbols, symbol) {
+				unresolvedSymbols = append(unresolvedSymbols, symbol)
+			}
+			for _, location := range locations {
+				definitions = append(definitions, preciseDefinition{symbol, location})
+			}
+		}
+	}
+
+	// Symbols that aren't defined in the uploads of the requested file, e.g.
+	// symbols of dependencies, are looked up in the uploads defining them,
+	// which may belong to other repositories.
+	for _, symbol := range unresolvedSymbols {
+		if slices.ContainsFunc(definitions, func(d preciseDefinition) bool { return d.symbol == symbol }) {
+			continue
+		}
+		definitionUploads, err := r.svc.GetSymbolDefinitionUploads(ctx, symbol)
+		if err != nil {
+			return nil, err
+		}
+		for _, upload := range definitionUploads {
+			if slices.ContainsFunc(uploads, func(u shared.CompletedUpload) bool { return u.ID == upload.ID }) {
+				continue
+			}
+			repo := sgtypes.Repo{ID: api.RepoID(upload.RepositoryID), Name: api.RepoName(upload.RepositoryName)}
+			locations, err := r.svc.GetSymbolDefinitions(ctx, r.MakeGitTreeTranslator(&repo, api.CommitID(upload.Commit)), upload, symbol)
+			if err != nil {
+				return nil, err
+			}
+			for _, location := range locations {
+				definitions = append(definitions, preciseDefinition{symbol, location})
+			}
+		}
+	}
+	return definitions, nil
+}
+
+// preciseSymbols returns the requested symbol, or if none was requested, the
+// global symbols of the occurrences at the requested range in the upload.
+func (r *rootResolver) preciseSymbols(ctx context.Context, args resolverstubs.UsagesForSymbolResolvedArgs, gitTreeTranslator codenav.GitTreeTranslator, upload shared.CompletedUpload) ([]string, error) {
+	if args.Symbol != nil && args.Symbol.EqualsName != "" {
+		return []string{args.Symbol.EqualsName}, nil
+	}
+
+	occurrences, err := r.svc.GetOccurrencesAtRange(ctx, gitTreeTranslator, upload, args.Path, args.Range)
+	if err != nil {
+		return nil, err
+	}
+	var symbols []string
+	for _, occurrence := range occurrences {
+		// Local symbols are not unique across documents, so they can't be
+		// looked up by name.
+		if occurrence.Symbol == "" || scip.IsLocalSymbol(occurrence.Symbol) || slices.Contains(symbols, occurrence.Symbol) {
+			continue
+		}
+		symbols = append(symbols, occurrence.Symbol)
+	}
+	return symbols, nil
+}
+
 func (r *rootResolver) MakeGitTreeTranslator(repo *sgtypes.Repo, baseCommit api.CommitID) codenav.GitTreeTranslator {
 	return codenav.NewGitTreeTranslator(r.gitserverClient, &codenav.TranslationBase{repo, baseCommit}, r.hunkCache)
 }
@@ -407,9 +580,12 @@ type codeGraphDataResolver struct {
 	// Arguments
 	svc               CodeNavService
 	gitTreeTranslator codenav.GitTreeTranslator
-	upload            UploadData
-	opts              *resolverstubs.CodeGraphDataOpts
-	provenance        resolverstubs.CodeGraphDataProvenance
+	// freshness, if set, tracks whether the requested revision still
+	// resolves to the commit gitTreeTranslator maps to.
+	freshness  *codenav.TargetCommitFreshness
+	upload     UploadData
+	opts       *resolverstubs.CodeGraphDataOpts
+	provenance resolverstubs.CodeGraphDataProvenance
 
 	// O11y
 	operations *operations
@@ -454,6 +630,7 @@ func NewUploadData(upload shared.CompletedUpload) UploadData {
 func newCodeGraphDataResolver(
 	svc CodeNavService,
 	gitTreeTranslator codenav.GitTreeTranslator,
+	freshness *codenav.TargetCommitFreshness,
 	upload shared.CompletedUpload,
 	opts *resolverstubs.CodeGraphDataOpts,
 	provenance resolverstubs.CodeGraphDataProvenance,
@@ -465,6 +642,7 @@ func newCodeGraphDataResolver(
 		/*documentRetrievalError*/ nil,
 		svc,
 		gitTreeTranslator,
+		freshness,
 		NewUploadData(upload),
 		opts,
 		provenance,
@@ -492,6 +670,15 @@ func (c *codeGraphDataResolver) tryRetrieveDocument(ctx context.Context) (*scip.
 	c.retrievedDocument.Do(func() {
 		c.document, c.documentRetrievalError = c.svc.SCIPDocument(ctx, c.gitTreeTranslator, c.upload, c.opts.Path)
 	})
+	if c.freshness != nil {
+		stale, err := c.freshness.Stale(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if stale {
+			return nil, ErrStaleTargetCommit
+		}
+	}
 	return c.document, c.documentRetrievalError
 }
 
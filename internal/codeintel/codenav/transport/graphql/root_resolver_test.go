@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"io"
+	"math"
 	"strings"
 	"testing"
 
@@ -21,13 +23,16 @@ import (
 	resolverstubs "github.com/sourcegraph/sourcegraph/internal/codeintel/resolvers"
 	"github.com/sourcegraph/sourcegraph/internal/codeintel/shared/resolvers/gitresolvers"
 	uploadsshared "github.com/sourcegraph/sourcegraph/internal/codeintel/uploads/shared"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbmocks"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/gitdomain"
 	"github.com/sourcegraph/sourcegraph/internal/observation"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
 	sgtypes "github.com/sourcegraph/sourcegraph/internal/types"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 	"github.com/sourcegraph/sourcegraph/lib/pointers"
+	"github.com/sourcegraph/sourcegraph/schema"
 )
 
 // Only exposed for tests, production code should use Unchecked function
@@ -444,6 +449,10 @@ func unwrap[T any](v T, err error) func(*testing.T) T {
 }
 
 func makeTestResolver(t *testing.T) resolverstubs.CodeGraphDataResolver {
+	return makeTestResolverWithFreshness(t, nil)
+}
+
+func makeTestResolverWithFreshness(t *testing.T, freshness *codenav.TargetCommitFreshness) resolverstubs.CodeGraphDataResolver {
 	codeNavSvc := NewStrictMockCodeNavService()
 	gitTreeTranslator := codenav.NewMockGitTreeTranslator()
 	index := unwrap(repro.Index("", "testpkg", sampleSourceFiles(), nil))(t)
@@ -463,7 +472,7 @@ func makeTestResolver(t *testing.T) resolverstubs.CodeGraphDataResolver {
 	})
 
 	return newCodeGraphDataResolver(
-		codeNavSvc, gitTreeTranslator, testUpload,
+		codeNavSvc, gitTreeTranslator, freshness, testUpload,
 		&resolverstubs.CodeGraphDataOpts{Repo: &sgtypes.Repo{}, Path: repoRelPath("locals.repro")},
 		resolverstubs.ProvenancePrecise, newOperations(&observation.TestContext))
 }
@@ -493,6 +502,29 @@ func TestOccurrences_BadArgs(t *testing.T) {
 	})
 }
 
+func TestOccurrences_StaleTargetCommit(t *testing.T) {
+	bgCtx := context.Background()
+
+	head := api.CommitID("deadbeef01")
+	client := gitserver.NewMockClient()
+	client.ResolveRevisionFunc.SetDefaultHook(func(context.Context, api.RepoName, string, gitserver.ResolveRevisionOptions) (api.CommitID, error) {
+		return head, nil
+	})
+	resolver := makeTestResolverWithFreshness(t, codenav.NewTargetCommitFreshness(client, "repo", "HEAD", head, 0))
+
+	args := resolverstubs.OccurrencesArgs{}
+	args.Normalize(10)
+	occs := unwrap(resolver.Occurrences(bgCtx, &args))(t)
+	_, err := occs.Nodes(bgCtx)
+	require.NoError(t, err)
+
+	// Advancing the target commit makes the mapped data stale.
+	head = "deadbeef02"
+	occs = unwrap(resolver.Occurrences(bgCtx, &args))(t)
+	_, err = occs.Nodes(bgCtx)
+	require.ErrorIs(t, err, ErrStaleTargetCommit)
+}
+
 func TestOccurrences_Pages(t *testing.T) {
 	resolver := makeTestResolver(t)
 	bgCtx := context.Background()
@@ -605,3 +637,444 @@ func TestOccurrences_Pages(t *testing.T) {
 		})
 	}
 }
+
+func TestSymbolKind(t *testing.T) {
+	testCases := []struct {
+		symbol string
+		want   *string
+	}{
+		{"scip-go gomod github.com/example/pkg v1.0.0 `github.com/example/pkg`/", pointers.Ptr("NAMESPACE")},
+		{"scip-go gomod github.com/example/pkg v1.0.0 `github.com/example/pkg`/Server#", pointers.Ptr("CLASS")},
+		{"scip-go gomod github.com/example/pkg v1.0.0 `github.com/example/pkg`/Server#Handle().", pointers.Ptr("METHOD")},
+		{"scip-go gomod github.com/example/pkg v1.0.0 `github.com/example/pkg`/Server#logger.", pointers.Ptr("VARIABLE")},
+		{"scip-typescript npm example 1.0.0 src/`index.ts`/identity().(value)", pointers.Ptr("VARIABLE")},
+		{"scip-typescript npm example 1.0.0 src/`index.ts`/identity().[T]", pointers.Ptr("TYPEPARAMETER")},
+		{"scip-java maven . . com/example/Main#main().!", nil},
+		{"local 42", nil},
+		{"not a valid symbol", nil},
+		{"", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.symbol, func(t *testing.T) {
+			require.Equal(t, tc.want, symbolKind(tc.symbol))
+		})
+	}
+}
+
+func TestUsagesForSymbol_TotalCount(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		ExperimentalFeatures: &schema.ExperimentalFeatures{ScipBasedAPIs: pointers.Ptr(true)},
+	}})
+	t.Cleanup(func() { conf.Mock(nil) })
+
+	mockCodeNavService := NewMockCodeNavService()
+	mockCodeNavService.SyntacticUsagesFunc.SetDefaultReturn(codenav.SyntacticUsagesResult{
+		Matches: []codenav.SyntacticMatch{
+			{Path: repoRelPath("a.go"), Range: scip.NewRangeUnchecked([]int32{1, 2, 3}), IsDefinition: true, Symbol: "a"},
+			{Path: repoRelPath("b.go"), Range: scip.NewRangeUnchecked([]int32{4, 5, 6}), Symbol: "a"},
+		},
+	}, codenav.PreviousSyntacticSearch{}, nil)
+	mockCodeNavService.SearchBasedUsagesFunc.SetDefaultReturn([]codenav.SearchBasedMatch{
+		{Path: repoRelPath("c.go"), Range: scip.NewRangeUnchecked([]int32{7, 8, 9})},
+	}, nil)
+
+	mockRepoStore := dbmocks.NewMockRepoStore()
+	mockRepoStore.GetByNameFunc.SetDefaultReturn(&sgtypes.Repo{ID: 1, Name: "github.com/foo/bar"}, nil)
+	mockGitserverClient := gitserver.NewMockClient()
+	mockGitserverClient.ResolveRevisionFunc.SetDefaultReturn("deadbeef", nil)
+
+	resolver, err := NewRootResolver(
+		observation.TestContextTB(t),
+		mockCodeNavService,
+		nil,
+		mockGitserverClient,
+		nil,
+		mockRepoStore,
+		nil,
+		nil,
+		nil,
+		nil,
+		0,
+		10,
+	)
+	require.NoError(t, err)
+
+	usagesForSymbol := func(first *int32) resolverstubs.UsageConnectionResolver {
+		usages, err := resolver.UsagesForSymbol(context.Background(), &resolverstubs.UsagesForSymbolArgs{
+			Range: resolverstubs.RangeInput{
+				Repository: "github.com/foo/bar",
+				Path:       "a.go",
+				Start:      resolverstubs.PositionInput{Line: 1, Character: 2},
+				End:        resolverstubs.PositionInput{Line: 1, Character: 3},
+			},
+			First: first,
+		})
+		require.NoError(t, err)
+		return usages
+	}
+
+	t.Run("single page", func(t *testing.T) {
+		usages := usagesForSymbol(nil)
+		nodes, err := usages.Nodes(context.Background())
+		require.NoError(t, err)
+		totalCount, err := usages.TotalCount(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int32(3), totalCount)
+		require.Equal(t, len(nodes), int(totalCount))
+	})
+
+	t.Run("multiple pages", func(t *testing.T) {
+		searches := len(mockCodeNavService.SearchBasedUsagesFunc.History())
+
+		// The syntactic usages fill the first page, the search-based usages
+		// are only on later pages but still counted.
+		usages := usagesForSymbol(pointers.Ptr(int32(1)))
+		nodes, err := usages.Nodes(context.Background())
+		require.NoError(t, err)
+		require.Len(t, nodes, 2)
+		require.Len(t, mockCodeNavService.SearchBasedUsagesFunc.History(), searches)
+
+		totalCount, err := usages.TotalCount(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int32(3), totalCount)
+		require.Len(t, mockCodeNavService.SearchBasedUsagesFunc.History(), searches+1)
+	})
+}
+
+func TestUsagesForSymbol_PreciseDefinitions(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		ExperimentalFeatures: &schema.ExperimentalFeatures{ScipBasedAPIs: pointers.Ptr(true)},
+	}})
+	t.Cleanup(func() { conf.Mock(nil) })
+
+	const symbol = "scip-go gomod github.com/foo/bar v1 `github.com/foo/bar`/Bar#"
+	upload := uploadsshared.CompletedUpload{ID: 42, RepositoryID: 1, RepositoryName: "github.com/foo/bar", Commit: "deadbeef"}
+
+	mockCodeNavService := NewMockCodeNavService()
+	mockCodeNavService.GetClosestCompletedUploadsForBlobFunc.SetDefaultReturn([]uploadsshared.CompletedUpload{upload}, nil)
+	mockCodeNavService.GetSymbolDefinitionsFunc.SetDefaultHook(func(_ context.Context, _ codenav.GitTreeTranslator, u uploadsshared.CompletedUpload, s string) ([]shared.UploadLocation, error) {
+		require.Equal(t, upload, u)
+		require.Equal(t, symbol, s)
+		return []shared.UploadLocation{{
+			Upload:       u,
+			Path:         repoRelPath("b.go"),
+			TargetCommit: "deadbeef",
+			TargetRange:  shared.Range{Start: shared.Position{Line: 4, Character: 5}, End: shared.Position{Line: 4, Character: 8}},
+		}}, nil
+	})
+
+	mockRepoStore := dbmocks.NewMockRepoStore()
+	mockRepoStore.GetByNameFunc.SetDefaultReturn(&sgtypes.Repo{ID: 1, Name: "github.com/foo/bar"}, nil)
+	mockGitserverClient := gitserver.NewMockClient()
+	mockGitserverClient.ResolveRevisionFunc.SetDefaultReturn("deadbeef", nil)
+
+	resolver, err := NewRootResolver(
+		observation.TestContextTB(t),
+		mockCodeNavService,
+		nil,
+		mockGitserverClient,
+		nil,
+		mockRepoStore,
+		nil,
+		nil,
+		nil,
+		nil,
+		0,
+		10,
+	)
+	require.NoError(t, err)
+
+	usages, err := resolver.UsagesForSymbol(context.Background(), &resolverstubs.UsagesForSymbolArgs{
+		Symbol: &resolverstubs.SymbolComparator{
+			Name:       resolverstubs.SymbolNameComparator{Equals: pointers.Ptr(symbol)},
+			Provenance: resolverstubs.CodeGraphDataProvenanceComparator{Equals: pointers.Ptr(resolverstubs.ProvenancePrecise)},
+		},
+		Range: resolverstubs.RangeInput{
+			Repository: "github.com/foo/bar",
+			Path:       "a.go",
+			Start:      resolverstubs.PositionInput{Line: 1, Character: 2},
+			End:        resolverstubs.PositionInput{Line: 1, Character: 5},
+		},
+	})
+	require.NoError(t, err)
+
+	nodes, err := usages.Nodes(context.Background())
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	require.Equal(t, resolverstubs.ProvenancePrecise, unwrap(nodes[0].Provenance(context.Background()))(t))
+	require.Equal(t, resolverstubs.UsageKindDefinition, nodes[0].UsageKind())
+	usageRange := unwrap(nodes[0].UsageRange(context.Background()))(t)
+	require.Equal(t, "b.go", usageRange.Path())
+	require.Equal(t, int32(4), usageRange.Range().Start().Line())
+	symbolInfo := unwrap(nodes[0].Symbol(context.Background()))(t)
+	require.Equal(t, symbol, unwrap(symbolInfo.Name())(t))
+	require.Empty(t, mockCodeNavService.SyntacticUsagesFunc.History())
+}
+
+func TestUsagesForSymbol_PreciseDefinitionsAtRange(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		ExperimentalFeatures: &schema.ExperimentalFeatures{ScipBasedAPIs: pointers.Ptr(true)},
+	}})
+	t.Cleanup(func() { conf.Mock(nil) })
+
+	const symbol = "scip-go gomod github.com/foo/bar v1 `github.com/foo/bar`/Bar#"
+	upload := uploadsshared.CompletedUpload{ID: 42, RepositoryID: 1, RepositoryName: "github.com/foo/bar", Commit: "deadbeef"}
+
+	mockCodeNavService := NewMockCodeNavService()
+	mockCodeNavService.GetClosestCompletedUploadsForBlobFunc.SetDefaultReturn([]uploadsshared.CompletedUpload{upload}, nil)
+	mockCodeNavService.GetOccurrencesAtRangeFunc.SetDefaultReturn([]*scip.Occurrence{
+		{Symbol: symbol, Range: []int32{1, 2, 5}},
+		{Symbol: "local 1", Range: []int32{1, 2, 5}},
+		{Symbol: symbol, Range: []int32{1, 2, 5}, SymbolRoles: int32(scip.SymbolRole_ReadAccess)},
+	}, nil)
+	mockCodeNavService.GetSymbolDefinitionsFunc.SetDefaultReturn([]shared.UploadLocation{{
+		Upload:       upload,
+		Path:         repoRelPath("b.go"),
+		TargetCommit: "deadbeef",
+		TargetRange:  shared.Range{Start: shared.Position{Line: 4, Character: 5}, End: shared.Position{Line: 4, Character: 8}},
+	}}, nil)
+
+	mockRepoStore := dbmocks.NewMockRepoStore()
+	mockRepoStore.GetByNameFunc.SetDefaultReturn(&sgtypes.Repo{ID: 1, Name: "github.com/foo/bar"}, nil)
+	mockGitserverClient := gitserver.NewMockClient()
+	mockGitserverClient.ResolveRevisionFunc.SetDefaultReturn("deadbeef", nil)
+
+	resolver, err := NewRootResolver(
+		observation.TestContextTB(t),
+		mockCodeNavService,
+		nil,
+		mockGitserverClient,
+		nil,
+		mockRepoStore,
+		nil,
+		nil,
+		nil,
+		nil,
+		0,
+		10,
+	)
+	require.NoError(t, err)
+
+	usages, err := resolver.UsagesForSymbol(context.Background(), &resolverstubs.UsagesForSymbolArgs{
+		Range: resolverstubs.RangeInput{
+			Repository: "github.com/foo/bar",
+			Path:       "a.go",
+			Start:      resolverstubs.PositionInput{Line: 1, Character: 2},
+			End:        resolverstubs.PositionInput{Line: 1, Character: 5},
+		},
+	})
+	require.NoError(t, err)
+
+	nodes, err := usages.Nodes(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, resolverstubs.ProvenancePrecise, unwrap(nodes[0].Provenance(context.Background()))(t))
+	require.Equal(t, symbol, unwrap(unwrap(nodes[0].Symbol(context.Background()))(t).Name())(t))
+
+	occurrencesCall := mockCodeNavService.GetOccurrencesAtRangeFunc.History()[0]
+	require.Equal(t, repoRelPath("a.go"), occurrencesCall.Arg3)
+	require.Equal(t, scip.NewRangeUnchecked([]int32{1, 2, 5}), occurrencesCall.Arg4)
+	// The local symbol is skipped and the global one looked up only once.
+	definitionsCalls := mockCodeNavService.GetSymbolDefinitionsFunc.History()
+	require.Len(t, definitionsCalls, 1)
+	require.Equal(t, symbol, definitionsCalls[0].Arg3)
+}
+
+func TestUsagesForSymbol_PreciseDefinitionsInOtherRepositories(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		ExperimentalFeatures: &schema.ExperimentalFeatures{ScipBasedAPIs: pointers.Ptr(true)},
+	}})
+	t.Cleanup(func() { conf.Mock(nil) })
+
+	const symbol = "scip-go gomod github.com/foo/baz v1 `github.com/foo/baz`/Baz#"
+	upload := uploadsshared.CompletedUpload{ID: 42, RepositoryID: 1, RepositoryName: "github.com/foo/bar", Commit: "deadbeef"}
+	dependencyUpload := uploadsshared.CompletedUpload{ID: 43, RepositoryID: 2, RepositoryName: "github.com/foo/baz", Commit: "cafebabe"}
+
+	mockCodeNavService := NewMockCodeNavService()
+	mockCodeNavService.GetClosestCompletedUploadsForBlobFunc.SetDefaultReturn([]uploadsshared.CompletedUpload{upload}, nil)
+	mockCodeNavService.GetSymbolDefinitionUploadsFunc.SetDefaultReturn([]uploadsshared.CompletedUpload{upload, dependencyUpload}, nil)
+	mockCodeNavService.GetSymbolDefinitionsFunc.SetDefaultHook(func(_ context.Context, gitTreeTranslator codenav.GitTreeTranslator, u uploadsshared.CompletedUpload, _ string) ([]shared.UploadLocation, error) {
+		if u.ID != dependencyUpload.ID {
+			return nil, nil
+		}
+		require.Equal(t, api.CommitID("cafebabe"), gitTreeTranslator.GetSourceCommit())
+		return []shared.UploadLocation{{
+			Upload:       u,
+			Path:         repoRelPath("baz.go"),
+			TargetCommit: "cafebabe",
+			TargetRange:  shared.Range{Start: shared.Position{Line: 4, Character: 5}, End: shared.Position{Line: 4, Character: 8}},
+		}}, nil
+	})
+
+	mockRepoStore := dbmocks.NewMockRepoStore()
+	mockRepoStore.GetByNameFunc.SetDefaultReturn(&sgtypes.Repo{ID: 1, Name: "github.com/foo/bar"}, nil)
+	mockGitserverClient := gitserver.NewMockClient()
+	mockGitserverClient.ResolveRevisionFunc.SetDefaultReturn("deadbeef", nil)
+
+	resolver, err := NewRootResolver(
+		observation.TestContextTB(t),
+		mockCodeNavService,
+		nil,
+		mockGitserverClient,
+		nil,
+		mockRepoStore,
+		nil,
+		nil,
+		nil,
+		nil,
+		0,
+		10,
+	)
+	require.NoError(t, err)
+
+	usages, err := resolver.UsagesForSymbol(context.Background(), &resolverstubs.UsagesForSymbolArgs{
+		Symbol: &resolverstubs.SymbolComparator{
+			Name:       resolverstubs.SymbolNameComparator{Equals: pointers.Ptr(symbol)},
+			Provenance: resolverstubs.CodeGraphDataProvenanceComparator{Equals: pointers.Ptr(resolverstubs.ProvenancePrecise)},
+		},
+		Range: resolverstubs.RangeInput{
+			Repository: "github.com/foo/bar",
+			Path:       "a.go",
+			Start:      resolverstubs.PositionInput{Line: 1, Character: 2},
+			End:        resolverstubs.PositionInput{Line: 1, Character: 5},
+		},
+	})
+	require.NoError(t, err)
+
+	nodes, err := usages.Nodes(context.Background())
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	usageRange := unwrap(nodes[0].UsageRange(context.Background()))(t)
+	require.Equal(t, "github.com/foo/baz", usageRange.Repository())
+	require.Equal(t, "cafebabe", usageRange.Revision())
+	require.Equal(t, "baz.go", usageRange.Path())
+	// The upload of the requested file is not searched twice.
+	require.Len(t, mockCodeNavService.GetSymbolDefinitionsFunc.History(), 2)
+}
+
+func TestUsagesForSymbol_DataSource(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		ExperimentalFeatures: &schema.ExperimentalFeatures{ScipBasedAPIs: pointers.Ptr(true)},
+	}})
+	t.Cleanup(func() { conf.Mock(nil) })
+
+	mockCodeNavService := NewMockCodeNavService()
+	mockCodeNavService.SyntacticUsagesFunc.SetDefaultReturn(codenav.SyntacticUsagesResult{
+		Matches: []codenav.SyntacticMatch{
+			{Path: repoRelPath("a.go"), Range: scip.NewRangeUnchecked([]int32{1, 2, 3}), IsDefinition: true, Symbol: "a"},
+		},
+	}, codenav.PreviousSyntacticSearch{}, nil)
+	mockCodeNavService.SearchBasedUsagesFunc.SetDefaultReturn([]codenav.SearchBasedMatch{
+		{Path: repoRelPath("b.go"), Range: scip.NewRangeUnchecked([]int32{4, 5, 6}), DataSource: result.FileMatchSourceZoekt},
+		{Path: repoRelPath("c.go"), Range: scip.NewRangeUnchecked([]int32{7, 8, 9}), DataSource: result.FileMatchSourceSearcher},
+		{Path: repoRelPath("d.go"), Range: scip.NewRangeUnchecked([]int32{10, 11, 12})},
+	}, nil)
+
+	mockRepoStore := dbmocks.NewMockRepoStore()
+	mockRepoStore.GetByNameFunc.SetDefaultReturn(&sgtypes.Repo{ID: 1, Name: "github.com/foo/bar"}, nil)
+	mockGitserverClient := gitserver.NewMockClient()
+	mockGitserverClient.ResolveRevisionFunc.SetDefaultReturn("deadbeef", nil)
+
+	resolver, err := NewRootResolver(
+		observation.TestContextTB(t),
+		mockCodeNavService,
+		nil,
+		mockGitserverClient,
+		nil,
+		mockRepoStore,
+		nil,
+		nil,
+		nil,
+		nil,
+		0,
+		10,
+	)
+	require.NoError(t, err)
+
+	usages, err := resolver.UsagesForSymbol(context.Background(), &resolverstubs.UsagesForSymbolArgs{
+		Range: resolverstubs.RangeInput{
+			Repository: "github.com/foo/bar",
+			Path:       "a.go",
+			Start:      resolverstubs.PositionInput{Line: 1, Character: 2},
+			End:        resolverstubs.PositionInput{Line: 1, Character: 3},
+		},
+	})
+	require.NoError(t, err)
+
+	nodes, err := usages.Nodes(context.Background())
+	require.NoError(t, err)
+	var dataSources []*string
+	for _, node := range nodes {
+		dataSources = append(dataSources, node.DataSource())
+	}
+	require.Equal(t, []*string{
+		nil,
+		pointers.Ptr("zoekt"),
+		pointers.Ptr("searcher"),
+		nil,
+	}, dataSources)
+}
+
+func TestSyntacticUsageResolver_Documentation(t *testing.T) {
+	repo := sgtypes.Repo{ID: 1, Name: "github.com/foo/bar"}
+
+	documented := NewSyntacticUsageResolver(codenav.SyntacticMatch{
+		Path:          repoRelPath("a.go"),
+		Range:         scip.NewRangeUnchecked([]int32{1, 2, 3}),
+		Symbol:        "scip-syntax . . . Foo#",
+		Documentation: []string{"Does foo things."},
+	}, repo, "deadbeef", nil)
+	symbol, err := documented.Symbol(context.Background())
+	require.NoError(t, err)
+	documentation, err := symbol.Documentation()
+	require.NoError(t, err)
+	require.Equal(t, &[]string{"Does foo things."}, documentation)
+
+	undocumented := NewSyntacticUsageResolver(codenav.SyntacticMatch{
+		Path:   repoRelPath("b.go"),
+		Range:  scip.NewRangeUnchecked([]int32{4, 5, 6}),
+		Symbol: "scip-syntax . . . Bar#",
+	}, repo, "deadbeef", nil)
+	symbol, err = undocumented.Symbol(context.Background())
+	require.NoError(t, err)
+	documentation, err = symbol.Documentation()
+	require.NoError(t, err)
+	require.Nil(t, documentation)
+}
+
+func TestUsageResolver_SurroundingContent(t *testing.T) {
+	mockGitserverClient := gitserver.NewMockClient()
+	mockGitserverClient.NewFileReaderFunc.SetDefaultHook(func(context.Context, api.RepoName, api.CommitID, string) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader("l0\nl1\nl2\nl3\nl4\n")), nil
+	})
+	linesGetter := newCachedLinesGetter(mockGitserverClient, 1024)
+
+	surroundingContent := func(line int32, linesBefore, linesAfter int32) (string, error) {
+		usage := NewSearchBasedUsageResolver(codenav.SearchBasedMatch{
+			Path:  repoRelPath("a.go"),
+			Range: scip.NewRangeUnchecked([]int32{line, 0, 2}),
+		}, sgtypes.Repo{ID: 1, Name: "github.com/foo/bar"}, "deadbeef", linesGetter)
+		return usage.SurroundingContent(context.Background(), &struct {
+			*resolverstubs.SurroundingLines `json:"surroundingLines"`
+		}{&resolverstubs.SurroundingLines{LinesBefore: &linesBefore, LinesAfter: &linesAfter}})
+	}
+
+	content, err := surroundingContent(2, 1, 1)
+	require.NoError(t, err)
+	require.Equal(t, "l1\nl2\nl3\n", content)
+
+	// Near the top of the file.
+	content, err = surroundingContent(1, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, "l0\nl1\n", content)
+
+	// Near the end of the file, without overflowing the line number.
+	content, err = surroundingContent(3, 0, math.MaxInt32)
+	require.NoError(t, err)
+	require.Equal(t, "l3\nl4\n", content)
+
+	_, err = surroundingContent(2, -1, 0)
+	require.ErrorIs(t, err, ErrIllegalSurroundingLines)
+	_, err = surroundingContent(2, 0, -1)
+	require.ErrorIs(t, err, ErrIllegalSurroundingLines)
+}
@@ -11,11 +11,20 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/codeintel/core"
 	resolverstubs "github.com/sourcegraph/sourcegraph/internal/codeintel/resolvers"
 	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+	"github.com/sourcegraph/sourcegraph/lib/pointers"
 )
 
+// ErrIllegalSurroundingLines occurs when a negative number of surrounding lines
+// is requested for a usage.
+var ErrIllegalSurroundingLines = errors.New("linesBefore and linesAfter must not be negative")
+
 type usageConnectionResolver struct {
 	nodes    []resolverstubs.UsageResolver
 	pageInfo resolverstubs.PageInfo
+	// totalCount counts the usages across all pages. It is only called if
+	// the client asks for the total count, as it may be expensive.
+	totalCount func(context.Context) (int32, error)
 }
 
 var _ resolverstubs.UsageConnectionResolver = &usageConnectionResolver{}
@@ -24,6 +33,10 @@ func (u *usageConnectionResolver) Nodes(ctx context.Context) ([]resolverstubs.Us
 	return u.nodes, nil
 }
 
+func (u *usageConnectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	return u.totalCount(ctx)
+}
+
 func (u *usageConnectionResolver) PageInfo() resolverstubs.PageInfo {
 	return u.pageInfo
 }
@@ -34,6 +47,8 @@ type usageResolver struct {
 	kind        resolverstubs.SymbolUsageKind
 	linesGetter LinesGetter
 	usageRange  *usageRangeResolver
+	// dataSource is the search backend which found a search-based usage.
+	dataSource *string
 }
 
 var _ resolverstubs.UsageResolver = &usageResolver{}
@@ -47,7 +62,8 @@ func NewSyntacticUsageResolver(usage codenav.SyntacticMatch, repository types.Re
 	}
 	return &usageResolver{
 		symbol: &symbolInformationResolver{
-			name: usage.Symbol,
+			name:          usage.Symbol,
+			documentation: usage.Documentation,
 		},
 		provenance:  resolverstubs.ProvenanceSyntactic,
 		kind:        kind,
@@ -60,6 +76,25 @@ func NewSyntacticUsageResolver(usage codenav.SyntacticMatch, repository types.Re
 		},
 	}
 }
+
+func NewPreciseDefinitionUsageResolver(symbol string, definition shared.UploadLocation, linesGetter LinesGetter) resolverstubs.UsageResolver {
+	upload := definition.Upload
+	return &usageResolver{
+		symbol: &symbolInformationResolver{
+			name: symbol,
+		},
+		provenance:  resolverstubs.ProvenancePrecise,
+		kind:        resolverstubs.UsageKindDefinition,
+		linesGetter: linesGetter,
+		usageRange: &usageRangeResolver{
+			repository: types.Repo{ID: api.RepoID(upload.RepositoryID), Name: api.RepoName(upload.RepositoryName)},
+			revision:   api.CommitID(definition.TargetCommit),
+			path:       definition.Path,
+			range_:     definition.TargetRange.ToSCIPRange(),
+		},
+	}
+}
+
 func NewSearchBasedUsageResolver(usage codenav.SearchBasedMatch, repository types.Repo, revision api.CommitID, linesGetter LinesGetter) resolverstubs.UsageResolver {
 	var kind resolverstubs.SymbolUsageKind
 	if usage.IsDefinition {
@@ -78,6 +113,7 @@ func NewSearchBasedUsageResolver(usage codenav.SearchBasedMatch, repository type
 			path:       usage.Path,
 			range_:     usage.Range,
 		},
+		dataSource: pointers.NonZeroPtr(usage.DataSource.String()),
 	}
 }
 
@@ -93,10 +129,10 @@ func (u *usageResolver) Provenance(ctx context.Context) (resolverstubs.CodeGraph
 	return u.provenance, nil
 }
 
+// DataSource returns whether a search-based usage was found via Zoekt or
+// Searcher. It is nil for other usages.
 func (u *usageResolver) DataSource() *string {
-	//TODO implement me
-	// NOTE: For search-based usages it would be good to return if this usage was found via Zoekt or Searcher
-	panic("implement me")
+	return u.dataSource
 }
 
 func (u *usageResolver) UsageRange(ctx context.Context) (resolverstubs.UsageRangeResolver, error) {
@@ -106,13 +142,28 @@ func (u *usageResolver) UsageRange(ctx context.Context) (resolverstubs.UsageRang
 func (u *usageResolver) SurroundingContent(ctx context.Context, args *struct {
 	*resolverstubs.SurroundingLines `json:"surroundingLines"`
 }) (string, error) {
+	var linesBefore, linesAfter int
+	if args.SurroundingLines != nil {
+		if args.LinesBefore != nil {
+			linesBefore = int(*args.LinesBefore)
+		}
+		if args.LinesAfter != nil {
+			linesAfter = int(*args.LinesAfter)
+		}
+	}
+	if linesBefore < 0 || linesAfter < 0 {
+		return "", ErrIllegalSurroundingLines
+	}
+
+	// Computed as int to not overflow for huge values of linesAfter. Lines
+	// past the end of the file are cut off by the linesGetter.
 	lines, err := u.linesGetter.Get(
 		ctx,
 		u.usageRange.repository.Name,
 		u.usageRange.revision,
 		u.usageRange.path.RawValue(),
-		int(u.usageRange.range_.Start.Line-*args.LinesBefore),
-		int(u.usageRange.range_.End.Line+*args.LinesAfter+1),
+		max(0, int(u.usageRange.range_.Start.Line)-linesBefore),
+		int(u.usageRange.range_.End.Line)+linesAfter+1,
 	)
 	if err != nil {
 		return "", err
@@ -125,7 +176,8 @@ func (u *usageResolver) UsageKind() resolverstubs.SymbolUsageKind {
 }
 
 type symbolInformationResolver struct {
-	name string
+	name          string
+	documentation []string
 }
 
 var _ resolverstubs.SymbolInformationResolver = &symbolInformationResolver{}
@@ -134,9 +186,45 @@ func (s *symbolInformationResolver) Name() (string, error) {
 	return s.name, nil
 }
 
+func (s *symbolInformationResolver) Kind() (*string, error) {
+	return symbolKind(s.name), nil
+}
+
+// symbolKind returns the GraphQL SymbolKind for the given SCIP symbol, based on
+// the suffix of its last descriptor. It returns nil if the symbol can't be parsed
+// or its descriptors don't tell us what kind of symbol it is.
+func symbolKind(symbol string) *string {
+	if scip.IsLocalSymbol(symbol) {
+		return nil
+	}
+	parsed, err := scip.ParseSymbol(symbol)
+	if err != nil || len(parsed.Descriptors) == 0 {
+		return nil
+	}
+
+	var kind string
+	switch parsed.Descriptors[len(parsed.Descriptors)-1].Suffix {
+	case scip.Descriptor_Namespace:
+		kind = "NAMESPACE"
+	case scip.Descriptor_Type:
+		kind = "CLASS"
+	case scip.Descriptor_Term, scip.Descriptor_Parameter:
+		kind = "VARIABLE"
+	case scip.Descriptor_Method:
+		kind = "METHOD"
+	case scip.Descriptor_TypeParameter:
+		kind = "TYPEPARAMETER"
+	default:
+		return nil
+	}
+	return &kind
+}
+
 func (s *symbolInformationResolver) Documentation() (*[]string, error) {
-	//TODO implement me
-	panic("implement me")
+	if len(s.documentation) == 0 {
+		return nil, nil
+	}
+	return &s.documentation, nil
 }
 
 type usageRangeResolver struct {
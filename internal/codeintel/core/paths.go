@@ -21,8 +21,10 @@ func NewUploadRelPathUnchecked(s string) UploadRelPath {
 	return UploadRelPath{rawValue: s}
 }
 
+// Equal reports whether p and other are the same path, treating backslash
+// and forward slash separators alike.
 func (p UploadRelPath) Equal(other UploadRelPath) bool {
-	return p.rawValue == other.rawValue
+	return toSlash(p.rawValue) == toSlash(other.rawValue)
 }
 
 func (p UploadRelPath) RawValue() string {
@@ -42,9 +44,12 @@ func NewRepoRelPathUnchecked(s string) RepoRelPath {
 
 // NewRepoRelPath takes an UploadLike as the first argument instead of a
 // *shared.CompletedUpload to avoid a dependency on a higher-level package.
+//
+// Backslash separators in the upload root and p, e.g. from uploads indexed on
+// Windows, are converted to forward slashes to match paths in the repo.
 func NewRepoRelPath(uploadLike UploadLike, p UploadRelPath) RepoRelPath {
 	// TODO: We should use filepath.Join here but that breaks some tests
-	return RepoRelPath{rawValue: uploadLike.GetRoot() + p.rawValue}
+	return RepoRelPath{rawValue: toSlash(uploadLike.GetRoot() + p.rawValue)}
 }
 
 func (p RepoRelPath) RawValue() string {
@@ -53,13 +58,25 @@ func (p RepoRelPath) RawValue() string {
 
 // NewUploadRelPath takes an UploadLike as the first argument instead of a
 // *shared.CompletedUpload to avoid a dependency on a higher-level package.
+//
+// Like NewRepoRelPath, it converts backslash separators to forward slashes,
+// so that an upload root like `sub\dir\` still matches `sub/dir/file.go`.
 func NewUploadRelPath(uploadLike UploadLike, p RepoRelPath) UploadRelPath {
 	// TODO: Introduce a panic here when u.Root is not a prefix of p.rawValue
 	// It seems like filepath.Rel can do the error-checking for us, but currently
 	// just using strings.TrimPrefix for compatibility with old behavior.
-	return NewUploadRelPathUnchecked(strings.TrimPrefix(p.rawValue, uploadLike.GetRoot()))
+	return NewUploadRelPathUnchecked(strings.TrimPrefix(toSlash(p.rawValue), toSlash(uploadLike.GetRoot())))
+}
+
+// toSlash replaces backslash path separators with forward slashes. Unlike
+// filepath.ToSlash, it does so regardless of the OS we are running on, as
+// uploads may have been indexed on a different one.
+func toSlash(path string) string {
+	return strings.ReplaceAll(path, `\`, "/")
 }
 
+// Equal reports whether p and other are the same path, treating backslash
+// and forward slash separators alike.
 func (p RepoRelPath) Equal(other RepoRelPath) bool {
-	return p.rawValue == other.rawValue
+	return toSlash(p.rawValue) == toSlash(other.rawValue)
 }
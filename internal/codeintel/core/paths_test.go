@@ -0,0 +1,42 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestPathsWithBackslashes(t *testing.T) {
+	testCases := []struct {
+		name          string
+		root          string
+		uploadRelPath string
+		repoRelPath   string
+	}{
+		{name: "forward slashes", root: "sub/dir/", uploadRelPath: "pkg/file.go", repoRelPath: "sub/dir/pkg/file.go"},
+		{name: "backslash root", root: `sub\dir\`, uploadRelPath: "pkg/file.go", repoRelPath: "sub/dir/pkg/file.go"},
+		{name: "mixed root", root: `sub\dir/`, uploadRelPath: "pkg/file.go", repoRelPath: "sub/dir/pkg/file.go"},
+		{name: "backslash document", root: "sub/dir/", uploadRelPath: `pkg\file.go`, repoRelPath: "sub/dir/pkg/file.go"},
+		{name: "empty root", root: "", uploadRelPath: `pkg\file.go`, repoRelPath: "pkg/file.go"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			upload := &UploadSummary{ID: 1, Root: tc.root}
+
+			repoRelPath := NewRepoRelPath(upload, NewUploadRelPathUnchecked(tc.uploadRelPath))
+			if have, want := repoRelPath.RawValue(), tc.repoRelPath; have != want {
+				t.Errorf("unexpected repo-relative path: have=%q want=%q", have, want)
+			}
+
+			uploadRelPath := NewUploadRelPath(upload, NewRepoRelPathUnchecked(tc.repoRelPath))
+			if have, want := uploadRelPath.RawValue(), toSlash(tc.uploadRelPath); have != want {
+				t.Errorf("unexpected upload-relative path: have=%q want=%q", have, want)
+			}
+			if !uploadRelPath.Equal(NewUploadRelPathUnchecked(tc.uploadRelPath)) {
+				t.Errorf("expected %q to equal %q", uploadRelPath.RawValue(), tc.uploadRelPath)
+			}
+			if !repoRelPath.Equal(NewRepoRelPathUnchecked(tc.repoRelPath)) {
+				t.Errorf("expected %q to equal %q", repoRelPath.RawValue(), tc.repoRelPath)
+			}
+		})
+	}
+}
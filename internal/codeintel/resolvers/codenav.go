@@ -249,7 +249,9 @@ type CodeGraphDataOpts struct {
 	Args   *CodeGraphDataArgs
 	Repo   *types.Repo
 	Commit api.CommitID
-	Path   core.RepoRelPath
+	// Rev is the revision that was requested and resolved to Commit, if any.
+	Rev  string
+	Path core.RepoRelPath
 }
 
 func (opts *CodeGraphDataOpts) Attrs() []attribute.KeyValue {
@@ -613,7 +615,11 @@ type StringComparator struct {
 	Equals *string
 }
 
-type UsageConnectionResolver = PagedConnectionResolver[UsageResolver]
+type UsageConnectionResolver interface {
+	PagedConnectionResolver[UsageResolver]
+	// TotalCount returns the number of usages across all pages.
+	TotalCount(ctx context.Context) (int32, error)
+}
 
 type UsageResolver interface {
 	Symbol(context.Context) (SymbolInformationResolver, error)
@@ -628,6 +634,7 @@ type UsageResolver interface {
 
 type SymbolInformationResolver interface {
 	Name() (string, error)
+	Kind() (*string, error)
 	Documentation() (*[]string, error)
 }
 
@@ -4,15 +4,20 @@ import (
 	"context"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/sourcegraph/log"
 
+	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/dotcom"
 	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/featureflag"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/gitdomain"
 	gitprotocol "github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/licensing"
 	"github.com/sourcegraph/sourcegraph/internal/search"
 	"github.com/sourcegraph/sourcegraph/internal/search/client"
 	"github.com/sourcegraph/sourcegraph/internal/search/commit"
@@ -26,7 +31,79 @@ import (
 )
 
 func Search(ctx context.Context, logger log.Logger, db database.DB, query string, monitorID int64, triggerID int32) (_ []*result.CommitMatch, err error) {
-	searchClient := client.New(logger, db, gitserver.NewClient("monitors.search"))
+	if err := checkLicensed(); err != nil {
+		return nil, err
+	}
+	hook := func(ctx context.Context, db database.DB, gs commit.GitserverClient, args *gitprotocol.SearchRequest, repoID api.RepoID, doSearch commit.DoSearchFunc) error {
+		return hookWithID(ctx, logger, db, gs, monitorID, triggerID, repoID, args, doSearch)
+	}
+	return runWithHook(ctx, logger, db, "monitors.search", query, hook)
+}
+
+// ErrCodeMonitorsDisabled is returned when evaluating a code monitor on an
+// instance that doesn't run code monitors.
+var ErrCodeMonitorsDisabled = errors.New("code monitors are disabled on this instance")
+
+// SearchWindow runs the query of a code monitor against the commits with a
+// committer date strictly between start and end, e.g. to backfill results or to
+// debug a monitor.
+//
+// Unlike Search, it doesn't only search the commits since the last run, and it
+// doesn't record which commits it searched. It doesn't affect what future runs
+// of the monitor notify about.
+func SearchWindow(ctx context.Context, logger log.Logger, db database.DB, monitorID int64, start, end time.Time) ([]*result.CommitMatch, error) {
+	// Matches the background workers, which don't run on dotcom.
+	if dotcom.SourcegraphDotComMode() {
+		return nil, ErrCodeMonitorsDisabled
+	}
+	if err := checkLicensed(); err != nil {
+		return nil, err
+	}
+	if !start.Before(end) {
+		return nil, errors.Newf("invalid time window: start (%s) must be before end (%s)", start, end)
+	}
+
+	cm := db.CodeMonitors()
+	m, err := cm.GetMonitor(ctx, monitorID)
+	if err != nil {
+		return nil, err
+	}
+	q, err := cm.GetQueryTriggerForMonitor(ctx, monitorID)
+	if err != nil {
+		return nil, err
+	}
+
+	// SECURITY: like the background workers, we search as the user that owns
+	// the code monitor.
+	ctx = actor.WithActor(ctx, actor.FromUser(m.UserID))
+	ctx = featureflag.WithFlags(ctx, db.FeatureFlags())
+
+	return runWithHook(ctx, logger, db, "monitors.search.window", q.QueryString, windowHook(start, end))
+}
+
+// checkLicensed returns an error if the license of the instance doesn't
+// include code monitors.
+func checkLicensed() error {
+	if err := licensing.Check(licensing.FeatureCodeMonitors); err != nil {
+		return errcode.MakeNonRetryable(err)
+	}
+	return nil
+}
+
+// windowHook returns a hook that restricts the search to the commits with a
+// committer date strictly between start and end.
+func windowHook(start, end time.Time) commit.CodeMonitorHook {
+	return func(_ context.Context, _ database.DB, _ commit.GitserverClient, args *gitprotocol.SearchRequest, _ api.RepoID, doSearch commit.DoSearchFunc) error {
+		argsCopy := *args
+		argsCopy.Query = gitprotocol.NewAnd(args.Query, &gitprotocol.CommitAfter{Time: start}, &gitprotocol.CommitBefore{Time: end})
+		return doSearch(&argsCopy)
+	}
+}
+
+// runWithHook plans query as a code monitor search, wraps its commit search
+// with hook and returns the matching commits.
+func runWithHook(ctx context.Context, logger log.Logger, db database.DB, scope string, query string, hook commit.CodeMonitorHook) ([]*result.CommitMatch, error) {
+	searchClient := client.New(logger, db, gitserver.NewClient(scope))
 	inputs, err := searchClient.Plan(
 		ctx,
 		"V3",
@@ -47,9 +124,6 @@ func Search(ctx context.Context, logger log.Logger, db database.DB, query string
 		return nil, errcode.MakeNonRetryable(err)
 	}
 
-	hook := func(ctx context.Context, db database.DB, gs commit.GitserverClient, args *gitprotocol.SearchRequest, repoID api.RepoID, doSearch commit.DoSearchFunc) error {
-		return hookWithID(ctx, logger, db, gs, monitorID, triggerID, repoID, args, doSearch)
-	}
 	planJob, err = addCodeMonitorHook(planJob, hook)
 	if err != nil {
 		return nil, errcode.MakeNonRetryable(err)
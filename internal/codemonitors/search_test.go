@@ -2,7 +2,9 @@ package codemonitors
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -11,8 +13,10 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
+	"github.com/sourcegraph/sourcegraph/internal/dotcom"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	gitprotocol "github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/licensing"
 	"github.com/sourcegraph/sourcegraph/internal/search"
 	"github.com/sourcegraph/sourcegraph/internal/search/commit"
 	"github.com/sourcegraph/sourcegraph/internal/search/job"
@@ -171,3 +175,89 @@ func TestCodeMonitorHook(t *testing.T) {
 		require.ErrorContains(t, err, "some commits may be skipped")
 	})
 }
+
+func TestWindowHook(t *testing.T) {
+	t.Parallel()
+
+	type fakeCommit struct {
+		message       string
+		committerDate time.Time
+	}
+	day := func(d int) time.Time { return time.Date(2023, time.March, d, 12, 0, 0, 0, time.UTC) }
+	commits := []fakeCommit{
+		{message: "fix: before the window", committerDate: day(1)},
+		{message: "fix: on the start of the window", committerDate: day(5)},
+		{message: "fix: in the window", committerDate: day(10)},
+		{message: "feat: in the window", committerDate: day(12)},
+		{message: "fix: also in the window", committerDate: day(14)},
+		{message: "fix: after the window", committerDate: day(20)},
+	}
+
+	// match evaluates the nodes of the query that this test uses against a
+	// commit, like gitserver does.
+	var match func(gitprotocol.Node, fakeCommit) bool
+	match = func(n gitprotocol.Node, c fakeCommit) bool {
+		switch v := n.(type) {
+		case *gitprotocol.Operator:
+			require.Equal(t, gitprotocol.And, v.Kind)
+			for _, operand := range v.Operands {
+				if !match(operand, c) {
+					return false
+				}
+			}
+			return true
+		case *gitprotocol.MessageMatches:
+			return strings.HasPrefix(c.message, v.Expr)
+		case *gitprotocol.CommitAfter:
+			return c.committerDate.After(v.Time)
+		case *gitprotocol.CommitBefore:
+			return c.committerDate.Before(v.Time)
+		default:
+			t.Fatalf("unexpected node %T", n)
+			return false
+		}
+	}
+
+	var matched []string
+	doSearch := func(args *gitprotocol.SearchRequest) error {
+		for _, c := range commits {
+			if match(args.Query, c) {
+				matched = append(matched, c.message)
+			}
+		}
+		return nil
+	}
+
+	args := &gitprotocol.SearchRequest{Revisions: []string{"HEAD"}, Query: &gitprotocol.MessageMatches{Expr: "fix"}}
+	err := windowHook(day(5), day(15))(context.Background(), nil, nil, args, 1, doSearch)
+	require.NoError(t, err)
+	require.Equal(t, []string{"fix: in the window", "fix: also in the window"}, matched)
+
+	// The original request is left alone.
+	require.Equal(t, &gitprotocol.MessageMatches{Expr: "fix"}, args.Query)
+}
+
+func TestSearchWindow(t *testing.T) {
+	ctx := context.Background()
+	logger := logtest.Scoped(t)
+	start := time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)
+	t.Cleanup(licensing.TestingSkipFeatureChecks())
+
+	t.Run("invalid window", func(t *testing.T) {
+		_, err := SearchWindow(ctx, logger, nil, 1, start, start)
+		require.ErrorContains(t, err, "invalid time window")
+	})
+
+	t.Run("disabled on dotcom", func(t *testing.T) {
+		dotcom.MockSourcegraphDotComMode(t, true)
+		_, err := SearchWindow(ctx, logger, nil, 1, start, start.Add(time.Hour))
+		require.ErrorIs(t, err, ErrCodeMonitorsDisabled)
+	})
+
+	t.Run("not licensed", func(t *testing.T) {
+		licensing.MockCheckFeatureError("code monitors are not licensed")
+		t.Cleanup(func() { licensing.TestingSkipFeatureChecks() })
+		_, err := SearchWindow(ctx, logger, nil, 1, start, start.Add(time.Hour))
+		require.ErrorContains(t, err, "code monitors are not licensed")
+	})
+}
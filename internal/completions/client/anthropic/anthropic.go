@@ -36,6 +36,14 @@ const (
 	clientID = "sourcegraph/1.0"
 )
 
+// PromptCachingBeta is the value of the anthropic-beta header that enables
+// prompt caching: https://docs.anthropic.com/en/docs/build-with-claude/prompt-caching
+const PromptCachingBeta = "prompt-caching-2024-07-31"
+
+// minThinkingBudget is the smallest thinking budget Anthropic accepts:
+// https://docs.anthropic.com/en/docs/build-with-claude/extended-thinking
+const minThinkingBudget = 1024
+
 type anthropicClient struct {
 	cli          httpcli.Doer
 	accessToken  string
@@ -59,18 +67,18 @@ func (a *anthropicClient) Complete(
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
 		return nil, err
 	}
-	if err = a.recordTokenUsage(request, response.Usage); err != nil {
-		return nil, err
-	}
-
 	completion := ""
 	for _, content := range response.Content {
 		completion += content.Text
 	}
+	if err = a.recordTokenUsage(request, response.Usage, completion); err != nil {
+		return nil, err
+	}
 
 	return &types.CompletionResponse{
-		Completion: completion,
-		StopReason: response.StopReason,
+		Completion:           completion,
+		StopReason:           response.StopReason,
+		NormalizedStopReason: types.NormalizeAnthropicStopReason(response.StopReason),
 	}, nil
 
 }
@@ -89,7 +97,22 @@ func (a *anthropicClient) Stream(
 
 	dec := NewDecoder(resp.Body)
 	completedString := ""
-	var inputPromptTokens int
+	// lastUsage is the last token usage reported in the stream, if any. The
+	// output tokens reported in message_delta events are cumulative, so it is
+	// recorded once the stream ends, even if the final event is missing. Usage
+	// that was never reported is estimated.
+	var lastUsage *anthropicMessagesResponseUsage
+	defer func() {
+		var reported anthropicMessagesResponseUsage
+		if lastUsage != nil {
+			reported = *lastUsage
+		}
+		if err := a.recordTokenUsage(request, reported, completedString); err != nil {
+			logger.Warn("Failed to count tokens with the token manager %w ", log.Error(err))
+		}
+	}()
+	// usage is the token usage reported since the last event we sent, if any.
+	var usage *types.TokenUsage
 	for dec.Scan() {
 		if ctx.Err() != nil && ctx.Err() == context.Canceled {
 			return nil
@@ -111,7 +134,12 @@ func (a *anthropicClient) Stream(
 		switch event.Type {
 		case "message_start":
 			if event.Message != nil && event.Message.Usage != nil {
-				inputPromptTokens = event.Message.Usage.InputTokens
+				promptUsage := *event.Message.Usage
+				lastUsage = &promptUsage
+				usage = &types.TokenUsage{
+					InputTokens:  promptUsage.InputTokens,
+					OutputTokens: promptUsage.OutputTokens,
+				}
 			}
 			continue
 		case "content_block_delta":
@@ -119,38 +147,51 @@ func (a *anthropicClient) Stream(
 				completedString += event.Delta.Text
 			}
 		case "message_delta":
-			if event.Delta != nil {
-				stopReason = event.Delta.StopReason
-
-				// Build the usage data based on what we've seen.
-				usageData := anthropicMessagesResponseUsage{
-					InputTokens:  inputPromptTokens,
-					OutputTokens: event.Usage.OutputTokens,
+			if event.Usage != nil {
+				if lastUsage == nil {
+					lastUsage = &anthropicMessagesResponseUsage{}
 				}
-				if err = a.recordTokenUsage(request, usageData); err != nil {
-					logger.Warn("Failed to count tokens with the token manager %w ", log.Error(err))
+				lastUsage.OutputTokens = event.Usage.OutputTokens
+				usage = &types.TokenUsage{
+					InputTokens:  lastUsage.InputTokens,
+					OutputTokens: lastUsage.OutputTokens,
 				}
 			}
+			if event.Delta != nil {
+				stopReason = event.Delta.StopReason
+			}
 		default:
 			continue
 		}
 
 		err = sendEvent(types.CompletionResponse{
-			Completion: completedString,
-			StopReason: stopReason,
+			Completion:           completedString,
+			StopReason:           stopReason,
+			NormalizedStopReason: types.NormalizeAnthropicStopReason(stopReason),
+			Usage:                usage,
 		})
 		if err != nil {
 			return err
 		}
+		usage = nil
 
 	}
 	return dec.Err()
 }
 
-func (a *anthropicClient) recordTokenUsage(request types.CompletionRequest, usage anthropicMessagesResponseUsage) error {
+func (a *anthropicClient) recordTokenUsage(request types.CompletionRequest, usage anthropicMessagesResponseUsage, completion string) error {
 	label := fmt.Sprintf("%s/%s", tokenusage.Anthropic, request.ModelConfigInfo.Model.ModelName)
-	return a.tokenManager.UpdateTokenCountsFromModelUsage(
+	if err := a.tokenManager.UpdateReportedTokenCounts(
+		request, completion,
 		usage.InputTokens, usage.OutputTokens,
+		label, tokenusage.Anthropic); err != nil {
+		return err
+	}
+	if usage.CacheCreationInputTokens == 0 && usage.CacheReadInputTokens == 0 {
+		return nil
+	}
+	return a.tokenManager.UpdatePromptCacheTokenCounts(
+		usage.CacheCreationInputTokens, usage.CacheReadInputTokens,
 		label, string(request.Feature),
 		tokenusage.Anthropic)
 }
@@ -177,12 +218,25 @@ func (a *anthropicClient) makeRequest(ctx context.Context, request types.Complet
 		TopP:          requestParams.TopP,
 		TopK:          requestParams.TopK,
 	}
+	if requestParams.ThinkingBudget > 0 {
+		if requestParams.ThinkingBudget < minThinkingBudget {
+			return nil, errors.Newf("thinking budget %d is less than the minimum of %d tokens", requestParams.ThinkingBudget, minThinkingBudget)
+		}
+		// Thinking tokens count towards max_tokens, so we add the budget to
+		// not cut the response short. Thinking is not compatible with
+		// changing the sampling parameters.
+		messagesPayload.Thinking = &anthropicThinking{Type: "enabled", BudgetTokens: requestParams.ThinkingBudget}
+		messagesPayload.MaxTokens += requestParams.ThinkingBudget
+		messagesPayload.Temperature = 0
+		messagesPayload.TopP = 0
+		messagesPayload.TopK = 0
+	}
 
 	if !a.viaGateway {
 		// Convert the eventual first message from `system` to a top-level system prompt
-		messagesPayload.System = "" // prevent the upstream API from setting this
+		messagesPayload.System = nil // prevent the upstream API from setting this
 		if len(messagesPayload.Messages) > 0 && messagesPayload.Messages[0].Role == types.SYSTEM_MESSAGE_SPEAKER {
-			messagesPayload.System = messagesPayload.Messages[0].Content[0].Text
+			messagesPayload.System = messagesPayload.Messages[0].Content
 			messagesPayload.Messages = messagesPayload.Messages[1:]
 		}
 	}
@@ -207,6 +261,10 @@ func (a *anthropicClient) makeRequest(ctx context.Context, request types.Complet
 	req.Header.Set("Client", clientID)
 	req.Header.Set("X-API-Key", a.accessToken)
 	req.Header.Set("anthropic-version", "2023-06-01")
+	if hasCacheBreakpoints(messages) {
+		// Cody Gateway forwards this header, so we set it either way.
+		req.Header.Set("anthropic-beta", PromptCachingBeta)
+	}
 
 	resp, err := a.cli.Do(req)
 	if err != nil {
@@ -229,10 +287,18 @@ type anthropicRequestParameters struct {
 	Stream        bool               `json:"stream,omitempty"`
 	StopSequences []string           `json:"stop_sequences,omitempty"`
 	MaxTokens     int                `json:"max_tokens,omitempty"`
+	Thinking      *anthropicThinking `json:"thinking,omitempty"`
 
 	// These are not accepted from the client an instead are only used to talk to the upstream LLM
 	// APIs directly (these do NOT need to be set when talking to Cody Gateway)
-	System string `json:"system,omitempty"`
+	System []anthropicMessageContent `json:"system,omitempty"`
+}
+
+// anthropicThinking enables extended thinking, see
+// https://docs.anthropic.com/en/docs/build-with-claude/extended-thinking.
+type anthropicThinking struct {
+	Type         string `json:"type"` // "enabled"
+	BudgetTokens int    `json:"budget_tokens"`
 }
 
 type anthropicMessage struct {
@@ -243,6 +309,13 @@ type anthropicMessage struct {
 type anthropicMessageContent struct {
 	Type string `json:"type"` // "text" or "image" (not yet supported)
 	Text string `json:"text"`
+
+	// CacheControl, if set, marks the end of a cacheable prompt prefix.
+	CacheControl *anthropicCacheControl `json:"cache_control,omitempty"`
+}
+
+type anthropicCacheControl struct {
+	Type string `json:"type"` // only "ephemeral" is supported
 }
 
 type anthropicNonStreamingResponse struct {
@@ -267,6 +340,11 @@ type anthropicStreamingResponseMessage struct {
 type anthropicMessagesResponseUsage struct {
 	InputTokens  int `json:"input_tokens"`
 	OutputTokens int `json:"output_tokens"`
+
+	// Only set if prompt caching was used. Tokens written to or read from the
+	// cache are not included in InputTokens.
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
 }
 
 type anthropicStreamingResponseTextBucket struct {
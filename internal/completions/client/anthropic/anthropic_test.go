@@ -222,6 +222,111 @@ func TestCompleteApiToMessages(t *testing.T) {
 	})
 }
 
+func TestPromptCaching(t *testing.T) {
+	var request *http.Request
+	mockClient := NewClient(&mockDoer{
+		func(r *http.Request) (*http.Response, error) {
+			request = r
+			return &http.Response{
+				StatusCode: http.StatusTooManyRequests,
+				Body:       io.NopCloser(bytes.NewReader([]byte("oh no, please slow down!"))),
+			}, nil
+		},
+	}, "", "", false, *tokenusage.NewManager())
+
+	complete := func(t *testing.T, messages []types.Message) {
+		t.Helper()
+		_, err := mockClient.Complete(context.Background(), log.Scoped("completions"), types.CompletionRequest{
+			Feature:         types.CompletionsFeatureChat,
+			ModelConfigInfo: types.ModelConfigInfo{},
+			Parameters: types.CompletionRequestParameters{
+				Messages: messages,
+			},
+			Version: types.CompletionsV1,
+		})
+		require.Error(t, err)
+		require.NotNil(t, request)
+	}
+
+	t.Run("with cache breakpoints", func(t *testing.T) {
+		complete(t, []types.Message{
+			{Speaker: "system", Text: "You are a helpful assistant.", CacheBreakpoint: true},
+			{Speaker: "human", Text: "Here is a large file.", CacheBreakpoint: true},
+			{Speaker: "assistant", Text: "Ok."},
+			{Speaker: "human", Text: "Summarize it."},
+		})
+
+		body, err := io.ReadAll(request.Body)
+		require.NoError(t, err)
+		autogold.Expect(`{"messages":[{"role":"user","content":[{"type":"text","text":"Here is a large file.","cache_control":{"type":"ephemeral"}}]},{"role":"assistant","content":[{"type":"text","text":"Ok."}]},{"role":"user","content":[{"type":"text","text":"Summarize it."}]}],"model":"","system":[{"type":"text","text":"You are a helpful assistant.","cache_control":{"type":"ephemeral"}}]}`).Equal(t, string(body))
+		assert.Equal(t, PromptCachingBeta, request.Header.Get("anthropic-beta"))
+	})
+
+	t.Run("without cache breakpoints", func(t *testing.T) {
+		complete(t, []types.Message{
+			{Speaker: "human", Text: "Summarize it."},
+		})
+
+		body, err := io.ReadAll(request.Body)
+		require.NoError(t, err)
+		autogold.Expect(`{"messages":[{"role":"user","content":[{"type":"text","text":"Summarize it."}]}],"model":""}`).Equal(t, string(body))
+		assert.Empty(t, request.Header.Get("anthropic-beta"))
+	})
+}
+
+func TestThinkingBudget(t *testing.T) {
+	var request *http.Request
+	mockClient := NewClient(&mockDoer{
+		func(r *http.Request) (*http.Response, error) {
+			request = r
+			return &http.Response{
+				StatusCode: http.StatusTooManyRequests,
+				Body:       io.NopCloser(bytes.NewReader([]byte("oh no, please slow down!"))),
+			}, nil
+		},
+	}, "", "", false, *tokenusage.NewManager())
+
+	_, err := mockClient.Complete(context.Background(), log.Scoped("completions"), types.CompletionRequest{
+		Feature:         types.CompletionsFeatureChat,
+		ModelConfigInfo: types.ModelConfigInfo{},
+		Parameters: types.CompletionRequestParameters{
+			Messages:          []types.Message{{Speaker: "human", Text: "Think about it."}},
+			MaxTokensToSample: 1000,
+			Temperature:       0.2,
+			TopK:              40,
+			ThinkingBudget:    2000,
+		},
+		Version: types.CompletionsV1,
+	})
+	require.Error(t, err)
+	require.NotNil(t, request)
+
+	body, err := io.ReadAll(request.Body)
+	require.NoError(t, err)
+	autogold.Expect(`{"messages":[{"role":"user","content":[{"type":"text","text":"Think about it."}]}],"model":"","max_tokens":3000,"thinking":{"type":"enabled","budget_tokens":2000}}`).Equal(t, string(body))
+}
+
+func TestThinkingBudget_BelowMinimum(t *testing.T) {
+	mockClient := NewClient(&mockDoer{
+		func(r *http.Request) (*http.Response, error) {
+			t.Fatal("unexpected request")
+			return nil, nil
+		},
+	}, "", "", false, *tokenusage.NewManager())
+
+	_, err := mockClient.Complete(context.Background(), log.Scoped("completions"), types.CompletionRequest{
+		Feature:         types.CompletionsFeatureChat,
+		ModelConfigInfo: types.ModelConfigInfo{},
+		Parameters: types.CompletionRequestParameters{
+			Messages:          []types.Message{{Speaker: "human", Text: "Think about it."}},
+			MaxTokensToSample: 1000,
+			ThinkingBudget:    minThinkingBudget - 1,
+		},
+		Version: types.CompletionsV1,
+	})
+	require.ErrorContains(t, err, "thinking budget")
+}
+
 func TestPinModel(t *testing.T) {
 	t.Run("Claude Instant", func(t *testing.T) {
 		assert.Equal(t, pinModel("claude-instant-1"), "claude-instant-1.2")
@@ -42,11 +42,29 @@ func toAnthropicMessages(messages []types.Message) ([]anthropicMessage, error) {
 			return nil, errors.New("message content cannot be empty")
 		}
 
+		content := anthropicMessageContent{Text: text, Type: "text"}
+		if message.CacheBreakpoint {
+			content.CacheControl = &anthropicCacheControl{Type: "ephemeral"}
+		}
+
 		anthropicMessages = append(anthropicMessages, anthropicMessage{
 			Role:    anthropicRole,
-			Content: []anthropicMessageContent{{Text: text, Type: "text"}},
+			Content: []anthropicMessageContent{content},
 		})
 	}
 
 	return anthropicMessages, nil
 }
+
+// hasCacheBreakpoints returns true if any of the messages marks the end of a
+// cacheable prompt prefix.
+func hasCacheBreakpoints(messages []anthropicMessage) bool {
+	for _, m := range messages {
+		for _, c := range m.Content {
+			if c.CacheControl != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -39,6 +39,10 @@ func NewClient(cli httpcli.Doer, endpoint, accessToken string, tokenManager toke
 
 const (
 	clientID = "sourcegraph/1.0"
+
+	// minThinkingBudget is the smallest thinking budget Anthropic models
+	// accept, also on Bedrock.
+	minThinkingBudget = 1024
 )
 
 type awsBedrockAnthropicCompletionStreamClient struct {
@@ -63,18 +67,18 @@ func (c *awsBedrockAnthropicCompletionStreamClient) Complete(
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
 		return nil, errors.Wrap(err, "decoding response")
 	}
-	if err := c.recordTokenUsage(request, response.Usage); err != nil {
-		return nil, err
-	}
-
 	completion := ""
 	for _, content := range response.Content {
 		completion += content.Text
 	}
+	if err := c.recordTokenUsage(request, response.Usage, completion); err != nil {
+		return nil, err
+	}
 
 	return &types.CompletionResponse{
-		Completion: completion,
-		StopReason: response.StopReason,
+		Completion:           completion,
+		StopReason:           response.StopReason,
+		NormalizedStopReason: types.NormalizeAnthropicStopReason(response.StopReason),
 	}, nil
 }
 
@@ -95,7 +99,16 @@ func (a *awsBedrockAnthropicCompletionStreamClient) Stream(
 	// the new incremental Anthropic API, but our clients still expect a full
 	// response in each event.
 	var totalCompletion string
-	var inputPromptTokens int
+	// usage is the token usage reported in the stream. The output tokens
+	// reported in message_delta events are cumulative, so it is recorded once
+	// the stream ends, even if the final event is missing. Usage that was never
+	// reported is estimated.
+	var usage bedrockAnthropicMessagesResponseUsage
+	defer func() {
+		if err := a.recordTokenUsage(request, usage, totalCompletion); err != nil {
+			logger.Warn("Failed to count tokens with the token manager %w ", log.Error(err))
+		}
+	}()
 	dec := eventstream.NewDecoder()
 	// Allocate a 1 MB buffer for decoding.
 	buf := make([]byte, 0, 1024*1024)
@@ -141,7 +154,7 @@ func (a *awsBedrockAnthropicCompletionStreamClient) Stream(
 		switch event.Type {
 		case "message_start":
 			if event.Message != nil && event.Message.Usage != nil {
-				inputPromptTokens = event.Message.Usage.InputTokens
+				usage.InputTokens = event.Message.Usage.InputTokens
 			}
 			continue
 		case "content_block_delta":
@@ -149,25 +162,20 @@ func (a *awsBedrockAnthropicCompletionStreamClient) Stream(
 				totalCompletion += event.Delta.Text
 			}
 		case "message_delta":
+			if event.Usage != nil {
+				usage.OutputTokens = event.Usage.OutputTokens
+			}
 			if event.Delta != nil {
 				stopReason = event.Delta.StopReason
-
-				// Build the usage data based on what we've seen.
-				usageData := bedrockAnthropicMessagesResponseUsage{
-					InputTokens:  inputPromptTokens,
-					OutputTokens: event.Usage.OutputTokens,
-				}
-				if err := a.recordTokenUsage(request, usageData); err != nil {
-					logger.Warn("Failed to count tokens with the token manager %w ", log.Error(err))
-				}
 			}
 		default:
 			continue
 		}
 		sentEvent = true
 		err = sendEvent(types.CompletionResponse{
-			Completion: totalCompletion,
-			StopReason: stopReason,
+			Completion:           totalCompletion,
+			StopReason:           stopReason,
+			NormalizedStopReason: types.NormalizeAnthropicStopReason(stopReason),
 		})
 		if err != nil {
 			return errors.Wrap(err, "sending event")
@@ -175,12 +183,12 @@ func (a *awsBedrockAnthropicCompletionStreamClient) Stream(
 	}
 }
 
-func (c *awsBedrockAnthropicCompletionStreamClient) recordTokenUsage(request types.CompletionRequest, usage bedrockAnthropicMessagesResponseUsage) error {
+func (c *awsBedrockAnthropicCompletionStreamClient) recordTokenUsage(request types.CompletionRequest, usage bedrockAnthropicMessagesResponseUsage, completion string) error {
 	label := fmt.Sprintf("anthropic/%s", request.ModelConfigInfo.Model.ModelName)
-	return c.tokenManager.UpdateTokenCountsFromModelUsage(
+	return c.tokenManager.UpdateReportedTokenCounts(
+		request, completion,
 		usage.InputTokens, usage.OutputTokens,
-		label, string(request.Feature),
-		tokenusage.AwsBedrock)
+		label, tokenusage.AwsBedrock)
 }
 
 type awsEventStreamPayload struct {
@@ -239,6 +247,19 @@ func (c *awsBedrockAnthropicCompletionStreamClient) makeRequest(ctx context.Cont
 		System:           system,
 		AnthropicVersion: "bedrock-2023-05-31",
 	}
+	if requestParams.ThinkingBudget > 0 {
+		if requestParams.ThinkingBudget < minThinkingBudget {
+			return nil, errors.Newf("thinking budget %d is less than the minimum of %d tokens", requestParams.ThinkingBudget, minThinkingBudget)
+		}
+		// Thinking tokens count towards max_tokens, so we add the budget to
+		// not cut the response short. Thinking is not compatible with
+		// changing the sampling parameters.
+		payload.Thinking = &bedrockAnthropicThinking{Type: "enabled", BudgetTokens: requestParams.ThinkingBudget}
+		payload.MaxTokens += requestParams.ThinkingBudget
+		payload.Temperature = 0
+		payload.TopP = 0
+		payload.TopK = 0
+	}
 
 	reqBody, err := json.Marshal(payload)
 	if err != nil {
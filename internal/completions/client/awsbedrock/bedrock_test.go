@@ -3,11 +3,15 @@ package awsbedrock
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/sourcegraph/log"
 	"github.com/stretchr/testify/require"
 
+	"github.com/sourcegraph/sourcegraph/internal/completions/tokenusage"
+	completionstypes "github.com/sourcegraph/sourcegraph/internal/completions/types"
 	"github.com/sourcegraph/sourcegraph/internal/conf/conftypes"
 	"github.com/sourcegraph/sourcegraph/internal/modelconfig/types"
 )
@@ -107,3 +111,27 @@ func TestAWSConfigOptsForKeyConfig(t *testing.T) {
 
 	})
 }
+
+type doerFunc func(*http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestThinkingBudget_BelowMinimum(t *testing.T) {
+	client := NewClient(doerFunc(func(*http.Request) (*http.Response, error) {
+		t.Fatal("unexpected request")
+		return nil, nil
+	}), "us-east-1", "key:secret", *tokenusage.NewManager())
+
+	_, err := client.Complete(context.Background(), log.Scoped("completions"), completionstypes.CompletionRequest{
+		Feature: completionstypes.CompletionsFeatureChat,
+		Parameters: completionstypes.CompletionRequestParameters{
+			Messages:          []completionstypes.Message{{Speaker: "human", Text: "Think about it."}},
+			MaxTokensToSample: 1000,
+			ThinkingBudget:    minThinkingBudget - 1,
+		},
+		Version: completionstypes.CompletionsV1,
+	})
+	require.ErrorContains(t, err, "thinking budget")
+}
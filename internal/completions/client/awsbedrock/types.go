@@ -38,6 +38,7 @@ type bedrockAnthropicCompletionsRequestParameters struct {
 	Stream        bool                      `json:"stream,omitempty"`
 	StopSequences []string                  `json:"stop_sequences,omitempty"`
 	MaxTokens     int                       `json:"max_tokens,omitempty"`
+	Thinking      *bedrockAnthropicThinking `json:"thinking,omitempty"`
 
 	// These are not accepted from the client an instead are only used to talk to the upstream LLM
 	// APIs directly (these do NOT need to be set when talking to Cody Gateway)
@@ -45,6 +46,12 @@ type bedrockAnthropicCompletionsRequestParameters struct {
 	AnthropicVersion string `json:"anthropic_version"`
 }
 
+// bedrockAnthropicThinking enables extended thinking of Claude models.
+type bedrockAnthropicThinking struct {
+	Type         string `json:"type"` // "enabled"
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
 type bedrockAnthropicMessage struct {
 	Role    string                           `json:"role"` // "user", "assistant", or "system" (only allowed for the first message)
 	Content []bedrockAnthropicMessageContent `json:"content"`
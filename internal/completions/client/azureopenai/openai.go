@@ -1,13 +1,16 @@
 package azureopenai
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"io"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -70,15 +73,16 @@ func GetAPIClient(endpoint, accessToken string) (CompletionsClient, error) {
 	defer apiClient.mu.Unlock()
 
 	// API Versions and docs https://learn.microsoft.com/en-us/azure/ai-services/openai/reference#completions
-	clientOpts := &azopenai.ClientOptions{
-		ClientOptions: azcore.ClientOptions{
-			Transport: apiVersionClient("2023-05-15"),
-		},
-	}
+	var transport httpcli.Doer = apiVersionClient("2023-05-15")
 	// Replace the HTTP Transport with the mock Doer if applicable.
 	// The Azure SDK's Transporter interface is identical to our cli.Doer's.
 	if MockAzureAPIClientTransport != nil {
-		clientOpts.ClientOptions.Transport = MockAzureAPIClientTransport
+		transport = MockAzureAPIClientTransport
+	}
+	clientOpts := &azopenai.ClientOptions{
+		ClientOptions: azcore.ClientOptions{
+			Transport: &reasoningEffortTransport{next: transport},
+		},
 	}
 
 	var err error
@@ -144,6 +148,9 @@ func (c *azureCompletionClient) Complete(
 	ctx context.Context,
 	log log.Logger,
 	request types.CompletionRequest) (*types.CompletionResponse, error) {
+	if err := validateReasoningEffort(request.Parameters.ReasoningEffort); err != nil {
+		return nil, err
+	}
 
 	switch request.Feature {
 	case types.CompletionsFeatureCode:
@@ -178,7 +185,7 @@ func doChatCompletionsAPIAutocomplete(
 	request types.CompletionRequest,
 	logger log.Logger,
 ) (*types.CompletionResponse, error) {
-	response, err := client.GetChatCompletions(ctx, getChatOptions(request), nil)
+	response, err := client.GetChatCompletions(withReasoningEffort(ctx, request), getChatOptions(request), nil)
 	if err != nil {
 		return nil, toStatusCodeError(err)
 	}
@@ -196,23 +203,15 @@ func doChatCompletionsAPIAutocomplete(
 	// perfect. (e.g. "gpt-4-32k-0314") If this is a problem, we should require server-side model config
 	// and allow the admin to specify the "real" model name, not the Azure OpenID one.
 	requestParams := request.Parameters
-	inputTokens, err := NumTokensFromAzureOpenAiMessages(requestParams.Messages, string(modelID))
-	if err != nil {
-		logger.Warn("Failed to count input tokens with the token manager %w ", log.Error(err))
-	}
-	outputTokens, err := NumTokensFromAzureOpenAiResponseString(*response.Choices[0].Delta.Content, string(modelID))
-	if err != nil {
-		logger.Warn("Failed to count input tokens with the token manager %w ", log.Error(err))
-	}
-	// Note: If we had an error calculating input/output tokens, that is unfortunate, the
-	// best thing we can do is record zero token usage which would be our hint to look at
-	// the logs for errors.
-	if err = recordTokenUsage(request, inputTokens, outputTokens); err != nil {
-		logger.Warn("Failed to count input tokens with the token manager %w ", log.Error(err))
+	inputTokens := countInputTokens(logger, requestParams.Messages, string(modelID))
+	outputTokens := countOutputTokens(logger, *response.Choices[0].Delta.Content, string(modelID))
+	if err := recordTokenUsage(request, inputTokens, outputTokens); err != nil {
+		logger.Warn("Failed to record token usage", log.Error(err))
 	}
 	return &types.CompletionResponse{
-		Completion: *response.Choices[0].Delta.Content,
-		StopReason: string(*response.Choices[0].FinishReason),
+		Completion:           *response.Choices[0].Delta.Content,
+		StopReason:           string(*response.Choices[0].FinishReason),
+		NormalizedStopReason: types.NormalizeOpenAIStopReason(string(*response.Choices[0].FinishReason)),
 	}, nil
 }
 
@@ -239,19 +238,10 @@ func doCompletionsAPIAutocomplete(
 	// BUG: This token counting will only be accurate IFF the Model ID matches the actual model name
 	// perfect. (e.g. "gpt-4-32k-0314") If this is a problem, we should require server-side model config
 	// and allow the admin to specify the "real" model name, not the Azure OpenID one.
-	inputTokens, err := NumTokensFromAzureOpenAiMessages(requestParams.Messages, string(modelID))
-	if err != nil {
-		logger.Warn("Failed to count input tokens with the token manager %w ", log.Error(err))
-	}
-	outputTokens, err := NumTokensFromAzureOpenAiResponseString(*response.Choices[0].Text, string(modelID))
-	if err != nil {
-		logger.Warn("Failed to count input tokens with the token manager %w ", log.Error(err))
-	}
-	// Note: If we had an error calculating input/output tokens, that is unfortunate, the
-	// best thing we can do is record zero token usage which would be our hint to look at
-	// the logs for errors.
-	if err = recordTokenUsage(request, inputTokens, outputTokens); err != nil {
-		logger.Warn("Failed to count input tokens with the token manager %w ", log.Error(err))
+	inputTokens := countInputTokens(logger, requestParams.Messages, string(modelID))
+	outputTokens := countOutputTokens(logger, *response.Choices[0].Text, string(modelID))
+	if err := recordTokenUsage(request, inputTokens, outputTokens); err != nil {
+		logger.Warn("Failed to record token usage", log.Error(err))
 	}
 	// Text and FinishReason are documented as REQUIRED but checking just to be safe
 	if !hasValidFirstCompletionsChoice(response.Choices) {
@@ -259,8 +249,9 @@ func doCompletionsAPIAutocomplete(
 		return &types.CompletionResponse{}, nil
 	}
 	return &types.CompletionResponse{
-		Completion: *response.Choices[0].Text,
-		StopReason: string(*response.Choices[0].FinishReason),
+		Completion:           *response.Choices[0].Text,
+		StopReason:           string(*response.Choices[0].FinishReason),
+		NormalizedStopReason: types.NormalizeOpenAIStopReason(string(*response.Choices[0].FinishReason)),
 	}, nil
 }
 
@@ -270,7 +261,7 @@ func completeChat(
 	request types.CompletionRequest,
 	logger log.Logger,
 ) (*types.CompletionResponse, error) {
-	response, err := client.GetChatCompletions(ctx, getChatOptions(request), nil)
+	response, err := client.GetChatCompletions(withReasoningEffort(ctx, request), getChatOptions(request), nil)
 	if err != nil {
 		return nil, toStatusCodeError(err)
 	}
@@ -287,23 +278,15 @@ func completeChat(
 	// BUG: This token counting will only be accurate IFF the Model ID matches the actual model name
 	// perfect. (e.g. "gpt-4-32k-0314") If this is a problem, we should require server-side model config
 	// and allow the admin to specify the "real" model name, not the Azure OpenID one.
-	inputTokens, err := NumTokensFromAzureOpenAiMessages(requestParams.Messages, string(modelID))
-	if err != nil {
-		logger.Warn("Failed to count input tokens with the token manager %w ", log.Error(err))
-	}
-	outputTokens, err := NumTokensFromAzureOpenAiResponseString(*response.Choices[0].Delta.Content, string(modelID))
-	if err != nil {
-		logger.Warn("Failed to count input tokens with the token manager %w ", log.Error(err))
-	}
-	// Note: If we had an error calculating input/output tokens, that is unfortunate, the
-	// best thing we can do is record zero token usage which would be our hint to look at
-	// the logs for errors.
+	inputTokens := countInputTokens(logger, requestParams.Messages, string(modelID))
+	outputTokens := countOutputTokens(logger, *response.Choices[0].Delta.Content, string(modelID))
 	if err := recordTokenUsage(request, inputTokens, outputTokens); err != nil {
-		logger.Warn("Failed to count input tokens with the token manager %w ", log.Error(err))
+		logger.Warn("Failed to record token usage", log.Error(err))
 	}
 	return &types.CompletionResponse{
-		Completion: *response.Choices[0].Delta.Content,
-		StopReason: string(*response.Choices[0].FinishReason),
+		Completion:           *response.Choices[0].Delta.Content,
+		StopReason:           string(*response.Choices[0].FinishReason),
+		NormalizedStopReason: types.NormalizeOpenAIStopReason(string(*response.Choices[0].FinishReason)),
 	}, nil
 }
 
@@ -313,6 +296,10 @@ func (c *azureCompletionClient) Stream(
 	request types.CompletionRequest,
 	sendEvent types.SendCompletionEvent,
 ) error {
+	if err := validateReasoningEffort(request.Parameters.ReasoningEffort); err != nil {
+		return err
+	}
+
 	switch request.Feature {
 	case types.CompletionsFeatureCode:
 		return streamAutocomplete(ctx, c.client, request, sendEvent, log)
@@ -324,12 +311,6 @@ func (c *azureCompletionClient) Stream(
 }
 
 func NumTokensFromAzureOpenAiMessages(messages []types.Message, model string) (numTokens int, error error) {
-	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
-	tkm, err := tiktoken.EncodingForModel(model)
-	if err != nil {
-		return 0, errors.Newf("tiktoken EncodingForModel error: %v", err)
-	}
-
 	var tokensPerMessage int
 	switch model {
 	case "gpt-3.5-turbo-0613",
@@ -338,21 +319,35 @@ func NumTokensFromAzureOpenAiMessages(messages []types.Message, model string) (n
 		"gpt-4-32k-0314",
 		"gpt-4-0613",
 		"gpt-4-32k-0613",
-		"gpt-4o":
+		"gpt-4o",
+		"gpt-4o-mini",
+		"gpt-4.1":
 		tokensPerMessage = 3
 	case "gpt-3.5-turbo-0301":
 		tokensPerMessage = 4 // every message follows <|im_start|>{role/name}\n{content}<|end|>\n
 	default:
+		// Newer models have to be checked first, as their names contain "gpt-4" too.
 		if strings.Contains(model, "gpt-3.5-turbo") {
 			return NumTokensFromAzureOpenAiMessages(messages, "gpt-3.5-turbo-0613")
+		} else if strings.Contains(model, "gpt-4o-mini") {
+			return NumTokensFromAzureOpenAiMessages(messages, "gpt-4o-mini")
+		} else if strings.Contains(model, "gpt-4o") {
+			return NumTokensFromAzureOpenAiMessages(messages, "gpt-4o")
+		} else if strings.Contains(model, "gpt-4.1") {
+			return NumTokensFromAzureOpenAiMessages(messages, "gpt-4.1")
 		} else if strings.Contains(model, "gpt-4") {
 			return NumTokensFromAzureOpenAiMessages(messages, "gpt-4-0613")
 		} else {
-			err = errors.Newf("num_tokens_from_messages() is not implemented for model %s. See https://github.com/openai/openai-python/blob/main/chatml.md for information on how messages are converted to tokens.", model)
+			err := errors.Newf("num_tokens_from_messages() is not implemented for model %s. See https://github.com/openai/openai-python/blob/main/chatml.md for information on how messages are converted to tokens.", model)
 			return 0, err
 		}
 	}
 
+	tkm, err := encodingForModel(model)
+	if err != nil {
+		return 0, err
+	}
+
 	for _, message := range messages {
 		numTokens += tokensPerMessage
 		numTokens += len(tkm.Encode(message.Text, nil, nil))
@@ -363,12 +358,55 @@ func NumTokensFromAzureOpenAiMessages(messages []types.Message, model string) (n
 }
 
 func NumTokensFromAzureOpenAiResponseString(response string, model string) (numTokens int, error error) {
+	tkm, err := encodingForModel(model)
+	if err != nil {
+		return 0, err
+	}
+	return len(tkm.Encode(response, nil, nil)), nil
+}
+
+// encodingForModel returns the tiktoken encoding used by model. Our version of
+// tiktoken doesn't know about all models that use the o200k_base encoding, so
+// we resolve those ourselves.
+func encodingForModel(model string) (*tiktoken.Tiktoken, error) {
 	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+	if strings.HasPrefix(model, "gpt-4o") || strings.HasPrefix(model, "gpt-4.1") {
+		return tiktoken.GetEncoding(tiktoken.MODEL_O200K_BASE)
+	}
 	tkm, err := tiktoken.EncodingForModel(model)
 	if err != nil {
-		return 0, errors.Newf("tiktoken EncodingForModel error: %v", err)
+		return nil, errors.Newf("tiktoken EncodingForModel error: %v", err)
 	}
-	return len(tkm.Encode(response, nil, nil)), nil
+	return tkm, nil
+}
+
+// countInputTokens counts the tokens of the given request messages. If they
+// can't be counted precisely, e.g. because tiktoken doesn't know the model, the
+// count is estimated, so that we don't record zero usage.
+func countInputTokens(logger log.Logger, messages []types.Message, model string) tokenusage.TokenCount {
+	n, err := NumTokensFromAzureOpenAiMessages(messages, model)
+	if err == nil {
+		return tokenusage.TokenCount{Tokens: n}
+	}
+	logger.Warn("Failed to count input tokens, falling back to an estimate", log.Error(err))
+
+	estimate := 0
+	for _, message := range messages {
+		estimate += tokenizer.EstimateTokens(message.Text) + tokenizer.EstimateTokens(message.Speaker)
+	}
+	return tokenusage.TokenCount{Tokens: estimate, Estimated: true}
+}
+
+// countOutputTokens counts the tokens of the given response, falling back to an
+// estimate like countInputTokens.
+func countOutputTokens(logger log.Logger, response string, model string) tokenusage.TokenCount {
+	n, err := NumTokensFromAzureOpenAiResponseString(response, model)
+	if err == nil {
+		return tokenusage.TokenCount{Tokens: n}
+	}
+	logger.Warn("Failed to count output tokens, falling back to an estimate", log.Error(err))
+
+	return tokenusage.TokenCount{Tokens: tokenizer.EstimateTokens(response), Estimated: true}
 }
 
 func streamAutocomplete(
@@ -397,7 +435,7 @@ func doStreamChatCompletionsAPI(
 	sendEvent types.SendCompletionEvent,
 	logger log.Logger,
 ) error {
-	resp, err := client.GetChatCompletionsStream(ctx, getChatOptions(request), nil)
+	resp, err := client.GetChatCompletionsStream(withReasoningEffort(ctx, request), getChatOptions(request), nil)
 	if err != nil {
 		return err
 	}
@@ -409,19 +447,10 @@ func doStreamChatCompletionsAPI(
 		entry, err := resp.ChatCompletionsStream.Read()
 		if errors.Is(err, io.EOF) {
 			requestParams := request.Parameters
-			inputTokens, err := NumTokensFromAzureOpenAiMessages(requestParams.Messages, string(modelID))
-			if err != nil {
-				logger.Warn("Failed to count input tokens with the token manager %w ", log.Error(err))
-			}
-			outputTokens, err := NumTokensFromAzureOpenAiResponseString(content, string(modelID))
-			if err != nil {
-				logger.Warn("Failed to count output tokens with the token manager %w ", log.Error(err))
-			}
-			// Note: If we had an error calculating input/output tokens, that is unfortunate, the
-			// best thing we can do is record zero token usage which would be our hint to look at
-			// the logs for errors.
-			if err = recordTokenUsage(request, inputTokens, outputTokens); err != nil {
-				logger.Warn("Failed to count tokens with the token manager %w ", log.Error(err))
+			inputTokens := countInputTokens(logger, requestParams.Messages, string(modelID))
+			outputTokens := countOutputTokens(logger, content, string(modelID))
+			if err := recordTokenUsage(request, inputTokens, outputTokens); err != nil {
+				logger.Warn("Failed to record token usage", log.Error(err))
 			}
 			return nil
 		}
@@ -436,8 +465,9 @@ func doStreamChatCompletionsAPI(
 				finish = string(*entry.Choices[0].FinishReason)
 			}
 			ev := types.CompletionResponse{
-				Completion: content,
-				StopReason: finish,
+				Completion:           content,
+				StopReason:           finish,
+				NormalizedStopReason: types.NormalizeOpenAIStopReason(finish),
 			}
 			err := sendEvent(ev)
 			if err != nil {
@@ -474,19 +504,10 @@ func doStreamCompletionsAPI(
 		// stream is done
 		if errors.Is(err, io.EOF) {
 			requestParams := request.Parameters
-			inputTokens, err := NumTokensFromAzureOpenAiMessages(requestParams.Messages, string(modelID))
-			if err != nil {
-				logger.Warn("Failed to count input tokens with the token manager %w ", log.Error(err))
-			}
-			outputTokens, err := NumTokensFromAzureOpenAiResponseString(content, string(modelID))
-			if err != nil {
-				logger.Warn("Failed to count output tokens with the token manager %w ", log.Error(err))
-			}
-			// Note: If we had an error calculating input/output tokens, that is unfortunate, the
-			// best thing we can do is record zero token usage which would be our hint to look at
-			// the logs for errors.
-			if err = recordTokenUsage(request, inputTokens, outputTokens); err != nil {
-				logger.Warn("Failed to count tokens with the token manager %w ", log.Error(err))
+			inputTokens := countInputTokens(logger, requestParams.Messages, string(modelID))
+			outputTokens := countOutputTokens(logger, content, string(modelID))
+			if err := recordTokenUsage(request, inputTokens, outputTokens); err != nil {
+				logger.Warn("Failed to record token usage", log.Error(err))
 			}
 			return nil
 		}
@@ -502,8 +523,9 @@ func doStreamCompletionsAPI(
 				finish = string(*entry.Choices[0].FinishReason)
 			}
 			ev := types.CompletionResponse{
-				Completion: content,
-				StopReason: finish,
+				Completion:           content,
+				StopReason:           finish,
+				NormalizedStopReason: types.NormalizeOpenAIStopReason(finish),
 			}
 			err := sendEvent(ev)
 			if err != nil {
@@ -521,7 +543,7 @@ func streamChat(
 	logger log.Logger,
 ) error {
 
-	resp, err := client.GetChatCompletionsStream(ctx, getChatOptions(request), nil)
+	resp, err := client.GetChatCompletionsStream(withReasoningEffort(ctx, request), getChatOptions(request), nil)
 	if err != nil {
 		return toStatusCodeError(err)
 	}
@@ -537,19 +559,10 @@ func streamChat(
 		// stream is done
 		if errors.Is(err, io.EOF) {
 			requestParams := request.Parameters
-			inputTokens, err := NumTokensFromAzureOpenAiMessages(requestParams.Messages, string(modelID))
-			if err != nil {
-				logger.Warn("Failed to count input tokens with the token manager %w ", log.Error(err))
-			}
-			outputTokens, err := NumTokensFromAzureOpenAiResponseString(content, string(modelID))
-			if err != nil {
-				logger.Warn("Failed to count output tokens with the token manager %w ", log.Error(err))
-			}
-			// Note: If we had an error calculating input/output tokens, that is unfortunate, the
-			// best thing we can do is record zero token usage which would be our hint to look at
-			// the logs for errors.
-			if err = recordTokenUsage(request, inputTokens, outputTokens); err != nil {
-				logger.Warn("Failed to count tokens with the token manager %w ", log.Error(err))
+			inputTokens := countInputTokens(logger, requestParams.Messages, string(modelID))
+			outputTokens := countOutputTokens(logger, content, string(modelID))
+			if err := recordTokenUsage(request, inputTokens, outputTokens); err != nil {
+				logger.Warn("Failed to record token usage", log.Error(err))
 			}
 			return nil
 		}
@@ -569,8 +582,9 @@ func streamChat(
 				finish = string(*entry.Choices[0].FinishReason)
 			}
 			ev := types.CompletionResponse{
-				Completion: content,
-				StopReason: finish,
+				Completion:           content,
+				StopReason:           finish,
+				NormalizedStopReason: types.NormalizeOpenAIStopReason(finish),
 			}
 			err := sendEvent(ev)
 			if err != nil {
@@ -700,7 +714,7 @@ func toStatusCodeError(err error) error {
 	return err
 }
 
-func recordTokenUsage(request types.CompletionRequest, inputTokens, outputTokens int) error {
+func recordTokenUsage(request types.CompletionRequest, inputTokens, outputTokens tokenusage.TokenCount) error {
 	// For Azure OpenAI the ModelName is tye Deployment ID, which isn't meaningful.
 	// So instead we use the model's ID, which is still opaque and user-defined. But will
 	// at least be more meaningful.
@@ -709,21 +723,100 @@ func recordTokenUsage(request types.CompletionRequest, inputTokens, outputTokens
 	tokenManager := tokenusage.NewManager()
 	label := tokenizer.AzureModel + "/" + string(modelID)
 	feature := string(request.Feature)
-	return tokenManager.UpdateTokenCountsFromModelUsage(
+	return tokenManager.UpdateTokenCounts(
 		inputTokens, outputTokens,
 		label, feature,
 		tokenusage.AzureOpenAI)
 }
 
+type reasoningEffortKey struct{}
+
+// withReasoningEffort registers the reasoning effort requested for a chat
+// completion on the context, see reasoningEffortTransport.
+func withReasoningEffort(ctx context.Context, request types.CompletionRequest) context.Context {
+	if request.Parameters.ReasoningEffort == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, reasoningEffortKey{}, request.Parameters.ReasoningEffort)
+}
+
+// validateReasoningEffort returns an error if effort is neither empty nor one
+// of the reasoning efforts supported by Azure OpenAI.
+func validateReasoningEffort(effort string) error {
+	switch effort {
+	case "", "low", "medium", "high":
+		return nil
+	default:
+		return errors.Errorf("invalid reasoning effort %q: must be one of low, medium or high", effort)
+	}
+}
+
+// reasoningAPIVersion is the first API version that supports the
+// reasoning_effort parameter.
+const reasoningAPIVersion = "2024-12-01-preview"
+
+type apiVersionKey struct{}
+
+// reasoningEffortTransport adds the reasoning_effort parameter registered with
+// withReasoningEffort to the body of chat completions requests. The version of
+// the Azure SDK we use predates reasoning models, so ChatCompletionsOptions
+// has no field for it. As older API versions reject the parameter, these
+// requests also use reasoningAPIVersion, see apiVersionRoundTripper.
+type reasoningEffortTransport struct {
+	next httpcli.Doer
+}
+
+func (t *reasoningEffortTransport) Do(req *http.Request) (*http.Response, error) {
+	effort, ok := req.Context().Value(reasoningEffortKey{}).(string)
+	if !ok || req.Body == nil || !strings.HasSuffix(req.URL.Path, "/chat/completions") {
+		return t.next.Do(req)
+	}
+
+	// The body is owned by the Azure SDK, which rewinds it on retries, so we
+	// must not close it.
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading request body")
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, errors.Wrap(err, "decoding request body")
+	}
+	if fields["reasoning_effort"], err = json.Marshal(effort); err != nil {
+		return nil, err
+	}
+	if body, err = json.Marshal(fields); err != nil {
+		return nil, errors.Wrap(err, "encoding request body")
+	}
+
+	newReq := req.Clone(context.WithValue(req.Context(), apiVersionKey{}, reasoningAPIVersion))
+	values := newReq.URL.Query()
+	values.Set("api-version", reasoningAPIVersion)
+	newReq.URL.RawQuery = values.Encode()
+	newReq.Body = io.NopCloser(bytes.NewReader(body))
+	newReq.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	newReq.ContentLength = int64(len(body))
+	newReq.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return t.next.Do(newReq)
+}
+
+// apiVersionRoundTripper sets the api-version of all requests to apiVersion,
+// unless the request needs a newer API version, see reasoningEffortTransport.
 type apiVersionRoundTripper struct {
 	rt         http.RoundTripper
 	apiVersion string
 }
 
 func (rt *apiVersionRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	apiVersion := rt.apiVersion
+	if v, ok := req.Context().Value(apiVersionKey{}).(string); ok {
+		apiVersion = v
+	}
 	newReq := req.Clone(req.Context())
 	values := newReq.URL.Query()
-	values.Set("api-version", rt.apiVersion)
+	values.Set("api-version", apiVersion)
 	newReq.URL.RawQuery = values.Encode()
 	return rt.rt.RoundTrip(newReq)
 }
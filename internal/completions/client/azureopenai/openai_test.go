@@ -3,13 +3,17 @@ package azureopenai
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/ai/azopenai"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/hexops/autogold/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -18,6 +22,7 @@ import (
 
 	"github.com/sourcegraph/sourcegraph/internal/completions/tokenusage"
 	"github.com/sourcegraph/sourcegraph/internal/completions/types"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
@@ -157,3 +162,228 @@ func TestGenericErr(t *testing.T) {
 		assert.False(t, ok)
 	})
 }
+
+func TestCountTokens(t *testing.T) {
+	logger := log.Scoped("completions")
+	messages := []types.Message{{Speaker: types.HUMAN_MESSAGE_SPEAKER, Text: "Hello, world!"}}
+
+	t.Run("precise", func(t *testing.T) {
+		input := countInputTokens(logger, messages, "gpt-4-0613")
+		assert.False(t, input.Estimated)
+		assert.Positive(t, input.Tokens)
+
+		output := countOutputTokens(logger, "The answer is 42.", "gpt-4-0613")
+		assert.False(t, output.Estimated)
+		assert.Positive(t, output.Tokens)
+	})
+
+	t.Run("tokenizer fails", func(t *testing.T) {
+		// tiktoken doesn't know this model, so we fall back to estimating.
+		_, err := NumTokensFromAzureOpenAiMessages(messages, "my-custom-deployment")
+		require.Error(t, err)
+
+		input := countInputTokens(logger, messages, "my-custom-deployment")
+		assert.Equal(t, tokenusage.TokenCount{Tokens: 6, Estimated: true}, input)
+
+		output := countOutputTokens(logger, "The answer is 42.", "my-custom-deployment")
+		assert.Equal(t, tokenusage.TokenCount{Tokens: 5, Estimated: true}, output)
+	})
+}
+
+func TestNumTokensFromAzureOpenAiMessages(t *testing.T) {
+	messages := []types.Message{
+		{Speaker: types.SYSTEM_MESSAGE_SPEAKER, Text: "You are Cody, an AI coding assistant from Sourcegraph."},
+		{Speaker: types.HUMAN_MESSAGE_SPEAKER, Text: `func main() { fmt.Println("こんにちは、世界") }`},
+	}
+
+	tests := []struct {
+		model string
+		want  int
+	}{
+		// cl100k_base
+		{model: "gpt-3.5-turbo-0301", want: 39},
+		{model: "gpt-4-0613", want: 37},
+		{model: "gpt-4-turbo", want: 37},
+		// o200k_base
+		{model: "gpt-4o", want: 35},
+		{model: "gpt-4o-2024-05-13", want: 35},
+		{model: "gpt-4o-mini", want: 35},
+		{model: "gpt-4o-mini-2024-07-18", want: 35},
+		{model: "gpt-4.1", want: 35},
+		{model: "gpt-4.1-2025-04-14", want: 35},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			got, err := NumTokensFromAzureOpenAiMessages(messages, tt.model)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var retryAfter string
+	MockAzureAPIClientTransport = httpcli.DoerFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Retry-After": []string{retryAfter}},
+			Body:       io.NopCloser(bytes.NewReader([]byte("too many requests"))),
+			Request:    req,
+		}, nil
+	})
+	t.Cleanup(func() {
+		MockAzureAPIClientTransport = nil
+		apiClient.mu.Lock()
+		apiClient.client = nil
+		apiClient.mu.Unlock()
+	})
+
+	tokenManager := tokenusage.NewManager()
+	client, err := NewClient(GetAPIClient, "https://example.openai.azure.com", "token", *tokenManager)
+	require.NoError(t, err)
+
+	complete := func(t *testing.T) *types.ErrStatusNotOK {
+		// The Azure SDK retries rate limited requests on its own, honoring
+		// Retry-After. We want to see the error right away.
+		ctx := policy.WithRetryOptions(context.Background(), policy.RetryOptions{MaxRetries: -1})
+		_, err := client.Complete(ctx, log.Scoped("completions"), types.CompletionRequest{
+			Feature: types.CompletionsFeatureChat,
+			Parameters: types.CompletionRequestParameters{
+				Messages: []types.Message{{Speaker: types.HUMAN_MESSAGE_SPEAKER, Text: "Hello"}},
+			},
+			Version: types.CompletionsVersionLegacy,
+		})
+		errNotOK, ok := types.IsErrStatusNotOK(err)
+		require.True(t, ok, "expected ErrStatusNotOK, got %v", err)
+		assert.Equal(t, http.StatusTooManyRequests, errNotOK.StatusCode)
+		return errNotOK
+	}
+
+	t.Run("delta-seconds", func(t *testing.T) {
+		retryAfter = "30"
+		errNotOK := complete(t)
+		assert.WithinDuration(t, time.Now().Add(30*time.Second), errNotOK.RetryAfter, 5*time.Second)
+	})
+
+	t.Run("HTTP-date", func(t *testing.T) {
+		want := time.Now().Add(time.Minute).UTC().Truncate(time.Second)
+		retryAfter = want.Format(http.TimeFormat)
+		errNotOK := complete(t)
+		assert.True(t, want.Equal(errNotOK.RetryAfter), "want %s, got %s", want, errNotOK.RetryAfter)
+
+		rec := httptest.NewRecorder()
+		errNotOK.WriteHeader(rec)
+		assert.Equal(t, retryAfter, rec.Header().Get("Retry-After"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		retryAfter = "soon"
+		errNotOK := complete(t)
+		assert.True(t, errNotOK.RetryAfter.IsZero())
+	})
+}
+
+func TestReasoningEffort(t *testing.T) {
+	var (
+		body       map[string]any
+		apiVersion string
+	)
+	MockAzureAPIClientTransport = httpcli.DoerFunc(func(req *http.Request) (*http.Response, error) {
+		body = nil
+		apiVersion = req.URL.Query().Get("api-version")
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"choices":[]}`))),
+			Request:    req,
+		}, nil
+	})
+	t.Cleanup(func() {
+		MockAzureAPIClientTransport = nil
+		apiClient.mu.Lock()
+		apiClient.client = nil
+		apiClient.mu.Unlock()
+	})
+
+	tokenManager := tokenusage.NewManager()
+	client, err := NewClient(GetAPIClient, "https://example.openai.azure.com", "token", *tokenManager)
+	require.NoError(t, err)
+
+	request := func(effort string) types.CompletionRequest {
+		return types.CompletionRequest{
+			Feature: types.CompletionsFeatureChat,
+			Parameters: types.CompletionRequestParameters{
+				Messages:        []types.Message{{Speaker: types.HUMAN_MESSAGE_SPEAKER, Text: "Hello"}},
+				ReasoningEffort: effort,
+			},
+			Version: types.CompletionsVersionLegacy,
+		}
+	}
+
+	t.Run("set", func(t *testing.T) {
+		_, err := client.Complete(context.Background(), log.Scoped("completions"), request("high"))
+		require.NoError(t, err)
+		assert.Equal(t, "high", body["reasoning_effort"])
+		assert.Contains(t, body, "messages")
+		assert.Equal(t, reasoningAPIVersion, apiVersion)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		body = nil
+		_, err := client.Complete(context.Background(), log.Scoped("completions"), request("extreme"))
+		require.ErrorContains(t, err, `invalid reasoning effort "extreme"`)
+		assert.Nil(t, body)
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		_, err := client.Complete(context.Background(), log.Scoped("completions"), request(""))
+		require.NoError(t, err)
+		assert.NotContains(t, body, "reasoning_effort")
+		assert.Contains(t, body, "messages")
+		assert.NotEqual(t, reasoningAPIVersion, apiVersion)
+	})
+}
+
+func TestAPIVersionRoundTripper(t *testing.T) {
+	var apiVersion string
+	rt := &apiVersionRoundTripper{
+		rt: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			apiVersion = req.URL.Query().Get("api-version")
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		}),
+		apiVersion: "2023-05-15",
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "https://example.openai.azure.com/openai/deployments/gpt/chat/completions?api-version=2024-02-01", nil)
+	require.NoError(t, err)
+	_, err = rt.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, "2023-05-15", apiVersion)
+
+	_, err = rt.RoundTrip(req.WithContext(context.WithValue(req.Context(), apiVersionKey{}, reasoningAPIVersion)))
+	require.NoError(t, err)
+	assert.Equal(t, reasoningAPIVersion, apiVersion)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestNormalizeStopReason(t *testing.T) {
+	for raw, want := range map[string]types.StopReason{
+		"": "",
+		string(azopenai.CompletionsFinishReasonStopped):           types.StopReasonEndTurn,
+		string(azopenai.CompletionsFinishReasonTokenLimitReached): types.StopReasonMaxTokens,
+		string(azopenai.CompletionsFinishReasonContentFiltered):   types.StopReasonContentFilter,
+		string(azopenai.CompletionsFinishReasonToolCalls):         types.StopReasonToolUse,
+		string(azopenai.CompletionsFinishReasonFunctionCall):      types.StopReasonToolUse,
+		"something_new": types.StopReasonOther,
+	} {
+		assert.Equal(t, want, types.NormalizeOpenAIStopReason(raw), "stop reason %q", raw)
+	}
+}
@@ -88,6 +88,14 @@ func getAPIProvider(modelConfigInfo types.ModelConfigInfo) (types.CompletionsCli
 		}
 	}
 
+	// Self-hosted gateways exposing the OpenAI API.
+	if compatibleCfg := ssConfig.OpenAICompatible; compatibleCfg != nil {
+		client := openai.NewCompatibleClient(
+			httpcli.UncachedExternalDoer, compatibleCfg.Endpoint, compatibleCfg.AccessToken,
+			compatibleCfg.ChatCompletionsPath, compatibleCfg.CompletionsPath, *tokenManager)
+		return client, nil
+	}
+
 	// The "Sourcegraph" provider, AKA Cody Gateway.
 	if sgProviderCfg := ssConfig.SourcegraphProvider; sgProviderCfg != nil {
 		client, err := codygateway.NewClient(
@@ -3,8 +3,11 @@ package codygateway
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/sourcegraph/log"
 	"go.opentelemetry.io/otel/attribute"
@@ -15,14 +18,41 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/completions/client/anthropic"
 	"github.com/sourcegraph/sourcegraph/internal/completions/client/fireworks"
 	"github.com/sourcegraph/sourcegraph/internal/completions/client/google"
+	"github.com/sourcegraph/sourcegraph/internal/completions/client/mistral"
 	"github.com/sourcegraph/sourcegraph/internal/completions/client/openai"
 	"github.com/sourcegraph/sourcegraph/internal/completions/tokenusage"
 	"github.com/sourcegraph/sourcegraph/internal/completions/types"
 	"github.com/sourcegraph/sourcegraph/internal/conf/conftypes"
+	"github.com/sourcegraph/sourcegraph/internal/env"
 	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	modelconfigSDK "github.com/sourcegraph/sourcegraph/internal/modelconfig/types"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
+var (
+	// Code completions are latency sensitive, a result that arrives late is
+	// useless to the user so we'd rather fail fast.
+	codeCompletionsTimeout = env.MustGetDuration("CODY_GATEWAY_CODE_COMPLETIONS_TIMEOUT", 15*time.Second, "Timeout for code completion requests sent to Cody Gateway, including reading the response. Set to 0 to disable.")
+	// Chat responses are streamed and can legitimately take minutes.
+	chatCompletionsTimeout = env.MustGetDuration("CODY_GATEWAY_CHAT_COMPLETIONS_TIMEOUT", 5*time.Minute, "Timeout for chat completion requests sent to Cody Gateway, including reading the response. Set to 0 to disable.")
+	// debugRouting enables logging which provider client and Cody Gateway
+	// endpoint a request is routed to, for diagnosing routing issues.
+	debugRouting = env.MustGetBool("CODY_GATEWAY_DEBUG_ROUTING", false, "Log which provider and Cody Gateway endpoint completion requests are routed to, at debug level.")
+)
+
+// requestTimeout returns the timeout for requests of the given feature, or 0
+// if no timeout should be applied.
+func requestTimeout(feature types.CompletionsFeature) time.Duration {
+	switch feature {
+	case types.CompletionsFeatureCode:
+		return codeCompletionsTimeout
+	case types.CompletionsFeatureChat:
+		return chatCompletionsTimeout
+	default:
+		return 0
+	}
+}
+
 // NewClient instantiates a completions provider backed by Sourcegraph's managed
 // Cody Gateway service.
 func NewClient(cli httpcli.Doer, endpoint, accessToken string, tokenManager tokenusage.Manager) (types.CompletionsClient, error) {
@@ -73,10 +103,33 @@ func overwriteErrSource(err error) error {
 	}
 	if statusErr, ok := types.IsErrStatusNotOK(err); ok {
 		statusErr.Source = "Sourcegraph Cody Gateway"
+		// Let callers know how long to back off when Cody Gateway rate limits
+		// this instance.
+		if statusErr.StatusCode == http.StatusTooManyRequests {
+			statusErr.RateLimit = parseRateLimit(statusErr.Header())
+		}
 	}
 	return err
 }
 
+// rateLimitHeaders are the headers Cody Gateway reports the rate limit state of
+// an instance in.
+var rateLimitHeaders = []string{"x-ratelimit-limit", "x-ratelimit-remaining", "retry-after"}
+
+// parseRateLimit returns the rate limit given by the x-ratelimit-* headers, or
+// nil if they are missing or invalid.
+func parseRateLimit(header http.Header) *types.RateLimit {
+	limit, err := strconv.Atoi(header.Get("x-ratelimit-limit"))
+	if err != nil {
+		return nil
+	}
+	remaining, err := strconv.Atoi(header.Get("x-ratelimit-remaining"))
+	if err != nil {
+		return nil
+	}
+	return &types.RateLimit{Limit: limit, Remaining: remaining}
+}
+
 func (c *codyGatewayClient) clientForParams(logger log.Logger, feature types.CompletionsFeature, request *types.CompletionRequest) (types.CompletionsClient, error) {
 	model := request.ModelConfigInfo.Model
 	logger.Info(
@@ -113,29 +166,36 @@ func (c *codyGatewayClient) clientForParams(logger log.Logger, feature types.Com
 	// the codyGatewayClient's access token and endpoint.
 	switch conftypes.CompletionsProviderName(providerID) {
 	case conftypes.CompletionsProviderNameAnthropic:
-		doer := gatewayDoer(
-			c.upstream, feature, c.gatewayURL, c.accessToken,
-			"/v1/completions/anthropic-messages")
+		path := "/v1/completions/anthropic-messages"
+		logRouting(logger, providerID, model, path)
+		doer := gatewayDoer(c.upstream, feature, c.gatewayURL, c.accessToken, path)
 		client := anthropic.NewClient(doer, "", "", true, c.tokenManager)
 		return client, nil
 
 	case conftypes.CompletionsProviderNameFireworks:
-		doer := gatewayDoer(
-			c.upstream, feature, c.gatewayURL, c.accessToken,
-			"/v1/completions/fireworks")
+		path := "/v1/completions/fireworks"
+		logRouting(logger, providerID, model, path)
+		doer := gatewayDoer(c.upstream, feature, c.gatewayURL, c.accessToken, path)
 		client := fireworks.NewClient(doer, "", "")
 		return client, nil
 
 	case conftypes.CompletionsProviderNameGoogle:
-		doer := gatewayDoer(
-			c.upstream, feature, c.gatewayURL, c.accessToken,
-			"/v1/completions/google")
+		path := "/v1/completions/google"
+		logRouting(logger, providerID, model, path)
+		doer := gatewayDoer(c.upstream, feature, c.gatewayURL, c.accessToken, path)
 		return google.NewClient(doer, "", "", true)
 
+	case conftypes.CompletionsProviderNameMistral:
+		path := "/v1/completions/mistral"
+		logRouting(logger, providerID, model, path)
+		doer := gatewayDoer(c.upstream, feature, c.gatewayURL, c.accessToken, path)
+		client := mistral.NewClient(doer, "", "", c.tokenManager)
+		return client, nil
+
 	case conftypes.CompletionsProviderNameOpenAI:
-		doer := gatewayDoer(
-			c.upstream, feature, c.gatewayURL, c.accessToken,
-			"/v1/completions/openai")
+		path := "/v1/completions/openai"
+		logRouting(logger, providerID, model, path)
+		doer := gatewayDoer(c.upstream, feature, c.gatewayURL, c.accessToken, path)
 		client := openai.NewClient(doer, "", "", c.tokenManager)
 		return client, nil
 
@@ -144,6 +204,7 @@ func (c *codyGatewayClient) clientForParams(logger log.Logger, feature types.Com
 			conftypes.CompletionsProviderNameAnthropic,
 			conftypes.CompletionsProviderNameFireworks,
 			conftypes.CompletionsProviderNameGoogle,
+			conftypes.CompletionsProviderNameMistral,
 			conftypes.CompletionsProviderNameOpenAI,
 		}
 		return nil, errors.Newf(
@@ -152,15 +213,50 @@ func (c *codyGatewayClient) clientForParams(logger log.Logger, feature types.Com
 	}
 }
 
+// logRouting records which provider and Cody Gateway endpoint a request for
+// model is routed to, if debugRouting is enabled.
+func logRouting(logger log.Logger, providerID modelconfigSDK.ProviderID, model modelconfigSDK.Model, path string) {
+	if !debugRouting {
+		return
+	}
+	logger.Debug(
+		"routing completions request via Cody Gateway",
+		log.String("provider", string(providerID)),
+		log.String("mref", string(model.ModelRef)),
+		log.String("modelName", model.ModelName),
+		log.String("gatewayPath", path))
+}
+
 type roundTripperFunc func(*http.Request) (*http.Response, error)
 
 func (rt roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 	return rt(req)
 }
 
+// cancelOnCloseBody cancels the context of a request once its response body
+// is closed.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
 // gatewayDoer redirects requests to Cody Gateway with all prerequisite headers.
+// Requests are subject to the timeout for the given feature, which covers the
+// whole request until the response body is closed.
 func gatewayDoer(upstream httpcli.Doer, feature types.CompletionsFeature, gatewayURL *url.URL, accessToken, path string) httpcli.Doer {
 	return httpcli.DoerFunc(func(req *http.Request) (*http.Response, error) {
+		cancel := context.CancelFunc(func() {})
+		if timeout := requestTimeout(feature); timeout > 0 {
+			var ctx context.Context
+			ctx, cancel = context.WithTimeout(req.Context(), timeout)
+			req = req.WithContext(ctx)
+		}
+
 		req.Host = gatewayURL.Host
 		req.URL = gatewayURL
 		req.URL.Path = path
@@ -185,9 +281,23 @@ func gatewayDoer(upstream httpcli.Doer, feature types.CompletionsFeature, gatewa
 				// https://github.com/open-telemetry/opentelemetry-specification/issues/454
 				span.SetAttributes(attribute.String("cody-gateway.x-trace", resp.Header.Get("X-Trace")))
 				span.SetAttributes(attribute.String("cody-gateway.x-trace-span", resp.Header.Get("X-Trace-Span")))
+				// Record how close this instance is to its Cody Gateway quota.
+				for _, h := range rateLimitHeaders {
+					if v := resp.Header.Get(h); v != "" {
+						span.SetAttributes(attribute.String("cody-gateway."+h, v))
+					}
+				}
 			}
 		}
 
+		// The response body is read after we return, so only release the
+		// request's context once the caller is done with it.
+		if err != nil || resp == nil || resp.Body == nil {
+			cancel()
+		} else {
+			resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+		}
+
 		return resp, err
 	})
 }
@@ -1,14 +1,29 @@
 package codygateway
 
 import (
+	"context"
+	"io"
+	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hexops/autogold/v2"
+	"github.com/sourcegraph/log"
+	"github.com/sourcegraph/log/logtest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	oteltracesdk "go.opentelemetry.io/otel/sdk/trace"
+	oteltracetest "go.opentelemetry.io/otel/sdk/trace/tracetest"
 
+	"github.com/sourcegraph/sourcegraph/internal/codygateway"
+	"github.com/sourcegraph/sourcegraph/internal/completions/tokenusage"
 	"github.com/sourcegraph/sourcegraph/internal/completions/types"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	modelconfigSDK "github.com/sourcegraph/sourcegraph/internal/modelconfig/types"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
@@ -26,3 +41,277 @@ func TestOverwriteErrorSource(t *testing.T) {
 	assert.NoError(t, overwriteErrSource(nil))
 	assert.Equal(t, "asdf", overwriteErrSource(errors.New("asdf")).Error())
 }
+
+func TestGatewayDoerTimeout(t *testing.T) {
+	gatewayURL, err := url.Parse("https://cody-gateway.example.com")
+	require.NoError(t, err)
+
+	// doRequest sends a request through gatewayDoer and returns the time left
+	// until the deadline of the request's context as seen by the upstream doer.
+	doRequest := func(t *testing.T, feature types.CompletionsFeature) (time.Duration, bool) {
+		var (
+			timeLeft    time.Duration
+			hasDeadline bool
+		)
+		upstream := httpcli.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			var deadline time.Time
+			deadline, hasDeadline = req.Context().Deadline()
+			timeLeft = time.Until(deadline)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     make(http.Header),
+				Body:       io.NopCloser(strings.NewReader("ok")),
+			}, nil
+		})
+
+		req, err := http.NewRequest(http.MethodPost, "https://example.com", nil)
+		require.NoError(t, err)
+		resp, err := gatewayDoer(upstream, feature, gatewayURL, "token", "/v1/completions/anthropic-messages").Do(req)
+		require.NoError(t, err)
+
+		// The response body must still be readable after Do returned.
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "ok", string(body))
+		require.NoError(t, resp.Body.Close())
+
+		return timeLeft, hasDeadline
+	}
+
+	t.Run("code completions get the short deadline", func(t *testing.T) {
+		timeLeft, ok := doRequest(t, types.CompletionsFeatureCode)
+		require.True(t, ok)
+		assert.LessOrEqual(t, timeLeft, codeCompletionsTimeout)
+		assert.Greater(t, timeLeft, codeCompletionsTimeout-5*time.Second)
+	})
+
+	t.Run("chat completions get the long deadline", func(t *testing.T) {
+		timeLeft, ok := doRequest(t, types.CompletionsFeatureChat)
+		require.True(t, ok)
+		assert.LessOrEqual(t, timeLeft, chatCompletionsTimeout)
+		assert.Greater(t, timeLeft, chatCompletionsTimeout-5*time.Second)
+		assert.Greater(t, timeLeft, codeCompletionsTimeout)
+	})
+
+	t.Run("unknown features get no deadline", func(t *testing.T) {
+		_, ok := doRequest(t, "unknown")
+		assert.False(t, ok)
+	})
+}
+
+func TestClientForParamsRoutingLog(t *testing.T) {
+	gatewayURL, err := url.Parse("https://cody-gateway.example.com")
+	require.NoError(t, err)
+	c := &codyGatewayClient{gatewayURL: gatewayURL, accessToken: "token"}
+
+	routingLogs := func(t *testing.T, mref modelconfigSDK.ModelRef) logtest.CapturedLogs {
+		logger, exportLogs := logtest.Captured(t)
+		_, err := c.clientForParams(logger, types.CompletionsFeatureChat, &types.CompletionRequest{
+			ModelConfigInfo: types.ModelConfigInfo{
+				Model: modelconfigSDK.Model{
+					ModelRef:  mref,
+					ModelName: "some-model",
+				},
+			},
+		})
+		require.NoError(t, err)
+		return exportLogs().Filter(func(l logtest.CapturedLog) bool {
+			return l.Message == "routing completions request via Cody Gateway"
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.Empty(t, routingLogs(t, "fireworks::v1::starcoder"))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		debugRouting = true
+		t.Cleanup(func() { debugRouting = false })
+
+		for mref, want := range map[modelconfigSDK.ModelRef]map[string]any{
+			"anthropic::2023-06-01::claude-3-sonnet": {
+				"provider":    "anthropic",
+				"gatewayPath": "/v1/completions/anthropic-messages",
+			},
+			"fireworks::v1::starcoder": {
+				"provider":    "fireworks",
+				"gatewayPath": "/v1/completions/fireworks",
+			},
+			"google::v1::gemini-1.5-pro": {
+				"provider":    "google",
+				"gatewayPath": "/v1/completions/google",
+			},
+			"mistral::v1::mixtral-8x7b-instruct": {
+				"provider":    "mistral",
+				"gatewayPath": "/v1/completions/mistral",
+			},
+			"openai::2024-02-01::gpt-4o": {
+				"provider":    "openai",
+				"gatewayPath": "/v1/completions/openai",
+			},
+		} {
+			logs := routingLogs(t, mref)
+			require.Len(t, logs, 1, mref)
+			assert.Equal(t, log.LevelDebug, logs[0].Level)
+			assert.Equal(t, want["provider"], logs[0].Fields["provider"], mref)
+			assert.Equal(t, want["gatewayPath"], logs[0].Fields["gatewayPath"], mref)
+			assert.Equal(t, string(mref), logs[0].Fields["mref"])
+		}
+	})
+}
+
+func TestClientForParamsMistral(t *testing.T) {
+	gatewayURL, err := url.Parse("https://cody-gateway.example.com")
+	require.NoError(t, err)
+
+	var got *http.Request
+	upstream := httpcli.DoerFunc(func(req *http.Request) (*http.Response, error) {
+		got = req
+		return &http.Response{
+			StatusCode: http.StatusInternalServerError,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil
+	})
+	c, err := NewClient(upstream, gatewayURL.String(), "token", tokenusage.Manager{})
+	require.NoError(t, err)
+
+	_, err = c.Complete(context.Background(), logtest.Scoped(t), types.CompletionRequest{
+		Feature: types.CompletionsFeatureChat,
+		ModelConfigInfo: types.ModelConfigInfo{
+			Model: modelconfigSDK.Model{
+				ModelRef:  "mistral::v1::mixtral-8x7b-instruct",
+				ModelName: "mixtral-8x7b-instruct",
+			},
+		},
+		Parameters: types.CompletionRequestParameters{
+			Messages: []types.Message{{Speaker: types.HUMAN_MESSAGE_SPEAKER, Text: "hello"}},
+		},
+	})
+	statusErr, ok := types.IsErrStatusNotOK(err)
+	require.True(t, ok)
+	assert.Equal(t, "Sourcegraph Cody Gateway", statusErr.Source)
+
+	require.NotNil(t, got)
+	assert.Equal(t, "cody-gateway.example.com", got.URL.Host)
+	assert.Equal(t, "/v1/completions/mistral", got.URL.Path)
+	assert.Equal(t, "Bearer token", got.Header.Get("Authorization"))
+	assert.Equal(t, string(types.CompletionsFeatureChat), got.Header.Get(codygateway.FeatureHeaderName))
+}
+
+func TestStreamUsage(t *testing.T) {
+	gatewayURL, err := url.Parse("https://cody-gateway.example.com")
+	require.NoError(t, err)
+
+	upstream := httpcli.DoerFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     make(http.Header),
+			Body: io.NopCloser(strings.NewReader(strings.Join([]string{
+				`data: {"type": "message_start", "message": {"usage": {"input_tokens": 25, "output_tokens": 1}}}`,
+				`data: {"type": "content_block_delta", "index": 0, "delta": {"type": "text_delta", "text": "He"}}`,
+				`data: {"type": "message_delta", "delta": {}, "usage": {"output_tokens": 2}}`,
+				`data: {"type": "content_block_delta", "index": 0, "delta": {"type": "text_delta", "text": "llo"}}`,
+				`data: {"type": "message_delta", "delta": {"stop_reason": "end_turn"}, "usage": {"output_tokens": 3}}`,
+				`data: {"type": "message_stop"}`,
+			}, "\n\n"))),
+		}, nil
+	})
+	c, err := NewClient(upstream, gatewayURL.String(), "token", *tokenusage.NewManager())
+	require.NoError(t, err)
+
+	var events []types.CompletionResponse
+	err = c.Stream(context.Background(), logtest.Scoped(t), types.CompletionRequest{
+		Feature: types.CompletionsFeatureChat,
+		ModelConfigInfo: types.ModelConfigInfo{
+			Model: modelconfigSDK.Model{
+				ModelRef:  "anthropic::2023-06-01::claude-3-sonnet",
+				ModelName: "claude-3-sonnet",
+			},
+		},
+	}, func(event types.CompletionResponse) error {
+		events = append(events, event)
+		return nil
+	})
+	require.NoError(t, err)
+
+	var usages []*types.TokenUsage
+	for _, event := range events {
+		usages = append(usages, event.Usage)
+	}
+	assert.Equal(t, []*types.TokenUsage{
+		{InputTokens: 25, OutputTokens: 1},
+		{InputTokens: 25, OutputTokens: 2},
+		nil,
+		{InputTokens: 25, OutputTokens: 3},
+	}, usages)
+	assert.Equal(t, types.StopReasonEndTurn, events[len(events)-1].NormalizedStopReason)
+}
+
+func TestGatewayRateLimit(t *testing.T) {
+	gatewayURL, err := url.Parse("https://cody-gateway.example.com")
+	require.NoError(t, err)
+
+	// complete sends a completions request through Cody Gateway, which responds
+	// with the given status code and rate limit headers. It returns the attributes
+	// recorded on the span of the request and the error.
+	complete := func(t *testing.T, statusCode int, header http.Header) ([]attribute.KeyValue, error) {
+		upstream := httpcli.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: statusCode,
+				Header:     header,
+				Body:       io.NopCloser(strings.NewReader(`{"choices": [{"text": "hello"}]}`)),
+			}, nil
+		})
+		c, err := NewClient(upstream, gatewayURL.String(), "token", *tokenusage.NewManager())
+		require.NoError(t, err)
+
+		recorder := oteltracetest.NewSpanRecorder()
+		tracer := oteltracesdk.NewTracerProvider(oteltracesdk.WithSpanProcessor(recorder)).Tracer("test")
+		ctx, span := tracer.Start(context.Background(), "completions")
+		_, err = c.Complete(ctx, logtest.Scoped(t), types.CompletionRequest{
+			Feature: types.CompletionsFeatureChat,
+			ModelConfigInfo: types.ModelConfigInfo{
+				Model: modelconfigSDK.Model{
+					ModelRef:  "openai::2024-02-01::gpt-4o",
+					ModelName: "gpt-4o",
+				},
+			},
+			Parameters: types.CompletionRequestParameters{
+				Messages: []types.Message{{Speaker: types.HUMAN_MESSAGE_SPEAKER, Text: "hello"}},
+			},
+		})
+		span.End()
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		return spans[0].Attributes(), err
+	}
+
+	t.Run("success", func(t *testing.T) {
+		attrs, err := complete(t, http.StatusOK, http.Header{
+			"X-Ratelimit-Limit":     {"100"},
+			"X-Ratelimit-Remaining": {"42"},
+		})
+		require.NoError(t, err)
+		assert.Contains(t, attrs, attribute.String("cody-gateway.x-ratelimit-limit", "100"))
+		assert.Contains(t, attrs, attribute.String("cody-gateway.x-ratelimit-remaining", "42"))
+	})
+
+	t.Run("throttled", func(t *testing.T) {
+		before := time.Now()
+		attrs, err := complete(t, http.StatusTooManyRequests, http.Header{
+			"X-Ratelimit-Limit":     {"100"},
+			"X-Ratelimit-Remaining": {"0"},
+			"Retry-After":           {"30"},
+		})
+		assert.Contains(t, attrs, attribute.String("cody-gateway.x-ratelimit-remaining", "0"))
+		assert.Contains(t, attrs, attribute.String("cody-gateway.retry-after", "30"))
+
+		statusErr, ok := types.IsErrStatusNotOK(err)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusTooManyRequests, statusErr.StatusCode)
+		assert.Equal(t, &types.RateLimit{Limit: 100, Remaining: 0}, statusErr.RateLimit)
+		assert.WithinRange(t, statusErr.RetryAfter, before.Add(30*time.Second), time.Now().Add(30*time.Second))
+	})
+}
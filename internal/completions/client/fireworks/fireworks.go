@@ -117,9 +117,10 @@ func (c *fireworksClient) Complete(
 	}
 
 	return &types.CompletionResponse{
-		Completion: completion,
-		StopReason: response.Choices[0].FinishReason,
-		Logprobs:   response.Choices[0].Logprobs,
+		Completion:           completion,
+		StopReason:           response.Choices[0].FinishReason,
+		NormalizedStopReason: types.NormalizeOpenAIStopReason(response.Choices[0].FinishReason),
+		Logprobs:             response.Choices[0].Logprobs,
 	}, nil
 }
 
@@ -166,9 +167,10 @@ func (c *fireworksClient) Stream(
 			}
 			accumulatedLogprobs = accumulatedLogprobs.Append(event.Choices[0].Logprobs)
 			ev := types.CompletionResponse{
-				Completion: content,
-				StopReason: event.Choices[0].FinishReason,
-				Logprobs:   accumulatedLogprobs,
+				Completion:           content,
+				StopReason:           event.Choices[0].FinishReason,
+				NormalizedStopReason: types.NormalizeOpenAIStopReason(event.Choices[0].FinishReason),
+				Logprobs:             accumulatedLogprobs,
 			}
 			err = sendEvent(ev)
 			if err != nil {
@@ -50,6 +50,13 @@ type googleGenerationConfig struct {
 	StopSequences   []string `json:"stopSequences,omitempty"`   // request.StopSequences
 	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"` // request.MaxTokensToSample
 	CandidateCount  int      `json:"candidateCount,omitempty"`  // request.CandidateCount
+
+	ThinkingConfig *googleThinkingConfig `json:"thinkingConfig,omitempty"` // request.ThinkingBudget
+}
+
+// Ref: https://ai.google.dev/gemini-api/docs/thinking
+type googleThinkingConfig struct {
+	ThinkingBudget int `json:"thinkingBudget"`
 }
 
 type googleResponse struct {
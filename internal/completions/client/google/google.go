@@ -205,8 +205,9 @@ func (c *googleCompletionStreamClient) handleGeminiStream(
 			content += event.Candidates[0].Content.Parts[0].Text
 
 			ev = types.CompletionResponse{
-				Completion: content,
-				StopReason: event.Candidates[0].FinishReason,
+				Completion:           content,
+				StopReason:           event.Candidates[0].FinishReason,
+				NormalizedStopReason: normalizeStopReason(event.Candidates[0].FinishReason),
 			}
 			err = sendEvent(ev)
 			if err != nil {
@@ -355,6 +356,12 @@ func (c *googleCompletionStreamClient) makeGeminiRequest(ctx context.Context, re
 			StopSequences:   requestParams.StopSequences,
 		},
 	}
+	if requestParams.ThinkingBudget > 0 {
+		// Thinking tokens count towards the max output tokens, so we add the
+		// budget to not cut the response short.
+		payload.GenerationConfig.ThinkingConfig = &googleThinkingConfig{ThinkingBudget: requestParams.ThinkingBudget}
+		payload.GenerationConfig.MaxOutputTokens += requestParams.ThinkingBudget
+	}
 	if c.viaGateway {
 		endpointURL = c.endpoint
 		// Add the Stream value to the payload if this is a Cody Gateway request,
@@ -476,3 +483,20 @@ func getgRPCMethod(stream bool, apiFamily APIFamily) string {
 func isDefaultAPIEndpoint(endpoint *url.URL) bool {
 	return endpoint.Host == defaultAPIHost
 }
+
+// normalizeStopReason maps the finish reasons of the Gemini API to
+// types.StopReason.
+func normalizeStopReason(reason string) types.StopReason {
+	switch reason {
+	case "":
+		return ""
+	case "STOP":
+		return types.StopReasonEndTurn
+	case "MAX_TOKENS":
+		return types.StopReasonMaxTokens
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII":
+		return types.StopReasonContentFilter
+	default:
+		return types.StopReasonOther
+	}
+}
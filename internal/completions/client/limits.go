@@ -0,0 +1,69 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/sourcegraph/sourcegraph/internal/completions/types"
+	"github.com/sourcegraph/sourcegraph/internal/env"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+var (
+	maxRequestMessages    = env.MustGetInt("SRC_COMPLETIONS_MAX_MESSAGES", 1000, "The maximum number of messages in a single completions request. 0 means no limit.")
+	maxRequestPromptBytes = env.MustGetInt("SRC_COMPLETIONS_MAX_PROMPT_BYTES", 10*1024*1024, "The maximum combined size in bytes of the messages in a single completions request. 0 means no limit.")
+)
+
+// RequestLimits bounds the size of a completions request, so that we reject
+// oversized requests before serializing them and sending them upstream.
+type RequestLimits struct {
+	// MaxMessages is the maximum number of messages. A value <= 0 means no
+	// limit.
+	MaxMessages int
+	// MaxPromptBytes is the maximum combined length of the text of all
+	// messages. A value <= 0 means no limit.
+	MaxPromptBytes int
+}
+
+// DefaultRequestLimits returns the request limits configured via the
+// environment.
+func DefaultRequestLimits() RequestLimits {
+	return RequestLimits{
+		MaxMessages:    maxRequestMessages,
+		MaxPromptBytes: maxRequestPromptBytes,
+	}
+}
+
+// ErrRequestTooLarge is returned when a completions request exceeds the
+// configured RequestLimits.
+type ErrRequestTooLarge struct {
+	// What is exceeding the limit, e.g. "messages".
+	What  string
+	Got   int
+	Limit int
+}
+
+func (e ErrRequestTooLarge) Error() string {
+	return fmt.Sprintf("completions request too large: got %d %s, the limit is %d", e.Got, e.What, e.Limit)
+}
+
+// IsErrRequestTooLarge returns true if err is or wraps an ErrRequestTooLarge.
+func IsErrRequestTooLarge(err error) bool {
+	return errors.HasType[ErrRequestTooLarge](err)
+}
+
+// Check returns an ErrRequestTooLarge if params exceed the limits.
+func (l RequestLimits) Check(params types.CompletionRequestParameters) error {
+	if l.MaxMessages > 0 && len(params.Messages) > l.MaxMessages {
+		return ErrRequestTooLarge{What: "messages", Got: len(params.Messages), Limit: l.MaxMessages}
+	}
+	if l.MaxPromptBytes > 0 {
+		size := 0
+		for _, m := range params.Messages {
+			size += len(m.Text)
+		}
+		if size > l.MaxPromptBytes {
+			return ErrRequestTooLarge{What: "prompt bytes", Got: size, Limit: l.MaxPromptBytes}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sourcegraph/sourcegraph/internal/completions/types"
+)
+
+func TestRequestLimits(t *testing.T) {
+	limits := RequestLimits{MaxMessages: 3, MaxPromptBytes: 10}
+
+	messages := func(texts ...string) types.CompletionRequestParameters {
+		var params types.CompletionRequestParameters
+		for _, text := range texts {
+			params.Messages = append(params.Messages, types.Message{Speaker: types.HUMAN_MESSAGE_SPEAKER, Text: text})
+		}
+		return params
+	}
+
+	t.Run("within limits", func(t *testing.T) {
+		assert.NoError(t, limits.Check(messages("abc", "def", "ghij")))
+	})
+
+	t.Run("too many messages", func(t *testing.T) {
+		err := limits.Check(messages("a", "b", "c", "d"))
+		assert.True(t, IsErrRequestTooLarge(err))
+		assert.EqualError(t, err, "completions request too large: got 4 messages, the limit is 3")
+	})
+
+	t.Run("prompt too large", func(t *testing.T) {
+		err := limits.Check(messages("abcdef", "ghijk"))
+		assert.True(t, IsErrRequestTooLarge(err))
+		assert.EqualError(t, err, "completions request too large: got 11 prompt bytes, the limit is 10")
+	})
+
+	t.Run("no limits", func(t *testing.T) {
+		assert.NoError(t, RequestLimits{}.Check(messages(strings.Repeat("a", 1000), "b", "c", "d")))
+	})
+}
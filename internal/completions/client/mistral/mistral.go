@@ -0,0 +1,244 @@
+// Package mistral implements a completions client for the Mistral API.
+package mistral
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sourcegraph/log"
+
+	"github.com/sourcegraph/sourcegraph/internal/completions/client/openai"
+	"github.com/sourcegraph/sourcegraph/internal/completions/tokenusage"
+	"github.com/sourcegraph/sourcegraph/internal/completions/types"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// fimCompletionsPath is the path of Mistral's fill-in-the-middle endpoint,
+// which is used for code completions.
+const fimCompletionsPath = "v1/fim/completions"
+
+// NewClient returns a client for the Mistral API.
+//
+// Chat requests are handled by the OpenAI client, as Mistral's chat endpoint
+// is compatible with the OpenAI API. Their token usage is recorded for Mistral. Code completions use Mistral's
+// fill-in-the-middle endpoint, which takes the code before and after the
+// cursor as separate fields instead.
+func NewClient(cli httpcli.Doer, endpoint, accessToken string, tokenManager tokenusage.Manager) types.CompletionsClient {
+	return &mistralClient{
+		chat:         openai.NewProviderClient(cli, endpoint, accessToken, tokenusage.Mistral, tokenManager),
+		cli:          cli,
+		endpoint:     endpoint,
+		accessToken:  accessToken,
+		tokenManager: tokenManager,
+	}
+}
+
+type mistralClient struct {
+	// chat handles chat requests.
+	chat         types.CompletionsClient
+	cli          httpcli.Doer
+	endpoint     string
+	accessToken  string
+	tokenManager tokenusage.Manager
+}
+
+func (c *mistralClient) Complete(
+	ctx context.Context,
+	logger log.Logger,
+	request types.CompletionRequest) (*types.CompletionResponse, error) {
+
+	switch request.Feature {
+	case types.CompletionsFeatureChat:
+		return c.chat.Complete(ctx, logger, request)
+	case types.CompletionsFeatureCode:
+	default:
+		return nil, errors.Errorf("unknown feature %q", request.Feature)
+	}
+
+	resp, err := c.makeFIMRequest(ctx, request, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var response fimResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	var completion string
+	if len(response.Choices) > 0 {
+		completion = response.Choices[0].Message.Content
+	}
+	if err := c.recordTokenUsage(request, response.Usage, completion); err != nil {
+		logger.Warn("Failed to count tokens with the token manager", log.Error(err))
+	}
+	if len(response.Choices) == 0 {
+		return &types.CompletionResponse{}, nil
+	}
+	return &types.CompletionResponse{
+		Completion:           response.Choices[0].Message.Content,
+		StopReason:           response.Choices[0].FinishReason,
+		NormalizedStopReason: normalizeStopReason(response.Choices[0].FinishReason),
+	}, nil
+}
+
+func (c *mistralClient) Stream(
+	ctx context.Context,
+	logger log.Logger,
+	request types.CompletionRequest,
+	sendEvent types.SendCompletionEvent) error {
+
+	switch request.Feature {
+	case types.CompletionsFeatureChat:
+		return c.chat.Stream(ctx, logger, request, sendEvent)
+	case types.CompletionsFeatureCode:
+	default:
+		return errors.Errorf("unknown feature %v", request.Feature)
+	}
+
+	resp, err := c.makeFIMRequest(ctx, request, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	dec := openai.NewDecoder(resp.Body)
+	var (
+		content string
+		usage   fimUsage
+	)
+	for dec.Scan() {
+		if ctx.Err() == context.Canceled {
+			return nil
+		}
+
+		data := dec.Data()
+		// Gracefully skip over any data that isn't JSON-like.
+		if !bytes.HasPrefix(data, []byte("{")) {
+			continue
+		}
+
+		var event fimResponse
+		if err := json.Unmarshal(data, &event); err != nil {
+			return errors.Errorf("failed to decode event payload: %w - body: %s", err, string(data))
+		}
+
+		// Usage is only included in the last event.
+		if event.Usage.PromptTokens > 0 || event.Usage.CompletionTokens > 0 {
+			usage = event.Usage
+		}
+
+		if len(event.Choices) > 0 {
+			content += event.Choices[0].Delta.Content
+			err = sendEvent(types.CompletionResponse{
+				Completion:           content,
+				StopReason:           event.Choices[0].FinishReason,
+				NormalizedStopReason: normalizeStopReason(event.Choices[0].FinishReason),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if dec.Err() != nil {
+		return dec.Err()
+	}
+
+	if err := c.recordTokenUsage(request, usage, content); err != nil {
+		logger.Warn("Failed to count tokens with the token manager", log.Error(err))
+	}
+	return nil
+}
+
+// makeFIMRequest calls the fill-in-the-middle endpoint with the prompt of the
+// code completions request.
+func (c *mistralClient) makeFIMRequest(ctx context.Context, request types.CompletionRequest, stream bool) (*http.Response, error) {
+	requestParams := request.Parameters
+	if l := len(requestParams.Messages); l != 1 {
+		return nil, errors.Errorf("expected to receive exactly one message with the prompt (got %d)", l)
+	}
+	prompt, suffix := splitFIMPrompt(requestParams.Messages[0].Text)
+
+	payload := fimRequest{
+		Model:       request.ModelConfigInfo.Model.ModelName,
+		Prompt:      prompt,
+		Suffix:      suffix,
+		Temperature: requestParams.Temperature,
+		MaxTokens:   requestParams.MaxTokensToSample,
+		Stop:        requestParams.StopSequences,
+		Stream:      stream,
+	}
+	if requestParams.TopP > 0 {
+		payload.TopP = requestParams.TopP
+	}
+
+	reqBody, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := url.Parse(c.endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse configured endpoint")
+	}
+	url.Path = fimCompletionsPath
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url.String(), bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+
+	resp, err := c.cli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, types.NewErrStatusNotOK("Mistral", resp)
+	}
+	return resp, nil
+}
+
+func (c *mistralClient) recordTokenUsage(request types.CompletionRequest, usage fimUsage, completion string) error {
+	label := "mistral/" + request.ModelConfigInfo.Model.ModelName
+	return c.tokenManager.UpdateReportedTokenCounts(
+		request, completion,
+		usage.PromptTokens, usage.CompletionTokens,
+		label, tokenusage.Mistral)
+}
+
+const (
+	fimSuffixMarker = "[SUFFIX]"
+	fimPrefixMarker = "[PREFIX]"
+)
+
+// splitFIMPrompt splits a Codestral-style prompt of the form
+// "[SUFFIX]<suffix>[PREFIX]<prefix>" into the prefix and suffix of the
+// cursor. Prompts without these markers are treated as the prefix only.
+func splitFIMPrompt(text string) (prompt, suffix string) {
+	_, rest, ok := strings.Cut(text, fimSuffixMarker)
+	if !ok {
+		return text, ""
+	}
+	suffix, prompt, ok = strings.Cut(rest, fimPrefixMarker)
+	if !ok {
+		return text, ""
+	}
+	return prompt, suffix
+}
+
+// normalizeStopReason maps the finish reasons of the Mistral API, which follows
+// the OpenAI API, to types.StopReason. Mistral additionally reports
+// "model_length" if the response was cut off by the context window.
+func normalizeStopReason(reason string) types.StopReason {
+	if reason == "model_length" {
+		return types.StopReasonMaxTokens
+	}
+	return types.NormalizeOpenAIStopReason(reason)
+}
@@ -0,0 +1,106 @@
+package mistral
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/log/logtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/completions/tokenusage"
+	"github.com/sourcegraph/sourcegraph/internal/completions/types"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	modelconfigSDK "github.com/sourcegraph/sourcegraph/internal/modelconfig/types"
+)
+
+func TestSplitFIMPrompt(t *testing.T) {
+	for _, tc := range []struct {
+		text   string
+		prompt string
+		suffix string
+	}{
+		{text: "[SUFFIX]\n}[PREFIX]func f() {\n", prompt: "func f() {\n", suffix: "\n}"},
+		{text: "<s>[SUFFIX][PREFIX]package main", prompt: "package main", suffix: ""},
+		{text: "package main", prompt: "package main", suffix: ""},
+		{text: "[SUFFIX]no prefix", prompt: "[SUFFIX]no prefix", suffix: ""},
+	} {
+		prompt, suffix := splitFIMPrompt(tc.text)
+		assert.Equal(t, tc.prompt, prompt, tc.text)
+		assert.Equal(t, tc.suffix, suffix, tc.text)
+	}
+}
+
+func TestClient(t *testing.T) {
+	var (
+		gotPath string
+		gotBody map[string]any
+	)
+	cli := httpcli.DoerFunc(func(req *http.Request) (*http.Response, error) {
+		gotPath = req.URL.Path
+		gotBody = nil
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&gotBody))
+		body := `{"choices":[{"message":{"content":"return 1"},"text":"return 1","finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":3}}`
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil
+	})
+	c := NewClient(cli, "https://api.mistral.ai", "token", *tokenusage.NewManager())
+
+	request := func(feature types.CompletionsFeature, text string) types.CompletionRequest {
+		return types.CompletionRequest{
+			Feature: feature,
+			ModelConfigInfo: types.ModelConfigInfo{
+				Model: modelconfigSDK.Model{ModelName: "codestral-latest"},
+			},
+			Parameters: types.CompletionRequestParameters{
+				Messages:          []types.Message{{Speaker: types.HUMAN_MESSAGE_SPEAKER, Text: text}},
+				MaxTokensToSample: 10,
+				TopK:              5,
+				TopP:              -1,
+			},
+		}
+	}
+
+	t.Run("code completions", func(t *testing.T) {
+		resp, err := c.Complete(context.Background(), logtest.Scoped(t), request(types.CompletionsFeatureCode, "[SUFFIX]\n}[PREFIX]func f() int {\n"))
+		require.NoError(t, err)
+		assert.Equal(t, "return 1", resp.Completion)
+		assert.Equal(t, types.StopReasonEndTurn, resp.NormalizedStopReason)
+
+		assert.Equal(t, "/v1/fim/completions", gotPath)
+		assert.Equal(t, map[string]any{
+			"model":      "codestral-latest",
+			"prompt":     "func f() int {\n",
+			"suffix":     "\n}",
+			"max_tokens": float64(10),
+		}, gotBody)
+	})
+
+	t.Run("chat", func(t *testing.T) {
+		resp, err := c.Complete(context.Background(), logtest.Scoped(t), request(types.CompletionsFeatureChat, "hello"))
+		require.NoError(t, err)
+		assert.Equal(t, "return 1", resp.Completion)
+
+		assert.Equal(t, "/v1/chat/completions", gotPath)
+		assert.NotContains(t, gotBody, "top_k")
+	})
+}
+
+func TestNormalizeStopReason(t *testing.T) {
+	for raw, want := range map[string]types.StopReason{
+		"":             "",
+		"stop":         types.StopReasonEndTurn,
+		"length":       types.StopReasonMaxTokens,
+		"model_length": types.StopReasonMaxTokens,
+		"tool_calls":   types.StopReasonToolUse,
+		"error":        types.StopReasonOther,
+	} {
+		assert.Equal(t, want, normalizeStopReason(raw), "stop reason %q", raw)
+	}
+}
@@ -0,0 +1,11 @@
+package mistral
+
+// For the latest available Mistral models,
+// See: https://docs.mistral.ai/getting-started/models/models_overview/
+const (
+	Codestral    = "codestral-latest"
+	MistralLarge = "mistral-large-latest"
+	MistralSmall = "mistral-small-latest"
+	Mixtral8x7b  = "open-mixtral-8x7b"
+	Mixtral8x22b = "open-mixtral-8x22b"
+)
@@ -0,0 +1,35 @@
+package mistral
+
+// fimRequest is the payload of Mistral's fill-in-the-middle endpoint.
+// https://docs.mistral.ai/api/#tag/fim
+type fimRequest struct {
+	Model       string   `json:"model"`                 // request.Model
+	Prompt      string   `json:"prompt"`                // the code before the cursor
+	Suffix      string   `json:"suffix,omitempty"`      // the code after the cursor
+	Temperature float32  `json:"temperature,omitempty"` // request.Temperature
+	TopP        float32  `json:"top_p,omitempty"`       // request.TopP
+	MaxTokens   int      `json:"max_tokens,omitempty"`  // request.MaxTokensToSample
+	Stop        []string `json:"stop,omitempty"`        // request.StopSequences
+	Stream      bool     `json:"stream,omitempty"`      // request.Stream
+}
+
+type fimUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+type fimChoiceContent struct {
+	Content string `json:"content"`
+}
+
+type fimChoice struct {
+	// Message is set for non-streaming requests, Delta for streaming ones.
+	Message      fimChoiceContent `json:"message"`
+	Delta        fimChoiceContent `json:"delta"`
+	FinishReason string           `json:"finish_reason"`
+}
+
+type fimResponse struct {
+	Usage   fimUsage    `json:"usage"`
+	Choices []fimChoice `json:"choices"`
+}
@@ -2,11 +2,15 @@ package client
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/sourcegraph/log"
 	"go.opentelemetry.io/otel/attribute"
 
+	"github.com/sourcegraph/sourcegraph/internal/completions/tokenizer"
 	"github.com/sourcegraph/sourcegraph/internal/completions/types"
 	"github.com/sourcegraph/sourcegraph/internal/metrics"
 	"github.com/sourcegraph/sourcegraph/internal/observation"
@@ -21,6 +25,9 @@ func newObservedClient(logger log.Logger, events *telemetry.EventRecorder, inner
 		ops:    ops,
 		events: telemetry.NewBestEffortEventRecorder(logger.Scoped("events"), events),
 		logger: logger,
+		limits: DefaultRequestLimits(),
+		retry:  DefaultRetryPolicy(),
+		redact: DefaultRedactFunc(),
 	}
 }
 
@@ -29,6 +36,10 @@ type observedClient struct {
 	ops    *operations
 	events *telemetry.BestEffortEventRecorder
 	logger log.Logger
+	limits RequestLimits
+	retry  RetryPolicy
+	// redact is applied to the request parameters before they are logged.
+	redact RedactFunc
 }
 
 var _ types.CompletionsClient = (*observedClient)(nil)
@@ -48,17 +59,39 @@ func (o *observedClient) Stream(ctx context.Context, logger log.Logger, request
 	})
 	defer endObservation(1, observation.Args{})
 
+	if err := o.limits.Check(params); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	// Streamed events contain the whole completion so far.
+	var completion string
+	var sent bool
 	tracedSend := func(event types.CompletionResponse) error {
+		sent = true
 		if event.StopReason != "" {
 			tr.AddEvent("stopped", attribute.String("reason", event.StopReason))
 		} else {
 			tr.AddEvent("completion", attribute.Int("len", len(event.Completion)))
 		}
+		if event.Completion != "" {
+			completion = event.Completion
+		}
 
 		return send(event)
 	}
 
-	return o.inner.Stream(ctx, logger, request, tracedSend)
+	// Once events were sent to the client, the request can't be retried
+	// without sending them again.
+	err = o.retry.Do(ctx, func() bool { return !sent }, func() error {
+		return o.inner.Stream(ctx, logger, request, tracedSend)
+	})
+	if err != nil {
+		logFailedRequest(logger, o.redact, request, err)
+		return err
+	}
+	o.observeOutputTokensPerSecond(request, completion, time.Since(start))
+	return nil
 }
 
 func (o *observedClient) Complete(ctx context.Context, logger log.Logger, request types.CompletionRequest) (resp *types.CompletionResponse, err error) {
@@ -82,9 +115,66 @@ func (o *observedClient) Complete(ctx context.Context, logger log.Logger, reques
 		},
 	})
 
-	return o.inner.Complete(ctx, logger, request)
+	if err := o.limits.Check(params); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	err = o.retry.Do(ctx, func() bool { return true }, func() (err error) {
+		resp, err = o.inner.Complete(ctx, logger, request)
+		return err
+	})
+	if err != nil {
+		logFailedRequest(logger, o.redact, request, err)
+	} else if resp != nil {
+		o.observeOutputTokensPerSecond(request, resp.Completion, time.Since(start))
+	}
+	return resp, err
+}
+
+// observeOutputTokensPerSecond records the output throughput of a successful
+// request that produced the given completion in the given time.
+func (o *observedClient) observeOutputTokensPerSecond(request types.CompletionRequest, completion string, d time.Duration) {
+	// Not every provider reports token usage, so we count the output tokens
+	// ourselves. The count is an approximation, but consistent across models.
+	tk, err := getOutputTokenizer()
+	if err != nil {
+		o.logger.Warn("failed to create tokenizer for throughput metrics", log.Error(err))
+		return
+	}
+	tokens, err := tk.Tokenize(completion)
+	if err != nil {
+		return
+	}
+
+	rate, ok := tokensPerSecond(len(tokens), d)
+	if !ok {
+		return
+	}
+	outputTokensPerSecond.WithLabelValues(
+		string(request.ModelConfigInfo.Provider.ID),
+		request.ModelConfigInfo.Model.ModelName,
+		string(request.Feature),
+	).Observe(rate)
+}
+
+// tokensPerSecond returns the throughput of producing the given number of tokens
+// in d. It returns false if no meaningful rate can be computed.
+func tokensPerSecond(tokens int, d time.Duration) (float64, bool) {
+	if tokens <= 0 || d <= 0 {
+		return 0, false
+	}
+	return float64(tokens) / d.Seconds(), true
 }
 
+var getOutputTokenizer = sync.OnceValues(tokenizer.NewCL100kBaseTokenizer)
+
+var outputTokensPerSecond = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "src_completions_output_tokens_per_second",
+	Help:    "Output tokens per second of successful completion requests, including time to first token.",
+	Buckets: []float64{1, 2, 5, 10, 20, 30, 40, 50, 75, 100, 150, 200, 300, 500},
+}, []string{"provider", "model", "feature"})
+
 type operations struct {
 	stream   *observation.Operation
 	complete *observation.Operation
@@ -0,0 +1,24 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokensPerSecond(t *testing.T) {
+	rate, ok := tokensPerSecond(500, 4*time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, 125.0, rate)
+
+	rate, ok = tokensPerSecond(30, 1500*time.Millisecond)
+	assert.True(t, ok)
+	assert.Equal(t, 20.0, rate)
+
+	_, ok = tokensPerSecond(0, time.Second)
+	assert.False(t, ok, "expected no rate without tokens")
+
+	_, ok = tokensPerSecond(100, 0)
+	assert.False(t, ok, "expected no rate without duration")
+}
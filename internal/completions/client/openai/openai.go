@@ -4,32 +4,74 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
 
 	"github.com/sourcegraph/log"
 
-	"github.com/sourcegraph/sourcegraph/internal/completions/tokenizer"
 	"github.com/sourcegraph/sourcegraph/internal/completions/tokenusage"
 	"github.com/sourcegraph/sourcegraph/internal/completions/types"
 	"github.com/sourcegraph/sourcegraph/internal/httpcli"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
+const (
+	defaultChatCompletionsPath = "v1/chat/completions"
+	defaultCompletionsPath     = "v1/completions"
+)
+
 func NewClient(cli httpcli.Doer, endpoint, accessToken string, tokenManager tokenusage.Manager) types.CompletionsClient {
+	return newClient(cli, endpoint, accessToken, "", "", false, tokenusage.OpenAI, tokenManager)
+}
+
+// NewProviderClient is like NewClient, for providers whose API is compatible with
+// the OpenAI API, e.g. Mistral. Token usage is recorded for provider instead of
+// OpenAI.
+func NewProviderClient(cli httpcli.Doer, endpoint, accessToken string, provider tokenusage.Provider, tokenManager tokenusage.Manager) types.CompletionsClient {
+	return newClient(cli, endpoint, accessToken, "", "", false, provider, tokenManager)
+}
+
+// NewCompatibleClient is like NewClient, for gateways that expose the OpenAI API under
+// different paths. The paths replace any path of the endpoint, empty paths fall back to
+// the ones of the OpenAI API.
+//
+// Unlike the OpenAI API, which rejects unknown parameters, the backends behind these
+// gateways (e.g. vLLM) also support top_k sampling, so it is passed along.
+func NewCompatibleClient(cli httpcli.Doer, endpoint, accessToken, chatCompletionsPath, completionsPath string, tokenManager tokenusage.Manager) types.CompletionsClient {
+	return newClient(cli, endpoint, accessToken, chatCompletionsPath, completionsPath, true, tokenusage.OpenAI, tokenManager)
+}
+
+func newClient(cli httpcli.Doer, endpoint, accessToken, chatCompletionsPath, completionsPath string, sendTopK bool, provider tokenusage.Provider, tokenManager tokenusage.Manager) types.CompletionsClient {
+	if chatCompletionsPath == "" {
+		chatCompletionsPath = defaultChatCompletionsPath
+	}
+	if completionsPath == "" {
+		completionsPath = defaultCompletionsPath
+	}
 	return &openAIChatCompletionStreamClient{
-		cli:          cli,
-		accessToken:  accessToken,
-		endpoint:     endpoint,
-		tokenManager: tokenManager,
+		cli:                 cli,
+		accessToken:         accessToken,
+		endpoint:            endpoint,
+		chatCompletionsPath: chatCompletionsPath,
+		completionsPath:     completionsPath,
+		sendTopK:            sendTopK,
+		provider:            provider,
+		tokenManager:        tokenManager,
 	}
 }
 
 type openAIChatCompletionStreamClient struct {
-	cli          httpcli.Doer
-	accessToken  string
-	endpoint     string
+	cli                 httpcli.Doer
+	accessToken         string
+	endpoint            string
+	chatCompletionsPath string
+	completionsPath     string
+	// sendTopK is true if the backend supports the non-standard top_k parameter.
+	sendTopK bool
+	// provider is the provider token usage is recorded for.
+	provider     tokenusage.Provider
 	tokenManager tokenusage.Manager
 }
 
@@ -60,8 +102,17 @@ func (c *openAIChatCompletionStreamClient) Complete(
 		return nil, err
 	}
 
+	// The request is bound to ctx, but not every Doer stops reading the body
+	// once ctx is done. Close it ourselves, so that we don't keep blocking on a
+	// slow or hanging response after the caller went away.
+	stop := context.AfterFunc(ctx, func() { resp.Body.Close() })
+	defer stop()
+
 	var response openaiResponse
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, err
 	}
 	if len(response.Choices) == 0 {
@@ -70,12 +121,13 @@ func (c *openAIChatCompletionStreamClient) Complete(
 	}
 
 	usage := response.Usage
-	if err = c.recordTokenUsage(request, usage.PromptTokens, usage.CompletionTokens); err != nil {
+	if err = c.recordTokenUsage(request, usage.PromptTokens, usage.CompletionTokens, response.Choices[0].Text); err != nil {
 		logger.Warn("Failed to count tokens with the token manager %w ", log.Error(err))
 	}
 	return &types.CompletionResponse{
-		Completion: response.Choices[0].Text,
-		StopReason: response.Choices[0].FinishReason,
+		Completion:           response.Choices[0].Text,
+		StopReason:           response.Choices[0].FinishReason,
+		NormalizedStopReason: types.NormalizeOpenAIStopReason(response.Choices[0].FinishReason),
 	}, nil
 }
 
@@ -143,8 +195,9 @@ func (c *openAIChatCompletionStreamClient) Stream(
 				content += event.Choices[0].Delta.Content
 			}
 			ev = types.CompletionResponse{
-				Completion: content,
-				StopReason: event.Choices[0].FinishReason,
+				Completion:           content,
+				StopReason:           event.Choices[0].FinishReason,
+				NormalizedStopReason: types.NormalizeOpenAIStopReason(event.Choices[0].FinishReason),
 			}
 			err = sendEvent(ev)
 			if err != nil {
@@ -156,27 +209,24 @@ func (c *openAIChatCompletionStreamClient) Stream(
 		return dec.Err()
 	}
 
-	if err = c.recordTokenUsage(request, promptTokens, completionTokens); err != nil {
+	if err = c.recordTokenUsage(request, promptTokens, completionTokens, content); err != nil {
 		logger.Warn("Failed to count tokens with the token manager %w", log.Error(err))
 	}
 	return nil
 }
 
-func (c *openAIChatCompletionStreamClient) recordTokenUsage(request types.CompletionRequest, promptTokens, completionTokens int) error {
-	feature := string(request.Feature)
+func (c *openAIChatCompletionStreamClient) recordTokenUsage(request types.CompletionRequest, promptTokens, completionTokens int, completion string) error {
 	model := request.ModelConfigInfo.Model.ModelName
-	label := tokenizer.OpenAIModel + "/" + string(model)
-	return c.tokenManager.UpdateTokenCountsFromModelUsage(
+	label := string(c.provider) + "/" + string(model)
+	return c.tokenManager.UpdateReportedTokenCounts(
+		request, completion,
 		promptTokens, completionTokens,
-		label, feature, tokenusage.OpenAI)
+		label, c.provider)
 }
 
 // makeRequest formats the request and calls the chat/completions endpoint for code_completion requests
 func (c *openAIChatCompletionStreamClient) makeRequest(ctx context.Context, request types.CompletionRequest, stream bool) (*http.Response, error) {
 	requestParams := request.Parameters
-	if requestParams.TopK < 0 {
-		requestParams.TopK = 0
-	}
 	if requestParams.TopP < 0 {
 		requestParams.TopP = 0
 	}
@@ -186,15 +236,17 @@ func (c *openAIChatCompletionStreamClient) makeRequest(ctx context.Context, requ
 		Model:       request.ModelConfigInfo.Model.ModelName,
 		Temperature: requestParams.Temperature,
 		TopP:        requestParams.TopP,
-		// TODO(sqs): map requestParams.TopK to openai
-		N:         1,
-		Stream:    stream,
-		MaxTokens: requestParams.MaxTokensToSample,
+		N:           1,
+		Stream:      stream,
+		MaxTokens:   requestParams.MaxTokensToSample,
 		// TODO: Our clients are currently heavily biased towards Anthropic,
 		// so the stop sequences we send might not actually be very useful
 		// for OpenAI.
 		Stop: requestParams.StopSequences,
 	}
+	if c.sendTopK && requestParams.TopK > 0 {
+		payload.TopK = requestParams.TopK
+	}
 	for _, m := range requestParams.Messages {
 		// TODO(sqs): map these 'roles' to openai system/user/assistant
 		var role string
@@ -222,7 +274,7 @@ func (c *openAIChatCompletionStreamClient) makeRequest(ctx context.Context, requ
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to parse configured endpoint")
 	}
-	url.Path = "v1/chat/completions"
+	url.Path = c.chatCompletionsPath
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url.String(), bytes.NewReader(reqBody))
 	if err != nil {
@@ -238,7 +290,7 @@ func (c *openAIChatCompletionStreamClient) makeRequest(ctx context.Context, requ
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, types.NewErrStatusNotOK("OpenAI", resp)
+		return nil, newErrStatusNotOK(resp)
 	}
 
 	return resp, nil
@@ -247,9 +299,6 @@ func (c *openAIChatCompletionStreamClient) makeRequest(ctx context.Context, requ
 // makeCompletionRequest formats the request and calls the completions endpoint for code_completion requests
 func (c *openAIChatCompletionStreamClient) makeCompletionRequest(ctx context.Context, request types.CompletionRequest, stream bool) (*http.Response, error) {
 	requestParams := request.Parameters
-	if requestParams.TopK < 0 {
-		requestParams.TopK = 0
-	}
 	if requestParams.TopP < 0 {
 		requestParams.TopP = 0
 	}
@@ -269,6 +318,9 @@ func (c *openAIChatCompletionStreamClient) makeCompletionRequest(ctx context.Con
 		Stop:        requestParams.StopSequences,
 		Prompt:      prompt,
 	}
+	if c.sendTopK && requestParams.TopK > 0 {
+		payload.TopK = requestParams.TopK
+	}
 
 	reqBody, err := json.Marshal(payload)
 	if err != nil {
@@ -278,7 +330,7 @@ func (c *openAIChatCompletionStreamClient) makeCompletionRequest(ctx context.Con
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to parse configured endpoint")
 	}
-	url.Path = "v1/completions"
+	url.Path = c.completionsPath
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url.String(), bytes.NewReader(reqBody))
 	if err != nil {
@@ -294,12 +346,30 @@ func (c *openAIChatCompletionStreamClient) makeCompletionRequest(ctx context.Con
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, types.NewErrStatusNotOK("OpenAI", resp)
+		return nil, newErrStatusNotOK(resp)
 	}
 
 	return resp, nil
 }
 
+// newErrStatusNotOK is like types.NewErrStatusNotOK, but if the body is a JSON
+// error, the error message contains only its message and type instead of the
+// whole body.
+func newErrStatusNotOK(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 8*1024))
+	resp.Body.Close()
+
+	var errResp openaiErrorResponse
+	if json.Unmarshal(body, &errResp) == nil && errResp.Error.Message != "" {
+		body = []byte(errResp.Error.Message)
+		if errResp.Error.Type != "" {
+			body = append(body, " ("+errResp.Error.Type+")"...)
+		}
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return types.NewErrStatusNotOK("OpenAI", resp)
+}
+
 func getPrompt(messages []types.Message) (string, error) {
 	if l := len(messages); l != 1 {
 		return "", errors.Errorf("expected to receive exactly one message with the prompt (got %d)", l)
@@ -3,9 +3,11 @@ package openai
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"io"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/hexops/autogold/v2"
 	"github.com/sourcegraph/log"
@@ -74,3 +76,248 @@ func TestErrStatusNotOK(t *testing.T) {
 		assert.True(t, ok)
 	})
 }
+
+func TestErrStatusNotOKBody(t *testing.T) {
+	tokenManager := tokenusage.NewManager()
+	compRequest := types.CompletionRequest{
+		Feature: types.CompletionsFeatureChat,
+		Version: types.CompletionsVersionLegacy,
+		Parameters: types.CompletionRequestParameters{
+			Messages: []types.Message{{Speaker: types.HUMAN_MESSAGE_SPEAKER, Text: "hello"}},
+		},
+	}
+
+	for _, tc := range []struct {
+		name       string
+		statusCode int
+		body       string
+		want       string
+	}{
+		{
+			name:       "bad request",
+			statusCode: http.StatusBadRequest,
+			body:       `{"error": {"message": "max_tokens is too large", "type": "invalid_request_error"}}`,
+			want:       `OpenAI: unexpected status code 400: max_tokens is too large (invalid_request_error)`,
+		},
+		{
+			name:       "not found",
+			statusCode: http.StatusNotFound,
+			body:       `{"error": {"message": "model not found"}}`,
+			want:       `OpenAI: unexpected status code 404: model not found`,
+		},
+		{
+			name:       "no JSON body",
+			statusCode: http.StatusNotFound,
+			body:       `404 page not found`,
+			want:       `OpenAI: unexpected status code 404: 404 page not found`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewCompatibleClient(&mockDoer{
+				func(r *http.Request) (*http.Response, error) {
+					return &http.Response{
+						StatusCode: tc.statusCode,
+						Body:       io.NopCloser(bytes.NewReader([]byte(tc.body))),
+					}, nil
+				},
+			}, "https://example.com", "", "", "", *tokenManager)
+
+			_, err := client.Complete(context.Background(), log.Scoped("completions"), compRequest)
+			require.Error(t, err)
+			assert.Equal(t, tc.want, err.Error())
+			statusErr, ok := types.IsErrStatusNotOK(err)
+			require.True(t, ok)
+			assert.Equal(t, tc.statusCode, statusErr.StatusCode)
+		})
+	}
+}
+
+func TestRequestURL(t *testing.T) {
+	tests := []struct {
+		name                string
+		endpoint            string
+		chatCompletionsPath string
+		completionsPath     string
+		wantChat            string
+		wantCode            string
+	}{
+		{
+			name:     "bare endpoint",
+			endpoint: "https://api.openai.com",
+			wantChat: "https://api.openai.com/v1/chat/completions",
+			wantCode: "https://api.openai.com/v1/completions",
+		},
+		{
+			name:     "path of endpoint is replaced",
+			endpoint: "https://gateway.example.com/some/path",
+			wantChat: "https://gateway.example.com/v1/chat/completions",
+			wantCode: "https://gateway.example.com/v1/completions",
+		},
+		{
+			name:                "prefixed paths",
+			endpoint:            "http://localhost:4000",
+			chatCompletionsPath: "openai/v1/chat/completions",
+			completionsPath:     "openai/v1/completions",
+			wantChat:            "http://localhost:4000/openai/v1/chat/completions",
+			wantCode:            "http://localhost:4000/openai/v1/completions",
+		},
+		{
+			name:                "prefixed paths replace path of endpoint",
+			endpoint:            "http://localhost:4000/v1",
+			chatCompletionsPath: "/openai/v1/chat/completions",
+			completionsPath:     "/openai/v1/completions",
+			wantChat:            "http://localhost:4000/openai/v1/chat/completions",
+			wantCode:            "http://localhost:4000/openai/v1/completions",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotURL string
+			tokenManager := tokenusage.NewManager()
+			client := NewCompatibleClient(&mockDoer{
+				func(r *http.Request) (*http.Response, error) {
+					gotURL = r.URL.String()
+					return &http.Response{
+						StatusCode: http.StatusTooManyRequests,
+						Body:       io.NopCloser(bytes.NewReader(nil)),
+					}, nil
+				},
+			}, tt.endpoint, "", tt.chatCompletionsPath, tt.completionsPath, *tokenManager)
+
+			for feature, want := range map[types.CompletionsFeature]string{
+				types.CompletionsFeatureChat: tt.wantChat,
+				types.CompletionsFeatureCode: tt.wantCode,
+			} {
+				gotURL = ""
+				_, err := client.Complete(context.Background(), log.NoOp(), types.CompletionRequest{
+					Feature: feature,
+					Parameters: types.CompletionRequestParameters{
+						Messages: []types.Message{{Speaker: types.HUMAN_MESSAGE_SPEAKER, Text: "hi"}},
+					},
+				})
+				require.Error(t, err)
+				assert.Equal(t, want, gotURL, "feature %s", feature)
+			}
+		})
+	}
+}
+
+func TestRequestBody(t *testing.T) {
+	newClient := func(compatible bool, body *map[string]any) types.CompletionsClient {
+		doer := &mockDoer{
+			func(r *http.Request) (*http.Response, error) {
+				*body = nil
+				if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+					return nil, err
+				}
+				return &http.Response{
+					StatusCode: http.StatusTooManyRequests,
+					Body:       io.NopCloser(bytes.NewReader(nil)),
+				}, nil
+			},
+		}
+		tokenManager := tokenusage.NewManager()
+		if compatible {
+			return NewCompatibleClient(doer, "http://localhost:8000", "", "", "", *tokenManager)
+		}
+		return NewClient(doer, "https://api.openai.com", "", *tokenManager)
+	}
+
+	tests := []struct {
+		name       string
+		compatible bool
+		params     types.CompletionRequestParameters
+		wantStop   any
+		wantTopK   any
+	}{
+		{
+			name:       "empty stop sequences are omitted",
+			compatible: true,
+			params:     types.CompletionRequestParameters{StopSequences: []string{}},
+		},
+		{
+			name:       "stop sequences",
+			compatible: true,
+			params:     types.CompletionRequestParameters{StopSequences: []string{"\n\n"}},
+			wantStop:   []any{"\n\n"},
+		},
+		{
+			name:       "top_k",
+			compatible: true,
+			params:     types.CompletionRequestParameters{TopK: 40},
+			wantTopK:   float64(40),
+		},
+		{
+			name:       "unset top_k is omitted",
+			compatible: true,
+			params:     types.CompletionRequestParameters{TopK: -1},
+		},
+		{
+			name:       "zero top_k is omitted",
+			compatible: true,
+			params:     types.CompletionRequestParameters{TopK: 0},
+		},
+		{
+			name:   "top_k is not sent to OpenAI",
+			params: types.CompletionRequestParameters{TopK: 40},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]any
+			client := newClient(tt.compatible, &body)
+
+			params := tt.params
+			params.Messages = []types.Message{{Speaker: types.HUMAN_MESSAGE_SPEAKER, Text: "hi"}}
+			for _, feature := range []types.CompletionsFeature{types.CompletionsFeatureChat, types.CompletionsFeatureCode} {
+				_, err := client.Complete(context.Background(), log.NoOp(), types.CompletionRequest{Feature: feature, Parameters: params})
+				require.Error(t, err)
+				require.NotNil(t, body, "feature %s", feature)
+				assert.Equal(t, tt.wantStop, body["stop"], "feature %s", feature)
+				assert.Equal(t, tt.wantTopK, body["top_k"], "feature %s", feature)
+			}
+		})
+	}
+}
+
+func TestCompleteCanceled(t *testing.T) {
+	// The response headers arrive, but the body never does.
+	body, w := io.Pipe()
+	defer w.Close()
+	tokenManager := tokenusage.NewManager()
+	client := NewClient(&mockDoer{
+		func(r *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: body}, nil
+		},
+	}, "", "", *tokenManager)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Complete(ctx, log.NoOp(), types.CompletionRequest{
+			Feature: types.CompletionsFeatureChat,
+			Parameters: types.CompletionRequestParameters{
+				Messages: []types.Message{{Speaker: types.HUMAN_MESSAGE_SPEAKER, Text: "hi"}},
+			},
+		})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(10 * time.Second):
+		t.Fatal("Complete did not return after the context was canceled")
+	}
+}
+
+func TestNewProviderClient(t *testing.T) {
+	// Usage of OpenAI-compatible providers must not be recorded as OpenAI usage.
+	c := NewProviderClient(nil, "https://api.mistral.ai", "token", tokenusage.Mistral, *tokenusage.NewManager())
+	assert.Equal(t, tokenusage.Mistral, c.(*openAIChatCompletionStreamClient).provider)
+
+	c = NewClient(nil, "https://api.openai.com", "token", *tokenusage.NewManager())
+	assert.Equal(t, tokenusage.OpenAI, c.(*openAIChatCompletionStreamClient).provider)
+}
@@ -7,6 +7,7 @@ type openAIChatCompletionsRequestParameters struct {
 	Messages         []message          `json:"messages"`                    // request.Messages
 	Temperature      float32            `json:"temperature,omitempty"`       // request.Temperature
 	TopP             float32            `json:"top_p,omitempty"`             // request.TopP
+	TopK             int                `json:"top_k,omitempty"`             // request.TopK, only for OpenAI-compatible backends
 	N                int                `json:"n,omitempty"`                 // always 1
 	Stream           bool               `json:"stream,omitempty"`            // request.Stream
 	Stop             []string           `json:"stop,omitempty"`              // request.StopSequences
@@ -24,6 +25,7 @@ type openAICompletionsRequestParameters struct {
 	Prompt           string             `json:"prompt"`                      // request.Messages[0] - formatted prompt expected to be the only message
 	Temperature      float32            `json:"temperature,omitempty"`       // request.Temperature
 	TopP             float32            `json:"top_p,omitempty"`             // request.TopP
+	TopK             int                `json:"top_k,omitempty"`             // request.TopK, only for OpenAI-compatible backends
 	N                int                `json:"n,omitempty"`                 // always 1
 	Stream           bool               `json:"stream,omitempty"`            // request.Stream
 	Stop             []string           `json:"stop,omitempty"`              // request.StopSequences
@@ -63,3 +65,13 @@ type openaiResponse struct {
 	Model   string         `json:"model"`
 	Choices []openaiChoice `json:"choices"`
 }
+
+// openaiErrorResponse is the body OpenAI and compatible servers return for
+// non-200 responses.
+// https://platform.openai.com/docs/guides/error-codes/api-errors
+type openaiErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
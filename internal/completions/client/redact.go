@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sourcegraph/log"
+
+	"github.com/sourcegraph/sourcegraph/internal/completions/types"
+	"github.com/sourcegraph/sourcegraph/internal/env"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+var promptRedaction = env.Get("SRC_COMPLETIONS_LOG_PROMPT_REDACTION", "strip", "How the user-supplied content of completions requests, i.e. the message texts and stop sequences, is redacted before requests are logged. Either \"strip\" to remove it, or \"hash\" to replace it with a hash, so that requests with the same content can be correlated.")
+
+// RedactFunc returns a copy of the given request parameters that is safe to
+// log. It must not modify the parameters it is given.
+type RedactFunc func(types.CompletionRequestParameters) types.CompletionRequestParameters
+
+// StripContent is a RedactFunc that removes the text of all messages and all
+// stop sequences. All other fields, including the speakers and the number of
+// messages and stop sequences, are kept.
+func StripContent(params types.CompletionRequestParameters) types.CompletionRequestParameters {
+	return redactContent(params, func(string) string { return "" })
+}
+
+// HashContent is a RedactFunc that replaces the text of all messages and all
+// stop sequences with a truncated SHA-256 hash. All other fields are kept.
+func HashContent(params types.CompletionRequestParameters) types.CompletionRequestParameters {
+	return redactContent(params, func(text string) string {
+		sum := sha256.Sum256([]byte(text))
+		return fmt.Sprintf("sha256:%x", sum[:8])
+	})
+}
+
+// redactContent applies redact to all free-form text the user supplied in
+// params.
+func redactContent(params types.CompletionRequestParameters, redact func(string) string) types.CompletionRequestParameters {
+	messages := make([]types.Message, len(params.Messages))
+	for i, m := range params.Messages {
+		m.Text = redact(m.Text)
+		messages[i] = m
+	}
+	params.Messages = messages
+
+	if params.StopSequences != nil {
+		stopSequences := make([]string, len(params.StopSequences))
+		for i, s := range params.StopSequences {
+			stopSequences[i] = redact(s)
+		}
+		params.StopSequences = stopSequences
+	}
+	return params
+}
+
+// DefaultRedactFunc returns the RedactFunc configured via the environment.
+// Unknown values fall back to StripContent, so that content is never logged by
+// accident.
+func DefaultRedactFunc() RedactFunc {
+	if promptRedaction == "hash" {
+		return HashContent
+	}
+	return StripContent
+}
+
+// logFailedRequest logs a completions request that failed with err. The request
+// parameters are passed through redact first.
+func logFailedRequest(logger log.Logger, redact RedactFunc, request types.CompletionRequest, err error) {
+	// The client went away, this is not worth logging.
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	fields := []log.Field{
+		log.Error(err),
+		log.String("feature", string(request.Feature)),
+		log.String("model", request.ModelConfigInfo.Model.ModelName),
+	}
+	if params, err := json.Marshal(redact(request.Parameters)); err == nil {
+		fields = append(fields, log.String("parameters", string(params)))
+	}
+	// Failed requests are already recorded as errors by the observation
+	// context, so this is only useful for debugging.
+	logger.Debug("completions request failed", fields...)
+}
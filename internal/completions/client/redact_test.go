@@ -0,0 +1,74 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/sourcegraph/log/logtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/completions/types"
+	modelconfigSDK "github.com/sourcegraph/sourcegraph/internal/modelconfig/types"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+func TestRedactFuncs(t *testing.T) {
+	params := types.CompletionRequestParameters{
+		Messages: []types.Message{
+			{Speaker: types.HUMAN_MESSAGE_SPEAKER, Text: "my secret code"},
+			{Speaker: types.ASSISTANT_MESSAGE_SPEAKER, Text: "my secret code"},
+		},
+		StopSequences:     []string{"my secret stop"},
+		MaxTokensToSample: 256,
+	}
+
+	stripped := StripContent(params)
+	assert.Equal(t, []types.Message{
+		{Speaker: types.HUMAN_MESSAGE_SPEAKER},
+		{Speaker: types.ASSISTANT_MESSAGE_SPEAKER},
+	}, stripped.Messages)
+	assert.Equal(t, []string{""}, stripped.StopSequences)
+	assert.Equal(t, 256, stripped.MaxTokensToSample)
+
+	hashed := HashContent(params)
+	require.Len(t, hashed.Messages, 2)
+	assert.Regexp(t, `^sha256:[0-9a-f]{16}$`, hashed.Messages[0].Text)
+	// The same content hashes to the same value, so requests can be correlated.
+	assert.Equal(t, hashed.Messages[0].Text, hashed.Messages[1].Text)
+	require.Len(t, hashed.StopSequences, 1)
+	assert.Regexp(t, `^sha256:[0-9a-f]{16}$`, hashed.StopSequences[0])
+
+	// The original parameters are left alone.
+	assert.Equal(t, "my secret code", params.Messages[0].Text)
+	assert.Equal(t, "my secret stop", params.StopSequences[0])
+}
+
+func TestLogFailedRequest(t *testing.T) {
+	logger, exportLogs := logtest.Captured(t)
+
+	request := types.CompletionRequest{
+		Feature:         types.CompletionsFeatureChat,
+		ModelConfigInfo: types.ModelConfigInfo{Model: modelconfigSDK.Model{ModelName: "claude-3-haiku"}},
+		Parameters: types.CompletionRequestParameters{
+			Messages: []types.Message{
+				{Speaker: types.HUMAN_MESSAGE_SPEAKER, Text: "my secret code"},
+			},
+			StopSequences:     []string{"my secret stop"},
+			MaxTokensToSample: 256,
+		},
+	}
+	logFailedRequest(logger, StripContent, request, errors.New("upstream error"))
+
+	logs := exportLogs()
+	require.Len(t, logs, 1)
+	fields := logs[0].Fields
+	assert.Equal(t, "claude-3-haiku", fields["model"])
+	assert.Equal(t, string(types.CompletionsFeatureChat), fields["feature"])
+
+	parameters, ok := fields["parameters"].(string)
+	require.True(t, ok)
+	assert.NotContains(t, parameters, "my secret code")
+	assert.NotContains(t, parameters, "my secret stop")
+	assert.Contains(t, parameters, `"speaker":"human"`)
+	assert.Contains(t, parameters, `"maxTokensToSample":256`)
+}
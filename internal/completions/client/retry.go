@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/internal/completions/types"
+	"github.com/sourcegraph/sourcegraph/internal/env"
+)
+
+var maxRequestRetries = env.MustGetInt("SRC_COMPLETIONS_MAX_RETRIES", 2, "The maximum number of times a completions request that failed with a transient error, e.g. a 429 or 5xx response, is retried. 0 disables retries.")
+
+// RetryPolicy controls how completions requests that failed with a transient
+// error are retried. Whether an error is transient is decided by
+// types.IsRetryableError, so that all providers are retried consistently.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of retries. A value <= 0 disables
+	// retries.
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry, it doubles with
+	// every further retry.
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximum delay before a retry. If the upstream asks to
+	// be retried later than that, e.g. with a Retry-After header, the request
+	// is not retried.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy returns the retry policy configured via the environment.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:     maxRequestRetries,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+	}
+}
+
+// Do calls fn until it succeeds, fails with an error that is not retryable, or
+// the retries are exhausted, and returns the last error. retryable is
+// consulted in addition to types.IsRetryableError, e.g. to not retry streaming
+// requests that already sent events to the client.
+func (p RetryPolicy) Do(ctx context.Context, retryable func() bool, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxRetries || !types.IsRetryableError(err) || !retryable() {
+			return err
+		}
+
+		backoff, ok := p.backoff(err, attempt)
+		if !ok {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// backoff returns the delay before retrying after the given failed attempt.
+// It returns false if the upstream asked to be retried later than MaxBackoff.
+func (p RetryPolicy) backoff(err error, attempt int) (time.Duration, bool) {
+	if statusErr, ok := types.IsErrStatusNotOK(err); ok && !statusErr.RetryAfter.IsZero() {
+		retryAfter := time.Until(statusErr.RetryAfter)
+		return max(retryAfter, 0), retryAfter <= p.MaxBackoff
+	}
+	return min(p.InitialBackoff<<attempt, p.MaxBackoff), true
+}
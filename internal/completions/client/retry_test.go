@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/log"
+	"github.com/sourcegraph/log/logtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/completions/types"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+func TestRetryPolicy_Do(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+	always := func() bool { return true }
+	statusErr := func(statusCode int) error {
+		return &types.ErrStatusNotOK{Source: "test", StatusCode: statusCode}
+	}
+
+	t.Run("retries transient errors", func(t *testing.T) {
+		calls := 0
+		err := policy.Do(context.Background(), always, func() error {
+			calls++
+			if calls < 3 {
+				return statusErr(http.StatusServiceUnavailable)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		calls := 0
+		err := policy.Do(context.Background(), always, func() error {
+			calls++
+			return statusErr(http.StatusTooManyRequests)
+		})
+		require.Error(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("does not retry other errors", func(t *testing.T) {
+		calls := 0
+		err := policy.Do(context.Background(), always, func() error {
+			calls++
+			return statusErr(http.StatusBadRequest)
+		})
+		require.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("does not retry if not retryable", func(t *testing.T) {
+		calls := 0
+		err := policy.Do(context.Background(), func() bool { return false }, func() error {
+			calls++
+			return statusErr(http.StatusServiceUnavailable)
+		})
+		require.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("does not retry if asked to retry too late", func(t *testing.T) {
+		calls := 0
+		err := policy.Do(context.Background(), always, func() error {
+			calls++
+			return &types.ErrStatusNotOK{Source: "test", StatusCode: http.StatusTooManyRequests, RetryAfter: time.Now().Add(time.Hour)}
+		})
+		require.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		calls := 0
+		err := RetryPolicy{}.Do(context.Background(), always, func() error {
+			calls++
+			return statusErr(http.StatusServiceUnavailable)
+		})
+		require.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+type streamFunc func(send types.SendCompletionEvent) error
+
+func (f streamFunc) Stream(_ context.Context, _ log.Logger, _ types.CompletionRequest, send types.SendCompletionEvent) error {
+	return f(send)
+}
+
+func (f streamFunc) Complete(context.Context, log.Logger, types.CompletionRequest) (*types.CompletionResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestObservedClient_StreamRetries(t *testing.T) {
+	logger := logtest.Scoped(t)
+	newClient := func(inner types.CompletionsClient) *observedClient {
+		client := newObservedClient(logger, nil, inner)
+		client.retry = RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+		return client
+	}
+	noop := func(types.CompletionResponse) error { return nil }
+
+	t.Run("retries before the first event", func(t *testing.T) {
+		calls := 0
+		client := newClient(streamFunc(func(send types.SendCompletionEvent) error {
+			calls++
+			if calls == 1 {
+				return &types.ErrStatusNotOK{Source: "test", StatusCode: http.StatusServiceUnavailable}
+			}
+			return send(types.CompletionResponse{Completion: "hello"})
+		}))
+		require.NoError(t, client.Stream(context.Background(), logger, types.CompletionRequest{}, noop))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("does not retry after the first event", func(t *testing.T) {
+		calls := 0
+		client := newClient(streamFunc(func(send types.SendCompletionEvent) error {
+			calls++
+			_ = send(types.CompletionResponse{Completion: "hello"})
+			return &types.ErrStatusNotOK{Source: "test", StatusCode: http.StatusServiceUnavailable}
+		}))
+		require.Error(t, client.Stream(context.Background(), logger, types.CompletionRequest{}, noop))
+		assert.Equal(t, 1, calls)
+	})
+}
@@ -2,6 +2,7 @@ package tokenizer
 
 import (
 	_ "embed"
+	"unicode/utf8"
 
 	_ "embed"
 
@@ -63,3 +64,14 @@ func NewCL100kBaseTokenizer() (Tokenizer, error) {
 
 	return &tiktokenTokenizer{tkm}, nil
 }
+
+// charsPerToken is the average number of characters per token of English text
+// and code for the tokenizers we use.
+const charsPerToken = 4
+
+// EstimateTokens returns a rough, character-based estimate of the number of
+// tokens in text. It should only be used when a precise count can't be
+// computed, as the estimate may be off considerably for non-English text.
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
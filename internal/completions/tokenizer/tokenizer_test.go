@@ -105,3 +105,12 @@ func TestTokenize(t *testing.T) {
 		})
 	}
 }
+
+func TestEstimateTokens(t *testing.T) {
+	require.Equal(t, 0, tokenizer.EstimateTokens(""))
+	require.Equal(t, 1, tokenizer.EstimateTokens("a"))
+	require.Equal(t, 1, tokenizer.EstimateTokens("abcd"))
+	require.Equal(t, 2, tokenizer.EstimateTokens("abcde"))
+	// Characters are counted, not bytes.
+	require.Equal(t, 1, tokenizer.EstimateTokens("🤚🏾"))
+}
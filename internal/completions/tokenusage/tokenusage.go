@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/sourcegraph/sourcegraph/internal/completions/tokenizer"
+	"github.com/sourcegraph/sourcegraph/internal/completions/types"
 	"github.com/sourcegraph/sourcegraph/internal/rcache"
 	"github.com/sourcegraph/sourcegraph/internal/redispool"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
@@ -31,6 +33,7 @@ const (
 	AzureOpenAI Provider = "azureopenai"
 	AwsBedrock  Provider = "awsbedrock"
 	Anthropic   Provider = "anthropic"
+	Mistral     Provider = "mistral"
 )
 
 func (m *Manager) UpdateTokenCountsFromModelUsage(inputTokens, outputTokens int, model, feature string, provider Provider) error {
@@ -45,6 +48,79 @@ func (m *Manager) UpdateTokenCountsFromModelUsage(inputTokens, outputTokens int,
 	return nil
 }
 
+// TokenCount is the number of tokens of a request or response.
+type TokenCount struct {
+	Tokens int
+	// Estimated is true if Tokens is a heuristic estimate, because the
+	// tokens couldn't be counted precisely.
+	Estimated bool
+}
+
+// UpdateTokenCounts records token usage like UpdateTokenCountsFromModelUsage.
+// Estimated counts are additionally recorded under separate "input_estimated"
+// and "output_estimated" keys, which hold the part of the input and output
+// totals that was estimated.
+func (m *Manager) UpdateTokenCounts(input, output TokenCount, model, feature string, provider Provider) error {
+	if err := m.UpdateTokenCountsFromModelUsage(input.Tokens, output.Tokens, model, feature, provider); err != nil {
+		return err
+	}
+
+	baseKey := fmt.Sprintf("%s:%s:%s:", provider, model, feature)
+	if input.Estimated {
+		if err := m.updateTokenCounts(baseKey+"input_estimated", int64(input.Tokens)); err != nil {
+			return errors.Newf("failed to update estimated input token counts: %w", err)
+		}
+	}
+	if output.Estimated {
+		if err := m.updateTokenCounts(baseKey+"output_estimated", int64(output.Tokens)); err != nil {
+			return errors.Newf("failed to update estimated output token counts: %w", err)
+		}
+	}
+	return nil
+}
+
+// UpdateReportedTokenCounts records the token usage a provider reported for
+// request and its completion. Providers don't always report usage, e.g. when a
+// stream ends before the event that carries it, so counts that weren't
+// reported are estimated from the request's messages and the completion, and
+// recorded as estimated.
+func (m *Manager) UpdateReportedTokenCounts(request types.CompletionRequest, completion string, inputTokens, outputTokens int, model string, provider Provider) error {
+	var prompt strings.Builder
+	for _, message := range request.Parameters.Messages {
+		prompt.WriteString(message.Text)
+	}
+	return m.UpdateTokenCounts(
+		ReportedOrEstimated(inputTokens, prompt.String()),
+		ReportedOrEstimated(outputTokens, completion),
+		model, string(request.Feature), provider)
+}
+
+// ReportedOrEstimated returns the number of tokens a provider reported for
+// text. If it reported none although text isn't empty, the count wasn't
+// reported and is estimated from text instead.
+func ReportedOrEstimated(reported int, text string) TokenCount {
+	if reported > 0 || text == "" {
+		return TokenCount{Tokens: reported}
+	}
+	return TokenCount{Tokens: tokenizer.EstimateTokens(text), Estimated: true}
+}
+
+// UpdatePromptCacheTokenCounts records the number of input tokens that were
+// written to and read from the provider's prompt cache. These are billed
+// differently from regular input tokens, and are not included in the input
+// counts recorded by UpdateTokenCountsFromModelUsage.
+func (m *Manager) UpdatePromptCacheTokenCounts(cacheCreationTokens, cacheReadTokens int, model, feature string, provider Provider) error {
+	baseKey := fmt.Sprintf("%s:%s:%s:", provider, model, feature)
+
+	if err := m.updateTokenCounts(baseKey+"cache_creation_input", int64(cacheCreationTokens)); err != nil {
+		return errors.Newf("failed to update cache creation token counts: %w", err)
+	}
+	if err := m.updateTokenCounts(baseKey+"cache_read_input", int64(cacheReadTokens)); err != nil {
+		return errors.Newf("failed to update cache read token counts: %w", err)
+	}
+	return nil
+}
+
 func (m *Manager) updateTokenCounts(key string, tokenCount int64) error {
 	if _, err := m.cache.IncrByInt64(key, tokenCount); err != nil {
 		return errors.Newf("failed to increment token count for key %s: %w", key, err)
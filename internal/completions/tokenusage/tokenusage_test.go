@@ -43,3 +43,23 @@ func TestGetAllTokenUsageData(t *testing.T) {
 		}
 	}
 }
+
+func TestReportedOrEstimated(t *testing.T) {
+	tests := []struct {
+		name     string
+		reported int
+		text     string
+		want     tokenusage.TokenCount
+	}{
+		{name: "reported", reported: 3, text: "hello world", want: tokenusage.TokenCount{Tokens: 3}},
+		{name: "not reported", reported: 0, text: "hello world", want: tokenusage.TokenCount{Tokens: 3, Estimated: true}},
+		{name: "empty text", reported: 0, text: "", want: tokenusage.TokenCount{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenusage.ReportedOrEstimated(tt.reported, tt.text); got != tt.want {
+				t.Errorf("ReportedOrEstimated() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
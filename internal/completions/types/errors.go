@@ -1,10 +1,15 @@
 package types
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/sourcegraph/log"
 
@@ -27,6 +32,13 @@ type ErrStatusNotOK struct {
 	SourceTraceContext *log.TraceContext
 
 	StatusCode int
+	// RetryAfter is when the source asked for the request to be retried, e.g.
+	// after being rate limited, as given by the Retry-After header of the
+	// response. It is zero if the source didn't say.
+	RetryAfter time.Time
+	// RateLimit is the rate limit the request was subject to. It is nil if the
+	// source didn't report it, or if the client doesn't parse it.
+	RateLimit *RateLimit
 	// responseBody is a truncated copy of the response body, read on a best-effort basis.
 	responseBody   string
 	responseHeader http.Header
@@ -34,6 +46,14 @@ type ErrStatusNotOK struct {
 
 var _ error = &ErrStatusNotOK{}
 
+// RateLimit is the state of a rate limit as reported by a source.
+type RateLimit struct {
+	// Limit is the number of requests allowed by the rate limit.
+	Limit int
+	// Remaining is the number of requests left until the rate limit is hit.
+	Remaining int
+}
+
 func (e *ErrStatusNotOK) Error() string {
 	return fmt.Sprintf("%s: unexpected status code %d: %s",
 		e.Source, e.StatusCode, e.responseBody)
@@ -66,11 +86,37 @@ func NewErrStatusNotOK(source string, resp *http.Response) error {
 		SourceTraceContext: tc,
 
 		StatusCode:     resp.StatusCode,
+		RetryAfter:     ParseRetryAfter(resp.Header, time.Now()),
 		responseBody:   string(respBody),
 		responseHeader: resp.Header,
 	}
 }
 
+// ParseRetryAfter returns the time given by the Retry-After header, which is
+// either a number of seconds or an HTTP date. It returns the zero time if the
+// header is missing or invalid.
+func ParseRetryAfter(header http.Header, now time.Time) time.Time {
+	v := header.Get("Retry-After")
+	if v == "" {
+		return time.Time{}
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return time.Time{}
+		}
+		return now.Add(time.Duration(seconds) * time.Second)
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+// Header returns the headers of the response the error was created from.
+func (e *ErrStatusNotOK) Header() http.Header {
+	return e.responseHeader
+}
+
 func IsErrStatusNotOK(err error) (*ErrStatusNotOK, bool) {
 	if err == nil {
 		return nil, false
@@ -103,6 +149,12 @@ func (e *ErrStatusNotOK) WriteHeader(w http.ResponseWriter) {
 		}
 	}
 
+	// Normalize the retry-after header to an HTTP date, which is also correct
+	// if the source responded with a number of seconds a while ago.
+	if !e.RetryAfter.IsZero() {
+		w.Header().Set("retry-after", e.RetryAfter.UTC().Format(http.TimeFormat))
+	}
+
 	// WriteHeader must come last, since it flushes the headers.
 	switch e.StatusCode {
 	// Only write back certain allow-listed status codes as-is - all other status
@@ -117,3 +169,44 @@ func (e *ErrStatusNotOK) WriteHeader(w http.ResponseWriter) {
 		w.WriteHeader(http.StatusServiceUnavailable)
 	}
 }
+
+// IsRetryableStatusCode returns true if a request to an LLM API that failed
+// with the given status code may succeed when retried, because the upstream
+// was rate limited, overloaded or temporarily unavailable.
+func IsRetryableStatusCode(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusRequestTimeout:
+		return true
+	case http.StatusNotImplemented, http.StatusHTTPVersionNotSupported:
+		return false
+	}
+	// Some providers use non-standard 5xx codes, e.g. Anthropic responds with
+	// 529 when overloaded.
+	return statusCode >= 500 && statusCode < 600
+}
+
+// IsRetryableError returns true if err, returned by a request to an LLM API,
+// is transient, ie. the same request may succeed when retried. Completions
+// clients should use this to decide whether to retry a request, so that retry
+// behavior is consistent across providers.
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// The caller gave up, retrying won't help.
+	if errors.IsAny(err, context.Canceled, context.DeadlineExceeded) {
+		return false
+	}
+
+	if e, ok := IsErrStatusNotOK(err); ok {
+		return IsRetryableStatusCode(e.StatusCode)
+	}
+
+	// Transient network errors.
+	if errors.IsAny(err, syscall.ECONNREFUSED, syscall.ECONNRESET, syscall.EPIPE, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
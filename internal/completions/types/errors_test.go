@@ -1,14 +1,19 @@
 package types
 
 import (
+	"context"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/hexops/autogold/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
 func TestErrStatusNotOK(t *testing.T) {
@@ -76,3 +81,74 @@ func TestErrStatusNotOKWriteHeader503(t *testing.T) {
 	assert.Equal(t, http.StatusServiceUnavailable, writtenResp.StatusCode)
 	assert.Equal(t, resp.Header, writtenResp.Header)
 }
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name  string
+		value string
+		want  time.Time
+	}{
+		{name: "missing", value: "", want: time.Time{}},
+		{name: "delta-seconds", value: "30", want: now.Add(30 * time.Second)},
+		{name: "negative delta-seconds", value: "-1", want: time.Time{}},
+		{name: "HTTP-date", value: "Mon, 01 Jan 2024 12:01:00 GMT", want: now.Add(time.Minute)},
+		{name: "invalid", value: "soon", want: time.Time{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			header := http.Header{}
+			if tc.value != "" {
+				header.Set("Retry-After", tc.value)
+			}
+			got := ParseRetryAfter(header, now)
+			assert.True(t, tc.want.Equal(got), "want %s, got %s", tc.want, got)
+		})
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	statusErr := func(statusCode int) error {
+		rec := httptest.NewRecorder()
+		rec.WriteHeader(statusCode)
+		return errors.Wrap(NewErrStatusNotOK("test", rec.Result()), "request failed")
+	}
+
+	// Dial a port nobody is listening on anymore.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+	_, connRefusedErr := http.Get("http://" + addr)
+	require.Error(t, connRefusedErr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr, nil)
+	require.NoError(t, err)
+	_, canceledErr := http.DefaultClient.Do(req)
+	require.Error(t, canceledErr)
+
+	for _, tc := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "429", err: statusErr(http.StatusTooManyRequests), want: true},
+		{name: "500", err: statusErr(http.StatusInternalServerError), want: true},
+		{name: "503", err: statusErr(http.StatusServiceUnavailable), want: true},
+		{name: "529", err: statusErr(529), want: true},
+		{name: "400", err: statusErr(http.StatusBadRequest), want: false},
+		{name: "401", err: statusErr(http.StatusUnauthorized), want: false},
+		{name: "501", err: statusErr(http.StatusNotImplemented), want: false},
+		{name: "context canceled", err: canceledErr, want: false},
+		{name: "deadline exceeded", err: errors.Wrap(context.DeadlineExceeded, "request failed"), want: false},
+		{name: "connection refused", err: connRefusedErr, want: true},
+		{name: "unexpected EOF", err: errors.Wrap(io.ErrUnexpectedEOF, "reading response"), want: true},
+		{name: "other", err: errors.New("invalid model"), want: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, IsRetryableError(tc.err))
+		})
+	}
+}
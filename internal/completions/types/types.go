@@ -20,6 +20,12 @@ const SYSTEM_MESSAGE_SPEAKER = "system"
 type Message struct {
 	Speaker string `json:"speaker"`
 	Text    string `json:"text"`
+
+	// CacheBreakpoint marks the end of a prompt prefix that the LLM API may
+	// cache, so that subsequent requests starting with the same messages, up to
+	// and including this one, are cheaper and faster. It is a hint and is
+	// ignored by providers that don't support prompt caching.
+	CacheBreakpoint bool `json:"cacheBreakpoint,omitempty"`
 }
 
 func (m Message) IsValidSpeaker() bool {
@@ -144,6 +150,18 @@ type CompletionRequestParameters struct {
 	TopP              float32   `json:"topP,omitempty"`
 	Stream            *bool     `json:"stream,omitempty"`
 	Logprobs          *uint8    `json:"logprobs"`
+
+	// ThinkingBudget is the number of tokens the model may spend on reasoning
+	// before producing its response. Zero means reasoning is not requested. It is
+	// only valid for models with the "reasoning" capability. Anthropic models,
+	// also on AWS Bedrock, require a budget of at least 1024 tokens. OpenAI and
+	// Azure OpenAI ignore the budget, use ReasoningEffort for them instead.
+	ThinkingBudget int `json:"thinkingBudget,omitempty"`
+
+	// ReasoningEffort hints how much effort reasoning models such as OpenAI's
+	// o1 and o3 spend on reasoning, one of "low", "medium" or "high". If empty,
+	// the provider's default is used.
+	ReasoningEffort string `json:"reasoningEffort,omitempty"`
 }
 
 // IsStream returns whether a streaming response is requested. For backwards
@@ -183,9 +201,90 @@ func (p *CompletionRequestParameters) Attrs(modelName string, feature Completion
 }
 
 type CompletionResponse struct {
-	Completion string    `json:"completion"`
-	StopReason string    `json:"stopReason"`
-	Logprobs   *Logprobs `json:"logprobs,omitempty"`
+	Completion string `json:"completion"`
+	// StopReason is the reason the model stopped generating, as reported by
+	// the LLM API. Its values depend on the provider, see NormalizedStopReason
+	// for a provider independent value.
+	StopReason string `json:"stopReason"`
+	// NormalizedStopReason is StopReason mapped to the vocabulary shared by
+	// all providers. It is empty as long as the model didn't stop.
+	NormalizedStopReason StopReason `json:"normalizedStopReason,omitempty"`
+	Logprobs             *Logprobs  `json:"logprobs,omitempty"`
+	// Usage is set on streamed events if the provider reported the token usage
+	// of the request since the previous event. The usage reported last is the
+	// authoritative one, earlier values are running totals.
+	Usage *TokenUsage `json:"usage,omitempty"`
+}
+
+// TokenUsage is the number of tokens used by a completions request.
+type TokenUsage struct {
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+}
+
+// StopReason is the reason a model stopped generating, normalized across
+// providers.
+type StopReason string
+
+const (
+	// StopReasonEndTurn means the model finished its response. Some providers
+	// (e.g. OpenAI) also report this if a stop sequence was generated.
+	StopReasonEndTurn StopReason = "end_turn"
+	// StopReasonMaxTokens means the response was cut off because it reached the
+	// maximum number of tokens to sample, or the context window of the model.
+	StopReasonMaxTokens StopReason = "max_tokens"
+	// StopReasonStopSequence means the model generated one of the requested
+	// stop sequences.
+	StopReasonStopSequence StopReason = "stop_sequence"
+	// StopReasonToolUse means the model stopped to call a tool.
+	StopReasonToolUse StopReason = "tool_use"
+	// StopReasonContentFilter means the response was withheld or cut off by the
+	// provider's content filters.
+	StopReasonContentFilter StopReason = "content_filter"
+	// StopReasonOther is used for stop reasons without a normalized equivalent.
+	// Refer to the raw value in CompletionResponse.StopReason.
+	StopReasonOther StopReason = "other"
+)
+
+// NormalizeOpenAIStopReason maps the finish reasons of the OpenAI API, which
+// are also used by OpenAI-compatible providers, to a StopReason. OpenAI
+// reports "stop" both if the model finished its response and if it generated a
+// stop sequence.
+func NormalizeOpenAIStopReason(reason string) StopReason {
+	switch reason {
+	case "":
+		return ""
+	case "stop":
+		return StopReasonEndTurn
+	case "length":
+		return StopReasonMaxTokens
+	case "content_filter":
+		return StopReasonContentFilter
+	case "tool_calls", "function_call":
+		return StopReasonToolUse
+	default:
+		return StopReasonOther
+	}
+}
+
+// NormalizeAnthropicStopReason maps the stop reasons of the Anthropic Messages
+// API, which are also used by Anthropic models on other platforms, to a
+// StopReason.
+func NormalizeAnthropicStopReason(reason string) StopReason {
+	switch reason {
+	case "":
+		return ""
+	case "end_turn":
+		return StopReasonEndTurn
+	case "max_tokens":
+		return StopReasonMaxTokens
+	case "stop_sequence":
+		return StopReasonStopSequence
+	case "tool_use":
+		return StopReasonToolUse
+	default:
+		return StopReasonOther
+	}
 }
 
 type Logprobs struct {
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestLegacyMessageConversion(t *testing.T) {
@@ -62,3 +63,30 @@ func TestLegacyMessageConversionWithTrailingAssistantResponse(t *testing.T) {
 		},
 	}).Equal(t, convertedMessages)
 }
+
+func TestNormalizeOpenAIStopReason(t *testing.T) {
+	for raw, want := range map[string]StopReason{
+		"":               "",
+		"stop":           StopReasonEndTurn,
+		"length":         StopReasonMaxTokens,
+		"content_filter": StopReasonContentFilter,
+		"tool_calls":     StopReasonToolUse,
+		"function_call":  StopReasonToolUse,
+		"something_new":  StopReasonOther,
+	} {
+		assert.Equal(t, want, NormalizeOpenAIStopReason(raw), "stop reason %q", raw)
+	}
+}
+
+func TestNormalizeAnthropicStopReason(t *testing.T) {
+	for raw, want := range map[string]StopReason{
+		"":              "",
+		"end_turn":      StopReasonEndTurn,
+		"max_tokens":    StopReasonMaxTokens,
+		"stop_sequence": StopReasonStopSequence,
+		"tool_use":      StopReasonToolUse,
+		"pause_turn":    StopReasonOther,
+	} {
+		assert.Equal(t, want, NormalizeAnthropicStopReason(raw), "stop reason %q", raw)
+	}
+}
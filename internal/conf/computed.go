@@ -963,6 +963,7 @@ func GetCompletionsConfig(siteConfig schema.SiteConfiguration) (c *conftypes.Com
 		PerCommunityUserCodeCompletionsMonthlyInteractionLimit: completionsConfig.PerCommunityUserCodeCompletionsMonthlyInteractionLimit,
 		PerProUserChatDailyInteractionLimit:                    completionsConfig.PerProUserChatDailyInteractionLimit,
 		PerProUserCodeCompletionsDailyInteractionLimit:         completionsConfig.PerProUserCodeCompletionsDailyInteractionLimit,
+		PerOrgMonthlyTokenBudget:                               completionsConfig.PerOrgMonthlyTokenBudget,
 		AzureCompletionModel:                                   completionsConfig.AzureCompletionModel,
 		AzureChatModel:                                         completionsConfig.AzureChatModel,
 	}
@@ -32,6 +32,7 @@ type CompletionsConfig struct {
 	PerCommunityUserCodeCompletionsMonthlyInteractionLimit int
 	PerProUserChatDailyInteractionLimit                    int
 	PerProUserCodeCompletionsDailyInteractionLimit         int
+	PerOrgMonthlyTokenBudget                               int
 	User                                                   string
 }
 
@@ -52,6 +53,7 @@ const (
 	CompletionsProviderNameSourcegraph CompletionsProviderName = "sourcegraph"
 	CompletionsProviderNameFireworks   CompletionsProviderName = "fireworks"
 	CompletionsProviderNameAWSBedrock  CompletionsProviderName = "aws-bedrock"
+	CompletionsProviderNameMistral     CompletionsProviderName = "mistral"
 )
 
 type EmbeddingsConfig struct {
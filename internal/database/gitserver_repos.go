@@ -44,7 +44,9 @@ type GitserverRepoStore interface {
 	SetCloneStatus(ctx context.Context, name api.RepoName, status types.CloneStatus, shardID string) error
 	// SetLastError will attempt to update ONLY the last error of a GitServerRepo. If
 	// a matching row does not yet exist a new one will be created.
-	// If the error value hasn't changed, the row will not be updated.
+	// A non-empty error also records the current time as the last error time. An
+	// empty error clears both. If the error was already empty, the row will not be
+	// updated.
 	SetLastError(ctx context.Context, name api.RepoName, error, shardID string) error
 	// SetLastOutput will attempt to create/update the output of the last repository clone/fetch.
 	// If a matching row does not exist, a new one will be created.
@@ -97,11 +99,12 @@ func (s *gitserverRepoStore) Transact(ctx context.Context) (GitserverRepoStore,
 func (s *gitserverRepoStore) Update(ctx context.Context, repos ...*types.GitserverRepo) error {
 	values := make([]*sqlf.Query, 0, len(repos))
 	for _, gr := range repos {
-		values = append(values, sqlf.Sprintf("(%s::integer, %s::text, %s::text, %s::text, %s::timestamp with time zone, %s::timestamp with time zone, %s::timestamp with time zone, %s::bigint, NOW())",
+		values = append(values, sqlf.Sprintf("(%s::integer, %s::text, %s::text, %s::text, %s::timestamp with time zone, %s::timestamp with time zone, %s::timestamp with time zone, %s::timestamp with time zone, %s::bigint, NOW())",
 			gr.RepoID,
 			gr.CloneStatus,
 			gr.ShardID,
 			dbutil.NewNullString(sanitizeToUTF8(gr.LastError)),
+			dbutil.NullTimeColumn(gr.LastErrorAt),
 			gr.LastFetched,
 			gr.LastChanged,
 			dbutil.NullTimeColumn(gr.CorruptedAt),
@@ -118,9 +121,9 @@ const updateGitserverReposQueryFmtstr = `
 WITH update_data AS (
 	SELECT * FROM (
 		VALUES
-		-- (<repo_id>, <clone_status>, <shard_id>, <last_error>, <last_fetched>, <last_changed>, <corrupted_at>, <repo_size_bytes>),
+		-- (<repo_id>, <clone_status>, <shard_id>, <last_error>, <last_error_at>, <last_fetched>, <last_changed>, <corrupted_at>, <repo_size_bytes>),
 			%s
-	) AS tmp(repo_id, clone_status, shard_id, last_error, last_fetched, last_changed, corrupted_at, repo_size_bytes)
+	) AS tmp(repo_id, clone_status, shard_id, last_error, last_error_at, last_fetched, last_changed, corrupted_at, repo_size_bytes)
 ),
 locked_data AS (
 	SELECT update_data.*
@@ -134,6 +137,7 @@ SET
 	clone_status = locked_data.clone_status,
 	shard_id = locked_data.shard_id,
 	last_error = locked_data.last_error,
+	last_error_at = locked_data.last_error_at,
 	last_fetched = locked_data.last_fetched,
 	last_changed = locked_data.last_changed,
 	corrupted_at = locked_data.corrupted_at,
@@ -256,6 +260,7 @@ SELECT
 	gr.clone_status,
 	gr.shard_id,
 	gr.last_error,
+	gr.last_error_at,
 	gr.last_fetched,
 	gr.last_changed,
 	gr.repo_size_bytes,
@@ -288,6 +293,7 @@ SELECT
 	gr.clone_status,
 	gr.shard_id,
 	gr.last_error,
+	gr.last_error_at,
 	gr.last_fetched,
 	gr.last_changed,
 	gr.repo_size_bytes,
@@ -317,6 +323,7 @@ SELECT
 	gr.clone_status,
 	gr.shard_id,
 	gr.last_error,
+	gr.last_error_at,
 	gr.last_fetched,
 	gr.last_changed,
 	gr.repo_size_bytes,
@@ -340,6 +347,7 @@ SELECT
 	gr.clone_status,
 	gr.shard_id,
 	gr.last_error,
+	gr.last_error_at,
 	gr.last_fetched,
 	gr.last_changed,
 	gr.repo_size_bytes,
@@ -382,6 +390,7 @@ func scanGitserverRepo(scanner dbutil.Scanner) (*types.GitserverRepo, api.RepoNa
 		&cloneStatus,
 		&gr.ShardID,
 		&dbutil.NullString{S: &gr.LastError},
+		&dbutil.NullTime{Time: &gr.LastErrorAt},
 		&gr.LastFetched,
 		&gr.LastChanged,
 		&dbutil.NullInt64{N: &gr.RepoSizeBytes},
@@ -429,13 +438,16 @@ func (s *gitserverRepoStore) SetLastError(ctx context.Context, name api.RepoName
 UPDATE gitserver_repos
 SET
 	last_error = %s,
+	last_error_at = CASE WHEN %s::text IS NULL THEN NULL ELSE NOW() END,
 	shard_id = %s,
 	updated_at = NOW()
 WHERE
 	repo_id = (SELECT id FROM repo WHERE name = %s)
 	AND
-	last_error IS DISTINCT FROM %s
-`, ns, shardID, name, ns))
+	-- Always record repeated errors, so that last_error_at reflects the most
+	-- recent failure.
+	(last_error IS DISTINCT FROM %s OR %s::text IS NOT NULL)
+`, ns, ns, shardID, name, ns, ns))
 	if err != nil {
 		return errors.Wrap(err, "setting last error")
 	}
@@ -617,11 +617,29 @@ func TestSetLastError(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	if fromDB.LastErrorAt.IsZero() {
+		t.Fatal("last_error_at should be set, but it was not")
+	}
 	gitserverRepo.LastError = "oops"
+	gitserverRepo.LastErrorAt = fromDB.LastErrorAt
 	if diff := cmp.Diff(gitserverRepo, fromDB, cmpopts.IgnoreFields(types.GitserverRepo{}, "UpdatedAt", "CorruptionLogs")); diff != "" {
 		t.Fatal(diff)
 	}
 
+	// Recording the same error again moves last_error_at forward.
+	err = db.GitserverRepos().SetLastError(ctx, repo.Name, "oops", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromDB, err = db.GitserverRepos().GetByID(ctx, gitserverRepo.RepoID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fromDB.LastErrorAt.Before(gitserverRepo.LastErrorAt) {
+		t.Fatalf("last_error_at moved backwards: %s < %s", fromDB.LastErrorAt, gitserverRepo.LastErrorAt)
+	}
+
 	// Remove error
 	const emptyErr = ""
 	err = db.GitserverRepos().SetLastError(ctx, repo.Name, emptyErr, "")
@@ -635,6 +653,7 @@ func TestSetLastError(t *testing.T) {
 	}
 
 	gitserverRepo.LastError = emptyErr
+	gitserverRepo.LastErrorAt = time.Time{}
 	if diff := cmp.Diff(gitserverRepo, fromDB, cmpopts.IgnoreFields(types.GitserverRepo{}, "UpdatedAt", "CorruptionLogs")); diff != "" {
 		t.Fatal(diff)
 	}
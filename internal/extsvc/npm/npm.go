@@ -173,6 +173,18 @@ func (client *HTTPClient) makeGetRequest(ctx context.Context, doer httpcli.Doer,
 	return resp.Body, nil
 }
 
+// Ping checks that the registry is reachable and accepts the configured
+// credentials, without requesting any package.
+func (client *HTTPClient) Ping(ctx context.Context) error {
+	// https://github.com/npm/cli/blob/latest/docs/lib/content/commands/npm-ping.md
+	url := fmt.Sprintf("%s/-/ping", client.registryURL)
+	body, err := client.makeGetRequest(ctx, client.uncachedClient, url)
+	if err != nil {
+		return err
+	}
+	return body.Close()
+}
+
 func (client *HTTPClient) GetDependencyInfo(ctx context.Context, dep *reposource.NpmVersionedPackage) (*DependencyInfo, error) {
 	// https://github.com/npm/registry/blob/master/docs/REGISTRY-API.md#getVersionedPackage
 	url := fmt.Sprintf("%s/%s/%s", client.registryURL, dep.PackageSyntax(), dep.Version)
@@ -29,6 +29,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/grpc/streamio"
 	"github.com/sourcegraph/sourcegraph/internal/observation"
 	"github.com/sourcegraph/sourcegraph/internal/perforce"
+	"github.com/sourcegraph/sourcegraph/internal/types"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
@@ -373,6 +374,11 @@ type Client interface {
 
 	RepoCloneProgress(context.Context, api.RepoName) (*protocol.RepoCloneProgress, error)
 
+	// ReposExist returns the clone status of each of the given repos. Unlike
+	// most other methods, it doesn't enqueue repos that aren't cloned for
+	// cloning.
+	ReposExist(context.Context, []api.RepoName) (map[api.RepoName]types.CloneStatus, error)
+
 	// ResolveRevision will return the absolute commit for a commit-ish spec. If spec is empty, HEAD is
 	// used.
 	//
@@ -707,6 +713,56 @@ func (c *clientImplementor) RepoCloneProgress(ctx context.Context, repo api.Repo
 	return &rcp, nil
 }
 
+func (c *clientImplementor) ReposExist(ctx context.Context, repos []api.RepoName) (_ map[api.RepoName]types.CloneStatus, err error) {
+	ctx, _, endObservation := c.operations.reposExist.With(ctx, &err, observation.Args{
+		MetricLabelValues: []string{c.scope},
+		Attrs: []attribute.KeyValue{
+			attribute.Int("numRepos", len(repos)),
+		},
+	})
+	defer endObservation(1, observation.Args{})
+
+	// Every gitserver only knows about the repos it owns, so we ask each of
+	// them for their share of repos.
+	reposByAddr := make(map[string][]api.RepoName)
+	for _, repo := range repos {
+		addr := c.clientSource.AddrForRepo(ctx, repo)
+		reposByAddr[addr] = append(reposByAddr[addr], repo)
+	}
+
+	var mu sync.Mutex
+	statuses := make(map[api.RepoName]types.CloneStatus, len(repos))
+	p := pool.New().WithErrors().WithContext(ctx)
+	for _, addrRepos := range reposByAddr {
+		p.Go(func(ctx context.Context) error {
+			client, err := c.clientSource.ClientForRepo(ctx, addrRepos[0])
+			if err != nil {
+				return err
+			}
+
+			names := make([]string, 0, len(addrRepos))
+			for _, repo := range addrRepos {
+				names = append(names, string(repo))
+			}
+			res, err := client.ReposExist(ctx, &proto.ReposExistRequest{RepoNames: names})
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for name, cloneStatus := range res.GetStatuses() {
+				statuses[api.RepoName(name)] = protocol.CloneStatusFromProto(cloneStatus)
+			}
+			return nil
+		})
+	}
+	if err := p.Wait(); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
 func (c *clientImplementor) IsPerforcePathCloneable(ctx context.Context, conn protocol.PerforceConnectionDetails, depotPath string) (err error) {
 	ctx, _, endObservation := c.operations.isPerforcePathCloneable.With(ctx, &err, observation.Args{
 		MetricLabelValues: []string{c.scope},
@@ -6,6 +6,8 @@ import (
 	"math/rand"
 	"reflect"
 	"sort"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"testing/quick"
@@ -21,6 +23,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/gitdomain"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	proto "github.com/sourcegraph/sourcegraph/internal/gitserver/v1"
+	"github.com/sourcegraph/sourcegraph/internal/types"
 )
 
 func TestClientArchiveOptions_ProtoRoundTrip(t *testing.T) {
@@ -341,6 +344,55 @@ func TestClient_SystemInfo(t *testing.T) {
 	}
 }
 
+func TestClient_ReposExist(t *testing.T) {
+	const (
+		gitserverAddr1 = "172.16.8.1:8080"
+		gitserverAddr2 = "172.16.8.2:8080"
+	)
+
+	var mu sync.Mutex
+	requestedAt := map[api.RepoName]string{}
+	source := gitserver.NewTestClientSource(t, []string{gitserverAddr1, gitserverAddr2}, func(o *gitserver.TestClientSourceOptions) {
+		o.ClientFunc = func(cc *grpc.ClientConn) proto.GitserverServiceClient {
+			cli := gitserver.NewStrictMockGitserverServiceClient()
+			cli.ReposExistFunc.SetDefaultHook(func(_ context.Context, in *proto.ReposExistRequest, _ ...grpc.CallOption) (*proto.ReposExistResponse, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				res := &proto.ReposExistResponse{Statuses: map[string]proto.CloneStatus{}}
+				for _, name := range in.GetRepoNames() {
+					requestedAt[api.RepoName(name)] = cc.Target()
+					if strings.HasPrefix(name, "cloned") {
+						res.Statuses[name] = proto.CloneStatus_CLONE_STATUS_CLONED
+					} else {
+						res.Statuses[name] = proto.CloneStatus_CLONE_STATUS_NOT_CLONED
+					}
+				}
+				return res, nil
+			})
+			return cli
+		}
+	})
+
+	client := gitserver.NewTestClient(t).WithClientSource(source)
+
+	repos := []api.RepoName{"cloned-a", "cloned-b", "cloned-c", "absent-a", "absent-b", "absent-c"}
+	statuses, err := client.ReposExist(context.Background(), repos)
+	require.NoError(t, err)
+	require.Equal(t, map[api.RepoName]types.CloneStatus{
+		"cloned-a": types.CloneStatusCloned,
+		"cloned-b": types.CloneStatusCloned,
+		"cloned-c": types.CloneStatusCloned,
+		"absent-a": types.CloneStatusNotCloned,
+		"absent-b": types.CloneStatusNotCloned,
+		"absent-c": types.CloneStatusNotCloned,
+	}, statuses)
+
+	// Every repo must have been asked for at the gitserver that owns it.
+	for _, repo := range repos {
+		require.Equal(t, source.AddrForRepo(context.Background(), repo), requestedAt[repo], repo)
+	}
+}
+
 type fuzzTime time.Time
 
 func (fuzzTime) Generate(rand *rand.Rand, _ int) reflect.Value {
@@ -157,6 +157,11 @@ func (r *errorTranslatingClient) RepoCloneProgress(ctx context.Context, in *prot
 	return res, convertGRPCErrorToGitDomainError(err)
 }
 
+func (r *errorTranslatingClient) ReposExist(ctx context.Context, in *proto.ReposExistRequest, opts ...grpc.CallOption) (*proto.ReposExistResponse, error) {
+	res, err := r.base.ReposExist(ctx, in, opts...)
+	return res, convertGRPCErrorToGitDomainError(err)
+}
+
 func (r *errorTranslatingClient) IsPerforcePathCloneable(ctx context.Context, in *proto.IsPerforcePathCloneableRequest, opts ...grpc.CallOption) (*proto.IsPerforcePathCloneableResponse, error) {
 	res, err := r.base.IsPerforcePathCloneable(ctx, in, opts...)
 	return res, convertGRPCErrorToGitDomainError(err)
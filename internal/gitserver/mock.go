@@ -105,6 +105,9 @@ type MockGitserverServiceClient struct {
 	// RepoCloneProgressFunc is an instance of a mock function object
 	// controlling the behavior of the method RepoCloneProgress.
 	RepoCloneProgressFunc *GitserverServiceClientRepoCloneProgressFunc
+	// ReposExistFunc is an instance of a mock function object controlling
+	// the behavior of the method ReposExist.
+	ReposExistFunc *GitserverServiceClientReposExistFunc
 	// ResolveRevisionFunc is an instance of a mock function object
 	// controlling the behavior of the method ResolveRevision.
 	ResolveRevisionFunc *GitserverServiceClientResolveRevisionFunc
@@ -264,6 +267,11 @@ func NewMockGitserverServiceClient() *MockGitserverServiceClient {
 				return
 			},
 		},
+		ReposExistFunc: &GitserverServiceClientReposExistFunc{
+			defaultHook: func(context.Context, *v1.ReposExistRequest, ...grpc.CallOption) (r0 *v1.ReposExistResponse, r1 error) {
+				return
+			},
+		},
 		ResolveRevisionFunc: &GitserverServiceClientResolveRevisionFunc{
 			defaultHook: func(context.Context, *v1.ResolveRevisionRequest, ...grpc.CallOption) (r0 *v1.ResolveRevisionResponse, r1 error) {
 				return
@@ -432,6 +440,11 @@ func NewStrictMockGitserverServiceClient() *MockGitserverServiceClient {
 				panic("unexpected invocation of MockGitserverServiceClient.RepoCloneProgress")
 			},
 		},
+		ReposExistFunc: &GitserverServiceClientReposExistFunc{
+			defaultHook: func(context.Context, *v1.ReposExistRequest, ...grpc.CallOption) (*v1.ReposExistResponse, error) {
+				panic("unexpected invocation of MockGitserverServiceClient.ReposExist")
+			},
+		},
 		ResolveRevisionFunc: &GitserverServiceClientResolveRevisionFunc{
 			defaultHook: func(context.Context, *v1.ResolveRevisionRequest, ...grpc.CallOption) (*v1.ResolveRevisionResponse, error) {
 				panic("unexpected invocation of MockGitserverServiceClient.ResolveRevision")
@@ -544,6 +557,9 @@ func NewMockGitserverServiceClientFrom(i v1.GitserverServiceClient) *MockGitserv
 		RepoCloneProgressFunc: &GitserverServiceClientRepoCloneProgressFunc{
 			defaultHook: i.RepoCloneProgress,
 		},
+		ReposExistFunc: &GitserverServiceClientReposExistFunc{
+			defaultHook: i.ReposExist,
+		},
 		ResolveRevisionFunc: &GitserverServiceClientResolveRevisionFunc{
 			defaultHook: i.ResolveRevision,
 		},
@@ -3945,6 +3961,127 @@ func (c GitserverServiceClientRepoCloneProgressFuncCall) Results() []interface{}
 	return []interface{}{c.Result0, c.Result1}
 }
 
+// GitserverServiceClientReposExistFunc describes the behavior when the
+// ReposExist method of the parent MockGitserverServiceClient instance is
+// invoked.
+type GitserverServiceClientReposExistFunc struct {
+	defaultHook func(context.Context, *v1.ReposExistRequest, ...grpc.CallOption) (*v1.ReposExistResponse, error)
+	hooks       []func(context.Context, *v1.ReposExistRequest, ...grpc.CallOption) (*v1.ReposExistResponse, error)
+	history     []GitserverServiceClientReposExistFuncCall
+	mutex       sync.Mutex
+}
+
+// ReposExist delegates to the next hook function in the queue and stores
+// the parameter and result values of this invocation.
+func (m *MockGitserverServiceClient) ReposExist(v0 context.Context, v1 *v1.ReposExistRequest, v2 ...grpc.CallOption) (*v1.ReposExistResponse, error) {
+	r0, r1 := m.ReposExistFunc.nextHook()(v0, v1, v2...)
+	m.ReposExistFunc.appendCall(GitserverServiceClientReposExistFuncCall{v0, v1, v2, r0, r1})
+	return r0, r1
+}
+
+// SetDefaultHook sets function that is called when the ReposExist method of
+// the parent MockGitserverServiceClient instance is invoked and the hook
+// queue is empty.
+func (f *GitserverServiceClientReposExistFunc) SetDefaultHook(hook func(context.Context, *v1.ReposExistRequest, ...grpc.CallOption) (*v1.ReposExistResponse, error)) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// ReposExist method of the parent MockGitserverServiceClient instance
+// invokes the hook at the front of the queue and discards it. After the
+// queue is empty, the default hook function is invoked for any future
+// action.
+func (f *GitserverServiceClientReposExistFunc) PushHook(hook func(context.Context, *v1.ReposExistRequest, ...grpc.CallOption) (*v1.ReposExistResponse, error)) {
+	f.mutex.Lock()
+	f.hooks = append(f.hooks, hook)
+	f.mutex.Unlock()
+}
+
+// SetDefaultReturn calls SetDefaultHook with a function that returns the
+// given values.
+func (f *GitserverServiceClientReposExistFunc) SetDefaultReturn(r0 *v1.ReposExistResponse, r1 error) {
+	f.SetDefaultHook(func(context.Context, *v1.ReposExistRequest, ...grpc.CallOption) (*v1.ReposExistResponse, error) {
+		return r0, r1
+	})
+}
+
+// PushReturn calls PushHook with a function that returns the given values.
+func (f *GitserverServiceClientReposExistFunc) PushReturn(r0 *v1.ReposExistResponse, r1 error) {
+	f.PushHook(func(context.Context, *v1.ReposExistRequest, ...grpc.CallOption) (*v1.ReposExistResponse, error) {
+		return r0, r1
+	})
+}
+
+func (f *GitserverServiceClientReposExistFunc) nextHook() func(context.Context, *v1.ReposExistRequest, ...grpc.CallOption) (*v1.ReposExistResponse, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+func (f *GitserverServiceClientReposExistFunc) appendCall(r0 GitserverServiceClientReposExistFuncCall) {
+	f.mutex.Lock()
+	f.history = append(f.history, r0)
+	f.mutex.Unlock()
+}
+
+// History returns a sequence of GitserverServiceClientReposExistFuncCall
+// objects describing the invocations of this function.
+func (f *GitserverServiceClientReposExistFunc) History() []GitserverServiceClientReposExistFuncCall {
+	f.mutex.Lock()
+	history := make([]GitserverServiceClientReposExistFuncCall, len(f.history))
+	copy(history, f.history)
+	f.mutex.Unlock()
+
+	return history
+}
+
+// GitserverServiceClientReposExistFuncCall is an object that describes an
+// invocation of method ReposExist on an instance of
+// MockGitserverServiceClient.
+type GitserverServiceClientReposExistFuncCall struct {
+	// Arg0 is the value of the 1st argument passed to this method
+	// invocation.
+	Arg0 context.Context
+	// Arg1 is the value of the 2nd argument passed to this method
+	// invocation.
+	Arg1 *v1.ReposExistRequest
+	// Arg2 is a slice containing the values of the variadic arguments
+	// passed to this method invocation.
+	Arg2 []grpc.CallOption
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 *v1.ReposExistResponse
+	// Result1 is the value of the 2nd result returned from this method
+	// invocation.
+	Result1 error
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation. The variadic slice argument is flattened in this array such
+// that one positional argument and three variadic arguments would result in
+// a slice of four, not two.
+func (c GitserverServiceClientReposExistFuncCall) Args() []interface{} {
+	trailing := []interface{}{}
+	for _, val := range c.Arg2 {
+		trailing = append(trailing, val)
+	}
+
+	return append([]interface{}{c.Arg0, c.Arg1}, trailing...)
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c GitserverServiceClientReposExistFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0, c.Result1}
+}
+
 // GitserverServiceClientResolveRevisionFunc describes the behavior when the
 // ResolveRevision method of the parent MockGitserverServiceClient instance
 // is invoked.
@@ -18,6 +18,7 @@ import (
 	gitdomain "github.com/sourcegraph/sourcegraph/internal/gitserver/gitdomain"
 	protocol "github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	perforce "github.com/sourcegraph/sourcegraph/internal/perforce"
+	types "github.com/sourcegraph/sourcegraph/internal/types"
 )
 
 // MockClient is a mock implementation of the Client interface (from the
@@ -105,6 +106,9 @@ type MockClient struct {
 	// RepoCloneProgressFunc is an instance of a mock function object
 	// controlling the behavior of the method RepoCloneProgress.
 	RepoCloneProgressFunc *ClientRepoCloneProgressFunc
+	// ReposExistFunc is an instance of a mock function object controlling the
+	// behavior of the method ReposExist.
+	ReposExistFunc *ClientReposExistFunc
 	// ResolveRevisionFunc is an instance of a mock function object
 	// controlling the behavior of the method ResolveRevision.
 	ResolveRevisionFunc *ClientResolveRevisionFunc
@@ -270,6 +274,11 @@ func NewMockClient() *MockClient {
 				return
 			},
 		},
+		ReposExistFunc: &ClientReposExistFunc{
+			defaultHook: func(context.Context, []api.RepoName) (r0 map[api.RepoName]types.CloneStatus, r1 error) {
+				return
+			},
+		},
 		ResolveRevisionFunc: &ClientResolveRevisionFunc{
 			defaultHook: func(context.Context, api.RepoName, string, ResolveRevisionOptions) (r0 api.CommitID, r1 error) {
 				return
@@ -452,6 +461,11 @@ func NewStrictMockClient() *MockClient {
 				panic("unexpected invocation of MockClient.RepoCloneProgress")
 			},
 		},
+		ReposExistFunc: &ClientReposExistFunc{
+			defaultHook: func(context.Context, []api.RepoName) (map[api.RepoName]types.CloneStatus, error) {
+				panic("unexpected invocation of MockClient.ReposExist")
+			},
+		},
 		ResolveRevisionFunc: &ClientResolveRevisionFunc{
 			defaultHook: func(context.Context, api.RepoName, string, ResolveRevisionOptions) (api.CommitID, error) {
 				panic("unexpected invocation of MockClient.ResolveRevision")
@@ -580,6 +594,9 @@ func NewMockClientFrom(i Client) *MockClient {
 		RepoCloneProgressFunc: &ClientRepoCloneProgressFunc{
 			defaultHook: i.RepoCloneProgress,
 		},
+		ReposExistFunc: &ClientReposExistFunc{
+			defaultHook: i.ReposExist,
+		},
 		ResolveRevisionFunc: &ClientResolveRevisionFunc{
 			defaultHook: i.ResolveRevision,
 		},
@@ -3580,6 +3597,113 @@ func (c ClientRepoCloneProgressFuncCall) Results() []interface{} {
 	return []interface{}{c.Result0, c.Result1}
 }
 
+// ClientReposExistFunc describes the behavior when the ReposExist method of the
+// parent MockClient instance is invoked.
+type ClientReposExistFunc struct {
+	defaultHook func(context.Context, []api.RepoName) (map[api.RepoName]types.CloneStatus, error)
+	hooks       []func(context.Context, []api.RepoName) (map[api.RepoName]types.CloneStatus, error)
+	history     []ClientReposExistFuncCall
+	mutex       sync.Mutex
+}
+
+// ReposExist delegates to the next hook function in the queue and stores the
+// parameter and result values of this invocation.
+func (m *MockClient) ReposExist(v0 context.Context, v1 []api.RepoName) (map[api.RepoName]types.CloneStatus, error) {
+	r0, r1 := m.ReposExistFunc.nextHook()(v0, v1)
+	m.ReposExistFunc.appendCall(ClientReposExistFuncCall{v0, v1, r0, r1})
+	return r0, r1
+}
+
+// SetDefaultHook sets function that is called when the ReposExist method of
+// the parent MockClient instance is invoked and the hook queue is empty.
+func (f *ClientReposExistFunc) SetDefaultHook(hook func(context.Context, []api.RepoName) (map[api.RepoName]types.CloneStatus, error)) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// ReposExist method of the parent MockClient instance invokes the hook at the
+// front of the queue and discards it. After the queue is empty, the default
+// hook function is invoked for any future action.
+func (f *ClientReposExistFunc) PushHook(hook func(context.Context, []api.RepoName) (map[api.RepoName]types.CloneStatus, error)) {
+	f.mutex.Lock()
+	f.hooks = append(f.hooks, hook)
+	f.mutex.Unlock()
+}
+
+// SetDefaultReturn calls SetDefaultHook with a function that returns the
+// given values.
+func (f *ClientReposExistFunc) SetDefaultReturn(r0 map[api.RepoName]types.CloneStatus, r1 error) {
+	f.SetDefaultHook(func(context.Context, []api.RepoName) (map[api.RepoName]types.CloneStatus, error) {
+		return r0, r1
+	})
+}
+
+// PushReturn calls PushHook with a function that returns the given values.
+func (f *ClientReposExistFunc) PushReturn(r0 map[api.RepoName]types.CloneStatus, r1 error) {
+	f.PushHook(func(context.Context, []api.RepoName) (map[api.RepoName]types.CloneStatus, error) {
+		return r0, r1
+	})
+}
+
+func (f *ClientReposExistFunc) nextHook() func(context.Context, []api.RepoName) (map[api.RepoName]types.CloneStatus, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+func (f *ClientReposExistFunc) appendCall(r0 ClientReposExistFuncCall) {
+	f.mutex.Lock()
+	f.history = append(f.history, r0)
+	f.mutex.Unlock()
+}
+
+// History returns a sequence of ClientReposExistFuncCall objects describing the invocations of
+// this function.
+func (f *ClientReposExistFunc) History() []ClientReposExistFuncCall {
+	f.mutex.Lock()
+	history := make([]ClientReposExistFuncCall, len(f.history))
+	copy(history, f.history)
+	f.mutex.Unlock()
+
+	return history
+}
+
+// ClientReposExistFuncCall is an object that describes an invocation of method ReposExist on an
+// instance of MockClient.
+type ClientReposExistFuncCall struct {
+	// Arg0 is the value of the 1st argument passed to this method
+	// invocation.
+	Arg0 context.Context
+	// Arg1 is the value of the 2nd argument passed to this method
+	// invocation.
+	Arg1 []api.RepoName
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 map[api.RepoName]types.CloneStatus
+	// Result1 is the value of the 2nd result returned from this method
+	// invocation.
+	Result1 error
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c ClientReposExistFuncCall) Args() []interface{} {
+	return []interface{}{c.Arg0, c.Arg1}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c ClientReposExistFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0, c.Result1}
+}
+
 // ClientResolveRevisionFunc describes the behavior when the ResolveRevision
 // method of the parent MockClient instance is invoked.
 type ClientResolveRevisionFunc struct {
@@ -33,6 +33,7 @@ type operations struct {
 	systemInfo               *observation.Operation
 	isRepoCloneable          *observation.Operation
 	repoCloneProgress        *observation.Operation
+	reposExist               *observation.Operation
 	isPerforcePathCloneable  *observation.Operation
 	checkPerforceCredentials *observation.Operation
 	perforceUsers            *observation.Operation
@@ -114,6 +115,7 @@ func newOperations(observationCtx *observation.Context) *operations {
 		systemInfo:               op("SystemInfo"),
 		isRepoCloneable:          op("IsRepoCloneable"),
 		repoCloneProgress:        op("RepoCloneProgress"),
+		reposExist:               op("ReposExist"),
 		isPerforcePathCloneable:  op("IsPerforcePathCloneable"),
 		checkPerforceCredentials: op("CheckPerforceCredentials"),
 		perforceUsers:            op("PerforceUsers"),
@@ -10,6 +10,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/gitdomain"
 	proto "github.com/sourcegraph/sourcegraph/internal/gitserver/v1"
 	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/types"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
@@ -275,6 +276,34 @@ func (r *RepoCloneProgress) FromProto(p *proto.RepoCloneProgressResponse) {
 	}
 }
 
+// CloneStatusToProto converts a clone status to its protobuf representation.
+func CloneStatusToProto(s types.CloneStatus) proto.CloneStatus {
+	switch s {
+	case types.CloneStatusNotCloned:
+		return proto.CloneStatus_CLONE_STATUS_NOT_CLONED
+	case types.CloneStatusCloning:
+		return proto.CloneStatus_CLONE_STATUS_CLONING
+	case types.CloneStatusCloned:
+		return proto.CloneStatus_CLONE_STATUS_CLONED
+	default:
+		return proto.CloneStatus_CLONE_STATUS_UNSPECIFIED
+	}
+}
+
+// CloneStatusFromProto converts a protobuf clone status to a types.CloneStatus.
+func CloneStatusFromProto(s proto.CloneStatus) types.CloneStatus {
+	switch s {
+	case proto.CloneStatus_CLONE_STATUS_NOT_CLONED:
+		return types.CloneStatusNotCloned
+	case proto.CloneStatus_CLONE_STATUS_CLONING:
+		return types.CloneStatusCloning
+	case proto.CloneStatus_CLONE_STATUS_CLONED:
+		return types.CloneStatusCloned
+	default:
+		return types.CloneStatusUnknown
+	}
+}
+
 // CreateCommitFromPatchRequest is the request information needed for creating
 // the simulated staging area git object for a repo.
 type CreateCommitFromPatchRequest struct {
@@ -65,6 +65,11 @@ func (r *automaticRetryClient) RepoCloneProgress(ctx context.Context, in *proto.
 	return r.base.RepoCloneProgress(ctx, in, opts...)
 }
 
+func (r *automaticRetryClient) ReposExist(ctx context.Context, in *proto.ReposExistRequest, opts ...grpc.CallOption) (*proto.ReposExistResponse, error) {
+	opts = append(defaults.RetryPolicy, opts...)
+	return r.base.ReposExist(ctx, in, opts...)
+}
+
 func (r *automaticRetryClient) IsPerforcePathCloneable(ctx context.Context, in *proto.IsPerforcePathCloneableRequest, opts ...grpc.CallOption) (*proto.IsPerforcePathCloneableResponse, error) {
 	opts = append(defaults.RetryPolicy, opts...)
 	return r.base.IsPerforcePathCloneable(ctx, in, opts...)
@@ -19,9 +19,11 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	GitserverRepositoryService_DeleteRepository_FullMethodName = "/gitserver.v1.GitserverRepositoryService/DeleteRepository"
-	GitserverRepositoryService_FetchRepository_FullMethodName  = "/gitserver.v1.GitserverRepositoryService/FetchRepository"
-	GitserverRepositoryService_ListRepositories_FullMethodName = "/gitserver.v1.GitserverRepositoryService/ListRepositories"
+	GitserverRepositoryService_DeleteRepository_FullMethodName     = "/gitserver.v1.GitserverRepositoryService/DeleteRepository"
+	GitserverRepositoryService_FetchRepository_FullMethodName      = "/gitserver.v1.GitserverRepositoryService/FetchRepository"
+	GitserverRepositoryService_ListRepositories_FullMethodName     = "/gitserver.v1.GitserverRepositoryService/ListRepositories"
+	GitserverRepositoryService_GarbageCollect_FullMethodName       = "/gitserver.v1.GitserverRepositoryService/GarbageCollect"
+	GitserverRepositoryService_CheckRepoConsistency_FullMethodName = "/gitserver.v1.GitserverRepositoryService/CheckRepoConsistency"
 )
 
 // GitserverRepositoryServiceClient is the client API for GitserverRepositoryService service.
@@ -36,6 +38,18 @@ type GitserverRepositoryServiceClient interface {
 	FetchRepository(ctx context.Context, in *FetchRepositoryRequest, opts ...grpc.CallOption) (*FetchRepositoryResponse, error)
 	// ListRepositories returns a list of all repositories on disk.
 	ListRepositories(ctx context.Context, in *ListRepositoriesRequest, opts ...grpc.CallOption) (*ListRepositoriesResponse, error)
+	// GarbageCollect runs git gc on a repository and waits for it to complete.
+	// This is useful for admins to reclaim disk space of a repository right
+	// away, instead of waiting for the next janitor run.
+	//
+	// If the repository is being cloned, fetched or maintained by gitserver, an
+	// Aborted error is returned.
+	GarbageCollect(ctx context.Context, in *GarbageCollectRequest, opts ...grpc.CallOption) (*GarbageCollectResponse, error)
+	// CheckRepoConsistency compares the repositories on disk with the repo
+	// store. It only reports drift, it doesn't fix it. The janitor runs the same
+	// check regularly to update the src_gitserver_repos_orphaned and
+	// src_gitserver_repos_missing metrics.
+	CheckRepoConsistency(ctx context.Context, in *CheckRepoConsistencyRequest, opts ...grpc.CallOption) (*CheckRepoConsistencyResponse, error)
 }
 
 type gitserverRepositoryServiceClient struct {
@@ -73,6 +87,24 @@ func (c *gitserverRepositoryServiceClient) ListRepositories(ctx context.Context,
 	return out, nil
 }
 
+func (c *gitserverRepositoryServiceClient) GarbageCollect(ctx context.Context, in *GarbageCollectRequest, opts ...grpc.CallOption) (*GarbageCollectResponse, error) {
+	out := new(GarbageCollectResponse)
+	err := c.cc.Invoke(ctx, GitserverRepositoryService_GarbageCollect_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gitserverRepositoryServiceClient) CheckRepoConsistency(ctx context.Context, in *CheckRepoConsistencyRequest, opts ...grpc.CallOption) (*CheckRepoConsistencyResponse, error) {
+	out := new(CheckRepoConsistencyResponse)
+	err := c.cc.Invoke(ctx, GitserverRepositoryService_CheckRepoConsistency_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // GitserverRepositoryServiceServer is the server API for GitserverRepositoryService service.
 // All implementations must embed UnimplementedGitserverRepositoryServiceServer
 // for forward compatibility
@@ -85,6 +117,18 @@ type GitserverRepositoryServiceServer interface {
 	FetchRepository(context.Context, *FetchRepositoryRequest) (*FetchRepositoryResponse, error)
 	// ListRepositories returns a list of all repositories on disk.
 	ListRepositories(context.Context, *ListRepositoriesRequest) (*ListRepositoriesResponse, error)
+	// GarbageCollect runs git gc on a repository and waits for it to complete.
+	// This is useful for admins to reclaim disk space of a repository right
+	// away, instead of waiting for the next janitor run.
+	//
+	// If the repository is being cloned, fetched or maintained by gitserver, an
+	// Aborted error is returned.
+	GarbageCollect(context.Context, *GarbageCollectRequest) (*GarbageCollectResponse, error)
+	// CheckRepoConsistency compares the repositories on disk with the repo
+	// store. It only reports drift, it doesn't fix it. The janitor runs the same
+	// check regularly to update the src_gitserver_repos_orphaned and
+	// src_gitserver_repos_missing metrics.
+	CheckRepoConsistency(context.Context, *CheckRepoConsistencyRequest) (*CheckRepoConsistencyResponse, error)
 	mustEmbedUnimplementedGitserverRepositoryServiceServer()
 }
 
@@ -101,6 +145,12 @@ func (UnimplementedGitserverRepositoryServiceServer) FetchRepository(context.Con
 func (UnimplementedGitserverRepositoryServiceServer) ListRepositories(context.Context, *ListRepositoriesRequest) (*ListRepositoriesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListRepositories not implemented")
 }
+func (UnimplementedGitserverRepositoryServiceServer) GarbageCollect(context.Context, *GarbageCollectRequest) (*GarbageCollectResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GarbageCollect not implemented")
+}
+func (UnimplementedGitserverRepositoryServiceServer) CheckRepoConsistency(context.Context, *CheckRepoConsistencyRequest) (*CheckRepoConsistencyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckRepoConsistency not implemented")
+}
 func (UnimplementedGitserverRepositoryServiceServer) mustEmbedUnimplementedGitserverRepositoryServiceServer() {
 }
 
@@ -169,6 +219,42 @@ func _GitserverRepositoryService_ListRepositories_Handler(srv interface{}, ctx c
 	return interceptor(ctx, in, info, handler)
 }
 
+func _GitserverRepositoryService_GarbageCollect_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GarbageCollectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GitserverRepositoryServiceServer).GarbageCollect(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GitserverRepositoryService_GarbageCollect_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GitserverRepositoryServiceServer).GarbageCollect(ctx, req.(*GarbageCollectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GitserverRepositoryService_CheckRepoConsistency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckRepoConsistencyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GitserverRepositoryServiceServer).CheckRepoConsistency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GitserverRepositoryService_CheckRepoConsistency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GitserverRepositoryServiceServer).CheckRepoConsistency(ctx, req.(*CheckRepoConsistencyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // GitserverRepositoryService_ServiceDesc is the grpc.ServiceDesc for GitserverRepositoryService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -188,6 +274,14 @@ var GitserverRepositoryService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListRepositories",
 			Handler:    _GitserverRepositoryService_ListRepositories_Handler,
 		},
+		{
+			MethodName: "GarbageCollect",
+			Handler:    _GitserverRepositoryService_GarbageCollect_Handler,
+		},
+		{
+			MethodName: "CheckRepoConsistency",
+			Handler:    _GitserverRepositoryService_CheckRepoConsistency_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "gitserver.proto",
@@ -202,6 +296,7 @@ const (
 	GitserverService_Search_FullMethodName                      = "/gitserver.v1.GitserverService/Search"
 	GitserverService_Archive_FullMethodName                     = "/gitserver.v1.GitserverService/Archive"
 	GitserverService_RepoCloneProgress_FullMethodName           = "/gitserver.v1.GitserverService/RepoCloneProgress"
+	GitserverService_ReposExist_FullMethodName                  = "/gitserver.v1.GitserverService/ReposExist"
 	GitserverService_IsPerforcePathCloneable_FullMethodName     = "/gitserver.v1.GitserverService/IsPerforcePathCloneable"
 	GitserverService_CheckPerforceCredentials_FullMethodName    = "/gitserver.v1.GitserverService/CheckPerforceCredentials"
 	GitserverService_PerforceUsers_FullMethodName               = "/gitserver.v1.GitserverService/PerforceUsers"
@@ -263,6 +358,10 @@ type GitserverServiceClient interface {
 	// error will be returned, with a RepoNotFoundPayload in the details.
 	Archive(ctx context.Context, in *ArchiveRequest, opts ...grpc.CallOption) (GitserverService_ArchiveClient, error)
 	RepoCloneProgress(ctx context.Context, in *RepoCloneProgressRequest, opts ...grpc.CallOption) (*RepoCloneProgressResponse, error)
+	// ReposExist returns the clone status of each of the given repositories on
+	// this gitserver instance. Unlike most other methods, it doesn't enqueue
+	// repositories that aren't cloned for cloning.
+	ReposExist(ctx context.Context, in *ReposExistRequest, opts ...grpc.CallOption) (*ReposExistResponse, error)
 	IsPerforcePathCloneable(ctx context.Context, in *IsPerforcePathCloneableRequest, opts ...grpc.CallOption) (*IsPerforcePathCloneableResponse, error)
 	CheckPerforceCredentials(ctx context.Context, in *CheckPerforceCredentialsRequest, opts ...grpc.CallOption) (*CheckPerforceCredentialsResponse, error)
 	PerforceUsers(ctx context.Context, in *PerforceUsersRequest, opts ...grpc.CallOption) (*PerforceUsersResponse, error)
@@ -592,6 +691,15 @@ func (c *gitserverServiceClient) RepoCloneProgress(ctx context.Context, in *Repo
 	return out, nil
 }
 
+func (c *gitserverServiceClient) ReposExist(ctx context.Context, in *ReposExistRequest, opts ...grpc.CallOption) (*ReposExistResponse, error) {
+	out := new(ReposExistResponse)
+	err := c.cc.Invoke(ctx, GitserverService_ReposExist_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *gitserverServiceClient) IsPerforcePathCloneable(ctx context.Context, in *IsPerforcePathCloneableRequest, opts ...grpc.CallOption) (*IsPerforcePathCloneableResponse, error) {
 	out := new(IsPerforcePathCloneableResponse)
 	err := c.cc.Invoke(ctx, GitserverService_IsPerforcePathCloneable_FullMethodName, in, out, opts...)
@@ -1004,6 +1112,10 @@ type GitserverServiceServer interface {
 	// error will be returned, with a RepoNotFoundPayload in the details.
 	Archive(*ArchiveRequest, GitserverService_ArchiveServer) error
 	RepoCloneProgress(context.Context, *RepoCloneProgressRequest) (*RepoCloneProgressResponse, error)
+	// ReposExist returns the clone status of each of the given repositories on
+	// this gitserver instance. Unlike most other methods, it doesn't enqueue
+	// repositories that aren't cloned for cloning.
+	ReposExist(context.Context, *ReposExistRequest) (*ReposExistResponse, error)
 	IsPerforcePathCloneable(context.Context, *IsPerforcePathCloneableRequest) (*IsPerforcePathCloneableResponse, error)
 	CheckPerforceCredentials(context.Context, *CheckPerforceCredentialsRequest) (*CheckPerforceCredentialsResponse, error)
 	PerforceUsers(context.Context, *PerforceUsersRequest) (*PerforceUsersResponse, error)
@@ -1211,6 +1323,9 @@ func (UnimplementedGitserverServiceServer) Archive(*ArchiveRequest, GitserverSer
 func (UnimplementedGitserverServiceServer) RepoCloneProgress(context.Context, *RepoCloneProgressRequest) (*RepoCloneProgressResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RepoCloneProgress not implemented")
 }
+func (UnimplementedGitserverServiceServer) ReposExist(context.Context, *ReposExistRequest) (*ReposExistResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReposExist not implemented")
+}
 func (UnimplementedGitserverServiceServer) IsPerforcePathCloneable(context.Context, *IsPerforcePathCloneableRequest) (*IsPerforcePathCloneableResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method IsPerforcePathCloneable not implemented")
 }
@@ -1454,6 +1569,24 @@ func _GitserverService_RepoCloneProgress_Handler(srv interface{}, ctx context.Co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _GitserverService_ReposExist_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReposExistRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GitserverServiceServer).ReposExist(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GitserverService_ReposExist_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GitserverServiceServer).ReposExist(ctx, req.(*ReposExistRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _GitserverService_IsPerforcePathCloneable_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(IsPerforcePathCloneableRequest)
 	if err := dec(in); err != nil {
@@ -1934,6 +2067,10 @@ var GitserverService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RepoCloneProgress",
 			Handler:    _GitserverService_RepoCloneProgress_Handler,
 		},
+		{
+			MethodName: "ReposExist",
+			Handler:    _GitserverService_ReposExist_Handler,
+		},
 		{
 			MethodName: "IsPerforcePathCloneable",
 			Handler:    _GitserverService_IsPerforcePathCloneable_Handler,
@@ -289,6 +289,16 @@ func (f Factory) Client(base ...Opt) (*http.Client, error) {
 	return &cli, err
 }
 
+// WithMiddleware returns a copy of the Factory that additionally wraps Doers
+// with the given middleware, on top of the Factory's own middleware stack.
+func (f Factory) WithMiddleware(mws ...Middleware) *Factory {
+	stack := mws
+	if f.stack != nil {
+		stack = append([]Middleware{f.stack}, mws...)
+	}
+	return &Factory{stack: NewMiddleware(stack...), common: f.common}
+}
+
 // NewFactory returns a Factory that applies the given common
 // Opts after the ones provided on each invocation of Client or Doer.
 //
@@ -72,6 +72,20 @@ type GenericProviderConfig struct {
 	Endpoint    string `json:"endpoint"`
 }
 
+// OpenAICompatibleProviderConfig is the configuration for self-hosted gateways exposing
+// the OpenAI API, e.g. vLLM, TGI or LiteLLM.
+type OpenAICompatibleProviderConfig struct {
+	AccessToken string `json:"accessToken"`
+	Endpoint    string `json:"endpoint"`
+
+	// ChatCompletionsPath replaces the path of the endpoint for chat requests. Defaults to
+	// "v1/chat/completions" if empty. Some gateways mount the OpenAI API under a prefix.
+	ChatCompletionsPath string `json:"chatCompletionsPath,omitempty"`
+	// CompletionsPath replaces the path of the endpoint for code completion requests. Defaults
+	// to "v1/completions" if empty.
+	CompletionsPath string `json:"completionsPath,omitempty"`
+}
+
 // SourcegraphProviderConfig is the configuration blog for configuring a provider
 // to be use Sourcegraph's Cody Gateway for requests.
 type SourcegraphProviderConfig struct {
@@ -82,10 +96,11 @@ type SourcegraphProviderConfig struct {
 // The "Provider" is conceptually a namespace for models. The server-side provider configuration
 // is needed to describe the API endpoint needed to serve its models.
 type ServerSideProviderConfig struct {
-	AWSBedrock          *AWSBedrockProviderConfig  `json:"awsBedrock,omitempty"`
-	AzureOpenAI         *AzureOpenAIProviderConfig `json:"azureOpenAi,omitempty"`
-	GenericProvider     *GenericProviderConfig     `json:"genericProvider,omitempty"`
-	SourcegraphProvider *SourcegraphProviderConfig `json:"sourcegraphProvider,omitempty"`
+	AWSBedrock          *AWSBedrockProviderConfig       `json:"awsBedrock,omitempty"`
+	AzureOpenAI         *AzureOpenAIProviderConfig      `json:"azureOpenAi,omitempty"`
+	GenericProvider     *GenericProviderConfig          `json:"genericProvider,omitempty"`
+	OpenAICompatible    *OpenAICompatibleProviderConfig `json:"openAiCompatible,omitempty"`
+	SourcegraphProvider *SourcegraphProviderConfig      `json:"sourcegraphProvider,omitempty"`
 }
 
 // ========================================================
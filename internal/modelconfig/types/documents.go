@@ -1,9 +1,33 @@
 package types
 
+import "slices"
+
 type DefaultModels struct {
 	Chat           ModelRef `json:"chat"`
 	FastChat       ModelRef `json:"fastChat"`
 	CodeCompletion ModelRef `json:"codeCompletion"`
+	// FastCodeCompletion is the model used for latency-sensitive code completions.
+	// It is optional, if unset CodeCompletion is used instead.
+	FastCodeCompletion ModelRef `json:"fastCodeCompletion,omitempty"`
+}
+
+// FeatureModels restricts which models can be used for each feature. An empty
+// list means that all models can be used for that feature.
+type FeatureModels struct {
+	// Chat is the allow-list for chat, including fast chat.
+	Chat []ModelRef `json:"chat,omitempty"`
+	// CodeCompletion is the allow-list for code completion.
+	CodeCompletion []ModelRef `json:"codeCompletion,omitempty"`
+}
+
+// ChatAllowed returns whether mref can be used for chat.
+func (fm *FeatureModels) ChatAllowed(mref ModelRef) bool {
+	return fm == nil || len(fm.Chat) == 0 || slices.Contains(fm.Chat, mref)
+}
+
+// CodeCompletionAllowed returns whether mref can be used for code completion.
+func (fm *FeatureModels) CodeCompletionAllowed(mref ModelRef) bool {
+	return fm == nil || len(fm.CodeCompletion) == 0 || slices.Contains(fm.CodeCompletion, mref)
 }
 
 type ModelMap map[ModelRef][]Model
@@ -18,6 +42,10 @@ type ModelConfiguration struct {
 	Models    []Model    `json:"models"`
 
 	DefaultModels DefaultModels `json:"defaultModels"`
+
+	// FeatureModels optionally restricts the Models that can be used for each
+	// feature. If nil, all Models can be used for every feature.
+	FeatureModels *FeatureModels `json:"featureModels,omitempty"`
 }
 
 // SiteModelConfiguration is the data type that is encoded into the site configuration schema,
@@ -108,4 +136,8 @@ type SiteModelConfiguration struct {
 	// Sourcegraph-supplied configuration data. Otherwise, will fallback to any
 	// any model that supports the required capabilities.
 	DefaultModels *DefaultModels `json:"defaultModels"`
+
+	// FeatureModels restricts which models can be used for each feature. If unset,
+	// all models can be used for every feature.
+	FeatureModels *FeatureModels `json:"featureModels,omitempty"`
 }
@@ -12,6 +12,9 @@ type ModelCapability string
 const (
 	ModelCapabilityAutocomplete ModelCapability = "autocomplete"
 	ModelCapabilityChat         ModelCapability = "chat"
+	// ModelCapabilityReasoning indicates the model supports extended thinking,
+	// i.e. spending a budget of tokens on reasoning before producing a response.
+	ModelCapabilityReasoning ModelCapability = "reasoning"
 )
 
 type ModelStatus string
@@ -62,4 +65,17 @@ type Model struct {
 
 	ClientSideConfig *ClientSideModelConfig `json:"clientSideConfig,omitempty"`
 	ServerSideConfig *ServerSideModelConfig `json:"serverSideConfig,omitempty"`
+
+	// DefaultParameters are applied to completion requests for this model, for
+	// any parameter the client did not specify.
+	DefaultParameters *ModelDefaultParameters `json:"defaultParameters,omitempty"`
+}
+
+// ModelDefaultParameters describes the default parameters for completion requests
+// to a model. Zero values mean that no default is set for that parameter.
+type ModelDefaultParameters struct {
+	Temperature   float32  `json:"temperature,omitempty"`
+	TopK          int      `json:"topK,omitempty"`
+	TopP          float32  `json:"topP,omitempty"`
+	StopSequences []string `json:"stopSequences,omitempty"`
 }
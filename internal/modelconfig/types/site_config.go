@@ -92,4 +92,6 @@ type ModelOverride struct {
 
 	ClientSideConfig *ClientSideModelConfig `json:"clientSideConfig,omitempty"`
 	ServerSideConfig *ServerSideModelConfig `json:"serverSideConfig,omitempty"`
+
+	DefaultParameters *ModelDefaultParameters `json:"defaultParameters,omitempty"`
 }
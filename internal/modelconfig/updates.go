@@ -88,6 +88,9 @@ func ApplyModelOverride(mod *types.Model, modelOverrides types.ModelOverride) er
 	if modelOverrides.ServerSideConfig != nil {
 		mod.ServerSideConfig = modelOverrides.ServerSideConfig
 	}
+	if modelOverrides.DefaultParameters != nil {
+		mod.DefaultParameters = modelOverrides.DefaultParameters
+	}
 
 	if err := validateModel(*mod); err != nil {
 		return errors.Wrap(err, "model is now in an invalid state")
@@ -163,6 +163,23 @@ func ValidateModelConfig(cfg *types.ModelConfiguration) error {
 	if !isKnownModel(cfg.DefaultModels.FastChat) {
 		return errors.Errorf("unknown chat model %q", cfg.DefaultModels.FastChat)
 	}
+	if fastCodeCompletion := cfg.DefaultModels.FastCodeCompletion; fastCodeCompletion != "" && !isKnownModel(fastCodeCompletion) {
+		return errors.Errorf("unknown fast code completion model %q", fastCodeCompletion)
+	}
+
+	// The default models must be usable for the features they are the default for.
+	if fm := cfg.FeatureModels; fm != nil {
+		for _, mref := range []types.ModelRef{cfg.DefaultModels.Chat, cfg.DefaultModels.FastChat} {
+			if !fm.ChatAllowed(mref) {
+				return errors.Errorf("default chat model %q is not allowed for chat", mref)
+			}
+		}
+		for _, mref := range []types.ModelRef{cfg.DefaultModels.CodeCompletion, cfg.DefaultModels.FastCodeCompletion} {
+			if mref != "" && !fm.CodeCompletionAllowed(mref) {
+				return errors.Errorf("default code completion model %q is not allowed for code completion", mref)
+			}
+		}
+	}
 
 	return nil
 }
@@ -269,6 +286,11 @@ func ValidateSiteConfig(doc *types.SiteModelConfiguration) error {
 		if err := ValidateModelRef(defModels.FastChat); err != nil {
 			return errors.Wrap(err, "default fast chat model")
 		}
+		if defModels.FastCodeCompletion != "" {
+			if err := ValidateModelRef(defModels.FastCodeCompletion); err != nil {
+				return errors.Wrap(err, "default fast completion model")
+			}
+		}
 	}
 
 	return nil
@@ -211,6 +211,32 @@ func TestEmbeddedModelConfig(t *testing.T) {
 	})
 }
 
+func TestValidateModelConfig_FeatureModels(t *testing.T) {
+	cfg, err := embedded.GetCodyGatewayModelConfig()
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(cfg.Models), 3)
+
+	cfg.DefaultModels = types.DefaultModels{
+		Chat:           cfg.Models[0].ModelRef,
+		FastChat:       cfg.Models[1].ModelRef,
+		CodeCompletion: cfg.Models[2].ModelRef,
+	}
+	cfg.FeatureModels = &types.FeatureModels{
+		Chat: []types.ModelRef{cfg.DefaultModels.Chat, cfg.DefaultModels.FastChat},
+	}
+	require.NoError(t, ValidateModelConfig(cfg))
+
+	cfg.FeatureModels.CodeCompletion = []types.ModelRef{cfg.DefaultModels.Chat}
+	err = ValidateModelConfig(cfg)
+	require.ErrorContains(t, err, "default code completion model")
+
+	cfg.FeatureModels = &types.FeatureModels{
+		Chat: []types.ModelRef{cfg.DefaultModels.Chat},
+	}
+	err = ValidateModelConfig(cfg)
+	require.ErrorContains(t, err, "is not allowed for chat")
+}
+
 func TestValidateSiteConfig(t *testing.T) {
 	// getValidSiteConfiguration returns a sophisticated SiteModelConfiguration object
 	// that is valid. So tests can start with something and introduce problems as needed.
@@ -384,5 +410,18 @@ func TestValidateSiteConfig(t *testing.T) {
 			err := ValidateSiteConfig(siteConfig)
 			assert.ErrorContains(t, err, "default chat model: modelRef is blank")
 		}
+
+		{
+			siteConfig := getValidSiteConfiguration()
+			siteConfig.DefaultModels = &types.DefaultModels{
+				Chat:           types.ModelRef("foo::bar::baz"),
+				FastChat:       types.ModelRef("foo::bar::baz"),
+				CodeCompletion: types.ModelRef("foo::bar::baz"),
+				// Invalid ModelRef, FastCodeCompletion is optional but must be well-formed.
+				FastCodeCompletion: types.ModelRef("foo/baz"),
+			}
+			err := ValidateSiteConfig(siteConfig)
+			assert.ErrorContains(t, err, "default fast completion model")
+		}
 	})
 }
@@ -181,17 +181,21 @@ func (s searchQuery) Search(ctx context.Context, repoRev types.RepositoryRevisio
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	var mu sync.Mutex     // serialize writes to w
-	var writeRowErr error // capture if w.Write fails
+	var mu sync.Mutex        // serialize writes to w
+	var writeRowErr error    // capture if w.Write fails
+	var backendsMissing bool // capture if a backend couldn't be searched
 
 	// TODO currently ignoring returned Alert
 	_, err = job.Run(ctx, s.clients, streaming.StreamFunc(func(se streaming.SearchEvent) {
-		// TODO fail if se.Stats indicate missing backends or other things
-		// which may indicate we are might miss data.
-
+		// Truncated indexed searches fail the job, see Exhaustive.Job. Missing
+		// backends mean we missed data as well.
 		mu.Lock()
 		defer mu.Unlock()
 
+		if se.Stats.BackendsMissing > 0 {
+			backendsMissing = true
+		}
+
 		for _, match := range se.Results {
 			err := matchWriter.Write(match)
 			if err != nil {
@@ -204,6 +208,9 @@ func (s searchQuery) Search(ctx context.Context, repoRev types.RepositoryRevisio
 	if writeRowErr != nil {
 		return writeRowErr
 	}
+	if err == nil && backendsMissing {
+		return errors.New("some search backends were unavailable")
+	}
 
 	// TODO how should we handle cloning (gitdomain.RepoNotExistError)?
 
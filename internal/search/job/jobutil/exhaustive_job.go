@@ -2,14 +2,23 @@ package jobutil
 
 import (
 	"context"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/sourcegraph/sourcegraph/internal/authz"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/search"
 	"github.com/sourcegraph/sourcegraph/internal/search/commit"
 	"github.com/sourcegraph/sourcegraph/internal/search/job"
+	"github.com/sourcegraph/sourcegraph/internal/search/limits"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
 	"github.com/sourcegraph/sourcegraph/internal/search/repos"
 	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+	"github.com/sourcegraph/sourcegraph/internal/search/zoekt"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 	"github.com/sourcegraph/sourcegraph/lib/iterator"
 )
@@ -20,6 +29,19 @@ import (
 // differentiate ourselves from the infrastructure.
 type Exhaustive struct {
 	repoPagerJob *repoPagerJob
+
+	// fileContainsFilter post-filters the results of the jobs returned by Job
+	// for file:has.content() predicates. Its child is set in Job.
+	fileContainsFilter *fileContainsFilterJob
+
+	// indexedChild searches both Zoekt and searcher. It is used instead of
+	// the child of repoPagerJob if the revisions to search are partitioned
+	// into indexed and unindexed ones. It is nil if the query can't use
+	// indexed search.
+	indexedChild job.PartialJob[resolvedRepos]
+
+	// skipIndexedSearch disables partitioning, see WithoutIndexedSearch.
+	skipIndexedSearch bool
 }
 
 const (
@@ -45,11 +67,9 @@ func NewExhaustive(inputs *search.Inputs) (Exhaustive, error) {
 		return Exhaustive{}, errors.Errorf("select: is not supported in Search Jobs")
 	}
 
-	// We don't support file predicates, such as file:has.content(), because the
-	// search breaks in unexpected ways. For example, for interactive search
-	// file:has.content() is translated to an AND query which we don't support in
-	// Search Jobs yet.
-	if pred, ok := hasPredicates(query.FieldFile, inputs.Query); ok {
+	// We don't support file predicates other than file:has.content(), because
+	// the search breaks in unexpected ways.
+	if pred, ok := hasPredicates(query.FieldFile, inputs.Query, fileContainsContentPredicates...); ok {
 		return Exhaustive{}, errors.Errorf("file predicates are not supported. Got %v", pred)
 	}
 
@@ -67,6 +87,24 @@ func NewExhaustive(inputs *search.Inputs) (Exhaustive, error) {
 		return Exhaustive{}, errors.Errorf("your query contains the following type filters: %v. However Search Jobs only supports: %v.", resultTypes, exhaustiveSupportedResultTypes)
 	}
 
+	// file:has.content() is lowered like in NewBasicJob: its patterns are
+	// added to the search pattern, and file matches are post-filtered to
+	// remove the ranges matching them. The commit search doesn't support this.
+	fileContainsPatterns := b.FileContainsContent()
+	var fileContainsFilter *fileContainsFilterJob
+	if len(fileContainsPatterns) > 0 {
+		if resultTypes.Has(result.TypeCommit | result.TypeDiff) {
+			return Exhaustive{}, errors.Errorf("file:has.content() is not supported in Search Jobs for commit and diff searches")
+		}
+
+		filterJob, err := NewFileContainsFilterJob(fileContainsPatterns, b.Pattern, b.IsCaseSensitive(), nil)
+		if err != nil {
+			return Exhaustive{}, err
+		}
+		fileContainsFilter = filterJob.(*fileContainsFilterJob)
+		b = withFileContainsPatterns(b, fileContainsPatterns)
+	}
+
 	var planJob job.Job
 
 	if resultTypes.Has(result.TypeCommit | result.TypeDiff) {
@@ -77,10 +115,16 @@ func NewExhaustive(inputs *search.Inputs) (Exhaustive, error) {
 		repoOptionsCopy := repoOptions
 		repoOptionsCopy.OnlyCloned = true
 
+		// The protocol default for exhaustive searches is
+		// limits.DefaultMaxSearchResultsExhaustive, which is also the
+		// default of the setting. The setting lets admins tune it for
+		// commit and diff searches, which are expensive for gitserver.
+		commitLimit := limits.SearchLimits(conf.Get()).ExhaustiveCommitDiffMaxResults
+
 		commitSearchJob := &commit.SearchJob{
 			Query:                commit.QueryToGitQuery(b, diff),
 			Diff:                 diff,
-			Limit:                b.MaxResults(inputs.DefaultLimit()),
+			Limit:                b.MaxResults(commitLimit),
 			IncludeModifiedFiles: authz.SubRepoEnabled(authz.DefaultSubRepoPermsChecker) || own,
 			Concurrency:          4,
 		}
@@ -104,28 +148,80 @@ func NewExhaustive(inputs *search.Inputs) (Exhaustive, error) {
 		return Exhaustive{}, errors.Errorf("internal error: expected a repo pager job when converting plan into search jobs got %T", planJob)
 	}
 
+	// Revisions indexed by Zoekt are searched with Zoekt, the remainder with
+	// searcher. Like in interactive search, queries with ref globs and
+	// commit/diff searches don't use the index.
+	var indexedChild job.PartialJob[resolvedRepos]
+	if partial, ok := repoPagerJob.child.(*reposPartialJob); ok && !repoPagerJob.skipPartitioning && !repoPagerJob.containsRefGlobs && b.Index() != query.No {
+		builder := &jobBuilder{
+			query:           b,
+			patternType:     inputs.PatternType,
+			resultTypes:     resultTypes,
+			repoOptions:     repoOptions,
+			features:        inputs.Features,
+			fileMatchLimit:  int32(computeFileMatchLimit(b, inputs.DefaultLimit())),
+			numContextLines: int(inputs.ContextLines),
+			exhaustive:      true,
+		}
+		zoektJob, err := builder.newZoektSearch(search.TextRequest)
+		if err != nil {
+			return Exhaustive{}, err
+		}
+		indexedChild = &reposPartialJob{NewParallelJob(&zoektTruncationJob{child: zoektJob}, partial.inner)}
+	}
+
 	return Exhaustive{
-		repoPagerJob: repoPagerJob,
+		repoPagerJob:       repoPagerJob,
+		fileContainsFilter: fileContainsFilter,
+		indexedChild:       indexedChild,
 	}, nil
 }
 
-func hasPredicates(field string, q query.Q) (pred string, ok bool) {
+// WithoutIndexedSearch returns a copy of e whose jobs search all revisions
+// with searcher. Use it if the results must not depend on what Zoekt
+// indexed, for example because Zoekt skips large files.
+func (e Exhaustive) WithoutIndexedSearch() Exhaustive {
+	e.skipIndexedSearch = true
+	return e
+}
+
+// fileContainsContentPredicates are the names of the file:has.content()
+// predicate and its alias.
+var fileContainsContentPredicates = []string{"contains.content", "has.content"}
+
+// hasPredicates returns the first predicate for field in q, ignoring the
+// predicates named in except.
+func hasPredicates(field string, q query.Q, except ...string) (pred string, ok bool) {
 	values, negated := q.StringValues(field)
 	for _, v := range append(values, negated...) {
 		pred, _, ok = query.ScanPredicate(field, []byte(v), query.DefaultPredicateRegistry)
-		if ok {
-			break
+		if name, _, _ := strings.Cut(pred, "("); ok && !slices.Contains(except, name) {
+			return pred, true
 		}
 	}
-	return
+	return "", false
 }
 
 func (e Exhaustive) Job(repoRevs *search.RepositoryRevisions) job.Job {
 	// TODO should we add in a timeout and limit here?
-	// TODO should we support indexed search and run through zoekt.PartitionRepos?
-	return e.repoPagerJob.child.Resolve(resolvedRepos{
-		unindexed: []*search.RepositoryRevisions{repoRevs},
-	})
+	var j job.Job
+	if e.indexedChild != nil && !e.skipIndexedSearch {
+		j = &partitionReposJob{
+			child:    e.indexedChild,
+			repoRevs: repoRevs,
+			useIndex: e.repoPagerJob.repoOpts.UseIndex,
+		}
+	} else {
+		j = e.repoPagerJob.child.Resolve(resolvedRepos{
+			unindexed: []*search.RepositoryRevisions{repoRevs},
+		})
+	}
+	if e.fileContainsFilter != nil {
+		filter := *e.fileContainsFilter
+		filter.child = j
+		return &filter
+	}
+	return j
 }
 
 // RepositoryRevSpecs is a wrapper around repos.Resolver.IterateRepoRevs.
@@ -141,3 +237,105 @@ func (e Exhaustive) ResolveRepositoryRevSpec(ctx context.Context, clients job.Ru
 func reposNewResolver(clients job.RuntimeClients) *repos.Resolver {
 	return repos.NewResolver(clients.Logger, clients.DB, clients.Gitserver, clients.SearcherURLs, clients.SearcherGRPCConnectionCache, clients.Zoekt)
 }
+
+// partitionReposJob partitions repoRevs into indexed and unindexed revisions
+// with zoekt.PartitionRepos and runs child over them.
+type partitionReposJob struct {
+	child    job.PartialJob[resolvedRepos]
+	repoRevs *search.RepositoryRevisions
+	useIndex query.YesNoOnly
+}
+
+func (j *partitionReposJob) Run(ctx context.Context, clients job.RuntimeClients, stream streaming.Sender) (alert *search.Alert, err error) {
+	_, ctx, stream, finish := job.StartSpan(ctx, stream, j)
+	defer func() { finish(alert, err) }()
+
+	rr, err := j.partition(ctx, clients)
+	if err != nil {
+		return nil, err
+	}
+
+	return j.child.Resolve(rr).Run(ctx, clients, stream)
+}
+
+func (j *partitionReposJob) partition(ctx context.Context, clients job.RuntimeClients) (resolvedRepos, error) {
+	indexed, unindexed, err := zoekt.PartitionRepos(
+		ctx,
+		clients.Logger,
+		[]*search.RepositoryRevisions{j.repoRevs},
+		clients.Zoekt,
+		search.TextRequest,
+		j.useIndex,
+		false,
+	)
+	if err != nil {
+		return resolvedRepos{}, err
+	}
+	return resolvedRepos{indexed: indexed, unindexed: unindexed}, nil
+}
+
+func (j *partitionReposJob) Name() string {
+	return "PartitionReposJob"
+}
+
+func (j *partitionReposJob) Attributes(v job.Verbosity) (res []attribute.KeyValue) {
+	switch v {
+	case job.VerbosityMax, job.VerbosityBasic:
+		res = append(res,
+			attribute.String("repo", string(j.repoRevs.Repo.Name)),
+			attribute.StringSlice("revs", j.repoRevs.Revs),
+			attribute.String("useIndex", string(j.useIndex)),
+		)
+	}
+	return res
+}
+
+func (j *partitionReposJob) Children() []job.Describer {
+	return []job.Describer{j.child}
+}
+
+func (j *partitionReposJob) MapChildren(fn job.MapFunc) job.Job {
+	cp := *j
+	cp.child = j.child.MapChildren(fn)
+	return &cp
+}
+
+// zoektTruncationJob fails if Zoekt reports that it didn't search everything,
+// e.g. because it hit a match limit or timed out. The results of a search job
+// must not depend on that, so the revision fails instead.
+type zoektTruncationJob struct {
+	child job.Job
+}
+
+func (j *zoektTruncationJob) Run(ctx context.Context, clients job.RuntimeClients, stream streaming.Sender) (alert *search.Alert, err error) {
+	_, ctx, stream, finish := job.StartSpan(ctx, stream, j)
+	defer func() { finish(alert, err) }()
+
+	var truncated atomic.Bool
+	alert, err = j.child.Run(ctx, clients, streaming.StreamFunc(func(event streaming.SearchEvent) {
+		if event.Stats.IsLimitHit || event.Stats.Status.Any(search.RepoStatusTimedOut) {
+			truncated.Store(true)
+		}
+		stream.Send(event)
+	}))
+	if err == nil && truncated.Load() {
+		err = errors.New("indexed search did not search all files")
+	}
+	return alert, err
+}
+
+func (j *zoektTruncationJob) Name() string {
+	return "ZoektTruncationJob"
+}
+
+func (j *zoektTruncationJob) Attributes(job.Verbosity) []attribute.KeyValue { return nil }
+
+func (j *zoektTruncationJob) Children() []job.Describer {
+	return []job.Describer{j.child}
+}
+
+func (j *zoektTruncationJob) MapChildren(fn job.MapFunc) job.Job {
+	cp := *j
+	cp.child = job.Map(j.child, fn)
+	return &cp
+}
@@ -1,15 +1,29 @@
 package jobutil
 
 import (
+	"context"
 	"testing"
 
 	"github.com/hexops/autogold/v2"
+	"github.com/sourcegraph/log/logtest"
 	"github.com/stretchr/testify/require"
 
+	"github.com/sourcegraph/zoekt"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/search"
+	searchbackend "github.com/sourcegraph/sourcegraph/internal/search/backend"
+	"github.com/sourcegraph/sourcegraph/internal/search/commit"
 	"github.com/sourcegraph/sourcegraph/internal/search/job"
+	"github.com/sourcegraph/sourcegraph/internal/search/job/mockjob"
 	"github.com/sourcegraph/sourcegraph/internal/search/job/printer"
+	"github.com/sourcegraph/sourcegraph/internal/search/limits"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/searcher"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+	zoektutil "github.com/sourcegraph/sourcegraph/internal/search/zoekt"
+	"github.com/sourcegraph/sourcegraph/internal/searcher/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/types"
 	"github.com/sourcegraph/sourcegraph/schema"
 )
@@ -327,6 +341,217 @@ func TestNewExhaustive(t *testing.T) {
 	}
 }
 
+func TestNewExhaustive_FileContainsContent(t *testing.T) {
+	repoRevs := &search.RepositoryRevisions{
+		Repo: types.MinimalRepo{
+			ID:   1,
+			Name: "repo",
+		},
+		Revs: []string{"dev1"},
+	}
+
+	for _, q := range []string{
+		`type:file index:no file:has.content(needle) haystack`,
+		`type:file index:no file:contains.content(needle) haystack`,
+	} {
+		t.Run(q, func(t *testing.T) {
+			searchType := query.SearchTypeLiteral
+			plan, err := query.Pipeline(query.Init(q, searchType))
+			require.NoError(t, err)
+
+			inputs := &search.Inputs{
+				Plan:         plan,
+				Query:        plan.ToQ(),
+				UserSettings: &schema.Settings{},
+				PatternType:  searchType,
+				Protocol:     search.Exhaustive,
+				Features:     &search.Features{},
+			}
+
+			exhaustive, err := NewExhaustive(inputs)
+			require.NoError(t, err)
+
+			// The predicate is lowered into the searcher query, and the
+			// results are post-filtered.
+			j := exhaustive.Job(repoRevs)
+			require.IsType(t, &fileContainsFilterJob{}, j)
+			filter := j.(*fileContainsFilterJob)
+			require.Equal(t, []string{"needle"}, filter.includePatterns)
+
+			require.IsType(t, &searcher.TextSearchJob{}, filter.child)
+			searcherJob := filter.child.(*searcher.TextSearchJob)
+			require.Equal(t, []*search.RepositoryRevisions{repoRevs}, searcherJob.Repos)
+			require.Equal(t, &protocol.AndNode{Children: []protocol.QueryNode{
+				&protocol.PatternNode{Value: "needle", IsRegExp: true},
+				&protocol.PatternNode{Value: "haystack"},
+			}}, searcherJob.PatternInfo.Query)
+
+			// Every call to Job gets its own filter.
+			require.NotSame(t, j, exhaustive.Job(repoRevs))
+		})
+	}
+}
+
+func TestNewExhaustive_IndexedSearch(t *testing.T) {
+	repoRevs := &search.RepositoryRevisions{
+		Repo: types.MinimalRepo{
+			ID:   1,
+			Name: "repo",
+		},
+		Revs: []string{"main", "dev1"},
+	}
+
+	newExhaustive := func(t *testing.T, q string) Exhaustive {
+		searchType := query.SearchTypeLiteral
+		plan, err := query.Pipeline(query.Init(q, searchType))
+		require.NoError(t, err)
+
+		inputs := &search.Inputs{
+			Plan:         plan,
+			Query:        plan.ToQ(),
+			UserSettings: &schema.Settings{},
+			PatternType:  searchType,
+			Protocol:     search.Exhaustive,
+			Features:     &search.Features{},
+		}
+
+		exhaustive, err := NewExhaustive(inputs)
+		require.NoError(t, err)
+		return exhaustive
+	}
+
+	t.Run("partitions", func(t *testing.T) {
+		exhaustive := newExhaustive(t, "type:file content")
+
+		j := exhaustive.Job(repoRevs)
+		require.IsType(t, &partitionReposJob{}, j)
+		partitionJob := j.(*partitionReposJob)
+
+		// Only main is indexed.
+		clients := job.RuntimeClients{
+			Logger: logtest.Scoped(t),
+			Zoekt: &searchbackend.FakeStreamer{
+				Repos: []*zoekt.RepoListEntry{{
+					Repository: zoekt.Repository{
+						ID:       1,
+						Name:     "repo",
+						Branches: []zoekt.RepositoryBranch{{Name: "main", Version: "deadbeef"}},
+					},
+				}},
+			},
+		}
+
+		rr, err := partitionJob.partition(context.Background(), clients)
+		require.NoError(t, err)
+		require.Len(t, rr.indexed.RepoRevs, 1)
+		require.Equal(t, []string{"main"}, rr.indexed.RepoRevs[1].Revs)
+		require.Len(t, rr.unindexed, 1)
+		require.Equal(t, []string{"dev1"}, rr.unindexed[0].Revs)
+
+		// Each partition is searched by its backend.
+		resolved := partitionJob.child.Resolve(rr).(*ParallelJob)
+		require.Len(t, resolved.children, 2)
+		zoektJob := resolved.children[0].(*zoektTruncationJob).child.(*zoektutil.RepoSubsetTextSearchJob)
+		require.Same(t, rr.indexed, zoektJob.Repos)
+		require.True(t, zoektJob.ZoektParams.Exhaustive)
+		require.Equal(t, rr.unindexed, resolved.children[1].(*searcher.TextSearchJob).Repos)
+	})
+
+	t.Run("without indexed search", func(t *testing.T) {
+		exhaustive := newExhaustive(t, "type:file content").WithoutIndexedSearch()
+
+		j := exhaustive.Job(repoRevs)
+		require.IsType(t, &searcher.TextSearchJob{}, j)
+		require.Equal(t, []*search.RepositoryRevisions{repoRevs}, j.(*searcher.TextSearchJob).Repos)
+	})
+
+	t.Run("index:no", func(t *testing.T) {
+		exhaustive := newExhaustive(t, "type:file index:no content")
+
+		require.IsType(t, &searcher.TextSearchJob{}, exhaustive.Job(repoRevs))
+	})
+}
+
+func TestZoektTruncationJob(t *testing.T) {
+	run := func(stats streaming.Stats) error {
+		mockJob := mockjob.NewMockJob()
+		mockJob.RunFunc.SetDefaultHook(func(_ context.Context, _ job.RuntimeClients, s streaming.Sender) (*search.Alert, error) {
+			s.Send(streaming.SearchEvent{
+				Results: []result.Match{&result.FileMatch{}},
+				Stats:   stats,
+			})
+			return nil, nil
+		})
+
+		var sent []result.Match
+		_, err := (&zoektTruncationJob{child: mockJob}).Run(context.Background(), job.RuntimeClients{}, streaming.StreamFunc(func(e streaming.SearchEvent) {
+			sent = append(sent, e.Results...)
+		}))
+		require.Len(t, sent, 1)
+		return err
+	}
+
+	require.NoError(t, run(streaming.Stats{}))
+	require.Error(t, run(streaming.Stats{IsLimitHit: true}))
+
+	var timedOut search.RepoStatusMap
+	timedOut.Update(1, search.RepoStatusTimedOut)
+	require.Error(t, run(streaming.Stats{Status: timedOut}))
+}
+
+func TestNewExhaustive_CommitDiffLimit(t *testing.T) {
+	repoRevs := &search.RepositoryRevisions{
+		Repo: types.MinimalRepo{
+			ID:   1,
+			Name: "repo",
+		},
+		Revs: []string{"dev1"},
+	}
+
+	limit := func(t *testing.T, q string) int {
+		searchType := query.SearchTypeLiteral
+		plan, err := query.Pipeline(query.Init(q, searchType))
+		require.NoError(t, err)
+
+		inputs := &search.Inputs{
+			Plan:         plan,
+			Query:        plan.ToQ(),
+			UserSettings: &schema.Settings{},
+			PatternType:  searchType,
+			Protocol:     search.Exhaustive,
+			Features:     &search.Features{},
+		}
+
+		exhaustive, err := NewExhaustive(inputs)
+		require.NoError(t, err)
+
+		j := exhaustive.Job(repoRevs)
+		require.IsType(t, &commit.SearchJob{}, j)
+		return j.(*commit.SearchJob).Limit
+	}
+
+	t.Run("default", func(t *testing.T) {
+		conf.Mock(&conf.Unified{})
+		t.Cleanup(func() { conf.Mock(nil) })
+
+		require.Equal(t, limits.DefaultMaxSearchResultsExhaustive, limit(t, "type:diff index:no foo"))
+		require.Greater(t, limit(t, "type:commit index:no foo"), limits.DefaultMaxSearchResultsStreaming)
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+			SearchLimits: &schema.SearchLimits{ExhaustiveCommitDiffMaxResults: 5_000_000},
+		}})
+		t.Cleanup(func() { conf.Mock(nil) })
+
+		require.Equal(t, 5_000_000, limit(t, "type:diff index:no foo"))
+		require.Equal(t, 5_000_000, limit(t, "type:commit index:no foo"))
+
+		// count: in the query takes precedence.
+		require.Equal(t, 100, limit(t, "type:diff index:no count:100 foo"))
+	})
+}
+
 func sPrintSexpMax(j job.Describer) string {
 	return "\n" + printer.SexpVerbose(j, job.VerbosityMax, true) + "\n"
 }
@@ -344,10 +569,10 @@ func TestNewExhaustive_negative(t *testing.T) {
 		{query: `type:file index:no r:.* .*`, isPatterntypeRegex: true},
 		{query: `type:file index:no r:repo .*`, isPatterntypeRegex: true},
 		// file predicates
-		{query: `type:file index:no file:has.content(content)`},
 		{query: `type:file index:no file:has.owner(owner)`},
-		{query: `type:file index:no file:contains.content(content)`},
 		{query: `type:file index:no file:has.contributor(contributor)`},
+		{query: `type:diff index:no file:has.content(content) foo`},
+		{query: `type:commit index:no file:contains.content(content) foo`},
 		// unsupported types
 		{query: `index:no type:repo`},
 		{query: `index:no type:symbol`},
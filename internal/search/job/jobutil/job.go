@@ -69,6 +69,22 @@ func NewPlanJob(inputs *search.Inputs, plan query.Plan) (job.Job, error) {
 	return logJob, nil
 }
 
+// withFileContainsPatterns returns b with the given file:contains.content()
+// patterns added to its pattern, so that only files containing them match.
+func withFileContainsPatterns(b query.Basic, patterns []string) query.Basic {
+	newNodes := make([]query.Node, 0, len(patterns)+1)
+	for _, pat := range patterns {
+		node := query.Pattern{Value: pat}
+		node.Annotation.Labels.Set(query.Regexp)
+		newNodes = append(newNodes, node)
+	}
+	if b.Pattern != nil {
+		newNodes = append(newNodes, b.Pattern)
+	}
+	b.Pattern = query.Operator{Operands: newNodes, Kind: query.And}
+	return b
+}
+
 // NewBasicJob converts a query.Basic into its job tree representation.
 func NewBasicJob(inputs *search.Inputs, b query.Basic) (job.Job, error) {
 
@@ -81,16 +97,7 @@ func NewBasicJob(inputs *search.Inputs, b query.Basic) (job.Job, error) {
 	fileContainsPatterns := b.FileContainsContent()
 	originalQuery := b
 	if len(fileContainsPatterns) > 0 {
-		newNodes := make([]query.Node, 0, len(fileContainsPatterns)+1)
-		for _, pat := range fileContainsPatterns {
-			node := query.Pattern{Value: pat}
-			node.Annotation.Labels.Set(query.Regexp)
-			newNodes = append(newNodes, node)
-		}
-		if b.Pattern != nil {
-			newNodes = append(newNodes, b.Pattern)
-		}
-		b.Pattern = query.Operator{Operands: newNodes, Kind: query.And}
+		b = withFileContainsPatterns(b, fileContainsPatterns)
 	}
 
 	{
@@ -844,6 +851,7 @@ type jobBuilder struct {
 	fileMatchLimit  int32
 	selector        filter.SelectPath
 	numContextLines int
+	exhaustive      bool
 }
 
 func (b *jobBuilder) newZoektGlobalSearch(typ search.IndexedRequestType) (job.Job, error) {
@@ -873,6 +881,7 @@ func (b *jobBuilder) newZoektGlobalSearch(typ search.IndexedRequestType) (job.Jo
 		Features:        *b.features,
 		PatternType:     b.patternType,
 		NumContextLines: b.numContextLines,
+		Exhaustive:      b.exhaustive,
 	}
 
 	switch typ {
@@ -906,6 +915,7 @@ func (b *jobBuilder) newZoektSearch(typ search.IndexedRequestType) (job.Job, err
 		Features:        *b.features,
 		PatternType:     b.patternType,
 		NumContextLines: b.numContextLines,
+		Exhaustive:      b.exhaustive,
 	}
 
 	switch typ {
@@ -950,8 +960,9 @@ func zoektQueryPatternsAsRegexps(q zoektquery.Q) (res []*regexp.Regexp) {
 }
 
 func jobMode(b query.Basic, repoOptions search.RepoOptions, resultTypes result.Types, inputs *search.Inputs) (repoUniverseSearch, skipRepoSubsetSearch, runZoektOverRepos bool) {
-	// Exhaustive search avoids zoekt since it splits up a search in a worker
-	// run per repo@revision.
+	// Exhaustive search splits up a search in a worker run per repo@revision,
+	// so it never searches the repo universe. Exhaustive.Job searches the
+	// revisions indexed by Zoekt with Zoekt itself.
 	if inputs.Protocol == search.Exhaustive {
 		repoUniverseSearch = false
 		skipRepoSubsetSearch = false
@@ -97,6 +97,13 @@ type FileMatch struct {
 
 	LimitHit bool
 
+	// Source is the search backend which found the match. It is
+	// FileMatchSourceUnknown if the backend didn't set it.
+	//
+	// Note: this is a uint8 rather than a string to not grow FileMatch, as
+	// it fits into the padding after LimitHit.
+	Source FileMatchSource `json:"-"`
+
 	// Debug is optionally set with a debug message explaining the result.
 	//
 	// Note: this is a pointer since usually this is unset. Pointer is 8 bytes
@@ -104,6 +111,28 @@ type FileMatch struct {
 	Debug *string `json:"-"`
 }
 
+// FileMatchSource is the search backend which found a FileMatch.
+type FileMatchSource uint8
+
+const (
+	FileMatchSourceUnknown FileMatchSource = iota
+	FileMatchSourceZoekt
+	FileMatchSourceSearcher
+)
+
+// String returns the name of the search backend, or an empty string if it is
+// unknown.
+func (s FileMatchSource) String() string {
+	switch s {
+	case FileMatchSourceZoekt:
+		return "zoekt"
+	case FileMatchSourceSearcher:
+		return "searcher"
+	default:
+		return ""
+	}
+}
+
 func (fm *FileMatch) RepoName() types.MinimalRepo {
 	return fm.File.Repo
 }
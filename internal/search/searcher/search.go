@@ -274,6 +274,7 @@ func convertMatches(repo types.MinimalRepo, commit api.CommitID, rev *string, se
 			ChunkMatches: chunkMatches,
 			PathMatches:  pathMatches,
 			LimitHit:     fm.LimitHit,
+			Source:       result.FileMatchSourceSearcher,
 		})
 	}
 	return matches
@@ -169,6 +169,10 @@ type ZoektParameters struct {
 	Features Features
 
 	PatternType query.SearchType
+
+	// Exhaustive, if true, makes Zoekt search for all matches without a time
+	// limit and without ranking, as search jobs need.
+	Exhaustive bool
 }
 
 // ToSearchOptions converts the parameters to options for the Zoekt search API.
@@ -217,6 +221,14 @@ func (o *ZoektParameters) ToSearchOptions(ctx context.Context) (searchOpts *zoek
 		searchOpts.TotalMaxMatchCount = limit
 	}
 
+	// Search jobs search one repository revision at a time in the background,
+	// so we don't want Zoekt to give up early.
+	if o.Exhaustive {
+		searchOpts.MaxWallTime = 0
+		searchOpts.ShardMaxMatchCount = max(searchOpts.ShardMaxMatchCount, limits.DefaultMaxSearchResultsExhaustive)
+		searchOpts.TotalMaxMatchCount = max(searchOpts.TotalMaxMatchCount, limits.DefaultMaxSearchResultsExhaustive)
+	}
+
 	// If we're searching repos, ignore the other options and only check one file per repo
 	if o.Select.Root() == filter.Repository {
 		searchOpts.ShardRepoMaxMatchCount = 1
@@ -228,8 +240,11 @@ func (o *ZoektParameters) ToSearchOptions(ctx context.Context) (searchOpts *zoek
 	}
 
 	// This enables our stream based ranking, where we wait a certain amount
-	// of time to collect results before ranking.
-	searchOpts.FlushWallTime = conf.SearchFlushWallTime(searchOpts.UseBM25Scoring)
+	// of time to collect results before ranking. Search jobs don't rank
+	// results.
+	if !o.Exhaustive {
+		searchOpts.FlushWallTime = conf.SearchFlushWallTime(searchOpts.UseBM25Scoring)
+	}
 
 	// Only use document ranks if the jobs to calculate the ranks are enabled. This
 	// is to make sure we don't use outdated ranks for scoring in Zoekt.
@@ -349,7 +349,7 @@ func zoektSearch(ctx context.Context, repos *IndexedRepoRevs, q zoektquery.Q, pa
 		return statusMap
 	}
 
-	if !foundResults.Load() && since(t0) >= searchOpts.MaxWallTime {
+	if !foundResults.Load() && searchOpts.MaxWallTime > 0 && since(t0) >= searchOpts.MaxWallTime {
 		c.Send(streaming.SearchEvent{Stats: streaming.Stats{Status: mkStatusMap(search.RepoStatusTimedOut)}})
 	}
 	return nil
@@ -421,6 +421,7 @@ func sendMatches(event *zoekt.SearchResult, pathRegexps []*regexp.Regexp, getRep
 					Path:            file.FileName,
 					PreciseLanguage: file.Language,
 				},
+				Source: result.FileMatchSourceZoekt,
 			}
 			if debug := file.Debug; debug != "" {
 				fm.Debug = &debug
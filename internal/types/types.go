@@ -590,6 +590,8 @@ type GitserverRepo struct {
 	CloneStatus CloneStatus
 	// The last error that occurred or empty if the last action was successful
 	LastError string
+	// The time LastError was last recorded, or zero if LastError is empty.
+	LastErrorAt time.Time
 	// The last time fetch was called.
 	LastFetched time.Time
 	// The last time a fetch updated the repository.
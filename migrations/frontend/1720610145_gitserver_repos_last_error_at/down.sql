@@ -0,0 +1 @@
+ALTER TABLE gitserver_repos DROP COLUMN IF EXISTS last_error_at;
@@ -0,0 +1,3 @@
+ALTER TABLE gitserver_repos ADD COLUMN IF NOT EXISTS last_error_at TIMESTAMP WITH TIME ZONE;
+
+COMMENT ON COLUMN gitserver_repos.last_error_at IS 'Timestamp of when last_error was last recorded';
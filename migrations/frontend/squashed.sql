@@ -2810,13 +2810,16 @@ CREATE TABLE gitserver_repos (
     repo_size_bytes bigint,
     corrupted_at timestamp with time zone,
     corruption_logs jsonb DEFAULT '[]'::jsonb NOT NULL,
-    cloning_progress text DEFAULT ''::text
+    cloning_progress text DEFAULT ''::text,
+    last_error_at timestamp with time zone
 );
 
 COMMENT ON COLUMN gitserver_repos.corrupted_at IS 'Timestamp of when repo corruption was detected';
 
 COMMENT ON COLUMN gitserver_repos.corruption_logs IS 'Log output of repo corruptions that have been detected - encoded as json';
 
+COMMENT ON COLUMN gitserver_repos.last_error_at IS 'Timestamp of when last_error was last recorded';
+
 CREATE TABLE gitserver_repos_statistics (
     shard_id text,
     total bigint DEFAULT 0 NOT NULL,
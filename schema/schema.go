@@ -711,6 +711,8 @@ type Completions struct {
 	PerCommunityUserCodeCompletionsMonthlyInteractionLimit int `json:"perCommunityUserCodeCompletionsMonthlyInteractionLimit,omitempty"`
 	// PerCommunityUserCodeCompletionsMonthlyLLMRequestLimit description: If > 0, limits the number of code completions requests allowed for a Community user in a month.  This is for Self-serve Cody and applies to Dotcom only.
 	PerCommunityUserCodeCompletionsMonthlyLLMRequestLimit int `json:"perCommunityUserCodeCompletionsMonthlyLLMRequestLimit,omitempty"`
+	// PerOrgMonthlyTokenBudget description: If > 0, limits the number of LLM tokens that can be used by the members of an organization in a calendar month. Requests by members of an organization that exhausted its budget are rejected until the next month starts. Members of multiple organizations are charged to the organization with the lowest ID only. Users who are not a member of any organization are not limited.
+	PerOrgMonthlyTokenBudget int `json:"perOrgMonthlyTokenBudget,omitempty"`
 	// PerProUserChatDailyInteractionLimit description: If > 0, enables the maximum number of completions interactions allowed to be made by a single Pro user in a day. This is for Cody PLG and applies to Dotcom only.
 	PerProUserChatDailyInteractionLimit int `json:"perProUserChatDailyInteractionLimit,omitempty"`
 	// PerProUserChatDailyLLMRequestLimit description: If > 0, limits the number of completions requests allowed for a Pro user in a day. This is for Self-serve Cody and applies to Dotcom only.
@@ -763,7 +765,7 @@ type DebugLog struct {
 
 // DefaultModelConfig description: The model configuration that is applied to every model for a given provider.
 type DefaultModelConfig struct {
-	// Capabilities description: Whether the model can be used for chat, just autocomplete, etc.
+	// Capabilities description: Whether the model can be used for chat, just autocomplete, etc. Models with the "reasoning" capability accept a thinking budget.
 	Capabilities     []string               `json:"capabilities"`
 	Category         string                 `json:"category"`
 	ClientSideConfig *ClientSideModelConfig `json:"clientSideConfig,omitempty"`
@@ -778,6 +780,8 @@ type DefaultModels struct {
 	CodeCompletion string `json:"codeCompletion,omitempty"`
 	// FastChat description: The qualified name of the model to use for fast chat, in '${ProviderID}::${APIVersionID}::${ModelID}' format
 	FastChat string `json:"fastChat,omitempty"`
+	// FastCodeCompletion description: The qualified name of the model to use for fast, latency-sensitive code completion, in '${ProviderID}::${APIVersionID}::${ModelID}' format. If unset, codeCompletion is used.
+	FastCodeCompletion string `json:"fastCodeCompletion,omitempty"`
 }
 
 // DequeueCacheConfig description: The configuration for the dequeue cache of multiqueue executors. Each queue defines a limit of dequeues in the expiration window as well as a weight, indicating how frequently a queue is picked at random. For example, a weight of 4 for batches and 1 for codeintel means out of 5 dequeues, statistically batches will be picked 4 times and codeintel 1 time (unless one of those queues is at its limit).
@@ -1199,6 +1203,14 @@ type ExternalIdentity struct {
 	Type           string `json:"type"`
 }
 
+// FeatureModels description: Restricts which models can be used for each feature. If the list for a feature is unset or empty, all configured models can be used for it.
+type FeatureModels struct {
+	// Chat description: The qualified names of the models that can be used for chat and fast chat, in '${ProviderID}::${APIVersionID}::${ModelID}' format
+	Chat []string `json:"chat,omitempty"`
+	// CodeCompletion description: The qualified names of the models that can be used for code completion, in '${ProviderID}::${APIVersionID}::${ModelID}' format
+	CodeCompletion []string `json:"codeCompletion,omitempty"`
+}
+
 // FileFilters description: Filters that allow you to specify which files in a repository should get embedded.
 type FileFilters struct {
 	// ExcludedFilePathPatterns description: A list of glob patterns that match file paths you want to exclude from embeddings. This is useful to exclude files with low information value (e.g., SVG files, test fixtures, mocks, auto-generated files, etc.).
@@ -1368,6 +1380,8 @@ type GitHubConnection struct {
 	//
 	// Note: ID is the GitHub GraphQL ID, not the GitHub database ID. eg: "curl https://api.github.com/repos/vuejs/vue | jq .node_id"
 	Exclude []*ExcludedGitHubRepo `json:"exclude,omitempty"`
+	// FetchRefspecs description: EXPERIMENTAL: The refspecs fetched from the code host, replacing the default set of refspecs. The default fetches branches, tags and the pull request, merge request and changeset refs of all supported code hosts. For example, only fetch `+refs/heads/*:refs/heads/*` and `+refs/tags/*:refs/tags/*` to leave out pull request refs.
+	FetchRefspecs []string `json:"fetchRefspecs,omitempty"`
 	// GitHubAppDetails description: If non-null, this is a GitHub App connection with some additional properties.
 	GitHubAppDetails *GitHubAppDetails `json:"gitHubAppDetails,omitempty"`
 	// GitURLType description: The type of Git URLs to use for cloning and fetching Git repositories on this GitHub instance.
@@ -1382,6 +1396,8 @@ type GitHubConnection struct {
 	InitialRepositoryEnablement bool `json:"initialRepositoryEnablement,omitempty"`
 	// Orgs description: An array of organization names identifying GitHub organizations whose repositories should be mirrored on Sourcegraph.
 	Orgs []string `json:"orgs,omitempty"`
+	// PartialClone description: EXPERIMENTAL: If true, repositories are mirrored as blobless partial clones (`--filter=blob:none`). Commits and trees are fetched as usual, but file contents are only fetched from the code host when they are first read. This greatly reduces disk usage for large repositories at the cost of slower first reads of a file, and requires the code host to support partial clone.
+	PartialClone bool `json:"partialClone,omitempty"`
 	// Pending description: Whether the code host connection is in a pending state.
 	Pending bool `json:"pending,omitempty"`
 	// RateLimit description: Rate limit applied when making background API requests to GitHub.
@@ -1413,6 +1429,8 @@ type GitHubConnection struct {
 	//
 	// If you need to narrow the set of mirrored repositories further (and don't want to enumerate it with a list or query set as above), create a new bot/machine user on GitHub or GitHub Enterprise that is only affiliated with the desired repositories.
 	RepositoryQuery []string `json:"repositoryQuery,omitempty"`
+	// SparseExcludePaths description: EXPERIMENTAL: Paths whose file contents are never fetched, e.g. vendored dependencies or generated assets that don't need to be searched. Repositories are mirrored as blobless partial clones and only the file contents outside of these paths are fetched for the default branch. Paths are relative to the repository root. Requires the code host to support partial clone.
+	SparseExcludePaths []string `json:"sparseExcludePaths,omitempty"`
 	// Token description: A GitHub personal access token. Create one for GitHub.com at https://github.com/settings/tokens/new?description=Sourcegraph (for GitHub Enterprise, replace github.com with your instance's hostname). See https://sourcegraph.com/docs/admin/code_hosts/github#github-api-access for which scopes are required for which use cases.
 	Token string `json:"token,omitempty"`
 	// Url description: URL of a GitHub instance, such as https://github.com or https://github-enterprise.example.com.
@@ -1477,6 +1495,8 @@ type GitLabConnection struct {
 	Certificate string `json:"certificate,omitempty"`
 	// Exclude description: A list of projects to never mirror from this GitLab instance. Takes precedence over "projects" and "projectQuery" configuration. Supports excluding by name ({"name": "group/name"}) or by ID ({"id": 42}).
 	Exclude []*ExcludedGitLabProject `json:"exclude,omitempty"`
+	// FetchRefspecs description: EXPERIMENTAL: The refspecs fetched from the code host, replacing the default set of refspecs. The default fetches branches, tags and the pull request, merge request and changeset refs of all supported code hosts. For example, only fetch `+refs/heads/*:refs/heads/*` and `+refs/tags/*:refs/tags/*` to leave out pull request refs.
+	FetchRefspecs []string `json:"fetchRefspecs,omitempty"`
 	// GitURLType description: The type of Git URLs to use for cloning and fetching Git repositories on this GitLab instance.
 	//
 	// If "http", Sourcegraph will access GitLab repositories using Git URLs of the form http(s)://gitlab.example.com/myteam/myproject.git (using https: if the GitLab instance uses HTTPS).
@@ -1489,6 +1509,8 @@ type GitLabConnection struct {
 	MarkInternalReposAsPublic bool `json:"markInternalReposAsPublic,omitempty"`
 	// NameTransformations description: An array of transformations will apply to the repository name. Currently, only regex replacement is supported. All transformations happen after "repositoryPathPattern" is processed.
 	NameTransformations []*GitLabNameTransformation `json:"nameTransformations,omitempty"`
+	// PartialClone description: EXPERIMENTAL: If true, repositories are mirrored as blobless partial clones (`--filter=blob:none`). Commits and trees are fetched as usual, but file contents are only fetched from the code host when they are first read. This greatly reduces disk usage for large repositories at the cost of slower first reads of a file, and requires the code host to support partial clone.
+	PartialClone bool `json:"partialClone,omitempty"`
 	// ProjectQuery description: An array of strings specifying which GitLab projects to mirror on Sourcegraph. Each string is a URL path and query that targets a GitLab API endpoint returning a list of projects. If the string only contains a query, then "projects" is used as the path. Examples: "?membership=true&search=foo", "groups/mygroup/projects".
 	//
 	// The special string "none" can be used as the only element to disable this feature. Projects matched by multiple query strings are only imported once. Here are a few endpoints that return a list of projects: https://docs.gitlab.com/ee/api/projects.html#list-all-projects, https://docs.gitlab.com/ee/api/groups.html#list-a-groups-projects, https://docs.gitlab.com/ee/api/search.html#scope-projects.
@@ -1503,6 +1525,8 @@ type GitLabConnection struct {
 	//
 	// It is important that the Sourcegraph repository name generated with this pattern be unique to this code host. If different code hosts generate repository names that collide, Sourcegraph's behavior is undefined.
 	RepositoryPathPattern string `json:"repositoryPathPattern,omitempty"`
+	// SparseExcludePaths description: EXPERIMENTAL: Paths whose file contents are never fetched, e.g. vendored dependencies or generated assets that don't need to be searched. Repositories are mirrored as blobless partial clones and only the file contents outside of these paths are fetched for the default branch. Paths are relative to the repository root. Requires the code host to support partial clone.
+	SparseExcludePaths []string `json:"sparseExcludePaths,omitempty"`
 	// Token description: A GitLab access token with "api" scope. Can be a personal access token (PAT) or an OAuth token. If you are enabling permissions with identity provider type "external", this token should also have "sudo" scope.
 	Token string `json:"token"`
 	// TokenOauthExpiry description: The OAuth token expiry (Unix timestamp in seconds)
@@ -1545,7 +1569,7 @@ type GitLabWebhook struct {
 type GitRecorder struct {
 	// IgnoredGitCommands description: List of git commands that should be ignored and not recorded.
 	IgnoredGitCommands []string `json:"ignoredGitCommands,omitempty"`
-	// Repos description: List of repositories whose git operations should be recorded. To record commands on all repositories, simply pass in an asterisk as the only item in the array.
+	// Repos description: List of repositories whose git operations should be recorded. Entries can be glob patterns such as "github.com/myorg/*", where "*" matches within a single path component and "**" across components. To record commands on all repositories, simply pass in an asterisk as the only item in the array.
 	Repos []string `json:"repos,omitempty"`
 	// Size description: Defines how many recordings to keep. Once this size is reached, the oldest entry will be removed.
 	Size int `json:"size,omitempty"`
@@ -1715,12 +1739,21 @@ type ModelFilters struct {
 	// StatusFilter description: Constrain models to just those matching one of the supplied statuses
 	StatusFilter []string `json:"statusFilter,omitempty"`
 }
+
+// ModelDefaultParameters description: Default parameters used for completion requests to the model, for any parameter the client does not specify.
+type ModelDefaultParameters struct {
+	StopSequences []string `json:"stopSequences,omitempty"`
+	Temperature   float64  `json:"temperature,omitempty"`
+	TopK          int      `json:"topK,omitempty"`
+	TopP          float64  `json:"topP,omitempty"`
+}
 type ModelOverride struct {
-	// Capabilities description: Whether the model can be used for chat, just autocomplete, etc.
-	Capabilities     []string               `json:"capabilities"`
-	Category         string                 `json:"category"`
-	ClientSideConfig *ClientSideModelConfig `json:"clientSideConfig,omitempty"`
-	ContextWindow    ContextWindow          `json:"contextWindow"`
+	// Capabilities description: Whether the model can be used for chat, just autocomplete, etc. Models with the "reasoning" capability accept a thinking budget.
+	Capabilities      []string                `json:"capabilities"`
+	Category          string                  `json:"category"`
+	ClientSideConfig  *ClientSideModelConfig  `json:"clientSideConfig,omitempty"`
+	ContextWindow     ContextWindow           `json:"contextWindow"`
+	DefaultParameters *ModelDefaultParameters `json:"defaultParameters,omitempty"`
 	// DisplayName description: display name
 	DisplayName string `json:"displayName"`
 	// ModelName description: model name used when sending requests to the LLM provider's backend API.
@@ -2055,9 +2088,15 @@ type OrganizationInvitations struct {
 type OtherExternalServiceConnection struct {
 	// Exclude description: A list of repositories to never mirror by name after applying repositoryPathPattern. Supports excluding by exact name ({"name": "myrepo"}) or regular expression ({"pattern": ".*secret.*"}).
 	Exclude []*ExcludedOtherRepo `json:"exclude,omitempty"`
+	// FetchLFSObjects description: EXPERIMENTAL: If true, the Git LFS objects of the default branch are fetched from the code host into the repository's LFS storage on gitserver after cloning and fetching a repository. Reading files, search and archives still return the LFS pointer files. Requires git-lfs to be installed on gitserver. Failing to fetch the LFS objects doesn't fail the clone or fetch.
+	FetchLFSObjects bool `json:"fetchLFSObjects,omitempty"`
+	// FetchRefspecs description: EXPERIMENTAL: The refspecs fetched from the code host, replacing the default set of refspecs. The default fetches branches, tags and the pull request, merge request and changeset refs of all supported code hosts. For example, only fetch `+refs/heads/*:refs/heads/*` and `+refs/tags/*:refs/tags/*` to leave out pull request refs.
+	FetchRefspecs []string `json:"fetchRefspecs,omitempty"`
 	// MakeReposPublicOnDotCom description: Whether or not these repositories should be marked as public on Sourcegraph.com. Defaults to false.
-	MakeReposPublicOnDotCom bool     `json:"makeReposPublicOnDotCom,omitempty"`
-	Repos                   []string `json:"repos"`
+	MakeReposPublicOnDotCom bool `json:"makeReposPublicOnDotCom,omitempty"`
+	// PartialClone description: EXPERIMENTAL: If true, repositories are mirrored as blobless partial clones (`--filter=blob:none`). Commits and trees are fetched as usual, but file contents are only fetched from the code host when they are first read. This greatly reduces disk usage for large repositories at the cost of slower first reads of a file, and requires the code host to support partial clone.
+	PartialClone bool     `json:"partialClone,omitempty"`
+	Repos        []string `json:"repos"`
 	// RepositoryPathPattern description: The pattern used to generate the corresponding Sourcegraph repository name for the repositories. In the pattern, the variable "{base}" is replaced with the Git clone base URL host and path, and "{repo}" is replaced with the repository path taken from the `repos` field.
 	//
 	// For example, if your Git clone base URL is https://git.example.com/repos and `repos` contains the value "my/repo", then a repositoryPathPattern of "{base}/{repo}" would mean that a repository at https://git.example.com/repos/my/repo is available on Sourcegraph at https://sourcegraph.example.com/git.example.com/repos/my/repo.
@@ -2066,7 +2105,9 @@ type OtherExternalServiceConnection struct {
 	//
 	// Note: These patterns are ignored if using src-expose / src-serve.
 	RepositoryPathPattern string `json:"repositoryPathPattern,omitempty"`
-	Url                   string `json:"url,omitempty"`
+	// SparseExcludePaths description: EXPERIMENTAL: Paths whose file contents are never fetched, e.g. vendored dependencies or generated assets that don't need to be searched. Repositories are mirrored as blobless partial clones and only the file contents outside of these paths are fetched for the default branch. Paths are relative to the repository root. Requires the code host to support partial clone.
+	SparseExcludePaths []string `json:"sparseExcludePaths,omitempty"`
+	Url                string   `json:"url,omitempty"`
 }
 type OutputVariable struct {
 	// Format description: The expected format of the output. If set, the output is being parsed in that format before being stored in the var. If not set, 'text' is assumed to the format.
@@ -2398,6 +2439,8 @@ type SearchLimits struct {
 	CommitDiffMaxRepos int `json:"commitDiffMaxRepos,omitempty"`
 	// CommitDiffWithTimeFilterMaxRepos description: The maximum number of repositories to search across when doing a "type:diff" or "type:commit" with a "after:" or "before:" filter. The user is prompted to narrow their query if the limit is exceeded. There is a separate limit (commitDiffMaxRepos) when "after:" or "before:" is not specified because those queries are slower. Defaults to 10000.
 	CommitDiffWithTimeFilterMaxRepos int `json:"commitDiffWithTimeFilterMaxRepos,omitempty"`
+	// ExhaustiveCommitDiffMaxResults description: The maximum number of results a search job returns for a "type:diff" or "type:commit" query. A "count:" in the query takes precedence. Defaults to 1000000.
+	ExhaustiveCommitDiffMaxResults int `json:"exhaustiveCommitDiffMaxResults,omitempty"`
 	// MaxRepos description: The maximum number of repositories to search across. The user is prompted to narrow their query if exceeded. Any value less than or equal to zero means unlimited.
 	MaxRepos int `json:"maxRepos,omitempty"`
 	// MaxTimeoutSeconds description: The maximum value for "timeout:" that search will respect. "timeout:" values larger than maxTimeoutSeconds are capped at maxTimeoutSeconds. Note: You need to ensure your load balancer / reverse proxy in front of Sourcegraph won't timeout the request for larger values. Note: Too many large rearch requests may harm Soucregraph for other users. Note: Experimental search jobs do not respect this limit. Defaults to 1 minute.
@@ -2610,8 +2653,12 @@ type ServerSideProviderConfigGoogleProvider struct {
 }
 type ServerSideProviderConfigOpenAICompatibleProvider struct {
 	AccessToken string `json:"accessToken"`
-	Endpoint    string `json:"endpoint"`
-	Type        string `json:"type"`
+	// ChatCompletionsPath description: The path chat requests are sent to, replacing any path of the endpoint. Defaults to 'v1/chat/completions'. Useful for gateways mounting the OpenAI API under a prefix, e.g. 'openai/v1/chat/completions'.
+	ChatCompletionsPath string `json:"chatCompletionsPath,omitempty"`
+	// CompletionsPath description: The path code completion requests are sent to, replacing any path of the endpoint. Defaults to 'v1/completions'. Useful for gateways mounting the OpenAI API under a prefix, e.g. 'openai/v1/completions'.
+	CompletionsPath string `json:"completionsPath,omitempty"`
+	Endpoint        string `json:"endpoint"`
+	Type            string `json:"type"`
 }
 type ServerSideProviderConfigOpenAIProvider struct {
 	AccessToken string `json:"accessToken"`
@@ -3417,6 +3464,7 @@ func (v *SiteConfiguration) UnmarshalJSON(data []byte) error {
 // SiteModelConfiguration description: BETA FEATURE, only enable if you know what you are doing. If set, Cody will use the new model configuration system and ignore the old 'completions' site configuration entirely.
 type SiteModelConfiguration struct {
 	DefaultModels *DefaultModels `json:"defaultModels,omitempty"`
+	FeatureModels *FeatureModels `json:"featureModels,omitempty"`
 	// ModelOverrides description: Override, or add to, the list of models Cody is aware of and how they are configured to work
 	ModelOverrides []*ModelOverride `json:"modelOverrides,omitempty"`
 	// ModelOverridesRecommendedSettings description: Override, or add to, the list of models Cody is aware of - but let Sourcegraph configure how the model should work. Only available for select models.
@@ -3,37 +3,148 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
-	"runtime"
 	"strings"
 	"time"
 
 	_ "github.com/lib/pq"
 	"github.com/urfave/cli/v2"
-	"k8s.io/utils/strings/slices"
 
 	"github.com/sourcegraph/conc/pool"
 	"github.com/sourcegraph/run"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
 type stampVersionKey struct{}
 type postReleaseKey struct{}
 type targetRegistryKey struct{}
 type fromRegistryKey struct{}
+type externalDBsKey struct{}
+type dbPingTimeoutKey struct{}
+type frontendInitTimeoutKey struct{}
+type frontendHealthTimeoutKey struct{}
+type seedFileKey struct{}
+
+// externalDBFlags allow pointing the tests at already running databases instead of creating a set of postgres containers per test.
+var externalDBFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "pgsql-dsn",
+		Usage: "Connection string of an already running frontend database. Must be reachable from the test's docker network. Requires codeintel-dsn and codeinsights-dsn to be set.",
+	},
+	&cli.StringFlag{
+		Name:  "codeintel-dsn",
+		Usage: "Connection string of an already running codeintel database. Must be reachable from the test's docker network.",
+	},
+	&cli.StringFlag{
+		Name:  "codeinsights-dsn",
+		Usage: "Connection string of an already running codeinsights database. Must be reachable from the test's docker network.",
+	},
+}
+
+// testTimeoutFlag bounds the runtime of each upgrade test, see withTestTimeout.
+var testTimeoutFlag = &cli.DurationFlag{
+	Name:  "test-timeout",
+	Usage: "Maximum runtime of a single upgrade test, after which its commands are killed and the test fails. Set to 0 to disable.",
+	Value: 45 * time.Minute,
+}
+
+// dbPingTimeoutFlag, frontendInitTimeoutFlag and frontendHealthTimeoutFlag bound how long a test waits for its databases and frontend to come up, see withStartupTimeouts.
+var (
+	dbPingTimeoutFlag = &cli.DurationFlag{
+		Name:  "db-ping-timeout",
+		Usage: "Maximum time to wait for a test's databases to accept connections. Raise this if postgres is slow to start, e.g. on busy CI agents running many tests in parallel. A higher value makes tests with broken databases take longer to fail.",
+		Value: defaultDBPingTimeout,
+	}
+	frontendInitTimeoutFlag = &cli.DurationFlag{
+		Name:  "frontend-init-timeout",
+		Usage: "Maximum time to wait for the frontend to initialize a test's databases. Raise this if the frontend is slow to start, e.g. on busy CI agents running many tests in parallel. A higher value makes tests with a broken frontend take longer to fail.",
+		Value: defaultFrontendInitTimeout,
+	}
+	frontendHealthTimeoutFlag = &cli.DurationFlag{
+		Name:  "frontend-health-timeout",
+		Usage: "Maximum time to wait for the upgraded frontend to report healthy on its /healthz endpoint. A frontend that doesn't become healthy in time fails the test.",
+		Value: defaultFrontendHealthTimeout,
+	}
+)
+
+// seedFileFlag points to SQL that is applied to the frontend database at the initial version of each test, see seedFrontendDB.
+var seedFileFlag = &cli.StringFlag{
+	Name:      "seed-file",
+	Usage:     "Path to a SQL file applied to the frontend database once it is initialized at the initial version of each test, e.g. to have rows for out of band migrations to migrate.",
+	TakesFile: true,
+}
+
+// skipVersionsFileFlag points to a file listing versions to skip in addition to knownBugVersions, see loadSkipVersions.
+var skipVersionsFileFlag = &cli.StringFlag{
+	Name:      "skip-versions-file",
+	Usage:     "Path to a YAML or JSON file listing initial versions not to test, in addition to the built-in list of versions with known bugs. Each entry has a \"version\", optionally the test \"types\" (std, mvu, auto) to skip it for and a \"reason\".",
+	TakesFile: true,
+}
+
+// minDiskGBFlag sets the free disk space docker needs for a run to start, see checkDockerDiskSpace.
+var minDiskGBFlag = &cli.IntFlag{
+	Name:  "min-disk-gb",
+	Usage: "Abort before running any test if docker has less than this many gigabytes of disk space free. Requires docker to run on this machine. Set to 0 to disable the check.",
+	Value: 0,
+}
+
+// outputFlag selects the format test results are printed in, see printResults.
+var outputFlag = &cli.StringFlag{
+	Name:  "output",
+	Usage: "Format of the test results printed once all tests ran, either \"text\" or \"json\".",
+	Value: "text",
+	Action: func(_ *cli.Context, output string) error {
+		if output != "text" && output != "json" {
+			return errors.Newf("--output must be either \"text\" or \"json\", got %q", output)
+		}
+		return nil
+	},
+}
+
+// concurrencyFlag sets the number of tests run at the same time, see maxRoutines. It applies to all commands, so it is given before the command,
+// e.g. `upgrade-test --concurrency 2 standard`.
+var concurrencyFlag = &cli.IntFlag{
+	Name:  "concurrency",
+	Usage: "Maximum number of tests to run concurrently. Sets the goroutine pool limit of every test type. Must be at least 1.",
+	Value: 10,
+}
+
+// progressOut is where progress, log and error output is written to. It is
+// stdout, unless the results are printed as JSON, in which case it is stderr
+// so that stdout only contains the results.
+var progressOut io.Writer = os.Stdout
+
+// setupOutput selects progressOut according to outputFlag and prints the
+// banner. It is the Before hook of all commands, since flag actions only run
+// after it.
+func setupOutput(cCtx *cli.Context) error {
+	if cCtx.String(outputFlag.Name) == "json" {
+		progressOut = os.Stderr
+	}
+	fmt.Fprintln(progressOut, "👉 Upgrade test ...")
+	return nil
+}
 
 // Register upgrade commands -- see README.md for more details.
 func main() {
-	fmt.Println("👉 Upgrade test ...")
-
 	app := &cli.App{
 		Name:  "upgrade-test",
 		Usage: "Upgrade test is a tool for testing the migrator services creation of upgrade paths and application of upgrade paths.\nWhen run relevant upgrade paths are tested for each version relevant to a given upgrade type, initializing Sourcegraph databases and frontend services for each version, and attempting to generate and apply an upgrade path to your current branches head.",
+		Flags: []cli.Flag{concurrencyFlag},
+		Before: func(cCtx *cli.Context) error {
+			if c := cCtx.Int(concurrencyFlag.Name); c < 1 {
+				return errors.Newf("--%s must be at least 1, got %d", concurrencyFlag.Name, c)
+			}
+			return nil
+		},
 		Commands: []*cli.Command{
 			{
 				Name:    "all-types",
 				Aliases: []string{"all"},
 				Usage:   "Runs all upgrade test types\n\nRequires stamp-version for tryAutoUpgrade call.",
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					&cli.StringFlag{
 						Name:    "stamp-version",
 						Aliases: []string{"sv"},
@@ -55,12 +166,6 @@ func main() {
 						Usage: "Registry host and path to pull versions we're upgrading from, i.e. index.docker.io/sourcegraph will pull index.docker.io/sourcegraph/migrator:<tag>",
 						Value: "sourcegraph/",
 					},
-					&cli.IntFlag{
-						Name:    "max-routines",
-						Aliases: []string{"mr"},
-						Usage:   "Maximum number of tests to run concurrently. Sets goroutine pool limit.\n Defaults to CPU cores count minus two.",
-						Value:   runtime.NumCPU() - 2,
-					},
 					&cli.StringSliceFlag{
 						Name:    "standard-versions",
 						Aliases: []string{"svs"},
@@ -76,16 +181,38 @@ func main() {
 						Aliases: []string{"avs"},
 						Usage:   "Override automatic version selection and set auto versions to test.",
 					},
-				},
+					testTimeoutFlag,
+					outputFlag,
+					dbPingTimeoutFlag,
+					frontendInitTimeoutFlag,
+					frontendHealthTimeoutFlag,
+					seedFileFlag,
+					skipVersionsFileFlag,
+					minDiskGBFlag,
+				}, externalDBFlags...),
+				Before: setupOutput,
 				Action: func(cCtx *cli.Context) error {
 					ctx := context.WithValue(cCtx.Context, stampVersionKey{}, cCtx.String("stamp-version"))
 					ctx = context.WithValue(ctx, postReleaseKey{}, cCtx.String("post-release-version"))
 					ctx = context.WithValue(ctx, targetRegistryKey{}, cCtx.String("target-registry"))
 					ctx = context.WithValue(ctx, fromRegistryKey{}, cCtx.String("from-registry"))
+					ctx = withStartupTimeouts(ctx, cCtx)
+					ctx = context.WithValue(ctx, seedFileKey{}, cCtx.String(seedFileFlag.Name))
+					ctx, err := withExternalDBs(ctx, cCtx)
+					if err != nil {
+						fmt.Fprintln(progressOut, "🚨 Error: invalid external database configuration: ", err)
+						os.Exit(1)
+					}
 
 					// check docker is running
 					if err := run.Cmd(ctx, "docker", "ps").Run().Wait(); err != nil {
-						fmt.Println("🚨 Error: could not connect to docker: ", err)
+						fmt.Fprintln(progressOut, "🚨 Error: could not connect to docker: ", err)
+						os.Exit(1)
+					}
+
+					// check docker has enough disk space for the tests
+					if err := checkDockerDiskSpace(ctx, cCtx.Int(minDiskGBFlag.Name)); err != nil {
+						fmt.Fprintln(progressOut, "🚨 Error: not enough disk space: ", err)
 						os.Exit(1)
 					}
 
@@ -97,7 +224,7 @@ func main() {
 						cCtx.String("post-release-version"),
 					)
 					if err != nil {
-						fmt.Println("🚨 Error: failed to get test version ranges: ", err)
+						fmt.Fprintln(progressOut, "🚨 Error: failed to get test version ranges: ", err)
 						os.Exit(1)
 					}
 
@@ -111,13 +238,19 @@ func main() {
 						targetMigratorImage = "migrator:candidate"
 					}
 
-					fmt.Println("Latest stable release version: ", latestStableVersion)
-					fmt.Println("Latest minor version: ", latestMinorVersion)
-					fmt.Println("Target version: ", targetVersion)
-					fmt.Println("Migrator image used to upgrade: ", targetMigratorImage)
-					fmt.Println("Standard Versions:", stdVersions)
-					fmt.Println("Multiversion Versions:", mvuVersions)
-					fmt.Println("Autoupgrade Versions:", autoVersions)
+					fmt.Fprintln(progressOut, "Latest stable release version: ", latestStableVersion)
+					fmt.Fprintln(progressOut, "Latest minor version: ", latestMinorVersion)
+					fmt.Fprintln(progressOut, "Target version: ", targetVersion)
+					fmt.Fprintln(progressOut, "Migrator image used to upgrade: ", targetMigratorImage)
+					fmt.Fprintln(progressOut, "Standard Versions:", stdVersions)
+					fmt.Fprintln(progressOut, "Multiversion Versions:", mvuVersions)
+					fmt.Fprintln(progressOut, "Autoupgrade Versions:", autoVersions)
+
+					skips, err := loadSkipVersions(cCtx.String(skipVersionsFileFlag.Name))
+					if err != nil {
+						fmt.Fprintln(progressOut, "🚨 Error: failed to load versions to skip: ", err)
+						os.Exit(1)
+					}
 
 					// initialize test results
 					var results TestResults
@@ -144,37 +277,38 @@ func main() {
 					}
 
 					// Run all test types
-					testPool := pool.New().WithMaxGoroutines(cCtx.Int("max-routines")).WithErrors()
+					testPool := pool.New().WithMaxGoroutines(maxRoutines(ctx, cCtx)).WithErrors()
 					for _, version := range versions {
 						version := version
-						if slices.Contains(knownBugVersions, version.Version.String()) {
+						if reason, ok := skipReason(skips, version.Type, version.Version); ok {
+							results.AddSkippedTest(version.Type, version.Version, reason)
 							continue
 						}
 
 						switch version.Type {
 						case "std":
 							testPool.Go(func() error {
-								fmt.Println("std: ", version.Version)
+								fmt.Fprintln(progressOut, "std: ", version.Version)
 								start := time.Now()
-								result := standardUpgradeTest(ctx, version.Version, targetVersion, latestStableVersion)
+								result := withTestTimeout(cCtx.Duration("test-timeout"), standardUpgradeTest)(ctx, version.Version, targetVersion, latestStableVersion)
 								result.Runtime = time.Since(start)
 								results.AddStdTest(result)
 								return nil
 							})
 						case "mvu":
 							testPool.Go(func() error {
-								fmt.Println("mvu: ", version.Version)
+								fmt.Fprintln(progressOut, "mvu: ", version.Version)
 								start := time.Now()
-								result := multiversionUpgradeTest(ctx, version.Version, targetVersion, latestStableVersion)
+								result := withTestTimeout(cCtx.Duration("test-timeout"), multiversionUpgradeTest)(ctx, version.Version, targetVersion, latestStableVersion)
 								result.Runtime = time.Since(start)
 								results.AddMVUTest(result)
 								return nil
 							})
 						case "auto":
 							testPool.Go(func() error {
-								fmt.Println("auto: ", version.Version)
+								fmt.Fprintln(progressOut, "auto: ", version.Version)
 								start := time.Now()
-								result := autoUpgradeTest(ctx, version.Version, targetVersion, latestStableVersion)
+								result := withTestTimeout(cCtx.Duration("test-timeout"), autoUpgradeTest)(ctx, version.Version, targetVersion, latestStableVersion)
 								result.Runtime = time.Since(start)
 								results.AddAutoTest(result)
 								return nil
@@ -182,16 +316,18 @@ func main() {
 						}
 					}
 					if err := testPool.Wait(); err != nil {
-						fmt.Println("🚨 Error: failed to run tests in pool: ", err)
+						fmt.Fprintln(progressOut, "🚨 Error: failed to run tests in pool: ", err)
 						return err
 					}
 
 					// This is where we do the majority of our printing to stdout.
 					results.OrderByVersion()
-					results.PrintSimpleResults()
-					if results.Failed() {
+					if err := printResults(cCtx, &results); err != nil {
+						return err
+					}
+					if results.HasFailures() {
 						results.DisplayErrors()
-						os.Exit(1)
+						return errors.New("one or more upgrade tests failed")
 					}
 
 					return nil
@@ -201,7 +337,7 @@ func main() {
 				Name:    "standard",
 				Aliases: []string{"std"},
 				Usage:   "Runs standard upgrade tests for all patch versions from the last minor version.\nEx: 5.1.x -> 5.2.x (head)",
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					&cli.StringFlag{
 						Name:    "stamp-version",
 						Aliases: []string{"sv"},
@@ -223,33 +359,50 @@ func main() {
 						Usage: "Registry host and path to pull versions we're upgrading from, i.e. index.docker.io/sourcegraph will pull index.docker.io/sourcegraph/migrator:<tag>",
 						Value: "sourcegraph/",
 					},
-					&cli.IntFlag{
-						Name:    "max-routines",
-						Aliases: []string{"mr"}, Usage: "Maximum number of tests to run concurrently. Sets goroutine pool limit.\n Defaults to 10.",
-						Value: runtime.NumCPU() - 2,
-					},
 					&cli.StringSliceFlag{
 						Name:    "standard-versions",
 						Aliases: []string{"svs"},
 						Usage:   "Override automatic version selection and set standard versions to test.",
 					},
-				},
+					testTimeoutFlag,
+					outputFlag,
+					dbPingTimeoutFlag,
+					frontendInitTimeoutFlag,
+					frontendHealthTimeoutFlag,
+					seedFileFlag,
+					skipVersionsFileFlag,
+					minDiskGBFlag,
+				}, externalDBFlags...),
+				Before: setupOutput,
 				Action: func(cCtx *cli.Context) error {
 					ctx := context.WithValue(cCtx.Context, stampVersionKey{}, cCtx.String("stamp-version"))
 					ctx = context.WithValue(ctx, postReleaseKey{}, cCtx.String("post-release-version"))
 					ctx = context.WithValue(ctx, targetRegistryKey{}, cCtx.String("target-registry"))
 					ctx = context.WithValue(ctx, fromRegistryKey{}, cCtx.String("from-registry"))
+					ctx = withStartupTimeouts(ctx, cCtx)
+					ctx = context.WithValue(ctx, seedFileKey{}, cCtx.String(seedFileFlag.Name))
+					ctx, err := withExternalDBs(ctx, cCtx)
+					if err != nil {
+						fmt.Fprintln(progressOut, "🚨 Error: invalid external database configuration: ", err)
+						os.Exit(1)
+					}
 
 					// check docker is running
 					if err := run.Cmd(ctx, "docker", "ps").Run().Wait(); err != nil {
-						fmt.Println("🚨 Error: could not connect to docker: ", err)
+						fmt.Fprintln(progressOut, "🚨 Error: could not connect to docker: ", err)
+						os.Exit(1)
+					}
+
+					// check docker has enough disk space for the tests
+					if err := checkDockerDiskSpace(ctx, cCtx.Int(minDiskGBFlag.Name)); err != nil {
+						fmt.Fprintln(progressOut, "🚨 Error: not enough disk space: ", err)
 						os.Exit(1)
 					}
 
 					// Get init versions to use for initializing upgrade environments for tests
 					latestMinorVersion, latestStableVersion, targetVersion, stdVersions, _, _, err := handleVersions(cCtx, cCtx.StringSlice("standard-versions"), nil, nil, cCtx.String("post-release-version"))
 					if err != nil {
-						fmt.Println("🚨 Error: failed to get test version ranges: ", err)
+						fmt.Fprintln(progressOut, "🚨 Error: failed to get test version ranges: ", err)
 						os.Exit(1)
 					}
 
@@ -263,11 +416,17 @@ func main() {
 						targetMigratorImage = "migrator:candidate"
 					}
 
-					fmt.Println("Latest stable release version: ", latestStableVersion)
-					fmt.Println("Latest minor version: ", latestMinorVersion)
-					fmt.Println("Target version: ", targetVersion)
-					fmt.Println("Migrator image used to upgrade: ", targetMigratorImage)
-					fmt.Println("Standard Versions:", stdVersions)
+					fmt.Fprintln(progressOut, "Latest stable release version: ", latestStableVersion)
+					fmt.Fprintln(progressOut, "Latest minor version: ", latestMinorVersion)
+					fmt.Fprintln(progressOut, "Target version: ", targetVersion)
+					fmt.Fprintln(progressOut, "Migrator image used to upgrade: ", targetMigratorImage)
+					fmt.Fprintln(progressOut, "Standard Versions:", stdVersions)
+
+					skips, err := loadSkipVersions(cCtx.String(skipVersionsFileFlag.Name))
+					if err != nil {
+						fmt.Fprintln(progressOut, "🚨 Error: failed to load versions to skip: ", err)
+						os.Exit(1)
+					}
 
 					// initialize test results
 					var results TestResults
@@ -275,16 +434,17 @@ func main() {
 					// Run Standard Upgrade Tests in goroutines. The current limit is set as 10 concurrent goroutines per test type (std, mvu, auto). This is to address
 					// dynamic port allocation issues that occur in docker when creating many bridge networks, but tests begin to fail when a sufficient number of
 					// goroutines are running on local machine. We may tune this in CI.
-					stdTestPool := pool.New().WithMaxGoroutines(cCtx.Int("max-routines")).WithErrors()
+					stdTestPool := pool.New().WithMaxGoroutines(maxRoutines(ctx, cCtx)).WithErrors()
 					for _, version := range stdVersions {
 						version := version
-						if slices.Contains(knownBugVersions, version.String()) {
+						if reason, ok := skipReason(skips, "std", version); ok {
+							results.AddSkippedTest("std", version, reason)
 							continue
 						}
 						stdTestPool.Go(func() error {
-							fmt.Println("std: ", version)
+							fmt.Fprintln(progressOut, "std: ", version)
 							start := time.Now()
-							result := standardUpgradeTest(ctx, version, targetVersion, latestStableVersion)
+							result := withTestTimeout(cCtx.Duration("test-timeout"), standardUpgradeTest)(ctx, version, targetVersion, latestStableVersion)
 							result.Runtime = time.Since(start)
 							results.AddStdTest(result)
 							if len(result.Errors) > 0 {
@@ -294,11 +454,11 @@ func main() {
 						})
 					}
 					if err := stdTestPool.Wait(); err != nil {
-						fmt.Println("🚨 Error: failed to run tests in pool: ", err)
+						fmt.Fprintln(progressOut, "🚨 Error: failed to run tests in pool: ", err)
 						for _, t := range results.StandardUpgradeTests {
-							fmt.Println("LOGS")
+							fmt.Fprintln(progressOut, "LOGS")
 							t.DisplayLog()
-							fmt.Println("ERROR")
+							fmt.Fprintln(progressOut, "ERROR")
 							t.DisplayErrors()
 						}
 						return err
@@ -306,10 +466,12 @@ func main() {
 
 					// This is where we do the majority of our printing to stdout.
 					results.OrderByVersion()
-					results.PrintSimpleResults()
-					if results.Failed() {
+					if err := printResults(cCtx, &results); err != nil {
+						return err
+					}
+					if results.HasFailures() {
 						results.DisplayErrors()
-						os.Exit(1)
+						return errors.New("one or more upgrade tests failed")
 					}
 
 					return nil
@@ -319,7 +481,7 @@ func main() {
 				Name:    "multiversion",
 				Aliases: []string{"mvu"},
 				Usage:   "Runs multiversion upgrade tests for all versions which would require a multiversion upgrade to reach your current repo head. i.e those versions more than a minor version behind the last minor release.\nEx: 3.4.1 -> 5.2.6",
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					&cli.StringFlag{
 						Name:    "stamp-version",
 						Aliases: []string{"sv"},
@@ -341,34 +503,50 @@ func main() {
 						Usage: "Registry host and path to pull versions we're upgrading from, i.e. index.docker.io/sourcegraph will pull index.docker.io/sourcegraph/migrator:<tag>",
 						Value: "sourcegraph/",
 					},
-					&cli.IntFlag{
-						Name:    "max-routines",
-						Aliases: []string{"mr"},
-						Usage:   "Maximum number of tests to run concurrently. Sets goroutine pool limit.\n Defaults to 10.",
-						Value:   runtime.NumCPU() - 2,
-					},
 					&cli.StringSliceFlag{
 						Name:    "mvu-versions",
 						Aliases: []string{"mvs"},
 						Usage:   "Override automatic version selection and set mvu versions to test.",
 					},
-				},
+					testTimeoutFlag,
+					outputFlag,
+					dbPingTimeoutFlag,
+					frontendInitTimeoutFlag,
+					frontendHealthTimeoutFlag,
+					seedFileFlag,
+					skipVersionsFileFlag,
+					minDiskGBFlag,
+				}, externalDBFlags...),
+				Before: setupOutput,
 				Action: func(cCtx *cli.Context) error {
 					ctx := context.WithValue(cCtx.Context, stampVersionKey{}, cCtx.String("stamp-version"))
 					ctx = context.WithValue(ctx, postReleaseKey{}, cCtx.String("post-release-version"))
 					ctx = context.WithValue(ctx, targetRegistryKey{}, cCtx.String("target-registry"))
 					ctx = context.WithValue(ctx, fromRegistryKey{}, cCtx.String("from-registry"))
+					ctx = withStartupTimeouts(ctx, cCtx)
+					ctx = context.WithValue(ctx, seedFileKey{}, cCtx.String(seedFileFlag.Name))
+					ctx, err := withExternalDBs(ctx, cCtx)
+					if err != nil {
+						fmt.Fprintln(progressOut, "🚨 Error: invalid external database configuration: ", err)
+						os.Exit(1)
+					}
 
 					// check docker is running
 					if err := run.Cmd(ctx, "docker", "ps").Run().Wait(); err != nil {
-						fmt.Println("🚨 Error: could not connect to docker: ", err)
+						fmt.Fprintln(progressOut, "🚨 Error: could not connect to docker: ", err)
+						os.Exit(1)
+					}
+
+					// check docker has enough disk space for the tests
+					if err := checkDockerDiskSpace(ctx, cCtx.Int(minDiskGBFlag.Name)); err != nil {
+						fmt.Fprintln(progressOut, "🚨 Error: not enough disk space: ", err)
 						os.Exit(1)
 					}
 
 					// Get init versions to use for initializing upgrade environments for tests
 					latestMinorVersion, latestStableVersion, targetVersion, _, mvuVersions, _, err := handleVersions(cCtx, nil, cCtx.StringSlice("mvu-versions"), nil, cCtx.String("post-release-version"))
 					if err != nil {
-						fmt.Println("🚨 Error: failed to get test version ranges: ", err)
+						fmt.Fprintln(progressOut, "🚨 Error: failed to get test version ranges: ", err)
 						os.Exit(1)
 					}
 
@@ -382,26 +560,33 @@ func main() {
 						targetMigratorImage = "migrator:candidate"
 					}
 
-					fmt.Println("Latest stable release version: ", latestStableVersion)
-					fmt.Println("Latest minor version: ", latestMinorVersion)
-					fmt.Println("Target version: ", targetVersion)
-					fmt.Println("Migrator image used to upgrade: ", targetMigratorImage)
-					fmt.Println("MVU Versions:", mvuVersions)
+					fmt.Fprintln(progressOut, "Latest stable release version: ", latestStableVersion)
+					fmt.Fprintln(progressOut, "Latest minor version: ", latestMinorVersion)
+					fmt.Fprintln(progressOut, "Target version: ", targetVersion)
+					fmt.Fprintln(progressOut, "Migrator image used to upgrade: ", targetMigratorImage)
+					fmt.Fprintln(progressOut, "MVU Versions:", mvuVersions)
+
+					skips, err := loadSkipVersions(cCtx.String(skipVersionsFileFlag.Name))
+					if err != nil {
+						fmt.Fprintln(progressOut, "🚨 Error: failed to load versions to skip: ", err)
+						os.Exit(1)
+					}
 
 					// initialize test results
 					var results TestResults
 
 					// Run MVU Upgrade Tests
-					mvuTestPool := pool.New().WithMaxGoroutines(cCtx.Int("max-routines")).WithErrors()
+					mvuTestPool := pool.New().WithMaxGoroutines(maxRoutines(ctx, cCtx)).WithErrors()
 					for _, version := range mvuVersions {
 						version := version
-						if slices.Contains(knownBugVersions, version.String()) {
+						if reason, ok := skipReason(skips, "mvu", version); ok {
+							results.AddSkippedTest("mvu", version, reason)
 							continue
 						}
 						mvuTestPool.Go(func() error {
-							fmt.Println("mvu: ", version)
+							fmt.Fprintln(progressOut, "mvu: ", version)
 							start := time.Now()
-							result := multiversionUpgradeTest(ctx, version, targetVersion, latestStableVersion)
+							result := withTestTimeout(cCtx.Duration("test-timeout"), multiversionUpgradeTest)(ctx, version, targetVersion, latestStableVersion)
 							result.Runtime = time.Since(start)
 							results.AddMVUTest(result)
 							if len(result.Errors) > 0 {
@@ -411,15 +596,17 @@ func main() {
 						})
 					}
 					if err := mvuTestPool.Wait(); err != nil {
-						fmt.Println("🚨 Error: failed to run tests in pool: ", err)
+						fmt.Fprintln(progressOut, "🚨 Error: failed to run tests in pool: ", err)
 						return err
 					}
 
 					results.OrderByVersion()
-					results.PrintSimpleResults()
-					if results.Failed() {
+					if err := printResults(cCtx, &results); err != nil {
+						return err
+					}
+					if results.HasFailures() {
 						results.DisplayErrors()
-						os.Exit(1)
+						return errors.New("one or more upgrade tests failed")
 					}
 
 					return nil
@@ -429,7 +616,7 @@ func main() {
 				Name:    "autoupgrade",
 				Aliases: []string{"auto"},
 				Usage:   "Runs autoupgrade upgrade tests for all versions.\n\nRequires stamp-version for tryAutoUpgrade call.",
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					&cli.StringFlag{
 						Name:    "stamp-version",
 						Aliases: []string{"sv"},
@@ -452,34 +639,50 @@ func main() {
 						Usage: "Registry host and path to pull versions we're upgrading from, i.e. index.docker.io/sourcegraph will pull index.docker.io/sourcegraph/migrator:<tag>",
 						Value: "sourcegraph/",
 					},
-					&cli.IntFlag{
-						Name:    "max-routines",
-						Aliases: []string{"mr"},
-						Usage:   "Maximum number of tests to run concurrently. Sets goroutine pool limit.\n Defaults to 10.",
-						Value:   runtime.NumCPU() - 2,
-					},
 					&cli.StringSliceFlag{
 						Name:    "auto-versions",
 						Aliases: []string{"avs"},
 						Usage:   "Override automatic version selection and set auto versions to test.",
 					},
-				},
+					testTimeoutFlag,
+					outputFlag,
+					dbPingTimeoutFlag,
+					frontendInitTimeoutFlag,
+					frontendHealthTimeoutFlag,
+					seedFileFlag,
+					skipVersionsFileFlag,
+					minDiskGBFlag,
+				}, externalDBFlags...),
+				Before: setupOutput,
 				Action: func(cCtx *cli.Context) error {
 					ctx := context.WithValue(cCtx.Context, stampVersionKey{}, cCtx.String("stamp-version"))
 					ctx = context.WithValue(ctx, postReleaseKey{}, cCtx.String("post-release-version"))
 					ctx = context.WithValue(ctx, targetRegistryKey{}, cCtx.String("target-registry"))
 					ctx = context.WithValue(ctx, fromRegistryKey{}, cCtx.String("from-registry"))
+					ctx = withStartupTimeouts(ctx, cCtx)
+					ctx = context.WithValue(ctx, seedFileKey{}, cCtx.String(seedFileFlag.Name))
+					ctx, err := withExternalDBs(ctx, cCtx)
+					if err != nil {
+						fmt.Fprintln(progressOut, "🚨 Error: invalid external database configuration: ", err)
+						os.Exit(1)
+					}
 
 					// check docker is running
 					if err := run.Cmd(ctx, "docker", "ps").Run().Wait(); err != nil {
-						fmt.Println("🚨 Error: could not connect to docker: ", err)
+						fmt.Fprintln(progressOut, "🚨 Error: could not connect to docker: ", err)
+						os.Exit(1)
+					}
+
+					// check docker has enough disk space for the tests
+					if err := checkDockerDiskSpace(ctx, cCtx.Int(minDiskGBFlag.Name)); err != nil {
+						fmt.Fprintln(progressOut, "🚨 Error: not enough disk space: ", err)
 						os.Exit(1)
 					}
 
 					// Get init versions to use for initializing upgrade environments for tests
 					latestMinorVersion, latestStableVersion, targetVersion, _, _, autoVersions, err := handleVersions(cCtx, nil, nil, cCtx.StringSlice("auto-versions"), cCtx.String("post-release-version"))
 					if err != nil {
-						fmt.Println("🚨 Error: failed to get test version ranges: ", err)
+						fmt.Fprintln(progressOut, "🚨 Error: failed to get test version ranges: ", err)
 						os.Exit(1)
 					}
 
@@ -493,26 +696,33 @@ func main() {
 						targetMigratorImage = "migrator:candidate"
 					}
 
-					fmt.Println("Latest stable release version: ", latestStableVersion)
-					fmt.Println("Latest minor version: ", latestMinorVersion)
-					fmt.Println("Target version: ", targetVersion)
-					fmt.Println("Migrator image used to upgrade: ", targetMigratorImage)
-					fmt.Println("Auto Versions:", autoVersions)
+					fmt.Fprintln(progressOut, "Latest stable release version: ", latestStableVersion)
+					fmt.Fprintln(progressOut, "Latest minor version: ", latestMinorVersion)
+					fmt.Fprintln(progressOut, "Target version: ", targetVersion)
+					fmt.Fprintln(progressOut, "Migrator image used to upgrade: ", targetMigratorImage)
+					fmt.Fprintln(progressOut, "Auto Versions:", autoVersions)
+
+					skips, err := loadSkipVersions(cCtx.String(skipVersionsFileFlag.Name))
+					if err != nil {
+						fmt.Fprintln(progressOut, "🚨 Error: failed to load versions to skip: ", err)
+						os.Exit(1)
+					}
 
 					// initialize test results
 					var results TestResults
 
 					// Run Autoupgrade Tests
-					autoTestPool := pool.New().WithMaxGoroutines(cCtx.Int("max-routines")).WithErrors()
+					autoTestPool := pool.New().WithMaxGoroutines(maxRoutines(ctx, cCtx)).WithErrors()
 					for _, version := range autoVersions {
 						version := version
-						if slices.Contains(knownBugVersions, version.String()) {
+						if reason, ok := skipReason(skips, "auto", version); ok {
+							results.AddSkippedTest("auto", version, reason)
 							continue
 						}
 						autoTestPool.Go(func() error {
-							fmt.Println("auto: ", version)
+							fmt.Fprintln(progressOut, "auto: ", version)
 							start := time.Now()
-							result := autoUpgradeTest(ctx, version, targetVersion, latestStableVersion)
+							result := withTestTimeout(cCtx.Duration("test-timeout"), autoUpgradeTest)(ctx, version, targetVersion, latestStableVersion)
 							result.Runtime = time.Since(start)
 							results.AddAutoTest(result)
 							if len(result.Errors) > 0 {
@@ -523,15 +733,17 @@ func main() {
 						})
 					}
 					if err := autoTestPool.Wait(); err != nil {
-						fmt.Println("🚨 Error: failed to run tests in pool: ", err)
+						fmt.Fprintln(progressOut, "🚨 Error: failed to run tests in pool: ", err)
 						return err
 					}
 
 					results.OrderByVersion()
-					results.PrintSimpleResults()
-					if results.Failed() {
+					if err := printResults(cCtx, &results); err != nil {
+						return err
+					}
+					if results.HasFailures() {
 						results.DisplayErrors()
-						os.Exit(1)
+						return errors.New("one or more upgrade tests failed")
 					}
 
 					return nil
@@ -541,7 +753,7 @@ func main() {
 	}
 
 	if err := app.Run(os.Args); err != nil {
-		fmt.Println("🚨 Error: failed to run tests: ", err)
+		fmt.Fprintln(progressOut, "🚨 Error: failed to run tests: ", err)
 		os.Exit(1)
 	}
 
@@ -5,16 +5,24 @@ import (
 	"context"
 	"crypto/rand"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/Masterminds/semver"
+	"github.com/dustin/go-humanize"
 	"github.com/sourcegraph/conc/pool"
 	"github.com/sourcegraph/run"
 	"github.com/urfave/cli/v2"
+	"sigs.k8s.io/yaml"
 
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
@@ -27,6 +35,8 @@ type Test struct {
 	Runtime  time.Duration
 	LogLines []string
 	Errors   []error
+	// SkipReason is set if the test was skipped instead of run.
+	SkipReason string
 }
 
 // Addlog registers a log entry.
@@ -40,17 +50,17 @@ func (t *Test) AddError(err error) {
 	t.Errors = append(t.Errors, err)
 }
 
-// DisplayErrors prints errors to stdout
+// DisplayErrors prints errors to progressOut
 func (t *Test) DisplayErrors() {
 	for _, err := range t.Errors {
-		fmt.Println(err.Error())
+		fmt.Fprintln(progressOut, err.Error())
 	}
 }
 
-// DisplayLog prints logs to stdout
+// DisplayLog prints logs to progressOut
 func (t *Test) DisplayLog() {
 	for _, log := range t.LogLines {
-		fmt.Println(log)
+		fmt.Fprintln(progressOut, log)
 	}
 }
 
@@ -59,6 +69,11 @@ func (t *Test) Failed() bool {
 	return 0 < len(t.Errors)
 }
 
+// Skipped returns true if the test was skipped instead of run.
+func (t *Test) Skipped() bool {
+	return t.SkipReason != ""
+}
+
 // TestResults is a collection of tests, organized by type. Its methods are generally used to control its logging behavior.
 type TestResults struct {
 	StandardUpgradeTests []Test
@@ -88,8 +103,23 @@ func (r *TestResults) AddAutoTest(test Test) {
 	r.AutoupgradeTests = append(r.AutoupgradeTests, test)
 }
 
-// Failed returns true if any given test has errors registered.
-func (r *TestResults) Failed() bool {
+// AddSkippedTest records that testing version with the given test type, one of "std", "mvu" and "auto", was skipped for reason.
+func (r *TestResults) AddSkippedTest(testType string, version *semver.Version, reason string) {
+	switch testType {
+	case "std":
+		r.AddStdTest(Test{Version: *version, Type: "standard", SkipReason: reason})
+	case "mvu":
+		r.AddMVUTest(Test{Version: *version, Type: "multiversion", SkipReason: reason})
+	case "auto":
+		r.AddAutoTest(Test{Version: *version, Type: "auto", SkipReason: reason})
+	}
+}
+
+// HasFailures returns true if any given test has errors registered. It is safe
+// to call while tests are still being added.
+func (r *TestResults) HasFailures() bool {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
 	if 0 < len(r.StandardUpgradeTests) {
 		for _, test := range r.StandardUpgradeTests {
 			if test.Failed() {
@@ -120,21 +150,31 @@ type typeVersion struct {
 	Version *semver.Version
 }
 
+// skipVersion is an initial version that is not tested, e.g. because of a known bug in that version.
+type skipVersion struct {
+	Version string `json:"version"`
+	// Types are the test types to skip the version for, any of "std", "mvu" and "auto". If empty the version is skipped for all test types.
+	Types  []string `json:"types,omitempty"`
+	Reason string   `json:"reason,omitempty"`
+}
+
+const mvuInitBug = "known bug in MVU if initialized in this version, see https://github.com/sourcegraph/sourcegraph/pull/46969"
+
 // Known bug versions
 // versions 4.1.0 to v4.4.2 are affected by a known bug in MVU if initialized in these versions: https://github.com/sourcegraph/sourcegraph/pull/46969
 // versions 5.1.6 to v5.1.9 are affected by a known bug in MVU in which a migration was incorrectly backported introducing drift
-var knownBugVersions = []string{
-	"4.1.0",
-	"4.1.1",
-	"4.1.2",
-	"4.1.3",
-	"4.2.0",
-	"4.2.1",
-	"4.3.0",
-	"4.3.1",
-	"4.4.0",
-	"4.4.1",
-	"4.4.2",
+var knownBugVersions = []skipVersion{
+	{Version: "4.1.0", Reason: mvuInitBug},
+	{Version: "4.1.1", Reason: mvuInitBug},
+	{Version: "4.1.2", Reason: mvuInitBug},
+	{Version: "4.1.3", Reason: mvuInitBug},
+	{Version: "4.2.0", Reason: mvuInitBug},
+	{Version: "4.2.1", Reason: mvuInitBug},
+	{Version: "4.3.0", Reason: mvuInitBug},
+	{Version: "4.3.1", Reason: mvuInitBug},
+	{Version: "4.4.0", Reason: mvuInitBug},
+	{Version: "4.4.1", Reason: mvuInitBug},
+	{Version: "4.4.2", Reason: mvuInitBug},
 
 	// "5.1.6", // known bug in MVU, standard upgrades unaffected
 	// "5.1.7",
@@ -142,6 +182,53 @@ var knownBugVersions = []string{
 	// "5.1.9",
 }
 
+// loadSkipVersions returns knownBugVersions merged with the versions listed in the YAML or JSON file at path, if set.
+func loadSkipVersions(path string) ([]skipVersion, error) {
+	skips := append([]skipVersion{}, knownBugVersions...)
+	if path == "" {
+		return skips, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fromFile []skipVersion
+	if err := yaml.UnmarshalStrict(data, &fromFile); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", path)
+	}
+	for _, skip := range fromFile {
+		if _, err := semver.NewVersion(skip.Version); err != nil {
+			return nil, errors.Wrapf(err, "invalid version %q in %s", skip.Version, path)
+		}
+		for _, testType := range skip.Types {
+			if testType != "std" && testType != "mvu" && testType != "auto" {
+				return nil, errors.Newf("invalid test type %q for version %s in %s, must be one of std, mvu and auto", testType, skip.Version, path)
+			}
+		}
+	}
+
+	return append(skips, fromFile...), nil
+}
+
+// skipReason returns why testing version with the given test type, one of "std", "mvu" and "auto", is skipped, if it is.
+func skipReason(skips []skipVersion, testType string, version *semver.Version) (string, bool) {
+	for _, skip := range skips {
+		v, err := semver.NewVersion(skip.Version)
+		if err != nil || !v.Equal(version) {
+			continue
+		}
+		if len(skip.Types) > 0 && !slices.Contains(skip.Types, testType) {
+			continue
+		}
+		if skip.Reason == "" {
+			return "no reason given", true
+		}
+		return skip.Reason, true
+	}
+	return "", false
+}
+
 // PrintSimpleResults prints a quick view of test results, on an errored test only the first line of the error is printed.
 //
 // TODO: this needs to implement optional indenting on anything that emits container logs
@@ -149,7 +236,9 @@ func (r *TestResults) PrintSimpleResults() {
 	if len(r.StandardUpgradeTests) != 0 {
 		stdRes := []string{}
 		for _, test := range r.StandardUpgradeTests {
-			if test.Failed() {
+			if test.Skipped() {
+				stdRes = append(stdRes, fmt.Sprintf("⏭️ %s Skipped -- %s", test.Version.String(), test.SkipReason))
+			} else if test.Failed() {
 				stdRes = append(stdRes, fmt.Sprintf("🚨 %s Failed -- %s\n%s", test.Version.String(), test.Runtime, test.Errors[len(test.Errors)-1]))
 			} else {
 				stdRes = append(stdRes, fmt.Sprintf("✅ %s Passed -- %s ", test.Version.String(), test.Runtime))
@@ -161,7 +250,9 @@ func (r *TestResults) PrintSimpleResults() {
 	if len(r.MVUUpgradeTests) != 0 {
 		mvuRes := []string{}
 		for _, test := range r.MVUUpgradeTests {
-			if test.Failed() {
+			if test.Skipped() {
+				mvuRes = append(mvuRes, fmt.Sprintf("⏭️ %s Skipped -- %s", test.Version.String(), test.SkipReason))
+			} else if test.Failed() {
 				mvuRes = append(mvuRes, fmt.Sprintf("🚨 %s Failed -- %s\n%s", test.Version.String(), test.Runtime, test.Errors[len(test.Errors)-1]))
 			} else {
 				mvuRes = append(mvuRes, fmt.Sprintf("✅ %s Passed -- %s", test.Version.String(), test.Runtime))
@@ -173,7 +264,9 @@ func (r *TestResults) PrintSimpleResults() {
 	if len(r.AutoupgradeTests) != 0 {
 		autoRes := []string{}
 		for _, test := range r.AutoupgradeTests {
-			if test.Failed() {
+			if test.Skipped() {
+				autoRes = append(autoRes, fmt.Sprintf("⏭️ %s Skipped -- %s", test.Version.String(), test.SkipReason))
+			} else if test.Failed() {
 				autoRes = append(autoRes, fmt.Sprintf("🚨 %s Failed -- %s\n%s", test.Version.String(), test.Runtime, test.Errors[len(test.Errors)-1]))
 			} else {
 				autoRes = append(autoRes, fmt.Sprintf("✅ %s Passed -- %s", test.Version.String(), test.Runtime))
@@ -184,25 +277,74 @@ func (r *TestResults) PrintSimpleResults() {
 	}
 }
 
+// testResultJSON is the JSON representation of a Test written by WriteJSON.
+type testResultJSON struct {
+	Version string `json:"version"`
+	Type    string `json:"type"`
+	// RuntimeMs is the runtime in milliseconds, so that it is easy to consume, e.g. from JavaScript.
+	RuntimeMs  int64  `json:"runtimeMs"`
+	Passed     bool   `json:"passed"`
+	Error      string `json:"error,omitempty"`
+	Skipped    bool   `json:"skipped,omitempty"`
+	SkipReason string `json:"skipReason,omitempty"`
+}
+
+// WriteJSON writes all tests as a JSON array to w, for consumption by e.g. CI dashboards. Only the first error of a failed test is included.
+func (r *TestResults) WriteJSON(w io.Writer) error {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	res := []testResultJSON{}
+	for _, tests := range [][]Test{r.StandardUpgradeTests, r.MVUUpgradeTests, r.AutoupgradeTests} {
+		for _, test := range tests {
+			t := testResultJSON{
+				Version:    test.Version.String(),
+				Type:       test.Type,
+				RuntimeMs:  test.Runtime.Milliseconds(),
+				Passed:     !test.Failed() && !test.Skipped(),
+				Skipped:    test.Skipped(),
+				SkipReason: test.SkipReason,
+			}
+			if test.Failed() {
+				t.Error = test.Errors[0].Error()
+			}
+			res = append(res, t)
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+// printResults prints the test results to stdout in the format selected with outputFlag.
+func printResults(cCtx *cli.Context, results *TestResults) error {
+	if cCtx.String(outputFlag.Name) == "json" {
+		return results.WriteJSON(os.Stdout)
+	}
+	results.PrintSimpleResults()
+	return nil
+}
+
 // DisplayErrrors, prints errors for all tests that errored.
 func (r *TestResults) DisplayErrors() {
 	r.Mutex.Lock()
 	defer r.Mutex.Unlock()
 	for _, test := range r.StandardUpgradeTests {
 		if test.Failed() {
-			fmt.Printf("--- 🚨 Standard Upgrade Test %s Failed:\n", test.Version.String())
+			fmt.Fprintf(progressOut, "--- 🚨 Standard Upgrade Test %s Failed:\n", test.Version.String())
 			test.DisplayLog()
 		}
 	}
 	for _, test := range r.MVUUpgradeTests {
 		if test.Failed() {
-			fmt.Printf("--- 🚨 Multiversion Upgrade Test %s Failed:\n", test.Version.String())
+			fmt.Fprintf(progressOut, "--- 🚨 Multiversion Upgrade Test %s Failed:\n", test.Version.String())
 			test.DisplayLog()
 		}
 	}
 	for _, test := range r.AutoupgradeTests {
 		if test.Failed() {
-			fmt.Printf("--- 🚨 Auto Upgrade Test %s Failed:\n", test.Version.String())
+			fmt.Fprintf(progressOut, "--- 🚨 Auto Upgrade Test %s Failed:\n", test.Version.String())
 			test.DisplayLog()
 		}
 	}
@@ -227,11 +369,234 @@ type testDB struct {
 	ContainerName     string
 	Image             string
 	ContainerHostPort string
+	// DSN is only set for external databases, see externalDBFlags.
+	DSN string
+}
+
+// dataSource returns the connection string used to connect to the database from the test runner.
+func (db *testDB) dataSource() string {
+	if db.DSN != "" {
+		return db.DSN
+	}
+	return fmt.Sprintf("postgres://sg@%s/sg?sslmode=disable", db.ContainerHostPort)
+}
+
+// externalDBs holds the connection strings of already running databases, see externalDBFlags.
+type externalDBs struct {
+	PGSQL        string
+	CodeIntel    string
+	CodeInsights string
+}
+
+const (
+	defaultDBPingTimeout         = 220 * time.Second
+	defaultFrontendInitTimeout   = 60 * time.Second
+	defaultFrontendHealthTimeout = 60 * time.Second
+)
+
+// withStartupTimeouts registers the timeouts for databases and the frontend to come up, set via flags, on the context.
+func withStartupTimeouts(ctx context.Context, cCtx *cli.Context) context.Context {
+	ctx = context.WithValue(ctx, dbPingTimeoutKey{}, cCtx.Duration(dbPingTimeoutFlag.Name))
+	ctx = context.WithValue(ctx, frontendHealthTimeoutKey{}, cCtx.Duration(frontendHealthTimeoutFlag.Name))
+	return context.WithValue(ctx, frontendInitTimeoutKey{}, cCtx.Duration(frontendInitTimeoutFlag.Name))
+}
+
+// getDBPingTimeout returns how long to wait for the databases of a test to accept connections.
+func getDBPingTimeout(ctx context.Context) time.Duration {
+	if timeout, ok := ctx.Value(dbPingTimeoutKey{}).(time.Duration); ok && timeout > 0 {
+		return timeout
+	}
+	return defaultDBPingTimeout
+}
+
+// getFrontendInitTimeout returns how long to wait for the frontend to initialize the databases of a test.
+func getFrontendInitTimeout(ctx context.Context) time.Duration {
+	if timeout, ok := ctx.Value(frontendInitTimeoutKey{}).(time.Duration); ok && timeout > 0 {
+		return timeout
+	}
+	return defaultFrontendInitTimeout
+}
+
+// getFrontendHealthTimeout returns how long to wait for the upgraded frontend of a test to report healthy.
+func getFrontendHealthTimeout(ctx context.Context) time.Duration {
+	if timeout, ok := ctx.Value(frontendHealthTimeoutKey{}).(time.Duration); ok && timeout > 0 {
+		return timeout
+	}
+	return defaultFrontendHealthTimeout
+}
+
+// withExternalDBs registers the external databases set via flags on the context. If no external databases are set the context is returned unchanged.
+func withExternalDBs(ctx context.Context, cCtx *cli.Context) (context.Context, error) {
+	external := externalDBs{
+		PGSQL:        cCtx.String("pgsql-dsn"),
+		CodeIntel:    cCtx.String("codeintel-dsn"),
+		CodeInsights: cCtx.String("codeinsights-dsn"),
+	}
+	if external == (externalDBs{}) {
+		return ctx, nil
+	}
+	if external.PGSQL == "" || external.CodeIntel == "" || external.CodeInsights == "" {
+		return ctx, errors.New("pgsql-dsn, codeintel-dsn and codeinsights-dsn must be set together")
+	}
+	return context.WithValue(ctx, externalDBsKey{}, external), nil
+}
+
+// getExternalDBs returns the external databases registered on the context, if any.
+func getExternalDBs(ctx context.Context) (externalDBs, bool) {
+	external, ok := ctx.Value(externalDBsKey{}).(externalDBs)
+	return external, ok
+}
+
+// maxRoutines returns the goroutine pool limit for running tests. Tests against external databases share the same databases, so they are run one at a time.
+func maxRoutines(ctx context.Context, cCtx *cli.Context) int {
+	if _, ok := getExternalDBs(ctx); ok {
+		return 1
+	}
+	return cCtx.Int(concurrencyFlag.Name)
+}
+
+// checkDockerDiskSpace returns an error if the filesystem docker stores its data on has less than minGB gigabytes free, so that a run fails once
+// upfront instead of every test failing to create its containers. The error reports how much space `docker system df` considers reclaimable.
+// A non-positive minGB disables the check.
+func checkDockerDiskSpace(ctx context.Context, minGB int) error {
+	if minGB <= 0 {
+		return nil
+	}
+
+	rootDir, err := run.Cmd(ctx, "docker", "info", "--format", "{{.DockerRootDir}}").Run().String()
+	if err != nil {
+		return errors.Wrap(err, "failed to get docker root dir")
+	}
+	rootDir = strings.TrimSpace(rootDir)
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(rootDir, &stat); err != nil {
+		return errors.Wrapf(err, "failed to get free disk space of docker root dir %s, docker must run on this machine to use --%s", rootDir, minDiskGBFlag.Name)
+	}
+	free := stat.Bavail * uint64(stat.Bsize)
+	if free >= uint64(minGB)*humanize.GByte {
+		return nil
+	}
+
+	out, err := run.Cmd(ctx, "docker", "system", "df", "--format", "{{.Type}}\t{{.Size}}\t{{.Reclaimable}}").Run().String()
+	if err != nil {
+		return errors.Wrap(err, "failed to get docker disk usage")
+	}
+	used, reclaimable, err := parseDockerSystemDF(out)
+	if err != nil {
+		return err
+	}
+	return errors.Newf("only %s of disk space is free for docker in %s, at least %d GB are required (--%s). Docker uses %s, of which %s are reclaimable, e.g. with `docker system prune`",
+		humanize.Bytes(free), rootDir, minGB, minDiskGBFlag.Name, humanize.Bytes(used), humanize.Bytes(reclaimable))
+}
+
+// parseDockerSystemDF sums up the used and reclaimable space in the output of `docker system df --format "{{.Type}}\t{{.Size}}\t{{.Reclaimable}}"`.
+// Reclaimable space is printed with a percentage, e.g. "1.2GB (50%)", which is ignored.
+func parseDockerSystemDF(out string) (used, reclaimable uint64, err error) {
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			return 0, 0, errors.Newf("unexpected docker system df output: %q", line)
+		}
+		size, err := humanize.ParseBytes(fields[1])
+		if err != nil {
+			return 0, 0, errors.Wrapf(err, "failed to parse size of %s", fields[0])
+		}
+		reclaimableSize, _, _ := strings.Cut(fields[2], " ")
+		r, err := humanize.ParseBytes(reclaimableSize)
+		if err != nil {
+			return 0, 0, errors.Wrapf(err, "failed to parse reclaimable size of %s", fields[0])
+		}
+		used += size
+		reclaimable += r
+	}
+	return used, reclaimable, nil
+}
+
+// upgradeTestFunc is the signature shared by all upgrade test types.
+type upgradeTestFunc func(ctx context.Context, initVersion, targetVersion, latestStableVersion *semver.Version) Test
+
+// withTestTimeout wraps an upgrade test so that its context is canceled after timeout. Commands run by the test are killed once the deadline is hit,
+// so a hung command, e.g. a stuck migrator upgrade, fails the test instead of hanging the whole run. A non-positive timeout disables the deadline.
+func withTestTimeout(timeout time.Duration, test upgradeTestFunc) upgradeTestFunc {
+	if timeout <= 0 {
+		return test
+	}
+	return func(ctx context.Context, initVersion, targetVersion, latestStableVersion *semver.Version) Test {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		result := test(ctx, initVersion, targetVersion, latestStableVersion)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			result.AddError(errors.Newf("🚨 test timed out after %s", timeout))
+		}
+		return result
+	}
+}
+
+// resetSchemas drops and recreates the public schema of each database, so that an external database can be reused between tests.
+func resetSchemas(ctx context.Context, test *Test, dbs []*testDB) error {
+	for _, db := range dbs {
+		test.AddLog(fmt.Sprintf("🧹 resetting schema of %s", db.DbName))
+		dbClient, err := sql.Open("postgres", db.dataSource())
+		if err != nil {
+			return errors.Newf("failed to connect to %s: %w", db.DbName, err)
+		}
+		_, err = dbClient.ExecContext(ctx, resetSchemaQuery)
+		dbClient.Close()
+		if err != nil {
+			return errors.Newf("failed to reset schema of %s: %w", db.DbName, err)
+		}
+	}
+	return nil
+}
+
+const resetSchemaQuery = `
+DROP SCHEMA IF EXISTS public CASCADE;
+CREATE SCHEMA public;
+`
+
+const (
+	// networkCreateAttempts is how often creating a test network is attempted when the docker daemon ran out of address pools.
+	networkCreateAttempts = 5
+	// networkCreateBackoff is the delay before the first retry of creating a test network. It doubles with every further retry.
+	networkCreateBackoff = 5 * time.Second
+)
+
+// createNetwork creates the docker network of a test. Bridge networks take up the docker daemon's address pools, so when running many tests in parallel
+// creating a network can fail until other tests tear down theirs. These failures are retried with exponential backoff, other failures are returned immediately.
+func createNetwork(ctx context.Context, test *Test, networkName string) error {
+	backoff := networkCreateBackoff
+	for attempt := 1; ; attempt++ {
+		out, err := run.Cmd(ctx, "docker", "network", "create", networkName).Run().String()
+		test.AddLog(out)
+		if err == nil {
+			return nil
+		}
+		if attempt >= networkCreateAttempts || !isAddressPoolExhausted(out, err) {
+			return err
+		}
+
+		test.AddLog(fmt.Sprintf("⏳ docker address pools exhausted, retrying to create network %s in %s (attempt %d/%d)", networkName, backoff, attempt+1, networkCreateAttempts))
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// isAddressPoolExhausted returns true if the output or error of a failed `docker network create` report that the daemon ran out of address pools.
+func isAddressPoolExhausted(out string, err error) bool {
+	const msg = "could not find an available, non-overlapping IPv4 address pool"
+	return strings.Contains(out, msg) || strings.Contains(err.Error(), msg)
 }
 
 // setupTestEnv initializeses a test environment and object. Creates a docker network for testing as well as instances of our three databases. Returning a cleanup function.
 // An instance of Sourcegraph-Frontend is also started to initialize the versions table of the database.
-// TODO: setupTestEnv should seed some initial data at the target initVersion. This will be usefull for testing OOB migrations
 func setupTestEnv(ctx context.Context, testType string, initVersion *semver.Version) (test Test, networkName string, dbs []*testDB, cleanup func(), err error) {
 	test = Test{
 		Version:  *initVersion,
@@ -260,13 +625,13 @@ func setupTestEnv(ctx context.Context, testType string, initVersion *semver.Vers
 		if err != nil {
 			test.AddError(errors.Newf("🚨 failed to pull images from -target-registry: %s", err))
 		}
-		fmt.Println(out)
+		fmt.Fprintln(progressOut, out)
 		out, err = run.Cmd(ctx, "docker", "image", "pull", fmt.Sprintf("%smigrator:%s", ctx.Value(fromRegistryKey{}).(string), initVersion.String())).Run().String()
 		test.AddLog(out)
 		if err != nil {
 			test.AddError(errors.Newf("🚨 failed to pull images from -target-registry: %s", err))
 		}
-		fmt.Println(out)
+		fmt.Fprintln(progressOut, out)
 	}
 
 	// Create a docker network for testing
@@ -276,12 +641,106 @@ func setupTestEnv(ctx context.Context, testType string, initVersion *semver.Vers
 	networkName = fmt.Sprintf("%s_test_%s", testType, initVersion)
 	test.AddLog(fmt.Sprintf("🐋 creating network %s", networkName))
 
-	out, err := run.Cmd(ctx, "docker", "network", "create", networkName).Run().String()
-	if err != nil {
+	if err := createNetwork(ctx, &test, networkName); err != nil {
 		test.AddError(errors.Newf("🚨 failed to create test network: %s", err))
 	}
+
+	if external, ok := getExternalDBs(ctx); ok {
+		// Reuse the already running databases, starting from an empty schema.
+		dbs = []*testDB{
+			{DbName: "pgsql", DSN: external.PGSQL},
+			{DbName: "codeintel-db", DSN: external.CodeIntel},
+			{DbName: "codeinsights-db", DSN: external.CodeInsights},
+		}
+		if err := resetSchemas(ctx, &test, dbs); err != nil {
+			test.AddError(errors.Newf("🚨 failed to reset external databases: %w", err))
+		}
+	} else {
+		dbs = createDBContainers(ctx, &test, testType, initVersion, networkName)
+	}
+
+	// Initialize the databases by running migrator with the `up` command.
+	test.LogLines = append(test.LogLines, "-- 🏗️  initializing database schemas with migrator")
+	out, err := run.Cmd(ctx, dockerMigratorBaseString(test, "up", fmt.Sprintf("%smigrator:%s", ctx.Value(fromRegistryKey{}), initVersion), networkName, dbs)...).Run().String()
+	if err != nil {
+		test.AddError(errors.Newf("🚨 failed to initialize database: %w", err))
+	}
 	test.AddLog(out)
 
+	// Verify that the databases are initialized.
+	test.AddLog("🔎 checking db schemas initialized")
+	for _, db := range dbs {
+		dbClient, err := sql.Open("postgres", db.dataSource())
+		if err != nil {
+			test.AddError(errors.Newf("🚨 failed to connect to %s: %s", db.DbName, err))
+			continue
+		}
+		defer dbClient.Close()
+
+		// check if tables have been created
+		rows, err := dbClient.Query(`SELECT tablename FROM pg_catalog.pg_tables WHERE schemaname='public';`)
+		if err != nil {
+			test.AddError(errors.Newf("🚨 failed to check %s for init: %s", db.DbName, err))
+			continue
+		}
+		defer rows.Close()
+		if err := rows.Err(); err != nil {
+			test.AddError(errors.Newf("🚨 failed to check %s for init: %s", db.DbName, err))
+			continue
+		} else {
+			test.AddLog(fmt.Sprintf("✅ %s initialized", db.DbName))
+		}
+	}
+
+	//start frontend and poll db until initial version is set by frontend
+	var cleanFrontend func()
+	cleanFrontend, _, err = startFrontend(ctx, test, fmt.Sprintf("%sfrontend", ctx.Value(fromRegistryKey{})), initVersion.String(), networkName, false, dbs)
+	if err != nil {
+		test.AddError(errors.Newf("🚨 failed to start frontend: %w", err))
+	}
+	defer cleanFrontend()
+
+	// Return a cleanup function that will remove the containers and network.
+	cleanup = func() {
+		// The cleanup must still run if the test timed out.
+		ctx := context.WithoutCancel(ctx)
+		// External databases are not owned by the test, their schemas are reset by the next test instead.
+		if _, ok := getExternalDBs(ctx); !ok {
+			test.LogLines = append(test.LogLines, "🧹 removing database containers")
+			out, err := run.Cmd(ctx, "docker", "container", "stop",
+				dbs[0].ContainerName,
+				dbs[1].ContainerName,
+				dbs[2].ContainerName).
+				Run().String()
+			if err != nil {
+				test.AddError(errors.Newf("🚨 failed to stop database containers after testing: %w", err))
+			}
+			test.AddLog(out)
+			out, err = run.Cmd(ctx, "docker", "container", "rm",
+				dbs[0].ContainerName,
+				dbs[1].ContainerName,
+				dbs[2].ContainerName).
+				Run().String()
+			if err != nil {
+				test.AddError(errors.Newf("🚨 failed to remove database containers after testing: %w", err))
+			}
+			test.AddLog(out)
+		}
+		test.AddLog("🧹 removing testing network")
+		out, err := run.Cmd(ctx, "docker", "network", "rm", networkName).Run().String()
+		if err != nil {
+			test.AddError(errors.Newf("🚨 failed to remove test network after testing: %w", err))
+		}
+		test.AddLog(out)
+	}
+
+	test.AddLog("-- 🏗️  setup complete")
+
+	return test, networkName, dbs, cleanup, err
+}
+
+// createDBContainers creates instances of our three databases at the given version on the test network, and waits for them to accept connections.
+func createDBContainers(ctx context.Context, test *Test, testType string, initVersion *semver.Version, networkName string) []*testDB {
 	// Note that we changed postgres versions in very early versions of Sourcegraph,
 	// In v3.38+ we use image postgres-12-alpine,
 	// in v3.37-v3.30 we use postgres-12.6-alpine,
@@ -290,10 +749,10 @@ func setupTestEnv(ctx context.Context, testType string, initVersion *semver.Vers
 	//
 	// This isn't relevant since this test will only ever initialize instances v3.38+
 	// worth noting in case this changes in the future.
-	dbs = []*testDB{
-		{"pgsql", fmt.Sprintf("%s_pgsql_%s", testType, initVersion), "postgres-12-alpine", ""},
-		{"codeintel-db", fmt.Sprintf("%s_codeintel-db_%s", testType, initVersion), "codeintel-db", ""},
-		{"codeinsights-db", fmt.Sprintf("%s_codeinsights-db_%s", testType, initVersion), "codeinsights-db", ""},
+	dbs := []*testDB{
+		{"pgsql", fmt.Sprintf("%s_pgsql_%s", testType, initVersion), "postgres-12-alpine", "", ""},
+		{"codeintel-db", fmt.Sprintf("%s_codeintel-db_%s", testType, initVersion), "codeintel-db", "", ""},
+		{"codeinsights-db", fmt.Sprintf("%s_codeinsights-db_%s", testType, initVersion), "codeinsights-db", "", ""},
 	}
 
 	// Here we create the three databases using docker run.
@@ -331,7 +790,7 @@ func setupTestEnv(ctx context.Context, testType string, initVersion *semver.Vers
 
 	// Create a timeout to validate the databases have initialized, this is to prevent a hung test
 	// When many goroutines are running this test this is a point of failure.
-	dbPingTimeout, cancel := context.WithTimeout(ctx, time.Second*220)
+	dbPingTimeout, cancel := context.WithTimeout(ctx, getDBPingTimeout(ctx))
 	wgDbPing := pool.New().WithErrors().WithContext(dbPingTimeout)
 	defer cancel()
 
@@ -339,7 +798,7 @@ func setupTestEnv(ctx context.Context, testType string, initVersion *semver.Vers
 	for _, db := range dbs {
 		db := db // this closure locks the index for the inner for loop
 		wgDbPing.Go(func(ctx context.Context) error {
-			dbClient, err := sql.Open("postgres", fmt.Sprintf("postgres://sg@%s/sg?sslmode=disable", db.ContainerHostPort))
+			dbClient, err := sql.Open("postgres", db.dataSource())
 			if err != nil {
 				test.AddError(errors.Newf("🚨 failed to connect to %s: %s", db.DbName, err))
 			}
@@ -370,79 +829,144 @@ func setupTestEnv(ctx context.Context, testType string, initVersion *semver.Vers
 		test.AddError(errors.Newf("🚨 containerized database startup error: %w", err))
 	}
 
-	// Initialize the databases by running migrator with the `up` command.
-	test.LogLines = append(test.LogLines, "-- 🏗️  initializing database schemas with migrator")
-	out, err = run.Cmd(ctx, dockerMigratorBaseString(test, "up", fmt.Sprintf("%smigrator:%s", ctx.Value(fromRegistryKey{}), initVersion), networkName, dbs)...).Run().String()
+	return dbs
+}
+
+// seedFrontendDB applies the statements of the --seed-file to the frontend database, so that out of band migrations have data to migrate during the upgrade.
+// It does nothing if no seed file is set.
+func seedFrontendDB(ctx context.Context, test *Test, dbs []*testDB) error {
+	seedFile, _ := ctx.Value(seedFileKey{}).(string)
+	if seedFile == "" {
+		return nil
+	}
+
+	test.AddLog(fmt.Sprintf("🌱 seeding pgsql with %s", seedFile))
+	seed, err := os.ReadFile(seedFile)
 	if err != nil {
-		test.AddError(errors.Newf("🚨 failed to initialize database: %w", err))
+		return errors.Wrap(err, "failed to read seed file")
 	}
-	test.AddLog(out)
 
-	// Verify that the databases are initialized.
-	test.AddLog("🔎 checking db schemas initialized")
+	var frontendDB *testDB
 	for _, db := range dbs {
-		dbClient, err := sql.Open("postgres", fmt.Sprintf("postgres://sg@%s/sg?sslmode=disable", db.ContainerHostPort))
-		if err != nil {
-			test.AddError(errors.Newf("🚨 failed to connect to %s: %s", db.DbName, err))
-			continue
-		}
-		defer dbClient.Close()
-
-		// check if tables have been created
-		rows, err := dbClient.Query(`SELECT tablename FROM pg_catalog.pg_tables WHERE schemaname='public';`)
-		if err != nil {
-			test.AddError(errors.Newf("🚨 failed to check %s for init: %s", db.DbName, err))
-			continue
-		}
-		defer rows.Close()
-		if err := rows.Err(); err != nil {
-			test.AddError(errors.Newf("🚨 failed to check %s for init: %s", db.DbName, err))
-			continue
-		} else {
-			test.AddLog(fmt.Sprintf("✅ %s initialized", db.DbName))
+		if db.DbName == "pgsql" {
+			frontendDB = db
 		}
 	}
+	if frontendDB == nil {
+		return errors.New("no pgsql db in test")
+	}
 
-	//start frontend and poll db until initial version is set by frontend
-	var cleanFrontend func()
-	cleanFrontend, err = startFrontend(ctx, test, fmt.Sprintf("%sfrontend", ctx.Value(fromRegistryKey{})), initVersion.String(), networkName, false, dbs)
+	client, err := sql.Open("postgres", frontendDB.dataSource())
 	if err != nil {
-		test.AddError(errors.Newf("🚨 failed to start frontend: %w", err))
+		return errors.Newf("failed to connect to %s: %w", frontendDB.DbName, err)
 	}
-	defer cleanFrontend()
+	defer client.Close()
 
-	// Return a cleanup function that will remove the containers and network.
-	cleanup = func() {
-		test.LogLines = append(test.LogLines, "🧹 removing database containers")
-		out, err := run.Cmd(ctx, "docker", "container", "stop",
-			dbs[0].ContainerName,
-			dbs[1].ContainerName,
-			dbs[2].ContainerName).
-			Run().String()
-		if err != nil {
-			test.AddError(errors.Newf("🚨 failed to stop database containers after testing: %w", err))
+	statements := splitSQLStatements(string(seed))
+	for i, statement := range statements {
+		if _, err := client.ExecContext(ctx, statement); err != nil {
+			return errors.Newf("statement %d of %s failed: %w", i+1, seedFile, err)
 		}
-		test.AddLog(out)
-		out, err = run.Cmd(ctx, "docker", "container", "rm",
-			dbs[0].ContainerName,
-			dbs[1].ContainerName,
-			dbs[2].ContainerName).
-			Run().String()
-		if err != nil {
-			test.AddError(errors.Newf("🚨 failed to remove database containers after testing: %w", err))
+	}
+
+	test.AddLog(fmt.Sprintf("✅ executed %d seed statements", len(statements)))
+	return nil
+}
+
+// splitSQLStatements splits a SQL script into its statements on semicolons. Semicolons in quoted strings, escape strings, quoted identifiers, dollar quoted
+// strings and comments don't end a statement. Comments and empty statements are dropped.
+func splitSQLStatements(script string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+	)
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" {
+			statements = append(statements, statement)
 		}
-		test.AddLog(out)
-		test.AddLog("🧹 removing testing network")
-		out, err = run.Cmd(ctx, "docker", "network", "rm", networkName).Run().String()
-		if err != nil {
-			test.AddError(errors.Newf("🚨 failed to remove test network after testing: %w", err))
+		current.Reset()
+	}
+
+	// prev is the last byte of the previous token.
+	var prev byte
+	for len(script) > 0 {
+		// n is the length of the token at the start of script.
+		n := 1
+		switch {
+		case strings.HasPrefix(script, "--"):
+			n = tokenEnd(script, 2, "\n")
+			current.WriteByte('\n')
+		case strings.HasPrefix(script, "/*"):
+			n = tokenEnd(script, 2, "*/")
+			current.WriteByte(' ')
+		case (script[0] == 'E' || script[0] == 'e') && strings.HasPrefix(script[1:], "'") && !isIdentifierByte(prev):
+			n = escapeStringEnd(script)
+			current.WriteString(script[:n])
+		case script[0] == '\'' || script[0] == '"':
+			// A doubled quote escapes a quote, which works out the same as two adjacent quoted strings.
+			n = tokenEnd(script, 1, script[:1])
+			current.WriteString(script[:n])
+		case script[0] == '$' && dollarQuoteTag(script) != "":
+			tag := dollarQuoteTag(script)
+			n = tokenEnd(script, len(tag), tag)
+			current.WriteString(script[:n])
+		case script[0] == ';':
+			flush()
+		default:
+			current.WriteByte(script[0])
 		}
-		test.AddLog(out)
+		prev = script[n-1]
+		script = script[n:]
 	}
+	flush()
 
-	test.AddLog("-- 🏗️  setup complete")
+	return statements
+}
 
-	return test, networkName, dbs, cleanup, err
+// tokenEnd returns the length of the token at the start of s that ends with terminator, searching from offset. An unterminated token runs until the end of s.
+func tokenEnd(s string, offset int, terminator string) int {
+	if end := strings.Index(s[offset:], terminator); end >= 0 {
+		return offset + end + len(terminator)
+	}
+	return len(s)
+}
+
+// escapeStringEnd returns the length of the escape string constant, e.g. E'it\'s', at the start of s. In escape strings a backslash escapes the next
+// character, including a quote. An unterminated string runs until the end of s.
+func escapeStringEnd(s string) int {
+	for i := 2; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '\'':
+			// A doubled quote escapes a quote as well.
+			if i+1 < len(s) && s[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// isIdentifierByte reports whether c can be part of an unquoted identifier or keyword.
+func isIdentifierByte(c byte) bool {
+	return c == '_' || c == '$' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+// dollarQuoteTag returns the dollar quote tag, e.g. $$ or $body$, that s starts with, if any.
+func dollarQuoteTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if c == '$' {
+			return s[:i+1]
+		}
+		if !(c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > 1 && '0' <= c && c <= '9') {
+			return ""
+		}
+	}
+	return ""
 }
 
 // validateDBs runs a few tests to assess the readiness of the database and whether or not drift exists on the schema.
@@ -455,7 +979,7 @@ func validateDBs(ctx context.Context, test *Test, version, migratorImage, networ
 	// Get DB clients
 	clients := make(map[string]*sql.DB)
 	for _, db := range dbs {
-		client, err := sql.Open("postgres", fmt.Sprintf("postgres://sg@%s/sg?sslmode=disable", db.ContainerHostPort))
+		client, err := sql.Open("postgres", db.dataSource())
 		if err != nil {
 			test.AddError(errors.Newf("🚨 failed to connect to %s: %w", db.DbName, err))
 			return err
@@ -496,6 +1020,7 @@ func validateDBs(ctx context.Context, test *Test, version, migratorImage, networ
 
 	// Check DBs for drift
 	test.AddLog("🔎 Checking DBs for drift")
+	var driftTarget string
 	if postUpgrade && ctx.Value(postReleaseKey{}) == "" {
 		// Get the last commit in the release branch, if validating an upgrade the upgrade boolean is true,
 		// in this case the drift target is the latest commit on the release candidate branch.
@@ -506,56 +1031,71 @@ func validateDBs(ctx context.Context, test *Test, version, migratorImage, networ
 			test.AddError(errors.Newf("🚨 failed to get latest commit on candidate branch: %w", err))
 		}
 		test.AddLog(fmt.Sprintf("Latest commit on candidate branch: %s", candidateGitHead.String()))
-		for _, db := range dbs {
-			out, err := run.Cmd(ctx, dockerMigratorBaseString(*test, fmt.Sprintf("drift --db %s --version %s --ignore-migrator-update --skip-version-check", db.DbName, candidateGitHead.String()),
-				migratorImage, networkName, dbs)...).Run().String()
-			if err != nil {
-				test.AddError(errors.Newf("🚨 failed to check drift on %s: %s", db.DbName, err))
-			}
-			test.AddLog(out)
-		}
+		driftTarget = fmt.Sprintf("--version %s --ignore-migrator-update --skip-version-check", candidateGitHead.String())
 	} else {
-		for _, db := range dbs {
-			out, err := run.Cmd(ctx, dockerMigratorBaseString(*test, fmt.Sprintf("drift --db %s --version v%s --ignore-migrator-update", db.DbName, version),
+		driftTarget = fmt.Sprintf("--version v%s --ignore-migrator-update", version)
+	}
+
+	// Each drift check spins up its own migrator container, so we run them
+	// concurrently. Test isn't safe for concurrent use, so every check records its
+	// output in its own slot, which are added to the test in order once all checks
+	// completed.
+	type driftResult struct {
+		out string
+		err error
+	}
+	driftResults := make([]driftResult, len(dbs))
+	driftPool := pool.New().WithContext(ctx)
+	for i, db := range dbs {
+		driftPool.Go(func(ctx context.Context) error {
+			out, err := run.Cmd(ctx, dockerMigratorBaseString(*test, fmt.Sprintf("drift --db %s %s", db.DbName, driftTarget),
 				migratorImage, networkName, dbs)...).Run().String()
-			if err != nil {
-				test.AddError(errors.Newf("🚨 failed to check drift on %s: %w", db.DbName, err))
-			}
-			test.AddLog(out)
+			driftResults[i] = driftResult{out: out, err: err}
+			return nil
+		})
+	}
+	_ = driftPool.Wait()
+	for i, db := range dbs {
+		if err := driftResults[i].err; err != nil {
+			test.AddError(errors.Newf("🚨 failed to check drift on %s: %w", db.DbName, err))
 		}
+		test.AddLog(driftResults[i].out)
 	}
 
 	return nil
 }
 
-// startFrontend starts a frontend container and polls the pgsql database for certain states. When the state conditions are startFrontend returns a cleanup function that will stop and remove the frontend container.
+// startFrontend starts a frontend container and polls the pgsql database for certain states. When the state conditions are startFrontend returns a cleanup function that will stop and remove the frontend container,
+// and the host address its HTTP port is published on, see checkFrontendHealth.
 // - checks that the version is set in pgsql
 // - checks for existence of site-config
 // - Optionally sets the auto upgrade env var to true or false.
-func startFrontend(ctx context.Context, test Test, image, version, networkName string, auto bool, dbs []*testDB) (cleanup func(), err error) {
+func startFrontend(ctx context.Context, test Test, image, version, networkName string, auto bool, dbs []*testDB) (cleanup func(), addr string, err error) {
 	stamp := ctx.Value(stampVersionKey{})
 
 	hash, err := newContainerHash()
 	if err != nil {
 		test.AddError(errors.Newf("🚨 failed to get container hash: %w", err))
-		return nil, err
+		return nil, "", err
 	}
 	test.AddLog(fmt.Sprintf("🐋 creating %s_frontend_%x", test.Type, hash))
 	// define cleanup function to stop and remove the container
 	cleanup = func() {
+		// The cleanup must still run if the test timed out.
+		ctx := context.WithoutCancel(ctx)
 		test.AddLog("🧹 removing frontend container")
 		out, err := run.Cmd(ctx, "docker", "container", "stop",
 			fmt.Sprintf("%s_frontend_%x", test.Type, hash),
 		).Run().String()
 		if err != nil {
-			fmt.Println("🚨 failed to stop frontend after testing: ", err)
+			fmt.Fprintln(progressOut, "🚨 failed to stop frontend after testing: ", err)
 		}
 		test.AddLog(out)
 		out, err = run.Cmd(ctx, "docker", "container", "rm",
 			fmt.Sprintf("%s_frontend_%x", test.Type, hash),
 		).Run().String()
 		if err != nil {
-			fmt.Println("🚨 failed to remove frontend after testing: ", err)
+			fmt.Fprintln(progressOut, "🚨 failed to remove frontend after testing: ", err)
 		}
 		test.AddLog(out)
 	}
@@ -566,6 +1106,7 @@ func startFrontend(ctx context.Context, test Test, image, version, networkName s
 		// "--detach",
 		"--platform", "linux/amd64",
 		"--name", fmt.Sprintf("%s_frontend_%x", test.Type, hash),
+		"-p", frontendPort,
 	}
 	envString := []string{
 		"-e", "DEPLOY_TYPE=docker-container",
@@ -573,6 +1114,9 @@ func startFrontend(ctx context.Context, test Test, image, version, networkName s
 		"-e", fmt.Sprintf("CODEINTEL_PGHOST=%s", dbs[1].ContainerName),
 		"-e", fmt.Sprintf("CODEINSIGHTS_PGDATASOURCE=postgres://sg@%s:5432/sg?sslmode=disable", dbs[2].ContainerName),
 	}
+	if dbs[0].DSN != "" {
+		envString = append(envString, externalDBsEnv(dbs)...)
+	}
 	if auto {
 		envString = append(envString, "-e", "SRC_AUTOUPGRADE=true")
 	}
@@ -594,11 +1138,11 @@ func startFrontend(ctx context.Context, test Test, image, version, networkName s
 	}()
 
 	// poll db until initial versions.version is set
-	setInitTimeout, cancel := context.WithTimeout(ctx, time.Second*60)
+	setInitTimeout, cancel := context.WithTimeout(ctx, getFrontendInitTimeout(ctx))
 	defer cancel()
 	test.AddLog("🔎 checking db initialization complete")
 
-	dbClient, err := sql.Open("postgres", fmt.Sprintf("postgres://sg@%s/sg?sslmode=disable", dbs[0].ContainerHostPort))
+	dbClient, err := sql.Open("postgres", dbs[0].dataSource())
 	if err != nil {
 		test.AddError(errors.Newf("🚨 failed to connect to %s: %w", dbs[0].DbName, err))
 	}
@@ -613,7 +1157,7 @@ func startFrontend(ctx context.Context, test Test, image, version, networkName s
 				test.AddError(errors.Newf("🚨 failed to get frontend logs on ctx timeout: %w", err))
 			}
 			err = errors.Newf("frontend container timed out during polling version update: \n%s", out)
-			return cleanup, err
+			return cleanup, "", err
 		default:
 		}
 		// check version string set
@@ -648,7 +1192,7 @@ func startFrontend(ctx context.Context, test Test, image, version, networkName s
 				test.AddError(errors.Newf("🚨 failed to get frontend logs on ctx timeout: %w", err))
 			}
 			err = errors.Newf("frontend container timed out during polling site-config initialization: \n%s", out)
-			return cleanup, err
+			return cleanup, "", err
 		default:
 		}
 		// check version string set
@@ -671,7 +1215,65 @@ func startFrontend(ctx context.Context, test Test, image, version, networkName s
 		}
 	}
 
-	return cleanup, nil
+	// get the dynamically allocated port of the frontend, like we do for the dbs
+	out, err := run.Cmd(ctx, "docker", "port", fmt.Sprintf("%s_frontend_%x", test.Type, hash), frontendPort).Run().String()
+	if err != nil {
+		return cleanup, "", errors.Newf("failed to get frontend port: %w", err)
+	}
+	// docker port can return multiple ports, ipv4 and ipv6, so we need to keep the former only.
+	addr, _, _ = strings.Cut(out, "\n")
+
+	return cleanup, addr, nil
+}
+
+// frontendPort is the port the frontend serves HTTP on within its container.
+const frontendPort = "3080"
+
+// frontendHealthPollInterval is how long checkFrontendHealth waits between requests.
+var frontendHealthPollInterval = 1 * time.Second
+
+// checkFrontendHealth polls the /healthz endpoint of the frontend published on addr until it responds with 200 OK.
+// This catches frontends that came up far enough to initialize the databases, but never serve requests. It gives up
+// after the frontend health timeout.
+func checkFrontendHealth(ctx context.Context, test *Test, addr string) error {
+	test.AddLog("🔎 checking frontend health")
+	timeout := getFrontendHealthTimeout(ctx)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	url := fmt.Sprintf("http://%s/healthz", addr)
+	for {
+		err := getHealthz(ctx, url)
+		if err == nil {
+			test.AddLog("✅ frontend is healthy")
+			return nil
+		}
+		test.AddLog(fmt.Sprintf(" ... waiting for frontend to become healthy: %s", err))
+
+		select {
+		case <-ctx.Done():
+			return errors.Newf("frontend not healthy after %s: %w", timeout, err)
+		case <-time.After(frontendHealthPollInterval):
+		}
+	}
+}
+
+// getHealthz requests the healthz endpoint at url, and returns an error unless it responded with 200 OK.
+func getHealthz(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Newf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+	return nil
 }
 
 const versionQuery = `SELECT version FROM versions;`
@@ -719,6 +1321,10 @@ func dockerMigratorBaseString(test Test, cmd, migratorImage, networkName string,
 		"-e", "CODEINSIGHTS_PGSSLMODE=disable",
 		"-e", "SRC_LOG_LEVEL=debug",
 	}
+	if dbs[0].DSN != "" {
+		// PG*DATASOURCE takes precedence over the individual connection variables above.
+		envString = append(envString, externalDBsEnv(dbs)...)
+	}
 
 	cmdString := []string{
 		"--network", networkName,
@@ -730,6 +1336,15 @@ func dockerMigratorBaseString(test Test, cmd, migratorImage, networkName string,
 	return append(baseString, cmdString...)
 }
 
+// externalDBsEnv returns the docker env arguments pointing a container at external databases.
+func externalDBsEnv(dbs []*testDB) []string {
+	return []string{
+		"-e", fmt.Sprintf("PGDATASOURCE=%s", dbs[0].DSN),
+		"-e", fmt.Sprintf("CODEINTEL_PGDATASOURCE=%s", dbs[1].DSN),
+		"-e", fmt.Sprintf("CODEINSIGHTS_PGDATASOURCE=%s", dbs[2].DSN),
+	}
+}
+
 // newContainerHash generates a random hash for naming containers in test, used for frontend and migrator
 func newContainerHash() ([]byte, error) {
 	hash := make([]byte, 4)
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/run"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+func TestTestResultsHasFailures(t *testing.T) {
+	passed := Test{Version: *semver.MustParse("5.0.0")}
+	failed := Test{Version: *semver.MustParse("5.1.0")}
+	failed.AddError(errors.New("drift detected"))
+
+	tests := []struct {
+		name    string
+		results func() *TestResults
+		want    bool
+	}{
+		{
+			name:    "no tests",
+			results: func() *TestResults { return &TestResults{} },
+			want:    false,
+		},
+		{
+			name: "all tests passed",
+			results: func() *TestResults {
+				r := &TestResults{}
+				r.AddStdTest(passed)
+				r.AddMVUTest(passed)
+				r.AddAutoTest(passed)
+				return r
+			},
+			want: false,
+		},
+		{
+			name: "failed standard test",
+			results: func() *TestResults {
+				r := &TestResults{}
+				r.AddStdTest(failed)
+				r.AddMVUTest(passed)
+				return r
+			},
+			want: true,
+		},
+		{
+			name: "failed multiversion test",
+			results: func() *TestResults {
+				r := &TestResults{}
+				r.AddStdTest(passed)
+				r.AddMVUTest(failed)
+				return r
+			},
+			want: true,
+		},
+		{
+			name: "failed autoupgrade test",
+			results: func() *TestResults {
+				r := &TestResults{}
+				r.AddAutoTest(passed)
+				r.AddAutoTest(failed)
+				return r
+			},
+			want: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.results().HasFailures(); got != tc.want {
+				t.Errorf("HasFailures() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("concurrent use", func(t *testing.T) {
+		r := &TestResults{}
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				r.AddStdTest(passed)
+			}()
+			go func() {
+				defer wg.Done()
+				_ = r.HasFailures()
+			}()
+		}
+		wg.Wait()
+		if r.HasFailures() {
+			t.Error("HasFailures() = true, want false")
+		}
+
+		r.AddMVUTest(failed)
+		if !r.HasFailures() {
+			t.Error("HasFailures() = false, want true")
+		}
+	})
+}
+
+func TestWithTestTimeout(t *testing.T) {
+	version := semver.MustParse("5.0.0")
+
+	var cleanupErr error
+	cleanedUp := false
+	hung := func(ctx context.Context, initVersion, _, _ *semver.Version) Test {
+		test := Test{Version: *initVersion}
+		defer func() {
+			cleanedUp = true
+			cleanupErr = run.Cmd(context.WithoutCancel(ctx), "true").Run().Wait()
+		}()
+
+		// Simulate a stuck migrator.
+		if err := run.Cmd(ctx, "sleep", "60").Run().Wait(); err != nil {
+			test.AddError(err)
+		}
+		return test
+	}
+
+	start := time.Now()
+	result := withTestTimeout(100*time.Millisecond, hung)(context.Background(), version, version, version)
+	if elapsed := time.Since(start); elapsed > 30*time.Second {
+		t.Fatalf("test was not interrupted, took %s", elapsed)
+	}
+	if !result.Failed() {
+		t.Error("Failed() = false, want true")
+	}
+	if !cleanedUp {
+		t.Error("cleanup did not run")
+	}
+	if cleanupErr != nil {
+		t.Errorf("cleanup failed: %s", cleanupErr)
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		passing := func(ctx context.Context, initVersion, _, _ *semver.Version) Test {
+			if _, ok := ctx.Deadline(); ok {
+				t.Error("unexpected deadline")
+			}
+			return Test{Version: *initVersion}
+		}
+		if result := withTestTimeout(0, passing)(context.Background(), version, version, version); result.Failed() {
+			t.Error("Failed() = true, want false")
+		}
+	})
+}
+
+func TestTestResultsWriteJSON(t *testing.T) {
+	var r TestResults
+	r.AddStdTest(Test{Version: *semver.MustParse("5.0.0"), Type: "standard", Runtime: 90 * time.Second})
+	failed := Test{Version: *semver.MustParse("5.1.0"), Type: "multiversion", Runtime: 1500 * time.Millisecond}
+	failed.AddError(errors.New("drift detected"))
+	failed.AddError(errors.New("cleanup failed"))
+	r.AddMVUTest(failed)
+
+	var buf bytes.Buffer
+	if err := r.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]any{
+		{"version": "5.0.0", "type": "standard", "runtimeMs": float64(90000), "passed": true},
+		{"version": "5.1.0", "type": "multiversion", "runtimeMs": float64(1500), "passed": false, "error": "drift detected"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected JSON (-want +got):\n%s", diff)
+	}
+}
+
+func TestSplitSQLStatements(t *testing.T) {
+	script := `-- seed users; the OOB migration backfills their settings
+INSERT INTO users (username) VALUES ('a;b'), ('it''s');
+/* quoted identifiers; too */ UPDATE "weird;name" SET x = 1;
+
+CREATE FUNCTION f() RETURNS int AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql;
+SELECT $$a;b$$, $1
+;INSERT INTO t VALUES (E'it\'s;', e'a\\', 'b;', E'c'';')
+;;`
+
+	want := []string{
+		"INSERT INTO users (username) VALUES ('a;b'), ('it''s')",
+		`UPDATE "weird;name" SET x = 1`,
+		"CREATE FUNCTION f() RETURNS int AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql",
+		"SELECT $$a;b$$, $1",
+		`INSERT INTO t VALUES (E'it\'s;', e'a\\', 'b;', E'c'';')`,
+	}
+	if diff := cmp.Diff(want, splitSQLStatements(script)); diff != "" {
+		t.Errorf("unexpected statements (-want +got):\n%s", diff)
+	}
+}
+
+func TestIsAddressPoolExhausted(t *testing.T) {
+	const exhausted = "Error response from daemon: could not find an available, non-overlapping IPv4 address pool among the defaults to assign to the network"
+
+	tests := []struct {
+		name string
+		out  string
+		err  error
+		want bool
+	}{
+		{name: "reported in output", out: exhausted, err: errors.New("exit status 1"), want: true},
+		{name: "reported in error", err: errors.New(exhausted), want: true},
+		{name: "other failure", out: "Error response from daemon: network with name standard_test_5.0.0 already exists", err: errors.New("exit status 1"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAddressPoolExhausted(tt.out, tt.err); got != tt.want {
+				t.Errorf("isAddressPoolExhausted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkipVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "skip.yaml")
+	if err := os.WriteFile(path, []byte(`
+- version: 5.1.6
+  types: [mvu]
+  reason: migration backported incorrectly
+- version: v5.2.0
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	skips, err := loadSkipVersions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		testType   string
+		version    string
+		wantReason string
+		wantSkip   bool
+	}{
+		{testType: "std", version: "4.1.0", wantReason: mvuInitBug, wantSkip: true},
+		{testType: "mvu", version: "5.1.6", wantReason: "migration backported incorrectly", wantSkip: true},
+		{testType: "std", version: "5.1.6", wantSkip: false},
+		{testType: "auto", version: "5.2.0", wantReason: "no reason given", wantSkip: true},
+		{testType: "std", version: "5.3.0", wantSkip: false},
+	}
+	for _, tt := range tests {
+		reason, skip := skipReason(skips, tt.testType, semver.MustParse(tt.version))
+		if skip != tt.wantSkip || reason != tt.wantReason {
+			t.Errorf("skipReason(%s, %s) = (%q, %v), want (%q, %v)", tt.testType, tt.version, reason, skip, tt.wantReason, tt.wantSkip)
+		}
+	}
+
+	t.Run("invalid test type", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "skip.json")
+		if err := os.WriteFile(path, []byte(`[{"version": "5.1.6", "types": ["multiversion"]}]`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadSkipVersions(path); err == nil {
+			t.Fatal("expected an error for an invalid test type")
+		}
+	})
+}
+
+func TestParseDockerSystemDF(t *testing.T) {
+	out := "Images\t12.5GB\t8.5GB (68%)\nContainers\t1.024kB\t0B (0%)\nLocal Volumes\t512MB\t512MB (100%)\nBuild Cache\t0B\t0B\n"
+	used, reclaimable, err := parseDockerSystemDF(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint64(12_500_000_000 + 1_024 + 512_000_000); used != want {
+		t.Errorf("unexpected used space: want=%d got=%d", want, used)
+	}
+	if want := uint64(8_500_000_000 + 512_000_000); reclaimable != want {
+		t.Errorf("unexpected reclaimable space: want=%d got=%d", want, reclaimable)
+	}
+
+	if _, _, err := parseDockerSystemDF("Images\t12.5GB"); err == nil {
+		t.Error("expected error for malformed output")
+	}
+}
+
+func TestCheckFrontendHealth(t *testing.T) {
+	frontendHealthPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { frontendHealthPollInterval = 1 * time.Second })
+
+	t.Run("becomes healthy", func(t *testing.T) {
+		var requests atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/healthz" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			// Unhealthy while still starting up.
+			if requests.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer srv.Close()
+
+		var test Test
+		if err := checkFrontendHealth(context.Background(), &test, srv.Listener.Addr().String()); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got := requests.Load(); got != 3 {
+			t.Errorf("requests = %d, want 3", got)
+		}
+	})
+
+	t.Run("never healthy", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer srv.Close()
+
+		ctx := context.WithValue(context.Background(), frontendHealthTimeoutKey{}, 100*time.Millisecond)
+		var test Test
+		err := checkFrontendHealth(ctx, &test, srv.Listener.Addr().String())
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if !strings.Contains(err.Error(), "unexpected status 503") {
+			t.Errorf("unexpected error: %s", err)
+		}
+	})
+}
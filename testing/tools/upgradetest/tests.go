@@ -40,6 +40,12 @@ func standardUpgradeTest(ctx context.Context, initVersion, targetVersion, latest
 		return test
 	}
 
+	// seed data at the initial version so out of band migrations have rows to migrate
+	if err := seedFrontendDB(ctx, &test, dbs); err != nil {
+		test.AddError(errors.Newf("🚨 failed to seed frontend db: %w", err))
+		return test
+	}
+
 	test.AddLog("-- ⚙️  performing standard upgrade")
 
 	if postRelease != "" {
@@ -58,10 +64,11 @@ func standardUpgradeTest(ctx context.Context, initVersion, targetVersion, latest
 
 	// Start frontend with candidate
 	var cleanFrontend func()
+	var frontendAddr string
 	if postRelease != "" {
-		cleanFrontend, err = startFrontend(ctx, test, fmt.Sprintf("%sfrontend", ctx.Value(targetRegistryKey{})), postRelease, networkName, false, dbs)
+		cleanFrontend, frontendAddr, err = startFrontend(ctx, test, fmt.Sprintf("%sfrontend", ctx.Value(targetRegistryKey{})), postRelease, networkName, false, dbs)
 	} else {
-		cleanFrontend, err = startFrontend(ctx, test, "frontend", "candidate", networkName, false, dbs)
+		cleanFrontend, frontendAddr, err = startFrontend(ctx, test, "frontend", "candidate", networkName, false, dbs)
 	}
 	if err != nil {
 		test.AddError(errors.Newf("🚨 candidate frontend error: %w", err))
@@ -81,6 +88,10 @@ func standardUpgradeTest(ctx context.Context, initVersion, targetVersion, latest
 		test.AddError(errors.Newf("🚨 Upgrade failed: %w", err))
 		return test
 	}
+	if err := checkFrontendHealth(ctx, &test, frontendAddr); err != nil {
+		test.AddError(errors.Newf("🚨 candidate frontend unhealthy: %w", err))
+		return test
+	}
 
 	return test
 }
@@ -93,7 +104,7 @@ func multiversionUpgradeTest(ctx context.Context, initVersion, targetVersion, la
 	//start test env
 	test, networkName, dbs, cleanup, err := setupTestEnv(ctx, "multiversion", initVersion)
 	if err != nil {
-		fmt.Println("🚨 failed to setup env: ", err)
+		fmt.Fprintln(progressOut, "🚨 failed to setup env: ", err)
 		cleanup()
 		return test
 	}
@@ -113,6 +124,12 @@ func multiversionUpgradeTest(ctx context.Context, initVersion, targetVersion, la
 		return test
 	}
 
+	// seed data at the initial version so out of band migrations have rows to migrate
+	if err := seedFrontendDB(ctx, &test, dbs); err != nil {
+		test.AddError(errors.Newf("🚨 failed to seed frontend db: %w", err))
+		return test
+	}
+
 	// Run multiversion upgrade using candidate image unless a post release version is specified, in which case use that image
 	//
 	// If the build version in the test has been stamped this is the "to" input for the upgrade command. If the builds arent stamped we use the latest stable release verstion,
@@ -152,10 +169,11 @@ func multiversionUpgradeTest(ctx context.Context, initVersion, targetVersion, la
 
 	// Start frontend with candidate unless a post release version is specified
 	var cleanFrontend func()
+	var frontendAddr string
 	if postRelease != "" {
-		cleanFrontend, err = startFrontend(ctx, test, fmt.Sprintf("%sfrontend", ctx.Value(targetRegistryKey{})), postRelease, networkName, false, dbs)
+		cleanFrontend, frontendAddr, err = startFrontend(ctx, test, fmt.Sprintf("%sfrontend", ctx.Value(targetRegistryKey{})), postRelease, networkName, false, dbs)
 	} else {
-		cleanFrontend, err = startFrontend(ctx, test, "frontend", "candidate", networkName, false, dbs)
+		cleanFrontend, frontendAddr, err = startFrontend(ctx, test, "frontend", "candidate", networkName, false, dbs)
 	}
 	if err != nil {
 		test.AddError(errors.Newf("🚨 candidate frontend error: %w", err))
@@ -170,6 +188,10 @@ func multiversionUpgradeTest(ctx context.Context, initVersion, targetVersion, la
 		test.AddError(errors.Newf("🚨 Upgrade failed: %w", err))
 		return test
 	}
+	if err := checkFrontendHealth(ctx, &test, frontendAddr); err != nil {
+		test.AddError(errors.Newf("🚨 candidate frontend unhealthy: %w", err))
+		return test
+	}
 
 	return test
 }
@@ -205,15 +227,22 @@ func autoUpgradeTest(ctx context.Context, initVersion, targetVersion, latestStab
 		return test
 	}
 
+	// seed data at the initial version so out of band migrations have rows to migrate
+	if err := seedFrontendDB(ctx, &test, dbs); err != nil {
+		test.AddError(errors.Newf("🚨 failed to seed frontend db: %w", err))
+		return test
+	}
+
 	// Set SRC_AUTOUPGRADE=true on Migrator and Frontend containers. Then start the frontend container.
 	test.AddLog("-- ⚙️  performing auto upgrade")
 
 	// Start frontend with candidate
 	var cleanFrontend func()
+	var frontendAddr string
 	if postRelease != "" {
-		cleanFrontend, err = startFrontend(ctx, test, fmt.Sprintf("%sfrontend", ctx.Value(targetRegistryKey{})), postRelease, networkName, true, dbs)
+		cleanFrontend, frontendAddr, err = startFrontend(ctx, test, fmt.Sprintf("%sfrontend", ctx.Value(targetRegistryKey{})), postRelease, networkName, true, dbs)
 	} else {
-		cleanFrontend, err = startFrontend(ctx, test, "frontend", "candidate", networkName, true, dbs)
+		cleanFrontend, frontendAddr, err = startFrontend(ctx, test, "frontend", "candidate", networkName, true, dbs)
 	}
 	if err != nil {
 		test.AddError(errors.Newf("🚨 candidate frontend error: %w", err))
@@ -233,6 +262,10 @@ func autoUpgradeTest(ctx context.Context, initVersion, targetVersion, latestStab
 		test.AddError(errors.Newf("🚨 Upgrade failed: %w", err))
 		return test
 	}
+	if err := checkFrontendHealth(ctx, &test, frontendAddr); err != nil {
+		test.AddError(errors.Newf("🚨 candidate frontend unhealthy: %w", err))
+		return test
+	}
 
 	return test
 }